@@ -6,8 +6,11 @@ import (
 
 	"github.com/andrewbytecoder/nmq/interfaces"
 	"github.com/andrewbytecoder/nmq/pkg/utils"
+	"github.com/andrewbytecoder/nmq/plugins/admin"
 	"github.com/andrewbytecoder/nmq/plugins/api"
+	"github.com/andrewbytecoder/nmq/plugins/health"
 	"github.com/andrewbytecoder/nmq/plugins/nmq"
+	"github.com/andrewbytecoder/nmq/plugins/profiling"
 	"go.uber.org/zap/zapcore"
 )
 
@@ -25,7 +28,7 @@ func main() {
 		utils.SetLogMaxSize(50), utils.SetLogMaxBackups(2),
 		utils.SetLogMaxAge(30), utils.SetLogCompress(true),
 		utils.SetLogFilename("./log/nmq.log"), utils.SetLogLevelKey("nmq"),
-		utils.SetConsoleWriterSyncer(true))
+		utils.SetConsoleWriterSyncer(true), utils.SetLogReopenOnSignal(true))
 	if err != nil {
 		fmt.Println("Failed to create logger")
 		return
@@ -47,4 +50,10 @@ func main() {
 func RegisterComponents(nmq *nmq.Nmq) {
 	// 注册网络插件
 	nmq.RegisterComponent(interfaces.NetworkComponentName, api.NewNetComponent(nmq))
+	// 注册健康检查端点
+	nmq.RegisterComponent(interfaces.HealthComponentName, health.NewHealthComponent(nmq))
+	// 注册管理端点
+	nmq.RegisterComponent(interfaces.AdminComponentName, admin.NewAdminComponent(nmq))
+	// 注册剖析组件，默认不开启，通过配置文件的 profiling 配置段或 Notify 事件开启
+	nmq.RegisterComponent(interfaces.ProfilingComponentName, profiling.NewProfilingComponent(nmq))
 }
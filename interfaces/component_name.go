@@ -6,4 +6,13 @@ const (
 
 	// NetworkComponentName is the name of the api component
 	NetworkComponentName = "api"
+
+	// HealthComponentName is the name of the health endpoint component
+	HealthComponentName = "health"
+
+	// AdminComponentName is the name of the admin API component
+	AdminComponentName = "admin"
+
+	// ProfilingComponentName is the name of the profiling agent component
+	ProfilingComponentName = "profiling"
 )
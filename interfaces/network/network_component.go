@@ -4,7 +4,8 @@ import (
 	"github.com/andrewbytecoder/nmq/pkg/utils"
 )
 
-// interface uuid: network_snow_flake
+// SnowFlakeInterface 雪花ID生成器的服务名称，通过 nmq.Resolve[NetSnowFlake] 获取
+const SnowFlakeInterface = "network_snow_flake"
 
 // NetSnowFlake 创建一个雪花ID生成器
 type NetSnowFlake interface {
@@ -0,0 +1,41 @@
+package nmq
+
+import "errors"
+
+var (
+	// ErrComponentNotFound 组件没有注册
+	ErrComponentNotFound = errors.New("component not found")
+	// ErrComponentSelf 不能通过管理接口操作组件管理器自身
+	ErrComponentSelf = errors.New("cannot operate on the component manager itself")
+)
+
+// ComponentInfo 组件信息
+type ComponentInfo struct {
+	Name      string          `json:"name"`
+	Version   string          `json:"version"`
+	Status    ComponentStatus `json:"status"`
+	Policy    string          `json:"policy"`               // 重启策略
+	Restarts  int             `json:"restarts"`             // 累计重启次数
+	LastError string          `json:"last_error,omitempty"` // 最近一次失败的原因
+}
+
+// ComponentAdmin 运维管理接口，组件管理器可选实现，管理端点组件通过它操作其他组件
+type ComponentAdmin interface {
+	// ListComponents 返回所有组件的信息，按名称排序
+	ListComponents() []ComponentInfo
+
+	// StartComponent 启动组件
+	StartComponent(name string) error
+
+	// StopComponent 停止组件
+	StopComponent(name string) error
+
+	// ResetComponent 重置组件
+	ResetComponent(name string) error
+
+	// ConfigSettings 返回当前生效的配置，敏感字段已脱敏
+	ConfigSettings() map[string]any
+
+	// ReloadConfig 重新读取配置文件并通知配置有变化的组件
+	ReloadConfig() error
+}
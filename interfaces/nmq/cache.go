@@ -0,0 +1,93 @@
+package nmq
+
+import (
+	"io"
+	"time"
+
+	"github.com/andrewbytecoder/nmq/pkg/options"
+)
+
+// PersistentCache 可以由组件管理器保存和恢复的缓存，localcache.Cache 满足该接口
+type PersistentCache interface {
+	// Save 将缓存快照写入 w
+	Save(w io.Writer) error
+
+	// Restore 从 r 加载快照，条目的过期时间顺延 downtime，返回加载的条目数
+	Restore(r io.Reader, downtime time.Duration) (int, error)
+}
+
+// CacheConfig 注册缓存的配置
+type CacheConfig struct {
+	Persist   bool   // 组件停止后保存快照到工作目录，组件启动前从快照恢复
+	File      string // 快照文件，相对路径位于 <workdir>/data/cache/<component> 下，默认 <name>.cache
+	Events    bool   // 将缓存变更以 CacheEvent 发布到事件总线的 CacheTopic
+	ValueHash bool   // CacheEvent 中附带值的哈希，默认只包含key和元数据
+}
+
+// SetCachePersist 设置是否在组件停止和启动时自动保存和恢复缓存
+func SetCachePersist(persist bool) options.Option {
+	return func(c any) {
+		if c, ok := c.(*CacheConfig); ok {
+			c.Persist = persist
+		}
+	}
+}
+
+// SetCacheFile 设置缓存快照文件
+func SetCacheFile(file string) options.Option {
+	return func(c any) {
+		if c, ok := c.(*CacheConfig); ok {
+			c.File = file
+		}
+	}
+}
+
+// SetCacheEvents 设置是否将缓存变更发布到事件总线，用于外部系统或其他节点同步失效派生数据
+//
+// 缓存需要支持变更订阅，localcache.Cache 满足要求
+func SetCacheEvents(events bool) options.Option {
+	return func(c any) {
+		if c, ok := c.(*CacheConfig); ok {
+			c.Events = events
+		}
+	}
+}
+
+// SetCacheEventValueHash 设置缓存变更事件是否附带值的哈希
+func SetCacheEventValueHash(hash bool) options.Option {
+	return func(c any) {
+		if c, ok := c.(*CacheConfig); ok {
+			c.ValueHash = hash
+		}
+	}
+}
+
+// CacheTopicPrefix 缓存变更发布到事件总线的主题前缀，完整主题为 "$sys.cache.<component>.<name>.<op>"，
+// op 为 set、delete、expire 或 evict，例如 "$sys.cache.sessions.users.set"
+const CacheTopicPrefix = "$sys.cache."
+
+// CacheTopic 返回 component 的缓存 name 发生 op 变更时发布的事件主题
+func CacheTopic(component, name, op string) string {
+	return CacheTopicPrefix + component + "." + name + "." + op
+}
+
+// CacheEvent 缓存变更事件，作为事件数据发布到 CacheTopic，只包含key和元数据，不包含值
+type CacheEvent struct {
+	Component string    `json:"component"`
+	Cache     string    `json:"cache"`
+	Op        string    `json:"op"` // set、delete、expire 或 evict
+	Key       string    `json:"key"`
+	Time      time.Time `json:"time"`
+	ValueHash string    `json:"value_hash,omitempty"` // 开启 SetCacheEventValueHash 时为写入或被删除的值的 fnv64a 哈希
+}
+
+// CacheRegistry 按组件注册缓存，开启持久化的缓存随组件生命周期自动保存和恢复，开启事件的缓存将变更发布到 CacheTopic
+//
+// 恢复时按快照文件的修改时间顺延TTL，停机期间不计入TTL；快照损坏时改名为 .corrupt 后以空缓存启动
+type CacheRegistry interface {
+	// RegisterCache 注册 component 的缓存 name，同名缓存重复注册时替换，通常在组件 Init 中调用
+	RegisterCache(component, name string, c PersistentCache, opts ...options.Option)
+
+	// UnregisterCache 取消注册 component 的缓存 name
+	UnregisterCache(component, name string)
+}
@@ -7,6 +7,8 @@ package nmq
 
 import (
 	"context"
+	"fmt"
+	"sync"
 
 	"go.uber.org/zap"
 )
@@ -17,14 +19,21 @@ import (
 type NmqContext interface {
 	GetContext() context.Context
 	GetCancel() context.CancelFunc
+	ComponentContexts // 按组件派生的子上下文
 	GetLogger() *zap.Logger
 	GetComponentManager() ComponentManager
-	GetInterface(uuid string) any  // 获取组件内部某个接口的实现
-	Notify(event string, data any) // 接收系统广播事件
-	Submit(task func()) error      // 提交一个异步任务
-	GetConfigFile() string         // 获取配置文件路径
-	GetCertPath() string           // 获取证书路径
-	GetWorkDir() string
+	GetInterface(uuid string) any   // 获取组件内部某个接口的实现，需要类型检查时使用 Resolve
+	Notify(event string, data any)  // 接收系统广播事件
+	Submit(task func()) error       // 提交一个异步任务
+	SubmitQueue                     // 经过有界队列提交异步任务
+	EventBus                        // 基于主题的事件总线
+	GetConfigFile() string          // 获取配置文件路径
+	GetCertPath() string            // 获取证书路径
+	GetWorkDir() string             // 获取工作目录
+	ResolvePath(path string) string // 将相对路径定位到工作目录下
+	ResourceAccounting              // 按组件统计资源使用情况
+	CacheRegistry                   // 随组件生命周期保存和恢复的缓存
+	ComponentLoggers                // 按组件命名并独立控制级别的日志记录器
 }
 
 // ComponentBase 是组件的基础结构体，提供了所有组件都需要的基本字段和功能
@@ -32,7 +41,9 @@ type ComponentBase struct {
 	NcpCtx           NmqContext       // ncp 上下文环境，提供全局上下文、日志和组件管理器访问
 	Log              *zap.Logger      // 全局日志对象，用于组件内部日志记录
 	ComponentManager ComponentManager // 组件管理器，用于访问其他组件
-	Status           ComponentStatus  // 组件当前的生命周期状态
+	Status           ComponentStatus  // 组件当前的生命周期状态，并发访问时使用 GetStatus/SetStatus
+
+	statusMu sync.RWMutex
 }
 
 // NewComponentBase 创建一个新的 ComponentBase 实例
@@ -49,6 +60,31 @@ func NewComponentBase(ncpCtx NmqContext) ComponentBase {
 	}
 }
 
+// NewNamedComponentBase 创建 ComponentBase，Log 为组件管理器提供的名为 name 的子日志记录器，
+// 日志级别可以通过配置文件的 log.levels.<name> 或运行时单独调整
+func NewNamedComponentBase(ncpCtx NmqContext, name string) ComponentBase {
+	return ComponentBase{
+		NcpCtx:           ncpCtx,
+		Log:              ncpCtx.ComponentLogger(name), // 组件自己的子日志记录器
+		ComponentManager: ncpCtx.GetComponentManager(),
+		Status:           ComponentOk,
+	}
+}
+
+// SetStatus 设置组件状态，组件管理器在生命周期变化时自动调用
+func (b *ComponentBase) SetStatus(status ComponentStatus) {
+	b.statusMu.Lock()
+	b.Status = status
+	b.statusMu.Unlock()
+}
+
+// GetStatus 获取组件当前状态
+func (b *ComponentBase) GetStatus() ComponentStatus {
+	b.statusMu.RLock()
+	defer b.statusMu.RUnlock()
+	return b.Status
+}
+
 // ComponentStatus 表示组件的生命周期状态
 //
 // @Description 组件当前所处的状态，用于监控和调试
@@ -57,16 +93,58 @@ func NewComponentBase(ncpCtx NmqContext) ComponentBase {
 // @Description - ComponentRunning: 运行中
 // @Description - ComponentStopped: 已停止
 // @Description - ComponentReset: 已重置
+// @Description - ComponentFailed: 运行失败且不再重启
+// @Description - ComponentRestarting: 等待重启
 type ComponentStatus uint
 
 const (
-	ComponentOk      ComponentStatus = 0
-	ComponentInit    ComponentStatus = 1
-	ComponentRunning ComponentStatus = 2
-	ComponentStopped ComponentStatus = 3
-	ComponentReset   ComponentStatus = 4
+	ComponentOk         ComponentStatus = 0
+	ComponentInit       ComponentStatus = 1
+	ComponentRunning    ComponentStatus = 2
+	ComponentStopped    ComponentStatus = 3
+	ComponentReset      ComponentStatus = 4
+	ComponentFailed     ComponentStatus = 5
+	ComponentRestarting ComponentStatus = 6
 )
 
+// String 返回状态名称
+func (s ComponentStatus) String() string {
+	switch s {
+	case ComponentOk:
+		return "ok"
+	case ComponentInit:
+		return "init"
+	case ComponentRunning:
+		return "running"
+	case ComponentStopped:
+		return "stopped"
+	case ComponentReset:
+		return "reset"
+	case ComponentFailed:
+		return "failed"
+	case ComponentRestarting:
+		return "restarting"
+	default:
+		return "unknown"
+	}
+}
+
+// MarshalText 以状态名称序列化
+func (s ComponentStatus) MarshalText() ([]byte, error) {
+	return []byte(s.String()), nil
+}
+
+// UnmarshalText 从状态名称反序列化
+func (s *ComponentStatus) UnmarshalText(text []byte) error {
+	for status := ComponentOk; status <= ComponentRestarting; status++ {
+		if status.String() == string(text) {
+			*s = status
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown component status %q", text)
+}
+
 // Component 是所有可注册组件必须实现的核心接口
 //
 // @Description 每个组件都需实现以下生命周期方法
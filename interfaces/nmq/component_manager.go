@@ -6,6 +6,8 @@
 package nmq
 
 import (
+	"context"
+
 	"github.com/spf13/cobra"
 )
 
@@ -14,4 +16,9 @@ type ComponentManager interface {
 	AddCommand(cmds ...*cobra.Command)
 	WgAdd(delta int)
 	WaitGroup()
+	Health(ctx context.Context) HealthReport // 汇总所有组件的健康状态
+	Supervisor                               // 监控组件运行时失败并按重启策略重启
+	HookRegistry                             // 注册启动和停止时的生命周期钩子
+	StatusTracker                            // 查询组件的状态变化历史
+	PhaseTracker                             // 查询和等待组件管理器的生命周期阶段
 }
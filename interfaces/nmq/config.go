@@ -0,0 +1,9 @@
+package nmq
+
+// Configurable 组件可选实现的配置接口
+//
+// 组件管理器在所有组件 Init 之前，把配置文件中以组件名称为键的配置段解码到 ConfigSection 返回的结构体指针，
+// 并按字段的 validate 标签校验；配置段中没有的字段保留原有的默认值，任一组件校验失败时终止启动
+type Configurable interface {
+	ConfigSection() any
+}
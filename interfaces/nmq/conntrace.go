@@ -0,0 +1,20 @@
+package nmq
+
+import "time"
+
+// ConnTraceInterface 通过 GetInterface 查找连接帧跟踪使用的接口标识
+const ConnTraceInterface = "conn_trace"
+
+// ConnTracer 按连接ID开启的帧调试跟踪，管理端点组件通过 GetInterface(ConnTraceInterface) 查找
+//
+// websocket server.Server 实现了该接口，持有服务器的组件在 GetInterface 中返回它即可通过管理接口操作
+type ConnTracer interface {
+	// TraceConn 在 d 时间内将连接收发的帧以带注释的十六进制写入独立文件，返回文件路径，再次调用从现在起延长到 d
+	TraceConn(id string, d time.Duration) (string, error)
+
+	// StopConnTrace 提前结束连接的跟踪
+	StopConnTrace(id string) error
+
+	// ConnTraces 返回正在跟踪的连接ID及跟踪的结束时间
+	ConnTraces() map[string]time.Time
+}
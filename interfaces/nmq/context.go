@@ -0,0 +1,12 @@
+package nmq
+
+import "context"
+
+// ComponentContexts 为每个组件提供从组件管理器上下文派生的子上下文，停止单个组件只取消该组件的协程
+type ComponentContexts interface {
+	// GetComponentContext 返回组件的子上下文，组件停止或组件管理器上下文取消时取消，组件重新启动后返回新的上下文
+	GetComponentContext(name string) context.Context
+
+	// GetComponentCancel 返回组件当前子上下文的取消函数
+	GetComponentCancel(name string) context.CancelFunc
+}
@@ -0,0 +1,24 @@
+package nmq
+
+// Dependent 组件可选实现的依赖声明接口，组件在依赖的组件全部启动成功后才会启动
+type Dependent interface {
+	// Dependencies 返回依赖的组件名称
+	Dependencies() []string
+}
+
+// ShutdownPriority 停止优先级，数值小的组件先停止，与启动顺序无关
+type ShutdownPriority int
+
+const (
+	ShutdownIngress ShutdownPriority = 100 // 最先停止：监听器、API 等流量入口，不再接收新消息
+	ShutdownBroker  ShutdownPriority = 200 // 消息代理和消费者，处理完已接收的消息
+	ShutdownDefault ShutdownPriority = 300 // 没有声明优先级的组件
+	ShutdownStorage ShutdownPriority = 400 // 最后停止：持久化层，保证之前停止的组件可以落盘
+)
+
+// ShutdownPrioritizer 组件可选实现的停止优先级声明接口，没有实现的组件使用 ShutdownDefault
+//
+// 停止时按优先级分批依次停止，同一优先级内依赖其他组件的组件先于被依赖的组件停止
+type ShutdownPrioritizer interface {
+	ShutdownPriority() ShutdownPriority
+}
@@ -0,0 +1,132 @@
+package nmq
+
+import (
+	"context"
+	"time"
+
+	"github.com/andrewbytecoder/nmq/pkg/options"
+)
+
+// Event 事件总线上传递的事件
+type Event struct {
+	Topic   string    // 事件主题，以 "." 分隔的多段名称，例如 "mq.client.connected"
+	Data    any       // 事件数据
+	Time    time.Time // 发布时间
+	Expires time.Time // 过期时间，为零时不过期，过期的事件在投递时被跳过
+}
+
+// Expired 判断事件在 now 时是否已经过期
+func (e Event) Expired(now time.Time) bool {
+	return !e.Expires.IsZero() && now.After(e.Expires)
+}
+
+// ExpiredEvent 投递时已过期的事件，发布到订阅者配置的过期主题
+type ExpiredEvent struct {
+	Event      Event  // 过期的原始事件
+	Subscriber string // 跳过该事件的订阅者名称
+}
+
+// EventHandler 事件处理函数，返回的错误交给订阅者的错误处理函数
+type EventHandler func(ctx context.Context, ev Event) error
+
+// SubscriberStats 单个订阅者的投递统计
+type SubscriberStats struct {
+	Delivered uint64 // 处理成功的事件数
+	Failed    uint64 // 处理失败（返回错误或 panic）的事件数
+	Dropped   uint64 // 缓冲区已满被丢弃的事件数
+	Expired   uint64 // 投递时已过期被跳过的事件数
+	Pending   int    // 缓冲区中等待处理的事件数
+}
+
+// Subscription 一个订阅
+type Subscription interface {
+	Name() string           // 订阅者名称
+	Pattern() string        // 订阅的主题模式
+	Stats() SubscriberStats // 投递统计
+	Unsubscribe()           // 取消订阅，缓冲区中尚未处理的事件会被丢弃
+}
+
+// EventBus 基于主题的事件总线
+//
+// 主题模式按 "." 分段匹配，"*" 匹配一段，"#" 匹配零段或多段；
+// 每个订阅者有独立的缓冲区，事件按发布顺序在协程池中逐个投递给订阅者，缓冲区已满时丢弃新事件；
+// 设置了 TTL 的事件在投递时已过期则跳过
+type EventBus interface {
+	// Publish 发布事件，不等待订阅者处理
+	Publish(topic string, data any, opts ...options.Option) error
+
+	// Subscribe 订阅匹配 pattern 的事件
+	Subscribe(pattern string, handler EventHandler, opts ...options.Option) (Subscription, error)
+}
+
+// PublishConfig 发布配置
+//
+// TTL 只在事件总线投递时检查，mq 组件的 producer/consumer/store 还没有消息投递路径，
+// 消息级别的 TTL 待 broker 投递实现后再在那里执行
+type PublishConfig struct {
+	TTL time.Duration // 事件的存活时间，超过后投递时跳过，小于等于0时不过期
+}
+
+// SetEventTTL 设置事件的存活时间，用于不能延迟执行的命令
+func SetEventTTL(ttl time.Duration) options.Option {
+	return func(c any) {
+		if c, ok := c.(*PublishConfig); ok {
+			c.TTL = ttl
+		}
+	}
+}
+
+// SubscribeConfig 订阅配置
+type SubscribeConfig struct {
+	Name         string                    // 订阅者名称，用于日志和监控指标，默认为主题模式
+	Buffer       int                       // 缓冲区大小
+	OnError      func(ev Event, err error) // 处理失败时的回调，默认记录日志
+	ExpiredTopic string                    // 投递时已过期的事件以 ExpiredEvent 发布到该主题，为空时直接丢弃
+}
+
+// SetSubscriberName 设置订阅者名称
+func SetSubscriberName(name string) options.Option {
+	return func(c any) {
+		if c, ok := c.(*SubscribeConfig); ok {
+			c.Name = name
+		}
+	}
+}
+
+// SetSubscriberBuffer 设置订阅者缓冲区大小
+func SetSubscriberBuffer(size int) options.Option {
+	return func(c any) {
+		if c, ok := c.(*SubscribeConfig); ok {
+			c.Buffer = size
+		}
+	}
+}
+
+// SetSubscriberOnError 设置处理失败时的回调
+func SetSubscriberOnError(fn func(ev Event, err error)) options.Option {
+	return func(c any) {
+		if c, ok := c.(*SubscribeConfig); ok {
+			c.OnError = fn
+		}
+	}
+}
+
+// SetSubscriberExpiredTopic 设置过期事件转发的主题
+func SetSubscriberExpiredTopic(topic string) options.Option {
+	return func(c any) {
+		if c, ok := c.(*SubscribeConfig); ok {
+			c.ExpiredTopic = topic
+		}
+	}
+}
+
+// Subscribe 订阅数据类型为 T 的事件，数据类型不匹配的事件被忽略
+func Subscribe[T any](bus EventBus, pattern string, handler func(ctx context.Context, topic string, data T) error, opts ...options.Option) (Subscription, error) {
+	return bus.Subscribe(pattern, func(ctx context.Context, ev Event) error {
+		data, ok := ev.Data.(T)
+		if !ok {
+			return nil
+		}
+		return handler(ctx, ev.Topic, data)
+	}, opts...)
+}
@@ -0,0 +1,12 @@
+package nmq
+
+// 可选功能名称，对应的集成通过构建标签裁剪，未编译进来时依赖这些功能的配置会在启动前检查中失败
+const (
+	FeatureGoPs      = "gops"
+	FeaturePyroscope = "pyroscope"
+)
+
+// FeatureRequirer 组件可选实现的接口，声明依赖的可选功能，任意功能未编译进来时启动前检查失败
+type FeatureRequirer interface {
+	RequiredFeatures() []string
+}
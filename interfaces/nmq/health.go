@@ -0,0 +1,27 @@
+package nmq
+
+import "context"
+
+// HealthChecker 组件可选实现的健康检查接口，没有实现的组件按 GetStatus 判断是否就绪
+type HealthChecker interface {
+	// Liveness 存活检查，返回错误表示组件已无法自行恢复，需要重启进程
+	Liveness(ctx context.Context) error
+
+	// Readiness 就绪检查，返回错误表示组件暂时不能处理请求，例如依赖尚未连接
+	Readiness(ctx context.Context) error
+}
+
+// ComponentHealth 单个组件的健康状态
+type ComponentHealth struct {
+	Name  string `json:"name"`            // 组件名称
+	Live  bool   `json:"live"`            // 是否存活
+	Ready bool   `json:"ready"`           // 是否就绪
+	Error string `json:"error,omitempty"` // 检查失败的原因
+}
+
+// HealthReport 所有组件健康状态的汇总，任一组件不存活则进程不存活，任一组件未就绪则进程未就绪
+type HealthReport struct {
+	Live       bool              `json:"live"`
+	Ready      bool              `json:"ready"`
+	Components []ComponentHealth `json:"components"`
+}
@@ -0,0 +1,37 @@
+package nmq
+
+import "context"
+
+// HookPhase 生命周期钩子的执行阶段
+type HookPhase int
+
+const (
+	HookPreStart  HookPhase = iota // 启动前检查通过后、组件 Start 之前，返回错误时终止启动
+	HookPostStart                  // 所有组件 Start 之后，返回错误时终止启动
+	HookPreStop                    // 组件 Stop 之前，错误只记录日志
+	HookPostStop                   // 所有组件 Stop 之后，错误只记录日志
+)
+
+func (p HookPhase) String() string {
+	switch p {
+	case HookPreStart:
+		return "pre-start"
+	case HookPostStart:
+		return "post-start"
+	case HookPreStop:
+		return "pre-stop"
+	case HookPostStop:
+		return "post-stop"
+	default:
+		return "unknown"
+	}
+}
+
+// Hook 生命周期钩子，ctx 在钩子超时后取消
+type Hook func(ctx context.Context) error
+
+// HookRegistry 注册生命周期钩子，用于在固定的时间点执行预热缓存、刷新指标、从服务发现注销等横切逻辑
+type HookRegistry interface {
+	// AddHook 注册 phase 阶段的钩子，同一阶段按 order 从小到大依次执行，order 相同时按注册顺序执行
+	AddHook(phase HookPhase, name string, order int, hook Hook)
+}
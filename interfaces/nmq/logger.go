@@ -0,0 +1,18 @@
+package nmq
+
+import (
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// ComponentLoggers 为每个组件提供命名的子日志记录器，各组件的日志级别独立控制，可以在运行时调整
+type ComponentLoggers interface {
+	// ComponentLogger 返回 logger.Named(name) 形式的子日志记录器，同一组件的日志记录器共享日志级别
+	ComponentLogger(name string) *zap.Logger
+
+	// SetComponentLogLevel 调整组件的日志级别，立即对该组件已经获取的日志记录器生效
+	SetComponentLogLevel(name string, level zapcore.Level)
+
+	// ComponentLogLevels 返回已经获取过日志记录器或设置过级别的组件的当前日志级别
+	ComponentLogLevels() map[string]zapcore.Level
+}
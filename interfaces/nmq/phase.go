@@ -0,0 +1,78 @@
+package nmq
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// Phase 组件管理器的生命周期阶段，阶段只能按定义顺序前进，任何未结束的阶段都可以进入 PhaseFailed
+type Phase int
+
+const (
+	PhaseLoading   Phase = iota // 加载插件和组件配置段
+	PhaseInit                   // 初始化组件
+	PhasePreflight              // 执行启动前检查
+	PhaseStarting               // 执行 pre-start 钩子并启动组件
+	PhaseRunning                // 所有组件和 post-start 钩子执行完成
+	PhaseDraining               // 停止组件并等待进行中的任务完成
+	PhaseStopped                // 停止完成
+	PhaseFailed                 // 初始化或启动失败
+)
+
+func (p Phase) String() string {
+	switch p {
+	case PhaseLoading:
+		return "loading"
+	case PhaseInit:
+		return "init"
+	case PhasePreflight:
+		return "preflight"
+	case PhaseStarting:
+		return "starting"
+	case PhaseRunning:
+		return "running"
+	case PhaseDraining:
+		return "draining"
+	case PhaseStopped:
+		return "stopped"
+	case PhaseFailed:
+		return "failed"
+	default:
+		return "unknown"
+	}
+}
+
+// Terminal 判断阶段是否为结束阶段
+func (p Phase) Terminal() bool {
+	return p == PhaseStopped || p == PhaseFailed
+}
+
+// PhaseTopicPrefix 阶段变化发布到事件总线的主题前缀，完整主题为前缀加阶段名称，例如 "$sys.nmq.phase.running"
+const PhaseTopicPrefix = "$sys.nmq.phase."
+
+// PhaseTopic 返回进入阶段 p 时发布的事件主题
+func PhaseTopic(p Phase) string {
+	return PhaseTopicPrefix + p.String()
+}
+
+// ErrPhaseUnreachable 组件管理器已经越过或无法再到达等待的阶段
+var ErrPhaseUnreachable = errors.New("nmq: phase unreachable")
+
+// PhaseTransition 一次阶段变化，作为事件数据发布到 PhaseTopic(To)
+type PhaseTransition struct {
+	From  Phase     `json:"from"`
+	To    Phase     `json:"to"`
+	Time  time.Time `json:"time"`
+	Error string    `json:"error,omitempty"` // 进入 PhaseFailed 的原因
+}
+
+// PhaseTracker 查询和等待组件管理器的生命周期阶段，用于嵌入方与 nmq 的就绪状态同步
+type PhaseTracker interface {
+	// Phase 返回当前阶段
+	Phase() Phase
+
+	// WaitForPhase 等待进入 phase 阶段，已经到达过该阶段时立即返回；
+	// 没有到达过且已经越过该阶段或进入结束阶段时返回 ErrPhaseUnreachable，ctx 结束时返回 ctx 的错误
+	WaitForPhase(ctx context.Context, phase Phase) error
+}
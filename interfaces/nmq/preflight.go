@@ -0,0 +1,8 @@
+package nmq
+
+import "context"
+
+// Preflighter 组件可选实现的启动前检查接口，在所有组件 Start 之前并发执行，返回错误会终止启动
+type Preflighter interface {
+	Preflight(ctx context.Context) error
+}
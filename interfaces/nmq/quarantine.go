@@ -0,0 +1,47 @@
+package nmq
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// QuarantineInterface 通过 GetInterface 查找消息隔离区使用的接口标识
+const QuarantineInterface = "consumer_quarantine"
+
+var (
+	// ErrMessageNotFound 隔离区中没有该消息
+	ErrMessageNotFound = errors.New("quarantined message not found")
+	// ErrNoCanary 没有配置用于重试隔离消息的 canary 处理函数
+	ErrNoCanary = errors.New("no canary handler configured")
+)
+
+// MessageFailure 消息的一次处理失败
+type MessageFailure struct {
+	Time  time.Time `json:"time"`
+	Error string    `json:"error"`
+	Stack string    `json:"stack,omitempty"` // 处理函数 panic 时的调用栈
+}
+
+// QuarantinedMessage 多次处理失败后被隔离的消息
+type QuarantinedMessage struct {
+	ID            string            `json:"id"`
+	Topic         string            `json:"topic"`
+	Headers       map[string]string `json:"headers,omitempty"`
+	Payload       []byte            `json:"payload"`
+	Failures      []MessageFailure  `json:"failures"`       // 按时间顺序的失败记录
+	Hints         []string          `json:"hints"`          // 根据失败记录给出的排查建议
+	QuarantinedAt time.Time         `json:"quarantined_at"` // 进入隔离区的时间
+}
+
+// Quarantine 消息隔离区，管理端点组件通过 GetInterface(QuarantineInterface) 查找
+type Quarantine interface {
+	// Quarantined 返回所有被隔离的消息，按进入隔离区的时间排序
+	Quarantined() []QuarantinedMessage
+
+	// RetryQuarantined 使用 canary 处理函数重试一次，成功后移出隔离区
+	RetryQuarantined(ctx context.Context, id string) error
+
+	// ReleaseQuarantined 将消息移出隔离区，之后该消息会重新交给处理函数
+	ReleaseQuarantined(id string) error
+}
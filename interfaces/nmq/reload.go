@@ -0,0 +1,9 @@
+package nmq
+
+// Reloader 组件可选实现的配置热加载接口
+type Reloader interface {
+	// Reload 应用新的组件配置，cfg 为配置文件中以组件名称为键的配置段，配置段被删除时为空
+	//
+	// 只有配置段发生变化时才会调用，返回错误表示新配置未生效，下次热加载时会重试
+	Reload(cfg map[string]any) error
+}
@@ -0,0 +1,56 @@
+package nmq
+
+import (
+	"net"
+	"os"
+	"sync"
+)
+
+// ResourceUsage 组件资源使用情况的近似统计
+//
+// @Description 只统计通过 ResourceAccounting 提交的任务和包装的构造函数创建的资源
+type ResourceUsage struct {
+	Goroutines int64  // 正在执行的异步任务数
+	Tasks      uint64 // 累计提交的异步任务数
+	CacheBytes int64  // 组件缓存估算占用的字节数
+	Conns      int64  // 当前打开的连接数
+	Files      int64  // 当前打开的文件数
+}
+
+// ResourceAccounting 按组件统计资源使用情况，用于定位资源占用过多的组件
+type ResourceAccounting interface {
+	// SubmitFor 提交一个异步任务并计入 component 的任务统计
+	SubmitFor(component string, task func()) error
+
+	// TrackCacheBytes 注册 component 的缓存字节数采样函数，例如 localcache 的 Bytes
+	TrackCacheBytes(component string, probe func() int64)
+
+	// WrapConn 包装连接，连接关闭前计入 component 的连接统计
+	WrapConn(component string, conn net.Conn) net.Conn
+
+	// OpenFile 打开文件，文件关闭前计入 component 的文件统计
+	OpenFile(component string, name string, flag int, perm os.FileMode) (*TrackedFile, error)
+
+	// ResourceReport 返回所有组件的资源使用情况，key 为组件名称
+	ResourceReport() map[string]ResourceUsage
+}
+
+// TrackedFile 计入资源统计的文件，关闭时释放统计
+type TrackedFile struct {
+	*os.File
+	once    sync.Once
+	release func()
+}
+
+// NewTrackedFile 创建计入资源统计的文件，release 在第一次关闭时调用
+func NewTrackedFile(f *os.File, release func()) *TrackedFile {
+	return &TrackedFile{File: f, release: release}
+}
+
+// Close 关闭文件并释放统计
+func (f *TrackedFile) Close() error {
+	if f.release != nil {
+		f.once.Do(f.release)
+	}
+	return f.File.Close()
+}
@@ -0,0 +1,89 @@
+package nmq
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"sync"
+)
+
+var (
+	// ErrServiceNotFound 没有注册该名称的服务，也没有组件通过 GetInterface 提供
+	ErrServiceNotFound = errors.New("nmq: service not found")
+	// ErrServiceType 服务不是请求的类型
+	ErrServiceType = errors.New("nmq: service type mismatch")
+	// ErrServiceContract 同名服务已经以其他类型注册
+	ErrServiceContract = errors.New("nmq: service registered with another type")
+	// ErrNilService 注册的服务实现为 nil
+	ErrNilService = errors.New("nmq: nil service")
+)
+
+// service 注册的服务和注册时声明的类型
+type service struct {
+	typ  reflect.Type
+	impl any
+}
+
+var (
+	serviceMu sync.RWMutex
+	services  = make(map[string]service)
+)
+
+// RegisterService 以类型 T 注册名为 name 的服务，同名服务只能以相同的 T 重新注册，
+// 已有的 GetInterface(name) 调用也可以取到注册的服务
+func RegisterService[T any](name string, impl T) error {
+	if isNil(reflect.ValueOf(&impl).Elem()) {
+		return fmt.Errorf("%w: %s", ErrNilService, name)
+	}
+	typ := reflect.TypeFor[T]()
+
+	serviceMu.Lock()
+	defer serviceMu.Unlock()
+	if s, ok := services[name]; ok && s.typ != typ {
+		return fmt.Errorf("%w: %s is %s, not %s", ErrServiceContract, name, s.typ, typ)
+	}
+	services[name] = service{typ: typ, impl: impl}
+	return nil
+}
+
+// UnregisterService 删除名为 name 的服务
+func UnregisterService(name string) {
+	serviceMu.Lock()
+	delete(services, name)
+	serviceMu.Unlock()
+}
+
+// LookupService 返回名为 name 的服务，不检查类型
+func LookupService(name string) (any, bool) {
+	serviceMu.RLock()
+	defer serviceMu.RUnlock()
+	s, ok := services[name]
+	return s.impl, ok
+}
+
+// Resolve 返回名为 name 的 T 类型服务，没有注册时回退到 ctx.GetInterface(name)，兼容只实现了 GetInterface 的组件
+func Resolve[T any](ctx NmqContext, name string) (T, error) {
+	var zero T
+	impl, ok := LookupService(name)
+	if !ok && ctx != nil {
+		impl = ctx.GetInterface(name)
+		ok = impl != nil
+	}
+	if !ok {
+		return zero, fmt.Errorf("%w: %s", ErrServiceNotFound, name)
+	}
+	t, ok := impl.(T)
+	if !ok {
+		return zero, fmt.Errorf("%w: %s is %T, not %s", ErrServiceType, name, impl, reflect.TypeFor[T]())
+	}
+	return t, nil
+}
+
+// isNil 判断可以为 nil 的值是否为 nil
+func isNil(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Chan, reflect.Func, reflect.Interface, reflect.Map, reflect.Pointer, reflect.Slice:
+		return v.IsNil()
+	}
+	return false
+}
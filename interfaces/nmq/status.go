@@ -0,0 +1,22 @@
+package nmq
+
+import "time"
+
+// StatusTransition 组件的一次状态变化
+type StatusTransition struct {
+	From  ComponentStatus `json:"from"`
+	To    ComponentStatus `json:"to"`
+	Time  time.Time       `json:"time"`
+	Error string          `json:"error,omitempty"` // 导致状态变化的错误
+}
+
+// StatusSetter 组件可选实现，组件管理器在 Init/Start/Stop/Reset 和失败重启时调用，嵌入 ComponentBase 的组件自动实现
+type StatusSetter interface {
+	SetStatus(status ComponentStatus)
+}
+
+// StatusTracker 记录组件生命周期状态的变化
+type StatusTracker interface {
+	// StatusHistory 返回组件最近的状态变化，按时间顺序排列，组件不存在时返回 nil
+	StatusHistory(name string) []StatusTransition
+}
@@ -0,0 +1,26 @@
+package nmq
+
+import "context"
+
+// 常用的任务优先级，SubmitPriority 也接受其他整数
+const (
+	PriorityLow    = -10 // 可以延后或在过载时丢弃的后台任务，例如统计和清理
+	PriorityNormal = 0   // 默认优先级
+	PriorityHigh   = 10  // 对延迟敏感的任务
+)
+
+// SubmitQueue 经过有界队列向协程池提交异步任务，队列已满时的处理方式由 nmq 配置决定
+type SubmitQueue interface {
+	// SubmitWait 提交任务，队列已满且配置为阻塞时等待直到有空位或 ctx 结束
+	SubmitWait(ctx context.Context, task func()) error
+
+	// SubmitPriority 以指定优先级提交任务，数值越大越先执行，丢弃模式下优先丢弃优先级低的任务
+	SubmitPriority(ctx context.Context, priority int, task func()) error
+
+	// TrySubmit 提交任务，队列已满时不阻塞
+	TrySubmit(task func()) error
+
+	// SubmitComponent 以组件的名义提交任务，配置了专用协程池的组件提交到自己的协程池，协程池已满时等待空位直到 ctx 结束，
+	// priority 对专用协程池不生效；其他组件以 priority 经过共享队列提交，与 SubmitPriority 相同
+	SubmitComponent(ctx context.Context, component string, priority int, task func()) error
+}
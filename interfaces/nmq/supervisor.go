@@ -0,0 +1,34 @@
+package nmq
+
+import "context"
+
+// RestartPolicy 组件失败后的重启策略
+type RestartPolicy int
+
+const (
+	RestartNever     RestartPolicy = iota // 不重启，启动失败时终止启动，运行时失败时标记为 ComponentFailed
+	RestartOnFailure                      // 启动失败或运行时失败时重启
+	RestartAlways                         // 除失败外，通过 Supervisor.Go 运行的协程正常退出时也重启
+)
+
+func (p RestartPolicy) String() string {
+	switch p {
+	case RestartNever:
+		return "never"
+	case RestartOnFailure:
+		return "on-failure"
+	case RestartAlways:
+		return "always"
+	default:
+		return "unknown"
+	}
+}
+
+// Supervisor 监控组件运行时失败，按组件的重启策略先 Stop 再 Start 重启组件
+type Supervisor interface {
+	// Go 以组件的名义运行协程，协程 panic 或返回错误时视为组件运行时失败，ctx 为组件的子上下文，在组件或 nmq 停止时取消
+	Go(component string, fn func(ctx context.Context) error)
+
+	// ReportFailure 报告组件运行时失败
+	ReportFailure(component string, err error)
+}
@@ -145,3 +145,16 @@ func (h *Histogram) With(labelValues ...string) metrics.Histogram {
 func (h *Histogram) Observe(value float64) {
 	h.hv.With(makeLabels(h.lvs...)).Observe(value)
 }
+
+// RegisterCounterFunc registers a CounterFunc whose value is read from function
+// on every collection. Unlike the promauto based constructors it returns the
+// registration error instead of panicking.
+func RegisterCounterFunc(opts prometheus.CounterOpts, function func() float64) error {
+	return prometheus.Register(prometheus.NewCounterFunc(opts, function))
+}
+
+// RegisterGaugeFunc registers a GaugeFunc whose value is read from function
+// on every collection, returning the registration error if any.
+func RegisterGaugeFunc(opts prometheus.GaugeOpts, function func() float64) error {
+	return prometheus.Register(prometheus.NewGaugeFunc(opts, function))
+}
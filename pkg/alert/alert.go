@@ -0,0 +1,398 @@
+// Package alert 在进程内按阈值规则评估 prometheus 指标，
+// 用于没有部署外部 Prometheus/Alertmanager 的环境
+//
+// 规则形如 `queue_depth > 10000 for 5m`，每条匹配的时间序列单独评估：
+// 条件满足时进入 pending，持续满足 for 指定的时间后进入 firing 并通知，之后条件不再满足时发送 resolved 通知
+package alert
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/andrewbytecoder/nmq/pkg/options"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"go.uber.org/zap"
+)
+
+// 默认评估间隔
+const defaultInterval = 15 * time.Second
+
+// ErrInvalidRule 规则名称为空、重复或表达式错误
+var ErrInvalidRule = errors.New("alert: invalid rule")
+
+// Rule 告警规则
+type Rule struct {
+	Name   string            `mapstructure:"name" json:"name"`     // 规则名称，不能重复
+	Expr   string            `mapstructure:"expr" json:"expr"`     // 阈值表达式，见 ParseExpr
+	Labels map[string]string `mapstructure:"labels" json:"labels"` // 附加到告警上的标签，例如 severity
+}
+
+// State 告警状态
+type State int
+
+const (
+	StatePending  State = iota // 条件满足但持续时间还不够
+	StateFiring                // 条件持续满足超过 for 指定的时间
+	StateResolved              // 触发后条件不再满足
+)
+
+func (s State) String() string {
+	switch s {
+	case StatePending:
+		return "pending"
+	case StateFiring:
+		return "firing"
+	case StateResolved:
+		return "resolved"
+	default:
+		return "unknown"
+	}
+}
+
+// MarshalText 以状态名称序列化
+func (s State) MarshalText() ([]byte, error) {
+	return []byte(s.String()), nil
+}
+
+// UnmarshalText 从状态名称反序列化
+func (s *State) UnmarshalText(text []byte) error {
+	for state := StatePending; state <= StateResolved; state++ {
+		if state.String() == string(text) {
+			*s = state
+			return nil
+		}
+	}
+	return fmt.Errorf("alert: unknown state %q", text)
+}
+
+// Alert 一条时间序列上的告警
+type Alert struct {
+	Rule       string            `json:"rule"`
+	Expr       string            `json:"expr"`
+	Labels     map[string]string `json:"labels"` // 时间序列的标签和规则的标签，规则的标签优先
+	Value      float64           `json:"value"`  // 最近一次评估时的指标值
+	State      State             `json:"state"`
+	ActiveAt   time.Time         `json:"active_at"`             // 条件开始满足的时间
+	FiredAt    time.Time         `json:"fired_at,omitempty"`    // 进入 firing 的时间
+	ResolvedAt time.Time         `json:"resolved_at,omitempty"` // 进入 resolved 的时间
+}
+
+// Notifier 接收 firing 和 resolved 通知
+type Notifier interface {
+	Notify(ctx context.Context, a Alert) error
+}
+
+// NotifierFunc 函数形式的 Notifier
+type NotifierFunc func(ctx context.Context, a Alert) error
+
+// Notify 实现 Notifier
+func (f NotifierFunc) Notify(ctx context.Context, a Alert) error {
+	return f(ctx, a)
+}
+
+// Config 评估配置
+type Config struct {
+	Rules     []Rule              // 告警规则
+	Interval  time.Duration       // 评估间隔
+	Gatherer  prometheus.Gatherer // 指标来源，默认为 prometheus.DefaultGatherer
+	Notifiers []Notifier          // 通知方式
+}
+
+// SetRules 添加告警规则
+func SetRules(rules ...Rule) options.Option {
+	return func(c any) {
+		if c, ok := c.(*Config); ok {
+			c.Rules = append(c.Rules, rules...)
+		}
+	}
+}
+
+// SetInterval 设置评估间隔
+func SetInterval(interval time.Duration) options.Option {
+	return func(c any) {
+		if c, ok := c.(*Config); ok {
+			c.Interval = interval
+		}
+	}
+}
+
+// SetGatherer 设置指标来源
+func SetGatherer(gatherer prometheus.Gatherer) options.Option {
+	return func(c any) {
+		if c, ok := c.(*Config); ok {
+			c.Gatherer = gatherer
+		}
+	}
+}
+
+// SetNotifiers 添加通知方式
+func SetNotifiers(notifiers ...Notifier) options.Option {
+	return func(c any) {
+		if c, ok := c.(*Config); ok {
+			c.Notifiers = append(c.Notifiers, notifiers...)
+		}
+	}
+}
+
+// NewConfig 创建评估配置
+func NewConfig(opts ...options.Option) *Config {
+	c := &Config{
+		Interval: defaultInterval,
+		Gatherer: prometheus.DefaultGatherer,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// rule 解析后的规则
+type rule struct {
+	Rule
+	expr *Expr
+}
+
+// Evaluator 定期评估告警规则并发送通知
+type Evaluator struct {
+	log   *zap.Logger
+	cfg   *Config
+	rules []rule
+
+	mu     sync.Mutex
+	active map[string]*Alert // 规则名称和序列标签到 pending/firing 告警
+
+	stop chan struct{}
+	once sync.Once
+	wg   sync.WaitGroup
+}
+
+// NewEvaluator 创建评估器，规则名称为空、重复或表达式错误时返回 ErrInvalidRule
+func NewEvaluator(log *zap.Logger, cfg *Config) (*Evaluator, error) {
+	e := &Evaluator{log: log, cfg: cfg, active: make(map[string]*Alert), stop: make(chan struct{})}
+	seen := make(map[string]bool, len(cfg.Rules))
+	for _, r := range cfg.Rules {
+		if r.Name == "" || seen[r.Name] {
+			return nil, fmt.Errorf("%w: empty or duplicate name %q", ErrInvalidRule, r.Name)
+		}
+		seen[r.Name] = true
+		expr, err := ParseExpr(r.Expr)
+		if err != nil {
+			return nil, fmt.Errorf("%w %s: %w", ErrInvalidRule, r.Name, err)
+		}
+		e.rules = append(e.rules, rule{Rule: r, expr: expr})
+	}
+	return e, nil
+}
+
+// Start 启动后台评估协程，没有规则时不做任何事
+func (e *Evaluator) Start(ctx context.Context) {
+	if len(e.rules) == 0 {
+		return
+	}
+	interval := e.cfg.Interval
+	if interval <= 0 {
+		interval = defaultInterval
+	}
+	e.wg.Add(1)
+	go func() {
+		defer e.wg.Done()
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case now := <-ticker.C:
+				if err := e.Evaluate(ctx, now); err != nil {
+					e.log.Warn("Alert evaluation failed", zap.Error(err))
+				}
+			case <-ctx.Done():
+				return
+			case <-e.stop:
+				return
+			}
+		}
+	}()
+}
+
+// Stop 停止后台评估协程，可以重复调用
+func (e *Evaluator) Stop() {
+	e.once.Do(func() {
+		close(e.stop)
+	})
+	e.wg.Wait()
+}
+
+// Alerts 返回当前 pending 和 firing 的告警，按规则名称和标签排序
+func (e *Evaluator) Alerts() []Alert {
+	e.mu.Lock()
+	keys := make([]string, 0, len(e.active))
+	for key := range e.active {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	alerts := make([]Alert, 0, len(keys))
+	for _, key := range keys {
+		alerts = append(alerts, *e.active[key])
+	}
+	e.mu.Unlock()
+	return alerts
+}
+
+// Evaluate 以 now 为评估时间评估一次所有规则，并发送状态变为 firing 或 resolved 的通知
+//
+// 返回采集指标和发送通知的错误，单个通知失败不影响其他通知
+func (e *Evaluator) Evaluate(ctx context.Context, now time.Time) error {
+	mfs, gatherErr := e.cfg.Gatherer.Gather()
+
+	var notify []Alert
+	e.mu.Lock()
+	seen := make(map[string]bool, len(e.active))
+	for _, r := range e.rules {
+		for _, s := range samples(mfs, r.expr) {
+			if !r.expr.Match(s.value) {
+				continue
+			}
+			key := r.Name + "\xff" + s.key
+			seen[key] = true
+			a, ok := e.active[key]
+			if !ok {
+				a = &Alert{Rule: r.Name, Expr: r.Expr, Labels: alertLabels(r, s.labels), State: StatePending, ActiveAt: now}
+				e.active[key] = a
+			}
+			a.Value = s.value
+			if a.State == StatePending && now.Sub(a.ActiveAt) >= r.expr.For {
+				a.State, a.FiredAt = StateFiring, now
+				notify = append(notify, *a)
+			}
+		}
+	}
+	for key, a := range e.active {
+		if seen[key] {
+			continue
+		}
+		delete(e.active, key)
+		if a.State == StateFiring {
+			a.State, a.ResolvedAt = StateResolved, now
+			notify = append(notify, *a)
+		}
+	}
+	e.mu.Unlock()
+
+	errs := []error{gatherErr}
+	for _, a := range notify {
+		e.log.Info("Alert state changed", zap.String("rule", a.Rule), zap.Stringer("state", a.State),
+			zap.Any("labels", a.Labels), zap.Float64("value", a.Value))
+		for _, n := range e.cfg.Notifiers {
+			if err := n.Notify(ctx, a); err != nil {
+				errs = append(errs, fmt.Errorf("notify %s: %w", a.Rule, err))
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// alertLabels 合并序列标签和规则标签，并加上 alertname
+func alertLabels(r rule, series map[string]string) map[string]string {
+	labels := make(map[string]string, len(series)+len(r.Labels)+1)
+	for k, v := range series {
+		labels[k] = v
+	}
+	for k, v := range r.Labels {
+		labels[k] = v
+	}
+	labels["alertname"] = r.Name
+	return labels
+}
+
+// sample 一条时间序列的值
+type sample struct {
+	key    string // 排序后的标签，用于区分时间序列
+	labels map[string]string
+	value  float64
+}
+
+// samples 返回与表达式的指标名称和标签匹配的时间序列，直方图和摘要通过 _sum、_count 后缀取值
+func samples(mfs []*dto.MetricFamily, expr *Expr) []sample {
+	var ret []sample
+	for _, mf := range mfs {
+		name := mf.GetName()
+		var suffix string
+		switch mf.GetType() {
+		case dto.MetricType_HISTOGRAM, dto.MetricType_SUMMARY:
+			suffix = strings.TrimPrefix(expr.Metric, name)
+			if suffix != "_sum" && suffix != "_count" {
+				continue
+			}
+		default:
+			if name != expr.Metric {
+				continue
+			}
+		}
+		for _, m := range mf.GetMetric() {
+			labels := make(map[string]string, len(m.GetLabel()))
+			for _, lp := range m.GetLabel() {
+				labels[lp.GetName()] = lp.GetValue()
+			}
+			if !matches(labels, expr.Matchers) {
+				continue
+			}
+			value, ok := metricValue(m, suffix)
+			if !ok {
+				continue
+			}
+			ret = append(ret, sample{key: seriesKey(labels), labels: labels, value: value})
+		}
+	}
+	return ret
+}
+
+// metricValue 返回指标的值，suffix 为直方图和摘要取值的后缀
+func metricValue(m *dto.Metric, suffix string) (float64, bool) {
+	switch {
+	case m.Gauge != nil:
+		return m.Gauge.GetValue(), true
+	case m.Counter != nil:
+		return m.Counter.GetValue(), true
+	case m.Untyped != nil:
+		return m.Untyped.GetValue(), true
+	case m.Histogram != nil && suffix == "_sum":
+		return m.Histogram.GetSampleSum(), true
+	case m.Histogram != nil && suffix == "_count":
+		return float64(m.Histogram.GetSampleCount()), true
+	case m.Summary != nil && suffix == "_sum":
+		return m.Summary.GetSampleSum(), true
+	case m.Summary != nil && suffix == "_count":
+		return float64(m.Summary.GetSampleCount()), true
+	default:
+		return 0, false
+	}
+}
+
+// matches 判断标签是否满足所有匹配条件
+func matches(labels, matchers map[string]string) bool {
+	for k, v := range matchers {
+		if labels[k] != v {
+			return false
+		}
+	}
+	return true
+}
+
+// seriesKey 按标签名称排序生成时间序列的唯一标识
+func seriesKey(labels map[string]string) string {
+	names := make([]string, 0, len(labels))
+	for name := range labels {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var b strings.Builder
+	for _, name := range names {
+		b.WriteString(name + "=" + labels[name] + "\xff")
+	}
+	return b.String()
+}
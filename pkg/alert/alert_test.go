@@ -0,0 +1,173 @@
+package alert
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"go.uber.org/zap"
+)
+
+// recorder 记录收到的通知
+type recorder struct {
+	mu     sync.Mutex
+	alerts []Alert
+}
+
+func (r *recorder) Notify(_ context.Context, a Alert) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.alerts = append(r.alerts, a)
+	return nil
+}
+
+func (r *recorder) states() []State {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	states := make([]State, 0, len(r.alerts))
+	for _, a := range r.alerts {
+		states = append(states, a.State)
+	}
+	return states
+}
+
+func TestNewEvaluatorInvalidRule(t *testing.T) {
+	for _, rules := range [][]Rule{
+		{{Name: "", Expr: "depth > 1"}},
+		{{Name: "a", Expr: "depth > 1"}, {Name: "a", Expr: "depth > 2"}},
+		{{Name: "a", Expr: "depth >"}},
+	} {
+		if _, err := NewEvaluator(zap.NewNop(), NewConfig(SetRules(rules...))); !errors.Is(err, ErrInvalidRule) {
+			t.Errorf("Expected ErrInvalidRule for %+v, got %v", rules, err)
+		}
+	}
+}
+
+func TestEvaluate(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	depth := prometheus.NewGaugeVec(prometheus.GaugeOpts{Name: "queue_depth"}, []string{"queue"})
+	reg.MustRegister(depth)
+
+	rec := &recorder{}
+	e, err := NewEvaluator(zap.NewNop(), NewConfig(
+		SetGatherer(reg),
+		SetNotifiers(rec),
+		SetRules(Rule{Name: "QueueBacklog", Expr: `queue_depth{queue="orders"} > 100 for 5m`, Labels: map[string]string{"severity": "warning"}}),
+	))
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	now := time.Now()
+
+	depth.WithLabelValues("orders").Set(500)
+	depth.WithLabelValues("audit").Set(500) // 标签不匹配
+	if err = e.Evaluate(ctx, now); err != nil {
+		t.Fatal(err)
+	}
+	alerts := e.Alerts()
+	if len(alerts) != 1 || alerts[0].State != StatePending || alerts[0].Labels["queue"] != "orders" ||
+		alerts[0].Labels["severity"] != "warning" || alerts[0].Labels["alertname"] != "QueueBacklog" {
+		t.Fatalf("Expected one pending alert, got %+v", alerts)
+	}
+	if len(rec.states()) != 0 {
+		t.Errorf("Expected no notification while pending, got %v", rec.states())
+	}
+
+	// 持续满足 for 指定的时间后触发，之后不重复通知
+	for _, d := range []time.Duration{4 * time.Minute, 5 * time.Minute, 6 * time.Minute} {
+		if err = e.Evaluate(ctx, now.Add(d)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if got := rec.states(); len(got) != 1 || got[0] != StateFiring {
+		t.Fatalf("Expected one firing notification, got %v", got)
+	}
+
+	depth.WithLabelValues("orders").Set(10)
+	if err = e.Evaluate(ctx, now.Add(7*time.Minute)); err != nil {
+		t.Fatal(err)
+	}
+	if got := rec.states(); len(got) != 2 || got[1] != StateResolved {
+		t.Fatalf("Expected resolved notification, got %v", got)
+	}
+	if len(e.Alerts()) != 0 {
+		t.Errorf("Expected no active alerts, got %+v", e.Alerts())
+	}
+
+	// pending 期间恢复不通知
+	depth.WithLabelValues("orders").Set(500)
+	_ = e.Evaluate(ctx, now.Add(8*time.Minute))
+	depth.WithLabelValues("orders").Set(0)
+	_ = e.Evaluate(ctx, now.Add(9*time.Minute))
+	if got := rec.states(); len(got) != 2 {
+		t.Errorf("Expected no notification for pending alert, got %v", got)
+	}
+}
+
+func TestEvaluateHistogram(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	h := prometheus.NewHistogram(prometheus.HistogramOpts{Name: "handle_seconds"})
+	reg.MustRegister(h)
+	h.Observe(1)
+	h.Observe(2)
+
+	rec := &recorder{}
+	e, err := NewEvaluator(zap.NewNop(), NewConfig(SetGatherer(reg), SetNotifiers(rec),
+		SetRules(Rule{Name: "Slow", Expr: "handle_seconds_sum > 2"}, Rule{Name: "Busy", Expr: "handle_seconds_count >= 2"})))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = e.Evaluate(context.Background(), time.Now()); err != nil {
+		t.Fatal(err)
+	}
+	if got := rec.states(); len(got) != 2 {
+		t.Errorf("Expected both histogram rules to fire, got %v", got)
+	}
+}
+
+func TestWebhook(t *testing.T) {
+	received := make(chan Alert, 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var a Alert
+		if err := json.NewDecoder(r.Body).Decode(&a); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		received <- a
+	}))
+	defer srv.Close()
+
+	n := Webhook(zap.NewNop(), srv.URL)
+	if err := n.Notify(context.Background(), Alert{Rule: "QueueBacklog", State: StateFiring}); err != nil {
+		t.Fatal(err)
+	}
+	if a := <-received; a.Rule != "QueueBacklog" || a.State != StateFiring {
+		t.Errorf("Unexpected webhook payload %+v", a)
+	}
+
+	bad := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer bad.Close()
+	if err := Webhook(zap.NewNop(), bad.URL).Notify(context.Background(), Alert{}); err == nil {
+		t.Error("Expected error for 500 response")
+	}
+}
+
+func TestPublisher(t *testing.T) {
+	var topic string
+	n := Publisher(func(tp string, data any) error {
+		topic = tp
+		return nil
+	})
+	if err := n.Notify(context.Background(), Alert{State: StateResolved}); err != nil || topic != "alert.resolved" {
+		t.Errorf("Unexpected publish topic %q: %v", topic, err)
+	}
+}
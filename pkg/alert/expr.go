@@ -0,0 +1,157 @@
+package alert
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/andrewbytecoder/nmq/pkg/convert"
+)
+
+// ErrInvalidExpr 规则表达式格式错误
+var ErrInvalidExpr = errors.New("alert: invalid rule expression")
+
+// 支持的比较运算符，较长的运算符在前，保证 ">=" 不会被解析为 ">"
+var operators = []string{">=", "<=", "==", "!=", ">", "<"}
+
+// Expr 解析后的阈值表达式，形如 `queue_depth{topic="orders"} > 10000 for 5m`
+type Expr struct {
+	Metric    string            // 指标名称，直方图和摘要可以使用 _sum、_count 后缀
+	Matchers  map[string]string // 标签需要等于的值
+	Op        string            // 比较运算符
+	Threshold float64           // 阈值
+	For       time.Duration     // 条件持续满足多久后触发，为0时立即触发
+}
+
+// ParseExpr 解析阈值表达式
+//
+// 格式为 `metric{label="value",...} op threshold [for duration]`，标签匹配和 for 子句可以省略，
+// op 为 >、>=、<、<=、==、!= 之一，duration 支持 time.ParseDuration 的格式和 "1d" 这样的天数
+func ParseExpr(s string) (*Expr, error) {
+	s = strings.TrimSpace(s)
+	e := &Expr{Matchers: map[string]string{}}
+
+	i := 0
+	for i < len(s) && isNameChar(s[i], i == 0) {
+		i++
+	}
+	if i == 0 {
+		return nil, fmt.Errorf("%w: missing metric name in %q", ErrInvalidExpr, s)
+	}
+	e.Metric, s = s[:i], strings.TrimSpace(s[i:])
+
+	if strings.HasPrefix(s, "{") {
+		end := strings.IndexByte(s, '}')
+		if end < 0 {
+			return nil, fmt.Errorf("%w: unclosed label matcher", ErrInvalidExpr)
+		}
+		if err := parseMatchers(s[1:end], e.Matchers); err != nil {
+			return nil, err
+		}
+		s = strings.TrimSpace(s[end+1:])
+	}
+
+	for _, op := range operators {
+		if strings.HasPrefix(s, op) {
+			e.Op, s = op, strings.TrimSpace(s[len(op):])
+			break
+		}
+	}
+	if e.Op == "" {
+		return nil, fmt.Errorf("%w: missing comparison operator", ErrInvalidExpr)
+	}
+
+	fields := strings.Fields(s)
+	switch {
+	case len(fields) == 1:
+	case len(fields) == 3 && fields[1] == "for":
+		d, err := convert.HumanDuration(fields[2])
+		if err != nil || d < 0 {
+			return nil, fmt.Errorf("%w: invalid duration %q", ErrInvalidExpr, fields[2])
+		}
+		e.For = d
+	default:
+		return nil, fmt.Errorf("%w: unexpected %q", ErrInvalidExpr, s)
+	}
+	threshold, err := strconv.ParseFloat(fields[0], 64)
+	if err != nil {
+		return nil, fmt.Errorf("%w: invalid threshold %q", ErrInvalidExpr, fields[0])
+	}
+	e.Threshold = threshold
+	return e, nil
+}
+
+// parseMatchers 解析 label="value" 列表
+func parseMatchers(s string, matchers map[string]string) error {
+	for _, part := range strings.Split(s, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		name, value, ok := strings.Cut(part, "=")
+		name = strings.TrimSpace(name)
+		if !ok || name == "" {
+			return fmt.Errorf("%w: invalid label matcher %q", ErrInvalidExpr, part)
+		}
+		value, err := strconv.Unquote(strings.TrimSpace(value))
+		if err != nil {
+			return fmt.Errorf("%w: label value must be quoted in %q", ErrInvalidExpr, part)
+		}
+		matchers[name] = value
+	}
+	return nil
+}
+
+// isNameChar 判断是否为指标名称的合法字符
+func isNameChar(c byte, first bool) bool {
+	return c == '_' || c == ':' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (!first && c >= '0' && c <= '9')
+}
+
+// Match 判断 value 是否满足条件
+func (e *Expr) Match(value float64) bool {
+	switch e.Op {
+	case ">":
+		return value > e.Threshold
+	case ">=":
+		return value >= e.Threshold
+	case "<":
+		return value < e.Threshold
+	case "<=":
+		return value <= e.Threshold
+	case "==":
+		return value == e.Threshold
+	case "!=":
+		return value != e.Threshold
+	default:
+		return false
+	}
+}
+
+// String 返回表达式的规范形式
+func (e *Expr) String() string {
+	var b strings.Builder
+	b.WriteString(e.Metric)
+	if len(e.Matchers) > 0 {
+		names := make([]string, 0, len(e.Matchers))
+		for name := range e.Matchers {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		b.WriteByte('{')
+		for i, name := range names {
+			if i > 0 {
+				b.WriteByte(',')
+			}
+			b.WriteString(name + "=" + strconv.Quote(e.Matchers[name]))
+		}
+		b.WriteByte('}')
+	}
+	b.WriteString(" " + e.Op + " " + strconv.FormatFloat(e.Threshold, 'g', -1, 64))
+	if e.For > 0 {
+		b.WriteString(" for " + e.For.String())
+	}
+	return b.String()
+}
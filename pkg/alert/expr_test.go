@@ -0,0 +1,54 @@
+package alert
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestParseExpr(t *testing.T) {
+	tests := []struct {
+		in       string
+		expected string
+		forDur   time.Duration
+	}{
+		{"queue_depth > 10000 for 5m", "queue_depth > 10000 for 5m0s", 5 * time.Minute},
+		{`nmq_pool_waiting_tasks{pool="default", region="us"}>=1.5`, `nmq_pool_waiting_tasks{pool="default",region="us"} >= 1.5`, 0},
+		{"up == 0 for 1d", "up == 0 for 24h0m0s", 24 * time.Hour},
+		{"free_workers<1", "free_workers < 1", 0},
+	}
+	for _, tt := range tests {
+		e, err := ParseExpr(tt.in)
+		if err != nil {
+			t.Errorf("ParseExpr(%q) failed: %v", tt.in, err)
+			continue
+		}
+		if got := e.String(); got != tt.expected || e.For != tt.forDur {
+			t.Errorf("ParseExpr(%q) = %q for %v, expected %q for %v", tt.in, got, e.For, tt.expected, tt.forDur)
+		}
+	}
+
+	for _, in := range []string{"", "> 1", "depth", "depth ~ 1", "depth > x", `depth{a=b} > 1`, `depth{a="b" > 1`, "depth > 1 for", "depth > 1 for xx", "depth > 1 during 5m"} {
+		if _, err := ParseExpr(in); !errors.Is(err, ErrInvalidExpr) {
+			t.Errorf("ParseExpr(%q) expected ErrInvalidExpr, got %v", in, err)
+		}
+	}
+}
+
+func TestExprMatch(t *testing.T) {
+	tests := []struct {
+		op       string
+		value    float64
+		expected bool
+	}{
+		{">", 11, true}, {">", 10, false},
+		{">=", 10, true}, {"<", 9, true},
+		{"<=", 11, false}, {"==", 10, true}, {"!=", 10, false},
+	}
+	for _, tt := range tests {
+		e := &Expr{Op: tt.op, Threshold: 10}
+		if got := e.Match(tt.value); got != tt.expected {
+			t.Errorf("%v %s 10 = %v, expected %v", tt.value, tt.op, got, tt.expected)
+		}
+	}
+}
@@ -0,0 +1,55 @@
+package alert
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/andrewbytecoder/nmq/pkg/httpclient"
+	"go.uber.org/zap"
+)
+
+// TopicPrefix 告警事件的主题前缀，状态名称作为最后一段
+const TopicPrefix = "alert."
+
+// 默认的 webhook 请求超时
+const defaultWebhookTimeout = 10 * time.Second
+
+// Topic 返回告警通知的事件主题，例如 "alert.firing"
+func Topic(s State) string {
+	return TopicPrefix + s.String()
+}
+
+// Publisher 以 Alert 为数据、Topic(a.State) 为主题发布告警事件，publish 通常为事件总线的 Publish
+func Publisher(publish func(topic string, data any) error) Notifier {
+	return NotifierFunc(func(_ context.Context, a Alert) error {
+		return publish(Topic(a.State), a)
+	})
+}
+
+// Webhook 以 JSON 形式将告警 POST 到 url，非 2xx 响应视为失败
+func Webhook(log *zap.Logger, url string) Notifier {
+	client := httpclient.NewHttpClient(log)
+	return NotifierFunc(func(ctx context.Context, a Alert) error {
+		body, err := json.Marshal(a)
+		if err != nil {
+			return err
+		}
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+		if err != nil {
+			return err
+		}
+		req.Header.Set("Content-Type", "application/json")
+		resp, err := client.SendRequestReturnEntity(req, defaultWebhookTimeout)
+		if err != nil {
+			return err
+		}
+		if resp.Status < 200 || resp.Status >= 300 {
+			return fmt.Errorf("alert: webhook returned status %d", resp.Status)
+		}
+		return nil
+	})
+}
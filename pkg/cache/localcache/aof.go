@@ -0,0 +1,451 @@
+package localcache
+
+import (
+	"bufio"
+	"context"
+	"encoding/gob"
+	"errors"
+	"io"
+	"os"
+	"reflect"
+	"sync"
+	"time"
+
+	"github.com/andrewbytecoder/nmq/pkg/ratelimit"
+)
+
+// AOF 文件后缀：当前日志和压缩过程中被轮转的旧日志
+const (
+	aofSuffix    = ".aof"
+	aofOldSuffix = ".aof.old"
+	aofTmpSuffix = ".tmp"
+)
+
+// 默认的日志刷盘间隔
+const defaultAOFSyncInterval = time.Second
+
+// aofOp 日志记录的操作类型
+type aofOp uint8
+
+const (
+	aofSet aofOp = iota + 1
+	aofDelete
+	aofFlush
+)
+
+// aofRecord 一条日志记录
+type aofRecord struct {
+	Op      aofOp
+	Key     string
+	Val     interface{}
+	Expire  int64
+	Sliding int64
+}
+
+// aofLog 追加写日志，每个日志文件只包含一个 gob 流，压缩时轮转为新文件
+type aofLog struct {
+	mu        sync.Mutex
+	compactMu sync.Mutex // 串行化压缩，避免并发压缩覆盖尚未写入快照的旧日志
+	path      string     // 快照文件路径，日志文件为 path + ".aof"
+	f         *os.File
+	w         *bufio.Writer
+	enc       *gob.Encoder
+	err       error // 第一次写入失败的错误，出错后停止写日志直到下一次压缩成功
+	unsynced  int   // 上次落盘后已经写入文件但尚未计入限速的字节数
+	closed    bool  // 日志已关闭，之后的压缩不再轮转出新日志
+
+	types map[reflect.Type]struct{} // 已经 gob.Register 的值类型
+
+	throttle *ratelimit.Bucket // 定期刷盘和写快照的限速令牌桶
+	ctx      context.Context   // 关闭日志时取消，结束限速等待
+	cancel   context.CancelFunc
+
+	stop chan struct{}
+	once sync.Once
+	wg   sync.WaitGroup
+}
+
+// openAOFLog 创建新的日志文件，已存在的文件会被清空
+func openAOFLog(path string, throttle *ratelimit.Bucket) (*aofLog, error) {
+	a := &aofLog{path: path, throttle: throttle, stop: make(chan struct{}), types: make(map[reflect.Type]struct{})}
+	if err := a.open(); err != nil {
+		return nil, err
+	}
+	a.ctx, a.cancel = context.WithCancel(context.Background())
+	return a, nil
+}
+
+// countWriter 统计写入底层文件的字节数
+type countWriter struct {
+	w io.Writer
+	n *int
+}
+
+func (c countWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	*c.n += n
+	return n, err
+}
+
+// open 创建日志文件和编码器 调用方需持有 a.mu 或保证独占
+func (a *aofLog) open() error {
+	f, err := os.OpenFile(a.path+aofSuffix, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o644)
+	if err != nil {
+		return err
+	}
+	a.f = f
+	a.w = bufio.NewWriter(countWriter{w: f, n: &a.unsynced})
+	a.enc = gob.NewEncoder(a.w)
+	a.err = nil
+	return nil
+}
+
+// register 注册值类型以便 gob 编码，每种类型只注册一次 调用方需持有 a.mu 或保证独占
+func (a *aofLog) register(v interface{}) (err error) {
+	if v == nil {
+		return nil
+	}
+	t := reflect.TypeOf(v)
+	if _, ok := a.types[t]; ok {
+		return nil
+	}
+	defer func() {
+		if e := recover(); e != nil {
+			err = CacheGobErr
+		}
+	}()
+	gob.Register(v)
+	a.types[t] = struct{}{}
+	return nil
+}
+
+// append 追加一条记录，写入失败后不再追加
+func (a *aofLog) append(r aofRecord) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.err != nil || a.enc == nil {
+		return
+	}
+	if a.err = a.register(r.Val); a.err != nil {
+		return
+	}
+	defer func() {
+		if e := recover(); e != nil {
+			a.err = CacheGobErr
+		}
+	}()
+	if err := a.enc.Encode(&r); err != nil {
+		a.err = err
+	}
+}
+
+// sync 将缓冲区写入文件并落盘
+func (a *aofLog) sync() error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.w == nil {
+		return a.err
+	}
+	if err := a.w.Flush(); err != nil {
+		return err
+	}
+	if err := a.f.Sync(); err != nil {
+		return err
+	}
+	a.unsynced = 0
+	return a.err
+}
+
+// throttledSync 按限速等待上次落盘后写入的字节数再落盘，等待期间不持有 a.mu，不阻塞缓存写入
+func (a *aofLog) throttledSync() error {
+	a.mu.Lock()
+	pending := a.unsynced
+	if a.w != nil {
+		pending += a.w.Buffered()
+	}
+	a.mu.Unlock()
+	if err := a.throttle.WaitN(a.ctx, pending); err != nil {
+		return err
+	}
+	return a.sync()
+}
+
+// rotate 将当前日志轮转为旧日志并创建新日志 调用方需持有所有分片锁
+//
+// 上次压缩失败留下的旧日志尚未写入快照，此时不轮转，避免覆盖旧日志；当前日志继续追加，
+// 日志记录都是覆盖写，在之后的快照上重新回放结果不变。改名或创建新日志失败时记录到 a.err，
+// 之后的写入被丢弃并由 SyncAOF 返回该错误，直到下一次轮转成功
+func (a *aofLog) rotate() error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.closed {
+		return CacheNoExist
+	}
+	old := a.path + aofOldSuffix
+	if _, err := os.Stat(old); err == nil {
+		return nil
+	} else if !os.IsNotExist(err) {
+		return err
+	}
+	// 关闭失败时旧日志末尾可能不完整，其中的修改已经包含在随后写入的快照中
+	_ = a.closeFile()
+	if err := os.Rename(a.path+aofSuffix, old); err != nil && !os.IsNotExist(err) {
+		a.err = err
+		return err
+	}
+	if err := a.open(); err != nil {
+		a.err = err
+		return err
+	}
+	return nil
+}
+
+// closeFile 刷盘并关闭日志文件 调用方需持有 a.mu
+func (a *aofLog) closeFile() error {
+	if a.f == nil {
+		return nil
+	}
+	err := a.w.Flush()
+	if e := a.f.Sync(); err == nil {
+		err = e
+	}
+	if e := a.f.Close(); err == nil {
+		err = e
+	}
+	a.f, a.w, a.enc, a.unsynced = nil, nil, nil, 0
+	return err
+}
+
+// close 停止后台协程并关闭日志文件，可重复调用
+//
+// 取消 a.ctx 中止正在进行的快照写入，并等待正在进行的压缩结束，之后的压缩不再轮转出新日志
+func (a *aofLog) close() error {
+	a.once.Do(func() {
+		a.cancel()
+		close(a.stop)
+	})
+	a.wg.Wait()
+	a.compactMu.Lock()
+	defer a.compactMu.Unlock()
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.closed = true
+	return a.closeFile()
+}
+
+// logSet 记录一次写入 调用方需持有分片写锁
+func (s *shard) logSet(k string, it Iterator) {
+	if s.aof != nil {
+		s.aof.append(aofRecord{Op: aofSet, Key: k, Val: it.Val, Expire: it.Expire, Sliding: it.Sliding})
+	}
+}
+
+// logDelete 记录一次删除 调用方需持有分片写锁
+func (s *shard) logDelete(k string) {
+	if s.aof != nil {
+		s.aof.append(aofRecord{Op: aofDelete, Key: k})
+	}
+}
+
+// OpenAOF 开启追加写持久化：从 path 快照和日志恢复缓存，之后的每次写入和删除都会追加到 path + ".aof"
+//
+// 日志按 SetAOFSyncInterval 的间隔刷盘，按 SetAOFCompactInterval 的间隔压缩为快照，
+// 快照使用 SetCodec 配置的编解码器，刷盘和写快照按 SetWriteLimit 限速；需要调用 Shutdown 关闭日志
+func (c *cache) OpenAOF(path string) error {
+	if c.aof.Load() != nil {
+		return CacheExist
+	}
+	// 恢复顺序：快照、压缩时未删除的旧日志、当前日志
+	if err := c.LoadFile(path); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	for _, name := range []string{path + aofOldSuffix, path + aofSuffix} {
+		if err := c.replay(name); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+
+	// 日志回放完毕后写入快照，快照写入成功后才删除日志，失败时保留日志，下次打开时重新回放
+	c.lockAll()
+	now := time.Now()
+	member := c.liveMember()
+	c.unlockAll()
+	if err := c.writeSnapshot(context.Background(), path, member, now); err != nil {
+		return err
+	}
+	for _, name := range []string{path + aofOldSuffix, path + aofSuffix} {
+		if err := os.Remove(name); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+
+	a, err := openAOFLog(path, c.throttle)
+	if err != nil {
+		return err
+	}
+	// 预先注册恢复出的值类型，之后只有新出现的类型才需要在写日志时注册
+	for _, it := range member {
+		if err = a.register(it.Val); err != nil {
+			_ = a.close()
+			return err
+		}
+	}
+	c.lockAll()
+	if !c.aof.CompareAndSwap(nil, a) {
+		c.unlockAll()
+		_ = a.close()
+		return CacheExist
+	}
+	for _, s := range c.shards {
+		s.aof = a
+	}
+	c.unlockAll()
+
+	a.wg.Add(1)
+	go c.runAOF(a)
+	return nil
+}
+
+// replay 回放日志文件，末尾不完整的记录会被忽略
+func (c *cache) replay(name string) error {
+	f, err := os.Open(name)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	dec := gob.NewDecoder(bufio.NewReader(f))
+	for {
+		var r aofRecord
+		if err = dec.Decode(&r); err != nil {
+			if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+				return nil
+			}
+			return err
+		}
+		switch r.Op {
+		case aofSet:
+			it := Iterator{Val: r.Val, Expire: r.Expire, Sliding: r.Sliding}
+			if it.Expired() {
+				continue
+			}
+			s := c.getShard(r.Key)
+			s.Lock()
+			evicted := s.put(r.Key, it)
+			s.Unlock()
+			c.dispatch(c.capture, EventEvict, evicted)
+		case aofDelete:
+			s := c.getShard(r.Key)
+			s.Lock()
+			s.remove(r.Key)
+			s.Unlock()
+		case aofFlush:
+			c.Flush()
+		}
+	}
+}
+
+// Compact 将当前缓存写入快照并清空日志，未开启 AOF 时返回 CacheNoExist
+//
+// 先在所有分片锁内复制数据并轮转日志，再写快照，写快照期间缓存可以正常读写；
+// 写快照失败时保留轮转出的旧日志，下一次压缩成功后才删除；Shutdown 关闭日志之后返回 CacheNoExist
+func (c *cache) Compact() error {
+	a := c.aof.Load()
+	if a == nil {
+		return CacheNoExist
+	}
+	a.compactMu.Lock()
+	defer a.compactMu.Unlock()
+
+	c.lockAll()
+	now := time.Now()
+	member := c.liveMember()
+	err := a.rotate()
+	c.unlockAll()
+	if err != nil {
+		return err
+	}
+
+	if err = c.writeSnapshot(a.ctx, a.path, member, now); err != nil {
+		return err
+	}
+	if err = os.Remove(a.path + aofOldSuffix); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+// liveMember 复制所有未过期的条目 调用方需持有所有分片锁
+func (c *cache) liveMember() map[string]Iterator {
+	member := make(map[string]Iterator)
+	for _, s := range c.shards {
+		for k, v := range s.member {
+			if !v.Expired() {
+				member[k] = v
+			}
+		}
+	}
+	return member
+}
+
+// writeSnapshot 将 member 写入快照文件 path，先写临时文件再重命名，保证快照文件总是完整的，
+// 写入按限速进行，ctx 取消时中止
+func (c *cache) writeSnapshot(ctx context.Context, path string, member map[string]Iterator, now time.Time) error {
+	tmp := path + aofTmpSuffix
+	f, err := os.Create(tmp)
+	if err != nil {
+		return err
+	}
+	w := bufio.NewWriter(ratelimit.NewWriter(ctx, f, c.throttle))
+	if err = writeHeader(w, c.codec, now); err == nil {
+		err = c.codec.Encode(w, member)
+	}
+	if err == nil {
+		err = w.Flush()
+	}
+	if err == nil {
+		err = f.Sync()
+	}
+	if e := f.Close(); err == nil {
+		err = e
+	}
+	if err == nil {
+		err = os.Rename(tmp, path)
+	}
+	if err != nil {
+		_ = os.Remove(tmp)
+	}
+	return err
+}
+
+// runAOF 定期刷盘和压缩日志，直到日志关闭
+func (c *cache) runAOF(a *aofLog) {
+	defer a.wg.Done()
+	syncTicker := time.NewTicker(c.aofSync)
+	defer syncTicker.Stop()
+	var compact <-chan time.Time
+	if c.aofCompact > 0 {
+		compactTicker := time.NewTicker(c.aofCompact)
+		defer compactTicker.Stop()
+		compact = compactTicker.C
+	}
+	for {
+		select {
+		case <-syncTicker.C:
+			_ = a.throttledSync()
+		case <-compact:
+			_ = c.Compact()
+		case <-a.stop:
+			return
+		}
+	}
+}
+
+// SyncAOF 立即将日志刷盘，返回日志写入过程中遇到的第一个错误，未开启 AOF 或 Shutdown 之后返回 CacheNoExist
+func (c *cache) SyncAOF() error {
+	a := c.aof.Load()
+	if a == nil {
+		return CacheNoExist
+	}
+	return a.sync()
+}
@@ -0,0 +1,308 @@
+// aof_test.go
+package localcache
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestAOFReplay(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cache.db")
+
+	cache := NewCache(SetCapture(nil))
+	if err := cache.OpenAOF(path); err != nil {
+		t.Fatalf("OpenAOF failed: %v", err)
+	}
+	cache.Set("key1", "value1", 0)
+	cache.Set("key2", "value2", 0)
+	cache.Set("counter", int64(1), 0)
+	cache.Set("short", "gone", 10*time.Millisecond)
+	cache.Delete("key2")
+	if _, err := cache.IncrementInt64("counter", 2); err != nil {
+		t.Fatalf("IncrementInt64 failed: %v", err)
+	}
+	if err := cache.Expire("key1", time.Hour); err != nil {
+		t.Fatalf("Expire failed: %v", err)
+	}
+	if err := cache.Shutdown(); err != nil {
+		t.Fatalf("Shutdown failed: %v", err)
+	}
+	time.Sleep(20 * time.Millisecond)
+
+	restored := NewCache(SetCapture(nil))
+	if err := restored.OpenAOF(path); err != nil {
+		t.Fatalf("OpenAOF failed: %v", err)
+	}
+	defer restored.Shutdown()
+
+	if v, ok := restored.Get("key1"); !ok || v != "value1" {
+		t.Errorf("Expected key1=value1, got %v, %v", v, ok)
+	}
+	if ttl, err := restored.TTL("key1"); err != nil || ttl <= 0 {
+		t.Errorf("Expected key1 to keep its ttl, got %v, %v", ttl, err)
+	}
+	if _, ok := restored.Get("key2"); ok {
+		t.Error("Expected key2 to stay deleted")
+	}
+	if _, ok := restored.Get("short"); ok {
+		t.Error("Expected expired key to be skipped")
+	}
+	if v, ok := restored.Get("counter"); !ok || v != int64(3) {
+		t.Errorf("Expected counter=3, got %v, %v", v, ok)
+	}
+}
+
+func TestAOFCompact(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cache.db")
+
+	cache := NewCache(SetCapture(nil))
+	if err := cache.OpenAOF(path); err != nil {
+		t.Fatalf("OpenAOF failed: %v", err)
+	}
+	for i := 0; i < 100; i++ {
+		cache.Set("key", i, 0)
+	}
+	if err := cache.SyncAOF(); err != nil {
+		t.Fatalf("SyncAOF failed: %v", err)
+	}
+	before, err := os.Stat(path + aofSuffix)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err = cache.Compact(); err != nil {
+		t.Fatalf("Compact failed: %v", err)
+	}
+	if err = cache.SyncAOF(); err != nil {
+		t.Fatalf("SyncAOF failed: %v", err)
+	}
+	after, err := os.Stat(path + aofSuffix)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if after.Size() >= before.Size() {
+		t.Errorf("Expected log to shrink after compaction, %d >= %d", after.Size(), before.Size())
+	}
+	if _, err = os.Stat(path + aofOldSuffix); !os.IsNotExist(err) {
+		t.Errorf("Expected rotated log to be removed, got %v", err)
+	}
+
+	// 压缩后的写入仍然记录在新日志中
+	cache.Set("after", "compact", 0)
+	cache.Shutdown()
+
+	restored := NewCache(SetCapture(nil))
+	if err = restored.OpenAOF(path); err != nil {
+		t.Fatalf("OpenAOF failed: %v", err)
+	}
+	defer restored.Shutdown()
+	if v, ok := restored.Get("key"); !ok || v != 99 {
+		t.Errorf("Expected key=99, got %v, %v", v, ok)
+	}
+	if v, ok := restored.Get("after"); !ok || v != "compact" {
+		t.Errorf("Expected after=compact, got %v, %v", v, ok)
+	}
+}
+
+func TestAOFRecover(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "cache.db")
+
+	cache := NewCache(SetCapture(nil))
+	if err := cache.OpenAOF(path); err != nil {
+		t.Fatalf("OpenAOF failed: %v", err)
+	}
+	cache.Set("old", "value", 0)
+	cache.Set("flushed", "value", 0)
+	cache.Flush()
+	cache.Set("tail", "value", 0)
+	if err := cache.SyncAOF(); err != nil {
+		t.Fatalf("SyncAOF failed: %v", err)
+	}
+	cache.Shutdown()
+
+	// 模拟压缩过程中崩溃：日志已轮转为旧日志，快照尚未写入
+	if err := os.Rename(path+aofSuffix, path+aofOldSuffix); err != nil {
+		t.Fatal(err)
+	}
+	restored := NewCache(SetCapture(nil))
+	if err := restored.OpenAOF(path); err != nil {
+		t.Fatalf("OpenAOF failed: %v", err)
+	}
+	if _, ok := restored.Get("flushed"); ok {
+		t.Error("Expected flush to be replayed")
+	}
+	if v, ok := restored.Get("tail"); !ok || v != "value" {
+		t.Errorf("Expected tail=value, got %v, %v", v, ok)
+	}
+	if _, err := os.Stat(path + aofOldSuffix); !os.IsNotExist(err) {
+		t.Errorf("Expected rotated log to be removed, got %v", err)
+	}
+	if err := restored.OpenAOF(path); !CacheErrExist(err) {
+		t.Errorf("Expected CacheExist, got %v", err)
+	}
+	restored.Set("partial", "value", 0)
+	restored.Shutdown()
+
+	// 模拟写入过程中崩溃：日志末尾的记录不完整
+	data, err := os.ReadFile(path + aofSuffix)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = os.WriteFile(path+aofSuffix, data[:len(data)-3], 0o644); err != nil {
+		t.Fatal(err)
+	}
+	restored = NewCache(SetCapture(nil))
+	if err = restored.OpenAOF(path); err != nil {
+		t.Fatalf("OpenAOF with truncated log failed: %v", err)
+	}
+	defer restored.Shutdown()
+	if _, ok := restored.Get("partial"); ok {
+		t.Error("Expected truncated record to be dropped")
+	}
+	if v, ok := restored.Get("tail"); !ok || v != "value" {
+		t.Errorf("Expected tail=value from snapshot, got %v, %v", v, ok)
+	}
+}
+
+func TestAOFCompactFailure(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cache.db")
+
+	cache := NewCache(SetCapture(nil))
+	if err := cache.OpenAOF(path); err != nil {
+		t.Fatalf("OpenAOF failed: %v", err)
+	}
+	defer cache.Shutdown()
+
+	// 临时快照文件的位置被非空目录占用，写快照失败
+	if err := os.MkdirAll(filepath.Join(path+aofTmpSuffix, "busy"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	cache.Set("first", "value", 0)
+	if err := cache.Compact(); err == nil {
+		t.Fatal("Expected compact to fail")
+	}
+	cache.Set("second", "value", 0)
+	if err := cache.Compact(); err == nil {
+		t.Fatal("Expected compact to fail")
+	}
+	if err := cache.SyncAOF(); err != nil {
+		t.Fatalf("Expected log to keep working, got %v", err)
+	}
+
+	// 第二次压缩没有覆盖第一次轮转出的旧日志，两次写入都能恢复
+	if err := os.RemoveAll(path + aofTmpSuffix); err != nil {
+		t.Fatal(err)
+	}
+	restored := NewCache(SetCapture(nil))
+	if err := restored.OpenAOF(path); err != nil {
+		t.Fatalf("OpenAOF failed: %v", err)
+	}
+	defer restored.Shutdown()
+	for _, k := range []string{"first", "second"} {
+		if _, ok := restored.Get(k); !ok {
+			t.Errorf("Expected %s to be recovered", k)
+		}
+	}
+}
+
+func TestWriteLimit(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cache.db")
+
+	cache := NewCache(SetCapture(nil), SetWriteLimit(10000))
+	cache.Set("key1", string(make([]byte, 15000)), 0)
+
+	// 超出桶容量的部分按限速写入
+	start := time.Now()
+	if err := cache.SaveFile(path); err != nil {
+		t.Fatalf("SaveFile failed: %v", err)
+	}
+	if d := time.Since(start); d < 300*time.Millisecond {
+		t.Errorf("Expected throttled save, took %v", d)
+	}
+
+	// 运行时取消限速
+	cache.SetWriteLimit(0)
+	start = time.Now()
+	if err := cache.SaveFile(path); err != nil {
+		t.Fatalf("SaveFile failed: %v", err)
+	}
+	if d := time.Since(start); d > 200*time.Millisecond {
+		t.Errorf("Expected unthrottled save, took %v", d)
+	}
+}
+
+func TestAOFWriteLimitShutdown(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cache.db")
+
+	cache := NewCache(SetCapture(nil), SetAOFSyncInterval(10*time.Millisecond))
+	if err := cache.OpenAOF(path); err != nil {
+		t.Fatalf("OpenAOF failed: %v", err)
+	}
+	cache.SetWriteLimit(1)
+	cache.Set("key1", string(make([]byte, 1000)), 0)
+	time.Sleep(30 * time.Millisecond)
+
+	// 关闭日志时中止限速等待，并且未限速的最终刷盘保留所有写入
+	start := time.Now()
+	if err := cache.Shutdown(); err != nil {
+		t.Fatalf("Shutdown failed: %v", err)
+	}
+	if d := time.Since(start); d > time.Second {
+		t.Errorf("Expected Shutdown to abort throttled sync, took %v", d)
+	}
+
+	restored := NewCache(SetCapture(nil))
+	if err := restored.OpenAOF(path); err != nil {
+		t.Fatalf("OpenAOF failed: %v", err)
+	}
+	defer restored.Shutdown()
+	if _, ok := restored.Get("key1"); !ok {
+		t.Error("Expected key1 to be restored")
+	}
+}
+
+func TestAOFShutdownRace(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cache.db")
+	cache := NewCache(SetCapture(nil))
+	if err := cache.OpenAOF(path); err != nil {
+		t.Fatalf("OpenAOF failed: %v", err)
+	}
+
+	// Compact 和 SyncAOF 与 Shutdown 并发执行，Shutdown 之后返回 CacheNoExist
+	var wg sync.WaitGroup
+	stop := make(chan struct{})
+	for i := 0; i < 2; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; ; j++ {
+				select {
+				case <-stop:
+					return
+				default:
+				}
+				cache.Set("key", j, 0)
+				_ = cache.Compact()
+				_ = cache.SyncAOF()
+			}
+		}()
+	}
+	time.Sleep(10 * time.Millisecond)
+	if err := cache.Shutdown(); err != nil {
+		t.Fatalf("Shutdown failed: %v", err)
+	}
+	close(stop)
+	wg.Wait()
+
+	if err := cache.Compact(); !errors.Is(err, CacheNoExist) {
+		t.Errorf("Expected CacheNoExist from Compact, got %v", err)
+	}
+	if err := cache.SyncAOF(); !errors.Is(err, CacheNoExist) {
+		t.Errorf("Expected CacheNoExist from SyncAOF, got %v", err)
+	}
+}
@@ -0,0 +1,293 @@
+package localcache
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"sync"
+	"time"
+
+	"github.com/andrewbytecoder/nmq/pkg/options"
+	"github.com/andrewbytecoder/nmq/pkg/utils/hash"
+)
+
+// 默认的 arena 总容量
+const defaultArenaBytes = 64 << 20
+
+// arenaHeader 条目头部：状态(1) + 过期时间(8) + key长度(2) + value长度(4)
+const arenaHeader = 1 + 8 + 2 + 4
+
+// 条目状态
+const (
+	arenaDead byte = iota
+	arenaLive
+)
+
+// ValueCodec arena 缓存的值编解码器，Unmarshal 不能持有 data，返回前需要复制
+type ValueCodec interface {
+	Marshal(v interface{}) ([]byte, error)
+	Unmarshal(data []byte) (interface{}, error)
+}
+
+// bytesValueCodec 原样保存 []byte 和 string，读取时统一返回 []byte
+type bytesValueCodec struct{}
+
+// BytesValueCodec 返回不做序列化的编解码器，值只能是 []byte 或 string
+func BytesValueCodec() ValueCodec { return bytesValueCodec{} }
+
+func (bytesValueCodec) Marshal(v interface{}) ([]byte, error) {
+	switch b := v.(type) {
+	case []byte:
+		return b, nil
+	case string:
+		return []byte(b), nil
+	}
+	return nil, CacheTypeErr
+}
+
+func (bytesValueCodec) Unmarshal(data []byte) (interface{}, error) {
+	return append([]byte(nil), data...), nil
+}
+
+// jsonValueCodec JSON 值编解码器，读取时数字为 float64，对象为 map[string]interface{}
+type jsonValueCodec struct{}
+
+// JSONValueCodec 返回 JSON 值编解码器
+func JSONValueCodec() ValueCodec { return jsonValueCodec{} }
+
+func (jsonValueCodec) Marshal(v interface{}) ([]byte, error) {
+	return json.Marshal(v)
+}
+
+func (jsonValueCodec) Unmarshal(data []byte) (interface{}, error) {
+	var v interface{}
+	err := json.Unmarshal(data, &v)
+	return v, err
+}
+
+// arenaShard arena 分片，所有条目序列化后追加写入预分配的字节数组
+//
+// 索引为 key 哈希到偏移量的映射，不包含指针，GC 不需要扫描缓存内容；
+// 删除和覆盖只标记旧条目失效，空间不足时原地压缩，压缩后仍然不足则按写入顺序淘汰最旧的条目
+type arenaShard struct {
+	sync.RWMutex
+	buf   []byte            // 预分配的 arena
+	end   int               // 下一次写入的偏移量
+	live  int               // 有效条目占用的字节数
+	index map[uint64]uint32 // key 哈希到条目偏移量
+}
+
+// entry 读取 off 处条目的头部
+func (s *arenaShard) entry(off int) (state byte, expire int64, key []byte, size int) {
+	b := s.buf[off:]
+	state = b[0]
+	expire = int64(binary.LittleEndian.Uint64(b[1:9]))
+	kl := int(binary.LittleEndian.Uint16(b[9:11]))
+	vl := int(binary.LittleEndian.Uint32(b[11:15]))
+	return state, expire, b[arenaHeader : arenaHeader+kl], arenaHeader + kl + vl
+}
+
+// value 返回 off 处条目的值
+func (s *arenaShard) value(off int) []byte {
+	b := s.buf[off:]
+	kl := int(binary.LittleEndian.Uint16(b[9:11]))
+	vl := int(binary.LittleEndian.Uint32(b[11:15]))
+	return b[arenaHeader+kl : arenaHeader+kl+vl]
+}
+
+// lookup 查找 k 对应的有效条目，哈希冲突时比较 key
+func (s *arenaShard) lookup(h uint64, k string) (int, bool) {
+	off, ok := s.index[h]
+	if !ok {
+		return 0, false
+	}
+	_, _, key, _ := s.entry(int(off))
+	return int(off), string(key) == k
+}
+
+// kill 将 off 处条目标记为失效
+func (s *arenaShard) kill(h uint64, off int) {
+	_, _, _, size := s.entry(off)
+	s.buf[off] = arenaDead
+	s.live -= size
+	delete(s.index, h)
+}
+
+// compact 将有效条目移动到 arena 头部，同时丢弃过期条目，need > 0 时先淘汰最旧的条目直到腾出 need 字节
+func (s *arenaShard) compact(need int, now int64) {
+	w, dropped := 0, 0
+	for r := 0; r < s.end; {
+		state, expire, key, size := s.entry(r)
+		if state == arenaLive {
+			h := hash.Fnv64a(string(key))
+			if (expire > 0 && now > expire) || dropped < need {
+				dropped += size
+				s.live -= size
+				delete(s.index, h)
+			} else {
+				if w != r {
+					copy(s.buf[w:], s.buf[r:r+size])
+					s.index[h] = uint32(w)
+				}
+				w += size
+			}
+		}
+		r += size
+	}
+	s.end = w
+}
+
+// set 写入条目，空间不足时压缩或淘汰，调用方需持有写锁
+func (s *arenaShard) set(h uint64, k string, v []byte, expire int64) error {
+	size := arenaHeader + len(k) + len(v)
+	if size > len(s.buf) {
+		return CacheArenaFull
+	}
+	if off, ok := s.index[h]; ok {
+		s.kill(h, int(off)) // 覆盖同一个key或哈希冲突的旧条目
+	}
+	if s.end+size > len(s.buf) {
+		// 可用空间 = 总容量 - 有效条目，仍然不足时淘汰最旧的条目
+		s.compact(size-(len(s.buf)-s.live), time.Now().UnixNano())
+	}
+
+	off := s.end
+	b := s.buf[off:]
+	b[0] = arenaLive
+	binary.LittleEndian.PutUint64(b[1:9], uint64(expire))
+	binary.LittleEndian.PutUint16(b[9:11], uint16(len(k)))
+	binary.LittleEndian.PutUint32(b[11:15], uint32(len(v)))
+	copy(b[arenaHeader:], k)
+	copy(b[arenaHeader+len(k):], v)
+	s.end += size
+	s.live += size
+	s.index[h] = uint32(off)
+	return nil
+}
+
+// ArenaCache 值序列化后保存在预分配字节数组中的缓存，适合条目数量很大、GC 扫描成为瓶颈的场景
+//
+// 与 Cache 相比不支持淘汰策略、事件和持久化，容量满时按写入顺序淘汰最旧的条目；
+// 每次读写都要经过编解码，适合值较小、读写频率适中的场景
+type ArenaCache struct {
+	shards []*arenaShard
+	mask   uint64
+	codec  ValueCodec
+}
+
+// NewArenaCache 创建 arena 缓存，使用 SetShards 设置分片数量，SetMaxBytes 设置 arena 总容量（默认64MB）
+func NewArenaCache(codec ValueCodec, opts ...options.Option) *ArenaCache {
+	config := NewConfig(opts...)
+	n := shardCount(config.shards)
+	total := config.maxBytes
+	if total <= 0 {
+		total = defaultArenaBytes
+	}
+	// 保证每个分片至少能容纳一个条目头部，且偏移量不超过 uint32
+	per := total / int64(n)
+	if per < arenaHeader {
+		per = arenaHeader
+	}
+	if per > 1<<32-1 {
+		per = 1<<32 - 1
+	}
+
+	c := &ArenaCache{
+		shards: make([]*arenaShard, n),
+		mask:   uint64(n - 1),
+		codec:  codec,
+	}
+	for i := range c.shards {
+		c.shards[i] = &arenaShard{buf: make([]byte, per), index: make(map[uint64]uint32)}
+	}
+	return c
+}
+
+// getShard 根据key的哈希值获取对应的分片
+func (c *ArenaCache) getShard(h uint64) *arenaShard {
+	return c.shards[h&c.mask]
+}
+
+// Set 添加cache 无论是否存在都会覆盖，d <= 0 表示永不过期
+//
+// 值编码失败返回编解码器的错误，编码后超过单个分片容量返回 CacheArenaFull
+func (c *ArenaCache) Set(k string, v interface{}, d time.Duration) error {
+	if len(k) > 1<<16-1 {
+		return CacheArenaKey
+	}
+	data, err := c.codec.Marshal(v)
+	if err != nil {
+		return err
+	}
+	var expire int64
+	if d > 0 {
+		expire = time.Now().Add(d).UnixNano()
+	}
+	h := hash.Fnv64a(k)
+	s := c.getShard(h)
+	s.Lock()
+	err = s.set(h, k, data, expire)
+	s.Unlock()
+	return err
+}
+
+// Get 根据key获取 cache，值在锁内解码，返回的对象不与 arena 共享内存
+func (c *ArenaCache) Get(k string) (interface{}, bool) {
+	h := hash.Fnv64a(k)
+	s := c.getShard(h)
+	s.RLock()
+	off, ok := s.lookup(h, k)
+	if !ok {
+		s.RUnlock()
+		return nil, false
+	}
+	if _, expire, _, _ := s.entry(off); expire > 0 && time.Now().UnixNano() > expire {
+		s.RUnlock()
+		return nil, false // 过期条目在压缩时回收
+	}
+	v, err := c.codec.Unmarshal(s.value(off))
+	s.RUnlock()
+	return v, err == nil
+}
+
+// Delete 删除k的cache
+func (c *ArenaCache) Delete(k string) {
+	h := hash.Fnv64a(k)
+	s := c.getShard(h)
+	s.Lock()
+	if off, ok := s.lookup(h, k); ok {
+		s.kill(h, off)
+	}
+	s.Unlock()
+}
+
+// Count 返回条目数量，包括已过期但尚未回收的条目
+func (c *ArenaCache) Count() int {
+	count := 0
+	for _, s := range c.shards {
+		s.RLock()
+		count += len(s.index)
+		s.RUnlock()
+	}
+	return count
+}
+
+// Bytes 返回有效条目占用的 arena 字节数
+func (c *ArenaCache) Bytes() int64 {
+	var n int64
+	for _, s := range c.shards {
+		s.RLock()
+		n += int64(s.live)
+		s.RUnlock()
+	}
+	return n
+}
+
+// Flush 清空所有条目，arena 内存保留复用
+func (c *ArenaCache) Flush() {
+	for _, s := range c.shards {
+		s.Lock()
+		s.end, s.live = 0, 0
+		s.index = make(map[uint64]uint32)
+		s.Unlock()
+	}
+}
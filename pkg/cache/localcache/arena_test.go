@@ -0,0 +1,108 @@
+// arena_test.go
+package localcache
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestArenaCacheBasic(t *testing.T) {
+	cache := NewArenaCache(BytesValueCodec(), SetShards(4), SetMaxBytes(4<<10))
+
+	if err := cache.Set("key1", "value1", 0); err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+	if err := cache.Set("key2", []byte("value2"), 0); err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+	if v, ok := cache.Get("key1"); !ok || !bytes.Equal(v.([]byte), []byte("value1")) {
+		t.Errorf("Expected key1=value1, got %v, %v", v, ok)
+	}
+	if err := cache.Set("key3", 1, 0); !CacheErrTypeErr(err) {
+		t.Errorf("Expected CacheTypeErr for int value, got %v", err)
+	}
+
+	// 覆盖后只保留新值
+	cache.Set("key1", "updated", 0)
+	if v, _ := cache.Get("key1"); string(v.([]byte)) != "updated" {
+		t.Errorf("Expected key1=updated, got %s", v)
+	}
+	if n := cache.Count(); n != 2 {
+		t.Errorf("Expected 2 entries, got %d", n)
+	}
+
+	cache.Delete("key1")
+	if _, ok := cache.Get("key1"); ok {
+		t.Error("Expected key1 to be deleted")
+	}
+	if want := int64(arenaHeader + len("key2") + len("value2")); cache.Bytes() != want {
+		t.Errorf("Expected %d live bytes, got %d", want, cache.Bytes())
+	}
+
+	cache.Flush()
+	if cache.Count() != 0 || cache.Bytes() != 0 {
+		t.Errorf("Expected empty cache after Flush, got %d entries", cache.Count())
+	}
+}
+
+func TestArenaCacheExpire(t *testing.T) {
+	cache := NewArenaCache(JSONValueCodec())
+	cache.Set("short", map[string]int{"a": 1}, time.Millisecond)
+	cache.Set("long", []int{1, 2}, time.Hour)
+
+	time.Sleep(5 * time.Millisecond)
+	if _, ok := cache.Get("short"); ok {
+		t.Error("Expected short to be expired")
+	}
+	v, ok := cache.Get("long")
+	if !ok || fmt.Sprint(v) != "[1 2]" {
+		t.Errorf("Expected long=[1 2], got %v, %v", v, ok)
+	}
+}
+
+func TestArenaCacheCompact(t *testing.T) {
+	// 单分片 1KB，每个条目 15+4+85=104 字节，最多容纳 9 个
+	cache := NewArenaCache(BytesValueCodec(), SetShards(1), SetMaxBytes(1024))
+	val := bytes.Repeat([]byte("x"), 85)
+	for i := 0; i < 9; i++ {
+		cache.Set(fmt.Sprintf("k%03d", i), val, 0)
+	}
+	// 删除后空间可以通过压缩回收，不淘汰有效条目
+	cache.Delete("k000")
+	cache.Delete("k001")
+	cache.Set("k100", val, 0)
+	cache.Set("k101", val, 0)
+	for _, k := range []string{"k002", "k008", "k100", "k101"} {
+		if _, ok := cache.Get(k); !ok {
+			t.Errorf("Expected %s to survive compaction", k)
+		}
+	}
+	if n := cache.Count(); n != 9 {
+		t.Errorf("Expected 9 entries, got %d", n)
+	}
+
+	// 空间不足时淘汰最旧的条目
+	cache.Set("k102", val, 0)
+	if _, ok := cache.Get("k002"); ok {
+		t.Error("Expected oldest entry k002 to be evicted")
+	}
+	for _, k := range []string{"k003", "k101", "k102"} {
+		if _, ok := cache.Get(k); !ok {
+			t.Errorf("Expected %s to be kept", k)
+		}
+	}
+
+	if err := cache.Set("big", make([]byte, 2048), 0); err != CacheArenaFull {
+		t.Errorf("Expected CacheArenaFull, got %v", err)
+	}
+}
+
+func BenchmarkArenaCacheSet(b *testing.B) {
+	cache := NewArenaCache(BytesValueCodec(), SetMaxBytes(16<<20))
+	val := []byte("value")
+	for i := 0; i < b.N; i++ {
+		cache.Set(fmt.Sprintf("key%d", i%100000), val, 0)
+	}
+}
@@ -0,0 +1,87 @@
+package localcache
+
+import "time"
+
+// groupByShard 将key按所属分片分组，批量操作时每个分片只加一次锁
+func (c *cache) groupByShard(keys []string) map[*shard][]string {
+	groups := make(map[*shard][]string)
+	for _, k := range keys {
+		s := c.getShard(k)
+		groups[s] = append(groups[s], k)
+	}
+	return groups
+}
+
+// MGet 批量获取 cache，返回存在且未过期的kv
+func (c *cache) MGet(keys []string) map[string]interface{} {
+	ret := make(map[string]interface{}, len(keys))
+	var expired, sliding []string
+	var misses uint64
+	for s, ks := range c.groupByShard(keys) {
+		s.RLock()
+		for _, k := range ks {
+			v, ok := s.member[k]
+			if !ok {
+				misses++
+				continue
+			}
+			if v.Expired() {
+				misses++
+				expired = append(expired, k)
+				continue
+			}
+			s.touch(k)
+			ret[k] = v.Val
+			if v.Sliding > 0 {
+				sliding = append(sliding, k)
+			}
+		}
+		s.RUnlock()
+	}
+	c.stats.hits.Add(uint64(len(ret)))
+	c.stats.misses.Add(misses)
+	// 顺延滑动过期时间并删除过期项
+	for _, k := range sliding {
+		c.slide(k)
+	}
+	for _, k := range expired {
+		c.deleteExpired(k)
+	}
+	return ret
+}
+
+// MSet 批量设置 cache，无论是否存在都会覆盖，所有kv使用相同的过期时间
+func (c *cache) MSet(items map[string]interface{}, d time.Duration) {
+	keys := make([]string, 0, len(items))
+	for k := range items {
+		keys = append(keys, k)
+	}
+
+	var evicted []kv
+	set := make([]kv, 0, len(items))
+	for s, ks := range c.groupByShard(keys) {
+		s.Lock()
+		for _, k := range ks {
+			evicted = append(evicted, s.set(k, items[k], d)...)
+			set = append(set, kv{k, items[k]})
+		}
+		s.Unlock()
+	}
+	c.dispatch(c.capture, EventEvict, evicted)
+	c.publish(EventSet, set)
+}
+
+// MDelete 批量删除 cache 如果 capture != nil 会对每个被删除的kv调用 capture 函数
+func (c *cache) MDelete(keys []string) {
+	var deleted []kv
+	for s, ks := range c.groupByShard(keys) {
+		s.Lock()
+		for _, k := range ks {
+			if v, ok := c.delete(s, k); ok {
+				deleted = append(deleted, kv{k, v})
+			}
+		}
+		s.Unlock()
+	}
+	c.dispatch(c.capture, EventDelete, deleted)
+}
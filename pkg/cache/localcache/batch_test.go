@@ -0,0 +1,71 @@
+// batch_test.go
+package localcache
+
+import (
+	"sort"
+	"testing"
+	"time"
+)
+
+func TestMSetMGet(t *testing.T) {
+	cache := NewCache(SetShards(4))
+
+	cache.MSet(map[string]interface{}{
+		"key1": "value1",
+		"key2": "value2",
+		"key3": "value3",
+	}, 0)
+
+	if count := cache.Count(); count != 3 {
+		t.Errorf("Expected count to be 3, got %d", count)
+	}
+
+	ret := cache.MGet([]string{"key1", "key3", "nonexistent"})
+	if len(ret) != 2 {
+		t.Errorf("Expected 2 values, got %d", len(ret))
+	}
+	if ret["key1"] != "value1" || ret["key3"] != "value3" {
+		t.Errorf("Unexpected values: %v", ret)
+	}
+	if _, ok := ret["nonexistent"]; ok {
+		t.Error("Expected nonexistent key to be absent")
+	}
+}
+
+func TestMGetExpired(t *testing.T) {
+	var captured []string
+	cache := NewCache(SetCapture(func(k string, v interface{}) {
+		captured = append(captured, k)
+	}))
+
+	cache.MSet(map[string]interface{}{"expiring": 1}, 10*time.Millisecond)
+	cache.Set("permanent", 2, 0)
+	time.Sleep(20 * time.Millisecond)
+
+	ret := cache.MGet([]string{"expiring", "permanent"})
+	if len(ret) != 1 || ret["permanent"] != 2 {
+		t.Errorf("Expected only permanent key, got %v", ret)
+	}
+	// 过期项被删除并回调 capture
+	if cache.Count() != 1 || len(captured) != 1 || captured[0] != "expiring" {
+		t.Errorf("Expected expiring key to be deleted, captured %v", captured)
+	}
+}
+
+func TestMDelete(t *testing.T) {
+	var captured []string
+	cache := NewCache(SetCapture(func(k string, v interface{}) {
+		captured = append(captured, k)
+	}))
+
+	cache.MSet(map[string]interface{}{"key1": 1, "key2": 2, "key3": 3}, 0)
+	cache.MDelete([]string{"key1", "key2", "nonexistent"})
+
+	if count := cache.Count(); count != 1 {
+		t.Errorf("Expected count to be 1, got %d", count)
+	}
+	sort.Strings(captured)
+	if len(captured) != 2 || captured[0] != "key1" || captured[1] != "key2" {
+		t.Errorf("Expected capture for key1 and key2, got %v", captured)
+	}
+}
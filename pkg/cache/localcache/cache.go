@@ -1,21 +1,59 @@
 package localcache
 
 import (
-	"encoding/gob"
+	"bufio"
+	"context"
 	"io"
 	"os"
 	"sync"
+	"sync/atomic"
 	"time"
 
+	"github.com/andrewbytecoder/nmq/pkg/concurrency/singleflighter"
 	"github.com/andrewbytecoder/nmq/pkg/options"
+	"github.com/andrewbytecoder/nmq/pkg/ratelimit"
+	"github.com/andrewbytecoder/nmq/pkg/utils/hash"
+	"golang.org/x/sync/singleflight"
 )
 
+// shard 缓存分片，每个分片持有独立的读写锁和数据，降低并发访问时的锁竞争
+type shard struct {
+	sync.RWMutex                     // 读写锁，保护当前分片的数据
+	member       map[string]Iterator // 维护存储kv关系，实际的缓存数据存储
+
+	capacity int              // 分片最大条目数，0表示不限制
+	evictMu  sync.Mutex       // 淘汰策略锁，读操作只持有读锁时也需要更新访问记录
+	policy   EvictionPolicy   // 淘汰策略，未设置容量限制时为nil
+	budget   *byteBudget      // 所有分片共享的内存预算，未设置内存上限时为nil
+	sizes    map[string]int64 // 当前分片中各个条目的估算大小
+	bytes    int64            // 当前分片条目的估算大小之和
+	aof      *aofLog          // 所有分片共享的追加写日志，未开启时为nil
+	persist  *persister       // 所有分片共享的持久化钩子，未配置时为nil
+	sliding  bool             // Set 设置的过期时间是否为滑动过期
+	jitter   float64          // 过期时间的默认抖动比例
+}
+
 // cache 本地缓存结构体，包含缓存数据和相关配置
 type cache struct {
-	sync.RWMutex                                      // 读写锁，保证并发安全
 	defaultExpire time.Duration                       // 默认超时时间
-	member        map[string]Iterator                 // 维护存储kv关系，实际的缓存数据存储
+	shards        []*shard                            // 缓存分片，key 通过 fnv 哈希映射到分片
+	mask          uint64                              // 分片掩码，分片数量为2的幂
 	capture       func(key string, value interface{}) // 删除缓存时回调函数，用于捕获被删除的缓存项
+	newPolicy     func() EvictionPolicy               // 淘汰策略构造函数，每个分片持有独立的策略实例
+	budget        *byteBudget                         // 内存预算，按估算大小限制缓存总量
+	janitor       *janitor                            // 后台清理协程，未设置清理间隔时为nil
+	loadGroup     *singleflight.Group                 // 合并同一个key的并发加载
+	events        *eventHub                           // 缓存事件订阅中心
+	codec         Codec                               // Save 使用的编解码器
+	stats         cacheStats                          // 命中、写入、淘汰等统计
+	aof           atomic.Pointer[aofLog]              // 追加写日志，未开启时为nil
+	aofSync       time.Duration                       // 日志刷盘间隔
+	aofCompact    time.Duration                       // 日志压缩间隔，0表示不自动压缩
+	throttle      *ratelimit.Bucket                   // 后台刷盘和快照写入的限速令牌桶
+	persist       *persister                          // 写入和删除的持久化钩子，未配置时为nil
+	captureSubmit func(task func()) error             // capture 异步执行函数，为nil时同步执行
+	callbacks     *workerPool                         // 内置的回调协程池，未配置时为nil
+	readRepair    int                                 // 读操作时抽查同一分片中过期项的数量，0表示关闭
 }
 
 // Cache 缓存包装结构体，通过嵌入cache提供缓存功能
@@ -27,1015 +65,532 @@ type Cache struct {
 func NewCache(options ...options.Option) Cache {
 	config := NewConfig(options...) // 使用选项模式创建配置
 
-	if config.member == nil {
-		config.member = make(map[string]Iterator) // 初始化成员映射
+	n := shardCount(config.shards)
+	// 限制最大条目数时分片数量不能超过条目数，保证每个分片至少能存放一个条目
+	for config.maxEntries > 0 && n > config.maxEntries {
+		n >>= 1
 	}
 
 	obj := &cache{
-		member:  config.member,  // 设置成员映射
-		capture: config.capture, // 设置捕获函数
+		shards:        make([]*shard, n),
+		mask:          uint64(n - 1),
+		capture:       config.capture, // 设置捕获函数
+		loadGroup:     singleflighter.NewSingleFlight(),
+		events:        newEventHub(config.eventSubmit),
+		captureSubmit: config.captureSubmit,
+		readRepair:    config.readRepair,
+		codec:         config.codec,
+		aofSync:       config.aofSyncInterval,
+		aofCompact:    config.aofCompactInterval,
+		throttle:      ratelimit.NewBucket(config.writeLimit, 0),
+	}
+	// 内置协程池只用于没有单独指定提交函数的回调
+	if config.callbackWorkers > 0 {
+		obj.callbacks = newWorkerPool(config.callbackWorkers, config.callbackQueue)
+		if obj.captureSubmit == nil {
+			obj.captureSubmit = obj.callbacks.submit
+		}
+		if obj.events.submit == nil {
+			obj.events.submit = obj.callbacks.submit
+		}
+	}
+	if config.persist.fn != nil {
+		obj.persist = newPersister(config.persist)
+	}
+	if config.maxBytes > 0 {
+		obj.budget = newByteBudget(config.maxBytes, config.sizeEstimator)
+	}
+	if config.maxEntries > 0 || obj.budget != nil {
+		obj.newPolicy = config.newPolicy
+		if obj.newPolicy == nil {
+			obj.newPolicy = NewLRUPolicy
+		}
+	}
+	for i := range obj.shards {
+		s := &shard{member: make(map[string]Iterator), sliding: config.sliding, jitter: config.jitter} // 初始化成员映射
+		if config.maxEntries > 0 {
+			// 将最大条目数均分到各个分片，余数分给前面的分片
+			s.capacity = config.maxEntries / n
+			if i < config.maxEntries%n {
+				s.capacity++
+			}
+		}
+		if obj.budget != nil {
+			s.budget = obj.budget
+			s.sizes = make(map[string]int64)
+		}
+		if obj.newPolicy != nil {
+			s.policy = obj.newPolicy()
+		}
+		obj.shards[i] = s
 	}
-
-	return Cache{
-		cache: obj, // 返回包装后的缓存实例
+	// 将初始化成员分配到对应分片，初始化成员不需要持久化
+	for k, v := range config.member {
+		obj.getShard(k).put(k, v)
+	}
+	for _, s := range obj.shards {
+		s.persist = obj.persist
 	}
-}
 
-// Set 设置缓存项，无论是否存在都会覆盖
-func (c *cache) Set(k string, v interface{}, d time.Duration) {
-	var expire int64 // 过期时间戳
+	if config.warmupFile != "" {
+		stats, err := obj.WarmupFile(config.warmupFile)
+		if os.IsNotExist(err) {
+			err = nil
+		}
+		if config.warmupReport != nil {
+			config.warmupReport(stats, err)
+		}
+	}
 
-	if d > 0 {
-		expire = time.Now().Add(d).UnixNano()
+	// 启动后台清理协程，需要调用 Shutdown 停止
+	if config.cleanupInterval > 0 {
+		runJanitor(obj, config.cleanupInterval)
 	}
 
-	c.Lock() // 加写锁
-	c.member[k] = Iterator{
-		Val:    v,      // 缓存值
-		Expire: expire, // 过期时间
+	return Cache{
+		cache: obj, // 返回包装后的缓存实例
 	}
-	c.Unlock() // 释放写锁
 }
 
-// set 添加cache 无论是否存在都会覆盖 内部无锁版本
-func (c *cache) set(k string, v interface{}, d time.Duration) {
-	var expire int64
-	if d > 0 {
-		expire = time.Now().Add(d).UnixNano()
+// shardCount 将分片数量向上取整为2的幂，小于1时使用默认值
+func shardCount(n int) int {
+	if n < 1 {
+		n = defaultShards
 	}
-	c.member[k] = Iterator{
-		Val:    v,
-		Expire: expire,
+	count := 1
+	for count < n {
+		count <<= 1
 	}
+	return count
 }
 
-// SetDefault 添加cache 无论是否存在都会覆盖 超时设置为创建cache的默认时间
-func (c *cache) SetDefault(k string, v interface{}) {
-	c.Set(k, v, 0)
+// getShard 根据key的哈希值获取对应的分片
+func (c *cache) getShard(k string) *shard {
+	return c.shards[hash.Fnv64a(k)&c.mask]
 }
 
-// SetNoExpire 添加cache 无论是否存在都会覆盖 超时设置为0(永不过期)
-func (c *cache) SetNoExpire(k string, v interface{}) {
-	c.Set(k, v, 0)
-}
-
-// Get 根据key获取 cache
-func (c *cache) Get(k string) (interface{}, bool) {
-	c.RLock() // 加读锁
-	if v, ok := c.member[k]; !ok {
-		c.RUnlock()
-		return nil, false
-	} else {
-		if v.Expired() { // 检查是否过期
-			c.RUnlock()
-			c.Delete(k) // 删除过期项
-			return nil, false
-		} else {
-			c.RUnlock()
-			return v.Val, true // 返回缓存值
-		}
+// lockAll 对所有分片加写锁
+func (c *cache) lockAll() {
+	for _, s := range c.shards {
+		s.Lock()
 	}
 }
 
-// get 根据key获取 cache 内部无锁版本
-func (c *cache) get(k string) (interface{}, bool) {
-	if v, ok := c.member[k]; !ok {
-		return nil, false
-	} else {
-		if v.Expired() {
-			c._delete(k) // 内部删除方法
-			return nil, false
-		}
-		c._delete(k)
-		return v.Val, true
+// unlockAll 释放所有分片的写锁
+func (c *cache) unlockAll() {
+	for _, s := range c.shards {
+		s.Unlock()
 	}
 }
 
-// GetWithExpire 根据key获取 cache 并带出过期时间
-func (c *cache) GetWithExpire(k string) (interface{}, time.Time, bool) {
-	c.RLock()
-	if v, ok := c.member[k]; !ok {
-		c.RUnlock()
-		return nil, time.Time{}, false
-	} else {
-		if v.Expired() {
-			c.RUnlock()
-			c.Delete(k)
-			return nil, time.Time{}, false
-		}
-		c.RUnlock()
-		if v.Expire > 0 {
-			return v.Val, time.Unix(0, v.Expire), true // 返回值和过期时间
-		}
-		return v.Val, time.Time{}, true
-	}
+// Set 设置缓存项，无论是否存在都会覆盖
+func (c *cache) Set(k string, v interface{}, d time.Duration) {
+	s := c.getShard(k)
+	s.Lock() // 加写锁
+	evicted := s.set(k, v, d)
+	capture := c.capture
+	s.Unlock() // 释放写锁
+	c.dispatch(capture, EventEvict, evicted)
+	c.publish(EventSet, []kv{{k, v}})
 }
 
-// Add 添加cache 如果存在则抛出异常
-func (c *cache) Add(k string, x interface{}, d time.Duration) error {
-	c.Lock()
-	if _, ok := c.get(k); ok { // 检查key是否已存在
-		c.Unlock()
-		return CacheExist
-	}
-	c.set(k, x, d) // 设置新值
-	c.Unlock()
-	return nil
+// set 添加cache 无论是否存在都会覆盖 内部无锁版本，过期时间按分片默认的抖动比例打散
+func (s *shard) set(k string, v interface{}, d time.Duration) []kv {
+	return s.setJitter(k, v, d, s.jitter)
 }
 
-// Replace 替换cache 如果有就设置没有就抛出异常
-func (c *cache) Replace(k string, x interface{}, d time.Duration) error {
-	c.Lock()
-	if _, ok := c.get(k); !ok { // 检查key是否存在
-		c.Unlock()
-		return CacheNoExist
-	}
-	c.set(k, x, d) // 替换值
-	c.Unlock()
-	return nil
+// setJitter 按抖动比例 f 打散过期时间后写入 内部无锁版本
+func (s *shard) setJitter(k string, v interface{}, d time.Duration, f float64) []kv {
+	return s.write(k, s.newIterator(v, d, f))
 }
 
-// Increment 为k对应的value增加n n必须为数字类型
-func (c *cache) Increment(k string, n int64) error {
-	c.Lock()
-	if v, ok := c.member[k]; !ok {
-		c.Unlock()
-		return CacheNoExist
-	} else {
-		if v.Expired() {
-			c.Unlock()
-			c.Delete(k)
-			return CacheExpire
-		}
-		// 根据不同的数值类型进行相应的增加操作
-		switch v.Val.(type) {
-		case int:
-			v.Val = v.Val.(int) + int(n)
-		case int8:
-			v.Val = v.Val.(int8) + int8(n)
-		case int16:
-			v.Val = v.Val.(int16) + int16(n)
-		case int32:
-			v.Val = v.Val.(int32) + int32(n)
-		case int64:
-			v.Val = v.Val.(int64) + n
-		case uint:
-			v.Val = v.Val.(uint) + uint(n)
-		case uintptr:
-			v.Val = v.Val.(uintptr) + uintptr(n)
-		case uint8:
-			v.Val = v.Val.(uint8) + uint8(n)
-		case uint16:
-			v.Val = v.Val.(uint16) + uint16(n)
-		case uint32:
-			v.Val = v.Val.(uint32) + uint32(n)
-		case uint64:
-			v.Val = v.Val.(uint64) + uint64(n)
-		case float32:
-			v.Val = v.Val.(float32) + float32(n)
-		case float64:
-			v.Val = v.Val.(float64) + float64(n)
-		default:
-			c.Unlock()
-			return CacheTypeErr
+// newIterator 按抖动比例 f 打散过期时间，并按分片配置决定是否为滑动过期，生成待写入的缓存项
+func (s *shard) newIterator(v interface{}, d time.Duration, f float64) Iterator {
+	var expire, sliding int64 // 过期时间戳和滑动过期时长
+	if d > 0 {
+		d = jitter(d, f)
+		expire = time.Now().Add(d).UnixNano()
+		if s.sliding {
+			sliding = int64(d)
 		}
-		c.member[k] = v
-		c.Unlock()
-		return nil
 	}
-}
-
-// IncrementFloat 为k对应的value增加n n必须为浮点数类型
-func (c *cache) IncrementFloat(k string, n float64) error {
-	c.Lock()
-	if v, ok := c.member[k]; !ok {
-		c.Unlock()
-		return CacheNoExist
-	} else {
-		if v.Expired() {
-			c.Unlock()
-			c.Delete(k)
-			return CacheExpire
-		}
-		// 根据不同的浮点数类型进行相应的增加操作
-		switch v.Val.(type) {
-		case float32:
-			v.Val = v.Val.(float32) + float32(n)
-		case float64:
-			v.Val = v.Val.(float64) + n
-		default:
-			c.Unlock()
-			return CacheTypeErr
-		}
-		c.member[k] = v
-		c.Unlock()
-		return nil
+	return Iterator{
+		Val:     v,       // 缓存值
+		Expire:  expire,  // 过期时间
+		Sliding: sliding, // 滑动过期时长
 	}
 }
 
-// IncrementInt 为k对应的value增加n n必须为int类型
-func (c *cache) IncrementInt(k string, n int) (int, error) {
-	c.Lock()
-	if v, ok := c.member[k]; !ok {
-		c.Unlock()
-		return 0, CacheNoExist
-	} else {
-		if v.Expired() {
-			c.Unlock()
-			c.Delete(k)
-			return 0, CacheExpire
-		}
-		if i, ok := v.Val.(int); !ok {
-			c.Unlock()
-			return 0, CacheTypeErr
-		} else {
-			ret := i + n
-			v.Val = ret
-			c.member[k] = v
-			c.Unlock()
-			return ret, nil
+// put 写入缓存项并维护淘汰策略，超出容量或内存预算时先淘汰旧的缓存项 内部无锁版本
+//
+// 返回被淘汰的缓存项，由调用方在释放锁之后交给 capture 处理
+func (s *shard) put(k string, it Iterator) []kv {
+	evicted := s.store(k, it)
+	if s.aof != nil {
+		s.logSet(k, it)
+		for _, v := range evicted {
+			s.logDelete(v.key)
 		}
 	}
+	return evicted
 }
 
-// IncrementInt8 为k对应的value增加n n必须为int8类型
-func (c *cache) IncrementInt8(k string, n int8) (int8, error) {
-	c.Lock()
-	if v, ok := c.member[k]; !ok {
-		c.Unlock()
-		return 0, CacheNoExist
-	} else {
-		if v.Expired() {
-			c.Unlock()
-			c.Delete(k)
-			return 0, CacheExpire
-		}
-		if i, ok := v.Val.(int8); !ok {
-			c.Unlock()
-			return 0, CacheTypeErr
-		} else {
-			ret := i + n
-			v.Val = ret
-			c.member[k] = v
-			c.Unlock()
-			return ret, nil
-		}
-	}
+// write 写入缓存项并加入持久化队列，用于 Set 等用户写入，加载快照和回放日志只调用 put 内部无锁版本
+func (s *shard) write(k string, it Iterator) []kv {
+	evicted := s.put(k, it)
+	s.persistSet(k, it)
+	return evicted
 }
 
-// IncrementInt16 为k对应的value增加n n必须为int16类型
-func (c *cache) IncrementInt16(k string, n int16) (int16, error) {
-	c.Lock()
-	if v, ok := c.member[k]; !ok {
-		c.Unlock()
-		return 0, CacheNoExist
-	} else {
-		if v.Expired() {
-			c.Unlock()
-			c.Delete(k)
-			return 0, CacheExpire
-		}
-		if i, ok := v.Val.(int16); !ok {
-			c.Unlock()
-			return 0, CacheTypeErr
-		} else {
-			ret := i + n
-			v.Val = ret
-			c.member[k] = v
-			c.Unlock()
-			return ret, nil
-		}
+// store put 的实现，不记录日志
+func (s *shard) store(k string, it Iterator) []kv {
+	if s.policy == nil {
+		s.member[k] = it
+		return nil
 	}
-}
 
-// IncrementInt32 为k对应的value增加n n必须为int32类型
-func (c *cache) IncrementInt32(k string, n int32) (int32, error) {
-	c.Lock()
-	if v, ok := c.member[k]; !ok {
-		c.Unlock()
-		return 0, CacheNoExist
-	} else {
-		if v.Expired() {
-			c.Unlock()
-			c.Delete(k)
-			return 0, CacheExpire
-		}
-		if i, ok := v.Val.(int32); !ok {
-			c.Unlock()
-			return 0, CacheTypeErr
-		} else {
-			ret := i + n
-			v.Val = ret
-			c.member[k] = v
-			c.Unlock()
-			return ret, nil
-		}
-	}
-}
+	s.evictMu.Lock()
+	defer s.evictMu.Unlock()
 
-// IncrementInt64 为k对应的value增加n n必须为int64类型
-func (c *cache) IncrementInt64(k string, n int64) (int64, error) {
-	c.Lock()
-	if v, ok := c.member[k]; !ok {
-		c.Unlock()
-		return 0, CacheNoExist
-	} else {
-		if v.Expired() {
-			c.Unlock()
-			c.Delete(k)
-			return 0, CacheExpire
-		}
-		if i, ok := v.Val.(int64); !ok {
-			c.Unlock()
-			return 0, CacheTypeErr
-		} else {
-			ret := i + n
-			v.Val = ret
-			c.member[k] = v
-			c.Unlock()
-			return ret, nil
+	var size int64
+	if s.budget != nil {
+		size = s.budget.estimate(k, it.Val)
+		// 单个条目超过内存上限，无法存放，直接视为被淘汰
+		if size > s.budget.max {
+			if _, ok := s.member[k]; ok {
+				delete(s.member, k)
+				s.policy.Remove(k)
+				s.release(k)
+			}
+			return []kv{{k, it.Val}}
 		}
 	}
-}
 
-// IncrementUint 为k对应的value增加n n必须为uint类型
-func (c *cache) IncrementUint(k string, n uint) (uint, error) {
-	c.Lock()
-	if v, ok := c.member[k]; !ok {
-		c.Unlock()
-		return 0, CacheNoExist
-	} else {
-		if v.Expired() {
-			c.Unlock()
-			c.Delete(k)
-			return 0, CacheExpire
-		}
-		if i, ok := v.Val.(uint); !ok {
-			c.Unlock()
-			return 0, CacheTypeErr
-		} else {
-			ret := i + n
-			v.Val = ret
-			c.member[k] = v
-			c.Unlock()
-			return ret, nil
-		}
+	if _, ok := s.member[k]; ok {
+		s.member[k] = it
+		s.policy.Access(k)
+		s.account(k, size)
+		return s.evict(k, false, 0)
 	}
-}
 
-// IncrementUint8 为k对应的value增加n n必须为uint8类型
-func (c *cache) IncrementUint8(k string, n uint8) (uint8, error) {
-	c.Lock()
-	if v, ok := c.member[k]; !ok {
-		c.Unlock()
-		return 0, CacheNoExist
-	} else {
-		if v.Expired() {
-			c.Unlock()
-			c.Delete(k)
-			return 0, CacheExpire
-		}
-		if i, ok := v.Val.(uint8); !ok {
-			c.Unlock()
-			return 0, CacheTypeErr
-		} else {
-			ret := i + n
-			v.Val = ret
-			c.member[k] = v
-			c.Unlock()
-			return ret, nil
-		}
-	}
+	evicted := s.evict("", true, size)
+	s.member[k] = it
+	s.policy.Add(k)
+	s.account(k, size)
+	return evicted
 }
 
-// IncrementUint16 为k对应的value增加n n必须为uint16类型
-func (c *cache) IncrementUint16(k string, n uint16) (uint16, error) {
-	c.Lock()
-	if v, ok := c.member[k]; !ok {
-		c.Unlock()
-		return 0, CacheNoExist
-	} else {
-		if v.Expired() {
-			c.Unlock()
-			c.Delete(k)
-			return 0, CacheExpire
+// evict 按淘汰策略删除缓存项，直到可以写入大小为 extra 的条目 内部无锁版本
+//
+// skip 为正在更新的key，不会被淘汰；newKey 表示即将写入新的key，需要预留一个条目的容量
+func (s *shard) evict(skip string, newKey bool, extra int64) []kv {
+	var evicted []kv
+	skipped := false
+	for s.overflow(newKey, extra) {
+		victim, ok := s.policy.Evict()
+		if !ok {
+			break
 		}
-		if i, ok := v.Val.(uint16); !ok {
-			c.Unlock()
-			return 0, CacheTypeErr
-		} else {
-			ret := i + n
-			v.Val = ret
-			c.member[k] = v
-			c.Unlock()
-			return ret, nil
-		}
-	}
-}
-
-// IncrementUint32 为k对应的value增加n n必须为uint32类型
-func (c *cache) IncrementUint32(k string, n uint32) (uint32, error) {
-	c.Lock()
-	if v, ok := c.member[k]; !ok {
-		c.Unlock()
-		return 0, CacheNoExist
-	} else {
-		if v.Expired() {
-			c.Unlock()
-			c.Delete(k)
-			return 0, CacheExpire
+		if victim == skip {
+			skipped = true
+			continue
 		}
-		if i, ok := v.Val.(uint32); !ok {
-			c.Unlock()
-			return 0, CacheTypeErr
-		} else {
-			ret := i + n
-			v.Val = ret
-			c.member[k] = v
-			c.Unlock()
-			return ret, nil
+		if v, ok := s.member[victim]; ok {
+			delete(s.member, victim)
+			s.release(victim)
+			evicted = append(evicted, kv{victim, v.Val})
 		}
 	}
-}
-
-// IncrementUint64 为k对应的value增加n n必须为uint64类型
-func (c *cache) IncrementUint64(k string, n uint64) (uint64, error) {
-	c.Lock()
-	if v, ok := c.member[k]; !ok {
-		c.Unlock()
-		return 0, CacheNoExist
-	} else {
-		if v.Expired() {
-			c.Unlock()
-			c.Delete(k)
-			return 0, CacheExpire
-		}
-		if i, ok := v.Val.(uint64); !ok {
-			c.Unlock()
-			return 0, CacheTypeErr
-		} else {
-			ret := i + n
-			v.Val = ret
-			c.member[k] = v
-			c.Unlock()
-			return ret, nil
-		}
+	if skipped {
+		s.policy.Add(skip)
 	}
+	return evicted
 }
 
-// IncrementUintPtr 为k对应的value增加n n必须为uintptr类型
-func (c *cache) IncrementUintPtr(k string, n uintptr) (uintptr, error) {
-	c.Lock()
-	if v, ok := c.member[k]; !ok {
-		c.Unlock()
-		return 0, CacheNoExist
-	} else {
-		if v.Expired() {
-			c.Unlock()
-			c.Delete(k)
-			return 0, CacheExpire
-		}
-		if i, ok := v.Val.(uintptr); !ok {
-			c.Unlock()
-			return 0, CacheTypeErr
-		} else {
-			ret := i + n
-			v.Val = ret
-			c.member[k] = v
-			c.Unlock()
-			return ret, nil
-		}
+// overflow 判断写入大小为 extra 的条目后是否超出分片容量或内存预算
+//
+// 内存预算由所有分片共享，当前分片没有可淘汰的条目时允许暂时超出，
+// 其他分片后续写入时会继续淘汰，使总量回落到上限以下
+func (s *shard) overflow(newKey bool, extra int64) bool {
+	if newKey && s.capacity > 0 && len(s.member) >= s.capacity {
+		return true
 	}
+	return s.budget != nil && s.budget.used.Load()+extra > s.budget.max
 }
 
-// IncrementFloat32 为k对应的value增加n n必须为float32类型
-func (c *cache) IncrementFloat32(k string, n float32) (float32, error) {
-	c.Lock()
-	if v, ok := c.member[k]; !ok {
-		c.Unlock()
-		return 0, CacheNoExist
-	} else {
-		if v.Expired() {
-			c.Unlock()
-			c.Delete(k)
-			return 0, CacheExpire
-		}
-		if i, ok := v.Val.(float32); !ok {
-			c.Unlock()
-			return 0, CacheTypeErr
-		} else {
-			ret := i + n
-			v.Val = ret
-			c.member[k] = v
-			c.Unlock()
-			return ret, nil
-		}
+// account 记录key的估算大小
+func (s *shard) account(k string, size int64) {
+	if s.budget == nil {
+		return
 	}
+	delta := size - s.sizes[k]
+	s.sizes[k] = size
+	s.bytes += delta
+	s.budget.used.Add(delta)
 }
 
-// IncrementFloat64 为k对应的value增加n n必须为float64类型
-func (c *cache) IncrementFloat64(k string, n float64) (float64, error) {
-	c.Lock()
-	if v, ok := c.member[k]; !ok {
-		c.Unlock()
-		return 0, CacheNoExist
-	} else {
-		if v.Expired() {
-			c.Unlock()
-			c.Delete(k)
-			return 0, CacheExpire
-		}
-		if i, ok := v.Val.(float64); !ok {
-			c.Unlock()
-			return 0, CacheTypeErr
-		} else {
-			ret := i + n
-			v.Val = ret
-			c.member[k] = v
-			c.Unlock()
-			return ret, nil
-		}
+// release 释放key占用的内存预算
+func (s *shard) release(k string) {
+	if s.budget == nil {
+		return
 	}
+	size := s.sizes[k]
+	delete(s.sizes, k)
+	s.bytes -= size
+	s.budget.used.Add(-size)
 }
 
-// Decrement 为k对应的value减少n n必须为数字类型
-func (c *cache) Decrement(k string, n int64) error {
-	c.Lock()
-	if v, ok := c.member[k]; !ok {
-		c.Unlock()
-		return CacheNoExist
-	} else {
-		if v.Expired() {
-			c.Unlock()
-			c.Delete(k)
-			return CacheExpire
-		}
-		// 根据不同的数值类型进行相应的减少操作
-		switch v.Val.(type) {
-		case int:
-			v.Val = v.Val.(int) - int(n)
-		case int8:
-			v.Val = v.Val.(int8) - int8(n)
-		case int16:
-			v.Val = v.Val.(int16) - int16(n)
-		case int32:
-			v.Val = v.Val.(int32) - int32(n)
-		case int64:
-			v.Val = v.Val.(int64) - n
-		case uint:
-			v.Val = v.Val.(uint) - uint(n)
-		case uintptr:
-			v.Val = v.Val.(uintptr) - uintptr(n)
-		case uint8:
-			v.Val = v.Val.(uint8) - uint8(n)
-		case uint16:
-			v.Val = v.Val.(uint16) - uint16(n)
-		case uint32:
-			v.Val = v.Val.(uint32) - uint32(n)
-		case uint64:
-			v.Val = v.Val.(uint64) - uint64(n)
-		case float32:
-			v.Val = v.Val.(float32) - float32(n)
-		case float64:
-			v.Val = v.Val.(float64) - float64(n)
-		default:
-			c.Unlock()
-			return CacheTypeErr
-		}
-		c.member[k] = v
-		c.Unlock()
-		return nil
+// touch 记录一次对key的访问
+func (s *shard) touch(k string) {
+	if s.policy == nil {
+		return
 	}
+	s.evictMu.Lock()
+	s.policy.Access(k)
+	s.evictMu.Unlock()
 }
 
-// DecrementFloat 为k对应的value减少n n必须为浮点数类型
-func (c *cache) DecrementFloat(k string, n float64) error {
-	c.Lock()
-	if v, ok := c.member[k]; !ok {
-		c.Unlock()
-		return CacheNoExist
-	} else {
-		if v.Expired() {
-			c.Unlock()
-			c.Delete(k)
-			return CacheExpire
-		}
-		// 根据不同的浮点数类型进行相应的减少操作
-		switch v.Val.(type) {
-		case float32:
-			v.Val = v.Val.(float32) - float32(n)
-		case float64:
-			v.Val = v.Val.(float64) - n
-		default:
-			c.Unlock()
-			return CacheTypeErr
-		}
-		c.member[k] = v
-		c.Unlock()
-		return nil
+// forget 将key从淘汰策略中移除
+func (s *shard) forget(k string) {
+	if s.policy == nil {
+		return
 	}
+	s.evictMu.Lock()
+	s.policy.Remove(k)
+	s.release(k)
+	s.evictMu.Unlock()
 }
 
-// DecrementInt 为k对应的value减少n n必须为int类型
-func (c *cache) DecrementInt(k string, n int) (int, error) {
-	c.Lock()
-	if v, ok := c.member[k]; !ok {
-		c.Unlock()
-		return 0, CacheNoExist
-	} else {
-		if v.Expired() {
-			c.Unlock()
-			c.Delete(k)
-			return 0, CacheExpire
-		}
-		if i, ok := v.Val.(int); !ok {
-			c.Unlock()
-			return 0, CacheTypeErr
-		} else {
-			ret := i - n
-			v.Val = ret
-			c.member[k] = v
-			c.Unlock()
-			return ret, nil
-		}
+// captureAll 依次调用 capture 处理被删除的缓存项
+func captureAll(capture func(string, interface{}), list []kv) {
+	if capture == nil {
+		return
 	}
-}
-
-// DecrementInt8 为k对应的value减少n n必须为int8类型
-func (c *cache) DecrementInt8(k string, n int8) (int8, error) {
-	c.Lock()
-	if v, ok := c.member[k]; !ok {
-		c.Unlock()
-		return 0, CacheNoExist
-	} else {
-		if v.Expired() {
-			c.Unlock()
-			c.Delete(k)
-			return 0, CacheExpire
-		}
-		if i, ok := v.Val.(int8); !ok {
-			c.Unlock()
-			return 0, CacheTypeErr
-		} else {
-			ret := i - n
-			v.Val = ret
-			c.member[k] = v
-			c.Unlock()
-			return ret, nil
-		}
+	for _, v := range list {
+		capture(v.key, v.value)
 	}
 }
 
-// DecrementInt16 为k对应的value减少n n必须为int16类型
-func (c *cache) DecrementInt16(k string, n int16) (int16, error) {
-	c.Lock()
-	if v, ok := c.member[k]; !ok {
-		c.Unlock()
-		return 0, CacheNoExist
-	} else {
-		if v.Expired() {
-			c.Unlock()
-			c.Delete(k)
-			return 0, CacheExpire
-		}
-		if i, ok := v.Val.(int16); !ok {
-			c.Unlock()
-			return 0, CacheTypeErr
-		} else {
-			ret := i - n
-			v.Val = ret
-			c.member[k] = v
-			c.Unlock()
-			return ret, nil
-		}
-	}
+// SetDefault 添加cache 无论是否存在都会覆盖 超时设置为创建cache的默认时间
+func (c *cache) SetDefault(k string, v interface{}) {
+	c.Set(k, v, 0)
 }
 
-// DecrementInt32 为k对应的value减少n n必须为int32类型
-func (c *cache) DecrementInt32(k string, n int32) (int32, error) {
-	c.Lock()
-	if v, ok := c.member[k]; !ok {
-		c.Unlock()
-		return 0, CacheNoExist
-	} else {
-		if v.Expired() {
-			c.Unlock()
-			c.Delete(k)
-			return 0, CacheExpire
-		}
-		if i, ok := v.Val.(int32); !ok {
-			c.Unlock()
-			return 0, CacheTypeErr
-		} else {
-			ret := i - n
-			v.Val = ret
-			c.member[k] = v
-			c.Unlock()
-			return ret, nil
-		}
-	}
+// SetNoExpire 添加cache 无论是否存在都会覆盖 超时设置为0(永不过期)
+func (c *cache) SetNoExpire(k string, v interface{}) {
+	c.Set(k, v, 0)
 }
 
-// DecrementInt64 为k对应的value减少n n必须为int64类型
-func (c *cache) DecrementInt64(k string, n int64) (int64, error) {
-	c.Lock()
-	if v, ok := c.member[k]; !ok {
-		c.Unlock()
-		return 0, CacheNoExist
+// Get 根据key获取 cache
+func (c *cache) Get(k string) (interface{}, bool) {
+	s := c.getShard(k)
+	defer c.readRepairShard(s)
+	s.RLock() // 加读锁
+	if v, ok := s.member[k]; !ok {
+		s.RUnlock()
+		c.stats.misses.Add(1)
+		return nil, false
 	} else {
-		if v.Expired() {
-			c.Unlock()
-			c.Delete(k)
-			return 0, CacheExpire
-		}
-		if i, ok := v.Val.(int64); !ok {
-			c.Unlock()
-			return 0, CacheTypeErr
+		if v.Expired() { // 检查是否过期
+			s.RUnlock()
+			c.stats.misses.Add(1)
+			c.deleteExpired(k) // 删除过期项
+			return nil, false
 		} else {
-			ret := i - n
-			v.Val = ret
-			c.member[k] = v
-			c.Unlock()
-			return ret, nil
+			s.touch(k)
+			s.RUnlock()
+			c.stats.hits.Add(1)
+			if v.Sliding > 0 {
+				c.slide(k) // 顺延滑动过期时间
+			}
+			return v.Val, true // 返回缓存值
 		}
 	}
 }
 
-// DecrementUint 为k对应的value减少n n必须为uint类型
-func (c *cache) DecrementUint(k string, n uint) (uint, error) {
-	c.Lock()
-	if v, ok := c.member[k]; !ok {
-		c.Unlock()
-		return 0, CacheNoExist
-	} else {
-		if v.Expired() {
-			c.Unlock()
-			c.Delete(k)
-			return 0, CacheExpire
-		}
-		if i, ok := v.Val.(uint); !ok {
-			c.Unlock()
-			return 0, CacheTypeErr
-		} else {
-			ret := i - n
-			v.Val = ret
-			c.member[k] = v
-			c.Unlock()
-			return ret, nil
-		}
+// get 根据key获取 cache 内部无锁版本，调用方需持有分片写锁
+//
+// 命中时不修改缓存；k已过期时将其删除，开启 read-repair 时同时删除抽查到的其他过期项，
+// 被删除的过期项通过第三个返回值交给调用方，在释放锁之后派发 EventExpire
+func (c *cache) get(s *shard, k string) (interface{}, bool, []kv) {
+	now := time.Now().UnixNano()
+	var expired []kv
+	if c.readRepair > 0 {
+		expired = c.repair(s, now)
 	}
-}
-
-// DecrementUint8 为k对应的value减少n n必须为uint8类型
-func (c *cache) DecrementUint8(k string, n uint8) (uint8, error) {
-	c.Lock()
-	if v, ok := c.member[k]; !ok {
-		c.Unlock()
-		return 0, CacheNoExist
-	} else {
-		if v.Expired() {
-			c.Unlock()
-			c.Delete(k)
-			return 0, CacheExpire
-		}
-		if i, ok := v.Val.(uint8); !ok {
-			c.Unlock()
-			return 0, CacheTypeErr
-		} else {
-			ret := i - n
-			v.Val = ret
-			c.member[k] = v
-			c.Unlock()
-			return ret, nil
-		}
+	v, ok := s.member[k]
+	if !ok {
+		return nil, false, expired
 	}
-}
-
-// DecrementUint16 为k对应的value减少n n必须为uint16类型
-func (c *cache) DecrementUint16(k string, n uint16) (uint16, error) {
-	c.Lock()
-	if v, ok := c.member[k]; !ok {
-		c.Unlock()
-		return 0, CacheNoExist
-	} else {
-		if v.Expired() {
-			c.Unlock()
-			c.Delete(k)
-			return 0, CacheExpire
-		}
-		if i, ok := v.Val.(uint16); !ok {
-			c.Unlock()
-			return 0, CacheTypeErr
-		} else {
-			ret := i - n
-			v.Val = ret
-			c.member[k] = v
-			c.Unlock()
-			return ret, nil
-		}
+	if v.Expired(now) {
+		val, _ := c.delete(s, k)
+		return nil, false, append(expired, kv{k, val})
 	}
+	return v.Val, true, expired
 }
 
-// DecrementUint32 为k对应的value减少n n必须为uint32类型
-func (c *cache) DecrementUint32(k string, n uint32) (uint32, error) {
-	c.Lock()
-	if v, ok := c.member[k]; !ok {
-		c.Unlock()
-		return 0, CacheNoExist
-	} else {
-		if v.Expired() {
-			c.Unlock()
-			c.Delete(k)
-			return 0, CacheExpire
-		}
-		if i, ok := v.Val.(uint32); !ok {
-			c.Unlock()
-			return 0, CacheTypeErr
-		} else {
-			ret := i - n
-			v.Val = ret
-			c.member[k] = v
-			c.Unlock()
-			return ret, nil
-		}
-	}
-}
-
-// DecrementUint64 为k对应的value减少n n必须为uint64类型
-func (c *cache) DecrementUint64(k string, n uint64) (uint64, error) {
-	c.Lock()
-	if v, ok := c.member[k]; !ok {
-		c.Unlock()
-		return 0, CacheNoExist
+// GetWithExpire 根据key获取 cache 并带出过期时间
+func (c *cache) GetWithExpire(k string) (interface{}, time.Time, bool) {
+	s := c.getShard(k)
+	defer c.readRepairShard(s)
+	s.RLock()
+	if v, ok := s.member[k]; !ok {
+		s.RUnlock()
+		c.stats.misses.Add(1)
+		return nil, time.Time{}, false
 	} else {
 		if v.Expired() {
-			c.Unlock()
-			c.Delete(k)
-			return 0, CacheExpire
-		}
-		if i, ok := v.Val.(uint64); !ok {
-			c.Unlock()
-			return 0, CacheTypeErr
-		} else {
-			ret := i - n
-			v.Val = ret
-			c.member[k] = v
-			c.Unlock()
-			return ret, nil
+			s.RUnlock()
+			c.stats.misses.Add(1)
+			c.deleteExpired(k)
+			return nil, time.Time{}, false
 		}
-	}
-}
-
-// DecrementUintPtr 为k对应的value减少n n必须为uintptr类型
-func (c *cache) DecrementUintPtr(k string, n uintptr) (uintptr, error) {
-	c.Lock()
-	if v, ok := c.member[k]; !ok {
-		c.Unlock()
-		return 0, CacheNoExist
-	} else {
-		if v.Expired() {
-			c.Unlock()
-			c.Delete(k)
-			return 0, CacheExpire
+		s.touch(k)
+		s.RUnlock()
+		c.stats.hits.Add(1)
+		if v.Sliding > 0 {
+			if expire, ok := c.slide(k); ok {
+				v.Expire = expire
+			}
 		}
-		if i, ok := v.Val.(uintptr); !ok {
-			c.Unlock()
-			return 0, CacheTypeErr
-		} else {
-			ret := i - n
-			v.Val = ret
-			c.member[k] = v
-			c.Unlock()
-			return ret, nil
+		if v.Expire > 0 {
+			return v.Val, time.Unix(0, v.Expire), true // 返回值和过期时间
 		}
+		return v.Val, time.Time{}, true
 	}
 }
 
-// DecrementFloat32 为k对应的value减少n n必须为float32类型
-func (c *cache) DecrementFloat32(k string, n float32) (float32, error) {
-	c.Lock()
-	if v, ok := c.member[k]; !ok {
-		c.Unlock()
-		return 0, CacheNoExist
-	} else {
-		if v.Expired() {
-			c.Unlock()
-			c.Delete(k)
-			return 0, CacheExpire
-		}
-		if i, ok := v.Val.(float32); !ok {
-			c.Unlock()
-			return 0, CacheTypeErr
-		} else {
-			ret := i - n
-			v.Val = ret
-			c.member[k] = v
-			c.Unlock()
-			return ret, nil
-		}
+// Add 添加cache 如果存在则抛出异常
+func (c *cache) Add(k string, x interface{}, d time.Duration) error {
+	s := c.getShard(k)
+	s.Lock()
+	_, ok, expired := c.get(s, k)
+	capture := c.capture
+	if ok { // 检查key是否已存在
+		s.Unlock()
+		c.dispatch(capture, EventExpire, expired)
+		return CacheExist
 	}
+	evicted := s.set(k, x, d) // 设置新值
+	s.Unlock()
+	c.dispatch(capture, EventExpire, expired)
+	c.dispatch(capture, EventEvict, evicted)
+	c.publish(EventSet, []kv{{k, x}})
+	return nil
 }
 
-// DecrementFloat64 为k对应的value减少n n必须为float64类型
-func (c *cache) DecrementFloat64(k string, n float64) (float64, error) {
-	c.Lock()
-	if v, ok := c.member[k]; !ok {
-		c.Unlock()
-		return 0, CacheNoExist
-	} else {
-		if v.Expired() {
-			c.Unlock()
-			c.Delete(k)
-			return 0, CacheExpire
-		}
-		if i, ok := v.Val.(float64); !ok {
-			c.Unlock()
-			return 0, CacheTypeErr
-		} else {
-			ret := i - n
-			v.Val = ret
-			c.member[k] = v
-			c.Unlock()
-			return ret, nil
-		}
+// Replace 替换cache 如果有就设置没有就抛出异常
+func (c *cache) Replace(k string, x interface{}, d time.Duration) error {
+	s := c.getShard(k)
+	s.Lock()
+	_, ok, expired := c.get(s, k)
+	capture := c.capture
+	if !ok { // 检查key是否存在
+		s.Unlock()
+		c.dispatch(capture, EventExpire, expired)
+		return CacheNoExist
 	}
+	evicted := s.set(k, x, d) // 替换值
+	s.Unlock()
+	c.dispatch(capture, EventExpire, expired)
+	c.dispatch(capture, EventEvict, evicted)
+	c.publish(EventSet, []kv{{k, x}})
+	return nil
 }
 
 // Delete 删除k的cache 如果 capture != nil 会调用 capture 函数 将 kv传入
 func (c *cache) Delete(k string) {
-	c.Lock()
-	v, ok := c.delete(k) // 调用内部删除方法
-	c.Unlock()
+	s := c.getShard(k)
+	s.Lock()
+	v, ok := c.delete(s, k) // 调用内部删除方法
+	capture := c.capture
+	s.Unlock()
 	if ok {
-		c.capture(k, v) // 调用捕获函数
+		c.dispatch(capture, EventDelete, []kv{{k, v}})
 	}
 }
 
-// _delete 内部删除方法
-func (c *cache) _delete(k string) {
-	v, ok := c.delete(k)
-	if ok {
-		c.capture(k, v)
+// deleteExpired 删除已经过期的k，加锁后再次检查，避免误删并发写入的新值
+func (c *cache) deleteExpired(k string) {
+	s := c.getShard(k)
+	s.Lock()
+	v, ok := s.member[k]
+	if !ok || !v.Expired() {
+		s.Unlock()
+		return
 	}
+	val, _ := c.delete(s, k)
+	capture := c.capture
+	s.Unlock()
+	c.dispatch(capture, EventExpire, []kv{{k, val}})
 }
 
-// delete 删除k的cache 如果具有 capture != nil 则会携带v返回
-func (c *cache) delete(k string) (interface{}, bool) {
-	if c.capture != nil {
-		if v, ok := c.member[k]; ok {
-			delete(c.member, k)
-			return v.Val, true
-		}
+// delete 删除k的cache 如果k存在则会携带v返回
+func (c *cache) delete(s *shard, k string) (interface{}, bool) {
+	v, ok := s.remove(k)
+	if !ok {
+		return nil, false
+	}
+	s.logDelete(k)
+	s.persistDelete(k, v.Val)
+	return v.Val, true
+}
+
+// remove 从分片中删除k，不记录日志也不持久化 内部无锁版本
+func (s *shard) remove(k string) (Iterator, bool) {
+	v, ok := s.member[k]
+	if !ok {
+		return v, false
 	}
-	delete(c.member, k)
-	return nil, false
+	s.forget(k)
+	delete(s.member, k)
+	return v, true
 }
 
 // DeleteExpire 删除已经过期的kv
 func (c *cache) DeleteExpire() {
 	var kvList []kv
-	if c.capture != nil {
-		kvList = make([]kv, 0, len(c.member)/4)
-	}
-	c.Lock()
 	t := time.Now().UnixNano()
-	// 遍历所有缓存项，删除过期的
-	for k, v := range c.member {
-		if v.Expired(t) {
-			if vv, ok := c.delete(k); ok && c.capture != nil {
-				kvList = append(kvList, kv{k, vv})
+	// 逐个分片遍历所有缓存项，删除过期的
+	for _, s := range c.shards {
+		s.Lock()
+		for k, v := range s.member {
+			if v.Expired(t) {
+				if vv, ok := c.delete(s, k); ok {
+					kvList = append(kvList, kv{k, vv})
+				}
 			}
 		}
+		s.Unlock()
 	}
-	c.Unlock()
 	// 调用捕获函数处理被删除的项
-	for _, v := range kvList {
-		c.capture(v.key, v.value)
-	}
+	c.dispatch(c.capture, EventExpire, kvList)
 }
 
 // ChangeCapture 替换cache中capture的处理函数
 func (c *cache) ChangeCapture(f func(string, interface{})) {
-	c.Lock()
+	c.lockAll()
 	c.capture = f
-	c.Unlock()
+	c.unlockAll()
 }
 
 // Save 将 c.member 写入到 w 中
-func (c *cache) Save(w io.Writer) (err error) {
-	enc := gob.NewEncoder(w)
-	defer func() {
-		if e := recover(); e != nil {
-			err = CacheGobErr
+func (c *cache) Save(w io.Writer) error {
+	c.lockAll()
+	now := time.Now()
+	// 合并所有分片
+	member := make(map[string]Iterator)
+	for _, s := range c.shards {
+		for k, iterator := range s.member {
+			member[k] = iterator
 		}
-	}()
-	c.Lock()
-	defer c.Unlock()
-	// 注册所有缓存值的类型，以便gob编码
-	for _, iterator := range c.member {
-		gob.Register(iterator.Val)
 	}
-	return enc.Encode(&c.member)
+	c.unlockAll()
+
+	if err := writeHeader(w, c.codec, now); err != nil {
+		return err
+	}
+	return c.codec.Encode(w, member)
 }
 
 // SaveFile 将 c.member 保存到 path 中
@@ -1045,28 +600,49 @@ func (c *cache) SaveFile(path string) error {
 		return err
 	}
 	defer f.Close()
-	return c.Save(f)
+	return c.Save(ratelimit.NewWriter(context.Background(), f, c.throttle))
+}
+
+// SetWriteLimit 运行时调整后台持久化的磁盘写入限速，单位字节/秒，0表示不限速
+func (c *cache) SetWriteLimit(bytesPerSec int) {
+	c.throttle.SetRate(bytesPerSec, 0)
 }
 
 // Load 从r 中加载 c.member
 func (c *cache) Load(r io.Reader) error {
-	dec := gob.NewDecoder(r)
-	member := map[string]Iterator{}
-	if err := dec.Decode(&member); err != nil {
+	member, _, err := decodeFile(r)
+	if err != nil {
 		return err
 	} else {
-		c.Lock()
 		// 只加载不存在或已过期的项
+		var evicted []kv
 		for k, iterator := range member {
-			if v, ok := c.member[k]; !ok || v.Expired() {
-				c.member[k] = iterator
+			s := c.getShard(k)
+			s.Lock()
+			if v, ok := s.member[k]; !ok || v.Expired() {
+				evicted = append(evicted, s.put(k, iterator)...)
 			}
+			s.Unlock()
 		}
-		c.Unlock()
+		c.dispatch(c.capture, EventEvict, evicted)
 	}
 	return nil
 }
 
+// decodeFile 读取文件头、解码缓存项并迁移到当前版本
+func decodeFile(r io.Reader) (map[string]Iterator, fileHeader, error) {
+	br := bufio.NewReader(r)
+	h, err := readHeader(br)
+	if err != nil {
+		return nil, h, err
+	}
+	member, err := h.codec.Decode(br)
+	if err == nil {
+		member, err = migrate(member, h.version)
+	}
+	return member, h, err
+}
+
 // LoadFile 从 path 中加载 c.member
 func (c *cache) LoadFile(path string) error {
 	f, err := os.Open(path)
@@ -1079,42 +655,82 @@ func (c *cache) LoadFile(path string) error {
 
 // Iterator 返回 cache 中所有有效的对象
 func (c *cache) Iterator() map[string]Iterator {
-	c.RLock()
-
-	ret := make(map[string]Iterator, len(c.member))
+	ret := make(map[string]Iterator, c.Count())
 	keys := make([]string, 0, 10)
 	// 筛选出未过期的项
-	for k, v := range c.member {
-		if !v.Expired() {
-			ret[k] = v
-		} else {
-			keys = append(keys, k)
+	for _, s := range c.shards {
+		s.RLock()
+		for k, v := range s.member {
+			if !v.Expired() {
+				ret[k] = v
+			} else {
+				keys = append(keys, k)
+			}
 		}
+		s.RUnlock()
 	}
-	c.RUnlock()
 	// 清除过期key
 	for _, key := range keys {
-		c.Delete(key)
+		c.deleteExpired(key)
 	}
 	return ret
 }
 
 // Count 计算现在 member 中 kv的数量 (所有)
 func (c *cache) Count() int {
-	c.RLock()
-	defer c.RUnlock()
-	return len(c.member)
+	count := 0
+	for _, s := range c.shards {
+		s.RLock()
+		count += len(s.member)
+		s.RUnlock()
+	}
+	return count
 }
 
 // Flush 释放member成员
 func (c *cache) Flush() {
-	c.Lock()
-	defer c.Unlock()
-	c.member = make(map[string]Iterator)
+	c.lockAll()
+	defer c.unlockAll()
+	if a := c.aof.Load(); a != nil {
+		a.append(aofRecord{Op: aofFlush})
+	}
+	for _, s := range c.shards {
+		s.member = make(map[string]Iterator)
+		if s.policy != nil {
+			s.policy = c.newPolicy()
+		}
+		if s.budget != nil {
+			s.budget.used.Add(-s.bytes)
+			s.sizes = make(map[string]int64)
+			s.bytes = 0
+		}
+	}
 }
 
-// Shutdown 关闭缓存，释放资源
+// Shutdown 关闭缓存，停止后台清理协程并释放资源
 func (c *cache) Shutdown() error {
+	if c.janitor != nil {
+		c.janitor.close()
+	}
+	// 写入 write-behind 队列中剩余的变更
+	if c.persist != nil {
+		c.persist.close()
+	}
+	// 先摘下并关闭日志，避免清空缓存被记录到日志中
+	var err error
+	c.lockAll()
+	a := c.aof.Swap(nil)
+	for _, s := range c.shards {
+		s.aof = nil
+	}
+	c.unlockAll()
+	if a != nil {
+		err = a.close()
+	}
 	c.Flush()
-	return nil
+	// 等待已提交的回调执行完毕
+	if c.callbacks != nil {
+		c.callbacks.close()
+	}
+	return err
 }
@@ -6,6 +6,7 @@ import (
 	"errors"
 	"io/ioutil"
 	"os"
+	"strconv"
 	"sync"
 	"testing"
 	"time"
@@ -18,8 +19,14 @@ func TestNewCache(t *testing.T) {
 		t.Error("Expected cache to be initialized")
 	}
 
-	if cache.member == nil {
-		t.Error("Expected member map to be initialized")
+	if len(cache.shards) != defaultShards {
+		t.Errorf("Expected %d shards, got %d", defaultShards, len(cache.shards))
+	}
+
+	for _, s := range cache.shards {
+		if s.member == nil {
+			t.Fatal("Expected member map to be initialized")
+		}
 	}
 
 	if cache.capture == nil {
@@ -122,7 +129,7 @@ func TestIncrementDecrement(t *testing.T) {
 	cache := NewCache()
 
 	// 测试对不存在的键进行增量操作
-	if err := cache.Increment("nonexistent", 1); !errors.Is(err, CacheNoExist) {
+	if _, err := cache.Increment("nonexistent", 1); !errors.Is(err, CacheNoExist) {
 		t.Errorf("Expected CacheNoExist error, got %v", err)
 	}
 
@@ -132,8 +139,10 @@ func TestIncrementDecrement(t *testing.T) {
 	cache.Set(key, initialValue, 0)
 
 	// 测试增量操作
-	if err := cache.Increment(key, 5); err != nil {
+	if v, err := cache.Increment(key, 5); err != nil {
 		t.Errorf("Expected no error when incrementing, got %v", err)
+	} else if v != 15 {
+		t.Errorf("Expected Increment to return 15, got %v", v)
 	}
 
 	// 验证结果
@@ -144,8 +153,10 @@ func TestIncrementDecrement(t *testing.T) {
 	}
 
 	// 测试减量操作
-	if err := cache.Decrement(key, 3); err != nil {
+	if v, err := cache.Decrement(key, 3); err != nil {
 		t.Errorf("Expected no error when decrementing, got %v", err)
+	} else if v != 12 {
+		t.Errorf("Expected Decrement to return 12, got %v", v)
 	}
 
 	// 验证结果
@@ -160,7 +171,7 @@ func TestIncrementDecrementFloat(t *testing.T) {
 	cache := NewCache()
 
 	// 测试对不存在的键进行浮点增量操作
-	if err := cache.IncrementFloat("nonexistent", 1.5); !errors.Is(err, CacheNoExist) {
+	if _, err := cache.IncrementFloat("nonexistent", 1.5); !errors.Is(err, CacheNoExist) {
 		t.Errorf("Expected CacheNoExist error, got %v", err)
 	}
 
@@ -170,7 +181,7 @@ func TestIncrementDecrementFloat(t *testing.T) {
 	cache.Set(key, initialValue, 0)
 
 	// 测试浮点增量操作
-	if err := cache.IncrementFloat(key, 2.3); err != nil {
+	if _, err := cache.IncrementFloat(key, 2.3); err != nil {
 		t.Errorf("Expected no error when incrementing float, got %v", err)
 	}
 
@@ -182,7 +193,7 @@ func TestIncrementDecrementFloat(t *testing.T) {
 	}
 
 	// 测试浮点减量操作
-	if err := cache.DecrementFloat(key, 1.1); err != nil {
+	if _, err := cache.DecrementFloat(key, 1.1); err != nil {
 		t.Errorf("Expected no error when decrementing float, got %v", err)
 	}
 
@@ -463,3 +474,57 @@ func TestGetWithExpire(t *testing.T) {
 		}
 	}
 }
+
+func TestShards(t *testing.T) {
+	// 测试分片数量向上取整为2的幂
+	tests := []struct {
+		shards   int
+		expected int
+	}{
+		{0, defaultShards},
+		{1, 1},
+		{3, 4},
+		{16, 16},
+		{100, 128},
+	}
+
+	for _, tt := range tests {
+		cache := NewCache(SetShards(tt.shards))
+		if len(cache.shards) != tt.expected {
+			t.Errorf("SetShards(%d): expected %d shards, got %d", tt.shards, tt.expected, len(cache.shards))
+		}
+	}
+
+	// 初始化成员会被分配到各自的分片中
+	cache := NewCache(SetShards(8), SetMember(map[string]Iterator{
+		"key1": {Val: "value1"},
+		"key2": {Val: "value2"},
+		"key3": {Val: "value3"},
+	}))
+	if count := cache.Count(); count != 3 {
+		t.Errorf("Expected count to be 3, got %d", count)
+	}
+	for _, k := range []string{"key1", "key2", "key3"} {
+		if _, ok := cache.getShard(k).member[k]; !ok {
+			t.Errorf("Expected key %s to be stored in its shard", k)
+		}
+	}
+}
+
+func BenchmarkSetGetParallel(b *testing.B) {
+	cache := NewCache()
+	keys := make([]string, 1024)
+	for i := range keys {
+		keys[i] = "key" + strconv.Itoa(i)
+	}
+
+	b.RunParallel(func(pb *testing.PB) {
+		i := 0
+		for pb.Next() {
+			k := keys[i%len(keys)]
+			cache.Set(k, i, 0)
+			cache.Get(k)
+			i++
+		}
+	})
+}
@@ -0,0 +1,80 @@
+package localcache
+
+import (
+	"errors"
+	"sync"
+
+	"github.com/andrewbytecoder/nmq/pkg/recovery"
+)
+
+// errPoolBusy 回调队列已满或已关闭，调用方退化为同步执行
+var errPoolBusy = errors.New("local_cache: callback pool busy")
+
+// workerPool 有界的回调协程池，固定数量的协程从有界队列中取任务执行
+type workerPool struct {
+	mu     sync.RWMutex
+	closed bool
+	tasks  chan func()
+	wg     sync.WaitGroup
+}
+
+// newWorkerPool 创建回调协程池，workers 至少为1，queue 为等待执行的任务上限
+func newWorkerPool(workers, queue int) *workerPool {
+	if workers < 1 {
+		workers = 1
+	}
+	if queue < 0 {
+		queue = 0
+	}
+	p := &workerPool{tasks: make(chan func(), queue)}
+	p.wg.Add(workers)
+	for i := 0; i < workers; i++ {
+		go p.run()
+	}
+	return p
+}
+
+// run 执行任务直到队列关闭，任务 panic 不会导致协程退出
+func (p *workerPool) run() {
+	defer p.wg.Done()
+	for task := range p.tasks {
+		recovery.Default.Wrap("localcache.callback", task)()
+	}
+}
+
+// submit 提交任务，队列已满或已关闭时立即返回 errPoolBusy，不会阻塞调用方
+func (p *workerPool) submit(task func()) error {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	if p.closed {
+		return errPoolBusy
+	}
+	select {
+	case p.tasks <- task:
+		return nil
+	default:
+		return errPoolBusy
+	}
+}
+
+// close 停止接收任务并等待队列中的任务执行完毕，可重复调用
+func (p *workerPool) close() {
+	p.mu.Lock()
+	if !p.closed {
+		p.closed = true
+		close(p.tasks)
+	}
+	p.mu.Unlock()
+	p.wg.Wait()
+}
+
+// runCapture 调用 capture 处理被删除的缓存项，配置了异步提交函数时在协程池中执行，提交失败则同步执行
+func (c *cache) runCapture(capture func(string, interface{}), list []kv) {
+	if capture == nil || len(list) == 0 {
+		return
+	}
+	if c.captureSubmit != nil && c.captureSubmit(func() { captureAll(capture, list) }) == nil {
+		return
+	}
+	captureAll(capture, list)
+}
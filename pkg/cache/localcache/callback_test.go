@@ -0,0 +1,104 @@
+// callback_test.go
+package localcache
+
+import (
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestCallbackWorkers(t *testing.T) {
+	release := make(chan struct{})
+	var captured atomic.Int32
+	cache := NewCache(
+		SetCapture(func(k string, v interface{}) {
+			<-release
+			captured.Add(1)
+		}),
+		SetCallbackWorkers(2, 10))
+
+	cache.Set("key1", 1, 0)
+	cache.Set("key2", 2, time.Millisecond)
+
+	// 慢的 capture 不会阻塞 Delete 和 DeleteExpire
+	done := make(chan struct{})
+	go func() {
+		cache.Delete("key1")
+		time.Sleep(5 * time.Millisecond)
+		cache.DeleteExpire()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("Expected Delete and DeleteExpire not to wait for capture")
+	}
+	if captured.Load() != 0 {
+		t.Errorf("Expected capture to be pending, got %d", captured.Load())
+	}
+
+	// Shutdown 等待已提交的回调执行完毕
+	close(release)
+	cache.Shutdown()
+	if captured.Load() != 2 {
+		t.Errorf("Expected 2 captures after Shutdown, got %d", captured.Load())
+	}
+}
+
+func TestCallbackWorkersEvents(t *testing.T) {
+	var wg sync.WaitGroup
+	wg.Add(1)
+	cache := NewCache(SetCapture(nil), SetCallbackWorkers(1, 1))
+	defer cache.Shutdown()
+
+	var got string
+	cache.OnSet(func(ev Event) {
+		got = ev.Key
+		wg.Done()
+	})
+	cache.Set("key1", 1, 0)
+	wg.Wait()
+	if got != "key1" {
+		t.Errorf("Expected set event for key1, got %q", got)
+	}
+}
+
+func TestCaptureSubmitFallback(t *testing.T) {
+	var captured []string
+	submits := 0
+	cache := NewCache(
+		SetCapture(func(k string, v interface{}) { captured = append(captured, k) }),
+		SetCaptureSubmit(func(task func()) error {
+			submits++
+			return errors.New("pool closed")
+		}))
+
+	cache.Set("key1", 1, 0)
+	cache.Delete("key1")
+	if submits != 1 || len(captured) != 1 {
+		t.Errorf("Expected capture to fall back to sync, submits=%d captured=%v", submits, captured)
+	}
+}
+
+func TestWorkerPoolBusy(t *testing.T) {
+	p := newWorkerPool(1, 1)
+	block := make(chan struct{})
+	started := make(chan struct{})
+	if err := p.submit(func() { close(started); <-block }); err != nil {
+		t.Fatal(err)
+	}
+	<-started
+	if err := p.submit(func() {}); err != nil {
+		t.Fatalf("Expected task to be queued, got %v", err)
+	}
+	if err := p.submit(func() {}); !errors.Is(err, errPoolBusy) {
+		t.Errorf("Expected errPoolBusy when queue is full, got %v", err)
+	}
+	close(block)
+	p.close()
+	if err := p.submit(func() {}); !errors.Is(err, errPoolBusy) {
+		t.Errorf("Expected errPoolBusy after close, got %v", err)
+	}
+}
@@ -0,0 +1,74 @@
+package localcache
+
+import (
+	"reflect"
+	"time"
+)
+
+// CompareAndSwap 当k存在、未过期且当前值等于 old 时将其替换为 new，并重新设置过期时间
+//
+// 返回是否替换成功，比较和替换在同一把分片锁内完成，可用于实现乐观并发控制
+func (c *cache) CompareAndSwap(k string, old, new interface{}, d time.Duration) bool {
+	evicted, capture, ok := c.compareAndSwap(k, old, new, d)
+	if !ok {
+		return false
+	}
+	c.dispatch(capture, EventEvict, evicted)
+	c.publish(EventSet, []kv{{k, new}})
+	return true
+}
+
+// compareAndSwap 持有分片锁比较并替换，返回被淘汰的条目和当时的删除回调
+func (c *cache) compareAndSwap(k string, old, new interface{}, d time.Duration) ([]kv, func(string, interface{}), bool) {
+	s := c.getShard(k)
+	s.Lock()
+	defer s.Unlock()
+	if v, ok := s.member[k]; !ok || v.Expired() || !equal(v.Val, old) {
+		return nil, nil, false
+	}
+	return s.set(k, new, d), c.capture, true
+}
+
+// DeleteIfEqual 当k存在、未过期且当前值等于 old 时删除k，返回是否删除成功
+func (c *cache) DeleteIfEqual(k string, old interface{}) bool {
+	v, capture, ok := c.deleteIfEqual(k, old)
+	if !ok {
+		return false
+	}
+	c.dispatch(capture, EventDelete, []kv{{k, v}})
+	return true
+}
+
+// deleteIfEqual 持有分片锁比较并删除，返回被删除的值和当时的删除回调
+func (c *cache) deleteIfEqual(k string, old interface{}) (interface{}, func(string, interface{}), bool) {
+	s := c.getShard(k)
+	s.Lock()
+	defer s.Unlock()
+	if v, ok := s.member[k]; !ok || v.Expired() || !equal(v.Val, old) {
+		return nil, nil, false
+	}
+	v, _ := c.delete(s, k)
+	return v, c.capture, true
+}
+
+// equal 比较两个缓存值，基本类型和指针使用 ==，其余类型使用 reflect.DeepEqual
+//
+// 结构体、数组即使可比较，也可能在 interface{} 字段中保存 slice、map，使用 == 比较会 panic
+func equal(a, b interface{}) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	ta, tb := reflect.TypeOf(a), reflect.TypeOf(b)
+	if ta != tb {
+		return false
+	}
+	switch ta.Kind() {
+	case reflect.Bool, reflect.String,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr,
+		reflect.Float32, reflect.Float64, reflect.Complex64, reflect.Complex128,
+		reflect.Pointer, reflect.Chan, reflect.UnsafePointer:
+		return a == b
+	}
+	return reflect.DeepEqual(a, b)
+}
@@ -0,0 +1,98 @@
+// cas_test.go
+package localcache
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestCompareAndSwap(t *testing.T) {
+	cache := NewCache(SetCapture(nil))
+	cache.Set("key1", "value1", 0)
+
+	if cache.CompareAndSwap("key1", "wrong", "value2", 0) {
+		t.Error("Expected swap with wrong old value to fail")
+	}
+	if !cache.CompareAndSwap("key1", "value1", "value2", time.Hour) {
+		t.Error("Expected swap to succeed")
+	}
+	if v, exp, ok := cache.GetWithExpire("key1"); !ok || v != "value2" || exp.IsZero() {
+		t.Errorf("Expected value2 with expiration, got %v %v", v, exp)
+	}
+	if cache.CompareAndSwap("nonexistent", nil, "value", 0) {
+		t.Error("Expected swap on nonexistent key to fail")
+	}
+
+	cache.Set("key2", "value1", 10*time.Millisecond)
+	time.Sleep(20 * time.Millisecond)
+	if cache.CompareAndSwap("key2", "value1", "value2", 0) {
+		t.Error("Expected swap on expired key to fail")
+	}
+
+	// 不可比较类型使用深度比较
+	cache.Set("slice", []int{1, 2}, 0)
+	if !cache.CompareAndSwap("slice", []int{1, 2}, []int{3}, 0) {
+		t.Error("Expected swap on equal slice to succeed")
+	}
+
+	// 可比较的结构体在 interface{} 字段中保存 slice 时不能使用 ==
+	type holder struct{ V interface{} }
+	cache.Set("holder", holder{V: []int{1, 2}}, 0)
+	if cache.CompareAndSwap("holder", holder{V: []int{1}}, holder{V: 1}, 0) {
+		t.Error("Expected swap with different slice field to fail")
+	}
+	if !cache.CompareAndSwap("holder", holder{V: []int{1, 2}}, holder{V: 1}, 0) {
+		t.Error("Expected swap with equal slice field to succeed")
+	}
+	if !cache.DeleteIfEqual("holder", holder{V: 1}) {
+		t.Error("Expected delete with equal struct to succeed")
+	}
+}
+
+func TestCompareAndSwapConcurrent(t *testing.T) {
+	cache := NewCache(SetCapture(nil))
+	cache.Set("counter", 0, 0)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				for {
+					v, _ := cache.Get("counter")
+					if cache.CompareAndSwap("counter", v, v.(int)+1, 0) {
+						break
+					}
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	if v, _ := cache.Get("counter"); v != 1000 {
+		t.Errorf("Expected counter to be 1000, got %v", v)
+	}
+}
+
+func TestDeleteIfEqual(t *testing.T) {
+	var captured []string
+	cache := NewCache(SetCapture(func(k string, v interface{}) {
+		captured = append(captured, k)
+	}))
+	cache.Set("key1", "value1", 0)
+
+	if cache.DeleteIfEqual("key1", "wrong") {
+		t.Error("Expected delete with wrong value to fail")
+	}
+	if !cache.DeleteIfEqual("key1", "value1") {
+		t.Error("Expected delete to succeed")
+	}
+	if _, ok := cache.Get("key1"); ok {
+		t.Error("Expected key1 to be deleted")
+	}
+	if len(captured) != 1 || captured[0] != "key1" {
+		t.Errorf("Expected capture for key1, got %v", captured)
+	}
+}
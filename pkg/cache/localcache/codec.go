@@ -0,0 +1,236 @@
+package localcache
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"encoding/gob"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/andrewbytecoder/nmq/pkg/compress"
+)
+
+// fileMagic 缓存持久化文件头魔数
+var fileMagic = []byte("NMQC")
+
+// FileVersion 当前持久化文件版本，文件格式或缓存项结构变化时递增，并通过 RegisterMigration 注册迁移
+//
+// 版本0表示没有文件头的旧版本 gob 文件，版本2开始文件头中记录快照时间
+const FileVersion uint8 = 2
+
+// Codec 缓存持久化编解码器，Save 使用配置的编解码器写入，Load 根据文件头自动选择
+type Codec interface {
+	// Name 编解码器名称，写入文件头用于 Load 时查找，长度不能超过255
+	Name() string
+	// Encode 将缓存项编码写入 w，Iterator.Expire 为绝对过期时间，原样保存即可保留TTL
+	Encode(w io.Writer, member map[string]Iterator) error
+	// Decode 从 r 中解码缓存项
+	Decode(r io.Reader) (map[string]Iterator, error)
+}
+
+var (
+	codecMu sync.RWMutex
+	codecs  = make(map[string]Codec)
+)
+
+func init() {
+	RegisterCodec(GobCodec())
+	RegisterCodec(JSONCodec())
+	RegisterCodec(MsgpackCodec())
+}
+
+// RegisterCodec 注册编解码器，同名编解码器会被覆盖
+func RegisterCodec(codec Codec) {
+	codecMu.Lock()
+	defer codecMu.Unlock()
+	codecs[codec.Name()] = codec
+}
+
+// lookupCodec 根据名称查找已注册的编解码器，"编解码器+压缩算法" 形式的名称按 CompressedCodec 组合
+func lookupCodec(name string) (Codec, bool) {
+	codecMu.RLock()
+	codec, ok := codecs[name]
+	codecMu.RUnlock()
+	if ok {
+		return codec, true
+	}
+	i := strings.LastIndexByte(name, '+')
+	if i < 0 {
+		return nil, false
+	}
+	if codec, ok = lookupCodec(name[:i]); !ok {
+		return nil, false
+	}
+	zip, err := compress.New(name[i+1:])
+	if err != nil {
+		return nil, false
+	}
+	return CompressedCodec(codec, zip), true
+}
+
+// fileHeader 持久化文件头
+type fileHeader struct {
+	codec    Codec
+	version  uint8
+	snapshot int64 // 快照时间（UnixNano），版本2之前的文件为0
+}
+
+// writeHeader 写入文件头：魔数 + 版本 + 编解码器名称长度 + 编解码器名称 + 快照时间
+func writeHeader(w io.Writer, codec Codec, snapshot time.Time) error {
+	name := codec.Name()
+	if len(name) == 0 || len(name) > 255 {
+		return CacheCodecErr
+	}
+	header := make([]byte, 0, len(fileMagic)+2+len(name)+8)
+	header = append(header, fileMagic...)
+	header = append(header, FileVersion, uint8(len(name)))
+	header = append(header, name...)
+	header = binary.BigEndian.AppendUint64(header, uint64(snapshot.UnixNano()))
+	_, err := w.Write(header)
+	return err
+}
+
+// readHeader 读取文件头，没有文件头时按版本0的 gob 格式处理
+//
+// 文件版本高于 FileVersion 时说明文件由更新的版本写入，拒绝加载以免降级后丢失数据
+func readHeader(r *bufio.Reader) (fileHeader, error) {
+	magic, err := r.Peek(len(fileMagic))
+	if err != nil || !bytes.Equal(magic, fileMagic) {
+		return fileHeader{codec: GobCodec()}, nil
+	}
+	if _, err = r.Discard(len(fileMagic)); err != nil {
+		return fileHeader{}, err
+	}
+	var buf [2]byte
+	if _, err = io.ReadFull(r, buf[:]); err != nil {
+		return fileHeader{}, err
+	}
+	h := fileHeader{version: buf[0]}
+	if h.version == 0 {
+		return fileHeader{}, fmt.Errorf("%w: invalid file version 0", CacheVersionErr)
+	}
+	if h.version > FileVersion {
+		return fileHeader{}, fmt.Errorf("%w: file version %d is newer than supported version %d, refusing to downgrade",
+			CacheVersionErr, h.version, FileVersion)
+	}
+	name := make([]byte, buf[1])
+	if _, err = io.ReadFull(r, name); err != nil {
+		return fileHeader{}, err
+	}
+	var ok bool
+	if h.codec, ok = lookupCodec(string(name)); !ok {
+		return fileHeader{}, fmt.Errorf("%w: unknown codec %q", CacheCodecErr, name)
+	}
+	if h.version >= 2 {
+		var ts [8]byte
+		if _, err = io.ReadFull(r, ts[:]); err != nil {
+			return fileHeader{}, err
+		}
+		h.snapshot = int64(binary.BigEndian.Uint64(ts[:]))
+	}
+	return h, nil
+}
+
+// gobCodec gob 编解码器，仅适用于Go程序之间，值类型需要能被 gob 编码
+type gobCodec struct{}
+
+// GobCodec 返回 gob 编解码器，也是默认的编解码器
+func GobCodec() Codec { return gobCodec{} }
+
+func (gobCodec) Name() string { return "gob" }
+
+func (gobCodec) Encode(w io.Writer, member map[string]Iterator) (err error) {
+	defer func() {
+		if e := recover(); e != nil {
+			err = CacheGobErr
+		}
+	}()
+	// 注册所有缓存值的类型，以便gob编码
+	for _, iterator := range member {
+		gob.Register(iterator.Val)
+	}
+	return gob.NewEncoder(w).Encode(&member)
+}
+
+func (gobCodec) Decode(r io.Reader) (map[string]Iterator, error) {
+	member := map[string]Iterator{}
+	if err := gob.NewDecoder(r).Decode(&member); err != nil {
+		return nil, err
+	}
+	return member, nil
+}
+
+// jsonEntry JSON 格式的缓存项
+type jsonEntry struct {
+	Val     interface{} `json:"val"`
+	Expire  int64       `json:"expire,omitempty"`
+	Sliding int64       `json:"sliding,omitempty"`
+}
+
+// jsonCodec JSON 编解码器，解码后数字统一为 float64，对象为 map[string]interface{}
+type jsonCodec struct{}
+
+// JSONCodec 返回 JSON 编解码器
+func JSONCodec() Codec { return jsonCodec{} }
+
+func (jsonCodec) Name() string { return "json" }
+
+func (jsonCodec) Encode(w io.Writer, member map[string]Iterator) error {
+	entries := make(map[string]jsonEntry, len(member))
+	for k, iterator := range member {
+		entries[k] = jsonEntry{Val: iterator.Val, Expire: iterator.Expire, Sliding: iterator.Sliding}
+	}
+	return json.NewEncoder(w).Encode(entries)
+}
+
+func (jsonCodec) Decode(r io.Reader) (map[string]Iterator, error) {
+	var entries map[string]jsonEntry
+	if err := json.NewDecoder(r).Decode(&entries); err != nil {
+		return nil, err
+	}
+	member := make(map[string]Iterator, len(entries))
+	for k, e := range entries {
+		member[k] = Iterator{Val: e.Val, Expire: e.Expire, Sliding: e.Sliding}
+	}
+	return member, nil
+}
+
+// compressedCodec 先用 Codec 编码再用 zip 压缩
+type compressedCodec struct {
+	Codec
+	zip compress.Codec
+}
+
+// CompressedCodec 返回在 codec 外层压缩的编解码器，名称为 "编解码器+压缩算法"，例如 "gob+zstd"，
+// 压缩算法从 compress 包的注册表中查找，Load 根据文件头中的名称自动解压，不需要调用 RegisterCodec
+func CompressedCodec(codec Codec, zip compress.Codec) Codec {
+	return compressedCodec{Codec: codec, zip: zip}
+}
+
+func (c compressedCodec) Name() string { return c.Codec.Name() + "+" + c.zip.Name() }
+
+func (c compressedCodec) Encode(w io.Writer, member map[string]Iterator) error {
+	zw, err := c.zip.NewWriter(w)
+	if err != nil {
+		return err
+	}
+	err = c.Codec.Encode(zw, member)
+	if e := zw.Close(); err == nil {
+		err = e
+	}
+	return err
+}
+
+func (c compressedCodec) Decode(r io.Reader) (map[string]Iterator, error) {
+	zr, err := c.zip.NewReader(r)
+	if err != nil {
+		return nil, err
+	}
+	defer zr.Close()
+	return c.Codec.Decode(zr)
+}
@@ -0,0 +1,166 @@
+// codec_test.go
+package localcache
+
+import (
+	"bytes"
+	"encoding/gob"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/andrewbytecoder/nmq/pkg/compress"
+)
+
+func TestCodecSaveLoad(t *testing.T) {
+	zstd, err := compress.New("zstd")
+	if err != nil {
+		t.Fatal(err)
+	}
+	snappy, err := compress.New("snappy")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, codec := range []Codec{
+		GobCodec(), JSONCodec(), MsgpackCodec(),
+		CompressedCodec(GobCodec(), zstd), CompressedCodec(MsgpackCodec(), snappy),
+	} {
+		t.Run(codec.Name(), func(t *testing.T) {
+			cache := NewCache(SetCapture(nil), SetCodec(codec))
+			cache.Set("str", "value", 0)
+			cache.Set("ttl", "value", time.Hour)
+			cache.Set("num", 42, 0)
+
+			buf := new(bytes.Buffer)
+			if err := cache.Save(buf); err != nil {
+				t.Fatalf("Save failed: %v", err)
+			}
+			if !bytes.HasPrefix(buf.Bytes(), fileMagic) {
+				t.Error("Expected file header")
+			}
+
+			// Load 根据文件头选择编解码器，不依赖 SetCodec
+			newCache := NewCache(SetCapture(nil))
+			if err := newCache.Load(buf); err != nil {
+				t.Fatalf("Load failed: %v", err)
+			}
+
+			if v, ok := newCache.Get("str"); !ok || v != "value" {
+				t.Errorf("Expected str to be value, got %v", v)
+			}
+			_, want, _ := cache.GetWithExpire("ttl")
+			if _, got, ok := newCache.GetWithExpire("ttl"); !ok || !got.Equal(want) {
+				t.Errorf("Expected ttl to expire at %v, got %v", want, got)
+			}
+			if _, ok := newCache.Get("num"); !ok {
+				t.Error("Expected num to be loaded")
+			}
+		})
+	}
+}
+
+func TestCodecLegacyGob(t *testing.T) {
+	// 没有文件头的旧版本 gob 文件
+	buf := new(bytes.Buffer)
+	member := map[string]Iterator{"key1": {Val: "value1"}}
+	if err := gob.NewEncoder(buf).Encode(&member); err != nil {
+		t.Fatal(err)
+	}
+
+	cache := NewCache(SetCapture(nil))
+	if err := cache.Load(buf); err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if v, ok := cache.Get("key1"); !ok || v != "value1" {
+		t.Errorf("Expected key1 to be value1, got %v", v)
+	}
+}
+
+func TestCodecHeaderErr(t *testing.T) {
+	cache := NewCache(SetCapture(nil))
+
+	unknown := append(append([]byte{}, fileMagic...), FileVersion, 3, 'x', 'm', 'l')
+	if err := cache.Load(bytes.NewReader(unknown)); !errors.Is(err, CacheCodecErr) {
+		t.Errorf("Expected CacheCodecErr, got %v", err)
+	}
+	unknownZip := append(append([]byte{}, fileMagic...), FileVersion, 7, 'g', 'o', 'b', '+', 'l', 'z', '4')
+	if err := cache.Load(bytes.NewReader(unknownZip)); !errors.Is(err, CacheCodecErr) {
+		t.Errorf("Expected CacheCodecErr for unknown compression, got %v", err)
+	}
+
+	future := append(append([]byte{}, fileMagic...), FileVersion+1, 4, 'j', 's', 'o', 'n')
+	if err := cache.Load(bytes.NewReader(future)); !errors.Is(err, CacheVersionErr) {
+		t.Errorf("Expected CacheVersionErr, got %v", err)
+	}
+}
+
+func TestMsgpackCodec(t *testing.T) {
+	long := string(bytes.Repeat([]byte("a"), 300))
+	member := map[string]Iterator{
+		"nil":    {Val: nil},
+		"bool":   {Val: true},
+		"int":    {Val: -1000, Expire: time.Now().UnixNano()},
+		"uint":   {Val: uint64(1 << 63)},
+		"float":  {Val: 1.5},
+		"long":   {Val: long},
+		"bytes":  {Val: []byte{1, 2, 3}},
+		"slice":  {Val: []string{"a", "b"}},
+		"nested": {Val: map[string]interface{}{"a": []interface{}{int8(-5), "b"}}},
+	}
+	want := map[string]Iterator{
+		"nil":    {Val: nil},
+		"bool":   {Val: true},
+		"int":    {Val: int64(-1000), Expire: member["int"].Expire},
+		"uint":   {Val: uint64(1 << 63)},
+		"float":  {Val: 1.5},
+		"long":   {Val: long},
+		"bytes":  {Val: []byte{1, 2, 3}},
+		"slice":  {Val: []interface{}{"a", "b"}},
+		"nested": {Val: map[string]interface{}{"a": []interface{}{int64(-5), "b"}}},
+	}
+
+	buf := new(bytes.Buffer)
+	if err := MsgpackCodec().Encode(buf, member); err != nil {
+		t.Fatalf("Encode failed: %v", err)
+	}
+	got, err := MsgpackCodec().Decode(buf)
+	if err != nil {
+		t.Fatalf("Decode failed: %v", err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected %v, got %v", want, got)
+	}
+
+	err = MsgpackCodec().Encode(new(bytes.Buffer), map[string]Iterator{"ch": {Val: make(chan int)}})
+	if !errors.Is(err, CacheMsgpackErr) {
+		t.Errorf("Expected CacheMsgpackErr, got %v", err)
+	}
+}
+
+func TestMsgpackDecodeLimits(t *testing.T) {
+	// 声明 4GB 的 bin32 但只有几个字节，按实际数据读取后返回错误而不是一次分配 4GB
+	huge := []byte{0xc6, 0xff, 0xff, 0xff, 0xff, 1, 2, 3}
+	if _, err := MsgpackCodec().Decode(bytes.NewReader(huge)); err == nil {
+		t.Error("Expected error for truncated bin32")
+	}
+
+	// 超过最大嵌套层数
+	deep := bytes.Repeat([]byte{0x91}, msgpackMaxDepth+1)
+	if _, err := MsgpackCodec().Decode(bytes.NewReader(append(deep, 0xc0))); !errors.Is(err, CacheCodecErr) {
+		t.Errorf("Expected CacheCodecErr for deep nesting, got %v", err)
+	}
+
+	// 超过分块大小的数据仍能完整读出
+	b := bytes.Repeat([]byte{'x'}, msgpackReadChunk*2+1)
+	buf := new(bytes.Buffer)
+	if err := MsgpackCodec().Encode(buf, map[string]Iterator{"k": {Val: b}}); err != nil {
+		t.Fatal(err)
+	}
+	member, err := MsgpackCodec().Decode(buf)
+	if err != nil {
+		t.Fatalf("Decode failed: %v", err)
+	}
+	if got, _ := member["k"].Val.([]byte); !bytes.Equal(got, b) {
+		t.Errorf("Expected %d bytes, got %d", len(b), len(got))
+	}
+}
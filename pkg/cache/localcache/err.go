@@ -8,6 +8,13 @@ var (
 	CacheExpire  = errors.New("local_cache: cache expire")
 	CacheTypeErr = errors.New("local_cache: cache incr type err")
 	CacheGobErr  = errors.New("local_cache: cache save gob err")
+
+	CacheCodecErr   = errors.New("local_cache: cache codec err")
+	CacheVersionErr = errors.New("local_cache: cache file version unsupported")
+	CacheMsgpackErr = errors.New("local_cache: cache msgpack unsupported type")
+
+	CacheArenaFull = errors.New("local_cache: arena entry too large")
+	CacheArenaKey  = errors.New("local_cache: arena key too long")
 )
 
 func CacheErrExist(e error) bool {
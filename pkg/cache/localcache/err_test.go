@@ -18,6 +18,9 @@ func TestCacheErrors(t *testing.T) {
 		{"CacheExpire", CacheExpire, "local_cache: cache expire"},
 		{"CacheTypeErr", CacheTypeErr, "local_cache: cache incr type err"},
 		{"CacheGobErr", CacheGobErr, "local_cache: cache save gob err"},
+		{"CacheCodecErr", CacheCodecErr, "local_cache: cache codec err"},
+		{"CacheVersionErr", CacheVersionErr, "local_cache: cache file version unsupported"},
+		{"CacheMsgpackErr", CacheMsgpackErr, "local_cache: cache msgpack unsupported type"},
 	}
 
 	for _, tt := range tests {
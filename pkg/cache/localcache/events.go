@@ -0,0 +1,163 @@
+package localcache
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// EventType 缓存事件类型
+type EventType int
+
+const (
+	// EventSet 通过 Set/MSet/Add/Replace 写入缓存项
+	EventSet EventType = iota
+	// EventDelete 通过 Delete/MDelete 主动删除缓存项
+	EventDelete
+	// EventExpire 缓存项过期后被删除
+	EventExpire
+	// EventEvict 缓存达到容量或内存上限，缓存项被淘汰
+	EventEvict
+	// EventLeaseExpire 租约没有及时续期，租约key被删除
+	EventLeaseExpire
+)
+
+// String 返回事件类型名称
+func (t EventType) String() string {
+	switch t {
+	case EventSet:
+		return "set"
+	case EventDelete:
+		return "delete"
+	case EventExpire:
+		return "expire"
+	case EventEvict:
+		return "evict"
+	case EventLeaseExpire:
+		return "lease_expire"
+	default:
+		return "unknown"
+	}
+}
+
+// Event 缓存事件
+type Event struct {
+	Type  EventType   // 事件类型
+	Key   string      // 缓存key
+	Value interface{} // 写入或被删除的值
+	Time  time.Time   // 事件发生时间
+}
+
+// listener 事件订阅者
+type listener struct {
+	id uint64
+	fn func(Event)
+}
+
+// eventHub 缓存事件订阅中心，支持同一事件类型注册多个订阅者
+type eventHub struct {
+	mu        sync.RWMutex
+	seq       uint64                   // 订阅者id生成序号
+	count     atomic.Int32             // 订阅者总数，无订阅者时跳过事件构造
+	listeners map[EventType][]listener // 事件类型到订阅者列表的映射
+	submit    func(task func()) error  // 异步派发函数，为nil时在调用方协程同步派发
+}
+
+// newEventHub 创建事件订阅中心
+func newEventHub(submit func(task func()) error) *eventHub {
+	return &eventHub{
+		listeners: make(map[EventType][]listener),
+		submit:    submit,
+	}
+}
+
+// subscribe 注册订阅者，返回取消订阅函数
+func (h *eventHub) subscribe(typ EventType, fn func(Event)) func() {
+	h.mu.Lock()
+	h.seq++
+	id := h.seq
+	h.listeners[typ] = append(h.listeners[typ], listener{id: id, fn: fn})
+	h.count.Add(1)
+	h.mu.Unlock()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			h.mu.Lock()
+			defer h.mu.Unlock()
+			ls := h.listeners[typ]
+			for i, l := range ls {
+				if l.id == id {
+					// 复制一份新切片，避免影响正在派发的旧切片
+					h.listeners[typ] = append(append([]listener{}, ls[:i]...), ls[i+1:]...)
+					h.count.Add(-1)
+					return
+				}
+			}
+		})
+	}
+}
+
+// publish 向订阅者派发事件，配置了 submit 时异步派发，提交失败则退化为同步派发
+func (h *eventHub) publish(typ EventType, list []kv) {
+	if h.count.Load() == 0 || len(list) == 0 {
+		return
+	}
+
+	h.mu.RLock()
+	ls := h.listeners[typ]
+	h.mu.RUnlock()
+	if len(ls) == 0 {
+		return
+	}
+
+	now := time.Now()
+	for _, item := range list {
+		ev := Event{Type: typ, Key: item.key, Value: item.value, Time: now}
+		for _, l := range ls {
+			fn := l.fn
+			if h.submit != nil && h.submit(func() { fn(ev) }) == nil {
+				continue
+			}
+			fn(ev)
+		}
+	}
+}
+
+// OnSet 订阅缓存写入事件，返回取消订阅函数
+func (c *cache) OnSet(fn func(Event)) func() {
+	return c.events.subscribe(EventSet, fn)
+}
+
+// OnDelete 订阅缓存主动删除事件，返回取消订阅函数
+func (c *cache) OnDelete(fn func(Event)) func() {
+	return c.events.subscribe(EventDelete, fn)
+}
+
+// OnExpire 订阅缓存过期删除事件，返回取消订阅函数
+func (c *cache) OnExpire(fn func(Event)) func() {
+	return c.events.subscribe(EventExpire, fn)
+}
+
+// OnEvict 订阅缓存淘汰事件，返回取消订阅函数
+func (c *cache) OnEvict(fn func(Event)) func() {
+	return c.events.subscribe(EventEvict, fn)
+}
+
+// OnLeaseExpire 订阅租约过期事件，返回取消订阅函数
+func (c *cache) OnLeaseExpire(fn func(Event)) func() {
+	return c.events.subscribe(EventLeaseExpire, fn)
+}
+
+// dispatch 在释放锁之后处理被移除的缓存项：调用 capture 并派发对应类型的事件
+func (c *cache) dispatch(capture func(string, interface{}), typ EventType, list []kv) {
+	c.runCapture(capture, list)
+	c.publish(typ, list)
+}
+
+// publish 记录统计、调用持久化钩子并向订阅者派发事件
+func (c *cache) publish(typ EventType, list []kv) {
+	c.stats.record(typ, len(list))
+	c.syncPersist()
+	c.events.publish(typ, list)
+}
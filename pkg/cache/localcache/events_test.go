@@ -0,0 +1,138 @@
+// events_test.go
+package localcache
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestEventSetDelete(t *testing.T) {
+	cache := NewCache(SetCapture(nil))
+
+	var sets, deletes []Event
+	cache.OnSet(func(ev Event) { sets = append(sets, ev) })
+	cache.OnDelete(func(ev Event) { deletes = append(deletes, ev) })
+
+	cache.Set("key1", "value1", 0)
+	cache.MSet(map[string]interface{}{"key2": "value2"}, 0)
+	if err := cache.Add("key3", "value3", 0); err != nil {
+		t.Fatalf("Add failed: %v", err)
+	}
+	if err := cache.Replace("key3", "value4", 0); err != nil {
+		t.Fatalf("Replace failed: %v", err)
+	}
+	if len(sets) != 4 {
+		t.Fatalf("Expected 4 set events, got %d", len(sets))
+	}
+	if sets[3].Type != EventSet || sets[3].Key != "key3" || sets[3].Value != "value4" {
+		t.Errorf("Unexpected set event: %+v", sets[3])
+	}
+
+	cache.Delete("key1")
+	cache.Delete("nonexistent")
+	cache.MDelete([]string{"key2", "key3"})
+	if len(deletes) != 3 {
+		t.Fatalf("Expected 3 delete events, got %d", len(deletes))
+	}
+	if deletes[0].Key != "key1" || deletes[0].Value != "value1" {
+		t.Errorf("Unexpected delete event: %+v", deletes[0])
+	}
+}
+
+func TestEventExpire(t *testing.T) {
+	cache := NewCache(SetCapture(nil))
+
+	var expired, deleted []string
+	cache.OnExpire(func(ev Event) { expired = append(expired, ev.Key) })
+	cache.OnDelete(func(ev Event) { deleted = append(deleted, ev.Key) })
+
+	cache.Set("key1", "value1", 10*time.Millisecond)
+	cache.Set("key2", "value2", 10*time.Millisecond)
+	time.Sleep(20 * time.Millisecond)
+
+	if _, ok := cache.Get("key1"); ok {
+		t.Error("Expected key1 to be expired")
+	}
+	cache.DeleteExpire()
+
+	if len(expired) != 2 {
+		t.Errorf("Expected 2 expire events, got %v", expired)
+	}
+	if len(deleted) != 0 {
+		t.Errorf("Expected no delete events, got %v", deleted)
+	}
+}
+
+func TestEventEvict(t *testing.T) {
+	cache := NewCache(SetCapture(nil), SetShards(1), SetMaxEntries(2))
+
+	var evicted []string
+	cache.OnEvict(func(ev Event) { evicted = append(evicted, ev.Key) })
+
+	cache.Set("key1", "value1", 0)
+	cache.Set("key2", "value2", 0)
+	cache.Set("key3", "value3", 0)
+
+	if len(evicted) != 1 || evicted[0] != "key1" {
+		t.Errorf("Expected key1 to be evicted, got %v", evicted)
+	}
+}
+
+func TestEventCancel(t *testing.T) {
+	cache := NewCache(SetCapture(nil))
+
+	var n int
+	cancel := cache.OnSet(func(Event) { n++ })
+	cache.Set("key1", "value1", 0)
+	cancel()
+	cancel()
+	cache.Set("key2", "value2", 0)
+
+	if n != 1 {
+		t.Errorf("Expected 1 event before cancel, got %d", n)
+	}
+}
+
+func TestEventSubmit(t *testing.T) {
+	var wg sync.WaitGroup
+	var submitted int
+	cache := NewCache(SetCapture(nil), SetEventSubmit(func(task func()) error {
+		submitted++
+		go task()
+		return nil
+	}))
+
+	var mu sync.Mutex
+	var keys []string
+	cache.OnSet(func(ev Event) {
+		mu.Lock()
+		keys = append(keys, ev.Key)
+		mu.Unlock()
+		wg.Done()
+	})
+
+	wg.Add(2)
+	cache.Set("key1", "value1", 0)
+	cache.Set("key2", "value2", 0)
+	wg.Wait()
+
+	if submitted != 2 || len(keys) != 2 {
+		t.Errorf("Expected 2 async events, got submitted=%d keys=%v", submitted, keys)
+	}
+}
+
+func TestEventSubmitFallback(t *testing.T) {
+	cache := NewCache(SetCapture(nil), SetEventSubmit(func(task func()) error {
+		return errors.New("pool closed")
+	}))
+
+	var n int
+	cache.OnSet(func(Event) { n++ })
+	cache.Set("key1", "value1", 0)
+
+	if n != 1 {
+		t.Errorf("Expected synchronous fallback, got %d events", n)
+	}
+}
@@ -0,0 +1,162 @@
+package localcache
+
+import "container/list"
+
+// EvictionPolicy 淘汰策略，记录key的写入和访问情况，在缓存达到最大条目数时选出被淘汰的key
+//
+// 每个分片持有独立的策略实例，调用方保证同一实例不会被并发调用
+type EvictionPolicy interface {
+	// Add 记录新写入的key
+	Add(key string)
+	// Access 记录一次对已存在key的访问或更新
+	Access(key string)
+	// Remove 移除key，key不存在时忽略
+	Remove(key string)
+	// Evict 选出并移除一个被淘汰的key，没有可淘汰的key时返回false
+	Evict() (string, bool)
+}
+
+// lruPolicy 最近最少使用淘汰策略，淘汰最久没有被访问的key
+type lruPolicy struct {
+	ll    *list.List               // 访问顺序链表，表头为最近访问
+	items map[string]*list.Element // key 到链表节点的索引
+}
+
+// NewLRUPolicy 创建LRU淘汰策略
+func NewLRUPolicy() EvictionPolicy {
+	return &lruPolicy{
+		ll:    list.New(),
+		items: make(map[string]*list.Element),
+	}
+}
+
+// Add 记录新写入的key
+func (p *lruPolicy) Add(key string) {
+	if e, ok := p.items[key]; ok {
+		p.ll.MoveToFront(e)
+		return
+	}
+	p.items[key] = p.ll.PushFront(key)
+}
+
+// Access 将key移动到表头
+func (p *lruPolicy) Access(key string) {
+	if e, ok := p.items[key]; ok {
+		p.ll.MoveToFront(e)
+	}
+}
+
+// Remove 移除key
+func (p *lruPolicy) Remove(key string) {
+	if e, ok := p.items[key]; ok {
+		p.ll.Remove(e)
+		delete(p.items, key)
+	}
+}
+
+// Evict 淘汰表尾最久未访问的key
+func (p *lruPolicy) Evict() (string, bool) {
+	e := p.ll.Back()
+	if e == nil {
+		return "", false
+	}
+	key := e.Value.(string)
+	p.ll.Remove(e)
+	delete(p.items, key)
+	return key, true
+}
+
+// lfuEntry LFU策略中的key及其访问频次
+type lfuEntry struct {
+	key  string
+	freq int
+}
+
+// lfuPolicy 最不经常使用淘汰策略，淘汰访问频次最低的key，频次相同时淘汰最久未访问的key
+//
+// 按频次分桶，所有操作均为 O(1)
+type lfuPolicy struct {
+	items   map[string]*list.Element // key 到频次桶中节点的索引
+	buckets map[int]*list.List       // 访问频次到key链表的映射，链表表头为最近访问
+	minFreq int                      // 当前最小访问频次
+}
+
+// NewLFUPolicy 创建LFU淘汰策略
+func NewLFUPolicy() EvictionPolicy {
+	return &lfuPolicy{
+		items:   make(map[string]*list.Element),
+		buckets: make(map[int]*list.List),
+	}
+}
+
+// Add 记录新写入的key，初始频次为1
+func (p *lfuPolicy) Add(key string) {
+	if _, ok := p.items[key]; ok {
+		p.Access(key)
+		return
+	}
+	p.items[key] = p.bucket(1).PushFront(&lfuEntry{key: key, freq: 1})
+	p.minFreq = 1
+}
+
+// Access 将key的访问频次加一
+func (p *lfuPolicy) Access(key string) {
+	e, ok := p.items[key]
+	if !ok {
+		return
+	}
+	entry := e.Value.(*lfuEntry)
+	p.unlink(e, entry.freq)
+	if p.minFreq == entry.freq && p.buckets[entry.freq] == nil {
+		p.minFreq++
+	}
+	entry.freq++
+	p.items[key] = p.bucket(entry.freq).PushFront(entry)
+}
+
+// Remove 移除key
+func (p *lfuPolicy) Remove(key string) {
+	e, ok := p.items[key]
+	if !ok {
+		return
+	}
+	p.unlink(e, e.Value.(*lfuEntry).freq)
+	delete(p.items, key)
+}
+
+// Evict 淘汰访问频次最低且最久未访问的key
+func (p *lfuPolicy) Evict() (string, bool) {
+	if len(p.items) == 0 {
+		return "", false
+	}
+	l := p.buckets[p.minFreq]
+	for l == nil {
+		// Remove 可能使最小频次桶为空，向上查找下一个非空桶
+		p.minFreq++
+		l = p.buckets[p.minFreq]
+	}
+	e := l.Back()
+	key := e.Value.(*lfuEntry).key
+	p.unlink(e, p.minFreq)
+	delete(p.items, key)
+	return key, true
+}
+
+// bucket 获取指定频次的链表，不存在时创建
+func (p *lfuPolicy) bucket(freq int) *list.List {
+	l, ok := p.buckets[freq]
+	if !ok {
+		l = list.New()
+		p.buckets[freq] = l
+	}
+	return l
+}
+
+// unlink 将节点从频次桶中移除，桶为空时删除该桶
+func (p *lfuPolicy) unlink(e *list.Element, freq int) {
+	l := p.buckets[freq]
+	l.Remove(e)
+	if l.Len() == 0 {
+		delete(p.buckets, freq)
+	}
+}
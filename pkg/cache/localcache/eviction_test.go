@@ -0,0 +1,133 @@
+// eviction_test.go
+package localcache
+
+import (
+	"strconv"
+	"testing"
+)
+
+func TestLRUPolicy(t *testing.T) {
+	p := NewLRUPolicy()
+	p.Add("a")
+	p.Add("b")
+	p.Add("c")
+
+	// 访问a之后，最久未访问的是b
+	p.Access("a")
+	if key, ok := p.Evict(); !ok || key != "b" {
+		t.Errorf("Expected to evict b, got %s", key)
+	}
+
+	p.Remove("c")
+	if key, ok := p.Evict(); !ok || key != "a" {
+		t.Errorf("Expected to evict a, got %s", key)
+	}
+
+	if _, ok := p.Evict(); ok {
+		t.Error("Expected no key to evict")
+	}
+}
+
+func TestLFUPolicy(t *testing.T) {
+	p := NewLFUPolicy()
+	p.Add("a")
+	p.Add("b")
+	p.Add("c")
+
+	// a访问两次，b访问一次，c未访问
+	p.Access("a")
+	p.Access("a")
+	p.Access("b")
+
+	if key, ok := p.Evict(); !ok || key != "c" {
+		t.Errorf("Expected to evict c, got %s", key)
+	}
+	if key, ok := p.Evict(); !ok || key != "b" {
+		t.Errorf("Expected to evict b, got %s", key)
+	}
+
+	// 频次相同时淘汰最久未访问的key
+	p.Add("d")
+	p.Add("e")
+	p.Remove("a")
+	if key, ok := p.Evict(); !ok || key != "d" {
+		t.Errorf("Expected to evict d, got %s", key)
+	}
+	if key, ok := p.Evict(); !ok || key != "e" {
+		t.Errorf("Expected to evict e, got %s", key)
+	}
+	if _, ok := p.Evict(); ok {
+		t.Error("Expected no key to evict")
+	}
+}
+
+func TestMaxEntriesLRU(t *testing.T) {
+	var captured []string
+	cache := NewCache(SetShards(1), SetMaxEntries(2), SetCapture(func(k string, v interface{}) {
+		captured = append(captured, k)
+	}))
+
+	cache.Set("key1", 1, 0)
+	cache.Set("key2", 2, 0)
+	cache.Get("key1")
+	cache.Set("key3", 3, 0)
+
+	if count := cache.Count(); count != 2 {
+		t.Errorf("Expected count to be 2, got %d", count)
+	}
+	if _, ok := cache.Get("key2"); ok {
+		t.Error("Expected key2 to be evicted")
+	}
+	if len(captured) != 1 || captured[0] != "key2" {
+		t.Errorf("Expected capture to be called with key2, got %v", captured)
+	}
+
+	// 更新已存在的key不会触发淘汰
+	cache.Set("key1", 10, 0)
+	if count := cache.Count(); count != 2 {
+		t.Errorf("Expected count to be 2 after update, got %d", count)
+	}
+}
+
+func TestMaxEntriesLFU(t *testing.T) {
+	cache := NewCache(SetShards(1), SetMaxEntries(2), SetEvictionPolicy(NewLFUPolicy))
+
+	cache.Set("hot", 1, 0)
+	cache.Set("cold", 2, 0)
+	cache.Get("hot")
+	cache.Get("hot")
+	cache.Get("cold")
+	cache.Set("new", 3, 0)
+
+	if _, ok := cache.Get("cold"); ok {
+		t.Error("Expected cold to be evicted")
+	}
+	if _, ok := cache.Get("hot"); !ok {
+		t.Error("Expected hot to be kept")
+	}
+}
+
+func TestMaxEntriesBound(t *testing.T) {
+	// 分片数量会被限制为不超过最大条目数，总条目数不超过上限
+	cache := NewCache(SetMaxEntries(10))
+	if len(cache.shards) > 10 {
+		t.Errorf("Expected at most 10 shards, got %d", len(cache.shards))
+	}
+
+	for i := 0; i < 1000; i++ {
+		cache.Set("key"+strconv.Itoa(i), i, 0)
+	}
+	if count := cache.Count(); count > 10 {
+		t.Errorf("Expected count to be at most 10, got %d", count)
+	}
+
+	// Delete和Flush之后淘汰策略保持一致
+	cache.Flush()
+	for i := 0; i < 10; i++ {
+		cache.Set("key"+strconv.Itoa(i), i, 0)
+		cache.Delete("key" + strconv.Itoa(i))
+	}
+	if count := cache.Count(); count != 0 {
+		t.Errorf("Expected count to be 0, got %d", count)
+	}
+}
@@ -3,8 +3,9 @@ package localcache
 import "time"
 
 type Iterator struct {
-	Val    interface{} // 实际存储的对象
-	Expire int64       // 过期时间，如果设置0，则表示不过期
+	Val     interface{} // 实际存储的对象
+	Expire  int64       // 过期时间，如果设置0，则表示不过期
+	Sliding int64       // 滑动过期时长（纳秒），大于0时每次命中都会将过期时间顺延该时长
 }
 
 // Expired 判断缓存是否过期
@@ -0,0 +1,44 @@
+package localcache
+
+import (
+	"sync"
+	"time"
+)
+
+// janitor 后台清理协程，定期删除过期的缓存项
+type janitor struct {
+	interval time.Duration // 清理间隔
+	stop     chan struct{} // 停止信号
+	once     sync.Once     // 保证只停止一次
+}
+
+// run 按间隔执行 DeleteExpire，直到收到停止信号
+func (j *janitor) run(c *cache) {
+	ticker := time.NewTicker(j.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			c.DeleteExpire()
+		case <-j.stop:
+			return
+		}
+	}
+}
+
+// close 停止清理协程，可重复调用
+func (j *janitor) close() {
+	j.once.Do(func() {
+		close(j.stop)
+	})
+}
+
+// runJanitor 为缓存启动清理协程
+func runJanitor(c *cache, interval time.Duration) {
+	j := &janitor{
+		interval: interval,
+		stop:     make(chan struct{}),
+	}
+	c.janitor = j
+	go j.run(c)
+}
@@ -0,0 +1,62 @@
+// janitor_test.go
+package localcache
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestJanitor(t *testing.T) {
+	var mu sync.Mutex
+	captured := make(map[string]interface{})
+	cache := NewCache(
+		SetCleanupInterval(20*time.Millisecond),
+		SetCapture(func(k string, v interface{}) {
+			mu.Lock()
+			captured[k] = v
+			mu.Unlock()
+		}),
+	)
+	defer cache.Shutdown()
+
+	cache.Set("expiring", "value", 10*time.Millisecond)
+	cache.Set("permanent", "value", 0)
+
+	// 等待清理协程删除过期项，不通过 Get 访问
+	time.Sleep(100 * time.Millisecond)
+
+	if count := cache.Count(); count != 1 {
+		t.Errorf("Expected count to be 1 after cleanup, got %d", count)
+	}
+	mu.Lock()
+	if _, ok := captured["expiring"]; !ok {
+		t.Error("Expected capture to be called for expired key")
+	}
+	mu.Unlock()
+}
+
+func TestJanitorShutdown(t *testing.T) {
+	cache := NewCache(SetCleanupInterval(10 * time.Millisecond))
+	if cache.janitor == nil {
+		t.Fatal("Expected janitor to be started")
+	}
+
+	// 多次 Shutdown 不会 panic
+	if err := cache.Shutdown(); err != nil {
+		t.Errorf("Expected no error, got %v", err)
+	}
+	if err := cache.Shutdown(); err != nil {
+		t.Errorf("Expected no error, got %v", err)
+	}
+
+	select {
+	case <-cache.janitor.stop:
+	default:
+		t.Error("Expected janitor to be stopped")
+	}
+
+	if NewCache().janitor != nil {
+		t.Error("Expected no janitor without cleanup interval")
+	}
+}
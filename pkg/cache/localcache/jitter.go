@@ -0,0 +1,27 @@
+package localcache
+
+import (
+	"math/rand/v2"
+	"time"
+)
+
+// jitter 返回在 d*(1-f) 到 d*(1+f) 之间均匀分布的时长，f 限制在 0~1，结果至少为1纳秒
+func jitter(d time.Duration, f float64) time.Duration {
+	if f <= 0 || d <= 0 {
+		return d
+	}
+	f = min(f, 1)
+	j := time.Duration(float64(d) * (1 + f*(2*rand.Float64()-1)))
+	return max(j, 1)
+}
+
+// SetWithJitter 设置缓存项，实际TTL在 ttl*(1±f) 内随机分布，f 覆盖 SetDefaultJitter 的默认比例
+func (c *cache) SetWithJitter(k string, v interface{}, ttl time.Duration, f float64) {
+	s := c.getShard(k)
+	s.Lock()
+	evicted := s.setJitter(k, v, ttl, f)
+	capture := c.capture
+	s.Unlock()
+	c.dispatch(capture, EventEvict, evicted)
+	c.publish(EventSet, []kv{{k, v}})
+}
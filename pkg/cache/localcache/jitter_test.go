@@ -0,0 +1,75 @@
+// jitter_test.go
+package localcache
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestJitter(t *testing.T) {
+	if d := jitter(time.Second, 0); d != time.Second {
+		t.Errorf("Expected no jitter, got %v", d)
+	}
+	for i := 0; i < 1000; i++ {
+		if d := jitter(time.Second, 0.2); d < 800*time.Millisecond || d > 1200*time.Millisecond {
+			t.Fatalf("Jitter %v out of range", d)
+		}
+		if d := jitter(time.Second, 5); d <= 0 || d > 2*time.Second {
+			t.Fatalf("Clamped jitter %v out of range", d)
+		}
+	}
+}
+
+// expireSpread 返回缓存项过期时间的最大差值
+func expireSpread(c Cache, n int) time.Duration {
+	var lo, hi time.Time
+	for i := 0; i < n; i++ {
+		_, e, _ := c.GetWithExpire(fmt.Sprintf("k%d", i))
+		if lo.IsZero() || e.Before(lo) {
+			lo = e
+		}
+		if e.After(hi) {
+			hi = e
+		}
+	}
+	return hi.Sub(lo)
+}
+
+func TestSetWithJitter(t *testing.T) {
+	cache := NewCache(SetCapture(nil))
+	for i := 0; i < 100; i++ {
+		cache.SetWithJitter(fmt.Sprintf("k%d", i), i, time.Hour, 0.1)
+	}
+	if spread := expireSpread(cache, 100); spread < time.Minute || spread > 12*time.Minute {
+		t.Errorf("Expected expiry spread within ±6m, got %v", spread)
+	}
+
+	// 默认抖动作用于 Set、Add 等所有写入
+	cache = NewCache(SetCapture(nil), SetDefaultJitter(0.1))
+	for i := 0; i < 100; i++ {
+		cache.Set(fmt.Sprintf("k%d", i), i, time.Hour)
+	}
+	if spread := expireSpread(cache, 100); spread < time.Minute {
+		t.Errorf("Expected default jitter to spread expiry, got %v", spread)
+	}
+
+	// 事务中的写入同样使用默认抖动
+	cache = NewCache(SetCapture(nil), SetDefaultJitter(0.1))
+	_ = cache.Update(func(tx Txn) error {
+		for i := 0; i < 100; i++ {
+			tx.Set(fmt.Sprintf("k%d", i), i, time.Hour)
+		}
+		return nil
+	})
+	if spread := expireSpread(cache, 100); spread < time.Minute {
+		t.Errorf("Expected default jitter to spread txn expiry, got %v", spread)
+	}
+
+	// 显式传入0时不抖动
+	cache.SetWithJitter("exact", 1, time.Hour, 0)
+	_, e, _ := cache.GetWithExpire("exact")
+	if d := time.Until(e); d > time.Hour || d < time.Hour-time.Second {
+		t.Errorf("Expected exact TTL, got %v", d)
+	}
+}
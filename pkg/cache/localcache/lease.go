@@ -0,0 +1,122 @@
+package localcache
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// leaseSeq 租约ID生成序号
+var leaseSeq atomic.Uint64
+
+// Lease 租约句柄，持有者需要在 TTL 内调用 KeepAlive 续期，否则租约key被删除并派发 EventLeaseExpire
+//
+// 适合表示进程内工作协程的在线状态：协程退出或卡死后租约自动过期，订阅者可以据此做清理或接管
+type Lease struct {
+	c     *cache
+	key   string
+	id    uint64
+	ttl   time.Duration
+	mu    sync.Mutex
+	timer *time.Timer
+}
+
+// AcquireLease 以 ttl 获取 key 的租约，缓存值为租约ID，key已存在且未过期时返回 CacheExist
+//
+// 租约不写入追加写日志，配置了持久化钩子时获取和续期按写入转发，释放和到期按删除转发；
+// 租约到期时由定时器删除，如果在此之前被读操作或清理协程删除，派发的是 EventExpire
+func (c *cache) AcquireLease(key string, ttl time.Duration) (*Lease, error) {
+	if ttl <= 0 {
+		return nil, CacheExpire
+	}
+	l := &Lease{c: c, key: key, id: leaseSeq.Add(1), ttl: ttl}
+
+	s := c.getShard(key)
+	s.Lock()
+	_, ok, expired := c.get(s, key)
+	capture := c.capture
+	if ok {
+		s.Unlock()
+		c.dispatch(capture, EventExpire, expired)
+		return nil, CacheExist
+	}
+	it := Iterator{Val: l.id, Expire: time.Now().Add(ttl).UnixNano()}
+	evicted := s.store(key, it)
+	s.persistSet(key, it)
+	l.mu.Lock()
+	l.timer = time.AfterFunc(ttl, l.check)
+	l.mu.Unlock()
+	s.Unlock()
+
+	c.dispatch(capture, EventExpire, expired)
+	c.dispatch(capture, EventEvict, evicted)
+	c.publish(EventSet, []kv{{key, l.id}})
+	return l, nil
+}
+
+// Key 返回租约key
+func (l *Lease) Key() string { return l.key }
+
+// ID 返回租约ID，与缓存中的值相同
+func (l *Lease) ID() uint64 { return l.id }
+
+// KeepAlive 将租约过期时间顺延 TTL 并派发 EventSet，租约已过期、被删除或被其他租约替换时返回 CacheNoExist
+func (l *Lease) KeepAlive() error {
+	s := l.c.getShard(l.key)
+	s.Lock()
+	it, ok := s.member[l.key]
+	if !ok || it.Val != l.id || it.Expired() {
+		s.Unlock()
+		return CacheNoExist
+	}
+	it.Expire = time.Now().Add(l.ttl).UnixNano()
+	s.member[l.key] = it
+	s.persistSet(l.key, it)
+	s.Unlock()
+	l.c.publish(EventSet, []kv{{l.key, l.id}})
+	return nil
+}
+
+// Release 主动释放租约，租约仍然有效时删除key并派发 EventDelete
+func (l *Lease) Release() {
+	l.mu.Lock()
+	if l.timer != nil {
+		l.timer.Stop()
+	}
+	l.mu.Unlock()
+
+	s := l.c.getShard(l.key)
+	s.Lock()
+	it, ok := s.member[l.key]
+	if !ok || it.Val != l.id {
+		s.Unlock()
+		return
+	}
+	v, _ := l.c.delete(s, l.key)
+	capture := l.c.capture
+	s.Unlock()
+	l.c.dispatch(capture, EventDelete, []kv{{l.key, v}})
+}
+
+// check 定时器回调：租约已过期时删除key并派发 EventLeaseExpire，已续期时按剩余时间重新计时
+func (l *Lease) check() {
+	s := l.c.getShard(l.key)
+	s.Lock()
+	it, ok := s.member[l.key]
+	if !ok || it.Val != l.id {
+		s.Unlock()
+		return
+	}
+	now := time.Now().UnixNano()
+	if !it.Expired(now) {
+		s.Unlock()
+		l.mu.Lock()
+		l.timer.Reset(time.Duration(it.Expire-now) + time.Millisecond)
+		l.mu.Unlock()
+		return
+	}
+	v, _ := l.c.delete(s, l.key)
+	capture := l.c.capture
+	s.Unlock()
+	l.c.dispatch(capture, EventLeaseExpire, []kv{{l.key, v}})
+}
@@ -0,0 +1,117 @@
+// lease_test.go
+package localcache
+
+import (
+	"testing"
+	"time"
+)
+
+func TestLease(t *testing.T) {
+	cache := NewCache(SetCapture(nil))
+	expired := make(chan Event, 1)
+	cache.OnLeaseExpire(func(ev Event) { expired <- ev })
+
+	l, err := cache.AcquireLease("worker1", 30*time.Millisecond)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := cache.AcquireLease("worker1", time.Second); !CacheErrExist(err) {
+		t.Errorf("Expected CacheExist for held lease, got %v", err)
+	}
+	if v, ok := cache.Get("worker1"); !ok || v != l.ID() {
+		t.Errorf("Expected lease id as value, got %v, %v", v, ok)
+	}
+
+	// 续期期间不会过期
+	for i := 0; i < 5; i++ {
+		time.Sleep(15 * time.Millisecond)
+		if err := l.KeepAlive(); err != nil {
+			t.Fatalf("KeepAlive %d failed: %v", i, err)
+		}
+	}
+	select {
+	case ev := <-expired:
+		t.Fatalf("Unexpected lease expiry %+v", ev)
+	default:
+	}
+
+	// 停止续期后过期
+	select {
+	case ev := <-expired:
+		if ev.Key != "worker1" || ev.Type != EventLeaseExpire || ev.Value != l.ID() {
+			t.Errorf("Unexpected event %+v", ev)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Expected lease to expire")
+	}
+	if err := l.KeepAlive(); !CacheErrNoExist(err) {
+		t.Errorf("Expected CacheNoExist after expiry, got %v", err)
+	}
+	if cache.Stats().Expired != 1 {
+		t.Errorf("Expected expiry to be counted, got %+v", cache.Stats())
+	}
+}
+
+func TestLeaseRelease(t *testing.T) {
+	cache := NewCache(SetCapture(nil))
+	deleted := make(chan Event, 1)
+	cache.OnDelete(func(ev Event) { deleted <- ev })
+
+	l, err := cache.AcquireLease("worker1", time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	l.Release()
+	if _, ok := cache.Get("worker1"); ok {
+		t.Error("Expected lease key to be deleted")
+	}
+	if ev := <-deleted; ev.Key != "worker1" {
+		t.Errorf("Unexpected event %+v", ev)
+	}
+
+	// 新租约不会被旧句柄释放
+	l2, err := cache.AcquireLease("worker1", time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	l.Release()
+	if err := l.KeepAlive(); !CacheErrNoExist(err) {
+		t.Errorf("Expected old handle to be invalid, got %v", err)
+	}
+	if err := l2.KeepAlive(); err != nil {
+		t.Errorf("Expected new lease to be valid, got %v", err)
+	}
+}
+
+func TestLeasePersist(t *testing.T) {
+	r := &recorder{}
+	cache := NewCache(SetCapture(nil), SetWriteThrough(r.persist))
+	defer cache.Shutdown()
+	sets := make(chan Event, 2)
+	cache.OnSet(func(ev Event) { sets <- ev })
+
+	l, err := cache.AcquireLease("worker1", time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(time.Millisecond)
+	if err = l.KeepAlive(); err != nil {
+		t.Fatal(err)
+	}
+	l.Release()
+
+	// 获取和续期按写入转发并派发 EventSet，释放按删除转发
+	if len(sets) != 2 {
+		t.Errorf("Expected EventSet for acquire and keepalive, got %d", len(sets))
+	}
+	ops := r.ops()
+	if len(ops) != 3 {
+		t.Fatalf("Expected 3 persisted ops, got %+v", ops)
+	}
+	if ops[0].Value != l.ID() || ops[0].Expire.IsZero() || !ops[1].Expire.After(ops[0].Expire) {
+		t.Errorf("Expected keepalive to extend the persisted expiry, got %+v", ops[:2])
+	}
+	if !ops[2].Delete {
+		t.Errorf("Expected release to be a delete, got %+v", ops[2])
+	}
+}
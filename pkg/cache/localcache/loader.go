@@ -0,0 +1,29 @@
+package localcache
+
+import (
+	"time"
+)
+
+// GetOrLoad 根据key获取 cache，不存在时调用 loader 加载并写入缓存
+//
+// 同一个key的并发加载会被合并（singleflight），只有一个调用方执行 loader，
+// 其他调用方等待并共享结果，避免缓存击穿；loader 返回错误时不写入缓存
+func (c *cache) GetOrLoad(k string, loader func() (interface{}, time.Duration, error)) (interface{}, error) {
+	if v, ok := c.Get(k); ok {
+		return v, nil
+	}
+
+	v, err, _ := c.loadGroup.Do(k, func() (interface{}, error) {
+		// 等待期间其他调用方可能已经写入，再检查一次
+		if v, ok := c.Get(k); ok {
+			return v, nil
+		}
+		v, d, err := loader()
+		if err != nil {
+			return nil, err
+		}
+		c.Set(k, v, d)
+		return v, nil
+	})
+	return v, err
+}
@@ -0,0 +1,80 @@
+// loader_test.go
+package localcache
+
+import (
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestGetOrLoad(t *testing.T) {
+	cache := NewCache()
+
+	calls := 0
+	loader := func() (interface{}, time.Duration, error) {
+		calls++
+		return "loaded", 0, nil
+	}
+
+	// 第一次加载并写入缓存
+	if v, err := cache.GetOrLoad("key", loader); err != nil || v != "loaded" {
+		t.Errorf("Expected loaded value, got %v, %v", v, err)
+	}
+	// 第二次直接命中缓存
+	if v, err := cache.GetOrLoad("key", loader); err != nil || v != "loaded" {
+		t.Errorf("Expected cached value, got %v, %v", v, err)
+	}
+	if calls != 1 {
+		t.Errorf("Expected loader to be called once, got %d", calls)
+	}
+}
+
+func TestGetOrLoadError(t *testing.T) {
+	cache := NewCache()
+	loadErr := errors.New("load failed")
+
+	_, err := cache.GetOrLoad("key", func() (interface{}, time.Duration, error) {
+		return nil, 0, loadErr
+	})
+	if !errors.Is(err, loadErr) {
+		t.Errorf("Expected load error, got %v", err)
+	}
+	// 加载失败不写入缓存
+	if _, ok := cache.Get("key"); ok {
+		t.Error("Expected key to not exist after failed load")
+	}
+}
+
+func TestGetOrLoadConcurrent(t *testing.T) {
+	cache := NewCache()
+
+	var calls int32
+	release := make(chan struct{})
+	loader := func() (interface{}, time.Duration, error) {
+		atomic.AddInt32(&calls, 1)
+		<-release
+		return 42, time.Minute, nil
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if v, err := cache.GetOrLoad("key", loader); err != nil || v != 42 {
+				t.Errorf("Expected 42, got %v, %v", v, err)
+			}
+		}()
+	}
+
+	// 等待所有调用方进入加载流程后再放行
+	time.Sleep(50 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	if n := atomic.LoadInt32(&calls); n != 1 {
+		t.Errorf("Expected loader to be called once, got %d", n)
+	}
+}
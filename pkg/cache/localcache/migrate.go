@@ -0,0 +1,45 @@
+package localcache
+
+import (
+	"fmt"
+	"sync"
+)
+
+// Migration 将版本 from 的缓存项迁移为版本 from+1 的缓存项
+type Migration func(member map[string]Iterator) (map[string]Iterator, error)
+
+var (
+	migrationMu sync.RWMutex
+	migrations  = map[uint8]Migration{
+		// 版本0（无文件头的 gob）与版本1的缓存项结构一致
+		0: func(member map[string]Iterator) (map[string]Iterator, error) { return member, nil },
+		// 版本2只在文件头中增加了快照时间
+		1: func(member map[string]Iterator) (map[string]Iterator, error) { return member, nil },
+	}
+)
+
+// RegisterMigration 注册从版本 from 到 from+1 的迁移，Load 时会依次执行直到 FileVersion
+//
+// 同一个版本重复注册会覆盖之前的迁移
+func RegisterMigration(from uint8, m Migration) {
+	migrationMu.Lock()
+	defer migrationMu.Unlock()
+	migrations[from] = m
+}
+
+// migrate 将版本 version 的缓存项依次迁移到 FileVersion，缺少某一步迁移时返回 CacheVersionErr
+func migrate(member map[string]Iterator, version uint8) (map[string]Iterator, error) {
+	migrationMu.RLock()
+	defer migrationMu.RUnlock()
+	for v := version; v < FileVersion; v++ {
+		m, ok := migrations[v]
+		if !ok {
+			return nil, fmt.Errorf("%w: no migration from file version %d", CacheVersionErr, v)
+		}
+		var err error
+		if member, err = m(member); err != nil {
+			return nil, fmt.Errorf("migrate file version %d: %w", v, err)
+		}
+	}
+	return member, nil
+}
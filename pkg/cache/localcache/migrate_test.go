@@ -0,0 +1,61 @@
+// migrate_test.go
+package localcache
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestMigrate(t *testing.T) {
+	member := map[string]Iterator{"key1": {Val: "value1"}}
+
+	// 当前版本不需要迁移
+	if got, err := migrate(member, FileVersion); err != nil || got["key1"].Val != "value1" {
+		t.Errorf("Unexpected result: %v %v", got, err)
+	}
+
+	// 版本0通过内置迁移升级
+	if got, err := migrate(member, 0); err != nil || got["key1"].Val != "value1" {
+		t.Errorf("Unexpected result: %v %v", got, err)
+	}
+}
+
+func TestRegisterMigration(t *testing.T) {
+	old := migrations[0]
+	defer RegisterMigration(0, old)
+
+	RegisterMigration(0, func(member map[string]Iterator) (map[string]Iterator, error) {
+		ret := make(map[string]Iterator, len(member))
+		for k, v := range member {
+			ret["v1:"+k] = v
+		}
+		return ret, nil
+	})
+	got, err := migrate(map[string]Iterator{"key1": {Val: 1}}, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := got["v1:key1"]; !ok {
+		t.Errorf("Expected migrated key, got %v", got)
+	}
+
+	RegisterMigration(0, func(map[string]Iterator) (map[string]Iterator, error) {
+		return nil, errors.New("broken")
+	})
+	if _, err := migrate(map[string]Iterator{}, 0); err == nil || !strings.Contains(err.Error(), "broken") {
+		t.Errorf("Expected migration error, got %v", err)
+	}
+}
+
+func TestMigrateMissing(t *testing.T) {
+	old := migrations[0]
+	migrationMu.Lock()
+	delete(migrations, 0)
+	migrationMu.Unlock()
+	defer RegisterMigration(0, old)
+
+	if _, err := migrate(map[string]Iterator{}, 0); !errors.Is(err, CacheVersionErr) {
+		t.Errorf("Expected CacheVersionErr, got %v", err)
+	}
+}
@@ -0,0 +1,384 @@
+package localcache
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"reflect"
+)
+
+// msgpackCodec msgpack 编解码器
+//
+// 支持 nil、bool、整数、浮点数、string、[]byte 以及由它们组成的切片和 string 为key的map，
+// 解码后整数统一为 int64（超出 int64 范围的为 uint64），切片为 []interface{}，map 为 map[string]interface{}
+type msgpackCodec struct{}
+
+// MsgpackCodec 返回 msgpack 编解码器
+func MsgpackCodec() Codec { return msgpackCodec{} }
+
+func (msgpackCodec) Name() string { return "msgpack" }
+
+// Encode 编码为 map: key -> [val, expire]，滑动过期的缓存项为 [val, expire, sliding]
+func (msgpackCodec) Encode(w io.Writer, member map[string]Iterator) error {
+	e := &msgpackEncoder{w: bufio.NewWriter(w)}
+	e.writeLen(0x80, 0xde, 0xdf, len(member))
+	for k, iterator := range member {
+		e.writeString(k)
+		if iterator.Sliding > 0 {
+			e.writeLen(0x90, 0xdc, 0xdd, 3)
+		} else {
+			e.writeLen(0x90, 0xdc, 0xdd, 2)
+		}
+		if err := e.encode(reflect.ValueOf(iterator.Val)); err != nil {
+			return fmt.Errorf("%w: key %q", err, k)
+		}
+		e.writeInt(iterator.Expire)
+		if iterator.Sliding > 0 {
+			e.writeInt(iterator.Sliding)
+		}
+	}
+	if e.err != nil {
+		return e.err
+	}
+	return e.w.Flush()
+}
+
+func (msgpackCodec) Decode(r io.Reader) (map[string]Iterator, error) {
+	d := &msgpackDecoder{r: bufio.NewReader(r)}
+	v, err := d.decode()
+	if err != nil {
+		return nil, err
+	}
+	entries, ok := v.(map[string]interface{})
+	if !ok {
+		return nil, CacheCodecErr
+	}
+	member := make(map[string]Iterator, len(entries))
+	for k, e := range entries {
+		pair, ok := e.([]interface{})
+		if !ok || len(pair) < 2 || len(pair) > 3 {
+			return nil, CacheCodecErr
+		}
+		it := Iterator{Val: pair[0]}
+		if it.Expire, ok = pair[1].(int64); !ok {
+			return nil, CacheCodecErr
+		}
+		if len(pair) == 3 {
+			if it.Sliding, ok = pair[2].(int64); !ok {
+				return nil, CacheCodecErr
+			}
+		}
+		member[k] = it
+	}
+	return member, nil
+}
+
+// msgpackEncoder msgpack 编码器，出错后忽略后续写入，由调用方统一检查 err
+type msgpackEncoder struct {
+	w   *bufio.Writer
+	err error
+	buf [9]byte
+}
+
+func (e *msgpackEncoder) write(b []byte) {
+	if e.err == nil {
+		_, e.err = e.w.Write(b)
+	}
+}
+
+// writeLen 写入 map/array 的长度，fix 为短格式前缀，c16/c32 为16位和32位长度的格式码
+func (e *msgpackEncoder) writeLen(fix, c16, c32 byte, n int) {
+	switch {
+	case n < 16:
+		e.write([]byte{fix | byte(n)})
+	case n <= math.MaxUint16:
+		e.buf[0] = c16
+		binary.BigEndian.PutUint16(e.buf[1:], uint16(n))
+		e.write(e.buf[:3])
+	default:
+		e.buf[0] = c32
+		binary.BigEndian.PutUint32(e.buf[1:], uint32(n))
+		e.write(e.buf[:5])
+	}
+}
+
+func (e *msgpackEncoder) writeBytes(c8, c16, c32 byte, b []byte) {
+	switch n := len(b); {
+	case n <= math.MaxUint8:
+		e.write([]byte{c8, byte(n)})
+	case n <= math.MaxUint16:
+		e.buf[0] = c16
+		binary.BigEndian.PutUint16(e.buf[1:], uint16(n))
+		e.write(e.buf[:3])
+	default:
+		e.buf[0] = c32
+		binary.BigEndian.PutUint32(e.buf[1:], uint32(n))
+		e.write(e.buf[:5])
+	}
+	e.write(b)
+}
+
+func (e *msgpackEncoder) writeString(s string) {
+	if len(s) < 32 {
+		e.write([]byte{0xa0 | byte(len(s))})
+		e.write([]byte(s))
+		return
+	}
+	e.writeBytes(0xd9, 0xda, 0xdb, []byte(s))
+}
+
+func (e *msgpackEncoder) writeInt(n int64) {
+	if n >= -32 && n <= math.MaxInt8 {
+		e.write([]byte{byte(n)})
+		return
+	}
+	e.buf[0] = 0xd3
+	binary.BigEndian.PutUint64(e.buf[1:], uint64(n))
+	e.write(e.buf[:9])
+}
+
+func (e *msgpackEncoder) writeUint(n uint64) {
+	if n <= math.MaxInt8 {
+		e.write([]byte{byte(n)})
+		return
+	}
+	e.buf[0] = 0xcf
+	binary.BigEndian.PutUint64(e.buf[1:], n)
+	e.write(e.buf[:9])
+}
+
+func (e *msgpackEncoder) encode(v reflect.Value) error {
+	if !v.IsValid() {
+		e.write([]byte{0xc0})
+		return nil
+	}
+	switch v.Kind() {
+	case reflect.Bool:
+		if v.Bool() {
+			e.write([]byte{0xc3})
+		} else {
+			e.write([]byte{0xc2})
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		e.writeInt(v.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		e.writeUint(v.Uint())
+	case reflect.Float32:
+		e.buf[0] = 0xca
+		binary.BigEndian.PutUint32(e.buf[1:], math.Float32bits(float32(v.Float())))
+		e.write(e.buf[:5])
+	case reflect.Float64:
+		e.buf[0] = 0xcb
+		binary.BigEndian.PutUint64(e.buf[1:], math.Float64bits(v.Float()))
+		e.write(e.buf[:9])
+	case reflect.String:
+		e.writeString(v.String())
+	case reflect.Slice, reflect.Array:
+		if v.Kind() == reflect.Slice && v.Type().Elem().Kind() == reflect.Uint8 {
+			e.writeBytes(0xc4, 0xc5, 0xc6, v.Bytes())
+			return nil
+		}
+		if v.Kind() == reflect.Slice && v.IsNil() {
+			e.write([]byte{0xc0})
+			return nil
+		}
+		e.writeLen(0x90, 0xdc, 0xdd, v.Len())
+		for i := 0; i < v.Len(); i++ {
+			if err := e.encode(v.Index(i)); err != nil {
+				return err
+			}
+		}
+	case reflect.Map:
+		if v.Type().Key().Kind() != reflect.String {
+			return CacheMsgpackErr
+		}
+		if v.IsNil() {
+			e.write([]byte{0xc0})
+			return nil
+		}
+		e.writeLen(0x80, 0xde, 0xdf, v.Len())
+		iter := v.MapRange()
+		for iter.Next() {
+			e.writeString(iter.Key().String())
+			if err := e.encode(iter.Value()); err != nil {
+				return err
+			}
+		}
+	case reflect.Interface, reflect.Pointer:
+		if v.IsNil() {
+			e.write([]byte{0xc0})
+			return nil
+		}
+		return e.encode(v.Elem())
+	default:
+		return CacheMsgpackErr
+	}
+	return nil
+}
+
+// 解码限制，避免损坏或恶意构造的快照耗尽内存和栈
+const (
+	msgpackReadChunk = 64 << 10 // 超过该长度时按实际读到的数据增长缓冲区，而不是按声明的长度一次分配
+	msgpackMaxDepth  = 64       // 数组和 map 的最大嵌套层数
+)
+
+// msgpackDecoder msgpack 解码器
+type msgpackDecoder struct {
+	r     *bufio.Reader
+	depth int
+}
+
+// read 读取 n 字节，n 来自未校验的长度字段，较大时分块读取，数据不足时返回 io.ErrUnexpectedEOF
+func (d *msgpackDecoder) read(n int) ([]byte, error) {
+	if n <= msgpackReadChunk {
+		b := make([]byte, n)
+		_, err := io.ReadFull(d.r, b)
+		return b, err
+	}
+	buf := bytes.NewBuffer(make([]byte, 0, msgpackReadChunk))
+	m, err := buf.ReadFrom(io.LimitReader(d.r, int64(n)))
+	if err == nil && m < int64(n) {
+		err = io.ErrUnexpectedEOF
+	}
+	return buf.Bytes(), err
+}
+
+// readUint 读取 n 字节的大端无符号整数
+func (d *msgpackDecoder) readUint(n int) (uint64, error) {
+	b, err := d.read(n)
+	if err != nil {
+		return 0, err
+	}
+	var u uint64
+	for _, c := range b {
+		u = u<<8 | uint64(c)
+	}
+	return u, nil
+}
+
+func (d *msgpackDecoder) decode() (interface{}, error) {
+	c, err := d.r.ReadByte()
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case c <= 0x7f:
+		return int64(c), nil
+	case c >= 0xe0:
+		return int64(int8(c)), nil
+	case c&0xf0 == 0x80:
+		return d.decodeMap(int(c & 0x0f))
+	case c&0xf0 == 0x90:
+		return d.decodeArray(int(c & 0x0f))
+	case c&0xe0 == 0xa0:
+		b, err := d.read(int(c & 0x1f))
+		return string(b), err
+	}
+
+	switch c {
+	case 0xc0:
+		return nil, nil
+	case 0xc2:
+		return false, nil
+	case 0xc3:
+		return true, nil
+	case 0xc4, 0xc5, 0xc6, 0xd9, 0xda, 0xdb:
+		// bin8/16/32 与 str8/16/32 的长度字节数分别为 1、2、4
+		size := map[byte]int{0xc4: 1, 0xc5: 2, 0xc6: 4, 0xd9: 1, 0xda: 2, 0xdb: 4}[c]
+		n, err := d.readUint(size)
+		if err != nil {
+			return nil, err
+		}
+		b, err := d.read(int(n))
+		if c >= 0xd9 {
+			return string(b), err
+		}
+		return b, err
+	case 0xca:
+		u, err := d.readUint(4)
+		return float64(math.Float32frombits(uint32(u))), err
+	case 0xcb:
+		u, err := d.readUint(8)
+		return math.Float64frombits(u), err
+	case 0xcc, 0xcd, 0xce, 0xcf:
+		u, err := d.readUint(1 << (c - 0xcc))
+		if u > math.MaxInt64 {
+			return u, err
+		}
+		return int64(u), err
+	case 0xd0:
+		u, err := d.readUint(1)
+		return int64(int8(u)), err
+	case 0xd1:
+		u, err := d.readUint(2)
+		return int64(int16(u)), err
+	case 0xd2:
+		u, err := d.readUint(4)
+		return int64(int32(u)), err
+	case 0xd3:
+		u, err := d.readUint(8)
+		return int64(u), err
+	case 0xdc, 0xdd:
+		n, err := d.readUint(2 << (c - 0xdc))
+		if err != nil {
+			return nil, err
+		}
+		return d.decodeArray(int(n))
+	case 0xde, 0xdf:
+		n, err := d.readUint(2 << (c - 0xde))
+		if err != nil {
+			return nil, err
+		}
+		return d.decodeMap(int(n))
+	}
+	return nil, CacheCodecErr
+}
+
+// enter 进入一层数组或 map，超过 msgpackMaxDepth 时返回错误
+func (d *msgpackDecoder) enter() error {
+	if d.depth++; d.depth > msgpackMaxDepth {
+		return CacheCodecErr
+	}
+	return nil
+}
+
+func (d *msgpackDecoder) decodeArray(n int) (interface{}, error) {
+	if err := d.enter(); err != nil {
+		return nil, err
+	}
+	defer func() { d.depth-- }()
+	ret := make([]interface{}, 0, min(n, 1024))
+	for i := 0; i < n; i++ {
+		v, err := d.decode()
+		if err != nil {
+			return nil, err
+		}
+		ret = append(ret, v)
+	}
+	return ret, nil
+}
+
+func (d *msgpackDecoder) decodeMap(n int) (interface{}, error) {
+	if err := d.enter(); err != nil {
+		return nil, err
+	}
+	defer func() { d.depth-- }()
+	ret := make(map[string]interface{}, min(n, 1024))
+	for i := 0; i < n; i++ {
+		k, err := d.decode()
+		if err != nil {
+			return nil, err
+		}
+		key, ok := k.(string)
+		if !ok {
+			return nil, CacheCodecErr
+		}
+		if ret[key], err = d.decode(); err != nil {
+			return nil, err
+		}
+	}
+	return ret, nil
+}
@@ -0,0 +1,193 @@
+package localcache
+
+import (
+	"strings"
+	"time"
+)
+
+// NamespaceSeparator 命名空间与key之间的分隔符
+const NamespaceSeparator = ":"
+
+// Namespace 缓存的命名空间视图，所有key自动加上 "name:" 前缀
+//
+// 多个组件可以共享同一个缓存实例而不会发生key冲突，Flush、Count、Iterator 等只作用于当前命名空间；
+// 容量、内存预算、事件订阅和统计与底层缓存共享，事件中的key为带前缀的完整key
+type Namespace struct {
+	c      *cache
+	name   string
+	prefix string
+}
+
+// Namespace 返回名为 name 的命名空间视图，视图本身不持有资源，可以随时创建
+func (c *cache) Namespace(name string) *Namespace {
+	return &Namespace{c: c, name: name, prefix: name + NamespaceSeparator}
+}
+
+// Namespace 返回嵌套的子命名空间，前缀为 "parent:name:"
+func (n *Namespace) Namespace(name string) *Namespace {
+	return &Namespace{c: n.c, name: n.prefix + name, prefix: n.prefix + name + NamespaceSeparator}
+}
+
+// Name 返回命名空间的完整名称
+func (n *Namespace) Name() string {
+	return n.name
+}
+
+// key 返回带前缀的完整key
+func (n *Namespace) key(k string) string {
+	return n.prefix + k
+}
+
+// Set 添加cache 无论是否存在都会覆盖
+func (n *Namespace) Set(k string, v interface{}, d time.Duration) {
+	n.c.Set(n.key(k), v, d)
+}
+
+// SetDefault 添加cache 使用默认超时时间
+func (n *Namespace) SetDefault(k string, v interface{}) {
+	n.c.SetDefault(n.key(k), v)
+}
+
+// SetNoExpire 添加cache 永不过期
+func (n *Namespace) SetNoExpire(k string, v interface{}) {
+	n.c.SetNoExpire(n.key(k), v)
+}
+
+// Get 根据key获取 cache
+func (n *Namespace) Get(k string) (interface{}, bool) {
+	return n.c.Get(n.key(k))
+}
+
+// GetWithExpire 根据key获取 cache 并带出超时时间
+func (n *Namespace) GetWithExpire(k string) (interface{}, time.Time, bool) {
+	return n.c.GetWithExpire(n.key(k))
+}
+
+// GetOrLoad 根据key获取 cache，不存在时调用 loader 加载并写入缓存
+func (n *Namespace) GetOrLoad(k string, loader func() (interface{}, time.Duration, error)) (interface{}, error) {
+	return n.c.GetOrLoad(n.key(k), loader)
+}
+
+// Add 添加 cache 如果存在则抛出异常
+func (n *Namespace) Add(k string, x interface{}, d time.Duration) error {
+	return n.c.Add(n.key(k), x, d)
+}
+
+// Replace 替换 cache 如果不存在则抛出异常
+func (n *Namespace) Replace(k string, x interface{}, d time.Duration) error {
+	return n.c.Replace(n.key(k), x, d)
+}
+
+// Delete 删除对应的 cache
+func (n *Namespace) Delete(k string) {
+	n.c.Delete(n.key(k))
+}
+
+// Increment 为k对应的value增加n
+func (n *Namespace) Increment(k string, x int64) (interface{}, error) {
+	return n.c.Increment(n.key(k), x)
+}
+
+// Decrement 为k对应的value减少n
+func (n *Namespace) Decrement(k string, x int64) (interface{}, error) {
+	return n.c.Decrement(n.key(k), x)
+}
+
+// Expire 重新设置k的过期时间
+func (n *Namespace) Expire(k string, d time.Duration) error {
+	return n.c.Expire(n.key(k), d)
+}
+
+// Persist 移除k的过期时间
+func (n *Namespace) Persist(k string) error {
+	return n.c.Persist(n.key(k))
+}
+
+// TTL 返回k的剩余存活时间
+func (n *Namespace) TTL(k string) (time.Duration, error) {
+	return n.c.TTL(n.key(k))
+}
+
+// Scan 遍历命名空间内以 prefix 开头且未过期的缓存项，fn 收到的key不带命名空间前缀
+func (n *Namespace) Scan(prefix string, fn func(k string, v interface{}) bool) {
+	n.c.Scan(n.key(prefix), func(k string, v interface{}) bool {
+		return fn(strings.TrimPrefix(k, n.prefix), v)
+	})
+}
+
+// Keys 返回命名空间内匹配 glob 模式且未过期的key，返回的key不带命名空间前缀
+func (n *Namespace) Keys(pattern string) ([]string, error) {
+	keys, err := n.c.Keys(escapePattern(n.prefix) + pattern)
+	for i, k := range keys {
+		keys[i] = strings.TrimPrefix(k, n.prefix)
+	}
+	return keys, err
+}
+
+// Iterator 返回命名空间内所有有效的对象，key不带命名空间前缀
+func (n *Namespace) Iterator() map[string]Iterator {
+	ret := make(map[string]Iterator)
+	var keys []string
+	for _, s := range n.c.shards {
+		s.RLock()
+		for k, v := range s.member {
+			if !strings.HasPrefix(k, n.prefix) {
+				continue
+			}
+			if !v.Expired() {
+				ret[strings.TrimPrefix(k, n.prefix)] = v
+			} else {
+				keys = append(keys, k)
+			}
+		}
+		s.RUnlock()
+	}
+	// 清除过期key
+	for _, key := range keys {
+		n.c.deleteExpired(key)
+	}
+	return ret
+}
+
+// Count 计算命名空间内 kv的数量 (所有)
+func (n *Namespace) Count() int {
+	count := 0
+	for _, s := range n.c.shards {
+		s.RLock()
+		for k := range s.member {
+			if strings.HasPrefix(k, n.prefix) {
+				count++
+			}
+		}
+		s.RUnlock()
+	}
+	return count
+}
+
+// Flush 清空命名空间内的所有缓存项，不影响其他命名空间
+func (n *Namespace) Flush() {
+	for _, s := range n.c.shards {
+		s.Lock()
+		for k := range s.member {
+			if strings.HasPrefix(k, n.prefix) {
+				n.c.delete(s, k)
+			}
+		}
+		s.Unlock()
+	}
+}
+
+// escapePattern 转义 glob 元字符，使 s 在模式中按字面匹配
+func escapePattern(s string) string {
+	if !strings.ContainsAny(s, `*?[\`) {
+		return s
+	}
+	var b strings.Builder
+	for _, r := range s {
+		if strings.ContainsRune(`*?[\`, r) {
+			b.WriteByte('\\')
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
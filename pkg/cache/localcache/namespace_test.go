@@ -0,0 +1,83 @@
+// namespace_test.go
+package localcache
+
+import (
+	"sort"
+	"testing"
+	"time"
+)
+
+func TestNamespace(t *testing.T) {
+	cache := NewCache(SetCapture(nil))
+	users := cache.Namespace("users")
+	orders := cache.Namespace("orders")
+
+	users.Set("1", "alice", 0)
+	users.Set("2", "bob", 0)
+	orders.Set("1", "order-1", 0)
+	cache.Set("1", "global", 0)
+
+	if v, ok := users.Get("1"); !ok || v != "alice" {
+		t.Errorf("Expected users:1=alice, got %v, %v", v, ok)
+	}
+	if v, ok := orders.Get("1"); !ok || v != "order-1" {
+		t.Errorf("Expected orders:1=order-1, got %v, %v", v, ok)
+	}
+	if v, ok := cache.Get("users:1"); !ok || v != "alice" {
+		t.Errorf("Expected raw key users:1=alice, got %v, %v", v, ok)
+	}
+	if users.Count() != 2 || orders.Count() != 1 {
+		t.Errorf("Unexpected counts users=%d orders=%d", users.Count(), orders.Count())
+	}
+
+	items := users.Iterator()
+	if len(items) != 2 || items["2"].Val != "bob" {
+		t.Errorf("Unexpected iterator result %v", items)
+	}
+
+	users.Flush()
+	if users.Count() != 0 {
+		t.Errorf("Expected users namespace to be empty, got %d", users.Count())
+	}
+	if orders.Count() != 1 || cache.Count() != 2 {
+		t.Errorf("Expected other keys to survive, orders=%d total=%d", orders.Count(), cache.Count())
+	}
+}
+
+func TestNamespaceKeys(t *testing.T) {
+	cache := NewCache(SetCapture(nil))
+	ns := cache.Namespace("a*b")
+	ns.Set("x1", 1, 0)
+	ns.Set("x2", 2, 0)
+	ns.Set("y", 3, 0)
+	ns.Set("short", 4, 10*time.Millisecond)
+	cache.Set("aZb:x3", 5, 0)
+
+	keys, err := ns.Keys("x*")
+	if err != nil {
+		t.Fatalf("Keys failed: %v", err)
+	}
+	sort.Strings(keys)
+	if len(keys) != 2 || keys[0] != "x1" || keys[1] != "x2" {
+		t.Errorf("Unexpected keys %v", keys)
+	}
+
+	time.Sleep(20 * time.Millisecond)
+	var scanned []string
+	ns.Scan("", func(k string, _ interface{}) bool {
+		scanned = append(scanned, k)
+		return true
+	})
+	if len(scanned) != 3 {
+		t.Errorf("Expected 3 live keys, got %v", scanned)
+	}
+
+	sub := ns.Namespace("sub")
+	sub.Set("k", "v", 0)
+	if sub.Name() != "a*b:sub" {
+		t.Errorf("Unexpected name %s", sub.Name())
+	}
+	if v, ok := cache.Get("a*b:sub:k"); !ok || v != "v" {
+		t.Errorf("Expected nested key, got %v, %v", v, ok)
+	}
+}
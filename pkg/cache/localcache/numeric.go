@@ -0,0 +1,310 @@
+package localcache
+
+// Integer 整数类型约束
+type Integer interface {
+	~int | ~int8 | ~int16 | ~int32 | ~int64 |
+		~uint | ~uint8 | ~uint16 | ~uint32 | ~uint64 | ~uintptr
+}
+
+// Float 浮点数类型约束
+type Float interface {
+	~float32 | ~float64
+}
+
+// Number 数值类型约束
+type Number interface {
+	Integer | Float
+}
+
+// update 在分片锁内用 fn 计算k的新值，与 Set 一样写回并派发 EventSet，返回新值
+//
+// k不存在返回 CacheNoExist，已过期则删除k并返回 CacheExpire
+func (c *cache) update(k string, fn func(v interface{}) (interface{}, error)) (interface{}, error) {
+	s := c.getShard(k)
+	s.Lock()
+	v, ok := s.member[k]
+	if !ok {
+		s.Unlock()
+		return nil, CacheNoExist
+	}
+	if v.Expired() {
+		s.Unlock()
+		c.deleteExpired(k)
+		return nil, CacheExpire
+	}
+	ret, err := fn(v.Val)
+	if err != nil {
+		s.Unlock()
+		return nil, err
+	}
+	v.Val = ret
+	evicted := s.write(k, v)
+	capture := c.capture
+	s.Unlock()
+	c.dispatch(capture, EventEvict, evicted)
+	c.publish(EventSet, []kv{{k, ret}})
+	return ret, nil
+}
+
+// IncrementN 为k对应的value增加n，value 的类型必须与 T 完全一致，返回增加后的值
+func IncrementN[T Number](c Cache, k string, n T) (T, error) {
+	return addN(c, k, n)
+}
+
+// DecrementN 为k对应的value减少n，value 的类型必须与 T 完全一致，返回减少后的值
+func DecrementN[T Number](c Cache, k string, n T) (T, error) {
+	return addN(c, k, -n)
+}
+
+// addN IncrementN 和 DecrementN 的实现，无符号整数取反后相加与相减结果一致
+func addN[T Number](c Cache, k string, n T) (T, error) {
+	v, err := c.update(k, func(v interface{}) (interface{}, error) {
+		i, ok := v.(T)
+		if !ok {
+			return nil, CacheTypeErr
+		}
+		return i + n, nil
+	})
+	if err != nil {
+		var zero T
+		return zero, err
+	}
+	return v.(T), nil
+}
+
+// addAny 将 n 按 v 的实际数值类型转换后相加
+func addAny[N int64 | float64](v interface{}, n N) (interface{}, error) {
+	switch i := v.(type) {
+	case int:
+		return i + int(n), nil
+	case int8:
+		return i + int8(n), nil
+	case int16:
+		return i + int16(n), nil
+	case int32:
+		return i + int32(n), nil
+	case int64:
+		return i + int64(n), nil
+	case uint:
+		return i + uint(n), nil
+	case uintptr:
+		return i + uintptr(n), nil
+	case uint8:
+		return i + uint8(n), nil
+	case uint16:
+		return i + uint16(n), nil
+	case uint32:
+		return i + uint32(n), nil
+	case uint64:
+		return i + uint64(n), nil
+	case float32:
+		return i + float32(n), nil
+	case float64:
+		return i + float64(n), nil
+	}
+	return nil, CacheTypeErr
+}
+
+// Increment 为k对应的value增加n n必须为数字类型，返回增加后的值，类型与原值一致
+func (c *cache) Increment(k string, n int64) (interface{}, error) {
+	return c.update(k, func(v interface{}) (interface{}, error) {
+		return addAny(v, n)
+	})
+}
+
+// Decrement 为k对应的value减少n n必须为数字类型，返回减少后的值，类型与原值一致
+func (c *cache) Decrement(k string, n int64) (interface{}, error) {
+	return c.update(k, func(v interface{}) (interface{}, error) {
+		return addAny(v, -n)
+	})
+}
+
+// IncrementFloat 为k对应的value增加n n必须为浮点数类型，返回增加后的值
+func (c *cache) IncrementFloat(k string, n float64) (interface{}, error) {
+	return c.update(k, func(v interface{}) (interface{}, error) {
+		switch v.(type) {
+		case float32, float64:
+			return addAny(v, n)
+		}
+		return nil, CacheTypeErr
+	})
+}
+
+// DecrementFloat 为k对应的value减少n n必须为浮点数类型，返回减少后的值
+func (c *cache) DecrementFloat(k string, n float64) (interface{}, error) {
+	return c.IncrementFloat(k, -n)
+}
+
+// IncrementInt64 为k对应的value增加n n必须为int64类型
+func (c *cache) IncrementInt64(k string, n int64) (int64, error) {
+	return addN(Cache{c}, k, n)
+}
+
+// IncrementFloat64 为k对应的value增加n n必须为float64类型
+func (c *cache) IncrementFloat64(k string, n float64) (float64, error) {
+	return addN(Cache{c}, k, n)
+}
+
+// DecrementInt64 为k对应的value减少n n必须为int64类型
+func (c *cache) DecrementInt64(k string, n int64) (int64, error) {
+	return addN(Cache{c}, k, -n)
+}
+
+// DecrementFloat64 为k对应的value减少n n必须为float64类型
+func (c *cache) DecrementFloat64(k string, n float64) (float64, error) {
+	return addN(Cache{c}, k, -n)
+}
+
+// IncrementInt 为k对应的value增加n n必须为int类型
+//
+// Deprecated: 使用 IncrementN[int]
+func (c *cache) IncrementInt(k string, n int) (int, error) {
+	return IncrementN(Cache{c}, k, n)
+}
+
+// IncrementInt8 为k对应的value增加n n必须为int8类型
+//
+// Deprecated: 使用 IncrementN[int8]
+func (c *cache) IncrementInt8(k string, n int8) (int8, error) {
+	return IncrementN(Cache{c}, k, n)
+}
+
+// IncrementInt16 为k对应的value增加n n必须为int16类型
+//
+// Deprecated: 使用 IncrementN[int16]
+func (c *cache) IncrementInt16(k string, n int16) (int16, error) {
+	return IncrementN(Cache{c}, k, n)
+}
+
+// IncrementInt32 为k对应的value增加n n必须为int32类型
+//
+// Deprecated: 使用 IncrementN[int32]
+func (c *cache) IncrementInt32(k string, n int32) (int32, error) {
+	return IncrementN(Cache{c}, k, n)
+}
+
+// IncrementUint 为k对应的value增加n n必须为uint类型
+//
+// Deprecated: 使用 IncrementN[uint]
+func (c *cache) IncrementUint(k string, n uint) (uint, error) {
+	return IncrementN(Cache{c}, k, n)
+}
+
+// IncrementUint8 为k对应的value增加n n必须为uint8类型
+//
+// Deprecated: 使用 IncrementN[uint8]
+func (c *cache) IncrementUint8(k string, n uint8) (uint8, error) {
+	return IncrementN(Cache{c}, k, n)
+}
+
+// IncrementUint16 为k对应的value增加n n必须为uint16类型
+//
+// Deprecated: 使用 IncrementN[uint16]
+func (c *cache) IncrementUint16(k string, n uint16) (uint16, error) {
+	return IncrementN(Cache{c}, k, n)
+}
+
+// IncrementUint32 为k对应的value增加n n必须为uint32类型
+//
+// Deprecated: 使用 IncrementN[uint32]
+func (c *cache) IncrementUint32(k string, n uint32) (uint32, error) {
+	return IncrementN(Cache{c}, k, n)
+}
+
+// IncrementUint64 为k对应的value增加n n必须为uint64类型
+//
+// Deprecated: 使用 IncrementN[uint64]
+func (c *cache) IncrementUint64(k string, n uint64) (uint64, error) {
+	return IncrementN(Cache{c}, k, n)
+}
+
+// IncrementUintPtr 为k对应的value增加n n必须为uintptr类型
+//
+// Deprecated: 使用 IncrementN[uintptr]
+func (c *cache) IncrementUintPtr(k string, n uintptr) (uintptr, error) {
+	return IncrementN(Cache{c}, k, n)
+}
+
+// IncrementFloat32 为k对应的value增加n n必须为float32类型
+//
+// Deprecated: 使用 IncrementN[float32]
+func (c *cache) IncrementFloat32(k string, n float32) (float32, error) {
+	return IncrementN(Cache{c}, k, n)
+}
+
+// DecrementInt 为k对应的value减少n n必须为int类型
+//
+// Deprecated: 使用 DecrementN[int]
+func (c *cache) DecrementInt(k string, n int) (int, error) {
+	return DecrementN(Cache{c}, k, n)
+}
+
+// DecrementInt8 为k对应的value减少n n必须为int8类型
+//
+// Deprecated: 使用 DecrementN[int8]
+func (c *cache) DecrementInt8(k string, n int8) (int8, error) {
+	return DecrementN(Cache{c}, k, n)
+}
+
+// DecrementInt16 为k对应的value减少n n必须为int16类型
+//
+// Deprecated: 使用 DecrementN[int16]
+func (c *cache) DecrementInt16(k string, n int16) (int16, error) {
+	return DecrementN(Cache{c}, k, n)
+}
+
+// DecrementInt32 为k对应的value减少n n必须为int32类型
+//
+// Deprecated: 使用 DecrementN[int32]
+func (c *cache) DecrementInt32(k string, n int32) (int32, error) {
+	return DecrementN(Cache{c}, k, n)
+}
+
+// DecrementUint 为k对应的value减少n n必须为uint类型
+//
+// Deprecated: 使用 DecrementN[uint]
+func (c *cache) DecrementUint(k string, n uint) (uint, error) {
+	return DecrementN(Cache{c}, k, n)
+}
+
+// DecrementUint8 为k对应的value减少n n必须为uint8类型
+//
+// Deprecated: 使用 DecrementN[uint8]
+func (c *cache) DecrementUint8(k string, n uint8) (uint8, error) {
+	return DecrementN(Cache{c}, k, n)
+}
+
+// DecrementUint16 为k对应的value减少n n必须为uint16类型
+//
+// Deprecated: 使用 DecrementN[uint16]
+func (c *cache) DecrementUint16(k string, n uint16) (uint16, error) {
+	return DecrementN(Cache{c}, k, n)
+}
+
+// DecrementUint32 为k对应的value减少n n必须为uint32类型
+//
+// Deprecated: 使用 DecrementN[uint32]
+func (c *cache) DecrementUint32(k string, n uint32) (uint32, error) {
+	return DecrementN(Cache{c}, k, n)
+}
+
+// DecrementUint64 为k对应的value减少n n必须为uint64类型
+//
+// Deprecated: 使用 DecrementN[uint64]
+func (c *cache) DecrementUint64(k string, n uint64) (uint64, error) {
+	return DecrementN(Cache{c}, k, n)
+}
+
+// DecrementUintPtr 为k对应的value减少n n必须为uintptr类型
+//
+// Deprecated: 使用 DecrementN[uintptr]
+func (c *cache) DecrementUintPtr(k string, n uintptr) (uintptr, error) {
+	return DecrementN(Cache{c}, k, n)
+}
+
+// DecrementFloat32 为k对应的value减少n n必须为float32类型
+//
+// Deprecated: 使用 DecrementN[float32]
+func (c *cache) DecrementFloat32(k string, n float32) (float32, error) {
+	return DecrementN(Cache{c}, k, n)
+}
@@ -0,0 +1,134 @@
+// numeric_test.go
+package localcache
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+type myInt int32
+
+func TestIncrementN(t *testing.T) {
+	cache := NewCache(SetCapture(nil))
+
+	cache.Set("int8", int8(10), 0)
+	if v, err := IncrementN(cache, "int8", int8(5)); err != nil || v != 15 {
+		t.Errorf("Expected 15, got %v %v", v, err)
+	}
+	if v, err := DecrementN(cache, "int8", int8(20)); err != nil || v != -5 {
+		t.Errorf("Expected -5, got %v %v", v, err)
+	}
+
+	cache.Set("uint", uint(10), 0)
+	if v, err := DecrementN(cache, "uint", uint(3)); err != nil || v != 7 {
+		t.Errorf("Expected 7, got %v %v", v, err)
+	}
+
+	cache.Set("float32", float32(1.5), 0)
+	if v, err := IncrementN(cache, "float32", float32(1)); err != nil || v != 2.5 {
+		t.Errorf("Expected 2.5, got %v %v", v, err)
+	}
+
+	// 自定义数值类型
+	cache.Set("my", myInt(1), 0)
+	if v, err := IncrementN(cache, "my", myInt(2)); err != nil || v != 3 {
+		t.Errorf("Expected 3, got %v %v", v, err)
+	}
+
+	// 类型必须完全一致
+	if _, err := IncrementN(cache, "int8", 1); !errors.Is(err, CacheTypeErr) {
+		t.Errorf("Expected CacheTypeErr, got %v", err)
+	}
+	if _, err := IncrementN(cache, "nonexistent", 1); !errors.Is(err, CacheNoExist) {
+		t.Errorf("Expected CacheNoExist, got %v", err)
+	}
+
+	cache.Set("expired", 1, 10*time.Millisecond)
+	time.Sleep(20 * time.Millisecond)
+	if _, err := IncrementN(cache, "expired", 1); !errors.Is(err, CacheExpire) {
+		t.Errorf("Expected CacheExpire, got %v", err)
+	}
+	if _, ok := cache.Get("expired"); ok {
+		t.Error("Expected expired key to be deleted")
+	}
+}
+
+func TestIncrementTyped(t *testing.T) {
+	cache := NewCache(SetCapture(nil))
+
+	cache.Set("int64", int64(1), 0)
+	if v, err := cache.IncrementInt64("int64", 2); err != nil || v != 3 {
+		t.Errorf("Expected 3, got %v %v", v, err)
+	}
+	cache.Set("float64", 1.0, 0)
+	if v, err := cache.DecrementFloat64("float64", 0.5); err != nil || v != 0.5 {
+		t.Errorf("Expected 0.5, got %v %v", v, err)
+	}
+
+	// 兼容的类型化包装方法
+	cache.Set("uint16", uint16(5), 0)
+	if v, err := cache.IncrementUint16("uint16", 1); err != nil || v != 6 {
+		t.Errorf("Expected 6, got %v %v", v, err)
+	}
+	if v, err := cache.DecrementUint16("uint16", 2); err != nil || v != 4 {
+		t.Errorf("Expected 4, got %v %v", v, err)
+	}
+
+	// 无类型方法按原值类型返回新值
+	if v, err := cache.Increment("uint16", 3); err != nil || v != uint16(7) {
+		t.Errorf("Expected uint16(7), got %v %v", v, err)
+	}
+	cache.Set("str", "value", 0)
+	if _, err := cache.Increment("str", 1); !errors.Is(err, CacheTypeErr) {
+		t.Errorf("Expected CacheTypeErr, got %v", err)
+	}
+	if _, err := cache.IncrementFloat("uint16", 1); !errors.Is(err, CacheTypeErr) {
+		t.Errorf("Expected CacheTypeErr for float increment on int, got %v", err)
+	}
+}
+
+func BenchmarkIncrementInt64(b *testing.B) {
+	cache := NewCache(SetCapture(nil))
+	cache.Set("key", int64(0), 0)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_, _ = cache.IncrementInt64("key", 1)
+	}
+}
+
+func BenchmarkIncrementN(b *testing.B) {
+	cache := NewCache(SetCapture(nil))
+	cache.Set("key", int64(0), 0)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_, _ = IncrementN(cache, "key", int64(1))
+	}
+}
+
+func TestIncrementWritesLikeSet(t *testing.T) {
+	cache := NewCache(SetCapture(nil), SetShards(1), SetMaxEntries(2))
+	sets := make(chan Event, 8)
+	cache.OnSet(func(ev Event) { sets <- ev })
+
+	cache.Set("a", int64(1), 0)
+	cache.Set("b", int64(1), 0)
+	if _, err := IncrementN(cache, "a", int64(1)); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := cache.IncrementInt64("a", 1); err != nil {
+		t.Fatal(err)
+	}
+	if len(sets) != 4 {
+		t.Errorf("Expected EventSet for each increment, got %d events", len(sets))
+	}
+
+	// 自增与 Set 一样更新访问记录，淘汰的是最久未访问的 b
+	cache.Set("c", int64(1), 0)
+	if v, ok := cache.Get("a"); !ok || v != int64(3) {
+		t.Errorf("Expected a=3 to survive eviction, got %v %v", v, ok)
+	}
+	if _, ok := cache.Get("b"); ok {
+		t.Error("Expected b to be evicted")
+	}
+}
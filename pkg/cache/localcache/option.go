@@ -2,15 +2,52 @@ package localcache
 
 import (
 	"fmt"
+	"time"
 
 	"github.com/andrewbytecoder/nmq/pkg/options"
 )
 
+// defaultShards 默认分片数量
+const defaultShards = 256
+
 // Config 本地缓存配置结构体
 type Config struct {
 	capture func(key string, value interface{}) // 缓存数据删除捕获函数，当缓存项被删除时会调用此函数
 
 	member map[string]Iterator // 成员映射，存储不同类型的缓存迭代器
+
+	shards int // 分片数量，会向上取整为2的幂
+
+	maxEntries int                   // 最大条目数，0表示不限制
+	newPolicy  func() EvictionPolicy // 达到最大条目数时使用的淘汰策略，默认LRU
+
+	maxBytes      int64                                     // 缓存估算内存上限（字节），0表示不限制
+	sizeEstimator func(key string, value interface{}) int64 // 缓存条目大小估算函数
+
+	cleanupInterval time.Duration // 后台清理过期缓存的间隔，0表示不启动清理协程
+
+	eventSubmit func(task func()) error // 缓存事件异步派发函数，为nil时同步派发
+
+	codec Codec // Save 使用的编解码器，默认gob
+
+	aofSyncInterval    time.Duration // 追加写日志的刷盘间隔
+	aofCompactInterval time.Duration // 追加写日志压缩为快照的间隔，0表示不自动压缩
+	writeLimit         int           // 后台刷盘和快照写入的限速，字节/秒，0表示不限速
+
+	persist persistConfig // 写入和删除的持久化钩子
+
+	sliding bool // Set 设置的过期时间是否为滑动过期
+
+	captureSubmit   func(task func()) error // capture 异步执行函数，为nil时同步执行
+	callbackWorkers int                     // 内置回调协程池的协程数量，0表示不启用
+	callbackQueue   int                     // 内置回调协程池的队列长度
+
+	readRepair int // 读操作时抽查同一分片中过期项的数量，0表示关闭
+
+	jitter float64 // 过期时间的默认抖动比例，0表示不抖动
+
+	warmupFile   string                   // 创建缓存时预热的快照文件
+	warmupReport func(WarmupStats, error) // 预热完成后的回调
 }
 
 // SetCapture 设置缓存删除捕获函数的配置选项
@@ -27,9 +64,193 @@ func SetMember(m map[string]Iterator) options.Option {
 	}
 }
 
+// SetShards 设置缓存分片数量，分片越多锁竞争越小，会向上取整为2的幂
+func SetShards(n int) options.Option {
+	return func(c interface{}) {
+		c.(*Config).shards = n
+	}
+}
+
+// SetMaxEntries 设置缓存最大条目数，超出后按淘汰策略删除旧的缓存项
+//
+// 为保证总条目数不超过 n，分片数量会被限制为不超过 n
+func SetMaxEntries(n int) options.Option {
+	return func(c interface{}) {
+		c.(*Config).maxEntries = n
+	}
+}
+
+// SetEvictionPolicy 设置淘汰策略构造函数，如 NewLRUPolicy、NewLFUPolicy
+func SetEvictionPolicy(newPolicy func() EvictionPolicy) options.Option {
+	return func(c interface{}) {
+		c.(*Config).newPolicy = newPolicy
+	}
+}
+
+// SetMaxBytes 设置缓存估算内存上限（字节），超出水位后按淘汰策略删除旧的缓存项
+//
+// 条目大小由 SetSizeEstimator 设置的函数估算，单个条目超过上限时不会被缓存
+func SetMaxBytes(n int64) options.Option {
+	return func(c interface{}) {
+		c.(*Config).maxBytes = n
+	}
+}
+
+// SetSizeEstimator 设置缓存条目大小估算函数，默认使用 DefaultSizeEstimator
+func SetSizeEstimator(f func(key string, value interface{}) int64) options.Option {
+	return func(c interface{}) {
+		c.(*Config).sizeEstimator = f
+	}
+}
+
+// SetCleanupInterval 设置后台清理过期缓存的间隔，大于0时启动清理协程，调用 Shutdown 停止
+func SetCleanupInterval(d time.Duration) options.Option {
+	return func(c interface{}) {
+		c.(*Config).cleanupInterval = d
+	}
+}
+
+// SetEventSubmit 设置缓存事件的异步派发函数，可以直接传入 NmqContext.Submit 使用 nmq 协程池
+//
+// 提交失败时退化为在调用方协程同步派发
+func SetEventSubmit(submit func(task func()) error) options.Option {
+	return func(c interface{}) {
+		c.(*Config).eventSubmit = submit
+	}
+}
+
+// SetCodec 设置 Save 使用的编解码器，Load 会根据文件头自动选择编解码器，
+// 快照需要压缩时使用 CompressedCodec 包装，例如 CompressedCodec(GobCodec(), zstd)
+func SetCodec(codec Codec) options.Option {
+	return func(c interface{}) {
+		c.(*Config).codec = codec
+	}
+}
+
+// SetAOFSyncInterval 设置追加写日志的刷盘间隔，默认1秒，进程崩溃时最多丢失该间隔内的写入
+func SetAOFSyncInterval(d time.Duration) options.Option {
+	return func(c interface{}) {
+		c.(*Config).aofSyncInterval = d
+	}
+}
+
+// SetAOFCompactInterval 设置追加写日志压缩为快照的间隔，0表示只在 OpenAOF 和手动调用 Compact 时压缩
+func SetAOFCompactInterval(d time.Duration) options.Option {
+	return func(c interface{}) {
+		c.(*Config).aofCompactInterval = d
+	}
+}
+
+// SetWriteLimit 设置日志定期刷盘、压缩快照和 SaveFile 的磁盘写入限速，单位字节/秒，0表示不限速
+//
+// 限速只作用于后台持久化，不影响缓存读写；运行时可以通过 Cache.SetWriteLimit 调整
+func SetWriteLimit(bytesPerSec int) options.Option {
+	return func(c interface{}) {
+		c.(*Config).writeLimit = bytesPerSec
+	}
+}
+
+// SetWriteThrough 设置 write-through 持久化钩子，Set、Add、Replace、MSet、Increment、Expire、Persist、Delete
+// 等在本地写入后同步调用 p，过期删除按删除转发；同一个key的变更按发生的顺序写入，调用 p 是串行的
+//
+// 持久化失败不会回滚本地缓存，重试耗尽后调用 SetPersistErrorHandler 设置的回调
+func SetWriteThrough(p Persister) options.Option {
+	return func(c interface{}) {
+		c.(*Config).persist.fn = p
+		c.(*Config).persist.behind = false
+	}
+}
+
+// SetWriteBehind 设置 write-behind 持久化钩子，变更按key合并后由后台协程批量调用 p
+//
+// 累计 batch 个变更或每隔 interval 写入一次，Shutdown 时写入剩余的变更
+func SetWriteBehind(p Persister, batch int, interval time.Duration) options.Option {
+	return func(c interface{}) {
+		c.(*Config).persist.fn = p
+		c.(*Config).persist.behind = true
+		c.(*Config).persist.batch = batch
+		c.(*Config).persist.interval = interval
+	}
+}
+
+// SetPersistRetry 设置持久化失败后的重试次数和第一次重试前的等待时间，之后每次等待时间翻倍
+func SetPersistRetry(retries int, backoff time.Duration) options.Option {
+	return func(c interface{}) {
+		c.(*Config).persist.retries = retries
+		c.(*Config).persist.backoff = backoff
+	}
+}
+
+// SetPersistErrorHandler 设置持久化重试耗尽后的错误回调，ops 为写入失败的变更
+func SetPersistErrorHandler(f func(ops []PersistOp, err error)) options.Option {
+	return func(c interface{}) {
+		c.(*Config).persist.onError = f
+	}
+}
+
+// SetSlidingExpiration 设置为 true 时 Set、Add、Replace、MSet 等设置的过期时间为滑动过期，
+// 每次 Get 命中都会将过期时间顺延为当前时间加上原始的过期时长，适合会话类数据
+func SetSlidingExpiration(sliding bool) options.Option {
+	return func(c interface{}) {
+		c.(*Config).sliding = sliding
+	}
+}
+
+// SetCaptureSubmit 设置 capture 的异步执行函数，可以直接传入 NmqContext.Submit 使用 nmq 协程池
+//
+// 慢的 capture 不会阻塞 Delete、DeleteExpire 等调用方，提交失败时退化为在调用方协程同步执行
+func SetCaptureSubmit(submit func(task func()) error) options.Option {
+	return func(c interface{}) {
+		c.(*Config).captureSubmit = submit
+	}
+}
+
+// SetCallbackWorkers 启用内置的有界回调协程池，capture 和事件在 workers 个协程中执行，最多排队 queue 个任务
+//
+// 只作用于没有通过 SetCaptureSubmit、SetEventSubmit 单独指定提交函数的回调，队列已满时退化为同步执行，
+// Shutdown 会等待队列中的回调执行完毕
+func SetCallbackWorkers(workers, queue int) options.Option {
+	return func(c interface{}) {
+		c.(*Config).callbackWorkers = workers
+		c.(*Config).callbackQueue = queue
+	}
+}
+
+// SetReadRepair 开启 read-repair：读操作时顺带抽查同一分片中最多 n 个缓存项并删除其中已过期的，0表示关闭
+//
+// 适合没有启动后台清理协程、过期项只能靠读操作清理的场景；Get 只在分片写锁空闲时尝试，不会阻塞读路径
+func SetReadRepair(n int) options.Option {
+	return func(c interface{}) {
+		c.(*Config).readRepair = n
+	}
+}
+
+// SetDefaultJitter 设置过期时间的默认抖动比例 f（0~1），所有带过期时间的写入的实际TTL在 ttl*(1±f) 内随机分布
+//
+// 同一时刻批量写入的缓存项不会在同一时刻集中过期，避免同时回源压垮后端存储
+func SetDefaultJitter(f float64) options.Option {
+	return func(c interface{}) {
+		c.(*Config).jitter = f
+	}
+}
+
+// SetWarmupFile 创建缓存时从 path 快照预热，过期时间按快照时间重新计算，完成后调用 report（可以为nil）
+//
+// 文件不存在时视为首次启动，report 收到空的统计和nil错误
+func SetWarmupFile(path string, report func(WarmupStats, error)) options.Option {
+	return func(c interface{}) {
+		c.(*Config).warmupFile = path
+		c.(*Config).warmupReport = report
+	}
+}
+
 // NewConfig 创建一个新的本地缓存配置实例
 func NewConfig(opts ...options.Option) *Config {
 	c := &Config{
+		shards: defaultShards,
+		codec:  GobCodec(),
+
+		aofSyncInterval: defaultAOFSyncInterval,
 		capture: func(k string, v interface{}) {
 			fmt.Printf("delete k:%s v:%v\n", k, v)
 		},
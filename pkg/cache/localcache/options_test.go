@@ -134,3 +134,16 @@ func TestOptionFunctionType(t *testing.T) {
 	var _ options.Option = SetCapture(nil)
 	var _ options.Option = SetMember(nil)
 }
+
+func TestSetShards(t *testing.T) {
+	// 测试 SetShards 选项函数
+	config := NewConfig()
+	if config.shards != defaultShards {
+		t.Errorf("Expected default shards to be %d, got %d", defaultShards, config.shards)
+	}
+
+	SetShards(16)(config)
+	if config.shards != 16 {
+		t.Errorf("Expected shards to be 16, got %d", config.shards)
+	}
+}
@@ -0,0 +1,200 @@
+package localcache
+
+import (
+	"sync"
+	"time"
+)
+
+// 默认的 write-behind 批量大小和刷写间隔
+const (
+	defaultPersistBatch    = 100
+	defaultPersistInterval = time.Second
+)
+
+// PersistOp 一次需要持久化的变更
+type PersistOp struct {
+	Key    string
+	Value  interface{}
+	Expire time.Time // 过期时间，零值表示永不过期，删除时为零值
+	Delete bool      // true 表示删除或过期，Value 为删除前的值
+}
+
+// Persister 用户提供的持久化函数，例如写入数据库，返回错误时按重试策略重试
+type Persister func(ops []PersistOp) error
+
+// persistConfig 持久化钩子配置
+type persistConfig struct {
+	fn       Persister
+	behind   bool          // true 表示 write-behind 异步批量写，否则 write-through 同步写
+	batch    int           // write-behind 批量大小
+	interval time.Duration // write-behind 刷写间隔
+	retries  int           // 失败后的重试次数
+	backoff  time.Duration // 第一次重试前的等待时间，之后每次翻倍
+	onError  func(ops []PersistOp, err error)
+}
+
+// persister 将 Set、Delete 等写操作交给用户的 Persister
+//
+// 变更在分片锁内按加锁顺序入队，同一个key的变更按发生的顺序写入，同一个批次内只保留最后一次变更；
+// write-through 在释放锁之后由调用方协程写入队列中的变更，写入完成后才返回，
+// write-behind 由后台协程批量写入。过期和租约到期按删除转发，被淘汰的条目不转发
+type persister struct {
+	persistConfig
+
+	mu      sync.Mutex
+	pending []PersistOp
+	index   map[string]int // key 在 pending 中的位置
+	flushMu sync.Mutex     // 串行化批量写入，保证同一个key的变更按顺序写入
+
+	kick chan struct{}
+	stop chan struct{}
+	done chan struct{}
+	once sync.Once
+}
+
+// newPersister 根据配置创建持久化钩子，write-behind 模式会启动后台协程
+func newPersister(cfg persistConfig) *persister {
+	p := &persister{persistConfig: cfg, index: make(map[string]int)}
+	if !p.behind {
+		return p
+	}
+	if p.batch <= 0 {
+		p.batch = defaultPersistBatch
+	}
+	if p.interval <= 0 {
+		p.interval = defaultPersistInterval
+	}
+	p.kick = make(chan struct{}, 1)
+	p.stop = make(chan struct{})
+	p.done = make(chan struct{})
+	go p.run()
+	return p
+}
+
+// enqueue 将一次变更加入队列 调用方需持有分片写锁，保证同一个key的变更按发生的顺序入队
+func (p *persister) enqueue(op PersistOp) {
+	p.mu.Lock()
+	if i, ok := p.index[op.Key]; ok {
+		p.pending[i] = op
+	} else {
+		p.index[op.Key] = len(p.pending)
+		p.pending = append(p.pending, op)
+	}
+	full := p.behind && len(p.pending) >= p.batch
+	p.mu.Unlock()
+	if full {
+		select {
+		case p.kick <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// write 调用 Persister，失败时按退避策略重试，最终失败交给错误回调
+func (p *persister) write(ops []PersistOp) {
+	backoff := p.backoff
+	var err error
+	for attempt := 0; ; attempt++ {
+		if err = p.fn(ops); err == nil {
+			return
+		}
+		if attempt >= p.retries {
+			break
+		}
+		time.Sleep(backoff)
+		backoff *= 2
+	}
+	if p.onError != nil {
+		p.onError(ops, err)
+	}
+}
+
+// take 取出所有待写入的变更
+func (p *persister) take() []PersistOp {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if len(p.pending) == 0 {
+		return nil
+	}
+	ops := p.pending
+	p.pending = nil
+	p.index = make(map[string]int, len(ops))
+	return ops
+}
+
+// flush 按批量大小写入所有待写入的变更，write-through 不分批
+func (p *persister) flush() {
+	p.flushMu.Lock()
+	defer p.flushMu.Unlock()
+	ops := p.take()
+	for len(ops) > 0 {
+		n := len(ops)
+		if p.batch > 0 {
+			n = min(n, p.batch)
+		}
+		p.write(ops[:n])
+		ops = ops[n:]
+	}
+}
+
+// run write-behind 后台协程，达到批量大小或间隔到期时写入
+func (p *persister) run() {
+	defer close(p.done)
+	ticker := time.NewTicker(p.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			p.flush()
+		case <-p.kick:
+			p.flush()
+		case <-p.stop:
+			p.flush()
+			return
+		}
+	}
+}
+
+// close 停止后台协程并写入剩余的变更，可重复调用
+func (p *persister) close() {
+	if !p.behind {
+		return
+	}
+	p.once.Do(func() {
+		close(p.stop)
+	})
+	<-p.done
+}
+
+// persistSet 将写入加入持久化队列 调用方需持有分片写锁
+func (s *shard) persistSet(k string, it Iterator) {
+	if s.persist == nil {
+		return
+	}
+	op := PersistOp{Key: k, Value: it.Val}
+	if it.Expire > 0 {
+		op.Expire = time.Unix(0, it.Expire)
+	}
+	s.persist.enqueue(op)
+}
+
+// persistDelete 将删除加入持久化队列 调用方需持有分片写锁
+func (s *shard) persistDelete(k string, v interface{}) {
+	if s.persist != nil {
+		s.persist.enqueue(PersistOp{Key: k, Value: v, Delete: true})
+	}
+}
+
+// syncPersist write-through 模式下写入队列中的变更，调用方不能持有分片锁
+func (c *cache) syncPersist() {
+	if c.persist != nil && !c.persist.behind {
+		c.persist.flush()
+	}
+}
+
+// FlushPersist 立即写入 write-behind 队列中的所有变更，未配置持久化钩子时直接返回
+func (c *cache) FlushPersist() {
+	if c.persist != nil && c.persist.behind {
+		c.persist.flush()
+	}
+}
@@ -0,0 +1,187 @@
+// persist_test.go
+package localcache
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+// recorder 记录 Persister 收到的批次
+type recorder struct {
+	mu      sync.Mutex
+	batches [][]PersistOp
+	fails   int // 前 fails 次调用返回错误
+}
+
+func (r *recorder) persist(ops []PersistOp) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.fails > 0 {
+		r.fails--
+		return errors.New("db unavailable")
+	}
+	r.batches = append(r.batches, append([]PersistOp(nil), ops...))
+	return nil
+}
+
+func (r *recorder) ops() []PersistOp {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var all []PersistOp
+	for _, b := range r.batches {
+		all = append(all, b...)
+	}
+	return all
+}
+
+func TestWriteThrough(t *testing.T) {
+	r := &recorder{fails: 1}
+	cache := NewCache(SetCapture(nil), SetWriteThrough(r.persist), SetPersistRetry(1, time.Millisecond))
+	defer cache.Shutdown()
+
+	cache.Set("key1", "value1", 0)
+	cache.MSet(map[string]interface{}{"key2": 2}, 0)
+	cache.Delete("key1")
+	cache.Set("short", 1, time.Millisecond)
+	time.Sleep(5 * time.Millisecond)
+	cache.Get("short") // 过期按删除转发
+
+	ops := r.ops()
+	if len(ops) != 5 {
+		t.Fatalf("Expected 5 persisted ops, got %+v", ops)
+	}
+	if ops[0].Key != "key1" || ops[0].Value != "value1" || ops[0].Delete || !ops[0].Expire.IsZero() {
+		t.Errorf("Unexpected first op %+v", ops[0])
+	}
+	if ops[2].Key != "key1" || !ops[2].Delete {
+		t.Errorf("Expected delete of key1, got %+v", ops[2])
+	}
+	if ops[3].Key != "short" || ops[3].Expire.IsZero() {
+		t.Errorf("Expected short to carry its expiry, got %+v", ops[3])
+	}
+	if ops[4].Key != "short" || !ops[4].Delete {
+		t.Errorf("Expected expiry of short to be a delete, got %+v", ops[4])
+	}
+}
+
+func TestWriteThroughUpdates(t *testing.T) {
+	r := &recorder{}
+	cache := NewCache(SetCapture(nil), SetWriteThrough(r.persist))
+	defer cache.Shutdown()
+
+	cache.Set("counter", int64(1), 0)
+	if _, err := cache.IncrementInt64("counter", 2); err != nil {
+		t.Fatal(err)
+	}
+	if err := cache.Expire("counter", time.Hour); err != nil {
+		t.Fatal(err)
+	}
+	if err := cache.Persist("counter"); err != nil {
+		t.Fatal(err)
+	}
+
+	ops := r.ops()
+	if len(ops) != 4 {
+		t.Fatalf("Expected 4 persisted ops, got %+v", ops)
+	}
+	if ops[1].Value != int64(3) {
+		t.Errorf("Expected increment to persist 3, got %+v", ops[1])
+	}
+	if ops[2].Expire.IsZero() || !ops[3].Expire.IsZero() {
+		t.Errorf("Expected Expire and Persist to update the expiry, got %+v", ops[2:])
+	}
+}
+
+func TestWriteThroughOrder(t *testing.T) {
+	r := &recorder{}
+	cache := NewCache(SetCapture(nil), SetWriteThrough(r.persist))
+	defer cache.Shutdown()
+
+	// 并发写同一个key，最后写入存储的值与缓存中的值一致
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			cache.Set("key", i, 0)
+		}(i)
+	}
+	wg.Wait()
+
+	ops := r.ops()
+	if len(ops) == 0 {
+		t.Fatal("Expected persisted ops")
+	}
+	v, _ := cache.Get("key")
+	if ops[len(ops)-1].Value != v {
+		t.Errorf("Expected last persisted value to be %v, got %+v", v, ops[len(ops)-1])
+	}
+}
+
+func TestWriteThroughError(t *testing.T) {
+	r := &recorder{fails: 3}
+	var failed []PersistOp
+	cache := NewCache(SetCapture(nil),
+		SetWriteThrough(r.persist),
+		SetPersistRetry(1, time.Millisecond),
+		SetPersistErrorHandler(func(ops []PersistOp, err error) { failed = append(failed, ops...) }))
+	defer cache.Shutdown()
+
+	cache.Set("key1", "value1", 0)
+	if len(failed) != 1 || failed[0].Key != "key1" {
+		t.Errorf("Expected key1 to be reported, got %+v", failed)
+	}
+	// 持久化失败不影响本地缓存
+	if v, ok := cache.Get("key1"); !ok || v != "value1" {
+		t.Errorf("Expected key1 in cache, got %v, %v", v, ok)
+	}
+}
+
+func TestWriteBehind(t *testing.T) {
+	r := &recorder{}
+	cache := NewCache(SetCapture(nil), SetWriteBehind(r.persist, 3, time.Hour))
+
+	// 同一个key的多次写入合并为一次
+	cache.Set("key1", 1, 0)
+	cache.Set("key1", 2, 0)
+	cache.Set("key2", 1, 0)
+	if len(r.ops()) != 0 {
+		t.Errorf("Expected no writes before the batch is full, got %+v", r.ops())
+	}
+	cache.Delete("key2")
+	cache.Set("key3", 1, 0)
+
+	// 达到批量大小后由后台协程写入
+	deadline := time.Now().Add(time.Second)
+	for len(r.ops()) == 0 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	ops := r.ops()
+	if len(ops) != 3 {
+		t.Fatalf("Expected 3 coalesced ops, got %+v", ops)
+	}
+	if ops[0].Value != 2 || !ops[1].Delete {
+		t.Errorf("Unexpected coalesced ops %+v", ops)
+	}
+
+	// Shutdown 写入剩余的变更
+	cache.Set("key4", 1, 0)
+	cache.Shutdown()
+	if ops = r.ops(); len(ops) != 4 || ops[3].Key != "key4" {
+		t.Errorf("Expected key4 to be flushed on shutdown, got %+v", ops)
+	}
+}
+
+func TestFlushPersist(t *testing.T) {
+	r := &recorder{}
+	cache := NewCache(SetCapture(nil), SetWriteBehind(r.persist, 100, time.Hour))
+	defer cache.Shutdown()
+
+	cache.Set("key1", 1, 0)
+	cache.FlushPersist()
+	if ops := r.ops(); len(ops) != 1 {
+		t.Errorf("Expected 1 op after FlushPersist, got %+v", ops)
+	}
+}
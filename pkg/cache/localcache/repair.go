@@ -0,0 +1,33 @@
+package localcache
+
+import "time"
+
+// repair 抽查分片中最多 c.readRepair 个缓存项并删除其中已过期的，调用方需持有分片写锁
+//
+// map 的遍历顺序是随机的，多次读操作累积下来可以覆盖整个分片
+func (c *cache) repair(s *shard, now int64) []kv {
+	var expired []kv
+	n := 0
+	for k, v := range s.member {
+		if n >= c.readRepair {
+			break
+		}
+		n++
+		if v.Expired(now) {
+			val, _ := c.delete(s, k)
+			expired = append(expired, kv{k, val})
+		}
+	}
+	return expired
+}
+
+// readRepairShard 读操作之后尝试对分片做 read-repair，分片锁被占用时直接跳过
+func (c *cache) readRepairShard(s *shard) {
+	if c.readRepair <= 0 || !s.TryLock() {
+		return
+	}
+	expired := c.repair(s, time.Now().UnixNano())
+	capture := c.capture
+	s.Unlock()
+	c.dispatch(capture, EventExpire, expired)
+}
@@ -0,0 +1,70 @@
+// repair_test.go
+package localcache
+
+import (
+	"testing"
+	"time"
+)
+
+func TestAddKeepsExisting(t *testing.T) {
+	var captured []string
+	cache := NewCache(SetCapture(func(k string, v interface{}) { captured = append(captured, k) }))
+	cache.Set("key1", "value1", 0)
+
+	if err := cache.Add("key1", "value2", 0); !CacheErrExist(err) {
+		t.Fatalf("Expected CacheExist, got %v", err)
+	}
+	// 已存在的k不能因为 Add 失败而被删除
+	if v, ok := cache.Get("key1"); !ok || v != "value1" {
+		t.Errorf("Expected key1=value1 after failed Add, got %v, %v", v, ok)
+	}
+	if err := cache.Replace("key1", "value3", 0); err != nil {
+		t.Fatalf("Replace failed: %v", err)
+	}
+	if v, _ := cache.Get("key1"); v != "value3" {
+		t.Errorf("Expected key1=value3, got %v", v)
+	}
+	if len(captured) != 0 {
+		t.Errorf("Expected no capture for live keys, got %v", captured)
+	}
+
+	// 过期的k按过期处理
+	var expired []string
+	cache.OnExpire(func(ev Event) { expired = append(expired, ev.Key) })
+	cache.Set("key2", "old", time.Millisecond)
+	time.Sleep(5 * time.Millisecond)
+	if err := cache.Add("key2", "new", 0); err != nil {
+		t.Fatalf("Expected Add to succeed on expired key, got %v", err)
+	}
+	if len(expired) != 1 || expired[0] != "key2" || len(captured) != 1 {
+		t.Errorf("Expected expire event and capture for key2, got %v, %v", expired, captured)
+	}
+}
+
+func TestReadRepair(t *testing.T) {
+	cache := NewCache(SetCapture(nil), SetShards(1), SetReadRepair(100))
+	for _, k := range []string{"a", "b", "c"} {
+		cache.Set(k, k, time.Millisecond)
+	}
+	cache.Set("live", "v", 0)
+	time.Sleep(5 * time.Millisecond)
+
+	var expired int
+	cache.OnExpire(func(Event) { expired++ })
+	if _, ok := cache.Get("live"); !ok {
+		t.Fatal("Expected live key")
+	}
+	if cache.Count() != 1 || expired != 3 {
+		t.Errorf("Expected expired siblings to be removed, count=%d expired=%d", cache.Count(), expired)
+	}
+
+	// 关闭时读操作不删除其他过期项
+	off := NewCache(SetCapture(nil), SetShards(1))
+	off.Set("a", 1, time.Millisecond)
+	off.Set("live", 1, 0)
+	time.Sleep(5 * time.Millisecond)
+	off.Get("live")
+	if off.Count() != 2 {
+		t.Errorf("Expected expired key to stay without read-repair, count=%d", off.Count())
+	}
+}
@@ -0,0 +1,38 @@
+package localcache
+
+import (
+	"io"
+	"time"
+)
+
+// Restore 从 r 中加载快照，快照中条目的过期时间整体顺延 downtime，返回加载的条目数
+//
+// 用于停机后恢复缓存，downtime 为快照保存到现在的时间，停机期间不计入TTL；
+// 顺延后仍已过期的条目直接丢弃，与 Load 一样只加载不存在或已过期的项
+func (c *cache) Restore(r io.Reader, downtime time.Duration) (int, error) {
+	member, _, err := decodeFile(r)
+	if err != nil {
+		return 0, err
+	}
+
+	now := time.Now().UnixNano()
+	loaded := 0
+	var evicted []kv
+	for k, iterator := range member {
+		if iterator.Expire > 0 {
+			iterator.Expire += int64(downtime)
+			if iterator.Expired(now) {
+				continue
+			}
+		}
+		s := c.getShard(k)
+		s.Lock()
+		if v, ok := s.member[k]; !ok || v.Expired() {
+			evicted = append(evicted, s.put(k, iterator)...)
+			loaded++
+		}
+		s.Unlock()
+	}
+	c.dispatch(c.capture, EventEvict, evicted)
+	return loaded, nil
+}
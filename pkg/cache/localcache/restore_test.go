@@ -0,0 +1,61 @@
+// restore_test.go
+package localcache
+
+import (
+	"bytes"
+	"testing"
+	"time"
+)
+
+func TestRestore(t *testing.T) {
+	cache := NewCache(SetCapture(nil))
+	cache.Set("long", "1", time.Hour)
+	cache.Set("short", "2", 20*time.Millisecond)
+	cache.SetNoExpire("forever", "3")
+
+	buf := new(bytes.Buffer)
+	if err := cache.Save(buf); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+	snapshot := buf.Bytes()
+	time.Sleep(40 * time.Millisecond)
+
+	// 停机时间顺延后 short 仍然有效
+	restored := NewCache(SetCapture(nil))
+	n, err := restored.Restore(bytes.NewReader(snapshot), time.Minute)
+	if err != nil {
+		t.Fatalf("Restore failed: %v", err)
+	}
+	if n != 3 {
+		t.Errorf("Expected 3 items restored, got %d", n)
+	}
+	for k, want := range map[string]string{"long": "1", "short": "2", "forever": "3"} {
+		if v, ok := restored.Get(k); !ok || v != want {
+			t.Errorf("Expected %s=%s, got %v %v", k, want, v, ok)
+		}
+	}
+	_, saved, _ := cache.GetWithExpire("long")
+	if _, exp, _ := restored.GetWithExpire("long"); !exp.Equal(saved.Add(time.Minute)) {
+		t.Errorf("Expected long to expire at %v, got %v", saved.Add(time.Minute), exp)
+	}
+
+	// 不顺延时已过期的条目被丢弃，已存在的未过期条目不被覆盖
+	restored = NewCache(SetCapture(nil))
+	restored.SetNoExpire("forever", "kept")
+	if n, err = restored.Restore(bytes.NewReader(snapshot), 0); err != nil {
+		t.Fatalf("Restore failed: %v", err)
+	}
+	if n != 1 {
+		t.Errorf("Expected 1 item restored, got %d", n)
+	}
+	if _, ok := restored.Get("short"); ok {
+		t.Error("Expected expired short to be dropped")
+	}
+	if v, _ := restored.Get("forever"); v != "kept" {
+		t.Errorf("Expected existing forever to be kept, got %v", v)
+	}
+
+	if _, err = restored.Restore(bytes.NewReader([]byte("NMQC")), 0); err == nil {
+		t.Error("Expected error for truncated snapshot")
+	}
+}
@@ -0,0 +1,181 @@
+package localcache
+
+import (
+	"path"
+	"strings"
+)
+
+// Scan 遍历所有以 prefix 开头且未过期的缓存项，fn 返回 false 时停止遍历
+//
+// 按分片复制匹配的缓存项后在锁外调用 fn，fn 中可以安全地读写缓存，
+// 遍历期间的并发修改可能不会被观察到
+func (c *cache) Scan(prefix string, fn func(k string, v interface{}) bool) {
+	var list []kv
+	for _, s := range c.shards {
+		list = list[:0]
+		s.RLock()
+		for k, v := range s.member {
+			if strings.HasPrefix(k, prefix) && !v.Expired() {
+				list = append(list, kv{k, v.Val})
+			}
+		}
+		s.RUnlock()
+		for _, item := range list {
+			if !fn(item.key, item.value) {
+				return
+			}
+		}
+	}
+}
+
+// Keys 返回所有匹配 glob 模式且未过期的key，顺序不固定
+//
+// 支持 * 匹配任意字符串（包括 /）、? 匹配单个字符、[abc] [a-z] [^a] 字符集以及 \ 转义，
+// 模式不合法时返回 path.ErrBadPattern
+func (c *cache) Keys(pattern string) ([]string, error) {
+	if _, err := match(pattern, ""); err != nil {
+		return nil, err
+	}
+	// 模式中第一个通配符之前的部分作为前缀缩小遍历范围
+	prefix := pattern
+	if i := strings.IndexAny(pattern, `*?[\`); i >= 0 {
+		prefix = pattern[:i]
+	}
+	var keys []string
+	c.Scan(prefix, func(k string, _ interface{}) bool {
+		if ok, _ := match(pattern, k); ok {
+			keys = append(keys, k)
+		}
+		return true
+	})
+	return keys, nil
+}
+
+// match 判断 name 是否匹配 glob 模式 pattern，* 可以跨越 /
+func match(pattern, name string) (bool, error) {
+	// 回溯位置：最近一次 * 在 pattern 和 name 中的位置
+	px, nx := 0, 0
+	starPx, starNx := -1, -1
+	matched := true
+	for px < len(pattern) || nx < len(name) {
+		if px < len(pattern) {
+			switch c := pattern[px]; c {
+			case '*':
+				starPx, starNx = px, nx
+				px++
+				continue
+			case '?':
+				if nx < len(name) {
+					px++
+					nx++
+					continue
+				}
+			case '[':
+				ok, width, err := matchClass(pattern[px:], name[nx:])
+				if err != nil {
+					return false, err
+				}
+				if ok {
+					px += width
+					nx++
+					continue
+				}
+			case '\\':
+				if px+1 >= len(pattern) {
+					return false, path.ErrBadPattern
+				}
+				if nx < len(name) && name[nx] == pattern[px+1] {
+					px += 2
+					nx++
+					continue
+				}
+			default:
+				if nx < len(name) && name[nx] == c {
+					px++
+					nx++
+					continue
+				}
+			}
+		}
+		// 不匹配时回到上一个 * 多吞一个字符重试
+		if starPx >= 0 && starNx < len(name) {
+			starNx++
+			px, nx = starPx+1, starNx
+			continue
+		}
+		matched = false
+		break
+	}
+	if !matched {
+		// 继续校验剩余模式的合法性，保证坏模式总能被发现
+		return false, validate(pattern[px:])
+	}
+	return true, nil
+}
+
+// matchClass 匹配 [...] 字符集，返回是否匹配以及字符集在模式中的长度
+func matchClass(pattern, name string) (bool, int, error) {
+	i := 1
+	negate := i < len(pattern) && pattern[i] == '^'
+	if negate {
+		i++
+	}
+	matched := false
+	for first := true; ; first = false {
+		if i >= len(pattern) {
+			return false, 0, path.ErrBadPattern
+		}
+		if pattern[i] == ']' && !first {
+			i++
+			break
+		}
+		lo, err := classChar(pattern, &i)
+		if err != nil {
+			return false, 0, err
+		}
+		hi := lo
+		if i+1 < len(pattern) && pattern[i] == '-' && pattern[i+1] != ']' {
+			i++
+			if hi, err = classChar(pattern, &i); err != nil {
+				return false, 0, err
+			}
+		}
+		if len(name) > 0 && lo <= name[0] && name[0] <= hi {
+			matched = true
+		}
+	}
+	return len(name) > 0 && matched != negate, i, nil
+}
+
+// classChar 读取字符集中的一个字符，处理 \ 转义
+func classChar(pattern string, i *int) (byte, error) {
+	if pattern[*i] == '\\' {
+		*i++
+		if *i >= len(pattern) {
+			return 0, path.ErrBadPattern
+		}
+	}
+	c := pattern[*i]
+	*i++
+	return c, nil
+}
+
+// validate 校验模式中未参与匹配部分的字符集和转义是否合法
+func validate(pattern string) error {
+	for i := 0; i < len(pattern); i++ {
+		switch pattern[i] {
+		case '\\':
+			if i+1 >= len(pattern) {
+				return path.ErrBadPattern
+			}
+			i++
+		case '[':
+			_, width, err := matchClass(pattern[i:], "")
+			if err != nil {
+				return err
+			}
+			i += width - 1
+		}
+	}
+	return nil
+}
@@ -0,0 +1,93 @@
+// scan_test.go
+package localcache
+
+import (
+	"path"
+	"sort"
+	"testing"
+	"time"
+)
+
+func TestScan(t *testing.T) {
+	cache := NewCache(SetCapture(nil), SetShards(4))
+	cache.Set("user:1", 1, 0)
+	cache.Set("user:2", 2, 0)
+	cache.Set("user:3", 3, 10*time.Millisecond)
+	cache.Set("order:1", 1, 0)
+	time.Sleep(20 * time.Millisecond)
+
+	var keys []string
+	cache.Scan("user:", func(k string, v interface{}) bool {
+		keys = append(keys, k)
+		return true
+	})
+	sort.Strings(keys)
+	if len(keys) != 2 || keys[0] != "user:1" || keys[1] != "user:2" {
+		t.Errorf("Unexpected scan result: %v", keys)
+	}
+
+	// fn 返回 false 时停止遍历
+	n := 0
+	cache.Scan("", func(string, interface{}) bool {
+		n++
+		return false
+	})
+	if n != 1 {
+		t.Errorf("Expected scan to stop after 1 item, got %d", n)
+	}
+
+	// fn 中可以修改缓存
+	cache.Scan("user:", func(k string, _ interface{}) bool {
+		cache.Delete(k)
+		return true
+	})
+	if _, ok := cache.Get("user:1"); ok {
+		t.Error("Expected user:1 to be deleted during scan")
+	}
+}
+
+func TestKeys(t *testing.T) {
+	cache := NewCache(SetCapture(nil))
+	for _, k := range []string{"topic/a/1", "topic/b/2", "topic/c", "queue/a", "t?x"} {
+		cache.Set(k, k, 0)
+	}
+
+	tests := []struct {
+		pattern  string
+		expected []string
+	}{
+		{"topic/*", []string{"topic/a/1", "topic/b/2", "topic/c"}},
+		{"topic/?/*", []string{"topic/a/1", "topic/b/2"}},
+		{"*/a*", []string{"queue/a", "topic/a/1"}},
+		{"topic/[ab]/*", []string{"topic/a/1", "topic/b/2"}},
+		{"topic/[^ab]", []string{"topic/c"}},
+		{"topic/[a-b]/?", []string{"topic/a/1", "topic/b/2"}},
+		{`t\?x`, []string{"t?x"}},
+		{"queue/a", []string{"queue/a"}},
+		{"none*", nil},
+	}
+	for _, tt := range tests {
+		keys, err := cache.Keys(tt.pattern)
+		if err != nil {
+			t.Errorf("Keys(%q) failed: %v", tt.pattern, err)
+			continue
+		}
+		sort.Strings(keys)
+		if len(keys) != len(tt.expected) {
+			t.Errorf("Keys(%q) = %v, expected %v", tt.pattern, keys, tt.expected)
+			continue
+		}
+		for i := range keys {
+			if keys[i] != tt.expected[i] {
+				t.Errorf("Keys(%q) = %v, expected %v", tt.pattern, keys, tt.expected)
+				break
+			}
+		}
+	}
+
+	for _, pattern := range []string{"topic/[ab", `topic\`, "x*[", "[]"} {
+		if _, err := cache.Keys(pattern); err != path.ErrBadPattern {
+			t.Errorf("Keys(%q) expected ErrBadPattern, got %v", pattern, err)
+		}
+	}
+}
@@ -0,0 +1,53 @@
+package localcache
+
+import (
+	"reflect"
+	"sync/atomic"
+)
+
+// entryOverhead 每个缓存条目在 map 和 Iterator 上的固定开销估算
+const entryOverhead = 64
+
+// byteBudget 所有分片共享的内存预算
+type byteBudget struct {
+	max      int64                                     // 内存上限（字节）
+	used     atomic.Int64                              // 当前已使用的估算大小
+	estimate func(key string, value interface{}) int64 // 条目大小估算函数
+}
+
+// newByteBudget 创建内存预算，未指定估算函数时使用 DefaultSizeEstimator
+func newByteBudget(max int64, estimate func(string, interface{}) int64) *byteBudget {
+	if estimate == nil {
+		estimate = DefaultSizeEstimator
+	}
+	return &byteBudget{
+		max:      max,
+		estimate: estimate,
+	}
+}
+
+// DefaultSizeEstimator 默认的条目大小估算函数
+//
+// string 和 []byte 按实际长度计算，其他类型按值本身的大小浅层估算，
+// 不会递归计算指针、切片和 map 引用的数据，缓存复杂结构时建议自定义估算函数
+func DefaultSizeEstimator(key string, value interface{}) int64 {
+	size := int64(len(key) + entryOverhead)
+	switch v := value.(type) {
+	case nil:
+	case string:
+		size += int64(len(v))
+	case []byte:
+		size += int64(len(v))
+	default:
+		size += int64(reflect.TypeOf(v).Size())
+	}
+	return size
+}
+
+// Bytes 返回缓存当前的估算内存大小，未设置内存上限时返回0
+func (c *cache) Bytes() int64 {
+	if c.budget == nil {
+		return 0
+	}
+	return c.budget.used.Load()
+}
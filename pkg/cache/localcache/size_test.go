@@ -0,0 +1,97 @@
+// size_test.go
+package localcache
+
+import (
+	"strconv"
+	"testing"
+)
+
+func TestDefaultSizeEstimator(t *testing.T) {
+	tests := []struct {
+		name     string
+		value    interface{}
+		expected int64
+	}{
+		{"nil", nil, entryOverhead + 1},
+		{"string", "hello", entryOverhead + 1 + 5},
+		{"bytes", make([]byte, 100), entryOverhead + 1 + 100},
+		{"int64", int64(1), entryOverhead + 1 + 8},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := DefaultSizeEstimator("k", tt.value); got != tt.expected {
+				t.Errorf("DefaultSizeEstimator() = %d, expected %d", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestMaxBytes(t *testing.T) {
+	var evicted []string
+	cache := NewCache(
+		SetShards(1),
+		SetMaxBytes(100),
+		SetSizeEstimator(func(key string, value interface{}) int64 {
+			return int64(len(value.([]byte)))
+		}),
+		SetCapture(func(k string, v interface{}) {
+			evicted = append(evicted, k)
+		}),
+	)
+
+	cache.Set("a", make([]byte, 40), 0)
+	cache.Set("b", make([]byte, 40), 0)
+	if bytes := cache.Bytes(); bytes != 80 {
+		t.Errorf("Expected bytes to be 80, got %d", bytes)
+	}
+
+	// 写入c超过水位，淘汰最久未访问的a
+	cache.Set("c", make([]byte, 40), 0)
+	if _, ok := cache.Get("a"); ok {
+		t.Error("Expected a to be evicted")
+	}
+	if bytes := cache.Bytes(); bytes != 80 {
+		t.Errorf("Expected bytes to be 80, got %d", bytes)
+	}
+
+	// 更新b变大后淘汰c，b本身保留
+	cache.Set("b", make([]byte, 90), 0)
+	if _, ok := cache.Get("b"); !ok {
+		t.Error("Expected b to be kept")
+	}
+	if _, ok := cache.Get("c"); ok {
+		t.Error("Expected c to be evicted")
+	}
+
+	// 单个条目超过上限时不会被缓存
+	cache.Set("huge", make([]byte, 200), 0)
+	if _, ok := cache.Get("huge"); ok {
+		t.Error("Expected huge to be rejected")
+	}
+	if len(evicted) != 3 || evicted[2] != "huge" {
+		t.Errorf("Expected evicted to be [a c huge], got %v", evicted)
+	}
+
+	// 删除和清空时释放预算
+	cache.Delete("b")
+	if bytes := cache.Bytes(); bytes != 0 {
+		t.Errorf("Expected bytes to be 0 after delete, got %d", bytes)
+	}
+	cache.Set("d", make([]byte, 10), 0)
+	cache.Flush()
+	if bytes := cache.Bytes(); bytes != 0 {
+		t.Errorf("Expected bytes to be 0 after flush, got %d", bytes)
+	}
+}
+
+func TestMaxBytesSharded(t *testing.T) {
+	// 多分片共享内存预算，总量不会长期超过上限
+	cache := NewCache(SetShards(16), SetMaxBytes(10*1024))
+	for i := 0; i < 10000; i++ {
+		cache.Set("key"+strconv.Itoa(i), make([]byte, 100), 0)
+	}
+	if bytes := cache.Bytes(); bytes > 10*1024+200 {
+		t.Errorf("Expected bytes to stay around the watermark, got %d", bytes)
+	}
+}
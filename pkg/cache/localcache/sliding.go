@@ -0,0 +1,38 @@
+package localcache
+
+import "time"
+
+// SetSliding 添加滑动过期的cache 无论是否存在都会覆盖，每次 Get 命中都会将过期时间顺延 d
+//
+// d <= 0 时永不过期，与缓存是否开启 SetSlidingExpiration 无关
+func (c *cache) SetSliding(k string, v interface{}, d time.Duration) {
+	it := Iterator{Val: v}
+	if d > 0 {
+		it.Expire = time.Now().Add(d).UnixNano()
+		it.Sliding = int64(d)
+	}
+	s := c.getShard(k)
+	s.Lock()
+	evicted := s.write(k, it)
+	capture := c.capture
+	s.Unlock()
+	c.dispatch(capture, EventEvict, evicted)
+	c.publish(EventSet, []kv{{k, v}})
+}
+
+// slide 将滑动过期的k的过期时间顺延为当前时间加上滑动时长，返回新的过期时间
+//
+// 读路径只持有读锁，这里重新加写锁并再次检查，k已被删除或过期时不做处理
+func (c *cache) slide(k string) (int64, bool) {
+	s := c.getShard(k)
+	s.Lock()
+	defer s.Unlock()
+	v, ok := s.member[k]
+	if !ok || v.Sliding <= 0 || v.Expired() {
+		return 0, false
+	}
+	v.Expire = time.Now().UnixNano() + v.Sliding
+	s.member[k] = v
+	s.logSet(k, v)
+	return v.Expire, true
+}
@@ -0,0 +1,81 @@
+// sliding_test.go
+package localcache
+
+import (
+	"bytes"
+	"testing"
+	"time"
+)
+
+func TestSetSliding(t *testing.T) {
+	cache := NewCache(SetCapture(nil))
+	cache.SetSliding("session", "alice", 50*time.Millisecond)
+	cache.Set("plain", "bob", 50*time.Millisecond)
+
+	// 持续访问时滑动过期的k不会过期
+	for i := 0; i < 4; i++ {
+		time.Sleep(20 * time.Millisecond)
+		if _, ok := cache.Get("session"); !ok {
+			t.Fatalf("Expected session to be kept alive, round %d", i)
+		}
+	}
+	if _, ok := cache.Get("plain"); ok {
+		t.Error("Expected plain key to expire")
+	}
+
+	// 停止访问后按原始时长过期
+	time.Sleep(60 * time.Millisecond)
+	if _, ok := cache.Get("session"); ok {
+		t.Error("Expected session to expire after inactivity")
+	}
+}
+
+func TestSlidingExpirationOption(t *testing.T) {
+	cache := NewCache(SetCapture(nil), SetSlidingExpiration(true))
+	cache.Set("key1", 1, 50*time.Millisecond)
+	cache.MSet(map[string]interface{}{"key2": 2}, 50*time.Millisecond)
+
+	time.Sleep(30 * time.Millisecond)
+	if _, exp, ok := cache.GetWithExpire("key1"); !ok || time.Until(exp) < 40*time.Millisecond {
+		t.Errorf("Expected GetWithExpire to return refreshed expiration, got %v, %v", exp, ok)
+	}
+	if got := cache.MGet([]string{"key2"}); len(got) != 1 {
+		t.Fatalf("Expected key2 hit, got %v", got)
+	}
+	time.Sleep(30 * time.Millisecond)
+	if got := cache.MGet([]string{"key1", "key2"}); len(got) != 2 {
+		t.Errorf("Expected both keys to be refreshed, got %v", got)
+	}
+
+	// Persist 移除滑动过期
+	if err := cache.Persist("key1"); err != nil {
+		t.Fatalf("Persist failed: %v", err)
+	}
+	if ttl, _ := cache.TTL("key1"); ttl != NoExpiration {
+		t.Errorf("Expected NoExpiration, got %v", ttl)
+	}
+	cache.Get("key1")
+	if ttl, _ := cache.TTL("key1"); ttl != NoExpiration {
+		t.Errorf("Expected Get not to re-enable expiration, got %v", ttl)
+	}
+}
+
+func TestSlidingCodec(t *testing.T) {
+	for _, codec := range []Codec{GobCodec(), JSONCodec(), MsgpackCodec()} {
+		src := NewCache(SetCapture(nil), SetCodec(codec))
+		src.SetSliding("session", "alice", time.Hour)
+		src.Set("plain", "bob", time.Hour)
+		var buf bytes.Buffer
+		if err := src.Save(&buf); err != nil {
+			t.Fatalf("%s Save failed: %v", codec.Name(), err)
+		}
+		dst := NewCache(SetCapture(nil))
+		if err := dst.Load(&buf); err != nil {
+			t.Fatalf("%s Load failed: %v", codec.Name(), err)
+		}
+		items := dst.Iterator()
+		if items["session"].Sliding != int64(time.Hour) || items["plain"].Sliding != 0 {
+			t.Errorf("%s: sliding not preserved, got %+v", codec.Name(), items)
+		}
+	}
+}
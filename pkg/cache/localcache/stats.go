@@ -0,0 +1,106 @@
+package localcache
+
+import (
+	"fmt"
+	"sync/atomic"
+
+	"github.com/andrewbytecoder/nmq/internal/prometheus"
+	stdprometheus "github.com/prometheus/client_golang/prometheus"
+)
+
+// Stats 缓存统计信息，各项均为自缓存创建以来的累计值
+type Stats struct {
+	Hits      uint64 // 命中次数
+	Misses    uint64 // 未命中次数（包括已过期）
+	Sets      uint64 // 写入次数
+	Deletes   uint64 // 主动删除的缓存项数
+	Evictions uint64 // 因容量或内存上限被淘汰的缓存项数
+	Expired   uint64 // 过期删除的缓存项数
+}
+
+// HitRatio 返回命中率，没有任何读取时返回0
+func (s Stats) HitRatio() float64 {
+	total := s.Hits + s.Misses
+	if total == 0 {
+		return 0
+	}
+	return float64(s.Hits) / float64(total)
+}
+
+// cacheStats 缓存统计计数器
+type cacheStats struct {
+	hits      atomic.Uint64
+	misses    atomic.Uint64
+	sets      atomic.Uint64
+	deletes   atomic.Uint64
+	evictions atomic.Uint64
+	expired   atomic.Uint64
+}
+
+// record 按事件类型累加统计
+func (s *cacheStats) record(typ EventType, n int) {
+	if n == 0 {
+		return
+	}
+	switch typ {
+	case EventSet:
+		s.sets.Add(uint64(n))
+	case EventDelete:
+		s.deletes.Add(uint64(n))
+	case EventExpire, EventLeaseExpire:
+		s.expired.Add(uint64(n))
+	case EventEvict:
+		s.evictions.Add(uint64(n))
+	}
+}
+
+// Stats 返回缓存统计信息
+func (c *cache) Stats() Stats {
+	return Stats{
+		Hits:      c.stats.hits.Load(),
+		Misses:    c.stats.misses.Load(),
+		Sets:      c.stats.sets.Load(),
+		Deletes:   c.stats.deletes.Load(),
+		Evictions: c.stats.evictions.Load(),
+		Expired:   c.stats.expired.Load(),
+	}
+}
+
+// RegisterMetrics 将缓存统计注册到 prometheus 默认注册器，name 作为 cache 标签区分不同的缓存实例
+//
+// 指标在采集时从 Stats 读取，不影响读写性能；同一个 name 重复注册会返回错误
+func (c *cache) RegisterMetrics(name string) error {
+	labels := stdprometheus.Labels{"cache": name}
+	counters := []struct {
+		name string
+		help string
+		load func() uint64
+	}{
+		{"hits_total", "Number of cache hits.", c.stats.hits.Load},
+		{"misses_total", "Number of cache misses, including expired entries.", c.stats.misses.Load},
+		{"sets_total", "Number of cache writes.", c.stats.sets.Load},
+		{"deletes_total", "Number of entries deleted explicitly.", c.stats.deletes.Load},
+		{"evictions_total", "Number of entries evicted by size limits.", c.stats.evictions.Load},
+		{"expired_total", "Number of expired entries removed.", c.stats.expired.Load},
+	}
+	for _, counter := range counters {
+		load := counter.load
+		err := prometheus.RegisterCounterFunc(stdprometheus.CounterOpts{
+			Namespace:   "nmq",
+			Subsystem:   "localcache",
+			Name:        counter.name,
+			Help:        counter.help,
+			ConstLabels: labels,
+		}, func() float64 { return float64(load()) })
+		if err != nil {
+			return fmt.Errorf("register localcache metric %s: %w", counter.name, err)
+		}
+	}
+	return prometheus.RegisterGaugeFunc(stdprometheus.GaugeOpts{
+		Namespace:   "nmq",
+		Subsystem:   "localcache",
+		Name:        "entries",
+		Help:        "Number of entries currently stored, including expired ones not yet removed.",
+		ConstLabels: labels,
+	}, func() float64 { return float64(c.Count()) })
+}
@@ -0,0 +1,69 @@
+// stats_test.go
+package localcache
+
+import (
+	"testing"
+	"time"
+
+	stdprometheus "github.com/prometheus/client_golang/prometheus"
+)
+
+func TestStats(t *testing.T) {
+	cache := NewCache(SetCapture(nil), SetShards(1), SetMaxEntries(2))
+
+	cache.Set("key1", "value1", 0)
+	cache.Set("key2", "value2", 10*time.Millisecond)
+	cache.Get("key1")
+	cache.Get("nonexistent")
+	cache.MGet([]string{"key1", "nonexistent"})
+
+	time.Sleep(20 * time.Millisecond)
+	cache.Get("key2") // 过期
+	cache.Set("key3", "value3", 0)
+	cache.Set("key4", "value4", 0) // 淘汰 key1
+	cache.Delete("key3")
+
+	want := Stats{Hits: 2, Misses: 3, Sets: 4, Deletes: 1, Evictions: 1, Expired: 1}
+	if got := cache.Stats(); got != want {
+		t.Errorf("Expected %+v, got %+v", want, got)
+	}
+	if ratio := cache.Stats().HitRatio(); ratio != 0.4 {
+		t.Errorf("Expected hit ratio 0.4, got %v", ratio)
+	}
+	if ratio := (Stats{}).HitRatio(); ratio != 0 {
+		t.Errorf("Expected hit ratio 0 without reads, got %v", ratio)
+	}
+}
+
+func TestRegisterMetrics(t *testing.T) {
+	cache := NewCache(SetCapture(nil))
+	if err := cache.RegisterMetrics("test_register"); err != nil {
+		t.Fatalf("RegisterMetrics failed: %v", err)
+	}
+	cache.Set("key1", "value1", 0)
+	cache.Get("key1")
+	cache.Get("nonexistent")
+
+	families, err := stdprometheus.DefaultGatherer.Gather()
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := make(map[string]float64)
+	for _, mf := range families {
+		for _, m := range mf.GetMetric() {
+			for _, l := range m.GetLabel() {
+				if l.GetName() == "cache" && l.GetValue() == "test_register" && m.GetCounter() != nil {
+					got[mf.GetName()] = m.GetCounter().GetValue()
+				}
+			}
+		}
+	}
+	if got["nmq_localcache_hits_total"] != 1 || got["nmq_localcache_misses_total"] != 1 || got["nmq_localcache_sets_total"] != 1 {
+		t.Errorf("Unexpected metrics: %v", got)
+	}
+
+	// 同名重复注册返回错误
+	if err := NewCache(SetCapture(nil)).RegisterMetrics("test_register"); err == nil {
+		t.Error("Expected error on duplicate registration")
+	}
+}
@@ -0,0 +1,74 @@
+package localcache
+
+import "time"
+
+// NoExpiration TTL 返回该值表示k永不过期
+const NoExpiration time.Duration = -1
+
+// Expire 重新设置k的过期时间为 d 之后，d <= 0 时立即按过期删除k，滑动过期的k同时将滑动时长改为 d
+//
+// k不存在或已过期返回 CacheNoExist
+func (c *cache) Expire(k string, d time.Duration) error {
+	s := c.getShard(k)
+	s.Lock()
+	v, ok := s.member[k]
+	if !ok || v.Expired() {
+		s.Unlock()
+		return CacheNoExist
+	}
+	if d <= 0 {
+		val, _ := c.delete(s, k)
+		capture := c.capture
+		s.Unlock()
+		c.dispatch(capture, EventExpire, []kv{{k, val}})
+		return nil
+	}
+	v.Expire = time.Now().Add(d).UnixNano()
+	if v.Sliding > 0 {
+		v.Sliding = int64(d)
+	}
+	s.member[k] = v
+	s.logSet(k, v)
+	s.persistSet(k, v)
+	s.Unlock()
+	c.syncPersist()
+	return nil
+}
+
+// Persist 移除k的过期时间和滑动过期，使其永不过期
+//
+// k不存在或已过期返回 CacheNoExist
+func (c *cache) Persist(k string) error {
+	s := c.getShard(k)
+	s.Lock()
+	v, ok := s.member[k]
+	if !ok || v.Expired() {
+		s.Unlock()
+		return CacheNoExist
+	}
+	v.Expire = 0
+	v.Sliding = 0
+	s.member[k] = v
+	s.logSet(k, v)
+	s.persistSet(k, v)
+	s.Unlock()
+	c.syncPersist()
+	return nil
+}
+
+// TTL 返回k剩余的存活时间，永不过期的k返回 NoExpiration
+//
+// k不存在或已过期返回 CacheNoExist
+func (c *cache) TTL(k string) (time.Duration, error) {
+	s := c.getShard(k)
+	s.RLock()
+	defer s.RUnlock()
+	v, ok := s.member[k]
+	if !ok || v.Expired() {
+		return 0, CacheNoExist
+	}
+	if v.Expire == 0 {
+		return NoExpiration, nil
+	}
+	return time.Until(time.Unix(0, v.Expire)), nil
+}
@@ -0,0 +1,72 @@
+// ttl_test.go
+package localcache
+
+import (
+	"testing"
+	"time"
+)
+
+func TestExpire(t *testing.T) {
+	cache := NewCache(SetCapture(nil))
+	cache.Set("key1", "value1", 20*time.Millisecond)
+
+	// 延长过期时间
+	if err := cache.Expire("key1", time.Hour); err != nil {
+		t.Fatalf("Expire failed: %v", err)
+	}
+	time.Sleep(30 * time.Millisecond)
+	if _, ok := cache.Get("key1"); !ok {
+		t.Error("Expected key1 to still exist after extension")
+	}
+
+	// d <= 0 立即删除
+	var expired []string
+	cache.OnExpire(func(ev Event) { expired = append(expired, ev.Key) })
+	if err := cache.Expire("key1", 0); err != nil {
+		t.Fatalf("Expire failed: %v", err)
+	}
+	if _, ok := cache.Get("key1"); ok {
+		t.Error("Expected key1 to be deleted")
+	}
+	if len(expired) != 1 {
+		t.Errorf("Expected 1 expire event, got %v", expired)
+	}
+
+	if err := cache.Expire("nonexistent", time.Hour); !CacheErrNoExist(err) {
+		t.Errorf("Expected CacheNoExist, got %v", err)
+	}
+}
+
+func TestPersist(t *testing.T) {
+	cache := NewCache(SetCapture(nil))
+	cache.Set("key1", "value1", 20*time.Millisecond)
+
+	if err := cache.Persist("key1"); err != nil {
+		t.Fatalf("Persist failed: %v", err)
+	}
+	time.Sleep(30 * time.Millisecond)
+	if _, ok := cache.Get("key1"); !ok {
+		t.Error("Expected key1 to never expire")
+	}
+	if err := cache.Persist("nonexistent"); !CacheErrNoExist(err) {
+		t.Errorf("Expected CacheNoExist, got %v", err)
+	}
+}
+
+func TestTTL(t *testing.T) {
+	cache := NewCache(SetCapture(nil))
+	cache.Set("key1", "value1", time.Hour)
+	cache.Set("key2", "value2", 0)
+	cache.Set("key3", "value3", 10*time.Millisecond)
+
+	if ttl, err := cache.TTL("key1"); err != nil || ttl <= 59*time.Minute || ttl > time.Hour {
+		t.Errorf("Expected TTL close to 1h, got %v %v", ttl, err)
+	}
+	if ttl, err := cache.TTL("key2"); err != nil || ttl != NoExpiration {
+		t.Errorf("Expected NoExpiration, got %v %v", ttl, err)
+	}
+	time.Sleep(20 * time.Millisecond)
+	if _, err := cache.TTL("key3"); !CacheErrNoExist(err) {
+		t.Errorf("Expected CacheNoExist for expired key, got %v", err)
+	}
+}
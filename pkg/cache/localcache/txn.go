@@ -0,0 +1,99 @@
+package localcache
+
+import "time"
+
+// Txn 事务内可以执行的操作，只能在 Update 的回调中使用
+type Txn interface {
+	// Get 获取k的值，能读到本事务中之前的写入
+	Get(k string) (interface{}, bool)
+	// Set 写入k，事务提交时生效
+	Set(k string, v interface{}, d time.Duration)
+	// Delete 删除k，事务提交时生效
+	Delete(k string)
+}
+
+// txnWrite 事务中缓存的一次写入，deleted 为 true 表示删除
+type txnWrite struct {
+	it      Iterator
+	deleted bool
+}
+
+// txn Txn 的实现，写入先缓存在 writes 中，回调成功返回后统一提交
+type txn struct {
+	c      *cache
+	now    int64
+	writes map[string]txnWrite
+	order  []string // 写入的key按首次写入的顺序排列，保证提交顺序确定
+}
+
+func (t *txn) Get(k string) (interface{}, bool) {
+	if w, ok := t.writes[k]; ok {
+		if w.deleted {
+			return nil, false
+		}
+		return w.it.Val, true
+	}
+	v, ok := t.c.getShard(k).member[k]
+	if !ok || v.Expired(t.now) {
+		return nil, false
+	}
+	return v.Val, true
+}
+
+func (t *txn) Set(k string, v interface{}, d time.Duration) {
+	s := t.c.getShard(k)
+	t.write(k, txnWrite{it: s.newIterator(v, d, s.jitter)})
+}
+
+func (t *txn) Delete(k string) {
+	t.write(k, txnWrite{deleted: true})
+}
+
+func (t *txn) write(k string, w txnWrite) {
+	if _, ok := t.writes[k]; !ok {
+		t.order = append(t.order, k)
+	}
+	t.writes[k] = w
+}
+
+// Update 在事务中原子地读写多个key，fn 返回错误或 panic 时所有写入都会被丢弃
+//
+// 事务期间持有所有分片的写锁，其他读写操作会被阻塞，fn 应尽量简短且不能调用缓存的其他方法，
+// 否则会死锁；事件和 capture 在提交并释放锁之后派发
+func (c *cache) Update(fn func(tx Txn) error) error {
+	t := &txn{c: c, now: time.Now().UnixNano(), writes: make(map[string]txnWrite)}
+
+	c.lockAll()
+	locked := true
+	defer func() {
+		if locked {
+			c.unlockAll()
+		}
+	}()
+	if err := fn(t); err != nil {
+		return err
+	}
+
+	// 提交
+	var evicted, set, deleted []kv
+	for _, k := range t.order {
+		w := t.writes[k]
+		s := c.getShard(k)
+		if w.deleted {
+			if v, ok := c.delete(s, k); ok {
+				deleted = append(deleted, kv{k, v})
+			}
+			continue
+		}
+		evicted = append(evicted, s.write(k, w.it)...)
+		set = append(set, kv{k, w.it.Val})
+	}
+	capture := c.capture
+	locked = false
+	c.unlockAll()
+
+	c.dispatch(capture, EventEvict, evicted)
+	c.dispatch(capture, EventDelete, deleted)
+	c.publish(EventSet, set)
+	return nil
+}
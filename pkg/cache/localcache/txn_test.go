@@ -0,0 +1,110 @@
+// txn_test.go
+package localcache
+
+import (
+	"errors"
+	"sync"
+	"testing"
+)
+
+func TestUpdate(t *testing.T) {
+	cache := NewCache(SetCapture(nil))
+	cache.Set("alice", 100, 0)
+	cache.Set("bob", 0, 0)
+	cache.Set("tmp", "x", 0)
+
+	var sets, deletes int
+	cache.OnSet(func(Event) { sets++ })
+	cache.OnDelete(func(Event) { deletes++ })
+
+	err := cache.Update(func(tx Txn) error {
+		a, _ := tx.Get("alice")
+		b, _ := tx.Get("bob")
+		tx.Set("alice", a.(int)-30, 0)
+		tx.Set("bob", b.(int)+30, 0)
+		tx.Delete("tmp")
+		// 能读到本事务中的写入
+		if v, _ := tx.Get("alice"); v != 70 {
+			t.Errorf("Expected read-your-writes alice=70, got %v", v)
+		}
+		if _, ok := tx.Get("tmp"); ok {
+			t.Error("Expected tmp to be deleted within the transaction")
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Update failed: %v", err)
+	}
+	if v, _ := cache.Get("alice"); v != 70 {
+		t.Errorf("Expected alice=70, got %v", v)
+	}
+	if v, _ := cache.Get("bob"); v != 30 {
+		t.Errorf("Expected bob=30, got %v", v)
+	}
+	if _, ok := cache.Get("tmp"); ok {
+		t.Error("Expected tmp to be deleted")
+	}
+	if sets != 2 || deletes != 1 {
+		t.Errorf("Expected 2 set and 1 delete events, got %d, %d", sets, deletes)
+	}
+}
+
+func TestUpdateRollback(t *testing.T) {
+	cache := NewCache(SetCapture(nil))
+	cache.Set("key1", 1, 0)
+
+	errAbort := errors.New("abort")
+	err := cache.Update(func(tx Txn) error {
+		tx.Set("key1", 2, 0)
+		tx.Set("key2", 2, 0)
+		return errAbort
+	})
+	if !errors.Is(err, errAbort) {
+		t.Errorf("Expected errAbort, got %v", err)
+	}
+	if v, _ := cache.Get("key1"); v != 1 {
+		t.Errorf("Expected key1 to be rolled back, got %v", v)
+	}
+	if _, ok := cache.Get("key2"); ok {
+		t.Error("Expected key2 not to be written")
+	}
+
+	// panic 同样回滚并释放锁
+	func() {
+		defer func() { recover() }()
+		cache.Update(func(tx Txn) error {
+			tx.Set("key1", 3, 0)
+			panic("boom")
+		})
+	}()
+	if v, _ := cache.Get("key1"); v != 1 {
+		t.Errorf("Expected key1 to be rolled back after panic, got %v", v)
+	}
+}
+
+func TestUpdateConcurrent(t *testing.T) {
+	cache := NewCache(SetCapture(nil))
+	cache.Set("a", 1000, 0)
+	cache.Set("b", 0, 0)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 100; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			cache.Update(func(tx Txn) error {
+				a, _ := tx.Get("a")
+				b, _ := tx.Get("b")
+				tx.Set("a", a.(int)-1, 0)
+				tx.Set("b", b.(int)+1, 0)
+				return nil
+			})
+		}()
+	}
+	wg.Wait()
+	a, _ := cache.Get("a")
+	b, _ := cache.Get("b")
+	if a.(int)+b.(int) != 1000 || b != 100 {
+		t.Errorf("Expected a+b=1000 and b=100, got a=%v b=%v", a, b)
+	}
+}
@@ -0,0 +1,154 @@
+package localcache
+
+import (
+	"bytes"
+	"encoding/gob"
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+)
+
+// Converter 编解码器可选实现的接口，GetInto 在缓存值与目标类型不一致时通过它转换，
+// 例如 JSON 快照加载后的 map[string]interface{} 转换为结构体
+type Converter interface {
+	// Convert 将 src 转换后写入 dst，dst 为非nil指针
+	Convert(src, dst interface{}) error
+}
+
+// Convert 通过 gob 编码再解码转换
+func (gobCodec) Convert(src, dst interface{}) error {
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(src); err != nil {
+		return err
+	}
+	return gob.NewDecoder(&buf).Decode(dst)
+}
+
+// Convert 通过 JSON 编码再解码转换
+func (jsonCodec) Convert(src, dst interface{}) error {
+	data, err := json.Marshal(src)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, dst)
+}
+
+// GetString 获取字符串类型的缓存，[]byte 会转换为字符串；不存在时返回 CacheNoExist，类型不符时返回 CacheTypeErr
+func (c *cache) GetString(k string) (string, error) {
+	v, ok := c.Get(k)
+	if !ok {
+		return "", CacheNoExist
+	}
+	switch s := v.(type) {
+	case string:
+		return s, nil
+	case []byte:
+		return string(s), nil
+	}
+	return "", CacheTypeErr
+}
+
+// GetInt64 获取整数类型的缓存，支持所有整数类型以及没有小数部分的浮点数（JSON 加载后的数字）
+//
+// 不存在时返回 CacheNoExist，类型不符或超出 int64 范围时返回 CacheTypeErr
+func (c *cache) GetInt64(k string) (int64, error) {
+	v, ok := c.Get(k)
+	if !ok {
+		return 0, CacheNoExist
+	}
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return rv.Int(), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		if n := rv.Uint(); n <= math.MaxInt64 {
+			return int64(n), nil
+		}
+	case reflect.Float32, reflect.Float64:
+		if f := rv.Float(); f == math.Trunc(f) && f >= math.MinInt64 && f < math.MaxInt64 {
+			return int64(f), nil
+		}
+	}
+	return 0, CacheTypeErr
+}
+
+// GetInt 获取 int 类型的缓存，规则同 GetInt64
+func (c *cache) GetInt(k string) (int, error) {
+	n, err := c.GetInt64(k)
+	if err != nil {
+		return 0, err
+	}
+	if int64(int(n)) != n {
+		return 0, CacheTypeErr
+	}
+	return int(n), nil
+}
+
+// GetFloat64 获取浮点数类型的缓存，整数会转换为浮点数
+func (c *cache) GetFloat64(k string) (float64, error) {
+	v, ok := c.Get(k)
+	if !ok {
+		return 0, CacheNoExist
+	}
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Float32, reflect.Float64:
+		return rv.Float(), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(rv.Int()), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return float64(rv.Uint()), nil
+	}
+	return 0, CacheTypeErr
+}
+
+// GetBool 获取布尔类型的缓存
+func (c *cache) GetBool(k string) (bool, error) {
+	v, ok := c.Get(k)
+	if !ok {
+		return false, CacheNoExist
+	}
+	b, ok := v.(bool)
+	if !ok {
+		return false, CacheTypeErr
+	}
+	return b, nil
+}
+
+// GetInto 将缓存值写入 dst 指向的变量，dst 必须是非nil指针
+//
+// 缓存值（或其指向的值）可以直接赋值给 *dst 时直接赋值，否则通过 SetCodec 配置的编解码器转换，
+// 编解码器没有实现 Converter 或转换失败时返回 CacheTypeErr；不存在时返回 CacheNoExist
+func (c *cache) GetInto(k string, dst interface{}) error {
+	rv := reflect.ValueOf(dst)
+	if rv.Kind() != reflect.Pointer || rv.IsNil() {
+		return fmt.Errorf("%w: destination must be a non-nil pointer, got %T", CacheTypeErr, dst)
+	}
+	v, ok := c.Get(k)
+	if !ok {
+		return CacheNoExist
+	}
+
+	elem := rv.Elem()
+	src := reflect.ValueOf(v)
+	if src.IsValid() {
+		if src.Type().AssignableTo(elem.Type()) {
+			elem.Set(src)
+			return nil
+		}
+		if src.Kind() == reflect.Pointer && !src.IsNil() && src.Elem().Type().AssignableTo(elem.Type()) {
+			elem.Set(src.Elem())
+			return nil
+		}
+	}
+
+	conv, ok := c.codec.(Converter)
+	if !ok {
+		return fmt.Errorf("%w: cannot assign %T to %s", CacheTypeErr, v, elem.Type())
+	}
+	if err := conv.Convert(v, dst); err != nil {
+		return fmt.Errorf("%w: convert %T to %s: %v", CacheTypeErr, v, elem.Type(), err)
+	}
+	return nil
+}
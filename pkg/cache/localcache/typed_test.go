@@ -0,0 +1,97 @@
+// typed_test.go
+package localcache
+
+import (
+	"bytes"
+	"math"
+	"testing"
+)
+
+func TestTypedGetters(t *testing.T) {
+	cache := NewCache(SetCapture(nil))
+	cache.Set("str", "value", 0)
+	cache.Set("bytes", []byte("raw"), 0)
+	cache.Set("int", int32(42), 0)
+	cache.Set("uint", uint64(math.MaxUint64), 0)
+	cache.Set("float", 3.0, 0)
+	cache.Set("frac", 3.5, 0)
+	cache.Set("bool", true, 0)
+
+	if s, err := cache.GetString("str"); err != nil || s != "value" {
+		t.Errorf("GetString: %q, %v", s, err)
+	}
+	if s, err := cache.GetString("bytes"); err != nil || s != "raw" {
+		t.Errorf("GetString bytes: %q, %v", s, err)
+	}
+	if _, err := cache.GetString("int"); !CacheErrTypeErr(err) {
+		t.Errorf("Expected CacheTypeErr, got %v", err)
+	}
+	if _, err := cache.GetString("missing"); !CacheErrNoExist(err) {
+		t.Errorf("Expected CacheNoExist, got %v", err)
+	}
+
+	if n, err := cache.GetInt64("int"); err != nil || n != 42 {
+		t.Errorf("GetInt64: %d, %v", n, err)
+	}
+	if n, err := cache.GetInt("float"); err != nil || n != 3 {
+		t.Errorf("GetInt float: %d, %v", n, err)
+	}
+	for _, k := range []string{"uint", "frac", "str"} {
+		if _, err := cache.GetInt64(k); !CacheErrTypeErr(err) {
+			t.Errorf("GetInt64(%s): expected CacheTypeErr, got %v", k, err)
+		}
+	}
+	if f, err := cache.GetFloat64("int"); err != nil || f != 42 {
+		t.Errorf("GetFloat64: %v, %v", f, err)
+	}
+	if b, err := cache.GetBool("bool"); err != nil || !b {
+		t.Errorf("GetBool: %v, %v", b, err)
+	}
+}
+
+type typedUser struct {
+	Name string
+	Age  int
+}
+
+func TestGetInto(t *testing.T) {
+	cache := NewCache(SetCapture(nil), SetCodec(JSONCodec()))
+	cache.Set("user", typedUser{"bob", 30}, 0)
+	cache.Set("ptr", &typedUser{"amy", 20}, 0)
+
+	var u typedUser
+	if err := cache.GetInto("user", &u); err != nil || u.Name != "bob" {
+		t.Errorf("GetInto: %+v, %v", u, err)
+	}
+	if err := cache.GetInto("ptr", &u); err != nil || u.Name != "amy" {
+		t.Errorf("GetInto pointer: %+v, %v", u, err)
+	}
+	if err := cache.GetInto("user", u); !CacheErrTypeErr(err) {
+		t.Errorf("Expected CacheTypeErr for non-pointer, got %v", err)
+	}
+
+	// JSON 快照加载后的值为 map[string]interface{}，通过编解码器转换
+	var buf bytes.Buffer
+	if err := cache.Save(&buf); err != nil {
+		t.Fatal(err)
+	}
+	loaded := NewCache(SetCapture(nil), SetCodec(JSONCodec()))
+	if err := loaded.Load(&buf); err != nil {
+		t.Fatal(err)
+	}
+	u = typedUser{}
+	if err := loaded.GetInto("user", &u); err != nil || u != (typedUser{"bob", 30}) {
+		t.Errorf("GetInto after load: %+v, %v", u, err)
+	}
+	var n int
+	if err := loaded.GetInto("user", &n); !CacheErrTypeErr(err) {
+		t.Errorf("Expected CacheTypeErr for mismatched type, got %v", err)
+	}
+
+	// msgpack 没有实现 Converter
+	plain := NewCache(SetCapture(nil), SetCodec(MsgpackCodec()))
+	plain.Set("user", map[string]interface{}{"Name": "bob"}, 0)
+	if err := plain.GetInto("user", &u); !CacheErrTypeErr(err) {
+		t.Errorf("Expected CacheTypeErr without converter, got %v", err)
+	}
+}
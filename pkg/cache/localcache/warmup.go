@@ -0,0 +1,75 @@
+package localcache
+
+import (
+	"io"
+	"os"
+	"time"
+)
+
+// WarmupStats 预热统计
+type WarmupStats struct {
+	Loaded      int           // 写入缓存的条目数
+	Expired     int           // 快照中已过期而丢弃的条目数
+	Skipped     int           // 缓存中已存在未过期的同名条目而跳过的条目数
+	SnapshotAge time.Duration // 快照距今的时间，文件没有记录快照时间时为0
+	Duration    time.Duration // 预热耗时
+}
+
+// Warmup 从 r 中加载快照预热缓存
+//
+// 与 Load 恢复绝对过期时间不同，Warmup 按快照时间计算每个条目的剩余TTL并从当前时间重新开始计时，
+// 停机期间不计入TTL；快照时刻已经过期的条目直接丢弃。版本2之前的文件没有快照时间，按绝对过期时间处理
+func (c *cache) Warmup(r io.Reader) (WarmupStats, error) {
+	start := time.Now()
+	member, h, err := decodeFile(r)
+	if err != nil {
+		return WarmupStats{}, err
+	}
+
+	var stats WarmupStats
+	now := start.UnixNano()
+	if h.snapshot > 0 {
+		stats.SnapshotAge = start.Sub(time.Unix(0, h.snapshot))
+	}
+	var evicted []kv
+	for k, it := range member {
+		if it.Expire > 0 {
+			if h.snapshot > 0 {
+				remaining := it.Expire - h.snapshot
+				if remaining <= 0 {
+					stats.Expired++
+					continue
+				}
+				it.Expire = now + remaining
+			} else if it.Expired(now) {
+				stats.Expired++
+				continue
+			}
+		}
+
+		s := c.getShard(k)
+		s.Lock()
+		if v, ok := s.member[k]; ok && !v.Expired(now) {
+			s.Unlock()
+			stats.Skipped++
+			continue
+		}
+		evicted = append(evicted, s.put(k, it)...)
+		s.Unlock()
+		stats.Loaded++
+	}
+	c.dispatch(c.capture, EventEvict, evicted)
+
+	stats.Duration = time.Since(start)
+	return stats, nil
+}
+
+// WarmupFile 从 path 快照预热缓存
+func (c *cache) WarmupFile(path string) (WarmupStats, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return WarmupStats{}, err
+	}
+	defer f.Close()
+	return c.Warmup(f)
+}
@@ -0,0 +1,92 @@
+// warmup_test.go
+package localcache
+
+import (
+	"bytes"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestWarmup(t *testing.T) {
+	// 两小时前的快照
+	snapshot := time.Now().Add(-2 * time.Hour)
+	at := snapshot.UnixNano()
+	member := map[string]Iterator{
+		"live":    {Val: "v1", Expire: at + int64(time.Hour)},
+		"expired": {Val: "v2", Expire: at - int64(time.Second)},
+		"forever": {Val: "v3"},
+		"exists":  {Val: "old", Expire: at + int64(time.Hour)},
+	}
+	var buf bytes.Buffer
+	if err := writeHeader(&buf, GobCodec(), snapshot); err != nil {
+		t.Fatal(err)
+	}
+	if err := GobCodec().Encode(&buf, member); err != nil {
+		t.Fatal(err)
+	}
+
+	cache := NewCache()
+	cache.Set("exists", "new", 0)
+	stats, err := cache.Warmup(&buf)
+	if err != nil {
+		t.Fatalf("Warmup failed: %v", err)
+	}
+	if stats.Loaded != 2 || stats.Expired != 1 || stats.Skipped != 1 {
+		t.Errorf("Unexpected stats: %+v", stats)
+	}
+	if stats.SnapshotAge < 2*time.Hour {
+		t.Errorf("Expected snapshot age >= 2h, got %v", stats.SnapshotAge)
+	}
+
+	// 剩余TTL从预热时开始计算，Load 会因为绝对过期时间已过而丢失该条目
+	_, expire, ok := cache.GetWithExpire("live")
+	if !ok {
+		t.Fatal("Expected live to be loaded")
+	}
+	if remaining := time.Until(expire); remaining < 59*time.Minute || remaining > time.Hour {
+		t.Errorf("Expected about 1h remaining, got %v", remaining)
+	}
+	if _, ok := cache.Get("expired"); ok {
+		t.Error("Expected expired to be dropped")
+	}
+	if v, _ := cache.Get("exists"); v != "new" {
+		t.Errorf("Expected existing entry to be kept, got %v", v)
+	}
+}
+
+func TestWarmupRoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cache.snap")
+	src := NewCache(SetCodec(JSONCodec()))
+	src.Set("key1", "value1", time.Hour)
+	if err := src.SaveFile(path); err != nil {
+		t.Fatal(err)
+	}
+
+	var stats WarmupStats
+	var reported bool
+	cache := NewCache(SetWarmupFile(path, func(s WarmupStats, err error) {
+		if err != nil {
+			t.Errorf("Unexpected warmup error: %v", err)
+		}
+		stats, reported = s, true
+	}))
+	if !reported || stats.Loaded != 1 {
+		t.Errorf("Expected warm-up report with 1 entry, got %+v", stats)
+	}
+	if v, ok := cache.Get("key1"); !ok || v != "value1" {
+		t.Errorf("Expected key1=value1, got %v, %v", v, ok)
+	}
+
+	// 首次启动没有快照文件
+	reported = false
+	NewCache(SetWarmupFile(filepath.Join(t.TempDir(), "missing"), func(s WarmupStats, err error) {
+		if err != nil || s.Loaded != 0 {
+			t.Errorf("Expected empty report for missing file, got %+v, %v", s, err)
+		}
+		reported = true
+	}))
+	if !reported {
+		t.Error("Expected report for missing file")
+	}
+}
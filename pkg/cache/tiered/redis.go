@@ -0,0 +1,326 @@
+package tiered
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/andrewbytecoder/nmq/pkg/options"
+)
+
+// ErrRedisClosed RedisStore 已经关闭
+var ErrRedisClosed = errors.New("tiered: redis store closed")
+
+// RedisError Redis 返回的错误响应
+type RedisError string
+
+func (e RedisError) Error() string { return "tiered: redis: " + string(e) }
+
+// RedisConfig Redis 连接配置
+type RedisConfig struct {
+	Addr        string        // 地址，例如 127.0.0.1:6379
+	Password    string        // 密码，为空时不认证
+	DB          int           // 数据库编号
+	PoolSize    int           // 最大空闲连接数
+	DialTimeout time.Duration // 建立连接超时
+	IOTimeout   time.Duration // 单条命令的读写超时，ctx 的截止时间更早时以 ctx 为准，0表示只使用 ctx
+}
+
+// SetRedisPassword 设置 Redis 密码
+func SetRedisPassword(password string) options.Option {
+	return func(c any) {
+		if c, ok := c.(*RedisConfig); ok {
+			c.Password = password
+		}
+	}
+}
+
+// SetRedisDB 设置 Redis 数据库编号
+func SetRedisDB(db int) options.Option {
+	return func(c any) {
+		if c, ok := c.(*RedisConfig); ok {
+			c.DB = db
+		}
+	}
+}
+
+// SetRedisPoolSize 设置最大空闲连接数
+func SetRedisPoolSize(n int) options.Option {
+	return func(c any) {
+		if c, ok := c.(*RedisConfig); ok {
+			c.PoolSize = n
+		}
+	}
+}
+
+// SetRedisDialTimeout 设置建立连接超时
+func SetRedisDialTimeout(d time.Duration) options.Option {
+	return func(c any) {
+		if c, ok := c.(*RedisConfig); ok {
+			c.DialTimeout = d
+		}
+	}
+}
+
+// SetRedisIOTimeout 设置单条命令的读写超时
+func SetRedisIOTimeout(d time.Duration) options.Option {
+	return func(c any) {
+		if c, ok := c.(*RedisConfig); ok {
+			c.IOTimeout = d
+		}
+	}
+}
+
+// NewRedisConfig 创建 Redis 连接配置
+func NewRedisConfig(addr string, opts ...options.Option) *RedisConfig {
+	c := &RedisConfig{
+		Addr:        addr,
+		PoolSize:    8,
+		DialTimeout: 5 * time.Second,
+		IOTimeout:   3 * time.Second,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// RedisStore 基于 RESP 协议的 Redis RemoteStore 实现，只使用 GET/SET/DEL 命令
+type RedisStore struct {
+	cfg *RedisConfig
+
+	mu     sync.Mutex
+	idle   []*redisConn // 空闲连接
+	closed bool
+}
+
+// redisConn 一个 Redis 连接
+type redisConn struct {
+	conn    net.Conn
+	r       *bufio.Reader
+	w       *bufio.Writer
+	timeout time.Duration // 默认读写超时
+}
+
+// NewRedisStore 创建 RedisStore，连接在首次使用时建立
+func NewRedisStore(cfg *RedisConfig) *RedisStore {
+	return &RedisStore{cfg: cfg}
+}
+
+// Get 实现 RemoteStore
+func (s *RedisStore) Get(ctx context.Context, key string) ([]byte, error) {
+	reply, err := s.do(ctx, "GET", key)
+	if err != nil {
+		return nil, err
+	}
+	if reply == nil {
+		return nil, ErrNotFound
+	}
+	b, ok := reply.([]byte)
+	if !ok {
+		return nil, fmt.Errorf("tiered: unexpected redis reply %v", reply)
+	}
+	return b, nil
+}
+
+// Set 实现 RemoteStore
+func (s *RedisStore) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	args := []string{"SET", key, string(value)}
+	if ttl > 0 {
+		ms := ttl.Milliseconds()
+		if ms == 0 {
+			ms = 1
+		}
+		args = append(args, "PX", strconv.FormatInt(ms, 10))
+	}
+	_, err := s.do(ctx, args...)
+	return err
+}
+
+// Delete 实现 RemoteStore
+func (s *RedisStore) Delete(ctx context.Context, key string) error {
+	_, err := s.do(ctx, "DEL", key)
+	return err
+}
+
+// Close 关闭所有空闲连接，之后的调用返回 ErrRedisClosed
+func (s *RedisStore) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.closed = true
+	for _, c := range s.idle {
+		_ = c.conn.Close()
+	}
+	s.idle = nil
+	return nil
+}
+
+// do 执行一条命令，连接出错时关闭连接，Redis 错误响应不影响连接复用
+func (s *RedisStore) do(ctx context.Context, args ...string) (interface{}, error) {
+	c, err := s.get(ctx)
+	if err != nil {
+		return nil, err
+	}
+	reply, err := c.do(ctx, args...)
+	var redisErr RedisError
+	if err != nil && !errors.As(err, &redisErr) {
+		_ = c.conn.Close()
+		return nil, err
+	}
+	s.put(c)
+	return reply, err
+}
+
+// get 获取一个空闲连接，没有时新建连接
+func (s *RedisStore) get(ctx context.Context) (*redisConn, error) {
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		return nil, ErrRedisClosed
+	}
+	if n := len(s.idle); n > 0 {
+		c := s.idle[n-1]
+		s.idle = s.idle[:n-1]
+		s.mu.Unlock()
+		return c, nil
+	}
+	s.mu.Unlock()
+	return s.dial(ctx)
+}
+
+// put 归还连接，超过最大空闲连接数时关闭
+func (s *RedisStore) put(c *redisConn) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed || len(s.idle) >= s.cfg.PoolSize {
+		_ = c.conn.Close()
+		return
+	}
+	s.idle = append(s.idle, c)
+}
+
+// dial 建立连接并完成认证和选库
+func (s *RedisStore) dial(ctx context.Context) (*redisConn, error) {
+	d := net.Dialer{Timeout: s.cfg.DialTimeout}
+	conn, err := d.DialContext(ctx, "tcp", s.cfg.Addr)
+	if err != nil {
+		return nil, err
+	}
+	c := &redisConn{conn: conn, r: bufio.NewReader(conn), w: bufio.NewWriter(conn), timeout: s.cfg.IOTimeout}
+	if s.cfg.Password != "" {
+		if _, err = c.do(ctx, "AUTH", s.cfg.Password); err != nil {
+			_ = conn.Close()
+			return nil, err
+		}
+	}
+	if s.cfg.DB != 0 {
+		if _, err = c.do(ctx, "SELECT", strconv.Itoa(s.cfg.DB)); err != nil {
+			_ = conn.Close()
+			return nil, err
+		}
+	}
+	return c, nil
+}
+
+// do 发送命令并读取响应，读写超时取 ctx 的截止时间和默认超时中较早的一个
+//
+// ctx 被取消时立即把连接的截止时间设为当前时间，使阻塞的读写返回，此时返回 ctx.Err()，
+// 响应没有读完的连接由调用方关闭
+func (c *redisConn) do(ctx context.Context, args ...string) (interface{}, error) {
+	deadline, ok := ctx.Deadline()
+	if c.timeout > 0 {
+		if d := time.Now().Add(c.timeout); !ok || d.Before(deadline) {
+			deadline = d
+		}
+	}
+	if err := c.conn.SetDeadline(deadline); err != nil {
+		return nil, err
+	}
+	stop := context.AfterFunc(ctx, func() {
+		_ = c.conn.SetDeadline(time.Now())
+	})
+	reply, err := c.roundTrip(args)
+	if !stop() {
+		return nil, ctx.Err()
+	}
+	return reply, err
+}
+
+// roundTrip 写入命令并读取一个响应
+func (c *redisConn) roundTrip(args []string) (interface{}, error) {
+	if err := writeCommand(c.w, args); err != nil {
+		return nil, err
+	}
+	if err := c.w.Flush(); err != nil {
+		return nil, err
+	}
+	return readReply(c.r)
+}
+
+// writeCommand 按 RESP 数组格式写入命令
+func writeCommand(w *bufio.Writer, args []string) error {
+	if _, err := fmt.Fprintf(w, "*%d\r\n", len(args)); err != nil {
+		return err
+	}
+	for _, arg := range args {
+		if _, err := fmt.Fprintf(w, "$%d\r\n%s\r\n", len(arg), arg); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// readReply 读取一个 RESP 响应，nil bulk 返回 nil，错误响应返回 RedisError
+func readReply(r *bufio.Reader) (interface{}, error) {
+	line, err := r.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	if len(line) < 3 || line[len(line)-2] != '\r' {
+		return nil, fmt.Errorf("tiered: invalid redis reply %q", line)
+	}
+	line = line[:len(line)-2]
+	switch line[0] {
+	case '+':
+		return line[1:], nil
+	case '-':
+		return nil, RedisError(line[1:])
+	case ':':
+		return strconv.ParseInt(line[1:], 10, 64)
+	case '$':
+		n, err := strconv.Atoi(line[1:])
+		if err != nil {
+			return nil, err
+		}
+		if n < 0 {
+			return nil, nil
+		}
+		b := make([]byte, n+2)
+		if _, err = io.ReadFull(r, b); err != nil {
+			return nil, err
+		}
+		return b[:n], nil
+	case '*':
+		n, err := strconv.Atoi(line[1:])
+		if err != nil {
+			return nil, err
+		}
+		if n < 0 {
+			return nil, nil
+		}
+		ret := make([]interface{}, n)
+		for i := range ret {
+			if ret[i], err = readReply(r); err != nil {
+				return nil, err
+			}
+		}
+		return ret, nil
+	}
+	return nil, fmt.Errorf("tiered: invalid redis reply %q", line)
+}
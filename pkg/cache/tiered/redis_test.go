@@ -0,0 +1,202 @@
+package tiered
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeRedis 支持 AUTH/SELECT/GET/SET/DEL 的简易 Redis 服务端
+type fakeRedis struct {
+	ln   net.Listener
+	mu   sync.Mutex
+	data map[string]string
+	ttl  map[string]string
+}
+
+func newFakeRedis(t *testing.T) *fakeRedis {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	f := &fakeRedis{ln: ln, data: make(map[string]string), ttl: make(map[string]string)}
+	go f.serve()
+	t.Cleanup(func() { _ = ln.Close() })
+	return f
+}
+
+func (f *fakeRedis) serve() {
+	for {
+		conn, err := f.ln.Accept()
+		if err != nil {
+			return
+		}
+		go f.handle(conn)
+	}
+}
+
+func (f *fakeRedis) handle(conn net.Conn) {
+	defer conn.Close()
+	r := bufio.NewReader(conn)
+	for {
+		req, err := readReply(r)
+		if err != nil {
+			return
+		}
+		var args []string
+		for _, a := range req.([]interface{}) {
+			args = append(args, string(a.([]byte)))
+		}
+		f.mu.Lock()
+		var reply string
+		switch strings.ToUpper(args[0]) {
+		case "AUTH":
+			if args[1] == "secret" {
+				reply = "+OK\r\n"
+			} else {
+				reply = "-ERR invalid password\r\n"
+			}
+		case "SELECT":
+			reply = "+OK\r\n"
+		case "GET":
+			if v, ok := f.data[args[1]]; ok {
+				reply = fmt.Sprintf("$%d\r\n%s\r\n", len(v), v)
+			} else {
+				reply = "$-1\r\n"
+			}
+		case "SET":
+			f.data[args[1]] = args[2]
+			if len(args) == 5 {
+				f.ttl[args[1]] = args[4]
+			}
+			reply = "+OK\r\n"
+		case "DEL":
+			delete(f.data, args[1])
+			reply = ":1\r\n"
+		default:
+			reply = "-ERR unknown command\r\n"
+		}
+		f.mu.Unlock()
+		if _, err = conn.Write([]byte(reply)); err != nil {
+			return
+		}
+	}
+}
+
+func TestRedisStore(t *testing.T) {
+	srv := newFakeRedis(t)
+	store := NewRedisStore(NewRedisConfig(srv.ln.Addr().String(), SetRedisPassword("secret"), SetRedisDB(1)))
+	defer store.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if _, err := store.Get(ctx, "key1"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("Expected ErrNotFound, got %v", err)
+	}
+	if err := store.Set(ctx, "key1", []byte("value\r\n1"), 1500*time.Millisecond); err != nil {
+		t.Fatal(err)
+	}
+	if v, err := store.Get(ctx, "key1"); err != nil || string(v) != "value\r\n1" {
+		t.Errorf("Expected value, got %q %v", v, err)
+	}
+	if ttl := srv.ttl["key1"]; ttl != "1500" {
+		t.Errorf("Expected PX 1500, got %q", ttl)
+	}
+	if err := store.Delete(ctx, "key1"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := store.Get(ctx, "key1"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected ErrNotFound after delete, got %v", err)
+	}
+
+	// 连接被复用
+	if n := len(store.idle); n != 1 {
+		t.Errorf("Expected 1 idle connection, got %d", n)
+	}
+
+	_ = store.Close()
+	if _, err := store.Get(ctx, "key1"); !errors.Is(err, ErrRedisClosed) {
+		t.Errorf("Expected ErrRedisClosed, got %v", err)
+	}
+}
+
+func TestRedisStoreAuthError(t *testing.T) {
+	srv := newFakeRedis(t)
+	store := NewRedisStore(NewRedisConfig(srv.ln.Addr().String(), SetRedisPassword("wrong")))
+	defer store.Close()
+
+	var redisErr RedisError
+	if _, err := store.Get(context.Background(), "key1"); !errors.As(err, &redisErr) {
+		t.Errorf("Expected RedisError, got %v", err)
+	}
+}
+
+func TestTieredRedis(t *testing.T) {
+	srv := newFakeRedis(t)
+	store := NewRedisStore(NewRedisConfig(srv.ln.Addr().String()))
+	defer store.Close()
+
+	ctx := context.Background()
+	c := NewCache(store, SetLocalTTL(time.Minute))
+	if err := c.Set(ctx, "key1", []byte("value1"), 0); err != nil {
+		t.Fatal(err)
+	}
+	c.Invalidate("key1")
+	if v, err := c.Get(ctx, "key1"); err != nil || string(v) != "value1" {
+		t.Errorf("Expected value1 from redis, got %q %v", v, err)
+	}
+}
+
+func TestRedisStoreTimeout(t *testing.T) {
+	// 只接受连接不回复的服务端
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			// 丢弃请求直到客户端关闭连接
+			go func() {
+				_, _ = io.Copy(io.Discard, conn)
+				_ = conn.Close()
+			}()
+		}
+	}()
+
+	// ctx 没有截止时间时使用默认读写超时
+	store := NewRedisStore(NewRedisConfig(ln.Addr().String(), SetRedisIOTimeout(50*time.Millisecond)))
+	defer store.Close()
+	var netErr net.Error
+	if _, err := store.Get(context.Background(), "key1"); !errors.As(err, &netErr) || !netErr.Timeout() {
+		t.Errorf("Expected timeout error, got %v", err)
+	}
+
+	// ctx 被取消时立即返回
+	store = NewRedisStore(NewRedisConfig(ln.Addr().String(), SetRedisIOTimeout(0)))
+	defer store.Close()
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(50*time.Millisecond, cancel)
+	start := time.Now()
+	if _, err := store.Get(ctx, "key1"); !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected context.Canceled, got %v", err)
+	}
+	if d := time.Since(start); d > 2*time.Second {
+		t.Errorf("Get returned %v after cancel", d)
+	}
+	if n := len(store.idle); n != 0 {
+		t.Errorf("Expected cancelled connection to be closed, got %d idle", n)
+	}
+}
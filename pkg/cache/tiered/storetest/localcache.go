@@ -0,0 +1,288 @@
+package storetest
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/andrewbytecoder/nmq/pkg/cache/localcache"
+)
+
+// LocalCache 两级缓存本地层依赖的 localcache.Cache 方法集合
+//
+// localcache.Cache 是具体类型而不是接口，这里列出一致性测试覆盖的方法，
+// 替代实现需要提供同样的方法并返回 localcache 中定义的错误
+type LocalCache interface {
+	Set(k string, v interface{}, d time.Duration)
+	Get(k string) (interface{}, bool)
+	GetWithExpire(k string) (interface{}, time.Time, bool)
+	Delete(k string)
+	Expire(k string, d time.Duration) error
+	Persist(k string) error
+	TTL(k string) (time.Duration, error)
+	IncrementInt64(k string, n int64) (int64, error)
+	CompareAndSwap(k string, old, new interface{}, d time.Duration) bool
+	DeleteIfEqual(k string, old interface{}) bool
+}
+
+var _ LocalCache = localcache.Cache{}
+
+// TestLocalCache 使用 DefaultConfig 运行本地缓存一致性测试，newCache 为每个子测试创建一个空的缓存
+func TestLocalCache(t *testing.T, newCache func(t *testing.T) LocalCache) {
+	TestLocalCacheWith(t, DefaultConfig(), newCache)
+}
+
+// TestLocalCacheWith 使用指定配置运行本地缓存一致性测试
+func TestLocalCacheWith(t *testing.T, cfg Config, newCache func(t *testing.T) LocalCache) {
+	tests := []struct {
+		name string
+		fn   func(t *testing.T, cfg Config, c LocalCache)
+	}{
+		{"SetGet", testCacheSetGet},
+		{"TTL", testCacheTTL},
+		{"ExpirePersist", testCacheExpirePersist},
+		{"Increment", testCacheIncrement},
+		{"ConcurrentIncrement", testCacheConcurrentIncrement},
+		{"CompareAndSwap", testCacheCompareAndSwap},
+		{"ConcurrentCompareAndSwap", testCacheConcurrentCompareAndSwap},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.fn(t, cfg, newCache(t))
+		})
+	}
+}
+
+// waitExpired 等待key过期，超过 cfg.Timeout 时终止测试
+func waitExpired(t *testing.T, cfg Config, c LocalCache, key string) {
+	t.Helper()
+	deadline := time.Now().Add(cfg.Timeout)
+	for {
+		if _, ok := c.Get(key); !ok {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("Key with ttl %v not expired after %v", cfg.TTL, cfg.Timeout)
+		}
+		time.Sleep(cfg.TTL / 4)
+	}
+}
+
+func testCacheSetGet(t *testing.T, _ Config, c LocalCache) {
+	if v, ok := c.Get("storetest:missing"); ok {
+		t.Fatalf("Get missing key = %v", v)
+	}
+	c.Set("storetest:key", "v1", 0)
+	c.Set("storetest:key", "v2", 0)
+	if v, ok := c.Get("storetest:key"); !ok || v != "v2" {
+		t.Fatalf("Get = %v, %v, want v2", v, ok)
+	}
+	c.Delete("storetest:key")
+	if _, ok := c.Get("storetest:key"); ok {
+		t.Fatal("Get after Delete found the key")
+	}
+	// 删除不存在的key不应 panic
+	c.Delete("storetest:key")
+}
+
+func testCacheTTL(t *testing.T, cfg Config, c LocalCache) {
+	before := time.Now()
+	c.Set("storetest:ttl", "value", cfg.TTL)
+	c.Set("storetest:forever", "value", 0)
+
+	_, exp, ok := c.GetWithExpire("storetest:ttl")
+	if !ok || exp.Before(before.Add(cfg.TTL)) || exp.After(time.Now().Add(cfg.TTL)) {
+		t.Fatalf("GetWithExpire = %v, %v, want about %v", exp, ok, before.Add(cfg.TTL))
+	}
+	if d, err := c.TTL("storetest:ttl"); err != nil || d <= 0 || d > cfg.TTL {
+		t.Fatalf("TTL = %v, %v, want (0, %v]", d, err, cfg.TTL)
+	}
+	if d, err := c.TTL("storetest:forever"); err != nil || d != localcache.NoExpiration {
+		t.Fatalf("TTL of key without expiration = %v, %v, want NoExpiration", d, err)
+	}
+	if _, err := c.TTL("storetest:missing"); !errors.Is(err, localcache.CacheNoExist) {
+		t.Fatalf("TTL of missing key err = %v, want CacheNoExist", err)
+	}
+
+	waitExpired(t, cfg, c, "storetest:ttl")
+	if _, _, ok := c.GetWithExpire("storetest:ttl"); ok {
+		t.Fatal("GetWithExpire found an expired key")
+	}
+	if _, err := c.TTL("storetest:ttl"); !errors.Is(err, localcache.CacheNoExist) {
+		t.Fatalf("TTL of expired key err = %v, want CacheNoExist", err)
+	}
+	if _, ok := c.Get("storetest:forever"); !ok {
+		t.Fatal("Key without expiration expired")
+	}
+}
+
+func testCacheExpirePersist(t *testing.T, cfg Config, c LocalCache) {
+	if err := c.Expire("storetest:missing", cfg.TTL); !errors.Is(err, localcache.CacheNoExist) {
+		t.Fatalf("Expire missing key err = %v, want CacheNoExist", err)
+	}
+	if err := c.Persist("storetest:missing"); !errors.Is(err, localcache.CacheNoExist) {
+		t.Fatalf("Persist missing key err = %v, want CacheNoExist", err)
+	}
+
+	// Persist 移除过期时间
+	c.Set("storetest:persist", "value", cfg.TTL)
+	if err := c.Persist("storetest:persist"); err != nil {
+		t.Fatalf("Persist: %v", err)
+	}
+	if d, err := c.TTL("storetest:persist"); err != nil || d != localcache.NoExpiration {
+		t.Fatalf("TTL after Persist = %v, %v, want NoExpiration", d, err)
+	}
+
+	// Expire 为永不过期的key设置过期时间
+	c.Set("storetest:expire", "value", 0)
+	if err := c.Expire("storetest:expire", cfg.TTL); err != nil {
+		t.Fatalf("Expire: %v", err)
+	}
+	waitExpired(t, cfg, c, "storetest:expire")
+	if _, ok := c.Get("storetest:persist"); !ok {
+		t.Fatal("Persisted key expired")
+	}
+
+	// d <= 0 立即删除
+	c.Set("storetest:now", "value", 0)
+	if err := c.Expire("storetest:now", 0); err != nil {
+		t.Fatalf("Expire(0): %v", err)
+	}
+	if _, ok := c.Get("storetest:now"); ok {
+		t.Fatal("Expire(0) kept the key")
+	}
+}
+
+func testCacheIncrement(t *testing.T, cfg Config, c LocalCache) {
+	if _, err := c.IncrementInt64("storetest:missing", 1); !errors.Is(err, localcache.CacheNoExist) {
+		t.Fatalf("IncrementInt64 missing key err = %v, want CacheNoExist", err)
+	}
+	c.Set("storetest:str", "value", 0)
+	if _, err := c.IncrementInt64("storetest:str", 1); !errors.Is(err, localcache.CacheTypeErr) {
+		t.Fatalf("IncrementInt64 string err = %v, want CacheTypeErr", err)
+	}
+
+	// 自增保留原有的过期时间
+	c.Set("storetest:counter", int64(1), time.Hour)
+	_, want, _ := c.GetWithExpire("storetest:counter")
+	if n, err := c.IncrementInt64("storetest:counter", 2); err != nil || n != 3 {
+		t.Fatalf("IncrementInt64 = %d, %v, want 3", n, err)
+	}
+	if n, err := c.IncrementInt64("storetest:counter", -5); err != nil || n != -2 {
+		t.Fatalf("IncrementInt64 negative = %d, %v, want -2", n, err)
+	}
+	if v, exp, ok := c.GetWithExpire("storetest:counter"); !ok || v != int64(-2) || !exp.Equal(want) {
+		t.Fatalf("GetWithExpire = %v, %v, %v, want -2 expiring at %v", v, exp, ok, want)
+	}
+
+	// 已过期的key不能自增
+	c.Set("storetest:expired", int64(1), cfg.TTL)
+	waitExpired(t, cfg, c, "storetest:expired")
+	if _, err := c.IncrementInt64("storetest:expired", 1); err == nil {
+		t.Fatal("IncrementInt64 expired key succeeded")
+	}
+}
+
+func testCacheConcurrentIncrement(t *testing.T, cfg Config, c LocalCache) {
+	const rounds = 100
+	c.Set("storetest:counter", int64(0), 0)
+	var wg sync.WaitGroup
+	errs := make(chan error, cfg.Workers)
+	for i := 0; i < cfg.Workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < rounds; j++ {
+				if _, err := c.IncrementInt64("storetest:counter", 1); err != nil {
+					errs <- err
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Fatal(err)
+	}
+	if v, _ := c.Get("storetest:counter"); v != int64(cfg.Workers*rounds) {
+		t.Fatalf("Counter = %v, want %d", v, cfg.Workers*rounds)
+	}
+}
+
+func testCacheCompareAndSwap(t *testing.T, cfg Config, c LocalCache) {
+	if c.CompareAndSwap("storetest:missing", nil, "value", 0) {
+		t.Fatal("CompareAndSwap on missing key succeeded")
+	}
+
+	c.Set("storetest:key", "v1", 0)
+	if c.CompareAndSwap("storetest:key", "other", "v2", 0) {
+		t.Fatal("CompareAndSwap with wrong old value succeeded")
+	}
+	if !c.CompareAndSwap("storetest:key", "v1", "v2", cfg.TTL) {
+		t.Fatal("CompareAndSwap with matching old value failed")
+	}
+	// 替换成功时使用新的过期时间
+	if v, _, ok := c.GetWithExpire("storetest:key"); !ok || v != "v2" {
+		t.Fatalf("Get after CompareAndSwap = %v, %v, want v2", v, ok)
+	}
+	if d, err := c.TTL("storetest:key"); err != nil || d == localcache.NoExpiration {
+		t.Fatalf("TTL after CompareAndSwap = %v, %v, want the new ttl", d, err)
+	}
+
+	// 不可比较的值按内容比较
+	c.Set("storetest:slice", []string{"a"}, 0)
+	if !c.CompareAndSwap("storetest:slice", []string{"a"}, []string{"b"}, 0) {
+		t.Fatal("CompareAndSwap with equal slice failed")
+	}
+
+	if c.DeleteIfEqual("storetest:slice", []string{"a"}) {
+		t.Fatal("DeleteIfEqual with wrong old value succeeded")
+	}
+	if !c.DeleteIfEqual("storetest:slice", []string{"b"}) {
+		t.Fatal("DeleteIfEqual with matching old value failed")
+	}
+	if _, ok := c.Get("storetest:slice"); ok {
+		t.Fatal("Get after DeleteIfEqual found the key")
+	}
+
+	// 已过期的key不参与比较
+	waitExpired(t, cfg, c, "storetest:key")
+	if c.CompareAndSwap("storetest:key", "v2", "v3", 0) {
+		t.Fatal("CompareAndSwap on expired key succeeded")
+	}
+}
+
+func testCacheConcurrentCompareAndSwap(t *testing.T, cfg Config, c LocalCache) {
+	// 每个协程用 CompareAndSwap 实现自增，最终值等于成功的次数之和
+	const rounds = 50
+	c.Set("storetest:cas", int64(0), 0)
+	var wg sync.WaitGroup
+	errs := make(chan error, cfg.Workers)
+	for i := 0; i < cfg.Workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < rounds; {
+				v, ok := c.Get("storetest:cas")
+				if !ok {
+					errs <- fmt.Errorf("Get(%q) missing", "storetest:cas")
+					return
+				}
+				if c.CompareAndSwap("storetest:cas", v, v.(int64)+1, 0) {
+					j++
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Fatal(err)
+	}
+	if v, _ := c.Get("storetest:cas"); v != int64(cfg.Workers*rounds) {
+		t.Fatalf("Counter = %v, want %d", v, cfg.Workers*rounds)
+	}
+}
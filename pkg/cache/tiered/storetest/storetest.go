@@ -0,0 +1,231 @@
+// Package storetest 提供 tiered.RemoteStore 和本地缓存的一致性测试套件
+//
+// 第三方存储实现在自己的测试中调用 TestRemoteStore，验证实现满足两级缓存依赖的语义：
+// 不存在的key返回 ErrNotFound、覆盖写、删除幂等、值的拷贝隔离、ttl 过期以及并发安全
+//
+//	func TestMyStore(t *testing.T) {
+//		storetest.TestRemoteStore(t, func(t *testing.T) tiered.RemoteStore {
+//			return newMyStore(t)
+//		})
+//	}
+//
+// 本地缓存实现调用 TestLocalCache，覆盖 TTL、Expire/Persist、IncrementInt64 和 CompareAndSwap 的语义。
+//
+// 消息队列的分段存储和消费位点存储目前在代码中还没有抽象出接口，暂不提供对应的一致性测试，
+// 待接口确定后再在这里补充。
+package storetest
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/andrewbytecoder/nmq/pkg/cache/tiered"
+)
+
+// Config 一致性测试配置
+type Config struct {
+	TTL     time.Duration // 过期测试使用的 ttl，需要大于存储的过期精度
+	Timeout time.Duration // 等待key过期的最长时间
+	Workers int           // 并发测试的协程数
+}
+
+// DefaultConfig 默认的一致性测试配置，适用于毫秒级过期精度的存储
+func DefaultConfig() Config {
+	return Config{TTL: 100 * time.Millisecond, Timeout: 3 * time.Second, Workers: 8}
+}
+
+// TestRemoteStore 使用 DefaultConfig 运行 RemoteStore 一致性测试，newStore 为每个子测试创建一个空的存储
+func TestRemoteStore(t *testing.T, newStore func(t *testing.T) tiered.RemoteStore) {
+	TestRemoteStoreWith(t, DefaultConfig(), newStore)
+}
+
+// TestRemoteStoreWith 使用指定配置运行 RemoteStore 一致性测试
+func TestRemoteStoreWith(t *testing.T, cfg Config, newStore func(t *testing.T) tiered.RemoteStore) {
+	tests := []struct {
+		name string
+		fn   func(t *testing.T, cfg Config, s tiered.RemoteStore)
+	}{
+		{"GetMissing", testGetMissing},
+		{"SetGet", testSetGet},
+		{"Overwrite", testOverwrite},
+		{"Delete", testDelete},
+		{"EmptyValue", testEmptyValue},
+		{"ValueIsolation", testValueIsolation},
+		{"NoExpire", testNoExpire},
+		{"Expire", testExpire},
+		{"Concurrent", testConcurrent},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.fn(t, cfg, newStore(t))
+		})
+	}
+}
+
+// mustSet 写入key，失败时终止测试
+func mustSet(t *testing.T, s tiered.RemoteStore, key string, value []byte, ttl time.Duration) {
+	t.Helper()
+	if err := s.Set(context.Background(), key, value, ttl); err != nil {
+		t.Fatalf("Set(%q): %v", key, err)
+	}
+}
+
+// expectValue 检查key的值
+func expectValue(t *testing.T, s tiered.RemoteStore, key string, want []byte) {
+	t.Helper()
+	got, err := s.Get(context.Background(), key)
+	if err != nil {
+		t.Fatalf("Get(%q): %v", key, err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Fatalf("Get(%q) = %q, want %q", key, got, want)
+	}
+}
+
+// expectNotFound 检查key不存在
+func expectNotFound(t *testing.T, s tiered.RemoteStore, key string) {
+	t.Helper()
+	if v, err := s.Get(context.Background(), key); !errors.Is(err, tiered.ErrNotFound) {
+		t.Fatalf("Get(%q) = %q, %v, want ErrNotFound", key, v, err)
+	}
+}
+
+func testGetMissing(t *testing.T, _ Config, s tiered.RemoteStore) {
+	expectNotFound(t, s, "storetest:missing")
+}
+
+func testSetGet(t *testing.T, _ Config, s tiered.RemoteStore) {
+	mustSet(t, s, "storetest:key", []byte("value"), 0)
+	expectValue(t, s, "storetest:key", []byte("value"))
+
+	// 二进制数据需要原样返回
+	bin := []byte{0, 1, 2, '\r', '\n', 0xff}
+	mustSet(t, s, "storetest:binary", bin, 0)
+	expectValue(t, s, "storetest:binary", bin)
+}
+
+func testOverwrite(t *testing.T, _ Config, s tiered.RemoteStore) {
+	mustSet(t, s, "storetest:key", []byte("v1"), 0)
+	mustSet(t, s, "storetest:key", []byte("v2"), 0)
+	expectValue(t, s, "storetest:key", []byte("v2"))
+}
+
+func testDelete(t *testing.T, _ Config, s tiered.RemoteStore) {
+	ctx := context.Background()
+	mustSet(t, s, "storetest:key", []byte("value"), 0)
+	if err := s.Delete(ctx, "storetest:key"); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	expectNotFound(t, s, "storetest:key")
+
+	// 删除不存在的key不返回错误
+	if err := s.Delete(ctx, "storetest:key"); err != nil {
+		t.Fatalf("Delete missing key: %v", err)
+	}
+}
+
+func testEmptyValue(t *testing.T, _ Config, s tiered.RemoteStore) {
+	// 空值与不存在是不同的状态
+	mustSet(t, s, "storetest:empty", []byte{}, 0)
+	got, err := s.Get(context.Background(), "storetest:empty")
+	if err != nil {
+		t.Fatalf("Get empty value: %v", err)
+	}
+	if len(got) != 0 {
+		t.Fatalf("Get empty value = %q", got)
+	}
+}
+
+func testValueIsolation(t *testing.T, _ Config, s tiered.RemoteStore) {
+	// 写入后修改调用方的切片不影响存储的值
+	value := []byte("value")
+	mustSet(t, s, "storetest:key", value, 0)
+	copy(value, "XXXXX")
+	expectValue(t, s, "storetest:key", []byte("value"))
+
+	// 修改读取到的切片不影响存储的值
+	got, err := s.Get(context.Background(), "storetest:key")
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	if len(got) > 0 {
+		got[0] = 'X'
+	}
+	expectValue(t, s, "storetest:key", []byte("value"))
+}
+
+func testNoExpire(t *testing.T, cfg Config, s tiered.RemoteStore) {
+	// ttl <= 0 表示不过期
+	mustSet(t, s, "storetest:zero", []byte("value"), 0)
+	mustSet(t, s, "storetest:negative", []byte("value"), -time.Second)
+	time.Sleep(2 * cfg.TTL)
+	expectValue(t, s, "storetest:zero", []byte("value"))
+	expectValue(t, s, "storetest:negative", []byte("value"))
+}
+
+func testExpire(t *testing.T, cfg Config, s tiered.RemoteStore) {
+	mustSet(t, s, "storetest:ttl", []byte("value"), cfg.TTL)
+	expectValue(t, s, "storetest:ttl", []byte("value"))
+
+	// 覆盖写使用新的 ttl
+	mustSet(t, s, "storetest:renew", []byte("value"), cfg.TTL)
+	mustSet(t, s, "storetest:renew", []byte("value"), 0)
+
+	deadline := time.Now().Add(cfg.Timeout)
+	for {
+		_, err := s.Get(context.Background(), "storetest:ttl")
+		if errors.Is(err, tiered.ErrNotFound) {
+			break
+		}
+		if err != nil {
+			t.Fatalf("Get expiring key: %v", err)
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("Key with ttl %v not expired after %v", cfg.TTL, cfg.Timeout)
+		}
+		time.Sleep(cfg.TTL / 4)
+	}
+	expectValue(t, s, "storetest:renew", []byte("value"))
+}
+
+func testConcurrent(t *testing.T, cfg Config, s tiered.RemoteStore) {
+	ctx := context.Background()
+	var wg sync.WaitGroup
+	errs := make(chan error, cfg.Workers)
+	for i := 0; i < cfg.Workers; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			key := fmt.Sprintf("storetest:concurrent:%d", i)
+			for j := 0; j < 50; j++ {
+				value := []byte(fmt.Sprintf("%d-%d", i, j))
+				if err := s.Set(ctx, key, value, 0); err != nil {
+					errs <- err
+					return
+				}
+				got, err := s.Get(ctx, key)
+				if err != nil {
+					errs <- err
+					return
+				}
+				if !bytes.Equal(got, value) {
+					errs <- fmt.Errorf("Get(%q) = %q, want %q", key, got, value)
+					return
+				}
+			}
+			errs <- s.Delete(ctx, key)
+		}(i)
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,59 @@
+package storetest
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/andrewbytecoder/nmq/pkg/cache/localcache"
+	"github.com/andrewbytecoder/nmq/pkg/cache/tiered"
+)
+
+// memStore 支持过期的内存存储，作为一致性测试套件自身的参考实现
+type memStore struct {
+	mu     sync.Mutex
+	data   map[string][]byte
+	expire map[string]time.Time
+}
+
+func newMemStore() *memStore {
+	return &memStore{data: make(map[string][]byte), expire: make(map[string]time.Time)}
+}
+
+func (m *memStore) Get(_ context.Context, key string) ([]byte, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	v, ok := m.data[key]
+	if e, has := m.expire[key]; !ok || has && time.Now().After(e) {
+		return nil, tiered.ErrNotFound
+	}
+	return append([]byte{}, v...), nil
+}
+
+func (m *memStore) Set(_ context.Context, key string, value []byte, ttl time.Duration) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.data[key] = append([]byte{}, value...)
+	delete(m.expire, key)
+	if ttl > 0 {
+		m.expire[key] = time.Now().Add(ttl)
+	}
+	return nil
+}
+
+func (m *memStore) Delete(_ context.Context, key string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.data, key)
+	delete(m.expire, key)
+	return nil
+}
+
+func TestMemStore(t *testing.T) {
+	TestRemoteStore(t, func(*testing.T) tiered.RemoteStore { return newMemStore() })
+}
+
+func TestLocalCacheConformance(t *testing.T) {
+	TestLocalCache(t, func(*testing.T) LocalCache { return localcache.NewCache(localcache.SetCapture(nil)) })
+}
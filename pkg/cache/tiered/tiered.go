@@ -0,0 +1,166 @@
+// Package tiered 提供本地缓存 + 远端存储的两级缓存
+//
+// 读取时先查 localcache，未命中再查 RemoteStore 并回填本地；
+// 写入支持 write-through（同时写远端和本地）和 write-around（写远端并使本地失效）两种模式，
+// 可选开启负缓存，避免远端不存在的key反复穿透
+package tiered
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/andrewbytecoder/nmq/pkg/cache/localcache"
+	"github.com/andrewbytecoder/nmq/pkg/options"
+)
+
+// ErrNotFound 远端存储中不存在该key
+var ErrNotFound = errors.New("tiered: key not found")
+
+// RemoteStore 远端缓存存储接口，例如 Redis
+type RemoteStore interface {
+	// Get 获取key对应的值，不存在时返回 ErrNotFound
+	Get(ctx context.Context, key string) ([]byte, error)
+	// Set 设置key的值，ttl <= 0 表示不过期
+	Set(ctx context.Context, key string, value []byte, ttl time.Duration) error
+	// Delete 删除key，key不存在时不返回错误
+	Delete(ctx context.Context, key string) error
+}
+
+// negative 负缓存标记，表示远端不存在该key
+type negative struct{}
+
+// Config 两级缓存配置
+type Config struct {
+	LocalTTL     time.Duration    // 本地缓存的过期时间，0表示与远端写入的ttl一致，读取回填时不过期
+	NegativeTTL  time.Duration    // 负缓存的过期时间，0表示不开启负缓存
+	WriteThrough bool             // 是否开启 write-through，关闭时写入远端后删除本地缓存
+	LocalOptions []options.Option // 创建 localcache 使用的选项
+}
+
+// SetLocalTTL 设置本地缓存的过期时间
+func SetLocalTTL(d time.Duration) options.Option {
+	return func(c any) {
+		if c, ok := c.(*Config); ok {
+			c.LocalTTL = d
+		}
+	}
+}
+
+// SetNegativeTTL 设置负缓存的过期时间，0表示不开启负缓存
+func SetNegativeTTL(d time.Duration) options.Option {
+	return func(c any) {
+		if c, ok := c.(*Config); ok {
+			c.NegativeTTL = d
+		}
+	}
+}
+
+// SetWriteThrough 设置是否开启 write-through
+func SetWriteThrough(writeThrough bool) options.Option {
+	return func(c any) {
+		if c, ok := c.(*Config); ok {
+			c.WriteThrough = writeThrough
+		}
+	}
+}
+
+// SetLocalOptions 设置创建 localcache 使用的选项
+func SetLocalOptions(opts ...options.Option) options.Option {
+	return func(c any) {
+		if c, ok := c.(*Config); ok {
+			c.LocalOptions = opts
+		}
+	}
+}
+
+// NewConfig 创建两级缓存配置
+func NewConfig(opts ...options.Option) *Config {
+	c := &Config{
+		WriteThrough: true,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// Cache 两级缓存
+type Cache struct {
+	local  localcache.Cache
+	remote RemoteStore
+	cfg    *Config
+}
+
+// NewCache 创建两级缓存
+func NewCache(remote RemoteStore, opts ...options.Option) *Cache {
+	cfg := NewConfig(opts...)
+	// 默认不打印本地缓存的删除日志
+	localOpts := append([]options.Option{localcache.SetCapture(nil)}, cfg.LocalOptions...)
+	return &Cache{
+		local:  localcache.NewCache(localOpts...),
+		remote: remote,
+		cfg:    cfg,
+	}
+}
+
+// Local 返回本地缓存，可用于查看统计或订阅事件
+func (c *Cache) Local() localcache.Cache {
+	return c.local
+}
+
+// Get 获取key的值，本地未命中时从远端加载并回填，同一个key的并发加载会被合并
+//
+// 远端不存在时返回 ErrNotFound，开启负缓存后在 NegativeTTL 内不会再次访问远端；
+// 返回的是本地缓存值的拷贝，调用方修改不会影响缓存
+func (c *Cache) Get(ctx context.Context, key string) ([]byte, error) {
+	v, err := c.local.GetOrLoad(key, func() (interface{}, time.Duration, error) {
+		value, err := c.remote.Get(ctx, key)
+		if errors.Is(err, ErrNotFound) && c.cfg.NegativeTTL > 0 {
+			return negative{}, c.cfg.NegativeTTL, nil
+		}
+		if err != nil {
+			return nil, 0, err
+		}
+		return value, c.cfg.LocalTTL, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := v.(negative); ok {
+		return nil, ErrNotFound
+	}
+	return append([]byte(nil), v.([]byte)...), nil
+}
+
+// Set 写入远端，write-through 模式下同时写入本地，否则删除本地缓存
+func (c *Cache) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	if err := c.remote.Set(ctx, key, value, ttl); err != nil {
+		// 远端写入失败时本地可能已经过时，删除本地缓存
+		c.local.Delete(key)
+		return err
+	}
+	if !c.cfg.WriteThrough {
+		c.local.Delete(key)
+		return nil
+	}
+	d := c.cfg.LocalTTL
+	if d <= 0 || (ttl > 0 && ttl < d) {
+		d = ttl
+	}
+	// 写入拷贝，调用方之后修改 value 不影响本地缓存
+	c.local.Set(key, append([]byte(nil), value...), d)
+	return nil
+}
+
+// Delete 删除远端和本地的key
+func (c *Cache) Delete(ctx context.Context, key string) error {
+	err := c.remote.Delete(ctx, key)
+	c.local.Delete(key)
+	return err
+}
+
+// Invalidate 只删除本地缓存，用于收到其他节点的失效通知
+func (c *Cache) Invalidate(key string) {
+	c.local.Delete(key)
+}
@@ -0,0 +1,178 @@
+package tiered
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// memStore 内存实现的 RemoteStore，记录 Get 调用次数
+type memStore struct {
+	mu   sync.Mutex
+	data map[string][]byte
+	gets atomic.Int32
+	err  error
+}
+
+func newMemStore() *memStore {
+	return &memStore{data: make(map[string][]byte)}
+}
+
+func (m *memStore) Get(ctx context.Context, key string) ([]byte, error) {
+	m.gets.Add(1)
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.err != nil {
+		return nil, m.err
+	}
+	v, ok := m.data[key]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return v, nil
+}
+
+func (m *memStore) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.err != nil {
+		return m.err
+	}
+	m.data[key] = value
+	return nil
+}
+
+func (m *memStore) Delete(ctx context.Context, key string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.data, key)
+	return nil
+}
+
+func TestGetFallback(t *testing.T) {
+	ctx := context.Background()
+	remote := newMemStore()
+	remote.data["key1"] = []byte("value1")
+	c := NewCache(remote)
+
+	for i := 0; i < 3; i++ {
+		v, err := c.Get(ctx, "key1")
+		if err != nil || string(v) != "value1" {
+			t.Fatalf("Expected value1, got %q %v", v, err)
+		}
+	}
+	if n := remote.gets.Load(); n != 1 {
+		t.Errorf("Expected 1 remote get, got %d", n)
+	}
+
+	if _, err := c.Get(ctx, "nonexistent"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected ErrNotFound, got %v", err)
+	}
+}
+
+func TestNegativeCache(t *testing.T) {
+	ctx := context.Background()
+	remote := newMemStore()
+	c := NewCache(remote, SetNegativeTTL(20*time.Millisecond))
+
+	for i := 0; i < 3; i++ {
+		if _, err := c.Get(ctx, "key1"); !errors.Is(err, ErrNotFound) {
+			t.Fatalf("Expected ErrNotFound, got %v", err)
+		}
+	}
+	if n := remote.gets.Load(); n != 1 {
+		t.Errorf("Expected 1 remote get with negative cache, got %d", n)
+	}
+
+	// 负缓存过期后重新访问远端
+	remote.data["key1"] = []byte("value1")
+	time.Sleep(30 * time.Millisecond)
+	if v, err := c.Get(ctx, "key1"); err != nil || string(v) != "value1" {
+		t.Errorf("Expected value1 after negative ttl, got %q %v", v, err)
+	}
+}
+
+func TestWriteThrough(t *testing.T) {
+	ctx := context.Background()
+	remote := newMemStore()
+	c := NewCache(remote)
+
+	if err := c.Set(ctx, "key1", []byte("value1"), 0); err != nil {
+		t.Fatal(err)
+	}
+	if string(remote.data["key1"]) != "value1" {
+		t.Error("Expected value to be written to remote")
+	}
+	if _, ok := c.Local().Get("key1"); !ok {
+		t.Error("Expected value to be written to local")
+	}
+
+	if err := c.Delete(ctx, "key1"); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := remote.data["key1"]; ok {
+		t.Error("Expected value to be deleted from remote")
+	}
+	if _, ok := c.Local().Get("key1"); ok {
+		t.Error("Expected value to be deleted from local")
+	}
+}
+
+func TestWriteAround(t *testing.T) {
+	ctx := context.Background()
+	remote := newMemStore()
+	c := NewCache(remote, SetWriteThrough(false))
+
+	remote.data["key1"] = []byte("old")
+	if _, err := c.Get(ctx, "key1"); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Set(ctx, "key1", []byte("new"), 0); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := c.Local().Get("key1"); ok {
+		t.Error("Expected local value to be invalidated")
+	}
+	if v, _ := c.Get(ctx, "key1"); string(v) != "new" {
+		t.Errorf("Expected new, got %q", v)
+	}
+}
+
+func TestSetRemoteError(t *testing.T) {
+	ctx := context.Background()
+	remote := newMemStore()
+	c := NewCache(remote)
+	c.Local().Set("key1", []byte("stale"), 0)
+
+	remote.err = errors.New("remote down")
+	if err := c.Set(ctx, "key1", []byte("value1"), 0); err == nil {
+		t.Fatal("Expected error from remote")
+	}
+	if _, ok := c.Local().Get("key1"); ok {
+		t.Error("Expected stale local value to be removed")
+	}
+}
+
+func TestGetReturnsCopy(t *testing.T) {
+	ctx := context.Background()
+	c := NewCache(newMemStore())
+
+	value := []byte("value1")
+	if err := c.Set(ctx, "key1", value, 0); err != nil {
+		t.Fatal(err)
+	}
+	value[0] = 'X'
+	v, err := c.Get(ctx, "key1")
+	if err != nil || string(v) != "value1" {
+		t.Fatalf("Expected value1, got %q %v", v, err)
+	}
+
+	// 修改返回值不影响本地缓存
+	v[0] = 'X'
+	if v, _ := c.Get(ctx, "key1"); string(v) != "value1" {
+		t.Errorf("Expected cached value1, got %q", v)
+	}
+}
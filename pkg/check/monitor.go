@@ -0,0 +1,304 @@
+package check
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"net"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/andrewbytecoder/nmq/pkg/options"
+)
+
+// ErrInvalidTarget 无法解析的检查目标
+var ErrInvalidTarget = errors.New("check: invalid target")
+
+// Result 一次检查的结果
+type Result struct {
+	Target    string        // 检查目标
+	Addrs     []string      // DNS 解析得到的地址
+	Reachable bool          // 是否可以建立连接
+	Latency   time.Duration // 建立连接（包括TLS握手）的耗时
+	NotAfter  time.Time     // 证书链中最早的过期时间，非TLS目标为零值
+	CheckedAt time.Time     // 检查时间
+	Err       error         // 检查失败的原因
+}
+
+// CertExpiry 证书即将过期事件
+type CertExpiry struct {
+	Target    string        // 检查目标
+	NotAfter  time.Time     // 证书过期时间
+	Remaining time.Duration // 剩余有效时间，已过期时为负数
+}
+
+// Config 检查配置
+type Config struct {
+	Interval     time.Duration    // 后台刷新间隔
+	Timeout      time.Duration    // 单次检查超时
+	CertWarning  time.Duration    // 证书剩余有效时间低于该值时发出 CertExpiry 事件
+	OnCertExpiry func(CertExpiry) // 证书即将过期的回调
+}
+
+// SetInterval 设置后台刷新间隔
+func SetInterval(interval time.Duration) options.Option {
+	return func(c any) {
+		if c, ok := c.(*Config); ok {
+			c.Interval = interval
+		}
+	}
+}
+
+// SetTimeout 设置单次检查超时
+func SetTimeout(timeout time.Duration) options.Option {
+	return func(c any) {
+		if c, ok := c.(*Config); ok {
+			c.Timeout = timeout
+		}
+	}
+}
+
+// SetCertExpiryWarning 设置证书即将过期的提醒阈值和回调
+func SetCertExpiryWarning(within time.Duration, fn func(CertExpiry)) options.Option {
+	return func(c any) {
+		if c, ok := c.(*Config); ok {
+			c.CertWarning = within
+			c.OnCertExpiry = fn
+		}
+	}
+}
+
+// NewConfig 创建检查配置，默认每分钟刷新，超时5秒，证书剩余14天时提醒
+func NewConfig(opts ...options.Option) *Config {
+	c := &Config{
+		Interval:    time.Minute,
+		Timeout:     5 * time.Second,
+		CertWarning: 14 * 24 * time.Hour,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// target 解析后的检查目标
+type target struct {
+	host string
+	addr string // host:port
+	tls  bool
+}
+
+// parseTarget 解析检查目标，支持 host:port、tls://host:port、http://host[:port]、https://host[:port]
+func parseTarget(s string) (target, error) {
+	if u, err := url.Parse(s); err == nil && u.Host != "" {
+		port := u.Port()
+		switch u.Scheme {
+		case "https":
+			port = defaultPort(port, "443")
+		case "http":
+			port = defaultPort(port, "80")
+		case "tls", "tcp":
+		default:
+			return target{}, ErrInvalidTarget
+		}
+		if port == "" {
+			return target{}, ErrInvalidTarget
+		}
+		return target{host: u.Hostname(), addr: net.JoinHostPort(u.Hostname(), port), tls: u.Scheme == "https" || u.Scheme == "tls"}, nil
+	}
+	host, port, err := net.SplitHostPort(s)
+	if err != nil || host == "" || port == "" {
+		return target{}, ErrInvalidTarget
+	}
+	return target{host: host, addr: s}, nil
+}
+
+func defaultPort(port, def string) string {
+	if port == "" {
+		return def
+	}
+	return port
+}
+
+// Monitor 检查结果缓存，Get 未命中时同步检查并缓存，Start 之后在后台按间隔刷新所有检查过的目标
+type Monitor struct {
+	cfg     *Config
+	mu      sync.RWMutex
+	results map[string]Result
+	warned  map[string]time.Time // 已经提醒过的证书过期时间，证书更换后重新提醒
+	stop    chan struct{}
+	done    chan struct{}
+	once    sync.Once
+}
+
+// NewMonitor 创建检查结果缓存
+func NewMonitor(opts ...options.Option) *Monitor {
+	return &Monitor{
+		cfg:     NewConfig(opts...),
+		results: make(map[string]Result),
+		warned:  make(map[string]time.Time),
+		stop:    make(chan struct{}),
+		done:    make(chan struct{}),
+	}
+}
+
+// Get 返回目标的检查结果，没有缓存时同步检查
+func (m *Monitor) Get(ctx context.Context, t string) Result {
+	m.mu.RLock()
+	r, ok := m.results[t]
+	m.mu.RUnlock()
+	if ok {
+		return r
+	}
+	return m.Refresh(ctx, t)
+}
+
+// Refresh 立即检查目标并更新缓存
+func (m *Monitor) Refresh(ctx context.Context, t string) Result {
+	r := m.check(ctx, t)
+	m.mu.Lock()
+	m.results[t] = r
+	m.mu.Unlock()
+	m.warnCert(r)
+	return r
+}
+
+// Remove 不再检查目标
+func (m *Monitor) Remove(t string) {
+	m.mu.Lock()
+	delete(m.results, t)
+	delete(m.warned, t)
+	m.mu.Unlock()
+}
+
+// Results 返回所有目标的缓存结果
+func (m *Monitor) Results() map[string]Result {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	ret := make(map[string]Result, len(m.results))
+	for t, r := range m.results {
+		ret[t] = r
+	}
+	return ret
+}
+
+// Start 启动后台刷新协程，需要调用 Stop 停止
+func (m *Monitor) Start() {
+	go func() {
+		defer close(m.done)
+		ticker := time.NewTicker(m.cfg.Interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				m.refreshAll()
+			case <-m.stop:
+				return
+			}
+		}
+	}()
+}
+
+// Stop 停止后台刷新协程，可重复调用
+func (m *Monitor) Stop() {
+	m.once.Do(func() {
+		close(m.stop)
+	})
+}
+
+// refreshAll 刷新所有检查过的目标，停止时取消进行中的检查
+func (m *Monitor) refreshAll() {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		select {
+		case <-m.stop:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	m.mu.RLock()
+	targets := make([]string, 0, len(m.results))
+	for t := range m.results {
+		targets = append(targets, t)
+	}
+	m.mu.RUnlock()
+
+	var wg sync.WaitGroup
+	for _, t := range targets {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			m.Refresh(ctx, t)
+		}()
+	}
+	wg.Wait()
+}
+
+// check 解析域名并建立连接，TLS 目标同时读取证书过期时间
+func (m *Monitor) check(ctx context.Context, s string) Result {
+	r := Result{Target: s, CheckedAt: time.Now()}
+	t, err := parseTarget(s)
+	if err != nil {
+		r.Err = err
+		return r
+	}
+	ctx, cancel := context.WithTimeout(ctx, m.cfg.Timeout)
+	defer cancel()
+
+	if r.Addrs, err = net.DefaultResolver.LookupHost(ctx, t.host); err != nil {
+		r.Err = err
+		return r
+	}
+
+	start := time.Now()
+	var conn net.Conn
+	if t.tls {
+		d := &tls.Dialer{Config: &tls.Config{
+			ServerName: t.host,
+			// 只读取证书过期时间，证书本身是否可信不影响检查结果
+			InsecureSkipVerify: true,
+		}}
+		conn, err = d.DialContext(ctx, "tcp", t.addr)
+	} else {
+		var d net.Dialer
+		conn, err = d.DialContext(ctx, "tcp", t.addr)
+	}
+	if err != nil {
+		r.Err = err
+		return r
+	}
+	defer conn.Close()
+	r.Reachable = true
+	r.Latency = time.Since(start)
+
+	if tc, ok := conn.(*tls.Conn); ok {
+		for _, cert := range tc.ConnectionState().PeerCertificates {
+			if r.NotAfter.IsZero() || cert.NotAfter.Before(r.NotAfter) {
+				r.NotAfter = cert.NotAfter
+			}
+		}
+	}
+	return r
+}
+
+// warnCert 证书剩余有效时间低于阈值时发出事件，同一张证书只提醒一次
+func (m *Monitor) warnCert(r Result) {
+	if m.cfg.OnCertExpiry == nil || r.NotAfter.IsZero() {
+		return
+	}
+	remaining := time.Until(r.NotAfter)
+	if remaining > m.cfg.CertWarning {
+		return
+	}
+	m.mu.Lock()
+	if m.warned[r.Target].Equal(r.NotAfter) {
+		m.mu.Unlock()
+		return
+	}
+	m.warned[r.Target] = r.NotAfter
+	m.mu.Unlock()
+	m.cfg.OnCertExpiry(CertExpiry{Target: r.Target, NotAfter: r.NotAfter, Remaining: remaining})
+}
@@ -0,0 +1,138 @@
+package check
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestParseTarget(t *testing.T) {
+	tests := []struct {
+		input string
+		addr  string
+		tls   bool
+		err   bool
+	}{
+		{"127.0.0.1:8080", "127.0.0.1:8080", false, false},
+		{"https://example.com", "example.com:443", true, false},
+		{"http://example.com", "example.com:80", false, false},
+		{"tls://example.com:8443", "example.com:8443", true, false},
+		{"tls://example.com", "", false, true},
+		{"ftp://example.com:21", "", false, true},
+		{"example.com", "", false, true},
+	}
+	for _, tt := range tests {
+		got, err := parseTarget(tt.input)
+		if (err != nil) != tt.err {
+			t.Errorf("parseTarget(%q) err = %v", tt.input, err)
+			continue
+		}
+		if err == nil && (got.addr != tt.addr || got.tls != tt.tls) {
+			t.Errorf("parseTarget(%q) = %+v", tt.input, got)
+		}
+	}
+}
+
+func TestMonitorReachable(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := ln.Addr().String()
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			conn.Close()
+		}
+	}()
+
+	m := NewMonitor(SetTimeout(time.Second))
+	r := m.Get(context.Background(), addr)
+	if !r.Reachable || r.Err != nil || len(r.Addrs) == 0 {
+		t.Fatalf("Unexpected result: %+v", r)
+	}
+
+	// 命中缓存时不重新检查
+	ln.Close()
+	if got := m.Get(context.Background(), addr); !got.CheckedAt.Equal(r.CheckedAt) {
+		t.Errorf("Expected cached result, got %+v", got)
+	}
+	if got := m.Refresh(context.Background(), addr); got.Reachable || got.Err == nil {
+		t.Errorf("Expected unreachable after close, got %+v", got)
+	}
+
+	if got := m.Get(context.Background(), "invalid"); !errors.Is(got.Err, ErrInvalidTarget) {
+		t.Errorf("Expected ErrInvalidTarget, got %v", got.Err)
+	}
+}
+
+func TestMonitorCertExpiry(t *testing.T) {
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {}))
+	defer srv.Close()
+
+	var (
+		mu     sync.Mutex
+		events []CertExpiry
+	)
+	// 测试证书有效期很长，使用足够大的阈值触发提醒
+	m := NewMonitor(
+		SetInterval(10*time.Millisecond),
+		SetCertExpiryWarning(200*365*24*time.Hour, func(e CertExpiry) {
+			mu.Lock()
+			events = append(events, e)
+			mu.Unlock()
+		}),
+	)
+	r := m.Get(context.Background(), srv.URL)
+	if !r.Reachable || r.NotAfter.IsZero() {
+		t.Fatalf("Unexpected result: %+v", r)
+	}
+	if !r.NotAfter.Equal(srv.Certificate().NotAfter) {
+		t.Errorf("Expected NotAfter %v, got %v", srv.Certificate().NotAfter, r.NotAfter)
+	}
+
+	m.Start()
+	deadline := time.Now().Add(time.Second)
+	for time.Now().Before(deadline) {
+		if got := m.Results()[srv.URL]; got.CheckedAt.After(r.CheckedAt) {
+			break
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	m.Stop()
+	m.Stop()
+
+	if got := m.Results()[srv.URL]; !got.CheckedAt.After(r.CheckedAt) {
+		t.Errorf("Expected background refresh, got %+v", got)
+	}
+	// 同一张证书只提醒一次
+	mu.Lock()
+	defer mu.Unlock()
+	if len(events) != 1 || events[0].Target != srv.URL || events[0].Remaining <= 0 {
+		t.Errorf("Unexpected events: %+v", events)
+	}
+}
+
+func TestMonitorCertNoWarning(t *testing.T) {
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {}))
+	defer srv.Close()
+
+	called := false
+	m := NewMonitor(SetCertExpiryWarning(time.Hour, func(CertExpiry) { called = true }))
+	m.Get(context.Background(), srv.URL)
+	if called {
+		t.Error("Unexpected cert expiry event")
+	}
+	m.Remove(srv.URL)
+	if len(m.Results()) != 0 {
+		t.Errorf("Expected no results after Remove, got %v", m.Results())
+	}
+}
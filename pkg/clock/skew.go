@@ -0,0 +1,343 @@
+package clock
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"net"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/andrewbytecoder/nmq/internal/prometheus"
+	"github.com/andrewbytecoder/nmq/pkg/options"
+	stdprometheus "github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	// ErrNoSource is returned by Check when no time source is configured.
+	ErrNoSource = errors.New("clock: no time source configured")
+	// ErrNTPResponse is returned when an NTP server sends a malformed or unsynchronized reply.
+	ErrNTPResponse = errors.New("clock: invalid ntp response")
+)
+
+// ntpEpochOffset is the number of seconds between the NTP epoch (1900) and the Unix epoch (1970).
+const ntpEpochOffset = 2208988800
+
+// Source reports the offset of a reference clock relative to the local clock.
+// A positive offset means the local clock is behind the reference.
+type Source func(ctx context.Context) (time.Duration, error)
+
+// NTPSource returns a Source that queries an NTP server with a single SNTP request.
+// The port defaults to 123 when server has none.
+func NTPSource(server string) Source {
+	if _, _, err := net.SplitHostPort(server); err != nil {
+		server = net.JoinHostPort(server, "123")
+	}
+	return func(ctx context.Context) (time.Duration, error) {
+		return QueryNTP(ctx, server)
+	}
+}
+
+// PeerSource returns a Source that compares the local clock against the time
+// reported by a cluster peer, correcting for half of the round trip.
+func PeerSource(peerTime func(ctx context.Context) (time.Time, error)) Source {
+	return func(ctx context.Context) (time.Duration, error) {
+		start := time.Now()
+		t, err := peerTime(ctx)
+		if err != nil {
+			return 0, err
+		}
+		rtt := time.Since(start)
+		return t.Sub(start.Add(rtt / 2)), nil
+	}
+}
+
+// QueryNTP sends one SNTP (RFC 4330) request to addr and returns the clock offset.
+func QueryNTP(ctx context.Context, addr string) (time.Duration, error) {
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "udp", addr)
+	if err != nil {
+		return 0, err
+	}
+	defer conn.Close()
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = conn.SetDeadline(deadline)
+	}
+
+	req := make([]byte, 48)
+	req[0] = 0x23 // LI = 0, VN = 4, Mode = 3 (client)
+	t1 := time.Now()
+	putNTPTime(req[40:], t1)
+	if _, err = conn.Write(req); err != nil {
+		return 0, err
+	}
+
+	resp := make([]byte, 48)
+	n, err := conn.Read(resp)
+	if err != nil {
+		return 0, err
+	}
+	t4 := time.Now()
+	// Mode 4 is a server reply, stratum 0 is a kiss-o'-death, LI 3 is an unsynchronized server.
+	if n < 48 || resp[0]&0x07 != 4 || resp[1] == 0 || resp[0]>>6 == 3 {
+		return 0, ErrNTPResponse
+	}
+	t2 := ntpTime(resp[32:])
+	t3 := ntpTime(resp[40:])
+	return (t2.Sub(t1) + t3.Sub(t4)) / 2, nil
+}
+
+// ntpTime decodes a 64-bit NTP timestamp.
+func ntpTime(b []byte) time.Time {
+	sec := int64(binary.BigEndian.Uint32(b[0:])) - ntpEpochOffset
+	frac := int64(binary.BigEndian.Uint32(b[4:]))
+	return time.Unix(sec, frac*1e9>>32)
+}
+
+// putNTPTime encodes t as a 64-bit NTP timestamp.
+func putNTPTime(b []byte, t time.Time) {
+	binary.BigEndian.PutUint32(b[0:], uint32(t.Unix()+ntpEpochOffset))
+	binary.BigEndian.PutUint32(b[4:], uint32(int64(t.Nanosecond())<<32/1e9))
+}
+
+// SkewConfig configures a SkewMonitor.
+type SkewConfig struct {
+	Sources   []Source                                // reference clocks, the median offset is used
+	Interval  time.Duration                           // how often to check in the background
+	Timeout   time.Duration                           // timeout of a single check across all sources
+	Threshold time.Duration                           // absolute offset above which the clock is considered skewed
+	OnSkew    func(offset time.Duration, skewed bool) // called when the skewed state changes
+}
+
+// SetSources appends reference clocks.
+func SetSources(sources ...Source) options.Option {
+	return func(c any) {
+		if c, ok := c.(*SkewConfig); ok {
+			c.Sources = append(c.Sources, sources...)
+		}
+	}
+}
+
+// SetSkewInterval sets the background check interval.
+func SetSkewInterval(interval time.Duration) options.Option {
+	return func(c any) {
+		if c, ok := c.(*SkewConfig); ok {
+			c.Interval = interval
+		}
+	}
+}
+
+// SetSkewTimeout sets the timeout of a single check.
+func SetSkewTimeout(timeout time.Duration) options.Option {
+	return func(c any) {
+		if c, ok := c.(*SkewConfig); ok {
+			c.Timeout = timeout
+		}
+	}
+}
+
+// SetSkewThreshold sets the offset above which the clock is considered skewed.
+func SetSkewThreshold(threshold time.Duration) options.Option {
+	return func(c any) {
+		if c, ok := c.(*SkewConfig); ok {
+			c.Threshold = threshold
+		}
+	}
+}
+
+// SetOnSkew sets the callback invoked when the skewed state changes.
+func SetOnSkew(fn func(offset time.Duration, skewed bool)) options.Option {
+	return func(c any) {
+		if c, ok := c.(*SkewConfig); ok {
+			c.OnSkew = fn
+		}
+	}
+}
+
+// NewSkewConfig returns a SkewConfig checking every minute with a 5s timeout
+// and a 1s threshold.
+func NewSkewConfig(opts ...options.Option) *SkewConfig {
+	c := &SkewConfig{
+		Interval:  time.Minute,
+		Timeout:   5 * time.Second,
+		Threshold: time.Second,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+var (
+	skewMetricsOnce sync.Once
+	offsetGauge     *prometheus.Gauge
+	skewedGauge     *prometheus.Gauge
+)
+
+// registerSkewMetrics registers the drift gauges on first use.
+func registerSkewMetrics() {
+	skewMetricsOnce.Do(func() {
+		offsetGauge = prometheus.NewGaugeFrom(stdprometheus.GaugeOpts{
+			Namespace: "nmq",
+			Subsystem: "clock",
+			Name:      "offset_seconds",
+			Help:      "Observed offset of the reference clock relative to the local clock.",
+		}, nil)
+		skewedGauge = prometheus.NewGaugeFrom(stdprometheus.GaugeOpts{
+			Namespace: "nmq",
+			Subsystem: "clock",
+			Name:      "skewed",
+			Help:      "Whether the local clock offset exceeds the configured threshold (1) or not (0).",
+		}, nil)
+	})
+}
+
+// SkewMonitor periodically compares the local clock against reference sources.
+// It implements the Skewed method used by skew-sensitive consumers such as
+// snowflake ID generation.
+type SkewMonitor struct {
+	cfg *SkewConfig
+
+	mu      sync.RWMutex
+	offset  time.Duration
+	skewed  bool
+	checked time.Time
+	err     error
+
+	stop chan struct{}
+	done chan struct{}
+	once sync.Once
+}
+
+// NewSkewMonitor creates a SkewMonitor. Call Start to check in the background.
+func NewSkewMonitor(opts ...options.Option) *SkewMonitor {
+	registerSkewMetrics()
+	return &SkewMonitor{
+		cfg:  NewSkewConfig(opts...),
+		stop: make(chan struct{}),
+		done: make(chan struct{}),
+	}
+}
+
+// Check queries all sources concurrently and updates the observed offset
+// with the median of the successful results. The previous state is kept
+// when every source fails.
+func (m *SkewMonitor) Check(ctx context.Context) (time.Duration, error) {
+	if len(m.cfg.Sources) == 0 {
+		return 0, ErrNoSource
+	}
+	ctx, cancel := context.WithTimeout(ctx, m.cfg.Timeout)
+	defer cancel()
+
+	offsets := make([]time.Duration, 0, len(m.cfg.Sources))
+	errs := make([]error, 0, len(m.cfg.Sources))
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for _, src := range m.cfg.Sources {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			off, err := src(ctx)
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				errs = append(errs, err)
+				return
+			}
+			offsets = append(offsets, off)
+		}()
+	}
+	wg.Wait()
+
+	if len(offsets) == 0 {
+		err := errors.Join(errs...)
+		m.mu.Lock()
+		m.err = err
+		m.mu.Unlock()
+		return 0, err
+	}
+	sort.Slice(offsets, func(i, j int) bool { return offsets[i] < offsets[j] })
+	offset := offsets[len(offsets)/2]
+	m.update(offset)
+	return offset, nil
+}
+
+// update records a new offset and notifies OnSkew when the state changes.
+func (m *SkewMonitor) update(offset time.Duration) {
+	abs := offset
+	if abs < 0 {
+		abs = -abs
+	}
+	skewed := abs > m.cfg.Threshold
+
+	m.mu.Lock()
+	changed := skewed != m.skewed
+	m.offset, m.skewed, m.checked, m.err = offset, skewed, time.Now(), nil
+	m.mu.Unlock()
+
+	offsetGauge.Set(offset.Seconds())
+	if skewed {
+		skewedGauge.Set(1)
+	} else {
+		skewedGauge.Set(0)
+	}
+	if changed && m.cfg.OnSkew != nil {
+		m.cfg.OnSkew(offset, skewed)
+	}
+}
+
+// Offset returns the last observed offset and when it was measured.
+func (m *SkewMonitor) Offset() (time.Duration, time.Time) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.offset, m.checked
+}
+
+// Skewed reports whether the last observed offset exceeds the threshold.
+func (m *SkewMonitor) Skewed() bool {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.skewed
+}
+
+// Err returns the error of the last check if every source failed.
+func (m *SkewMonitor) Err() error {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.err
+}
+
+// Start checks immediately and then every Interval until Stop is called.
+func (m *SkewMonitor) Start() {
+	go func() {
+		defer close(m.done)
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		go func() {
+			<-m.stop
+			cancel()
+		}()
+
+		_, _ = m.Check(ctx)
+		ticker := time.NewTicker(m.cfg.Interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				_, _ = m.Check(ctx)
+			case <-m.stop:
+				return
+			}
+		}
+	}()
+}
+
+// Stop stops the background checks and waits for the running check to return.
+// It is safe to call Stop more than once, but only after Start.
+func (m *SkewMonitor) Stop() {
+	m.once.Do(func() {
+		close(m.stop)
+	})
+	<-m.done
+}
@@ -0,0 +1,126 @@
+package clock
+
+import (
+	"context"
+	"errors"
+	"net"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeNTPServer answers SNTP requests with the local time shifted by offset.
+func fakeNTPServer(t *testing.T, offset time.Duration) string {
+	t.Helper()
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	go func() {
+		buf := make([]byte, 48)
+		for {
+			_, addr, err := conn.ReadFrom(buf)
+			if err != nil {
+				return
+			}
+			resp := make([]byte, 48)
+			resp[0] = 0x24 // LI = 0, VN = 4, Mode = 4 (server)
+			resp[1] = 1    // stratum
+			now := time.Now().Add(offset)
+			putNTPTime(resp[32:], now)
+			putNTPTime(resp[40:], now)
+			_, _ = conn.WriteTo(resp, addr)
+		}
+	}()
+	return conn.LocalAddr().String()
+}
+
+func TestNTPTime(t *testing.T) {
+	now := time.Unix(1700000000, 123456789)
+	b := make([]byte, 8)
+	putNTPTime(b, now)
+	if d := ntpTime(b).Sub(now); d < -time.Microsecond || d > time.Microsecond {
+		t.Errorf("Expected round trip within 1us, got %v", d)
+	}
+}
+
+func TestQueryNTP(t *testing.T) {
+	addr := fakeNTPServer(t, 3*time.Second)
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	off, err := QueryNTP(ctx, addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if off < 2900*time.Millisecond || off > 3100*time.Millisecond {
+		t.Errorf("Expected offset around 3s, got %v", off)
+	}
+}
+
+func TestSkewMonitor(t *testing.T) {
+	var mu sync.Mutex
+	var events []bool
+	peerOffset := 0 * time.Second
+	peer := PeerSource(func(ctx context.Context) (time.Time, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		return time.Now().Add(peerOffset), nil
+	})
+	m := NewSkewMonitor(
+		SetSources(NTPSource(fakeNTPServer(t, 0)), peer, peer),
+		SetSkewThreshold(time.Second),
+		SetOnSkew(func(offset time.Duration, skewed bool) {
+			mu.Lock()
+			events = append(events, skewed)
+			mu.Unlock()
+		}),
+	)
+
+	if _, err := m.Check(context.Background()); err != nil || m.Skewed() {
+		t.Fatalf("Expected no skew, got %v %v", m.Skewed(), err)
+	}
+
+	// Two of three sources drift by 5s, so the median exceeds the threshold.
+	mu.Lock()
+	peerOffset = 5 * time.Second
+	mu.Unlock()
+	off, err := m.Check(context.Background())
+	if err != nil || !m.Skewed() || off < 4*time.Second {
+		t.Errorf("Expected skew, got %v %v", off, err)
+	}
+	if got, _ := m.Offset(); got != off {
+		t.Errorf("Expected Offset %v, got %v", off, got)
+	}
+
+	mu.Lock()
+	peerOffset = 0
+	mu.Unlock()
+	_, _ = m.Check(context.Background())
+	mu.Lock()
+	if len(events) != 2 || !events[0] || events[1] {
+		t.Errorf("Expected skew then recovery events, got %v", events)
+	}
+	mu.Unlock()
+}
+
+func TestSkewMonitorErrors(t *testing.T) {
+	if _, err := NewSkewMonitor().Check(context.Background()); !errors.Is(err, ErrNoSource) {
+		t.Errorf("Expected ErrNoSource, got %v", err)
+	}
+
+	broken := errors.New("peer down")
+	m := NewSkewMonitor(SetSources(func(context.Context) (time.Duration, error) { return 0, broken }))
+	if _, err := m.Check(context.Background()); !errors.Is(err, broken) || !errors.Is(m.Err(), broken) {
+		t.Errorf("Expected source error, got %v", err)
+	}
+
+	m = NewSkewMonitor(SetSources(func(context.Context) (time.Duration, error) { return 2 * time.Second, nil }),
+		SetSkewInterval(time.Hour))
+	m.Start()
+	m.Stop()
+	m.Stop()
+	if !m.Skewed() {
+		t.Error("Expected Start to check immediately")
+	}
+}
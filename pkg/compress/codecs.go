@@ -0,0 +1,371 @@
+package compress
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"slices"
+	"sync"
+
+	"github.com/klauspost/compress/flate"
+	"github.com/klauspost/compress/gzip"
+	"github.com/klauspost/compress/snappy"
+	"github.com/klauspost/compress/zstd"
+)
+
+func init() {
+	Register("none", func(int) (Codec, error) { return noneCodec{}, nil })
+	Register("gzip", newGzip)
+	Register("deflate", newDeflate)
+	Register("snappy", newSnappy)
+	Register("zstd", newZstd)
+}
+
+// noneCodec 不压缩，用于关闭压缩时保持统一的调用方式
+type noneCodec struct{}
+
+func (noneCodec) Name() string { return "none" }
+
+func (noneCodec) Compress(dst, src []byte) ([]byte, error) { return append(dst, src...), nil }
+
+func (noneCodec) Decompress(dst, src []byte) ([]byte, error) { return append(dst, src...), nil }
+
+func (noneCodec) NewWriter(w io.Writer) (io.WriteCloser, error) { return nopWriteCloser{w}, nil }
+
+func (noneCodec) NewReader(r io.Reader) (io.ReadCloser, error) { return io.NopCloser(r), nil }
+
+type nopWriteCloser struct {
+	io.Writer
+}
+
+func (nopWriteCloser) Close() error { return nil }
+
+// checkLevel 校验 flate 系列的压缩级别
+func checkLevel(level int) error {
+	if level == DefaultLevel {
+		return nil
+	}
+	if level < flate.HuffmanOnly || level > flate.BestCompression {
+		return fmt.Errorf("%w: %d", ErrInvalidLevel, level)
+	}
+	return nil
+}
+
+// gzipCodec gzip 编解码器
+type gzipCodec struct {
+	level   int
+	writers sync.Pool // *gzip.Writer
+	readers sync.Pool // *gzip.Reader
+}
+
+func newGzip(level int) (Codec, error) {
+	if err := checkLevel(level); err != nil {
+		return nil, err
+	}
+	return &gzipCodec{level: level}, nil
+}
+
+func (g *gzipCodec) Name() string { return "gzip" }
+
+func (g *gzipCodec) getWriter(w io.Writer) (*gzip.Writer, error) {
+	if zw, ok := g.writers.Get().(*gzip.Writer); ok {
+		zw.Reset(w)
+		return zw, nil
+	}
+	return gzip.NewWriterLevel(w, g.level)
+}
+
+func (g *gzipCodec) getReader(r io.Reader) (*gzip.Reader, error) {
+	if zr, ok := g.readers.Get().(*gzip.Reader); ok {
+		return zr, zr.Reset(r)
+	}
+	return gzip.NewReader(r)
+}
+
+func (g *gzipCodec) Compress(dst, src []byte) ([]byte, error) {
+	buf := bytes.NewBuffer(dst)
+	zw, err := g.getWriter(buf)
+	if err != nil {
+		return dst, err
+	}
+	defer g.writers.Put(zw)
+	if _, err = zw.Write(src); err != nil {
+		return dst, err
+	}
+	if err = zw.Close(); err != nil {
+		return dst, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (g *gzipCodec) Decompress(dst, src []byte) ([]byte, error) {
+	zr, err := g.getReader(bytes.NewReader(src))
+	if err != nil {
+		return dst, err
+	}
+	defer g.readers.Put(zr)
+	buf := bytes.NewBuffer(dst)
+	if _, err = buf.ReadFrom(zr); err != nil {
+		return dst, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (g *gzipCodec) NewWriter(w io.Writer) (io.WriteCloser, error) {
+	zw, err := g.getWriter(w)
+	if err != nil {
+		return nil, err
+	}
+	return &pooledWriter{WriteCloser: zw, release: func() { g.writers.Put(zw) }}, nil
+}
+
+func (g *gzipCodec) NewReader(r io.Reader) (io.ReadCloser, error) {
+	zr, err := g.getReader(r)
+	if err != nil {
+		return nil, err
+	}
+	return &pooledReader{ReadCloser: zr, release: func() { g.readers.Put(zr) }}, nil
+}
+
+// deflateCodec 原始 deflate 编解码器，没有 gzip 头部，适合帧压缩
+type deflateCodec struct {
+	level   int
+	writers sync.Pool // *flate.Writer
+	readers sync.Pool // io.ReadCloser 同时实现 flate.Resetter
+}
+
+func newDeflate(level int) (Codec, error) {
+	if err := checkLevel(level); err != nil {
+		return nil, err
+	}
+	return &deflateCodec{level: level}, nil
+}
+
+func (d *deflateCodec) Name() string { return "deflate" }
+
+func (d *deflateCodec) getWriter(w io.Writer) (*flate.Writer, error) {
+	if fw, ok := d.writers.Get().(*flate.Writer); ok {
+		fw.Reset(w)
+		return fw, nil
+	}
+	return flate.NewWriter(w, d.level)
+}
+
+func (d *deflateCodec) getReader(r io.Reader) (io.ReadCloser, error) {
+	if fr, ok := d.readers.Get().(io.ReadCloser); ok {
+		return fr, fr.(flate.Resetter).Reset(r, nil)
+	}
+	return flate.NewReader(r), nil
+}
+
+func (d *deflateCodec) Compress(dst, src []byte) ([]byte, error) {
+	buf := bytes.NewBuffer(dst)
+	fw, err := d.getWriter(buf)
+	if err != nil {
+		return dst, err
+	}
+	defer d.writers.Put(fw)
+	if _, err = fw.Write(src); err != nil {
+		return dst, err
+	}
+	if err = fw.Close(); err != nil {
+		return dst, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (d *deflateCodec) Decompress(dst, src []byte) ([]byte, error) {
+	fr, err := d.getReader(bytes.NewReader(src))
+	if err != nil {
+		return dst, err
+	}
+	defer d.readers.Put(fr)
+	buf := bytes.NewBuffer(dst)
+	if _, err = buf.ReadFrom(fr); err != nil {
+		return dst, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (d *deflateCodec) NewWriter(w io.Writer) (io.WriteCloser, error) {
+	fw, err := d.getWriter(w)
+	if err != nil {
+		return nil, err
+	}
+	return &pooledWriter{WriteCloser: fw, release: func() { d.writers.Put(fw) }}, nil
+}
+
+func (d *deflateCodec) NewReader(r io.Reader) (io.ReadCloser, error) {
+	fr, err := d.getReader(r)
+	if err != nil {
+		return nil, err
+	}
+	return &pooledReader{ReadCloser: fr, release: func() { d.readers.Put(fr) }}, nil
+}
+
+// snappyCodec snappy 编解码器，没有压缩级别
+//
+// Compress 和 Decompress 使用 snappy 块格式，适合单条消息；NewWriter 和 NewReader 使用 snappy 流格式，
+// 两种格式互不兼容
+type snappyCodec struct {
+	writers sync.Pool // *snappy.Writer
+	readers sync.Pool // *snappy.Reader
+}
+
+func newSnappy(level int) (Codec, error) {
+	if level != DefaultLevel {
+		return nil, fmt.Errorf("%w: %d", ErrInvalidLevel, level)
+	}
+	return &snappyCodec{}, nil
+}
+
+func (s *snappyCodec) Name() string { return "snappy" }
+
+func (s *snappyCodec) Compress(dst, src []byte) ([]byte, error) {
+	n := snappy.MaxEncodedLen(len(src))
+	if n < 0 {
+		return dst, snappy.ErrTooLarge
+	}
+	dst = slices.Grow(dst, n)
+	out := snappy.Encode(dst[len(dst):len(dst)+n], src)
+	return dst[:len(dst)+len(out)], nil
+}
+
+func (s *snappyCodec) Decompress(dst, src []byte) ([]byte, error) {
+	n, err := snappy.DecodedLen(src)
+	if err != nil {
+		return dst, err
+	}
+	dst = slices.Grow(dst, n)
+	out, err := snappy.Decode(dst[len(dst):len(dst)+n], src)
+	if err != nil {
+		return dst, err
+	}
+	return dst[:len(dst)+len(out)], nil
+}
+
+func (s *snappyCodec) NewWriter(w io.Writer) (io.WriteCloser, error) {
+	sw, ok := s.writers.Get().(*snappy.Writer)
+	if ok {
+		sw.Reset(w)
+	} else {
+		sw = snappy.NewBufferedWriter(w)
+	}
+	return &pooledWriter{WriteCloser: sw, release: func() { s.writers.Put(sw) }}, nil
+}
+
+func (s *snappyCodec) NewReader(r io.Reader) (io.ReadCloser, error) {
+	sr, ok := s.readers.Get().(*snappy.Reader)
+	if ok {
+		sr.Reset(r)
+	} else {
+		sr = snappy.NewReader(r)
+	}
+	return &pooledReader{ReadCloser: io.NopCloser(sr), release: func() { s.readers.Put(sr) }}, nil
+}
+
+// zstdCodec zstd 编解码器，压缩级别为 zstd 标准的 1-22，映射到最接近的编码速度档位
+//
+// Compress 和 Decompress 使用共享的编码器和解码器，它们支持并发调用；
+// 流式读写使用单协程的编码器和解码器，通过 sync.Pool 复用
+type zstdCodec struct {
+	level   zstd.EncoderLevel
+	enc     *zstd.Encoder
+	dec     *zstd.Decoder
+	writers sync.Pool // *zstd.Encoder
+	readers sync.Pool // *zstd.Decoder
+}
+
+func newZstd(level int) (Codec, error) {
+	l := zstd.SpeedDefault
+	if level != DefaultLevel {
+		if level < 1 || level > 22 {
+			return nil, fmt.Errorf("%w: %d", ErrInvalidLevel, level)
+		}
+		l = zstd.EncoderLevelFromZstd(level)
+	}
+	enc, err := zstd.NewWriter(nil, zstd.WithEncoderLevel(l))
+	if err != nil {
+		return nil, err
+	}
+	dec, err := zstd.NewReader(nil)
+	if err != nil {
+		return nil, err
+	}
+	return &zstdCodec{level: l, enc: enc, dec: dec}, nil
+}
+
+func (z *zstdCodec) Name() string { return "zstd" }
+
+func (z *zstdCodec) Compress(dst, src []byte) ([]byte, error) {
+	return z.enc.EncodeAll(src, dst), nil
+}
+
+func (z *zstdCodec) Decompress(dst, src []byte) ([]byte, error) {
+	out, err := z.dec.DecodeAll(src, dst)
+	if err != nil {
+		return dst, err
+	}
+	return out, nil
+}
+
+func (z *zstdCodec) NewWriter(w io.Writer) (io.WriteCloser, error) {
+	zw, ok := z.writers.Get().(*zstd.Encoder)
+	if ok {
+		zw.Reset(w)
+	} else {
+		var err error
+		if zw, err = zstd.NewWriter(w, zstd.WithEncoderLevel(z.level), zstd.WithEncoderConcurrency(1)); err != nil {
+			return nil, err
+		}
+	}
+	return &pooledWriter{WriteCloser: zw, release: func() { z.writers.Put(zw) }}, nil
+}
+
+func (z *zstdCodec) NewReader(r io.Reader) (io.ReadCloser, error) {
+	zr, ok := z.readers.Get().(*zstd.Decoder)
+	if ok {
+		if err := zr.Reset(r); err != nil {
+			return nil, err
+		}
+	} else {
+		var err error
+		if zr, err = zstd.NewReader(r, zstd.WithDecoderConcurrency(1)); err != nil {
+			return nil, err
+		}
+	}
+	// 解码器 Close 后不能复用，归还前只解除对 r 的引用
+	return &pooledReader{ReadCloser: io.NopCloser(zr), release: func() { _ = zr.Reset(nil); z.readers.Put(zr) }}, nil
+}
+
+// pooledWriter Close 后将编码器归还到池中，重复 Close 只生效一次
+type pooledWriter struct {
+	io.WriteCloser
+	release func()
+}
+
+func (p *pooledWriter) Close() error {
+	if p.release == nil {
+		return nil
+	}
+	err := p.WriteCloser.Close()
+	p.release()
+	p.release = nil
+	return err
+}
+
+// pooledReader Close 后将解码器归还到池中，重复 Close 只生效一次
+type pooledReader struct {
+	io.ReadCloser
+	release func()
+}
+
+func (p *pooledReader) Close() error {
+	if p.release == nil {
+		return nil
+	}
+	err := p.ReadCloser.Close()
+	p.release()
+	p.release = nil
+	return err
+}
@@ -0,0 +1,119 @@
+// Package compress 提供可插拔的压缩编解码器注册表
+//
+// 帧压缩、WAL 分段、备份和 webhook 请求体等场景通过名称查找编解码器，
+// 压缩级别由配置指定，编码器和解码器通过 sync.Pool 复用以避免每条消息都分配
+package compress
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"sync"
+
+	"github.com/andrewbytecoder/nmq/pkg/options"
+)
+
+// DefaultLevel 使用编解码器自身的默认压缩级别
+const DefaultLevel = -1
+
+var (
+	ErrUnknownCodec = errors.New("compress: unknown codec")
+	ErrInvalidLevel = errors.New("compress: invalid level")
+)
+
+// Codec 压缩编解码器，实现需要支持并发调用
+type Codec interface {
+	// Name 编解码器名称，用于配置和注册表查找
+	Name() string
+	// Compress 压缩 src 并追加到 dst 后返回
+	Compress(dst, src []byte) ([]byte, error)
+	// Decompress 解压 src 并追加到 dst 后返回
+	Decompress(dst, src []byte) ([]byte, error)
+	// NewWriter 返回流式压缩写入器，Close 时写入尾部数据并归还编码器，不会关闭 w
+	NewWriter(w io.Writer) (io.WriteCloser, error)
+	// NewReader 返回流式解压读取器，Close 时归还解码器，不会关闭 r
+	NewReader(r io.Reader) (io.ReadCloser, error)
+}
+
+// Factory 根据压缩级别创建编解码器，level 为 DefaultLevel 时使用默认级别
+type Factory func(level int) (Codec, error)
+
+var (
+	registryMu sync.RWMutex
+	registry   = make(map[string]Factory)
+)
+
+// Register 注册编解码器工厂，同名的工厂会被覆盖
+//
+// 内置 gzip、deflate、snappy、zstd 和 none，lz4 等可以由调用方注册
+func Register(name string, f Factory) {
+	registryMu.Lock()
+	registry[name] = f
+	registryMu.Unlock()
+}
+
+// Names 返回所有已注册的编解码器名称
+func Names() []string {
+	registryMu.RLock()
+	names := make([]string, 0, len(registry))
+	for name := range registry {
+		names = append(names, name)
+	}
+	registryMu.RUnlock()
+	sort.Strings(names)
+	return names
+}
+
+// Config 编解码器配置
+type Config struct {
+	levels map[string]int // 各编解码器的压缩级别
+}
+
+// SetLevel 设置指定编解码器的压缩级别
+func SetLevel(name string, level int) options.Option {
+	return func(c any) {
+		if c, ok := c.(*Config); ok {
+			c.levels[name] = level
+		}
+	}
+}
+
+// SetLevels 批量设置压缩级别，通常来自配置文件中的 name -> level 映射
+func SetLevels(levels map[string]int) options.Option {
+	return func(c any) {
+		if c, ok := c.(*Config); ok {
+			for name, level := range levels {
+				c.levels[name] = level
+			}
+		}
+	}
+}
+
+// NewConfig 创建编解码器配置
+func NewConfig(opts ...options.Option) *Config {
+	c := &Config{levels: make(map[string]int)}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// Level 返回指定编解码器的压缩级别，未配置时返回 DefaultLevel
+func (c *Config) Level(name string) int {
+	if level, ok := c.levels[name]; ok {
+		return level
+	}
+	return DefaultLevel
+}
+
+// New 按名称创建编解码器，压缩级别从 opts 中读取
+func New(name string, opts ...options.Option) (Codec, error) {
+	registryMu.RLock()
+	f, ok := registry[name]
+	registryMu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("%w: %q", ErrUnknownCodec, name)
+	}
+	return f(NewConfig(opts...).Level(name))
+}
@@ -0,0 +1,119 @@
+// compress_test.go
+package compress
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestCodecRoundTrip(t *testing.T) {
+	src := []byte(strings.Repeat("nmq compress round trip ", 100))
+	for _, name := range []string{"none", "gzip", "deflate", "snappy", "zstd"} {
+		c, err := New(name)
+		if err != nil {
+			t.Fatalf("New(%s) failed: %v", name, err)
+		}
+		if c.Name() != name {
+			t.Errorf("Expected name %s, got %s", name, c.Name())
+		}
+
+		// 多次调用以覆盖池中复用的编码器和解码器
+		for i := 0; i < 3; i++ {
+			packed, err := c.Compress([]byte("hdr"), src)
+			if err != nil {
+				t.Fatalf("%s Compress failed: %v", name, err)
+			}
+			if !bytes.HasPrefix(packed, []byte("hdr")) {
+				t.Errorf("%s: expected dst prefix to be kept", name)
+			}
+			if name != "none" && len(packed) >= len(src) {
+				t.Errorf("%s: expected compressed size < %d, got %d", name, len(src), len(packed))
+			}
+			out, err := c.Decompress(nil, packed[3:])
+			if err != nil {
+				t.Fatalf("%s Decompress failed: %v", name, err)
+			}
+			if !bytes.Equal(out, src) {
+				t.Errorf("%s: round trip mismatch", name)
+			}
+		}
+	}
+}
+
+func TestCodecStream(t *testing.T) {
+	for _, name := range []string{"gzip", "snappy", "zstd"} {
+		t.Run(name, func(t *testing.T) {
+			c, err := New(name, SetLevel("gzip", 9), SetLevel("zstd", 19))
+			if err != nil {
+				t.Fatalf("New failed: %v", err)
+			}
+			// 第二轮覆盖池中复用的编码器和解码器
+			testStream(t, c)
+			testStream(t, c)
+		})
+	}
+}
+
+func testStream(t *testing.T, c Codec) {
+	t.Helper()
+	var buf bytes.Buffer
+	w, err := c.NewWriter(&buf)
+	if err != nil {
+		t.Fatalf("NewWriter failed: %v", err)
+	}
+	for i := 0; i < 10; i++ {
+		io.WriteString(w, "segment ")
+	}
+	if err = w.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+	if err = w.Close(); err != nil {
+		t.Errorf("Expected second Close to be a no-op, got %v", err)
+	}
+
+	r, err := c.NewReader(&buf)
+	if err != nil {
+		t.Fatalf("NewReader failed: %v", err)
+	}
+	data, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatalf("ReadAll failed: %v", err)
+	}
+	r.Close()
+	if string(data) != strings.Repeat("segment ", 10) {
+		t.Errorf("Unexpected stream content %q", data)
+	}
+}
+
+func TestRegistry(t *testing.T) {
+	if _, err := New("lz4"); !errors.Is(err, ErrUnknownCodec) {
+		t.Errorf("Expected ErrUnknownCodec, got %v", err)
+	}
+	for _, name := range []string{"deflate", "snappy", "zstd"} {
+		if _, err := New(name, SetLevels(map[string]int{name: 42})); !errors.Is(err, ErrInvalidLevel) {
+			t.Errorf("%s: expected ErrInvalidLevel, got %v", name, err)
+		}
+	}
+
+	var level int
+	Register("test", func(l int) (Codec, error) {
+		level = l
+		return noneCodec{}, nil
+	})
+	if _, err := New("test", SetLevel("test", 3), SetLevel("gzip", 1)); err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	if level != 3 {
+		t.Errorf("Expected level 3, got %d", level)
+	}
+	if _, err := New("test"); err != nil || level != DefaultLevel {
+		t.Errorf("Expected default level, got %d, %v", level, err)
+	}
+	names := Names()
+	if strings.Join(names, ",") != "deflate,gzip,none,snappy,test,zstd" {
+		t.Errorf("Unexpected names %v", names)
+	}
+}
@@ -0,0 +1,232 @@
+package convert
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-viper/mapstructure/v2"
+)
+
+// FieldError 单个配置项的校验错误
+type FieldError struct {
+	Field   string // 配置项路径，例如 server.port
+	Rule    string // 未通过的规则，例如 required、min=1
+	Message string
+}
+
+func (e FieldError) Error() string {
+	return e.Field + ": " + e.Message
+}
+
+// ValidationErrors 所有未通过校验的配置项
+type ValidationErrors []FieldError
+
+func (e ValidationErrors) Error() string {
+	msgs := make([]string, len(e))
+	for i, fe := range e {
+		msgs[i] = fe.Error()
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// DecodeSection 将配置段解码到 out 指向的结构体并校验
+//
+// 配置项按 mapstructure 标签匹配，时间字段支持 HumanDuration 的格式（例如 1d2h），
+// 配置段中没有的字段保留 out 中原有的默认值；校验失败时返回 ValidationErrors
+func DecodeSection(section map[string]any, out any) error {
+	dec, err := mapstructure.NewDecoder(&mapstructure.DecoderConfig{
+		DecodeHook: mapstructure.ComposeDecodeHookFunc(
+			humanDurationHook,
+			mapstructure.StringToSliceHookFunc(","),
+			mapstructure.TextUnmarshallerHookFunc(),
+		),
+		WeaklyTypedInput: true,
+		Result:           out,
+	})
+	if err != nil {
+		return err
+	}
+	if err = dec.Decode(section); err != nil {
+		return err
+	}
+	return Validate(out)
+}
+
+// humanDurationHook 将字符串解码为 time.Duration
+func humanDurationHook(from, to reflect.Type, data any) (any, error) {
+	if from.Kind() != reflect.String || to != reflect.TypeOf(time.Duration(0)) {
+		return data, nil
+	}
+	return HumanDuration(data.(string))
+}
+
+// Validate 按 validate 标签校验结构体，返回所有未通过的字段
+//
+// 标签以 "," 分隔多个规则：
+//
+//	required   不能为零值
+//	min=N      数值不小于 N，字符串、切片、map 的长度不小于 N，时间字段的 N 可以写为 1s
+//	max=N      数值不大于 N，字符串、切片、map 的长度不大于 N
+//	oneof=a b  取值为空格分隔的候选值之一
+//
+// 嵌套结构体、结构体指针和结构体切片会递归校验，零值字段只校验 required
+func Validate(v any) error {
+	rv := reflect.ValueOf(v)
+	for rv.Kind() == reflect.Pointer {
+		if rv.IsNil() {
+			return nil
+		}
+		rv = rv.Elem()
+	}
+	if rv.Kind() != reflect.Struct {
+		return nil
+	}
+	var errs ValidationErrors
+	validateStruct(rv, "", &errs)
+	if len(errs) == 0 {
+		return nil
+	}
+	return errs
+}
+
+// validateStruct 递归校验结构体的导出字段
+func validateStruct(rv reflect.Value, prefix string, errs *ValidationErrors) {
+	rt := rv.Type()
+	for i := 0; i < rt.NumField(); i++ {
+		sf := rt.Field(i)
+		if !sf.IsExported() {
+			continue
+		}
+		name, squash := fieldName(sf)
+		path := name
+		if squash {
+			path = strings.TrimSuffix(prefix, ".")
+		} else if prefix != "" {
+			path = prefix + name
+		}
+		fv := rv.Field(i)
+		if tag := sf.Tag.Get("validate"); tag != "" && tag != "-" {
+			validateField(fv, path, tag, errs)
+		}
+		validateNested(fv, path, errs)
+	}
+}
+
+// validateNested 递归校验嵌套的结构体
+func validateNested(fv reflect.Value, path string, errs *ValidationErrors) {
+	for fv.Kind() == reflect.Pointer {
+		if fv.IsNil() {
+			return
+		}
+		fv = fv.Elem()
+	}
+	switch fv.Kind() {
+	case reflect.Struct:
+		if fv.Type() == reflect.TypeOf(time.Time{}) {
+			return
+		}
+		validateStruct(fv, joinPath(path), errs)
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < fv.Len(); i++ {
+			validateNested(fv.Index(i), fmt.Sprintf("%s[%d]", path, i), errs)
+		}
+	}
+}
+
+func joinPath(path string) string {
+	if path == "" {
+		return ""
+	}
+	return path + "."
+}
+
+// fieldName 返回字段的配置项名称，优先使用 mapstructure 标签
+func fieldName(sf reflect.StructField) (string, bool) {
+	tag := sf.Tag.Get("mapstructure")
+	name, opts, _ := strings.Cut(tag, ",")
+	squash := sf.Anonymous && (strings.Contains(opts, "squash") || name == "")
+	if name == "" {
+		name = strings.ToLower(sf.Name)
+	}
+	return name, squash
+}
+
+// validateField 按规则校验单个字段
+func validateField(fv reflect.Value, path, tag string, errs *ValidationErrors) {
+	for _, rule := range strings.Split(tag, ",") {
+		rule = strings.TrimSpace(rule)
+		key, arg, _ := strings.Cut(rule, "=")
+		if key == "required" {
+			if fv.IsZero() {
+				*errs = append(*errs, FieldError{Field: path, Rule: rule, Message: "is required"})
+				return
+			}
+			continue
+		}
+		if fv.IsZero() {
+			continue
+		}
+		if msg := checkRule(fv, key, arg); msg != "" {
+			*errs = append(*errs, FieldError{Field: path, Rule: rule, Message: msg})
+		}
+	}
+}
+
+// checkRule 检查 min、max、oneof 规则，通过时返回空字符串
+func checkRule(fv reflect.Value, key, arg string) string {
+	for fv.Kind() == reflect.Pointer {
+		fv = fv.Elem()
+	}
+	switch key {
+	case "min", "max":
+		got, limit, err := measure(fv, arg)
+		if err != nil {
+			return fmt.Sprintf("invalid rule %s=%s: %v", key, arg, err)
+		}
+		if key == "min" && got < limit {
+			return fmt.Sprintf("must be at least %s", arg)
+		}
+		if key == "max" && got > limit {
+			return fmt.Sprintf("must be at most %s", arg)
+		}
+	case "oneof":
+		got := fmt.Sprint(fv.Interface())
+		for _, opt := range strings.Fields(arg) {
+			if got == opt {
+				return ""
+			}
+		}
+		return fmt.Sprintf("must be one of [%s]", arg)
+	default:
+		return fmt.Sprintf("unknown rule %q", key)
+	}
+	return ""
+}
+
+// measure 返回字段用于比较的值和规则中的界限，字符串、切片、map 比较长度
+func measure(fv reflect.Value, arg string) (float64, float64, error) {
+	if fv.Type() == reflect.TypeOf(time.Duration(0)) {
+		limit, err := HumanDuration(arg)
+		return float64(fv.Int()), float64(limit), err
+	}
+	limit, err := strconv.ParseFloat(arg, 64)
+	if err != nil {
+		return 0, 0, err
+	}
+	switch fv.Kind() {
+	case reflect.String, reflect.Slice, reflect.Map, reflect.Array:
+		return float64(fv.Len()), limit, nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(fv.Int()), limit, nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return float64(fv.Uint()), limit, nil
+	case reflect.Float32, reflect.Float64:
+		return fv.Float(), limit, nil
+	default:
+		return 0, 0, errors.New("unsupported field type " + fv.Type().String())
+	}
+}
@@ -0,0 +1,83 @@
+package convert
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type tlsConfig struct {
+	Cert string `mapstructure:"cert" validate:"required"`
+}
+
+type peerConfig struct {
+	Addr string `mapstructure:"addr" validate:"required"`
+}
+
+type sectionConfig struct {
+	Addr     string        `mapstructure:"addr" validate:"required"`
+	Port     int           `mapstructure:"port" validate:"min=1,max=65535"`
+	Mode     string        `mapstructure:"mode" validate:"oneof=tcp udp"`
+	Timeout  time.Duration `mapstructure:"timeout" validate:"min=1s"`
+	Tags     []string      `mapstructure:"tags" validate:"max=2"`
+	TLS      *tlsConfig    `mapstructure:"tls"`
+	Peers    []peerConfig  `mapstructure:"peers"`
+	Optional int           `mapstructure:"optional" validate:"min=10"`
+}
+
+// TestDecodeSection 测试配置段解码和默认值保留
+func TestDecodeSection(t *testing.T) {
+	cfg := &sectionConfig{Port: 8080, Mode: "tcp", Timeout: 5 * time.Second}
+	err := DecodeSection(map[string]any{
+		"addr":    "localhost",
+		"timeout": "1d2h",
+		"tags":    "a,b",
+	}, cfg)
+	assert.NoError(t, err)
+	assert.Equal(t, "localhost", cfg.Addr)
+	assert.Equal(t, 8080, cfg.Port)
+	assert.Equal(t, 26*time.Hour, cfg.Timeout)
+	assert.Equal(t, []string{"a", "b"}, cfg.Tags)
+}
+
+// TestValidate 测试所有未通过的字段一起返回
+func TestValidate(t *testing.T) {
+	cfg := &sectionConfig{
+		Port:    70000,
+		Mode:    "quic",
+		Timeout: time.Millisecond,
+		Tags:    []string{"a", "b", "c"},
+		TLS:     &tlsConfig{},
+		Peers:   []peerConfig{{Addr: "p1"}, {}},
+	}
+	err := Validate(cfg)
+	var verrs ValidationErrors
+	assert.True(t, errors.As(err, &verrs))
+
+	fields := make(map[string]string)
+	for _, fe := range verrs {
+		fields[fe.Field] = fe.Rule
+	}
+	assert.Equal(t, map[string]string{
+		"addr":          "required",
+		"port":          "max=65535",
+		"mode":          "oneof=tcp udp",
+		"timeout":       "min=1s",
+		"tags":          "max=2",
+		"tls.cert":      "required",
+		"peers[1].addr": "required",
+	}, fields)
+	assert.True(t, strings.Contains(err.Error(), "port: must be at most 65535"))
+}
+
+// TestDecodeSectionTypeError 测试类型无法转换时返回解码错误
+func TestDecodeSectionTypeError(t *testing.T) {
+	cfg := &sectionConfig{}
+	err := DecodeSection(map[string]any{"port": "not a number"}, cfg)
+	var verrs ValidationErrors
+	assert.Error(t, err)
+	assert.False(t, errors.As(err, &verrs))
+}
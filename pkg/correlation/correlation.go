@@ -0,0 +1,198 @@
+// Package correlation 提供贯穿各个子系统的关联ID
+//
+// 进入的请求和消息通过 Middleware、Extract、ExtractMap 取出调用方传来的关联ID，没有时生成新的ID；
+// 发出的请求和消息通过 Inject、InjectMap 把关联ID传给下游，日志通过 Field 附带关联ID
+package correlation
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/binary"
+	"net/http"
+	"time"
+
+	"github.com/andrewbytecoder/nmq/pkg/utils"
+	"go.uber.org/zap"
+)
+
+const (
+	// Header 传递关联ID的HTTP请求头和响应头
+	Header = "X-Nmq-Correlation-Id"
+	// RequestIDHeader 常见的请求ID请求头，没有 Header 时从该请求头读取
+	RequestIDHeader = "X-Request-Id"
+	// MessageKey 消息头中的关联ID键
+	MessageKey = "correlation_id"
+	// LogKey 日志中的关联ID字段名
+	LogKey = "correlation_id"
+	// AttributeKey 链路追踪中的关联ID属性名
+	AttributeKey = "nmq.correlation_id"
+)
+
+// 关联ID的最大长度，超过时认为是非法输入并重新生成
+const maxIDLen = 128
+
+// Generator 关联ID生成函数
+type Generator func() string
+
+// generate 默认使用 ULID
+var generate Generator = ULID
+
+// SetGenerator 设置全局的关联ID生成函数，需要在处理请求前调用
+func SetGenerator(g Generator) {
+	generate = g
+}
+
+// New 生成新的关联ID
+func New() string {
+	return generate()
+}
+
+// crockford ULID 使用的 Crockford Base32 字符表
+const crockford = "0123456789ABCDEFGHJKMNPQRSTVWXYZ"
+
+// ULID 生成 ULID：48位毫秒时间戳加80位随机数，共26个字符，按生成时间排序
+func ULID() string {
+	var b [16]byte
+	ms := uint64(time.Now().UnixMilli())
+	binary.BigEndian.PutUint16(b[0:], uint16(ms>>32))
+	binary.BigEndian.PutUint32(b[2:], uint32(ms))
+	_, _ = rand.Read(b[6:])
+
+	// 128位按5位一组编码，最高位补2个0位
+	hi := binary.BigEndian.Uint64(b[0:])
+	lo := binary.BigEndian.Uint64(b[8:])
+	var out [26]byte
+	for i := 25; i >= 0; i-- {
+		out[i] = crockford[lo&0x1f]
+		lo = lo>>5 | hi<<59
+		hi >>= 5
+	}
+	return string(out[:])
+}
+
+// Snowflake 返回使用雪花算法节点生成关联ID的生成函数，时钟偏差期间雪花ID暂停生成，改用 ULID
+func Snowflake(node *utils.SnowNode) Generator {
+	return func() string {
+		id, err := node.TryGenerate()
+		if err != nil {
+			return ULID()
+		}
+		return id.Base58()
+	}
+}
+
+type ctxKey struct{}
+
+// NewContext 返回携带关联ID的 context
+func NewContext(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, ctxKey{}, id)
+}
+
+// FromContext 返回 ctx 中的关联ID
+func FromContext(ctx context.Context) (string, bool) {
+	id, ok := ctx.Value(ctxKey{}).(string)
+	return id, ok && id != ""
+}
+
+// Ensure 返回携带关联ID的 context 和关联ID，ctx 中没有时生成新的ID
+func Ensure(ctx context.Context) (context.Context, string) {
+	if id, ok := FromContext(ctx); ok {
+		return ctx, id
+	}
+	id := New()
+	return NewContext(ctx, id), id
+}
+
+// valid 关联ID只允许可打印的ASCII字符，防止日志和响应头注入
+func valid(id string) bool {
+	if id == "" || len(id) > maxIDLen {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		if id[i] <= ' ' || id[i] > '~' {
+			return false
+		}
+	}
+	return true
+}
+
+// Extract 从请求头取出关联ID放入 ctx，没有或非法时生成新的ID
+func Extract(ctx context.Context, header http.Header) (context.Context, string) {
+	id := header.Get(Header)
+	if id == "" {
+		id = header.Get(RequestIDHeader)
+	}
+	if !valid(id) {
+		id = New()
+	}
+	return NewContext(ctx, id), id
+}
+
+// Inject 将请求 context 中的关联ID写入 Header，context 中没有时不做任何事
+func Inject(req *http.Request) {
+	id, ok := FromContext(req.Context())
+	if !ok {
+		return
+	}
+	if req.Header == nil {
+		req.Header = make(http.Header)
+	}
+	req.Header.Set(Header, id)
+}
+
+// ExtractMap 从消息头取出关联ID放入 ctx，没有或非法时生成新的ID
+func ExtractMap(ctx context.Context, headers map[string]string) (context.Context, string) {
+	id := headers[MessageKey]
+	if !valid(id) {
+		id = New()
+	}
+	return NewContext(ctx, id), id
+}
+
+// InjectMap 将 ctx 中的关联ID写入消息头，headers 为 nil 时创建新的消息头
+func InjectMap(ctx context.Context, headers map[string]string) map[string]string {
+	id, ok := FromContext(ctx)
+	if !ok {
+		return headers
+	}
+	if headers == nil {
+		headers = make(map[string]string)
+	}
+	headers[MessageKey] = id
+	return headers
+}
+
+// Field 返回携带关联ID的日志字段，ctx 中没有时返回空字段
+func Field(ctx context.Context) zap.Field {
+	id, ok := FromContext(ctx)
+	if !ok {
+		return zap.Skip()
+	}
+	return zap.String(LogKey, id)
+}
+
+// Logger 返回附带关联ID字段的日志记录器
+func Logger(ctx context.Context, logger *zap.Logger) *zap.Logger {
+	if _, ok := FromContext(ctx); !ok {
+		return logger
+	}
+	return logger.With(Field(ctx))
+}
+
+// Attributes 返回写入链路追踪 span 的属性，ctx 中没有关联ID时返回 nil
+func Attributes(ctx context.Context) map[string]string {
+	id, ok := FromContext(ctx)
+	if !ok {
+		return nil
+	}
+	return map[string]string{AttributeKey: id}
+}
+
+// Middleware 为进入的HTTP请求设置关联ID，并写入响应头
+func Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx, id := Extract(r.Context(), r.Header)
+		w.Header().Set(Header, id)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
@@ -0,0 +1,136 @@
+package correlation
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/andrewbytecoder/nmq/pkg/utils"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+func TestULID(t *testing.T) {
+	ids := make([]string, 0, 3)
+	seen := make(map[string]bool)
+	for i := 0; i < 3; i++ {
+		id := ULID()
+		if len(id) != 26 || id[0] > '7' || seen[id] {
+			t.Fatalf("Unexpected ULID %q", id)
+		}
+		seen[id] = true
+		ids = append(ids, id)
+		time.Sleep(2 * time.Millisecond)
+	}
+	// 不同毫秒生成的 ULID 按时间排序
+	if !sort.StringsAreSorted(ids) {
+		t.Errorf("Expected ULIDs sorted by time, got %v", ids)
+	}
+}
+
+func TestSnowflakeGenerator(t *testing.T) {
+	node, err := utils.NewSnowNode(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	SetGenerator(Snowflake(node))
+	defer SetGenerator(ULID)
+	if id := New(); id == "" || len(id) == 26 {
+		t.Errorf("Expected snowflake id, got %q", id)
+	}
+}
+
+func TestMiddlewarePropagation(t *testing.T) {
+	var got string
+	srv := httptest.NewServer(Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got, _ = FromContext(r.Context())
+	})))
+	defer srv.Close()
+
+	ctx := NewContext(context.Background(), "abc-123")
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, srv.URL, nil)
+	Inject(req)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if got != "abc-123" || resp.Header.Get(Header) != "abc-123" {
+		t.Errorf("Expected propagated id, got %q and response %q", got, resp.Header.Get(Header))
+	}
+
+	// 兼容 X-Request-Id，非法的ID重新生成
+	for header, value := range map[string]string{RequestIDHeader: "req-1", Header: "bad id"} {
+		req, _ = http.NewRequest(http.MethodGet, srv.URL, nil)
+		req.Header.Set(header, value)
+		resp, err = http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		if value == "req-1" && got != "req-1" {
+			t.Errorf("Expected id from %s, got %q", RequestIDHeader, got)
+		}
+		if value != "req-1" && (got == value || len(got) != 26) {
+			t.Errorf("Expected regenerated id, got %q", got)
+		}
+	}
+}
+
+func TestMessageHeaders(t *testing.T) {
+	ctx, id := Ensure(context.Background())
+	headers := InjectMap(ctx, nil)
+	if headers[MessageKey] != id {
+		t.Fatalf("Expected id in headers, got %v", headers)
+	}
+	ctx2, id2 := ExtractMap(context.Background(), headers)
+	if id2 != id {
+		t.Errorf("Expected %q, got %q", id, id2)
+	}
+	if _, same := Ensure(ctx2); same != id {
+		t.Errorf("Expected Ensure to keep id, got %q", same)
+	}
+	if InjectMap(context.Background(), nil) != nil {
+		t.Error("Expected nil headers without id")
+	}
+	if attrs := Attributes(ctx); attrs[AttributeKey] != id {
+		t.Errorf("Unexpected attributes %v", attrs)
+	}
+}
+
+func TestLogger(t *testing.T) {
+	var buf bytes.Buffer
+	logger := zap.New(zapcore.NewCore(zapcore.NewJSONEncoder(zap.NewProductionEncoderConfig()), zapcore.AddSync(&buf), zap.InfoLevel))
+	ctx := NewContext(context.Background(), "abc")
+	Logger(ctx, logger).Info("with id")
+	logger.Info("field", Field(context.Background()))
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 2 || !strings.Contains(lines[0], `"correlation_id":"abc"`) {
+		t.Fatalf("Unexpected logs %q", buf.String())
+	}
+	if strings.Contains(lines[1], LogKey) {
+		t.Error("Expected no correlation field without id")
+	}
+}
+
+type skewGuard bool
+
+func (g skewGuard) Skewed() bool { return bool(g) }
+
+func TestSnowflakeFallsBackOnSkew(t *testing.T) {
+	node, err := utils.NewSnowNode(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	node.SetSkewGuard(skewGuard(true))
+	// 时钟偏差期间改用 ULID
+	if id := Snowflake(node)(); len(id) != 26 {
+		t.Errorf("Expected ULID fallback, got %q", id)
+	}
+}
@@ -0,0 +1,92 @@
+// Package deadline 提供请求截止时间预算，保证下游调用不会超过调用方的截止时间
+//
+// 进入的请求通过 Middleware 把调用方传来的剩余时间转换为 context 截止时间，
+// 发出的请求通过 WithTimeout 把超时限制在剩余预算内，并通过 Inject 把剩余预算传给下游
+package deadline
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// Header 传递剩余时间预算的HTTP请求头，值为毫秒数
+const Header = "X-Nmq-Deadline-Budget"
+
+// Remaining 返回 ctx 距离截止时间的剩余时间，没有截止时间时 ok 为 false
+func Remaining(ctx context.Context) (remaining time.Duration, ok bool) {
+	d, ok := ctx.Deadline()
+	if !ok {
+		return 0, false
+	}
+	return time.Until(d), true
+}
+
+// Budget 返回不超过 ctx 剩余时间的超时时间
+//
+// timeout <= 0 表示调用方不限制，此时直接使用 ctx 的剩余时间；两者都没有时返回0表示不限制
+func Budget(ctx context.Context, timeout time.Duration) time.Duration {
+	remaining, ok := Remaining(ctx)
+	if !ok {
+		return max(timeout, 0)
+	}
+	if remaining <= 0 {
+		// 预算已经耗尽，返回最小的正数让调用立即超时
+		return time.Nanosecond
+	}
+	if timeout <= 0 || remaining < timeout {
+		return remaining
+	}
+	return timeout
+}
+
+// WithTimeout 返回截止时间不晚于 ctx 截止时间的子 context，timeout <= 0 时只继承 ctx 的截止时间
+func WithTimeout(ctx context.Context, timeout time.Duration) (context.Context, context.CancelFunc) {
+	if timeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+	// context.WithTimeout 本身会取父子截止时间中较早的一个
+	return context.WithTimeout(ctx, timeout)
+}
+
+// WithReserve 返回截止时间比 ctx 提前 reserve 的子 context，为调用方保留处理下游结果的时间
+//
+// ctx 没有截止时间时只返回可取消的子 context
+func WithReserve(ctx context.Context, reserve time.Duration) (context.Context, context.CancelFunc) {
+	d, ok := ctx.Deadline()
+	if !ok {
+		return context.WithCancel(ctx)
+	}
+	return context.WithDeadline(ctx, d.Add(-reserve))
+}
+
+// Inject 将请求 context 的剩余时间写入 Header，下游服务可以据此设置自己的截止时间
+func Inject(req *http.Request) {
+	remaining, ok := Remaining(req.Context())
+	if !ok {
+		return
+	}
+	if req.Header == nil {
+		req.Header = make(http.Header)
+	}
+	req.Header.Set(Header, strconv.FormatInt(max(remaining.Milliseconds(), 0), 10))
+}
+
+// Extract 根据请求头中的剩余时间为 ctx 设置截止时间，没有或无法解析时只返回可取消的子 context
+func Extract(ctx context.Context, header http.Header) (context.Context, context.CancelFunc) {
+	ms, err := strconv.ParseInt(header.Get(Header), 10, 64)
+	if err != nil || ms < 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, time.Duration(ms)*time.Millisecond)
+}
+
+// Middleware 为进入的HTTP请求设置截止时间，处理函数中的下游调用可以使用 r.Context() 继承预算
+func Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx, cancel := Extract(r.Context(), r.Header)
+		defer cancel()
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
@@ -0,0 +1,100 @@
+package deadline
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestBudget(t *testing.T) {
+	bg := context.Background()
+	if got := Budget(bg, 0); got != 0 {
+		t.Errorf("Expected unlimited budget, got %v", got)
+	}
+	if got := Budget(bg, time.Second); got != time.Second {
+		t.Errorf("Expected 1s, got %v", got)
+	}
+
+	ctx, cancel := context.WithTimeout(bg, 100*time.Millisecond)
+	defer cancel()
+	if got := Budget(ctx, time.Second); got > 100*time.Millisecond || got <= 0 {
+		t.Errorf("Expected budget limited by deadline, got %v", got)
+	}
+	if got := Budget(ctx, 10*time.Millisecond); got != 10*time.Millisecond {
+		t.Errorf("Expected 10ms, got %v", got)
+	}
+	if got := Budget(ctx, 0); got > 100*time.Millisecond || got <= 0 {
+		t.Errorf("Expected remaining time, got %v", got)
+	}
+
+	expired, cancel2 := context.WithDeadline(bg, time.Now().Add(-time.Second))
+	defer cancel2()
+	if got := Budget(expired, time.Second); got != time.Nanosecond {
+		t.Errorf("Expected exhausted budget, got %v", got)
+	}
+}
+
+func TestWithTimeoutAndReserve(t *testing.T) {
+	parent, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	pd, _ := parent.Deadline()
+
+	ctx, cancel1 := WithTimeout(parent, time.Hour)
+	defer cancel1()
+	if d, _ := ctx.Deadline(); !d.Equal(pd) {
+		t.Errorf("Expected child deadline not to outlive parent, got %v want %v", d, pd)
+	}
+
+	ctx, cancel2 := WithReserve(parent, 20*time.Millisecond)
+	defer cancel2()
+	if d, _ := ctx.Deadline(); !d.Equal(pd.Add(-20 * time.Millisecond)) {
+		t.Errorf("Expected reserved deadline, got %v", d)
+	}
+
+	ctx, cancel3 := WithReserve(context.Background(), time.Second)
+	defer cancel3()
+	if _, ok := ctx.Deadline(); ok {
+		t.Error("Expected no deadline without parent deadline")
+	}
+}
+
+func TestPropagation(t *testing.T) {
+	var budget time.Duration
+	var ok bool
+	srv := httptest.NewServer(Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		budget, ok = Remaining(r.Context())
+	})))
+	defer srv.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, srv.URL, nil)
+	Inject(req)
+	ms, err := strconv.Atoi(req.Header.Get(Header))
+	if err != nil || ms <= 0 || ms > 2000 {
+		t.Fatalf("Unexpected budget header %q", req.Header.Get(Header))
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if !ok || budget <= 0 || budget > 2*time.Second {
+		t.Errorf("Expected handler deadline from header, got %v %v", budget, ok)
+	}
+
+	// 没有预算头时不设置截止时间
+	req, _ = http.NewRequest(http.MethodGet, srv.URL, nil)
+	resp, err = http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if ok {
+		t.Error("Expected no deadline without budget header")
+	}
+}
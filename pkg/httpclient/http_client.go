@@ -7,6 +7,8 @@ import (
 	"net/http"
 	"time"
 
+	"github.com/andrewbytecoder/nmq/pkg/correlation"
+	"github.com/andrewbytecoder/nmq/pkg/deadline"
 	"go.uber.org/zap"
 )
 
@@ -62,8 +64,12 @@ func handleAuthenticationError(resp *http.Response) error {
 //   - []byte: 响应体的字节数据
 //   - error: 请求过程中可能发生的错误
 func (hc *HttpClient) Send(request *http.Request, timeout time.Duration) ([]byte, error) {
-	// 设置请求超时时间
-	hc.c.Timeout = timeout
+	// 设置请求超时时间，不超过请求 context 剩余的截止时间预算
+	ctx, cancel := deadline.WithTimeout(request.Context(), timeout)
+	defer cancel()
+	request = request.Clone(ctx)
+	deadline.Inject(request)
+	correlation.Inject(request)
 	// 执行HTTP请求
 	resp, err := hc.c.Do(request)
 	if err != nil {
@@ -93,8 +99,12 @@ func (hc *HttpClient) Send(request *http.Request, timeout time.Duration) ([]byte
 //   - *EntityResponse: 包含状态码、响应体和响应头的响应实体
 //   - error: 请求过程中可能发生的错误
 func (hc *HttpClient) SendRequestReturnEntity(request *http.Request, timeout time.Duration) (*EntityResponse, error) {
-	// 设置超时时间
-	hc.c.Timeout = timeout
+	// 设置超时时间，不超过请求 context 剩余的截止时间预算
+	ctx, cancel := deadline.WithTimeout(request.Context(), timeout)
+	defer cancel()
+	request = request.Clone(ctx)
+	deadline.Inject(request)
+	correlation.Inject(request)
 	resp, err := hc.c.Do(request)
 	if err != nil {
 		return nil, err
@@ -10,6 +10,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/andrewbytecoder/nmq/pkg/deadline"
 	"go.uber.org/zap/zaptest"
 )
 
@@ -142,6 +143,42 @@ func TestHttpClient_Send_Timeout(t *testing.T) {
 	}
 }
 
+func TestHttpClient_Send_DeadlineBudget(t *testing.T) {
+	logger := zaptest.NewLogger(t)
+	client := NewHttpClient(logger)
+
+	budget := make(chan string, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		budget <- r.Header.Get(deadline.Header)
+		time.Sleep(500 * time.Millisecond)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	// 调用方的截止时间早于 Send 的超时时间
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, server.URL, nil)
+	if err != nil {
+		t.Fatalf("Failed to create request: %v", err)
+	}
+
+	start := time.Now()
+	_, err = client.Send(req, 10*time.Second)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected deadline exceeded, got: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 400*time.Millisecond {
+		t.Errorf("Expected call to stop at caller deadline, took %v", elapsed)
+	}
+	if b := <-budget; b == "" {
+		t.Error("Expected budget header to be sent downstream")
+	}
+	if req.Header.Get(deadline.Header) != "" {
+		t.Error("Expected caller request headers to be left untouched")
+	}
+}
+
 func TestHttpClient_SendRequestReturnEntity_Success(t *testing.T) {
 	logger := zaptest.NewLogger(t)
 	client := NewHttpClient(logger)
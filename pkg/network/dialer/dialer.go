@@ -0,0 +1,211 @@
+// Package dialer 对 net.Dialer 的统一封装，提供连接超时、IPv4/IPv6 happy-eyeballs、
+// 重试策略以及可选的 SOCKS5 代理，供客户端、websocket 客户端和桥接等组件使用，
+// 保证各处的连接行为一致且可配置
+package dialer
+
+import (
+	"context"
+	"errors"
+	"net"
+	"time"
+
+	"github.com/andrewbytecoder/nmq/pkg/options"
+)
+
+// 默认配置
+const (
+	defaultTimeout       = 10 * time.Second
+	defaultKeepAlive     = 30 * time.Second
+	defaultFallbackDelay = 300 * time.Millisecond
+	defaultRetryBackoff  = 100 * time.Millisecond
+	defaultMaxBackoff    = 5 * time.Second
+)
+
+// Config 拨号配置
+type Config struct {
+	// Timeout 单次连接超时，包括 SOCKS5 握手
+	Timeout time.Duration
+	// KeepAlive TCP keepalive 间隔，负数表示关闭
+	KeepAlive time.Duration
+	// FallbackDelay happy-eyeballs 中优先地址族失败前等待多久开始尝试另一个地址族，负数表示关闭
+	FallbackDelay time.Duration
+	// Retries 首次失败后的重试次数，0 表示不重试
+	Retries int
+	// RetryBackoff 第一次重试前的等待时间，之后每次翻倍
+	RetryBackoff time.Duration
+	// MaxBackoff 重试等待时间的上限
+	MaxBackoff time.Duration
+	// Proxy SOCKS5 代理地址 host:port，为空时直连
+	Proxy string
+	// ProxyUser SOCKS5 用户名，为空时不做认证
+	ProxyUser string
+	// ProxyPassword SOCKS5 密码
+	ProxyPassword string
+}
+
+// SetTimeout 设置单次连接超时
+func SetTimeout(d time.Duration) options.Option {
+	return func(c any) {
+		if c, ok := c.(*Config); ok {
+			c.Timeout = d
+		}
+	}
+}
+
+// SetKeepAlive 设置 TCP keepalive 间隔
+func SetKeepAlive(d time.Duration) options.Option {
+	return func(c any) {
+		if c, ok := c.(*Config); ok {
+			c.KeepAlive = d
+		}
+	}
+}
+
+// SetFallbackDelay 设置 happy-eyeballs 的回退等待时间
+func SetFallbackDelay(d time.Duration) options.Option {
+	return func(c any) {
+		if c, ok := c.(*Config); ok {
+			c.FallbackDelay = d
+		}
+	}
+}
+
+// SetRetry 设置重试次数和初始退避时间
+func SetRetry(retries int, backoff time.Duration) options.Option {
+	return func(c any) {
+		if c, ok := c.(*Config); ok {
+			c.Retries = retries
+			c.RetryBackoff = backoff
+		}
+	}
+}
+
+// SetMaxBackoff 设置重试等待时间的上限
+func SetMaxBackoff(d time.Duration) options.Option {
+	return func(c any) {
+		if c, ok := c.(*Config); ok {
+			c.MaxBackoff = d
+		}
+	}
+}
+
+// SetSOCKS5 设置 SOCKS5 代理，user 为空时不做认证
+func SetSOCKS5(addr, user, password string) options.Option {
+	return func(c any) {
+		if c, ok := c.(*Config); ok {
+			c.Proxy = addr
+			c.ProxyUser = user
+			c.ProxyPassword = password
+		}
+	}
+}
+
+// NewConfig 创建拨号配置
+func NewConfig(opts ...options.Option) *Config {
+	c := &Config{
+		Timeout:       defaultTimeout,
+		KeepAlive:     defaultKeepAlive,
+		FallbackDelay: defaultFallbackDelay,
+		RetryBackoff:  defaultRetryBackoff,
+		MaxBackoff:    defaultMaxBackoff,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// Dialer 按配置建立连接，可以被多个协程共享
+type Dialer struct {
+	cfg *Config
+	nd  net.Dialer
+}
+
+// New 创建拨号器
+func New(opts ...options.Option) *Dialer {
+	return NewWithConfig(NewConfig(opts...))
+}
+
+// NewWithConfig 使用已有配置创建拨号器，cfg 为 nil 时使用默认配置
+func NewWithConfig(cfg *Config) *Dialer {
+	if cfg == nil {
+		cfg = NewConfig()
+	}
+	return &Dialer{
+		cfg: cfg,
+		// net.Dialer 在 FallbackDelay 为正时按 RFC 6555 并行尝试 IPv4 和 IPv6
+		nd: net.Dialer{
+			Timeout:       cfg.Timeout,
+			KeepAlive:     cfg.KeepAlive,
+			FallbackDelay: cfg.FallbackDelay,
+		},
+	}
+}
+
+// Dial 建立连接，签名与 net.Dial 一致
+func (d *Dialer) Dial(network, address string) (net.Conn, error) {
+	return d.DialContext(context.Background(), network, address)
+}
+
+// DialContext 建立连接，失败时按退避策略重试，ctx 取消时立即返回
+func (d *Dialer) DialContext(ctx context.Context, network, address string) (net.Conn, error) {
+	backoff := d.cfg.RetryBackoff
+	for attempt := 0; ; attempt++ {
+		conn, err := d.dialOnce(ctx, network, address)
+		if err == nil {
+			return conn, nil
+		}
+		if attempt >= d.cfg.Retries || ctx.Err() != nil || !retryable(err) {
+			return nil, err
+		}
+
+		t := time.NewTimer(backoff)
+		select {
+		case <-ctx.Done():
+			t.Stop()
+			return nil, err
+		case <-t.C:
+		}
+		backoff *= 2
+		if d.cfg.MaxBackoff > 0 && backoff > d.cfg.MaxBackoff {
+			backoff = d.cfg.MaxBackoff
+		}
+	}
+}
+
+// dialOnce 建立一次连接，配置了代理时通过 SOCKS5 代理连接
+func (d *Dialer) dialOnce(ctx context.Context, network, address string) (net.Conn, error) {
+	if d.cfg.Proxy == "" {
+		return d.nd.DialContext(ctx, network, address)
+	}
+	if d.cfg.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, d.cfg.Timeout)
+		defer cancel()
+	}
+	conn, err := d.nd.DialContext(ctx, "tcp", d.cfg.Proxy)
+	if err != nil {
+		return nil, err
+	}
+	if err = socks5Connect(ctx, conn, address, d.cfg.ProxyUser, d.cfg.ProxyPassword); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return conn, nil
+}
+
+// retryable 判断错误是否值得重试，代理认证失败等确定性错误不重试
+func retryable(err error) bool {
+	if errors.Is(err, ErrSOCKS5Auth) {
+		return false
+	}
+	var serr *SOCKS5Error
+	if errors.As(err, &serr) {
+		return serr.retryable()
+	}
+	var dnsErr *net.DNSError
+	if errors.As(err, &dnsErr) {
+		return dnsErr.IsTemporary || dnsErr.IsTimeout
+	}
+	return true
+}
@@ -0,0 +1,202 @@
+// dialer_test.go
+package dialer
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"io"
+	"net"
+	"strconv"
+	"testing"
+	"time"
+)
+
+// echoServer 启动一个回显服务，返回监听地址
+func echoServer(t *testing.T) string {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ln.Close() })
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				io.Copy(conn, conn)
+			}()
+		}
+	}()
+	return ln.Addr().String()
+}
+
+// socksServer 启动一个最小的 SOCKS5 代理，reply 非 0 时拒绝 CONNECT
+func socksServer(t *testing.T, user, password string, reply byte) string {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ln.Close() })
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go serveSOCKS(conn, user, password, reply)
+		}
+	}()
+	return ln.Addr().String()
+}
+
+func serveSOCKS(conn net.Conn, user, password string, reply byte) {
+	defer conn.Close()
+	buf := make([]byte, 512)
+	if _, err := io.ReadFull(conn, buf[:2]); err != nil {
+		return
+	}
+	if _, err := io.ReadFull(conn, buf[:buf[1]]); err != nil {
+		return
+	}
+	if user == "" {
+		conn.Write([]byte{socks5Version, socks5AuthNone})
+	} else {
+		conn.Write([]byte{socks5Version, socks5AuthPassword})
+		io.ReadFull(conn, buf[:2])
+		u := make([]byte, buf[1])
+		io.ReadFull(conn, u)
+		io.ReadFull(conn, buf[:1])
+		p := make([]byte, buf[0])
+		io.ReadFull(conn, p)
+		if string(u) != user || string(p) != password {
+			conn.Write([]byte{0x01, 0x01})
+			return
+		}
+		conn.Write([]byte{0x01, 0x00})
+	}
+
+	if _, err := io.ReadFull(conn, buf[:4]); err != nil {
+		return
+	}
+	var host string
+	switch buf[3] {
+	case socks5AtypIPv4:
+		io.ReadFull(conn, buf[:4])
+		host = net.IP(buf[:4]).String()
+	case socks5AtypDomain:
+		io.ReadFull(conn, buf[:1])
+		n := int(buf[0])
+		io.ReadFull(conn, buf[:n])
+		host = string(buf[:n])
+	}
+	io.ReadFull(conn, buf[:2])
+	port := binary.BigEndian.Uint16(buf[:2])
+	if reply != 0 {
+		conn.Write([]byte{socks5Version, reply, 0, socks5AtypIPv4, 0, 0, 0, 0, 0, 0})
+		return
+	}
+	target, err := net.Dial("tcp", net.JoinHostPort(host, strconv.Itoa(int(port))))
+	if err != nil {
+		conn.Write([]byte{socks5Version, 0x05, 0, socks5AtypIPv4, 0, 0, 0, 0, 0, 0})
+		return
+	}
+	defer target.Close()
+	conn.Write([]byte{socks5Version, 0, 0, socks5AtypIPv4, 127, 0, 0, 1, 0, 0})
+	go io.Copy(target, conn)
+	io.Copy(conn, target)
+}
+
+func roundTrip(t *testing.T, conn net.Conn) {
+	t.Helper()
+	defer conn.Close()
+	if _, err := conn.Write([]byte("ping")); err != nil {
+		t.Fatal(err)
+	}
+	buf := make([]byte, 4)
+	if _, err := io.ReadFull(conn, buf); err != nil {
+		t.Fatal(err)
+	}
+	if string(buf) != "ping" {
+		t.Errorf("Expected ping, got %q", buf)
+	}
+}
+
+func TestDialDirect(t *testing.T) {
+	addr := echoServer(t)
+	conn, err := New(SetTimeout(time.Second)).Dial("tcp", addr)
+	if err != nil {
+		t.Fatalf("Dial failed: %v", err)
+	}
+	roundTrip(t, conn)
+}
+
+func TestDialRetry(t *testing.T) {
+	// 先占用端口再关闭，得到一个拒绝连接的地址
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := ln.Addr().String()
+	ln.Close()
+
+	d := New(SetRetry(2, 20*time.Millisecond))
+	start := time.Now()
+	if _, err = d.Dial("tcp", addr); err == nil {
+		t.Fatal("Expected dial to fail")
+	}
+	// 两次重试分别等待 20ms 和 40ms
+	if elapsed := time.Since(start); elapsed < 60*time.Millisecond {
+		t.Errorf("Expected retries with backoff, elapsed %v", elapsed)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Millisecond)
+	defer cancel()
+	start = time.Now()
+	if _, err = New(SetRetry(10, time.Second)).DialContext(ctx, "tcp", addr); err == nil {
+		t.Fatal("Expected dial to fail")
+	}
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Errorf("Expected context to stop retries, elapsed %v", elapsed)
+	}
+}
+
+func TestDialSOCKS5(t *testing.T) {
+	target := echoServer(t)
+	_, port, _ := net.SplitHostPort(target)
+
+	proxy := socksServer(t, "", "", 0)
+	conn, err := New(SetSOCKS5(proxy, "", "")).Dial("tcp", target)
+	if err != nil {
+		t.Fatalf("Dial via proxy failed: %v", err)
+	}
+	roundTrip(t, conn)
+
+	// 域名由代理解析
+	proxy = socksServer(t, "user", "secret", 0)
+	conn, err = New(SetSOCKS5(proxy, "user", "secret")).Dial("tcp", net.JoinHostPort("localhost", port))
+	if err != nil {
+		t.Fatalf("Dial via authenticated proxy failed: %v", err)
+	}
+	roundTrip(t, conn)
+
+	if _, err = New(SetSOCKS5(proxy, "user", "wrong"), SetRetry(3, time.Second)).Dial("tcp", target); !errors.Is(err, ErrSOCKS5Auth) {
+		t.Errorf("Expected ErrSOCKS5Auth, got %v", err)
+	}
+
+	proxy = socksServer(t, "", "", 0x02)
+	start := time.Now()
+	_, err = New(SetSOCKS5(proxy, "", ""), SetRetry(3, time.Second)).Dial("tcp", target)
+	var serr *SOCKS5Error
+	if !errors.As(err, &serr) || serr.Code != 0x02 {
+		t.Errorf("Expected SOCKS5Error 0x02, got %v", err)
+	}
+	if time.Since(start) > 500*time.Millisecond {
+		t.Error("Expected ruleset rejection not to be retried")
+	}
+}
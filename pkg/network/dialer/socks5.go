@@ -0,0 +1,159 @@
+package dialer
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"time"
+)
+
+// SOCKS5 协议常量 RFC 1928 / RFC 1929
+const (
+	socks5Version      = 0x05
+	socks5AuthNone     = 0x00
+	socks5AuthPassword = 0x02
+	socks5AuthNoAccept = 0xff
+	socks5CmdConnect   = 0x01
+	socks5AtypIPv4     = 0x01
+	socks5AtypDomain   = 0x03
+	socks5AtypIPv6     = 0x04
+)
+
+// ErrSOCKS5Auth 代理拒绝了认证
+var ErrSOCKS5Auth = errors.New("dialer: socks5 authentication failed")
+
+// SOCKS5Error 代理返回的 CONNECT 失败响应
+type SOCKS5Error struct {
+	Code byte
+}
+
+// socks5Replies 响应码说明
+var socks5Replies = map[byte]string{
+	0x01: "general failure",
+	0x02: "connection not allowed by ruleset",
+	0x03: "network unreachable",
+	0x04: "host unreachable",
+	0x05: "connection refused",
+	0x06: "ttl expired",
+	0x07: "command not supported",
+	0x08: "address type not supported",
+}
+
+func (e *SOCKS5Error) Error() string {
+	if msg, ok := socks5Replies[e.Code]; ok {
+		return "dialer: socks5 " + msg
+	}
+	return fmt.Sprintf("dialer: socks5 unknown reply %#x", e.Code)
+}
+
+// retryable 规则拒绝和协议不支持的错误重试也不会成功
+func (e *SOCKS5Error) retryable() bool {
+	switch e.Code {
+	case 0x02, 0x07, 0x08:
+		return false
+	}
+	return true
+}
+
+// socks5Connect 在已连接到代理的 conn 上完成握手并请求连接 address
+func socks5Connect(ctx context.Context, conn net.Conn, address, user, password string) error {
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+		defer conn.SetDeadline(time.Time{})
+	}
+
+	host, portStr, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	port, err := strconv.ParseUint(portStr, 10, 16)
+	if err != nil {
+		return fmt.Errorf("dialer: invalid port %q", portStr)
+	}
+
+	// 协商认证方式
+	method := byte(socks5AuthNone)
+	if user != "" {
+		method = socks5AuthPassword
+	}
+	if _, err = conn.Write([]byte{socks5Version, 1, method}); err != nil {
+		return err
+	}
+	buf := make([]byte, 2, 262)
+	if _, err = io.ReadFull(conn, buf); err != nil {
+		return err
+	}
+	if buf[0] != socks5Version {
+		return fmt.Errorf("dialer: unexpected socks version %d", buf[0])
+	}
+	if buf[1] == socks5AuthNoAccept || buf[1] != method {
+		return ErrSOCKS5Auth
+	}
+	if method == socks5AuthPassword {
+		if len(user) > 255 || len(password) > 255 {
+			return ErrSOCKS5Auth
+		}
+		req := []byte{0x01, byte(len(user))}
+		req = append(req, user...)
+		req = append(req, byte(len(password)))
+		req = append(req, password...)
+		if _, err = conn.Write(req); err != nil {
+			return err
+		}
+		if _, err = io.ReadFull(conn, buf[:2]); err != nil {
+			return err
+		}
+		if buf[1] != 0x00 {
+			return ErrSOCKS5Auth
+		}
+	}
+
+	// 发送 CONNECT 请求，域名交给代理解析
+	req := []byte{socks5Version, socks5CmdConnect, 0x00}
+	if ip := net.ParseIP(host); ip == nil {
+		if len(host) > 255 {
+			return fmt.Errorf("dialer: host name too long")
+		}
+		req = append(req, socks5AtypDomain, byte(len(host)))
+		req = append(req, host...)
+	} else if ip4 := ip.To4(); ip4 != nil {
+		req = append(req, socks5AtypIPv4)
+		req = append(req, ip4...)
+	} else {
+		req = append(req, socks5AtypIPv6)
+		req = append(req, ip.To16()...)
+	}
+	req = binary.BigEndian.AppendUint16(req, uint16(port))
+	if _, err = conn.Write(req); err != nil {
+		return err
+	}
+
+	// 读取响应并丢弃绑定地址
+	buf = buf[:4]
+	if _, err = io.ReadFull(conn, buf); err != nil {
+		return err
+	}
+	if buf[1] != 0x00 {
+		return &SOCKS5Error{Code: buf[1]}
+	}
+	var n int
+	switch buf[3] {
+	case socks5AtypIPv4:
+		n = net.IPv4len
+	case socks5AtypIPv6:
+		n = net.IPv6len
+	case socks5AtypDomain:
+		if _, err = io.ReadFull(conn, buf[:1]); err != nil {
+			return err
+		}
+		n = int(buf[0])
+	default:
+		return fmt.Errorf("dialer: unexpected socks address type %d", buf[3])
+	}
+	_, err = io.ReadFull(conn, make([]byte, n+2))
+	return err
+}
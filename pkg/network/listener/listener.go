@@ -0,0 +1,256 @@
+package listener
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+
+	"github.com/andrewbytecoder/nmq/pkg/options"
+)
+
+// systemd socket activation 约定的环境变量及起始 fd
+const (
+	envListenPID     = "LISTEN_PID"
+	envListenFDs     = "LISTEN_FDS"
+	envListenFDNames = "LISTEN_FDNAMES"
+	listenFDsStart   = 3
+)
+
+// ErrReusePortUnsupported 当前平台不支持 SO_REUSEPORT
+var ErrReusePortUnsupported = errors.New("listener: SO_REUSEPORT is not supported on this platform")
+
+// Config 监听器配置
+type Config struct {
+	// ReusePort 是否开启 SO_REUSEPORT，开启后多个进程可以监听同一端口，由内核做负载均衡
+	ReusePort bool
+	// SocketActivation 是否优先使用 systemd 通过 LISTEN_FDS 传入的监听套接字
+	SocketActivation bool
+	// FDName 当 systemd 传入多个套接字时，按 LISTEN_FDNAMES 中的名字选择，为空时使用第一个
+	FDName string
+}
+
+// SetReusePort 设置是否开启 SO_REUSEPORT
+func SetReusePort(reusePort bool) options.Option {
+	return func(c any) {
+		if c, ok := c.(*Config); ok {
+			c.ReusePort = reusePort
+		}
+	}
+}
+
+// SetSocketActivation 设置是否使用 systemd socket activation
+func SetSocketActivation(activation bool) options.Option {
+	return func(c any) {
+		if c, ok := c.(*Config); ok {
+			c.SocketActivation = activation
+		}
+	}
+}
+
+// SetFDName 设置 socket activation 时使用的套接字名字
+func SetFDName(name string) options.Option {
+	return func(c any) {
+		if c, ok := c.(*Config); ok {
+			c.FDName = name
+		}
+	}
+}
+
+// NewConfig 创建监听器配置
+func NewConfig(opts ...options.Option) *Config {
+	c := &Config{}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// Listen 按配置创建监听器
+//
+// 开启 SocketActivation 且 systemd 传入了匹配的套接字时直接复用该套接字，
+// 热升级启动时复用父进程传入的相同 network 和 address 的套接字，否则按 ReusePort 配置新建监听。
+// 新建和继承的监听器会在 Upgrade 时传给新进程。
+func Listen(network, address string, cfg *Config) (net.Listener, error) {
+	if cfg == nil {
+		cfg = NewConfig()
+	}
+
+	if cfg.SocketActivation {
+		ln, err := defaultActivation.take(cfg.FDName)
+		if err != nil {
+			return nil, err
+		}
+		if ln != nil {
+			return defaultRegistry.track(ln, network, address), nil
+		}
+	}
+
+	// 热升级启动时优先复用父进程传入的套接字
+	if ln := defaultRegistry.take(network, address); ln != nil {
+		return defaultRegistry.track(ln, network, address), nil
+	}
+
+	lc := net.ListenConfig{}
+	if cfg.ReusePort {
+		lc.Control = func(network, address string, c syscall.RawConn) error {
+			var serr error
+			if err := c.Control(func(fd uintptr) {
+				serr = setReusePort(fd)
+			}); err != nil {
+				return err
+			}
+			return serr
+		}
+	}
+
+	ln, err := lc.Listen(context.Background(), network, address)
+	if err != nil {
+		return nil, err
+	}
+	return defaultRegistry.track(ln, network, address), nil
+}
+
+// namedListener 记录 systemd 传入套接字的名字
+type namedListener struct {
+	net.Listener
+	name string
+}
+
+// activation 记录 systemd 通过 socket activation 传入的监听器
+//
+// 环境变量只在第一次使用时解析，之后各调用方按 FDName 取走各自的监听器，
+// 未被取用的监听器保留到 Ready 时再关闭，不会因为某个调用方没有命中而提前关闭。
+type activation struct {
+	mu     sync.Mutex
+	once   sync.Once
+	byName map[string][]*namedListener // FDName 到尚未取用的监听器，按 fd 顺序排列
+	order  []*namedListener            // 按 fd 顺序排列的全部监听器，用于不指定名字时选取
+	err    error
+}
+
+var defaultActivation = &activation{}
+
+// load 解析 systemd 传入的监听套接字，只在第一次调用时执行，读取后清理环境变量
+func (a *activation) load() error {
+	a.once.Do(func() {
+		a.byName = make(map[string][]*namedListener)
+		lns, err := activatedListeners()
+		if err != nil {
+			a.err = err
+			return
+		}
+		for _, ln := range lns {
+			a.byName[ln.name] = append(a.byName[ln.name], ln)
+		}
+		a.order = lns
+	})
+	return a.err
+}
+
+// take 取出一个名字为 name 的监听器，name 为空时按 fd 顺序取第一个尚未取用的监听器
+func (a *activation) take(name string) (*namedListener, error) {
+	if err := a.load(); err != nil {
+		return nil, err
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	var ln *namedListener
+	if name == "" {
+		for _, l := range a.order {
+			if a.remove(l) {
+				ln = l
+				break
+			}
+		}
+	} else if lns := a.byName[name]; len(lns) > 0 {
+		ln = lns[0]
+		a.remove(ln)
+	}
+	return ln, nil
+}
+
+// remove 从 byName 中移除 ln，ln 已经被取用时返回 false
+func (a *activation) remove(ln *namedListener) bool {
+	lns := a.byName[ln.name]
+	for i, l := range lns {
+		if l == ln {
+			a.byName[ln.name] = append(lns[:i:i], lns[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// drain 取出所有尚未取用的监听器
+func (a *activation) drain() []net.Listener {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	var ret []net.Listener
+	for _, l := range a.order {
+		if a.remove(l) {
+			ret = append(ret, l)
+		}
+	}
+	return ret
+}
+
+// Activated 取出 systemd 通过 socket activation 传入、尚未被 Listen 取用的监听器，调用方负责关闭
+//
+// 仅当 LISTEN_PID 与当前进程一致时生效，环境变量只在第一次使用时解析并清理，避免子进程误用。
+func Activated() ([]net.Listener, error) {
+	if err := defaultActivation.load(); err != nil {
+		return nil, err
+	}
+	return defaultActivation.drain(), nil
+}
+
+// activatedListeners 按 fd 顺序解析 LISTEN_FDS 传入的监听套接字
+func activatedListeners() ([]*namedListener, error) {
+	pid, err := strconv.Atoi(os.Getenv(envListenPID))
+	if err != nil || pid != os.Getpid() {
+		return nil, nil
+	}
+
+	n, err := strconv.Atoi(os.Getenv(envListenFDs))
+	if err != nil || n <= 0 {
+		return nil, nil
+	}
+
+	var names []string
+	if v := os.Getenv(envListenFDNames); v != "" {
+		names = strings.Split(v, ":")
+	}
+
+	_ = os.Unsetenv(envListenPID)
+	_ = os.Unsetenv(envListenFDs)
+	_ = os.Unsetenv(envListenFDNames)
+
+	lns := make([]*namedListener, 0, n)
+	for i := 0; i < n; i++ {
+		fd := listenFDsStart + i
+		name := ""
+		if i < len(names) {
+			name = names[i]
+		}
+
+		f := os.NewFile(uintptr(fd), name)
+		ln, err := net.FileListener(f)
+		// FileListener 会 dup 一份 fd，原始文件可以直接关闭
+		_ = f.Close()
+		if err != nil {
+			for _, l := range lns {
+				_ = l.Close()
+			}
+			return nil, fmt.Errorf("listener: fd %d is not a listening socket: %w", fd, err)
+		}
+		lns = append(lns, &namedListener{Listener: ln, name: name})
+	}
+
+	return lns, nil
+}
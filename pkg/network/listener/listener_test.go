@@ -0,0 +1,110 @@
+package listener
+
+import (
+	"net"
+	"os"
+	"runtime"
+	"strconv"
+	"testing"
+)
+
+func TestListenReusePort(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("SO_REUSEPORT is not supported on windows")
+	}
+
+	cfg := NewConfig(SetReusePort(true))
+	ln1, err := Listen("tcp", "127.0.0.1:0", cfg)
+	if err != nil {
+		t.Fatalf("Listen() error = %v", err)
+	}
+	defer ln1.Close()
+
+	// 开启 SO_REUSEPORT 后第二个监听器可以绑定同一端口
+	ln2, err := Listen("tcp", ln1.Addr().String(), cfg)
+	if err != nil {
+		t.Fatalf("Listen() on reused port error = %v", err)
+	}
+	defer ln2.Close()
+}
+
+func TestListenWithoutReusePort(t *testing.T) {
+	ln1, err := Listen("tcp", "127.0.0.1:0", nil)
+	if err != nil {
+		t.Fatalf("Listen() error = %v", err)
+	}
+	defer ln1.Close()
+
+	if ln2, err := Listen("tcp", ln1.Addr().String(), nil); err == nil {
+		ln2.Close()
+		t.Error("expected bind error without SO_REUSEPORT")
+	}
+}
+
+func TestActivatedPIDMismatch(t *testing.T) {
+	t.Setenv(envListenPID, strconv.Itoa(os.Getpid()+1))
+	t.Setenv(envListenFDs, "1")
+
+	lns, err := Activated()
+	if err != nil {
+		t.Fatalf("Activated() error = %v", err)
+	}
+	if len(lns) != 0 {
+		t.Errorf("Activated() returned %d listeners, want 0", len(lns))
+	}
+	// 不属于当前进程的环境变量不应被清理
+	if os.Getenv(envListenFDs) != "1" {
+		t.Error("LISTEN_FDS should be kept when LISTEN_PID mismatches")
+	}
+}
+
+func TestActivationTake(t *testing.T) {
+	newNamed := func(name string) *namedListener {
+		ln, err := net.Listen("tcp", "127.0.0.1:0")
+		if err != nil {
+			t.Fatalf("net.Listen() error = %v", err)
+		}
+		return &namedListener{Listener: ln, name: name}
+	}
+
+	// 模拟已经解析过的 systemd 套接字
+	a := &activation{byName: make(map[string][]*namedListener)}
+	a.once.Do(func() {})
+	a.order = []*namedListener{newNamed("http"), newNamed("ws"), newNamed("metrics")}
+	for _, ln := range a.order {
+		a.byName[ln.name] = append(a.byName[ln.name], ln)
+	}
+
+	ln, err := a.take("ws")
+	if err != nil || ln == nil {
+		t.Fatalf("take() = %v, %v", ln, err)
+	}
+	defer ln.Close()
+	if ln.name != "ws" {
+		t.Errorf("take() name = %s, want ws", ln.name)
+	}
+	// 未命中的调用不会关闭其他调用方的套接字
+	if ln, _ := a.take("admin"); ln != nil {
+		t.Error("take() should return nil when no name matches")
+	}
+	if ln, _ := a.take("ws"); ln != nil {
+		t.Error("take() should not hand out the same listener twice")
+	}
+
+	// 不指定名字时按 fd 顺序取第一个尚未取用的套接字
+	first, _ := a.take("")
+	if first == nil || first.name != "http" {
+		t.Fatalf("take(\"\") = %v, want http", first)
+	}
+	defer first.Close()
+
+	// 剩余的套接字在 drain 时取出，Ready 会关闭它们
+	rest := a.drain()
+	if len(rest) != 1 || rest[0].(*namedListener).name != "metrics" {
+		t.Fatalf("drain() = %v, want [metrics]", rest)
+	}
+	_ = rest[0].Close()
+	if lns := a.drain(); len(lns) != 0 {
+		t.Errorf("drain() returned %d listeners after draining, want 0", len(lns))
+	}
+}
@@ -0,0 +1,8 @@
+//go:build !(linux || darwin || freebsd || netbsd || openbsd || dragonfly)
+
+package listener
+
+// setReusePort 当前平台不支持 SO_REUSEPORT
+func setReusePort(fd uintptr) error {
+	return ErrReusePortUnsupported
+}
@@ -0,0 +1,273 @@
+package listener
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"os/exec"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// 热升级时父进程传给子进程的环境变量
+const (
+	envInheritFDs = "NMQ_INHERIT_FDS" // 继承的监听套接字，按 fd 顺序以 "," 分隔的 network|address
+	envReadyFD    = "NMQ_READY_FD"    // 子进程就绪后写入并关闭的管道 fd
+)
+
+var (
+	// ErrUpgradeUnsupported 当前平台不支持通过 fd 继承热升级
+	ErrUpgradeUnsupported = errors.New("listener: upgrade is not supported on this platform")
+	// ErrUpgradeInProgress 已有热升级正在进行
+	ErrUpgradeInProgress = errors.New("listener: upgrade already in progress")
+	// ErrChildNotReady 子进程在就绪前退出或关闭了就绪管道
+	ErrChildNotReady = errors.New("listener: upgraded process exited before ready")
+)
+
+// registry 记录通过 Listen 创建的监听器和从父进程继承的监听器
+type registry struct {
+	mu        sync.Mutex
+	once      sync.Once
+	inherited map[string][]net.Listener // network|address 到继承的监听器
+	ready     *os.File                  // 就绪管道，非热升级启动时为 nil
+	active    map[*trackedListener]struct{}
+	upgrading bool
+}
+
+var defaultRegistry = &registry{active: make(map[*trackedListener]struct{})}
+
+// trackedListener 关闭时从 registry 中移除的监听器
+type trackedListener struct {
+	net.Listener
+	network, address string
+	once             sync.Once
+}
+
+func (l *trackedListener) Close() error {
+	l.once.Do(func() {
+		defaultRegistry.mu.Lock()
+		delete(defaultRegistry.active, l)
+		defaultRegistry.mu.Unlock()
+	})
+	return l.Listener.Close()
+}
+
+func inheritKey(network, address string) string {
+	return network + "|" + address
+}
+
+// load 读取父进程传入的监听套接字和就绪管道，只在第一次调用时执行，读取后清理环境变量
+func (r *registry) load() {
+	r.once.Do(func() {
+		r.inherited = make(map[string][]net.Listener)
+		keys := os.Getenv(envInheritFDs)
+		readyFD, err := strconv.Atoi(os.Getenv(envReadyFD))
+		_ = os.Unsetenv(envInheritFDs)
+		_ = os.Unsetenv(envReadyFD)
+		if err != nil {
+			return
+		}
+		r.ready = os.NewFile(uintptr(readyFD), "ready")
+		if keys == "" {
+			return
+		}
+		for i, key := range strings.Split(keys, ",") {
+			f := os.NewFile(uintptr(listenFDsStart+i), key)
+			ln, err := net.FileListener(f)
+			_ = f.Close()
+			if err != nil {
+				continue
+			}
+			r.inherited[key] = append(r.inherited[key], ln)
+		}
+	})
+}
+
+// take 取出一个继承的监听器
+func (r *registry) take(network, address string) net.Listener {
+	r.load()
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	key := inheritKey(network, address)
+	lns := r.inherited[key]
+	if len(lns) == 0 {
+		return nil
+	}
+	r.inherited[key] = lns[1:]
+	return lns[0]
+}
+
+// track 记录监听器，热升级时传给子进程
+func (r *registry) track(ln net.Listener, network, address string) net.Listener {
+	t := &trackedListener{Listener: ln, network: network, address: address}
+	r.mu.Lock()
+	r.active[t] = struct{}{}
+	r.mu.Unlock()
+	return t
+}
+
+// Inherited 当前进程是否由热升级启动
+func Inherited() bool {
+	defaultRegistry.load()
+	return defaultRegistry.ready != nil
+}
+
+// Ready 通知父进程子进程已经就绪，父进程收到后开始停止；非热升级启动时不做任何事
+//
+// 应在所有监听器创建完成、组件启动成功后调用，未被取用的继承监听器和 systemd 传入的监听器会被关闭
+func Ready() error {
+	for _, ln := range defaultActivation.drain() {
+		_ = ln.Close()
+	}
+
+	r := defaultRegistry
+	r.load()
+	r.mu.Lock()
+	ready := r.ready
+	r.ready = nil
+	unused := r.inherited
+	r.inherited = make(map[string][]net.Listener)
+	r.mu.Unlock()
+
+	for _, lns := range unused {
+		for _, ln := range lns {
+			_ = ln.Close()
+		}
+	}
+	if ready == nil {
+		return nil
+	}
+	_, err := ready.Write([]byte{1})
+	if e := ready.Close(); err == nil {
+		err = e
+	}
+	return err
+}
+
+// filer 可以导出 fd 的监听器
+type filer interface {
+	File() (*os.File, error)
+}
+
+// Upgrade 以相同的参数启动新版本的可执行文件，并把所有通过 Listen 创建的监听套接字传给它
+//
+// 子进程中 Listen 以相同的 network 和 address 调用时直接复用继承的套接字，调用 Ready 后 Upgrade 返回，
+// 之后父进程应停止接受新连接、处理完进行中的请求后退出；ctx 结束前子进程没有就绪时杀死子进程并返回错误
+func Upgrade(ctx context.Context) (*os.Process, error) {
+	if runtime.GOOS == "windows" {
+		return nil, ErrUpgradeUnsupported
+	}
+	r := defaultRegistry
+	r.mu.Lock()
+	if r.upgrading {
+		r.mu.Unlock()
+		return nil, ErrUpgradeInProgress
+	}
+	r.upgrading = true
+	lns := make([]*trackedListener, 0, len(r.active))
+	for ln := range r.active {
+		lns = append(lns, ln)
+	}
+	r.mu.Unlock()
+	defer func() {
+		r.mu.Lock()
+		r.upgrading = false
+		r.mu.Unlock()
+	}()
+
+	exe, err := os.Executable()
+	if err != nil {
+		return nil, err
+	}
+
+	files := make([]*os.File, 0, len(lns)+1)
+	defer func() {
+		for _, f := range files {
+			_ = f.Close()
+		}
+	}()
+	keys := make([]string, 0, len(lns))
+	for _, ln := range lns {
+		inner := ln.Listener
+		if nl, ok := inner.(*namedListener); ok {
+			inner = nl.Listener
+		}
+		fl, ok := inner.(filer)
+		if !ok {
+			continue
+		}
+		// 子进程接管 unix 套接字文件，父进程关闭监听器时不能删除它
+		if ul, ok := inner.(*net.UnixListener); ok {
+			ul.SetUnlinkOnClose(false)
+		}
+		f, err := fl.File()
+		if err != nil {
+			return nil, fmt.Errorf("listener: export %s %s: %w", ln.network, ln.address, err)
+		}
+		files = append(files, f)
+		keys = append(keys, inheritKey(ln.network, ln.address))
+	}
+
+	pr, pw, err := os.Pipe()
+	if err != nil {
+		return nil, err
+	}
+	defer pr.Close()
+	files = append(files, pw)
+
+	cmd := exec.Command(exe, os.Args[1:]...)
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, os.Stdout, os.Stderr
+	cmd.ExtraFiles = files
+	cmd.Env = append(upgradeEnv(os.Environ()),
+		envInheritFDs+"="+strings.Join(keys, ","),
+		envReadyFD+"="+strconv.Itoa(listenFDsStart+len(keys)))
+	if err = cmd.Start(); err != nil {
+		return nil, err
+	}
+	// 父进程关闭写端，子进程退出时读端返回 EOF
+	_ = pw.Close()
+	files = files[:len(files)-1]
+
+	ready := make(chan error, 1)
+	go func() {
+		buf := make([]byte, 1)
+		if _, err := io.ReadFull(pr, buf); err != nil {
+			ready <- ErrChildNotReady
+			return
+		}
+		ready <- nil
+	}()
+	select {
+	case err = <-ready:
+	case <-ctx.Done():
+		err = ctx.Err()
+	}
+	if err != nil {
+		_ = cmd.Process.Kill()
+		_ = cmd.Wait()
+		return nil, err
+	}
+	// 子进程独立运行，回收由 init 进程负责
+	_ = cmd.Process.Release()
+	return cmd.Process, nil
+}
+
+// upgradeEnv 去掉上一次热升级和 systemd socket activation 遗留的环境变量
+func upgradeEnv(env []string) []string {
+	ret := make([]string, 0, len(env))
+	for _, kv := range env {
+		switch {
+		case strings.HasPrefix(kv, envInheritFDs+"="), strings.HasPrefix(kv, envReadyFD+"="),
+			strings.HasPrefix(kv, envListenPID+"="), strings.HasPrefix(kv, envListenFDs+"="),
+			strings.HasPrefix(kv, envListenFDNames+"="):
+			continue
+		}
+		ret = append(ret, kv)
+	}
+	return ret
+}
@@ -0,0 +1,95 @@
+package listener
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"os"
+	"runtime"
+	"testing"
+	"time"
+)
+
+// 子进程模式的环境变量，值为父进程监听的实际地址
+const envTestChild = "NMQ_TEST_UPGRADE_CHILD"
+
+func TestMain(m *testing.M) {
+	if addr := os.Getenv(envTestChild); addr != "" {
+		os.Exit(upgradeChild(addr))
+	}
+	os.Exit(m.Run())
+}
+
+// upgradeChild 以热升级子进程运行：复用继承的套接字，就绪后处理一个连接并退出
+func upgradeChild(addr string) int {
+	if !Inherited() {
+		return 2
+	}
+	ln, err := Listen("tcp", "127.0.0.1:0", nil)
+	if err != nil || ln.Addr().String() != addr {
+		return 3
+	}
+	if err = Ready(); err != nil {
+		return 4
+	}
+	_ = ln.(*trackedListener).Listener.(*net.TCPListener).SetDeadline(time.Now().Add(5 * time.Second))
+	conn, err := ln.Accept()
+	if err != nil {
+		return 5
+	}
+	_, _ = conn.Write([]byte("child"))
+	_ = conn.Close()
+	return 0
+}
+
+func TestUpgrade(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("upgrade is not supported on windows")
+	}
+	ln, err := Listen("tcp", "127.0.0.1:0", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := ln.Addr().String()
+	t.Setenv(envTestChild, addr)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if _, err = Upgrade(ctx); err != nil {
+		t.Fatal(err)
+	}
+	// 父进程关闭自己的监听器后，新连接由子进程处理
+	_ = ln.Close()
+
+	conn, err := net.DialTimeout("tcp", addr, time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	_ = conn.SetDeadline(time.Now().Add(5 * time.Second))
+	got, err := io.ReadAll(conn)
+	if err != nil || string(got) != "child" {
+		t.Errorf("Expected reply from child, got %q %v", got, err)
+	}
+}
+
+func TestUpgradeChildNotReady(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("upgrade is not supported on windows")
+	}
+	// 子进程在就绪前退出
+	t.Setenv(envTestChild, "unexpected")
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if _, err := Upgrade(ctx); !errors.Is(err, ErrChildNotReady) {
+		t.Errorf("Expected ErrChildNotReady, got %v", err)
+	}
+}
+
+func TestUpgradeEnv(t *testing.T) {
+	env := upgradeEnv([]string{"PATH=/bin", envInheritFDs + "=tcp|:80", envReadyFD + "=4", envListenFDs + "=1"})
+	if len(env) != 1 || env[0] != "PATH=/bin" {
+		t.Errorf("Unexpected env %v", env)
+	}
+}
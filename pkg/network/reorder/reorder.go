@@ -0,0 +1,274 @@
+// Package reorder 提供接收端的重排序缓冲区
+//
+// UDP、QUIC 等不保证顺序的传输层按流创建一个 Buffer，收到的消息带上发送端的序号调用 Push，
+// Buffer 按序号连续地交付消息；乱序到达的消息最多缓存 Window 个序号或 Timeout 时间，
+// 超过限制仍未到达的序号作为 Gap 事件上报后跳过
+package reorder
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/andrewbytecoder/nmq/pkg/options"
+)
+
+// ErrClosed 缓冲区已经关闭
+var ErrClosed = errors.New("reorder: buffer closed")
+
+// Gap 被跳过的连续序号区间 [From, To]
+type Gap struct {
+	From uint64
+	To   uint64
+}
+
+// Len 区间内的序号个数
+func (g Gap) Len() uint64 {
+	return g.To - g.From + 1
+}
+
+// Stats 缓冲区统计
+type Stats struct {
+	Delivered  uint64 // 已交付的消息数
+	Missing    uint64 // 作为 Gap 跳过的序号数
+	Duplicates uint64 // 序号已交付或已跳过被丢弃的消息数
+	Pending    int    // 缓冲区中等待交付的消息数
+	Next       uint64 // 下一个等待交付的序号
+}
+
+// Config 重排序缓冲区配置
+type Config struct {
+	Window  int           // 最多缓存的序号跨度，超过时跳过最早缺失的序号
+	Timeout time.Duration // 缺失的序号最多等待的时间，为0时只按 Window 跳过
+	Initial uint64        // 第一个消息的序号
+	OnGap   func(Gap)     // 跳过缺失的序号时调用，在跳过之后的消息交付之前调用
+}
+
+// SetWindow 设置最多缓存的序号跨度
+func SetWindow(window int) options.Option {
+	return func(c any) {
+		if c, ok := c.(*Config); ok {
+			c.Window = window
+		}
+	}
+}
+
+// SetTimeout 设置缺失的序号最多等待的时间
+func SetTimeout(timeout time.Duration) options.Option {
+	return func(c any) {
+		if c, ok := c.(*Config); ok {
+			c.Timeout = timeout
+		}
+	}
+}
+
+// SetInitial 设置第一个消息的序号
+func SetInitial(seq uint64) options.Option {
+	return func(c any) {
+		if c, ok := c.(*Config); ok {
+			c.Initial = seq
+		}
+	}
+}
+
+// SetOnGap 设置跳过缺失序号时的回调
+func SetOnGap(fn func(Gap)) options.Option {
+	return func(c any) {
+		if c, ok := c.(*Config); ok {
+			c.OnGap = fn
+		}
+	}
+}
+
+// NewConfig 创建重排序缓冲区配置，默认窗口为 256，等待 100ms
+func NewConfig(opts ...options.Option) *Config {
+	c := &Config{Window: 256, Timeout: 100 * time.Millisecond}
+	for _, opt := range opts {
+		opt(c)
+	}
+	if c.Window <= 0 {
+		c.Window = 1
+	}
+	return c
+}
+
+// entry 缓冲区中等待交付的消息
+type entry[T any] struct {
+	val T
+	at  time.Time // 到达时间
+}
+
+// Buffer 单个流的重排序缓冲区，可以并发调用
+//
+// deliver 和 OnGap 在持有缓冲区锁时按顺序调用，不能在回调中调用同一个 Buffer 的方法
+type Buffer[T any] struct {
+	mu      sync.Mutex
+	cfg     *Config
+	deliver func(seq uint64, v T)
+	next    uint64
+	pending map[uint64]entry[T]
+	timer   *time.Timer
+	closed  bool
+
+	delivered  uint64
+	missing    uint64
+	duplicates uint64
+}
+
+// NewBuffer 创建重排序缓冲区，消息按序号连续地交付给 deliver
+func NewBuffer[T any](deliver func(seq uint64, v T), cfg *Config) *Buffer[T] {
+	if cfg == nil {
+		cfg = NewConfig()
+	}
+	return &Buffer[T]{
+		cfg:     cfg,
+		deliver: deliver,
+		next:    cfg.Initial,
+		pending: make(map[uint64]entry[T]),
+	}
+}
+
+// Push 接收序号为 seq 的消息，按序到达时立即交付，已交付或已跳过的序号被丢弃
+func (b *Buffer[T]) Push(seq uint64, v T) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.closed {
+		return ErrClosed
+	}
+	if _, ok := b.pending[seq]; ok || seq < b.next {
+		b.duplicates++
+		return nil
+	}
+
+	if seq == b.next {
+		b.emit(seq, v)
+		b.flush()
+	} else {
+		b.pending[seq] = entry[T]{val: v, at: time.Now()}
+		// 超出窗口时跳过最早的序号，使 seq 落在窗口内
+		if window := uint64(b.cfg.Window); seq-b.next >= window {
+			b.advance(seq - window + 1)
+		}
+	}
+	b.schedule()
+	return nil
+}
+
+// Stats 返回缓冲区统计
+func (b *Buffer[T]) Stats() Stats {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return Stats{
+		Delivered:  b.delivered,
+		Missing:    b.missing,
+		Duplicates: b.duplicates,
+		Pending:    len(b.pending),
+		Next:       b.next,
+	}
+}
+
+// Close 跳过所有缺失的序号交付缓冲区中剩余的消息，之后 Push 返回 ErrClosed，可重复调用
+func (b *Buffer[T]) Close() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.closed {
+		return
+	}
+	b.closed = true
+	if b.timer != nil {
+		b.timer.Stop()
+		b.timer = nil
+	}
+	for len(b.pending) > 0 {
+		b.advance(b.lowest())
+	}
+}
+
+// emit 交付消息并推进下一个序号 调用方需持有 b.mu
+func (b *Buffer[T]) emit(seq uint64, v T) {
+	b.delivered++
+	b.next = seq + 1
+	b.deliver(seq, v)
+}
+
+// flush 交付缓冲区中从 next 开始连续的消息 调用方需持有 b.mu
+func (b *Buffer[T]) flush() {
+	for {
+		e, ok := b.pending[b.next]
+		if !ok {
+			return
+		}
+		delete(b.pending, b.next)
+		b.emit(b.next, e.val)
+	}
+}
+
+// advance 将 next 推进到 target，交付其间已到达的消息并上报缺失的区间 调用方需持有 b.mu
+func (b *Buffer[T]) advance(target uint64) {
+	for b.next < target {
+		if e, ok := b.pending[b.next]; ok {
+			delete(b.pending, b.next)
+			b.emit(b.next, e.val)
+			continue
+		}
+		gap := Gap{From: b.next, To: b.next}
+		for gap.To+1 < target {
+			if _, ok := b.pending[gap.To+1]; ok {
+				break
+			}
+			gap.To++
+		}
+		b.missing += gap.Len()
+		b.next = gap.To + 1
+		if b.cfg.OnGap != nil {
+			b.cfg.OnGap(gap)
+		}
+	}
+	b.flush()
+}
+
+// lowest 返回缓冲区中最小的序号 调用方需持有 b.mu 并保证缓冲区不为空
+func (b *Buffer[T]) lowest() uint64 {
+	first := true
+	var low uint64
+	for seq := range b.pending {
+		if first || seq < low {
+			low, first = seq, false
+		}
+	}
+	return low
+}
+
+// schedule 缓冲区不为空时按最早到达的消息设置超时 调用方需持有 b.mu
+func (b *Buffer[T]) schedule() {
+	if b.cfg.Timeout <= 0 || len(b.pending) == 0 || b.timer != nil {
+		return
+	}
+	b.timer = time.AfterFunc(b.cfg.Timeout-time.Since(b.oldest()), b.expire)
+}
+
+// oldest 返回缓冲区中最早到达的时间，即 next 开始缺失的时间 调用方需持有 b.mu
+func (b *Buffer[T]) oldest() time.Time {
+	var oldest time.Time
+	for _, e := range b.pending {
+		if oldest.IsZero() || e.at.Before(oldest) {
+			oldest = e.at
+		}
+	}
+	return oldest
+}
+
+// expire 缺失的序号等待超时后跳过，交付之后已到达的连续消息
+func (b *Buffer[T]) expire() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.timer = nil
+	if b.closed {
+		return
+	}
+	now := time.Now()
+	for len(b.pending) > 0 && now.Sub(b.oldest()) >= b.cfg.Timeout {
+		b.advance(b.lowest())
+	}
+	b.schedule()
+}
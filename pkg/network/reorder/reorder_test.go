@@ -0,0 +1,117 @@
+package reorder
+
+import (
+	"errors"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/andrewbytecoder/nmq/pkg/options"
+)
+
+// recorder 记录交付的序号和跳过的区间
+type recorder struct {
+	mu   sync.Mutex
+	seqs []uint64
+	gaps []Gap
+}
+
+func (r *recorder) deliver(seq uint64, v string) {
+	r.mu.Lock()
+	r.seqs = append(r.seqs, seq)
+	r.mu.Unlock()
+}
+
+func (r *recorder) gap(g Gap) {
+	r.mu.Lock()
+	r.gaps = append(r.gaps, g)
+	r.mu.Unlock()
+}
+
+func (r *recorder) snapshot() ([]uint64, []Gap) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]uint64(nil), r.seqs...), append([]Gap(nil), r.gaps...)
+}
+
+func newRecorder(opts ...options.Option) (*recorder, *Buffer[string]) {
+	r := &recorder{}
+	opts = append(opts, SetOnGap(r.gap))
+	return r, NewBuffer(r.deliver, NewConfig(opts...))
+}
+
+func TestBufferReorder(t *testing.T) {
+	r, b := newRecorder(SetTimeout(0))
+	for _, seq := range []uint64{2, 0, 3, 1, 1, 0, 4} {
+		if err := b.Push(seq, ""); err != nil {
+			t.Fatal(err)
+		}
+	}
+	seqs, gaps := r.snapshot()
+	if want := []uint64{0, 1, 2, 3, 4}; !reflect.DeepEqual(seqs, want) || len(gaps) != 0 {
+		t.Errorf("Expected %v without gaps, got %v %v", want, seqs, gaps)
+	}
+	if st := b.Stats(); st.Delivered != 5 || st.Duplicates != 2 || st.Pending != 0 || st.Next != 5 {
+		t.Errorf("Unexpected stats: %+v", st)
+	}
+}
+
+func TestBufferWindow(t *testing.T) {
+	r, b := newRecorder(SetWindow(4), SetTimeout(0), SetInitial(10))
+	// 窗口为4：14 到达时跳过 10 交付 11，16 到达时跳过 12 交付 13 到 16
+	for _, seq := range []uint64{11, 13, 14, 15, 16} {
+		_ = b.Push(seq, "")
+	}
+	seqs, gaps := r.snapshot()
+	if want := []uint64{11, 13, 14, 15, 16}; !reflect.DeepEqual(seqs, want) {
+		t.Errorf("Expected %v, got %v", want, seqs)
+	}
+	if want := []Gap{{10, 10}, {12, 12}}; !reflect.DeepEqual(gaps, want) {
+		t.Errorf("Expected gaps %v, got %v", want, gaps)
+	}
+
+	// 远超窗口的序号跳过整个区间
+	_ = b.Push(100, "")
+	if _, gaps = r.snapshot(); gaps[len(gaps)-1] != (Gap{17, 96}) {
+		t.Errorf("Unexpected gaps: %v", gaps)
+	}
+	if st := b.Stats(); st.Missing != 82 || st.Pending != 1 || st.Next != 97 {
+		t.Errorf("Unexpected stats: %+v", st)
+	}
+}
+
+func TestBufferTimeout(t *testing.T) {
+	r, b := newRecorder(SetTimeout(20 * time.Millisecond))
+	_ = b.Push(0, "")
+	_ = b.Push(2, "")
+	_ = b.Push(3, "")
+	_ = b.Push(5, "")
+
+	deadline := time.Now().Add(2 * time.Second)
+	for b.Stats().Pending > 0 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	seqs, gaps := r.snapshot()
+	if want := []uint64{0, 2, 3, 5}; !reflect.DeepEqual(seqs, want) {
+		t.Errorf("Expected %v, got %v", want, seqs)
+	}
+	if want := []Gap{{1, 1}, {4, 4}}; !reflect.DeepEqual(gaps, want) {
+		t.Errorf("Expected gaps %v, got %v", want, gaps)
+	}
+}
+
+func TestBufferClose(t *testing.T) {
+	r, b := newRecorder(SetTimeout(time.Hour))
+	_ = b.Push(1, "")
+	_ = b.Push(3, "")
+	b.Close()
+	b.Close()
+	seqs, gaps := r.snapshot()
+	if want := []uint64{1, 3}; !reflect.DeepEqual(seqs, want) || len(gaps) != 2 {
+		t.Errorf("Unexpected result: %v %v", seqs, gaps)
+	}
+	if err := b.Push(4, ""); !errors.Is(err, ErrClosed) {
+		t.Errorf("Expected ErrClosed, got %v", err)
+	}
+}
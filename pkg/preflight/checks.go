@@ -0,0 +1,130 @@
+package preflight
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"time"
+
+	"github.com/andrewbytecoder/nmq/pkg/check"
+)
+
+var (
+	ErrFailed       = errors.New("preflight: checks failed")
+	ErrClock        = errors.New("preflight: system clock out of range")
+	ErrUnreachable  = errors.New("preflight: address unreachable")
+	ErrFileLimit    = errors.New("preflight: open file limit too low")
+	ErrNotDirectory = errors.New("preflight: not a directory")
+)
+
+// PortFree 检查 TCP 地址可以监听
+func PortFree(addr string, severity Severity) Check {
+	return Check{
+		Name:     "port " + addr,
+		Severity: severity,
+		Run: func(ctx context.Context) error {
+			var lc net.ListenConfig
+			ln, err := lc.Listen(ctx, "tcp", addr)
+			if err != nil {
+				return err
+			}
+			return ln.Close()
+		},
+	}
+}
+
+// Writable 通过创建并删除临时文件检查目录可写
+func Writable(dir string, severity Severity) Check {
+	return Check{
+		Name:     "writable " + dir,
+		Severity: severity,
+		Run: func(ctx context.Context) error {
+			fi, err := os.Stat(dir)
+			if err != nil {
+				return err
+			}
+			if !fi.IsDir() {
+				return fmt.Errorf("%w: %s", ErrNotDirectory, dir)
+			}
+			f, err := os.CreateTemp(dir, ".nmq-preflight-*")
+			if err != nil {
+				return err
+			}
+			name := f.Name()
+			_, err = f.Write([]byte("ok"))
+			if e := f.Close(); err == nil {
+				err = e
+			}
+			if e := os.Remove(name); err == nil {
+				err = e
+			}
+			return err
+		},
+	}
+}
+
+// ClockSane 检查系统时间不早于 min，min 通常取构建时间，用于发现未同步的时钟导致证书和过期时间判断出错
+//
+// max 大于0时同时检查系统时间不晚于 min 之后 max
+func ClockSane(min time.Time, max time.Duration, severity Severity) Check {
+	return Check{
+		Name:     "clock",
+		Severity: severity,
+		Run: func(ctx context.Context) error {
+			now := time.Now()
+			if now.Before(min) {
+				return fmt.Errorf("%w: %s is before %s", ErrClock, now.Format(time.RFC3339), min.Format(time.RFC3339))
+			}
+			if max > 0 && now.After(min.Add(max)) {
+				return fmt.Errorf("%w: %s is after %s", ErrClock, now.Format(time.RFC3339), min.Add(max).Format(time.RFC3339))
+			}
+			return nil
+		},
+	}
+}
+
+// FileLimit 检查进程可打开的文件数不少于 min，不支持查询的平台上总是通过
+func FileLimit(min uint64, severity Severity) Check {
+	return Check{
+		Name:     "ulimit nofile",
+		Severity: severity,
+		Run: func(ctx context.Context) error {
+			cur, ok, err := fileLimit()
+			if err != nil || !ok {
+				return err
+			}
+			if cur < min {
+				return fmt.Errorf("%w: %d < %d", ErrFileLimit, cur, min)
+			}
+			return nil
+		},
+	}
+}
+
+// Reachable 通过 check.Monitor 检查依赖可以连接，target 支持 host:port、tls://host:port、http(s)://host[:port]
+//
+// m 为 nil 时使用一次性的 Monitor；传入共享的 Monitor 时检查结果会被缓存，后续由 Monitor 在后台刷新
+func Reachable(m *check.Monitor, target string, severity Severity) Check {
+	return Check{
+		Name:     "reachable " + target,
+		Severity: severity,
+		Run: func(ctx context.Context) error {
+			monitor := m
+			if monitor == nil {
+				monitor = check.NewMonitor()
+			}
+			r := monitor.Refresh(ctx, target)
+			if r.Err != nil {
+				return fmt.Errorf("%w: %v", ErrUnreachable, r.Err)
+			}
+			return nil
+		},
+	}
+}
+
+// Func 将普通函数包装为检查
+func Func(name string, severity Severity, fn func(ctx context.Context) error) Check {
+	return Check{Name: name, Severity: severity, Run: fn}
+}
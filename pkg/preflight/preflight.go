@@ -0,0 +1,144 @@
+package preflight
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/andrewbytecoder/nmq/pkg/recovery"
+)
+
+// Severity 检查失败时的处理方式
+type Severity int
+
+const (
+	SeverityWarn  Severity = iota // 失败时只记录警告，继续启动
+	SeverityFatal                 // 失败时终止启动
+)
+
+func (s Severity) String() string {
+	switch s {
+	case SeverityWarn:
+		return "warn"
+	case SeverityFatal:
+		return "fatal"
+	default:
+		return fmt.Sprintf("severity(%d)", int(s))
+	}
+}
+
+// Check 一项启动前检查
+type Check struct {
+	Name     string                          // 检查名称，出现在报告中
+	Severity Severity                        // 检查失败时的处理方式
+	Run      func(ctx context.Context) error // 执行检查，返回错误表示检查未通过
+}
+
+// Result 单项检查的结果
+type Result struct {
+	Name     string
+	Severity Severity
+	Err      error
+	Duration time.Duration
+}
+
+// Passed 检查是否通过
+func (r Result) Passed() bool {
+	return r.Err == nil
+}
+
+// Report 所有检查的结果，按名称排序
+type Report struct {
+	Results []Result
+}
+
+// Passed 没有失败的 SeverityFatal 检查时返回 true，失败的 SeverityWarn 检查不影响结果
+func (r Report) Passed() bool {
+	return len(r.Failures(SeverityFatal)) == 0
+}
+
+// Failures 返回指定级别的失败检查
+func (r Report) Failures(severity Severity) []Result {
+	var ret []Result
+	for _, res := range r.Results {
+		if !res.Passed() && res.Severity == severity {
+			ret = append(ret, res)
+		}
+	}
+	return ret
+}
+
+// Err 汇总失败的 SeverityFatal 检查，全部通过时返回 nil
+func (r Report) Err() error {
+	failures := r.Failures(SeverityFatal)
+	if len(failures) == 0 {
+		return nil
+	}
+	msgs := make([]string, len(failures))
+	for i, res := range failures {
+		msgs[i] = res.Name + ": " + res.Err.Error()
+	}
+	return fmt.Errorf("%w: %s", ErrFailed, strings.Join(msgs, "; "))
+}
+
+// String 以每行一项的形式输出报告
+func (r Report) String() string {
+	var b strings.Builder
+	for _, res := range r.Results {
+		status := "PASS"
+		if !res.Passed() {
+			status = "FAIL"
+		}
+		fmt.Fprintf(&b, "[%s] %-5s %s (%s)", status, res.Severity, res.Name, res.Duration.Round(time.Millisecond))
+		if res.Err != nil {
+			fmt.Fprintf(&b, ": %v", res.Err)
+		}
+		b.WriteByte('\n')
+	}
+	return b.String()
+}
+
+// Run 并发执行所有检查，每项检查的耗时受 timeout 限制，timeout 小于等于0时不限制
+//
+// 检查中的 panic 视为检查失败
+func Run(ctx context.Context, timeout time.Duration, checks ...Check) Report {
+	results := make([]Result, len(checks))
+	var wg sync.WaitGroup
+	for i, c := range checks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results[i] = run(ctx, timeout, c)
+		}()
+	}
+	wg.Wait()
+
+	sort.SliceStable(results, func(i, j int) bool { return results[i].Name < results[j].Name })
+	return Report{Results: results}
+}
+
+// run 执行单项检查，超时后不再等待检查返回
+func run(ctx context.Context, timeout time.Duration, c Check) Result {
+	res := Result{Name: c.Name, Severity: c.Severity}
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	start := time.Now()
+	done := make(chan error, 1)
+	go func() {
+		done <- recovery.Default.Do("preflight."+c.Name, nil, func() error { return c.Run(ctx) })
+	}()
+	select {
+	case res.Err = <-done:
+	case <-ctx.Done():
+		res.Err = ctx.Err()
+	}
+	res.Duration = time.Since(start)
+	return res
+}
@@ -0,0 +1,85 @@
+package preflight
+
+import (
+	"context"
+	"errors"
+	"net"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRun(t *testing.T) {
+	report := Run(context.Background(), 50*time.Millisecond,
+		Func("ok", SeverityFatal, func(context.Context) error { return nil }),
+		Func("warn", SeverityWarn, func(context.Context) error { return errors.New("low disk") }),
+		Func("slow", SeverityWarn, func(ctx context.Context) error {
+			<-ctx.Done()
+			time.Sleep(time.Second)
+			return nil
+		}),
+		Func("panic", SeverityWarn, func(context.Context) error { panic("boom") }),
+	)
+	if len(report.Results) != 4 || report.Results[0].Name != "ok" {
+		t.Fatalf("Unexpected report: %+v", report)
+	}
+	if !report.Passed() || report.Err() != nil {
+		t.Errorf("Expected warnings not to fail the report, got %v", report.Err())
+	}
+	if got := len(report.Failures(SeverityWarn)); got != 3 {
+		t.Errorf("Expected 3 warnings, got %d", got)
+	}
+	for _, res := range report.Results {
+		if res.Name == "slow" && !errors.Is(res.Err, context.DeadlineExceeded) {
+			t.Errorf("Expected timeout, got %v", res.Err)
+		}
+	}
+
+	report = Run(context.Background(), 0,
+		Func("db", SeverityFatal, func(context.Context) error { return errors.New("refused") }))
+	if report.Passed() || !errors.Is(report.Err(), ErrFailed) || !strings.Contains(report.Err().Error(), "db: refused") {
+		t.Errorf("Expected fatal failure, got %v", report.Err())
+	}
+	if !strings.Contains(report.String(), "[FAIL] fatal db") {
+		t.Errorf("Unexpected report string: %q", report.String())
+	}
+}
+
+func TestChecks(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	addr := ln.Addr().String()
+
+	dir := t.TempDir()
+	report := Run(context.Background(), time.Second,
+		PortFree(addr, SeverityFatal),
+		PortFree("127.0.0.1:0", SeverityFatal),
+		Writable(dir, SeverityFatal),
+		Writable(dir+"/missing", SeverityFatal),
+		ClockSane(time.Now().Add(-time.Hour), 0, SeverityFatal),
+		ClockSane(time.Now().Add(time.Hour), 0, SeverityWarn),
+		FileLimit(1, SeverityFatal),
+		Reachable(nil, addr, SeverityFatal),
+	)
+	failed := make(map[string]error)
+	for _, res := range report.Results {
+		if !res.Passed() {
+			failed[res.Name] = res.Err
+		}
+	}
+	if len(failed) != 3 {
+		t.Fatalf("Unexpected failures: %v", failed)
+	}
+	if _, ok := failed["port "+addr]; !ok {
+		t.Error("Expected port in use to fail")
+	}
+	if _, ok := failed["writable "+dir+"/missing"]; !ok {
+		t.Error("Expected missing dir to fail")
+	}
+	if err := failed["clock"]; !errors.Is(err, ErrClock) {
+		t.Errorf("Expected ErrClock, got %v", err)
+	}
+}
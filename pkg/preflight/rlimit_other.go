@@ -0,0 +1,8 @@
+//go:build !unix
+
+package preflight
+
+// fileLimit 当前平台不支持查询可打开的文件数
+func fileLimit() (uint64, bool, error) {
+	return 0, false, nil
+}
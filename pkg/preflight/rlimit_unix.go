@@ -0,0 +1,14 @@
+//go:build unix
+
+package preflight
+
+import "syscall"
+
+// fileLimit 返回进程当前可打开的文件数
+func fileLimit() (uint64, bool, error) {
+	var rl syscall.Rlimit
+	if err := syscall.Getrlimit(syscall.RLIMIT_NOFILE, &rl); err != nil {
+		return 0, false, err
+	}
+	return uint64(rl.Cur), true, nil
+}
@@ -0,0 +1,122 @@
+package ratelimit
+
+import (
+	"context"
+	"io"
+	"math"
+	"sync"
+	"time"
+)
+
+// Bucket 令牌桶，按数量（通常为字节数）限速，速率可以在运行时调整，速率小于等于0时不限速
+//
+// 一次取走的令牌数可以超过桶容量，超出部分记为欠账，由之后的等待偿还，
+// 因此大块写入不会被拒绝，只是平均速率不超过设置的速率
+type Bucket struct {
+	mu     sync.Mutex
+	rate   float64 // 每秒产生的令牌数
+	burst  float64 // 桶容量
+	tokens float64 // 当前令牌数，欠账时为负数
+	last   time.Time
+}
+
+// NewBucket 创建每秒产生 rate 个令牌、容量为 burst 的令牌桶，burst 小于等于0时等于 rate
+func NewBucket(rate, burst int) *Bucket {
+	b := &Bucket{}
+	b.SetRate(rate, burst)
+	b.tokens = b.burst
+	return b
+}
+
+// SetRate 调整速率和容量，立即对之后的 WaitN 生效，burst 小于等于0时等于 rate
+func (b *Bucket) SetRate(rate, burst int) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.refill(time.Now())
+	if burst <= 0 {
+		burst = rate
+	}
+	b.rate, b.burst = float64(max(rate, 0)), float64(max(burst, 0))
+	b.tokens = math.Min(b.tokens, b.burst)
+}
+
+// Rate 返回当前的速率和容量
+func (b *Bucket) Rate() (rate, burst int) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return int(b.rate), int(b.burst)
+}
+
+// refill 按经过的时间补充令牌 调用方需持有 b.mu
+func (b *Bucket) refill(now time.Time) {
+	if !b.last.IsZero() && b.rate > 0 {
+		b.tokens = math.Min(b.burst, b.tokens+now.Sub(b.last).Seconds()*b.rate)
+	}
+	b.last = now
+}
+
+// WaitN 取走 n 个令牌，令牌不足时等待，ctx 结束时归还令牌并返回 ctx 的错误
+func (b *Bucket) WaitN(ctx context.Context, n int) error {
+	if n <= 0 {
+		return nil
+	}
+	b.mu.Lock()
+	if b.rate <= 0 {
+		b.mu.Unlock()
+		return nil
+	}
+	b.refill(time.Now())
+	b.tokens -= float64(n)
+	var wait time.Duration
+	if b.tokens < 0 {
+		wait = time.Duration(-b.tokens / b.rate * float64(time.Second))
+	}
+	b.mu.Unlock()
+	if wait <= 0 {
+		return nil
+	}
+
+	t := time.NewTimer(wait)
+	defer t.Stop()
+	select {
+	case <-t.C:
+		return nil
+	case <-ctx.Done():
+		b.mu.Lock()
+		b.tokens = math.Min(b.burst, b.tokens+float64(n))
+		b.mu.Unlock()
+		return ctx.Err()
+	}
+}
+
+// writer 写入前从令牌桶取走与写入字节数相同的令牌
+type writer struct {
+	ctx context.Context
+	w   io.Writer
+	b   *Bucket
+}
+
+// NewWriter 返回按 b 限速的 io.Writer，大块写入按桶容量拆分，ctx 结束后写入返回 ctx 的错误
+func NewWriter(ctx context.Context, w io.Writer, b *Bucket) io.Writer {
+	return &writer{ctx: ctx, w: w, b: b}
+}
+
+func (w *writer) Write(p []byte) (int, error) {
+	written := 0
+	for len(p) > 0 {
+		chunk := len(p)
+		if _, burst := w.b.Rate(); burst > 0 && chunk > burst {
+			chunk = burst
+		}
+		if err := w.b.WaitN(w.ctx, chunk); err != nil {
+			return written, err
+		}
+		n, err := w.w.Write(p[:chunk])
+		written += n
+		if err != nil {
+			return written, err
+		}
+		p = p[chunk:]
+	}
+	return written, nil
+}
@@ -0,0 +1,75 @@
+package ratelimit
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBucketWaitN(t *testing.T) {
+	b := NewBucket(1000, 100)
+
+	// 桶初始是满的，容量内的请求立即返回
+	start := time.Now()
+	assert.NoError(t, b.WaitN(context.Background(), 100))
+	assert.Less(t, time.Since(start), 50*time.Millisecond)
+
+	// 令牌耗尽后按速率等待
+	start = time.Now()
+	assert.NoError(t, b.WaitN(context.Background(), 100))
+	assert.GreaterOrEqual(t, time.Since(start), 80*time.Millisecond)
+}
+
+func TestBucketUnlimited(t *testing.T) {
+	b := NewBucket(0, 0)
+	start := time.Now()
+	for i := 0; i < 100; i++ {
+		assert.NoError(t, b.WaitN(context.Background(), 1<<20))
+	}
+	assert.Less(t, time.Since(start), 50*time.Millisecond)
+}
+
+func TestBucketSetRate(t *testing.T) {
+	b := NewBucket(10, 0)
+	rate, burst := b.Rate()
+	assert.Equal(t, 10, rate)
+	assert.Equal(t, 10, burst)
+
+	// 提高速率后欠账很快还清
+	assert.NoError(t, b.WaitN(context.Background(), 10))
+	b.SetRate(100000, 1000)
+	start := time.Now()
+	assert.NoError(t, b.WaitN(context.Background(), 1000))
+	assert.Less(t, time.Since(start), 100*time.Millisecond)
+
+	b.SetRate(0, 0)
+	assert.NoError(t, b.WaitN(context.Background(), 1<<30))
+}
+
+func TestBucketCancel(t *testing.T) {
+	b := NewBucket(1, 1)
+	assert.NoError(t, b.WaitN(context.Background(), 1))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	assert.ErrorIs(t, b.WaitN(ctx, 100), context.DeadlineExceeded)
+	assert.Less(t, time.Since(start), time.Second)
+}
+
+func TestWriter(t *testing.T) {
+	b := NewBucket(10000, 1000)
+	var buf bytes.Buffer
+	w := NewWriter(context.Background(), &buf, b)
+
+	start := time.Now()
+	n, err := w.Write(make([]byte, 3000))
+	assert.NoError(t, err)
+	assert.Equal(t, 3000, n)
+	assert.Equal(t, 3000, buf.Len())
+	// 首个容量内的块立即写入，剩余2000字节按速率等待
+	assert.GreaterOrEqual(t, time.Since(start), 150*time.Millisecond)
+}
@@ -0,0 +1,244 @@
+// Package recovery 提供统一的 panic 恢复，将各入口（HTTP处理函数、消息处理、协程池任务、连接回调）中的 panic
+// 转换为带调用栈的 PanicError，同时记录日志、上报指标，并可选地把脱敏后的请求内容保存下来用于复现
+package recovery
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httputil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"runtime/debug"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/andrewbytecoder/nmq/internal/prometheus"
+	"github.com/andrewbytecoder/nmq/pkg/options"
+	stdprometheus "github.com/prometheus/client_golang/prometheus"
+	"go.uber.org/zap"
+)
+
+// 默认保存的请求内容上限
+const defaultMaxPayload = 64 << 10
+
+// ErrPanic 所有 PanicError 都满足 errors.Is(err, ErrPanic)
+var ErrPanic = errors.New("recovery: panic")
+
+// panics 按入口统计恢复的 panic 次数
+var panics = prometheus.NewCounterFrom(stdprometheus.CounterOpts{
+	Namespace: "nmq",
+	Name:      "panics_recovered_total",
+	Help:      "Number of panics recovered, partitioned by entry point.",
+}, []string{"source"})
+
+// PanicError panic 转换后的错误
+type PanicError struct {
+	Source string      // 发生 panic 的入口
+	Value  interface{} // recover() 返回的值
+	Stack  []byte      // panic 时的调用栈
+}
+
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("recovery: panic in %s: %v", e.Source, e.Value)
+}
+
+// Unwrap panic 的值是 error 时返回该错误
+func (e *PanicError) Unwrap() error {
+	err, _ := e.Value.(error)
+	return err
+}
+
+// Is 使 errors.Is(err, ErrPanic) 成立
+func (e *PanicError) Is(target error) bool {
+	return target == ErrPanic
+}
+
+// Config 恢复配置
+type Config struct {
+	Logger     *zap.Logger         // 记录 panic 的日志，为nil时不记录
+	DumpDir    string              // 保存请求内容的目录，为空时不保存
+	MaxPayload int                 // 保存的请求内容上限（字节）
+	Redact     func([]byte) []byte // 保存前的脱敏函数，默认为 Redact
+	OnPanic    func(*PanicError)   // 每次恢复后调用
+}
+
+// SetLogger 设置记录 panic 的日志
+func SetLogger(logger *zap.Logger) options.Option {
+	return func(c any) {
+		if c, ok := c.(*Config); ok {
+			c.Logger = logger
+		}
+	}
+}
+
+// SetDumpDir 设置保存请求内容的目录，max <= 0 时使用默认上限64KB
+func SetDumpDir(dir string, max int) options.Option {
+	return func(c any) {
+		if c, ok := c.(*Config); ok {
+			c.DumpDir = dir
+			if max > 0 {
+				c.MaxPayload = max
+			}
+		}
+	}
+}
+
+// SetRedact 设置保存请求内容前的脱敏函数
+func SetRedact(redact func([]byte) []byte) options.Option {
+	return func(c any) {
+		if c, ok := c.(*Config); ok {
+			c.Redact = redact
+		}
+	}
+}
+
+// SetOnPanic 设置每次恢复后的回调，可以用于告警
+func SetOnPanic(fn func(*PanicError)) options.Option {
+	return func(c any) {
+		if c, ok := c.(*Config); ok {
+			c.OnPanic = fn
+		}
+	}
+}
+
+// NewConfig 创建恢复配置
+func NewConfig(opts ...options.Option) *Config {
+	c := &Config{
+		MaxPayload: defaultMaxPayload,
+		Redact:     Redact,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// Recoverer 按配置恢复 panic，可以在多个协程中共享
+type Recoverer struct {
+	cfg *Config
+}
+
+// Default 不记录日志、不保存请求内容的默认实例，只上报指标
+var Default = New()
+
+// New 创建 Recoverer
+func New(opts ...options.Option) *Recoverer {
+	return &Recoverer{cfg: NewConfig(opts...)}
+}
+
+// Recover 必须直接 defer 调用，发生 panic 时将其转换为 PanicError 写入 *err（err 为nil时丢弃）
+//
+//	defer r.Recover("mq.consumer", msg, &err)
+func (r *Recoverer) Recover(source string, payload []byte, err *error) {
+	v := recover()
+	if v == nil {
+		return
+	}
+	pe := r.handle(source, v, payload)
+	if err != nil {
+		*err = pe
+	}
+}
+
+// Do 执行 fn，fn 发生 panic 时返回 PanicError，payload 为 fn 处理的数据，用于复现
+func (r *Recoverer) Do(source string, payload []byte, fn func() error) (err error) {
+	defer r.Recover(source, payload, &err)
+	return fn()
+}
+
+// Wrap 返回发生 panic 时不会导致进程退出的 fn，用于协程池任务和回调
+func (r *Recoverer) Wrap(source string, fn func()) func() {
+	return func() {
+		defer r.Recover(source, nil, nil)
+		fn()
+	}
+}
+
+// Go 在新协程中执行 fn
+func (r *Recoverer) Go(source string, fn func()) {
+	go r.Wrap(source, fn)()
+}
+
+// Middleware 恢复HTTP处理函数中的 panic 并返回500，保存的请求内容只包含请求行和请求头
+//
+// http.ErrAbortHandler 是中断响应的约定用法，会继续向上抛出
+func (r *Recoverer) Middleware(source string, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		defer func() {
+			v := recover()
+			if v == nil {
+				return
+			}
+			if v == http.ErrAbortHandler {
+				panic(v)
+			}
+			var payload []byte
+			if r.cfg.DumpDir != "" {
+				payload, _ = httputil.DumpRequest(req, false)
+			}
+			r.handle(source, v, payload)
+			http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		}()
+		next.ServeHTTP(w, req)
+	})
+}
+
+// handle 记录一次恢复的 panic
+func (r *Recoverer) handle(source string, v interface{}, payload []byte) *PanicError {
+	pe := &PanicError{Source: source, Value: v, Stack: debug.Stack()}
+	panics.With("source", source).Add(1)
+
+	fields := []zap.Field{zap.String("source", source), zap.Any("panic", v), zap.ByteString("stack", pe.Stack)}
+	if payload != nil && r.cfg.DumpDir != "" {
+		path, err := r.dump(source, payload)
+		if err != nil {
+			fields = append(fields, zap.NamedError("dump_error", err))
+		} else {
+			fields = append(fields, zap.String("dump", path))
+		}
+	}
+	if r.cfg.Logger != nil {
+		r.cfg.Logger.Error("panic recovered", fields...)
+	}
+	if r.cfg.OnPanic != nil {
+		r.cfg.OnPanic(pe)
+	}
+	return pe
+}
+
+// dump 将脱敏并截断后的请求内容写入 DumpDir，返回文件路径
+func (r *Recoverer) dump(source string, payload []byte) (string, error) {
+	if r.cfg.Redact != nil {
+		payload = r.cfg.Redact(payload)
+	}
+	if r.cfg.MaxPayload > 0 && len(payload) > r.cfg.MaxPayload {
+		payload = payload[:r.cfg.MaxPayload]
+	}
+	if err := os.MkdirAll(r.cfg.DumpDir, 0o700); err != nil {
+		return "", err
+	}
+	name := "panic-" + sanitize(source) + "-" + strconv.FormatInt(time.Now().UnixNano(), 10) + ".dump"
+	path := filepath.Join(r.cfg.DumpDir, name)
+	return path, os.WriteFile(path, payload, 0o600)
+}
+
+// sanitize 将入口名称转换为可以用于文件名的字符串
+func sanitize(source string) string {
+	return strings.Map(func(r rune) rune {
+		if r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '-' || r == '_' || r == '.' {
+			return r
+		}
+		return '_'
+	}, source)
+}
+
+// secretPattern 匹配 JSON、表单、请求头中常见的敏感字段及其值
+var secretPattern = regexp.MustCompile(`(?i)("?(?:password|passwd|secret|token|api[_-]?key|authorization|cookie)"?\s*[:=]\s*)("[^"]*"|[^\s&,;]+(?:[ \t]+[^\s&,;]+)*)`)
+
+// Redact 默认的脱敏函数，将 password、token、authorization 等字段的值替换为 [REDACTED]
+func Redact(payload []byte) []byte {
+	return secretPattern.ReplaceAll(payload, []byte(`${1}[REDACTED]`))
+}
@@ -0,0 +1,86 @@
+package recovery
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestDo(t *testing.T) {
+	var got *PanicError
+	r := New(SetOnPanic(func(pe *PanicError) { got = pe }))
+
+	if err := r.Do("test", nil, func() error { return io.EOF }); err != io.EOF {
+		t.Errorf("Expected fn error to pass through, got %v", err)
+	}
+
+	err := r.Do("test", nil, func() error { panic("boom") })
+	var pe *PanicError
+	if !errors.As(err, &pe) || !errors.Is(err, ErrPanic) {
+		t.Fatalf("Expected PanicError, got %v", err)
+	}
+	if pe.Source != "test" || pe.Value != "boom" || len(pe.Stack) == 0 || got != pe {
+		t.Errorf("Unexpected PanicError: %+v", pe)
+	}
+
+	// panic 的值是 error 时可以通过 errors.Is 判断
+	err = r.Do("test", nil, func() error { panic(io.ErrUnexpectedEOF) })
+	if !errors.Is(err, io.ErrUnexpectedEOF) {
+		t.Errorf("Expected wrapped panic error, got %v", err)
+	}
+}
+
+func TestWrap(t *testing.T) {
+	done := make(chan *PanicError, 1)
+	r := New(SetOnPanic(func(pe *PanicError) { done <- pe }))
+	r.Go("worker", func() { panic("task failed") })
+	if pe := <-done; pe.Source != "worker" {
+		t.Errorf("Expected source worker, got %s", pe.Source)
+	}
+}
+
+func TestMiddlewareDump(t *testing.T) {
+	dir := t.TempDir()
+	r := New(SetDumpDir(dir, 0))
+	h := r.Middleware("http/api", http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		panic("handler failed")
+	}))
+
+	req := httptest.NewRequest(http.MethodPost, "/api?token=abc123", nil)
+	req.Header.Set("Authorization", "Bearer secret-value")
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	if rec.Code != http.StatusInternalServerError {
+		t.Errorf("Expected 500, got %d", rec.Code)
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil || len(entries) != 1 {
+		t.Fatalf("Expected one dump file, got %v, %v", entries, err)
+	}
+	if !strings.HasPrefix(entries[0].Name(), "panic-http_api-") {
+		t.Errorf("Unexpected dump file name %s", entries[0].Name())
+	}
+	data, _ := os.ReadFile(dir + "/" + entries[0].Name())
+	if strings.Contains(string(data), "secret-value") || strings.Contains(string(data), "abc123") {
+		t.Errorf("Expected dump to be redacted, got %q", data)
+	}
+	if !strings.Contains(string(data), "POST /api") {
+		t.Errorf("Expected request line in dump, got %q", data)
+	}
+}
+
+func TestRedact(t *testing.T) {
+	in := `{"user":"bob","password":"hunter2"} api_key=xyz&page=1`
+	out := string(Redact([]byte(in)))
+	if strings.Contains(out, "hunter2") || strings.Contains(out, "xyz") {
+		t.Errorf("Expected secrets to be redacted, got %s", out)
+	}
+	if !strings.Contains(out, `"user":"bob"`) || !strings.Contains(out, "page=1") {
+		t.Errorf("Expected other fields to be kept, got %s", out)
+	}
+}
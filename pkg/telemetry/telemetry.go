@@ -0,0 +1,241 @@
+// Package telemetry 提供可选的匿名使用情况上报，默认关闭
+//
+// 上报内容只包含版本、平台、启用的组件列表以及消息速率的数量级，
+// 不包含主机名、地址、配置或消息内容，用于帮助维护者确定功能优先级
+package telemetry
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"runtime"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/andrewbytecoder/nmq/pkg/httpclient"
+	"github.com/andrewbytecoder/nmq/pkg/options"
+	"go.uber.org/zap"
+)
+
+// 默认上报间隔和单次请求超时
+const (
+	defaultInterval = 24 * time.Hour
+	defaultTimeout  = 10 * time.Second
+)
+
+// Report 一次上报的内容
+type Report struct {
+	InstanceID  string   `json:"instance_id"`  // 进程启动时随机生成，不与主机关联
+	Version     string   `json:"version"`      // nmq 版本
+	GoVersion   string   `json:"go_version"`   // Go 版本
+	OS          string   `json:"os"`           // 操作系统
+	Arch        string   `json:"arch"`         // CPU 架构
+	Components  []string `json:"components"`   // 启用的组件名称
+	MessageRate string   `json:"message_rate"` // 每秒消息数的数量级，例如 "0"、"1+"、"100+"
+}
+
+// Config 上报配置
+type Config struct {
+	Enabled     bool            // 是否开启上报，默认关闭
+	Endpoint    string          // 上报地址，为空时不上报
+	Interval    time.Duration   // 上报间隔
+	Version     string          // nmq 版本
+	Components  func() []string // 获取启用的组件名称
+	MessageRate func() float64  // 获取当前每秒消息数
+}
+
+// SetEnabled 设置是否开启上报
+func SetEnabled(enabled bool) options.Option {
+	return func(c any) {
+		if c, ok := c.(*Config); ok {
+			c.Enabled = enabled
+		}
+	}
+}
+
+// SetEndpoint 设置上报地址
+func SetEndpoint(endpoint string) options.Option {
+	return func(c any) {
+		if c, ok := c.(*Config); ok {
+			c.Endpoint = endpoint
+		}
+	}
+}
+
+// SetInterval 设置上报间隔
+func SetInterval(interval time.Duration) options.Option {
+	return func(c any) {
+		if c, ok := c.(*Config); ok {
+			c.Interval = interval
+		}
+	}
+}
+
+// SetVersion 设置上报的 nmq 版本
+func SetVersion(version string) options.Option {
+	return func(c any) {
+		if c, ok := c.(*Config); ok {
+			c.Version = version
+		}
+	}
+}
+
+// SetComponents 设置获取启用组件名称的函数
+func SetComponents(components func() []string) options.Option {
+	return func(c any) {
+		if c, ok := c.(*Config); ok {
+			c.Components = components
+		}
+	}
+}
+
+// SetMessageRate 设置获取每秒消息数的函数，只会上报其数量级
+func SetMessageRate(rate func() float64) options.Option {
+	return func(c any) {
+		if c, ok := c.(*Config); ok {
+			c.MessageRate = rate
+		}
+	}
+}
+
+// NewConfig 创建上报配置
+func NewConfig(opts ...options.Option) *Config {
+	c := &Config{
+		Interval: defaultInterval,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// Reporter 定期上报匿名使用情况
+type Reporter struct {
+	log    *zap.Logger
+	cfg    *Config
+	client *httpclient.HttpClient
+	id     string
+
+	stop chan struct{}
+	once sync.Once
+	wg   sync.WaitGroup
+}
+
+// NewReporter 创建上报器
+func NewReporter(log *zap.Logger, cfg *Config) *Reporter {
+	return &Reporter{
+		log:    log,
+		cfg:    cfg,
+		client: httpclient.NewHttpClient(log),
+		id:     newInstanceID(),
+		stop:   make(chan struct{}),
+	}
+}
+
+// Enabled 是否开启了上报
+func (r *Reporter) Enabled() bool {
+	return r.cfg.Enabled && r.cfg.Endpoint != ""
+}
+
+// Start 启动后台上报协程，启动时立即上报一次，之后按间隔上报；未开启时不做任何事
+func (r *Reporter) Start(ctx context.Context) {
+	if !r.Enabled() {
+		return
+	}
+	interval := r.cfg.Interval
+	if interval <= 0 {
+		interval = defaultInterval
+	}
+	r.wg.Add(1)
+	go func() {
+		defer r.wg.Done()
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			if err := r.Send(ctx); err != nil {
+				r.log.Debug("telemetry report failed", zap.Error(err))
+			}
+			select {
+			case <-ticker.C:
+			case <-ctx.Done():
+				return
+			case <-r.stop:
+				return
+			}
+		}
+	}()
+}
+
+// Stop 停止后台上报协程，可以重复调用
+func (r *Reporter) Stop() {
+	r.once.Do(func() {
+		close(r.stop)
+	})
+	r.wg.Wait()
+}
+
+// Collect 生成一次上报内容
+func (r *Reporter) Collect() Report {
+	report := Report{
+		InstanceID:  r.id,
+		Version:     r.cfg.Version,
+		GoVersion:   runtime.Version(),
+		OS:          runtime.GOOS,
+		Arch:        runtime.GOARCH,
+		Components:  []string{},
+		MessageRate: magnitude(0),
+	}
+	if r.cfg.Components != nil {
+		report.Components = append(report.Components, r.cfg.Components()...)
+		sort.Strings(report.Components)
+	}
+	if r.cfg.MessageRate != nil {
+		report.MessageRate = magnitude(r.cfg.MessageRate())
+	}
+	return report
+}
+
+// Send 立即上报一次，未开启时直接返回
+func (r *Reporter) Send(ctx context.Context) error {
+	if !r.Enabled() {
+		return nil
+	}
+	body, err := json.Marshal(r.Collect())
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, r.cfg.Endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := r.client.SendRequestReturnEntity(req, defaultTimeout)
+	if err != nil {
+		return err
+	}
+	if resp.Status < 200 || resp.Status >= 300 {
+		return fmt.Errorf("telemetry: unexpected status %d", resp.Status)
+	}
+	return nil
+}
+
+// magnitude 返回速率的十进制数量级，例如 0 -> "0"，42 -> "10+"，1500 -> "1000+"
+func magnitude(rate float64) string {
+	if rate < 1 || math.IsNaN(rate) {
+		return "0"
+	}
+	return fmt.Sprintf("%.0f+", math.Pow(10, math.Floor(math.Log10(rate))))
+}
+
+// newInstanceID 生成随机实例ID
+func newInstanceID() string {
+	b := make([]byte, 8)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
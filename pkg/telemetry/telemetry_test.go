@@ -0,0 +1,102 @@
+package telemetry
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"go.uber.org/zap/zaptest"
+)
+
+func TestMagnitude(t *testing.T) {
+	tests := []struct {
+		rate     float64
+		expected string
+	}{
+		{0, "0"},
+		{0.5, "0"},
+		{1, "1+"},
+		{42, "10+"},
+		{1500, "1000+"},
+	}
+	for _, tt := range tests {
+		if got := magnitude(tt.rate); got != tt.expected {
+			t.Errorf("magnitude(%v) = %q, expected %q", tt.rate, got, tt.expected)
+		}
+	}
+}
+
+func TestDisabledByDefault(t *testing.T) {
+	called := make(chan struct{}, 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		called <- struct{}{}
+	}))
+	defer srv.Close()
+
+	r := NewReporter(zaptest.NewLogger(t), NewConfig(SetEndpoint(srv.URL)))
+	if r.Enabled() {
+		t.Fatal("Expected reporter to be disabled by default")
+	}
+	r.Start(context.Background())
+	if err := r.Send(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	r.Stop()
+
+	select {
+	case <-called:
+		t.Error("Expected no request when disabled")
+	default:
+	}
+}
+
+func TestReporterSend(t *testing.T) {
+	reports := make(chan Report, 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var report Report
+		if err := json.NewDecoder(r.Body).Decode(&report); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		reports <- report
+	}))
+	defer srv.Close()
+
+	r := NewReporter(zaptest.NewLogger(t), NewConfig(
+		SetEnabled(true),
+		SetEndpoint(srv.URL),
+		SetInterval(time.Hour),
+		SetVersion("v1.0.0"),
+		SetComponents(func() []string { return []string{"mq", "api"} }),
+		SetMessageRate(func() float64 { return 250 }),
+	))
+	r.Start(context.Background())
+	defer r.Stop()
+
+	select {
+	case report := <-reports:
+		if report.Version != "v1.0.0" || report.MessageRate != "100+" || report.InstanceID == "" {
+			t.Errorf("Unexpected report: %+v", report)
+		}
+		if len(report.Components) != 2 || report.Components[0] != "api" {
+			t.Errorf("Expected sorted components, got %v", report.Components)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Expected a report on start")
+	}
+}
+
+func TestReporterSendStatus(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer srv.Close()
+
+	r := NewReporter(zaptest.NewLogger(t), NewConfig(SetEnabled(true), SetEndpoint(srv.URL)))
+	if err := r.Send(context.Background()); err == nil {
+		t.Error("Expected error on non-2xx status")
+	}
+}
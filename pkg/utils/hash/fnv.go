@@ -23,3 +23,8 @@ func (f fnv64a) Sum64(key string) uint64 {
 
 	return hash
 }
+
+// Fnv64a 计算 key 的 64 位 FNV-1a 哈希值，不产生内存分配
+func Fnv64a(key string) uint64 {
+	return fnv64a{}.Sum64(key)
+}
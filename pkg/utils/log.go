@@ -13,6 +13,7 @@ type LogConfig struct {
 	level            zapcore.Level      // 日志输出级别（debug/info/warn/error/fatal
 	levelKey         string             // JSON 输出中表示日志级别的字段名。
 	consoleWriter    bool               // 是否将日志输出到控制台
+	reopenOnSignal   bool               // 收到 SIGUSR1 时是否重新打开日志文件
 }
 
 // An Option configures a Logger.
@@ -82,6 +83,13 @@ func SetConsoleWriterSyncer(consoleWriter bool) Option {
 	})
 }
 
+// SetLogReopenOnSignal 设置收到 SIGUSR1 时重新打开日志文件，用于兼容外部 logrotate，Windows 上无效
+func SetLogReopenOnSignal(reopen bool) Option {
+	return optionFunc(func(c *LogConfig) {
+		c.reopenOnSignal = reopen
+	})
+}
+
 // CreateProductZapLogger 创建一个生产级别的 zap 日志记录器。
 func CreateProductZapLogger(op ...Option) (*zap.Logger, error) {
 	logConfig := &LogConfig{
@@ -92,6 +100,12 @@ func CreateProductZapLogger(op ...Option) (*zap.Logger, error) {
 		opt.apply(logConfig)
 	}
 
+	// 登记日志文件，支持 RotateLogs、ReopenLogs 手动切割和重新打开
+	registerLogFile(logConfig.lumberjackLogger)
+	if logConfig.reopenOnSignal {
+		watchReopenSignal()
+	}
+
 	// 创建 zap 的核心配置
 	fileWriteSyncer := zapcore.AddSync(logConfig.lumberjackLogger)
 
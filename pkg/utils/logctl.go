@@ -0,0 +1,64 @@
+package utils
+
+import (
+	"sync"
+
+	"gopkg.in/natefinch/lumberjack.v2"
+)
+
+// logFiles 通过 CreateProductZapLogger 创建的日志文件，供 RotateLogs、ReopenLogs 统一操作
+var logFiles = struct {
+	sync.Mutex
+	m map[*lumberjack.Logger]struct{}
+}{m: make(map[*lumberjack.Logger]struct{})}
+
+// zapWriters 通过 NewZapWriter 创建且未关闭的写入器，供 FlushLogs 统一刷新
+var zapWriters = struct {
+	sync.Mutex
+	m map[*ZapWriter]struct{}
+}{m: make(map[*ZapWriter]struct{})}
+
+// registerLogFile 登记日志文件
+func registerLogFile(l *lumberjack.Logger) {
+	logFiles.Lock()
+	logFiles.m[l] = struct{}{}
+	logFiles.Unlock()
+}
+
+// eachLogFile 依次处理所有日志文件，返回遇到的第一个错误
+func eachLogFile(fn func(l *lumberjack.Logger) error) error {
+	logFiles.Lock()
+	defer logFiles.Unlock()
+	var first error
+	for l := range logFiles.m {
+		if err := fn(l); err != nil && first == nil {
+			first = err
+		}
+	}
+	return first
+}
+
+// RotateLogs 立即切割所有日志文件：当前文件按 lumberjack 规则重命名为备份并新建日志文件
+func RotateLogs() error {
+	return eachLogFile(func(l *lumberjack.Logger) error {
+		return l.Rotate()
+	})
+}
+
+// ReopenLogs 关闭所有日志文件，下一次写入时按原路径重新打开
+//
+// 用于配合外部 logrotate：日志文件被移走后调用，后续日志会写入新建的文件而不是被移走的文件
+func ReopenLogs() error {
+	return eachLogFile(func(l *lumberjack.Logger) error {
+		return l.Close()
+	})
+}
+
+// FlushLogs 将所有 ZapWriter 中不完整的行输出到日志
+func FlushLogs() {
+	zapWriters.Lock()
+	defer zapWriters.Unlock()
+	for w := range zapWriters.m {
+		w.Flush()
+	}
+}
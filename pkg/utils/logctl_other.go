@@ -0,0 +1,6 @@
+//go:build windows || plan9
+
+package utils
+
+// watchReopenSignal 当前平台没有 SIGUSR1，不做任何事
+func watchReopenSignal() {}
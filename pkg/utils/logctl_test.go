@@ -0,0 +1,63 @@
+package utils
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"go.uber.org/zap/zapcore"
+)
+
+func TestRotateAndReopenLogs(t *testing.T) {
+	dir := t.TempDir()
+	name := filepath.Join(dir, "nmq.log")
+	log, err := CreateProductZapLogger(SetLogFilename(name), SetLogLevel(zapcore.InfoLevel))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	log.Info("before rotate")
+	if err = RotateLogs(); err != nil {
+		t.Fatalf("RotateLogs failed: %v", err)
+	}
+	log.Info("after rotate")
+	entries, _ := os.ReadDir(dir)
+	if len(entries) != 2 {
+		t.Fatalf("Expected current log and one backup, got %d files", len(entries))
+	}
+
+	// 模拟外部 logrotate 移走日志文件后重新打开
+	if err = os.Rename(name, name+".1"); err != nil {
+		t.Fatal(err)
+	}
+	if err = ReopenLogs(); err != nil {
+		t.Fatalf("ReopenLogs failed: %v", err)
+	}
+	log.Info("after reopen")
+	data, err := os.ReadFile(name)
+	if err != nil {
+		t.Fatalf("Expected log file to be recreated: %v", err)
+	}
+	if !strings.Contains(string(data), "after reopen") || strings.Contains(string(data), "after rotate") {
+		t.Errorf("Unexpected log content %s", data)
+	}
+	_ = ReopenLogs()
+}
+
+func TestFlushLogs(t *testing.T) {
+	name := filepath.Join(t.TempDir(), "nmq.log")
+	log, err := CreateProductZapLogger(SetLogFilename(name), SetLogLevel(zapcore.InfoLevel))
+	if err != nil {
+		t.Fatal(err)
+	}
+	w := NewZapWriter(log, zapcore.InfoLevel)
+	w.Write([]byte("partial line"))
+	FlushLogs()
+	data, _ := os.ReadFile(name)
+	if !strings.Contains(string(data), "partial line") {
+		t.Errorf("Expected FlushLogs to write buffered line, got %s", data)
+	}
+	w.(*ZapWriter).Close()
+	_ = ReopenLogs()
+}
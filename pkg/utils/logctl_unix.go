@@ -0,0 +1,26 @@
+//go:build !windows && !plan9
+
+package utils
+
+import (
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+)
+
+// reopenOnce 保证只启动一个信号处理协程
+var reopenOnce sync.Once
+
+// watchReopenSignal 收到 SIGUSR1 时重新打开所有日志文件
+func watchReopenSignal() {
+	reopenOnce.Do(func() {
+		ch := make(chan os.Signal, 1)
+		signal.Notify(ch, syscall.SIGUSR1)
+		go func() {
+			for range ch {
+				_ = ReopenLogs()
+			}
+		}()
+	})
+}
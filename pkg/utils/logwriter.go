@@ -3,6 +3,7 @@ package utils
 import (
 	"bytes"
 	"io"
+	"sync"
 
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
@@ -10,6 +11,7 @@ import (
 
 // ZapWriter 将 io.Writer 接口桥接到 zap.Logger
 type ZapWriter struct {
+	mu     sync.Mutex
 	logger *zap.Logger
 	level  zapcore.Level
 	buffer []byte
@@ -17,16 +19,24 @@ type ZapWriter struct {
 
 // NewZapWriter 创建一个新的 io.Writer，输出到 zap.Logger
 // level: 日志级别 (zap.InfoLevel, zap.WarnLevel 等)
+//
+// 返回的写入器会登记到 FlushLogs 中，不再使用时调用 Close 输出剩余内容并取消登记
 func NewZapWriter(logger *zap.Logger, level zapcore.Level) io.Writer {
-	return &ZapWriter{
+	w := &ZapWriter{
 		logger: logger,
 		level:  level,
 		buffer: make([]byte, 0, 1024*10), // 预分配缓冲区
 	}
+	zapWriters.Lock()
+	zapWriters.m[w] = struct{}{}
+	zapWriters.Unlock()
+	return w
 }
 
 // Write 实现 io.Writer 接口
 func (w *ZapWriter) Write(p []byte) (n int, err error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
 	// 追加数据到缓冲区
 	w.buffer = append(w.buffer, p...)
 
@@ -67,6 +77,8 @@ func (w *ZapWriter) Write(p []byte) (n int, err error) {
 
 // Flush 将剩余缓冲区内容输出（例如程序退出时调用）
 func (w *ZapWriter) Flush() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
 	if len(w.buffer) > 0 {
 		line := string(w.buffer)
 		switch w.level {
@@ -82,3 +94,12 @@ func (w *ZapWriter) Flush() {
 		w.buffer = w.buffer[:0] // 清空
 	}
 }
+
+// Close 输出剩余缓冲区内容并从 FlushLogs 中取消登记
+func (w *ZapWriter) Close() error {
+	zapWriters.Lock()
+	delete(zapWriters.m, w)
+	zapWriters.Unlock()
+	w.Flush()
+	return nil
+}
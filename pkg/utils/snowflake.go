@@ -7,6 +7,7 @@ import (
 	"fmt"
 	"strconv"
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
@@ -53,6 +54,29 @@ var ErrInvalidBase58 = errors.New("invalid base58")
 // ErrInvalidBase32 is returned by ParseBase32 when given an invalid []byte
 var ErrInvalidBase32 = errors.New("invalid base32")
 
+// ErrClockSkew is returned by TryGenerate while the SkewGuard reports a skewed clock
+var ErrClockSkew = errors.New("snowflake generation paused: clock skew detected")
+
+// SkewGuard reports whether the local clock is too far from the reference time
+// for snowflake IDs to stay ordered across nodes. clock.SkewMonitor implements it.
+type SkewGuard interface {
+	Skewed() bool
+}
+
+// defaultGuard holds the SkewGuard used by nodes without one of their own.
+var defaultGuard atomic.Pointer[SkewGuard]
+
+// SetDefaultSkewGuard installs guard on every SnowNode that has no guard set
+// through SetSkewGuard, including nodes created before the call.
+// A nil guard removes the default.
+func SetDefaultSkewGuard(guard SkewGuard) {
+	if guard == nil {
+		defaultGuard.Store(nil)
+		return
+	}
+	defaultGuard.Store(&guard)
+}
+
 // Create maps for decoding Base58/Base32.
 // This speeds up the process tremendously.
 func init() {
@@ -88,6 +112,8 @@ type SnowNode struct {
 	stepMask  int64
 	timeShift uint8
 	nodeShift uint8
+
+	guard SkewGuard
 }
 
 // An SnowID is a custom type used for a snowflake SnowID.  This is used so we can
@@ -159,6 +185,33 @@ func (n *SnowNode) Generate() SnowID {
 	return r
 }
 
+// SetSkewGuard pauses TryGenerate while guard reports a skewed clock,
+// overriding the default set by SetDefaultSkewGuard.
+// Generate is not affected and keeps issuing IDs.
+func (n *SnowNode) SetSkewGuard(guard SkewGuard) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.guard = guard
+}
+
+// TryGenerate creates a unique snowflake SnowID like Generate, but returns
+// ErrClockSkew instead while the node's SkewGuard, or the default one,
+// reports a skewed clock
+func (n *SnowNode) TryGenerate() (SnowID, error) {
+	n.mu.Lock()
+	guard := n.guard
+	n.mu.Unlock()
+	if guard == nil {
+		if p := defaultGuard.Load(); p != nil {
+			guard = *p
+		}
+	}
+	if guard != nil && guard.Skewed() {
+		return 0, ErrClockSkew
+	}
+	return n.Generate(), nil
+}
+
 // Int64 returns an int64 of the snowflake SnowID
 func (f SnowID) Int64() int64 {
 	return int64(f)
@@ -0,0 +1,57 @@
+package utils
+
+import (
+	"errors"
+	"testing"
+)
+
+type fakeGuard bool
+
+func (g fakeGuard) Skewed() bool { return bool(g) }
+
+func TestSnowNodeSkewGuard(t *testing.T) {
+	n, err := NewSnowNode(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	first, err := n.TryGenerate()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	n.SetSkewGuard(fakeGuard(true))
+	if _, err = n.TryGenerate(); !errors.Is(err, ErrClockSkew) {
+		t.Errorf("Expected ErrClockSkew, got %v", err)
+	}
+
+	n.SetSkewGuard(fakeGuard(false))
+	next, err := n.TryGenerate()
+	if err != nil || next <= first {
+		t.Errorf("Expected increasing id, got %v %v", next, err)
+	}
+}
+
+func TestDefaultSkewGuard(t *testing.T) {
+	n, err := NewSnowNode(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	SetDefaultSkewGuard(fakeGuard(true))
+	defer SetDefaultSkewGuard(nil)
+	if _, err = n.TryGenerate(); !errors.Is(err, ErrClockSkew) {
+		t.Errorf("Expected ErrClockSkew from default guard, got %v", err)
+	}
+
+	// The node's own guard takes precedence over the default
+	n.SetSkewGuard(fakeGuard(false))
+	if _, err = n.TryGenerate(); err != nil {
+		t.Errorf("Expected node guard to override default, got %v", err)
+	}
+
+	n.SetSkewGuard(nil)
+	SetDefaultSkewGuard(nil)
+	if _, err = n.TryGenerate(); err != nil {
+		t.Errorf("Expected no error without guard, got %v", err)
+	}
+}
@@ -7,6 +7,7 @@ import (
 	"log"
 	"net/url"
 
+	"github.com/andrewbytecoder/nmq/pkg/network/dialer"
 	"github.com/gorilla/websocket"
 	"go.uber.org/zap"
 )
@@ -48,13 +49,32 @@ func (c *Client) Dial() error {
 	u := url.URL{Scheme: "ws", Host: addr, Path: "/ws"}
 	log.Printf("connecting to %s", u.String())
 
+	// Use the configured dialer for the underlying TCP connection
+	// 使用配置的拨号器建立底层TCP连接
+	d := c.cfg.Dialer
+	if d == nil {
+		d = dialer.New()
+	}
+	wsDialer := *websocket.DefaultDialer
+	wsDialer.NetDialContext = d.DialContext
+	// Offer permessage-deflate when compression is enabled
+	// 开启压缩时协商permessage-deflate
+	wsDialer.EnableCompression = c.cfg.Compression
+
 	// Dial the websocket server
 	// 拨号连接websocket服务器
-	ws, _, err := websocket.DefaultDialer.Dial(u.String(), nil)
+	ws, _, err := wsDialer.Dial(u.String(), nil)
 	if err != nil {
 		log.Fatal("dial:", err)
 		return err
 	}
+	if c.cfg.Compression {
+		// A no-op when the server did not negotiate compression
+		// 服务器未协商压缩时不生效
+		if err = ws.SetCompressionLevel(c.cfg.CompressionLevel); err != nil {
+			c.log.Warn("invalid compression level", zap.Int("level", c.cfg.CompressionLevel), zap.Error(err))
+		}
+	}
 	c.ws = ws
 	return nil
 }
@@ -77,5 +97,8 @@ func (c *Client) ReadMessage() (messageType int, p []byte, err error) {
 // 实现了websocket.Client接口的WriteMessage方法
 // 参数messageType是消息类型，data是消息数据
 func (c *Client) WriteMessage(messageType int, data []byte) error {
+	// Only messages over the threshold are worth compressing
+	// 只压缩超过阈值的消息
+	c.ws.EnableWriteCompression(c.cfg.Compression && len(data) >= c.cfg.CompressionThreshold)
 	return c.ws.WriteMessage(messageType, data)
 }
@@ -1,6 +1,11 @@
 package client
 
-import "github.com/andrewbytecoder/nmq/pkg/options"
+import (
+	"compress/flate"
+
+	"github.com/andrewbytecoder/nmq/pkg/network/dialer"
+	"github.com/andrewbytecoder/nmq/pkg/options"
+)
 
 // Config holds the configuration for the websocket server
 // 包含端口和地址配置项
@@ -11,6 +16,18 @@ type Config struct {
 	// Addr specifies the address the server will bind to
 	// 服务器绑定的IP地址，默认为"0.0.0.0"
 	Addr string
+	// Dialer establishes the underlying TCP connection, nil means dialer defaults
+	// 建立底层TCP连接的拨号器，为nil时使用dialer的默认配置
+	Dialer *dialer.Dialer
+	// Compression negotiates permessage-deflate (RFC 7692) with the peer
+	// 与对端协商permessage-deflate（RFC 7692）压缩
+	Compression bool
+	// CompressionLevel is the flate level used for compressed messages, see compress/flate
+	// 压缩消息使用的flate压缩级别，参考compress/flate，默认flate.BestSpeed
+	CompressionLevel int
+	// CompressionThreshold is the smallest text or binary message that is compressed, smaller messages are sent as is
+	// 压缩的最小消息长度，更短的消息不压缩，默认1024字节
+	CompressionThreshold int
 }
 
 // NewConfig creates a new Config instance with default values and applies provided options
@@ -18,8 +35,10 @@ type Config struct {
 // 参数opts是可变的选项函数，用于自定义配置
 func NewConfig(opts ...options.Option) *Config {
 	c := &Config{
-		Port: 8080,
-		Addr: "0.0.0.0",
+		Port:                 8080,
+		Addr:                 "0.0.0.0",
+		CompressionLevel:     flate.BestSpeed,
+		CompressionThreshold: 1024,
 	}
 
 	// Apply each option to the config
@@ -56,3 +75,29 @@ func SetAddr(addr string) options.Option {
 		}
 	}
 }
+
+// SetDialer returns an Option that sets the Dialer field of Config
+// 返回一个设置Config的Dialer字段的Option函数
+// 参数d是建立底层连接的拨号器，用于统一超时、重试和代理配置
+func SetDialer(d *dialer.Dialer) options.Option {
+	return func(c any) {
+		// Type assert to ensure we're working with a Config pointer
+		// 类型断言确保我们操作的是Config指针
+		if c, ok := c.(*Config); ok {
+			c.Dialer = d
+		}
+	}
+}
+
+// SetCompression returns an Option that enables permessage-deflate with the given flate
+// level for messages of at least threshold bytes
+// 返回一个开启permessage-deflate压缩的Option函数，长度不小于threshold的消息使用level级别压缩
+func SetCompression(level, threshold int) options.Option {
+	return func(c any) {
+		if c, ok := c.(*Config); ok {
+			c.Compression = true
+			c.CompressionLevel = level
+			c.CompressionThreshold = threshold
+		}
+	}
+}
@@ -0,0 +1,109 @@
+package server
+
+import (
+	"crypto/subtle"
+	"errors"
+	"net/http"
+	"strings"
+
+	"go.uber.org/zap"
+)
+
+var (
+	// ErrUnauthorized is returned by an AuthFunc when the request carries no valid credentials, answered with 401
+	// 请求没有携带有效凭证，握手以401拒绝
+	ErrUnauthorized = errors.New("websocket: unauthorized")
+	// ErrForbidden is returned by an AuthFunc when the credentials are valid but not allowed, answered with 403
+	// 凭证有效但没有权限，握手以403拒绝
+	ErrForbidden = errors.New("websocket: forbidden")
+)
+
+// AuthFunc authenticates a handshake request before it is upgraded and returns the
+// principal stored on the connection, errors wrapping ErrForbidden are answered with
+// 403 and all other errors with 401
+// 在升级之前认证握手请求，返回保存在连接上的身份；包装了ErrForbidden的错误返回403，其他错误返回401
+type AuthFunc func(r *http.Request) (principal string, err error)
+
+// TokenFromRequest returns the bearer token of the Authorization header, or the token
+// query parameter for browsers that cannot set headers on websocket requests
+// 返回Authorization请求头中的Bearer令牌，浏览器无法设置请求头时使用token查询参数
+func TokenFromRequest(r *http.Request) string {
+	if h := r.Header.Get("Authorization"); len(h) > 7 && strings.EqualFold(h[:7], "Bearer ") {
+		return strings.TrimSpace(h[7:])
+	}
+	return r.URL.Query().Get("token")
+}
+
+// APIKeyFromRequest returns the X-API-Key header or the api_key query parameter
+// 返回X-API-Key请求头或api_key查询参数
+func APIKeyFromRequest(r *http.Request) string {
+	if k := r.Header.Get("X-API-Key"); k != "" {
+		return k
+	}
+	return r.URL.Query().Get("api_key")
+}
+
+// TokenAuth returns an AuthFunc passing the request token to verify, JWTs can be
+// parsed and validated inside verify
+// 返回将请求中的令牌交给verify校验的AuthFunc，JWT可以在verify中解析和校验
+func TokenAuth(verify func(token string) (principal string, err error)) AuthFunc {
+	return func(r *http.Request) (string, error) {
+		token := TokenFromRequest(r)
+		if token == "" {
+			return "", ErrUnauthorized
+		}
+		return verify(token)
+	}
+}
+
+// APIKeyAuth returns an AuthFunc accepting the keys of keys, the value is the principal of the key
+// 返回接受keys中密钥的AuthFunc，值为密钥对应的身份
+func APIKeyAuth(keys map[string]string) AuthFunc {
+	return func(r *http.Request) (string, error) {
+		key := APIKeyFromRequest(r)
+		if key == "" {
+			return "", ErrUnauthorized
+		}
+		// compare every key in constant time so the match position does not leak
+		// 与所有密钥做常量时间比较，不泄露匹配位置
+		var principal string
+		found := 0
+		for k, p := range keys {
+			if subtle.ConstantTimeCompare([]byte(k), []byte(key)) == 1 {
+				principal, found = p, 1
+			}
+		}
+		if found == 0 {
+			return "", ErrUnauthorized
+		}
+		return principal, nil
+	}
+}
+
+// authenticate runs the configured AuthFunc and writes the rejection response on failure
+// 执行配置的AuthFunc，失败时写入拒绝响应
+func (s *Server) authenticate(w http.ResponseWriter, r *http.Request) (string, bool) {
+	if s.cfg.auth == nil {
+		return "", true
+	}
+	var principal string
+	var err error
+	if perr := s.rec.Do("websocket.auth", nil, func() error {
+		principal, err = s.cfg.auth(r)
+		return nil
+	}); perr != nil {
+		err = perr
+	}
+	if err == nil {
+		return principal, true
+	}
+
+	code, reason := http.StatusUnauthorized, "unauthorized"
+	if errors.Is(err, ErrForbidden) {
+		code, reason = http.StatusForbidden, "forbidden"
+	}
+	handshakeFailed(reason)
+	s.log.Warn("websocket handshake rejected", zap.String("remote", r.RemoteAddr), zap.Int("status", code), zap.Error(err))
+	http.Error(w, http.StatusText(code), code)
+	return "", false
+}
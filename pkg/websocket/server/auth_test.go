@@ -0,0 +1,109 @@
+package server
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/gorilla/websocket"
+	stdprometheus "github.com/prometheus/client_golang/prometheus"
+	"go.uber.org/zap"
+)
+
+// counterValue reads the counter name for the given reason label
+// 读取指定计数器在reason标签下的值
+func counterValue(t *testing.T, name, reason string) float64 {
+	t.Helper()
+	families, err := stdprometheus.DefaultGatherer.Gather()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, mf := range families {
+		if mf.GetName() != name {
+			continue
+		}
+		for _, m := range mf.GetMetric() {
+			for _, l := range m.GetLabel() {
+				if l.GetName() == "reason" && l.GetValue() == reason {
+					return m.GetCounter().GetValue()
+				}
+			}
+		}
+	}
+	return 0
+}
+
+func TestAuth(t *testing.T) {
+	auth := TokenAuth(func(token string) (string, error) {
+		switch token {
+		case "alice-token":
+			return "alice", nil
+		case "banned":
+			return "", ErrForbidden
+		}
+		return "", ErrUnauthorized
+	})
+	cfg := NewConfig(SetAuth(auth))
+	connected := make(chan *Conn, 2)
+	cfg.SetOnConnect(func(conn *Conn) { connected <- conn })
+	url := newTestServer(t, NewServer(zap.NewNop(), cfg))
+
+	unauthorized := counterValue(t, "nmq_websocket_handshake_failures_total", "unauthorized")
+	forbidden := counterValue(t, "nmq_websocket_handshake_failures_total", "forbidden")
+
+	tests := []struct {
+		url    string
+		header http.Header
+		code   int
+	}{
+		{url, nil, http.StatusUnauthorized},
+		{url + "?token=wrong", nil, http.StatusUnauthorized},
+		{url + "?token=banned", nil, http.StatusForbidden},
+		{url, http.Header{"Authorization": {"Bearer banned"}}, http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		_, resp, err := websocket.DefaultDialer.Dial(tt.url, tt.header)
+		if !errors.Is(err, websocket.ErrBadHandshake) || resp == nil || resp.StatusCode != tt.code {
+			t.Errorf("%s %v: expected %d, got %v %v", tt.url, tt.header, tt.code, resp, err)
+		}
+	}
+	if got := counterValue(t, "nmq_websocket_handshake_failures_total", "unauthorized") - unauthorized; got != 2 {
+		t.Errorf("Expected 2 unauthorized handshakes, got %v", got)
+	}
+	if got := counterValue(t, "nmq_websocket_handshake_failures_total", "forbidden") - forbidden; got != 2 {
+		t.Errorf("Expected 2 forbidden handshakes, got %v", got)
+	}
+
+	_, conn := dial(t, url+"?token=alice-token", connected)
+	if conn.Principal() != "alice" {
+		t.Errorf("Expected principal alice, got %q", conn.Principal())
+	}
+	ws, _, err := websocket.DefaultDialer.Dial(url, http.Header{"Authorization": {"bearer alice-token"}})
+	if err != nil {
+		t.Fatalf("Expected bearer header to authenticate, got %v", err)
+	}
+	_ = ws.Close()
+}
+
+func TestAPIKeyAuth(t *testing.T) {
+	auth := APIKeyAuth(map[string]string{"k1": "svc-a"})
+	for _, tt := range []struct {
+		target, header string
+		principal      string
+		err            error
+	}{
+		{"/ws?api_key=k1", "", "svc-a", nil},
+		{"/ws", "k1", "svc-a", nil},
+		{"/ws?api_key=k2", "", "", ErrUnauthorized},
+		{"/ws", "", "", ErrUnauthorized},
+	} {
+		r, _ := http.NewRequest(http.MethodGet, tt.target, nil)
+		if tt.header != "" {
+			r.Header.Set("X-API-Key", tt.header)
+		}
+		principal, err := auth(r)
+		if principal != tt.principal || !errors.Is(err, tt.err) {
+			t.Errorf("%s %q: got %q %v", tt.target, tt.header, principal, err)
+		}
+	}
+}
@@ -0,0 +1,91 @@
+package server
+
+import (
+	"bytes"
+	"compress/flate"
+	"context"
+	"net"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/gorilla/websocket"
+	"go.uber.org/zap"
+)
+
+// countConn counts the bytes read from the wire
+// 统计从网络读取的字节数
+type countConn struct {
+	net.Conn
+	n *atomic.Int64
+}
+
+func (c countConn) Read(p []byte) (int, error) {
+	n, err := c.Conn.Read(p)
+	c.n.Add(int64(n))
+	return n, err
+}
+
+func TestCompression(t *testing.T) {
+	payload := []byte(strings.Repeat(`{"topic":"news","payload":"hello websocket"},`, 1500))
+	small := bytes.Repeat([]byte("a"), 512)
+
+	for _, tt := range []struct {
+		name     string
+		enabled  bool
+		compress bool
+	}{
+		{"negotiated", true, true},
+		{"disabled", false, false},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := NewConfig()
+			if tt.enabled {
+				cfg = NewConfig(SetCompression(flate.BestCompression, 1024))
+			}
+			connected := make(chan *Conn, 1)
+			received := make(chan []byte, 1)
+			cfg.SetOnConnect(func(conn *Conn) { connected <- conn })
+			cfg.SetOnMessage(func(conn *Conn, messageType int, data []byte) { received <- data })
+			url := newTestServer(t, NewServer(zap.NewNop(), cfg))
+
+			var read atomic.Int64
+			d := *websocket.DefaultDialer
+			d.EnableCompression = true
+			d.NetDialContext = func(ctx context.Context, network, addr string) (net.Conn, error) {
+				c, err := (&net.Dialer{}).DialContext(ctx, network, addr)
+				return countConn{Conn: c, n: &read}, err
+			}
+			ws, _, err := d.Dial(url, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer ws.Close()
+			conn := <-connected
+
+			// 超过阈值的消息被压缩，低于阈值的消息原样发送
+			for _, data := range [][]byte{payload, small} {
+				before := read.Load()
+				if err = conn.Send(websocket.TextMessage, data); err != nil {
+					t.Fatal(err)
+				}
+				if got := readText(t, ws); got != string(data) {
+					t.Fatalf("Expected %d bytes, got %d", len(data), len(got))
+				}
+				wire := read.Load() - before
+				if compressed := wire < int64(len(data)); compressed != (tt.compress && len(data) >= 1024) {
+					t.Errorf("%d byte message took %d bytes on the wire", len(data), wire)
+				}
+			}
+
+			// 客户端发送的压缩消息可以被服务器解压
+			ws.EnableWriteCompression(true)
+			if err = ws.WriteMessage(websocket.TextMessage, payload); err != nil {
+				t.Fatal(err)
+			}
+			if got := <-received; !bytes.Equal(got, payload) {
+				t.Errorf("Expected server to receive %d bytes, got %d", len(payload), len(got))
+			}
+		})
+	}
+}
@@ -1,8 +1,29 @@
 package server
 
 import (
+	"compress/flate"
+	"os"
+	"time"
+
+	"github.com/andrewbytecoder/nmq/pkg/network/ip"
 	"github.com/andrewbytecoder/nmq/pkg/options"
-	"github.com/gorilla/websocket"
+	"github.com/andrewbytecoder/nmq/pkg/utils"
+)
+
+// FullPolicy decides what Conn.Send does when the send queue of a slow client is full
+// 慢客户端发送队列已满时Conn.Send的处理方式
+type FullPolicy int
+
+const (
+	// FullDrop drops the message and returns ErrSendQueueFull
+	// 丢弃消息并返回ErrSendQueueFull
+	FullDrop FullPolicy = iota
+	// FullClose closes the client with a policy violation close frame and returns ErrSendQueueFull
+	// 发送策略违规关闭帧关闭客户端，并返回ErrSendQueueFull
+	FullClose
+	// FullBlock waits at most SendTimeout for space, a Broadcast waits on every slow client in turn
+	// 最多等待SendTimeout直到有空位，Broadcast会依次等待每个慢客户端
+	FullBlock
 )
 
 // Config holds the configuration for the websocket server
@@ -14,9 +35,93 @@ type Config struct {
 	// Addr specifies the address the server will bind to
 	// 服务器绑定的IP地址，默认为"0.0.0.0"
 	Addr string
+	// ReusePort enables SO_REUSEPORT so several processes can share the port
+	// 开启SO_REUSEPORT，允许多个进程监听同一端口
+	ReusePort bool
+	// SocketActivation uses the listening socket passed by systemd (LISTEN_FDS) when present
+	// 优先使用systemd通过LISTEN_FDS传入的监听套接字
+	SocketActivation bool
+	// SendQueueSize is the number of outbound messages buffered per connection
+	// 每个连接缓冲的待发送消息数，默认256
+	SendQueueSize int
+	// FullPolicy decides what happens when the send queue is full, FullDrop by default
+	// 发送队列已满时的处理方式，默认FullDrop
+	FullPolicy FullPolicy
+	// SendTimeout bounds how long Send waits under FullBlock, 0 waits until the connection closes
+	// FullBlock策略下Send的最长等待时间，0表示一直等待直到连接关闭
+	SendTimeout time.Duration
+	// MaxMessageSize is the largest message read from a client, larger messages close the connection, 0 means unlimited
+	// 从客户端读取的最大消息长度，超过时关闭连接，0表示不限制，默认1MiB
+	MaxMessageSize int64
+	// WriteTimeout bounds a single write to a client, 0 means no timeout
+	// 单次向客户端写入的超时时间，0表示不超时，默认10秒
+	WriteTimeout time.Duration
+	// SnowNode generates connection ids, nil means node 0
+	// 生成连接ID的雪花节点，为nil时使用节点0
+	SnowNode *utils.SnowNode
+	// CertPath is the directory relative certificate files are resolved against,
+	// usually the manager's cert path from NmqContext.GetCertPath
+	// 相对路径的证书文件所在目录，通常使用组件管理器的 NmqContext.GetCertPath
+	CertPath string
+	// CertFile and KeyFile enable TLS (wss) when both are set
+	// 同时设置证书和私钥时开启TLS（wss）
+	CertFile string
+	KeyFile  string
+	// ClientCAFile enables client certificate verification against the CAs in the file
+	// 设置后使用文件中的CA校验客户端证书
+	ClientCAFile string
+	// RequireClientCert rejects clients without a certificate, otherwise a certificate is only verified when given
+	// 为true时拒绝没有证书的客户端，否则只校验客户端提供的证书
+	RequireClientCert bool
+	// CertReloadInterval is how often the certificate files are checked for changes, 0 disables the check
+	// 检查证书文件变化的间隔，0表示不检查，可以调用 Server.ReloadCertificate 手动重新加载
+	CertReloadInterval time.Duration
+	// Compression negotiates permessage-deflate (RFC 7692) with the peer
+	// 与对端协商permessage-deflate（RFC 7692）压缩
+	Compression bool
+	// CompressionLevel is the flate level used for compressed messages, see compress/flate
+	// 压缩消息使用的flate压缩级别，参考compress/flate，默认flate.BestSpeed
+	CompressionLevel int
+	// CompressionThreshold is the smallest text or binary message that is compressed, smaller messages are sent as is
+	// 压缩的最小消息长度，更短的消息不压缩，默认1024字节
+	CompressionThreshold int
+	// IPFilter rejects handshakes from blocked addresses with 403, nil allows every address
+	// 拒绝被阻止地址的握手并返回403，为nil时允许所有地址
+	IPFilter *ip.Filter
+	// TrustProxy takes the client address from X-Forwarded-For or X-Real-IP, only enable it behind a proxy
+	// 从X-Forwarded-For或X-Real-IP获取客户端地址，只应在代理之后开启
+	TrustProxy bool
+	// MaxConnections and MaxConnectionsPerIP reject handshakes over the limit with 503, 0 means unlimited
+	// 总连接数和单IP连接数上限，超过时握手返回503，0表示不限制
+	MaxConnections      int
+	MaxConnectionsPerIP int
+	// PingInterval is how often a ping is sent to every client, 0 disables pings
+	// 向每个客户端发送ping的间隔，0表示不发送，默认30秒
+	PingInterval time.Duration
+	// PongWait is how long the server waits for a pong or message before dropping the client,
+	// it must be longer than PingInterval, 0 disables the deadline
+	// 等待pong或消息的最长时间，超时后断开客户端，需要大于PingInterval，0表示不限制，默认60秒
+	PongWait time.Duration
+	// IdleTimeout closes clients that sent no message for that long, pongs do not count, 0 disables it
+	// 客户端超过该时间未发送消息时关闭连接，pong不计入，0表示不限制
+	IdleTimeout time.Duration
+	// ShutdownTimeout bounds how long Stop waits for clients to answer the close frame, 0 waits forever
+	// Stop等待客户端回复关闭帧的最长时间，0表示一直等待，默认5秒
+	ShutdownTimeout time.Duration
+	// Bandwidth is the default byte rate limit of every connection, unlimited by default,
+	// Server.SetBandwidth, Server.SetPrincipalBandwidth and Conn.SetBandwidth change it at runtime
+	// 每个连接默认的字节速率限制，默认不限制，运行时通过Server.SetBandwidth、
+	// Server.SetPrincipalBandwidth和Conn.SetBandwidth调整
+	Bandwidth Bandwidth
+	// TraceDir is the directory Server.TraceConn writes trace files to, the system temp directory by default
+	// Server.TraceConn写入跟踪文件的目录，默认为系统临时目录
+	TraceDir string
 
-	onConnect    func(conn *websocket.Conn)
-	onDisconnect func(conn *websocket.Conn)
+	auth         AuthFunc
+	middlewares  []Middleware
+	onConnect    func(conn *Conn)
+	onDisconnect func(conn *Conn)
+	onMessage    func(conn *Conn, messageType int, data []byte)
 }
 
 // NewConfig creates a new Config instance with default values and applies provided options
@@ -24,8 +129,19 @@ type Config struct {
 // 参数opts是可变的选项函数，用于自定义配置
 func NewConfig(opts ...options.Option) *Config {
 	c := &Config{
-		Port: 8080,
-		Addr: "0.0.0.0",
+		Port:                 8080,
+		Addr:                 "0.0.0.0",
+		SendQueueSize:        256,
+		MaxMessageSize:       1 << 20,
+		WriteTimeout:         10 * time.Second,
+		CertPath:             "./",
+		CertReloadInterval:   time.Minute,
+		ShutdownTimeout:      5 * time.Second,
+		PingInterval:         30 * time.Second,
+		PongWait:             60 * time.Second,
+		CompressionLevel:     flate.BestSpeed,
+		CompressionThreshold: 1024,
+		TraceDir:             os.TempDir(),
 	}
 
 	// Apply each option to the config
@@ -63,9 +179,250 @@ func SetAddr(addr string) options.Option {
 	}
 }
 
-func (c *Config) SetOnConnect(fn func(conn *websocket.Conn)) {
+// SetReusePort returns an Option that enables SO_REUSEPORT on the listener
+// 返回一个开启SO_REUSEPORT的Option函数
+func SetReusePort(reusePort bool) options.Option {
+	return func(c any) {
+		if c, ok := c.(*Config); ok {
+			c.ReusePort = reusePort
+		}
+	}
+}
+
+// SetSocketActivation returns an Option that enables systemd socket activation
+// 返回一个开启systemd socket activation的Option函数
+func SetSocketActivation(activation bool) options.Option {
+	return func(c any) {
+		if c, ok := c.(*Config); ok {
+			c.SocketActivation = activation
+		}
+	}
+}
+
+// SetSendQueueSize returns an Option that sets the per connection send queue size
+// 返回一个设置每个连接发送队列长度的Option函数
+func SetSendQueueSize(size int) options.Option {
+	return func(c any) {
+		if c, ok := c.(*Config); ok {
+			c.SendQueueSize = size
+		}
+	}
+}
+
+// SetFullPolicy returns an Option that sets how Send handles a full send queue,
+// timeout bounds the wait under FullBlock
+// 返回一个设置发送队列已满时处理方式的Option函数，timeout为FullBlock策略的最长等待时间
+func SetFullPolicy(policy FullPolicy, timeout time.Duration) options.Option {
+	return func(c any) {
+		if c, ok := c.(*Config); ok {
+			c.FullPolicy = policy
+			c.SendTimeout = timeout
+		}
+	}
+}
+
+// SetMaxMessageSize returns an Option that sets the largest message read from a client
+// 返回一个设置从客户端读取的最大消息长度的Option函数
+func SetMaxMessageSize(size int64) options.Option {
+	return func(c any) {
+		if c, ok := c.(*Config); ok {
+			c.MaxMessageSize = size
+		}
+	}
+}
+
+// SetWriteTimeout returns an Option that sets the write timeout
+// 返回一个设置单次写入超时时间的Option函数
+func SetWriteTimeout(d time.Duration) options.Option {
+	return func(c any) {
+		if c, ok := c.(*Config); ok {
+			c.WriteTimeout = d
+		}
+	}
+}
+
+// SetSnowNode returns an Option that sets the snowflake node generating connection ids
+// 返回一个设置生成连接ID的雪花节点的Option函数
+func SetSnowNode(node *utils.SnowNode) options.Option {
+	return func(c any) {
+		if c, ok := c.(*Config); ok {
+			c.SnowNode = node
+		}
+	}
+}
+
+// SetCertPath returns an Option that sets the directory of relative certificate files
+// 返回一个设置证书目录的Option函数
+func SetCertPath(path string) options.Option {
+	return func(c any) {
+		if c, ok := c.(*Config); ok {
+			c.CertPath = path
+		}
+	}
+}
+
+// SetTLS returns an Option that enables TLS with the given certificate and key files
+// 返回一个使用证书和私钥开启TLS的Option函数
+func SetTLS(certFile, keyFile string) options.Option {
+	return func(c any) {
+		if c, ok := c.(*Config); ok {
+			c.CertFile = certFile
+			c.KeyFile = keyFile
+		}
+	}
+}
+
+// SetClientCA returns an Option that verifies client certificates against caFile,
+// require rejects clients that do not present a certificate
+// 返回一个使用caFile校验客户端证书的Option函数，require为true时要求客户端必须提供证书
+func SetClientCA(caFile string, require bool) options.Option {
+	return func(c any) {
+		if c, ok := c.(*Config); ok {
+			c.ClientCAFile = caFile
+			c.RequireClientCert = require
+		}
+	}
+}
+
+// SetCertReloadInterval returns an Option that sets how often the certificate files are checked for changes
+// 返回一个设置证书文件变化检查间隔的Option函数
+func SetCertReloadInterval(d time.Duration) options.Option {
+	return func(c any) {
+		if c, ok := c.(*Config); ok {
+			c.CertReloadInterval = d
+		}
+	}
+}
+
+// SetCompression returns an Option that enables permessage-deflate with the given flate
+// level for messages of at least threshold bytes
+// 返回一个开启permessage-deflate压缩的Option函数，长度不小于threshold的消息使用level级别压缩
+func SetCompression(level, threshold int) options.Option {
+	return func(c any) {
+		if c, ok := c.(*Config); ok {
+			c.Compression = true
+			c.CompressionLevel = level
+			c.CompressionThreshold = threshold
+		}
+	}
+}
+
+// SetIPFilter returns an Option that filters handshakes by client address,
+// trustProxy takes the address from the proxy headers
+// 返回一个按客户端地址过滤握手的Option函数，trustProxy为true时从代理头部获取地址
+func SetIPFilter(filter *ip.Filter, trustProxy bool) options.Option {
+	return func(c any) {
+		if c, ok := c.(*Config); ok {
+			c.IPFilter = filter
+			c.TrustProxy = trustProxy
+		}
+	}
+}
+
+// SetMaxConnections returns an Option that limits the total connections and the connections per client address
+// 返回一个限制总连接数和单IP连接数的Option函数
+func SetMaxConnections(total, perIP int) options.Option {
+	return func(c any) {
+		if c, ok := c.(*Config); ok {
+			c.MaxConnections = total
+			c.MaxConnectionsPerIP = perIP
+		}
+	}
+}
+
+// SetHeartbeat returns an Option that sets the ping interval and the pong deadline
+// 返回一个设置ping间隔和pong超时时间的Option函数
+func SetHeartbeat(pingInterval, pongWait time.Duration) options.Option {
+	return func(c any) {
+		if c, ok := c.(*Config); ok {
+			c.PingInterval = pingInterval
+			c.PongWait = pongWait
+		}
+	}
+}
+
+// SetIdleTimeout returns an Option that closes clients idle for longer than d
+// 返回一个设置空闲连接超时时间的Option函数
+func SetIdleTimeout(d time.Duration) options.Option {
+	return func(c any) {
+		if c, ok := c.(*Config); ok {
+			c.IdleTimeout = d
+		}
+	}
+}
+
+// SetShutdownTimeout returns an Option that sets how long Stop waits for clients to drain
+// 返回一个设置Stop等待客户端断开的最长时间的Option函数
+func SetShutdownTimeout(d time.Duration) options.Option {
+	return func(c any) {
+		if c, ok := c.(*Config); ok {
+			c.ShutdownTimeout = d
+		}
+	}
+}
+
+// SetBandwidth returns an Option that limits the bytes per second read from and written to every connection, 0 means unlimited
+// 返回一个限制每个连接每秒读写字节数的Option函数，0表示不限制
+func SetBandwidth(read, write int) options.Option {
+	return func(c any) {
+		if c, ok := c.(*Config); ok {
+			c.Bandwidth = Bandwidth{Read: read, Write: write}
+		}
+	}
+}
+
+// SetTraceDir returns an Option that sets the directory trace files are written to
+// 返回一个设置跟踪文件目录的Option函数
+func SetTraceDir(dir string) options.Option {
+	return func(c any) {
+		if c, ok := c.(*Config); ok {
+			c.TraceDir = dir
+		}
+	}
+}
+
+// SetAuth returns an Option that authenticates handshakes with fn before upgrading
+// 返回一个在升级之前使用fn认证握手请求的Option函数
+func SetAuth(fn AuthFunc) options.Option {
+	return func(c any) {
+		if c, ok := c.(*Config); ok {
+			c.auth = fn
+		}
+	}
+}
+
+// SetMiddleware returns an Option that appends middlewares to the handshake chain,
+// they run in order before the IP filter, authentication and the upgrade
+// 返回一个追加握手中间件的Option函数，中间件按顺序在IP过滤、认证和升级之前执行
+func SetMiddleware(mws ...Middleware) options.Option {
+	return func(c any) {
+		if c, ok := c.(*Config); ok {
+			c.middlewares = append(c.middlewares, mws...)
+		}
+	}
+}
+
+// TLSEnabled reports whether the server serves wss
+// 是否开启TLS
+func (c *Config) TLSEnabled() bool {
+	return c.CertFile != "" && c.KeyFile != ""
+}
+
+// SetOnConnect sets the callback invoked after a connection is registered
+// 设置连接注册后调用的回调
+func (c *Config) SetOnConnect(fn func(conn *Conn)) {
 	c.onConnect = fn
 }
-func (c *Config) SetOnDisconnect(fn func(conn *websocket.Conn)) {
+
+// SetOnDisconnect sets the callback invoked after a connection is closed
+// 设置连接关闭后调用的回调
+func (c *Config) SetOnDisconnect(fn func(conn *Conn)) {
 	c.onDisconnect = fn
 }
+
+// SetOnMessage sets the callback invoked for every message received from a client,
+// it runs on the reader goroutine of the connection
+// 设置收到客户端消息时调用的回调，在连接的读协程中执行
+func (c *Config) SetOnMessage(fn func(conn *Conn, messageType int, data []byte)) {
+	c.onMessage = fn
+}
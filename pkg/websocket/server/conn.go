@@ -0,0 +1,328 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"net"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/andrewbytecoder/nmq/pkg/ratelimit"
+	"github.com/andrewbytecoder/nmq/pkg/utils"
+	"github.com/gorilla/websocket"
+	"go.uber.org/zap"
+)
+
+var (
+	// ErrConnClosed is returned when sending to a closed connection
+	// 向已关闭的连接发送消息
+	ErrConnClosed = errors.New("websocket: connection closed")
+	// ErrSendQueueFull is returned when the send queue of a connection is full
+	// 连接的发送队列已满
+	ErrSendQueueFull = errors.New("websocket: send queue full")
+	// ErrConnNotFound is returned by SendTo when no connection has the given id
+	// SendTo 找不到指定ID的连接
+	ErrConnNotFound = errors.New("websocket: connection not found")
+)
+
+// closeGoingAway is the close frame sent to clients when the server shuts down
+// 服务器停止时发送给客户端的关闭帧
+var closeGoingAway = websocket.FormatCloseMessage(websocket.CloseGoingAway, "server shutting down")
+
+// closeIdle is the close frame sent to clients idle for longer than IdleTimeout
+// 空闲超过IdleTimeout时发送给客户端的关闭帧
+var closeIdle = websocket.FormatCloseMessage(websocket.CloseNormalClosure, "idle timeout")
+
+// closeSlowClient is the close frame sent to clients closed by FullClose
+// FullClose策略关闭客户端时发送的关闭帧
+var closeSlowClient = websocket.FormatCloseMessage(websocket.ClosePolicyViolation, "slow consumer")
+
+// message is a queued outbound message
+// 发送队列中的一条消息
+type message struct {
+	typ  int
+	data []byte
+}
+
+// Conn is a client connection accepted by the server, writes go through a
+// buffered send queue drained by a dedicated writer goroutine
+// 服务器接受的客户端连接，写入经过缓冲的发送队列，由独立的写协程发送
+type Conn struct {
+	// id identifies the connection within the server
+	// 连接在服务器内的唯一ID
+	id utils.SnowID
+	// principal is the identity returned by the AuthFunc
+	// AuthFunc返回的身份
+	principal string
+	// ip is the client address the connection limits were counted against
+	// 连接数限制统计使用的客户端地址
+	ip string
+	// ws is the underlying websocket connection
+	// 底层的websocket连接
+	ws *websocket.Conn
+	// send is the outbound queue drained by writeLoop
+	// 由writeLoop消费的发送队列
+	send chan message
+	// done is closed when the connection is closed
+	// 连接关闭时关闭
+	done chan struct{}
+	// topics holds the topics the connection joined, guarded by srv.mu
+	// 连接加入的主题，由srv.mu保护
+	topics map[string]struct{}
+	// lastActive is the unix nano time of the last message received from the client
+	// 最后一次收到客户端消息的时间，单位纳秒
+	lastActive atomic.Int64
+	// trace is the active frame tracer, nil when tracing is off
+	// 当前的帧跟踪，未开启跟踪时为nil
+	trace atomic.Pointer[tracer]
+	// bandwidth overrides the identity and server limits, guarded by srv.mu
+	// 连接单独的限速，优先于身份和服务器的限速，由srv.mu保护
+	bandwidth *Bandwidth
+	// readLimit and writeLimit throttle the bytes read from and written to the client
+	// 限制从客户端读取和向客户端写入字节数的令牌桶
+	readLimit  *ratelimit.Bucket
+	writeLimit *ratelimit.Bucket
+	// ctx is cancelled when the connection closes, ending throttle waits
+	// 连接关闭时取消，结束限速等待
+	ctx    context.Context
+	cancel context.CancelFunc
+	once   sync.Once
+	srv    *Server
+}
+
+// ID returns the id of the connection
+// 返回连接ID
+func (c *Conn) ID() utils.SnowID {
+	return c.id
+}
+
+// Principal returns the identity the handshake was authenticated as, empty without an AuthFunc
+// 返回握手认证得到的身份，未配置AuthFunc时为空
+func (c *Conn) Principal() string {
+	return c.principal
+}
+
+// RemoteAddr returns the remote network address of the client
+// 返回客户端的远端地址
+func (c *Conn) RemoteAddr() net.Addr {
+	return c.ws.RemoteAddr()
+}
+
+// Send queues a message for the writer goroutine, when the queue is full the
+// FullPolicy of the server decides whether the message is dropped, the client
+// is closed or the caller waits for space
+// 将消息放入发送队列，队列已满时按服务器的FullPolicy丢弃消息、关闭客户端或等待空位
+func (c *Conn) Send(messageType int, data []byte) error {
+	m := message{typ: messageType, data: data}
+	if err := c.trySend(m); !errors.Is(err, ErrSendQueueFull) {
+		return err
+	}
+	switch c.srv.cfg.FullPolicy {
+	case FullBlock:
+		return c.waitSend(m)
+	case FullClose:
+		c.reap("slow_client")
+		_ = c.ws.WriteControl(websocket.CloseMessage, closeSlowClient, time.Now().Add(c.controlTimeout()))
+		_ = c.Close()
+	}
+	return ErrSendQueueFull
+}
+
+// trySend queues a message without blocking
+// 不阻塞地将消息放入发送队列
+func (c *Conn) trySend(m message) error {
+	select {
+	case <-c.done:
+		return ErrConnClosed
+	default:
+	}
+	select {
+	case c.send <- m:
+		return nil
+	case <-c.done:
+		return ErrConnClosed
+	default:
+		return ErrSendQueueFull
+	}
+}
+
+// waitSend waits at most SendTimeout for space in the send queue
+// 最多等待SendTimeout直到发送队列有空位
+func (c *Conn) waitSend(m message) error {
+	var timeout <-chan time.Time
+	if c.srv.cfg.SendTimeout > 0 {
+		timer := time.NewTimer(c.srv.cfg.SendTimeout)
+		defer timer.Stop()
+		timeout = timer.C
+	}
+	select {
+	case c.send <- m:
+		return nil
+	case <-c.done:
+		return ErrConnClosed
+	case <-timeout:
+		return ErrSendQueueFull
+	}
+}
+
+// Join subscribes the connection to topic
+// 连接订阅主题
+func (c *Conn) Join(topic string) error {
+	return c.srv.Join(c, topic)
+}
+
+// Leave unsubscribes the connection from topic
+// 连接取消订阅主题
+func (c *Conn) Leave(topic string) {
+	c.srv.Leave(c, topic)
+}
+
+// Close closes the connection and removes it from the server, it is safe to call more than once
+// 关闭连接并从服务器移除，可重复调用
+func (c *Conn) Close() error {
+	return c.srv.Close(c)
+}
+
+// Done returns a channel that is closed when the connection is closed
+// 返回连接关闭时关闭的通道
+func (c *Conn) Done() <-chan struct{} {
+	return c.done
+}
+
+// shutdown queues a close frame after the pending messages, the connection is closed
+// once the client answers it. When the queue is full the frame is written directly
+// 在待发送消息之后放入关闭帧，客户端回复后连接关闭；发送队列已满时直接写入关闭帧
+func (c *Conn) shutdown() {
+	err := c.trySend(message{typ: websocket.CloseMessage, data: closeGoingAway})
+	if errors.Is(err, ErrSendQueueFull) {
+		_ = c.ws.WriteControl(websocket.CloseMessage, closeGoingAway, time.Now().Add(time.Second))
+	}
+}
+
+// writeLoop sends queued messages until the connection is closed, a write error closes the connection
+// 发送队列中的消息直到连接关闭，写入失败时关闭连接
+func (c *Conn) writeLoop() {
+	cfg := c.srv.cfg
+	var ping <-chan time.Time
+	if cfg.PingInterval > 0 {
+		ticker := time.NewTicker(cfg.PingInterval)
+		defer ticker.Stop()
+		ping = ticker.C
+	}
+	var idle <-chan time.Time
+	var idleTimer *time.Timer
+	if cfg.IdleTimeout > 0 {
+		idleTimer = time.NewTimer(cfg.IdleTimeout)
+		defer idleTimer.Stop()
+		idle = idleTimer.C
+	}
+	for {
+		select {
+		case m := <-c.send:
+			if cfg.WriteTimeout > 0 {
+				_ = c.ws.SetWriteDeadline(time.Now().Add(cfg.WriteTimeout))
+			}
+			if err := c.writeLimit.WaitN(c.ctx, len(m.data)); err != nil {
+				return
+			}
+			// Only messages over the threshold are worth compressing
+			// 只压缩超过阈值的消息
+			c.ws.EnableWriteCompression(cfg.Compression && len(m.data) >= cfg.CompressionThreshold)
+			if err := c.ws.WriteMessage(m.typ, m.data); err != nil {
+				_ = c.Close()
+				return
+			}
+			c.traceFrame(traceSend, m.typ, m.data)
+			c.srv.bytesWritten.Add(int64(len(m.data)))
+			bytesTransferred.With("direction", "write").Add(float64(len(m.data)))
+			// Nothing may follow a close frame, readLoop closes the connection when the client answers
+			// 关闭帧之后不能再发送消息，客户端回复后由readLoop关闭连接
+			if m.typ == websocket.CloseMessage {
+				return
+			}
+		case <-ping:
+			if err := c.ws.WriteControl(websocket.PingMessage, nil, time.Now().Add(c.controlTimeout())); err != nil {
+				_ = c.Close()
+				return
+			}
+			c.traceFrame(traceSend, websocket.PingMessage, nil)
+		case <-idle:
+			// Reset the timer to the remaining time when a message arrived meanwhile
+			// 期间收到过消息时按剩余时间重置定时器
+			if remaining := cfg.IdleTimeout - time.Since(time.Unix(0, c.lastActive.Load())); remaining > 0 {
+				idleTimer.Reset(remaining)
+				continue
+			}
+			c.reap("idle")
+			_ = c.ws.WriteControl(websocket.CloseMessage, closeIdle, time.Now().Add(c.controlTimeout()))
+			_ = c.Close()
+			return
+		case <-c.done:
+			return
+		}
+	}
+}
+
+// controlTimeout returns the write deadline for control frames
+// 返回控制帧的写入超时时间
+func (c *Conn) controlTimeout() time.Duration {
+	if c.srv.cfg.WriteTimeout > 0 {
+		return c.srv.cfg.WriteTimeout
+	}
+	return time.Second
+}
+
+// readLoop delivers incoming messages to the onMessage callback until the connection fails or is closed,
+// every message or pong extends the read deadline by PongWait
+// 将收到的消息交给onMessage回调，直到连接出错或关闭；每条消息或pong都会将读超时延长PongWait
+func (c *Conn) readLoop() {
+	defer c.Close()
+	pongWait := c.srv.cfg.PongWait
+	extend := func() {
+		if pongWait > 0 {
+			_ = c.ws.SetReadDeadline(time.Now().Add(pongWait))
+		}
+	}
+	extend()
+	if c.srv.cfg.MaxMessageSize > 0 {
+		// Larger messages fail the read with ErrReadLimit and the peer receives a 1009 close frame
+		// 超过限制的消息读取失败并返回ErrReadLimit，对端收到1009关闭帧
+		c.ws.SetReadLimit(c.srv.cfg.MaxMessageSize)
+	}
+	c.ws.SetPongHandler(func(appData string) error {
+		c.traceFrame(traceRecv, websocket.PongMessage, []byte(appData))
+		extend()
+		return nil
+	})
+	for {
+		typ, data, err := c.ws.ReadMessage()
+		if err != nil {
+			var ne net.Error
+			if errors.As(err, &ne) && ne.Timeout() {
+				c.reap("pong_timeout")
+			} else if errors.Is(err, websocket.ErrReadLimit) {
+				c.reap("read_limit")
+			}
+			return
+		}
+		extend()
+		c.traceFrame(traceRecv, typ, data)
+		c.lastActive.Store(time.Now().UnixNano())
+		c.srv.bytesRead.Add(int64(len(data)))
+		bytesTransferred.With("direction", "read").Add(float64(len(data)))
+		// Waiting here stops reading so TCP flow control slows the client down
+		// 在这里等待会暂停读取，由TCP流量控制让客户端降速
+		if err = c.readLimit.WaitN(c.ctx, len(data)); err != nil {
+			return
+		}
+		c.srv.message(c, typ, data)
+	}
+}
+
+// reap records a connection closed by the server
+// 记录一次被服务器主动关闭的连接
+func (c *Conn) reap(reason string) {
+	connectionsReaped.With("reason", reason).Add(1)
+	c.srv.log.Debug("reap connection", zap.Int64("id", int64(c.id)), zap.String("reason", reason))
+}
@@ -0,0 +1,67 @@
+package server
+
+import (
+	"net/http"
+
+	nethttp "github.com/andrewbytecoder/nmq/pkg/network/http"
+	"go.uber.org/zap"
+)
+
+// clientIP returns the address the IP filter and the per-IP limit apply to
+// 返回IP过滤和单IP连接数限制使用的客户端地址
+func (s *Server) clientIP(r *http.Request) string {
+	if s.cfg.TrustProxy {
+		return nethttp.ClientIP(r)
+	}
+	return nethttp.RemoteIP(r)
+}
+
+// admit checks the client against the IP filter and reserves a connection slot,
+// rejecting blocked clients with 403 and clients over a limit with 503.
+// The slot must be given back with release unless the connection is registered
+// 使用IP过滤器检查客户端并占用一个连接名额，被阻止的客户端返回403，超过限制返回503；
+// 连接未注册时需要调用release归还名额
+func (s *Server) admit(w http.ResponseWriter, r *http.Request) (string, bool) {
+	ip := s.clientIP(r)
+	code, reason := http.StatusForbidden, ""
+	if s.cfg.IPFilter != nil && s.cfg.IPFilter.Blocked(ip) {
+		reason = "blocked"
+	} else if reason = s.reserve(ip); reason != "" {
+		code = http.StatusServiceUnavailable
+	}
+	if reason == "" {
+		return ip, true
+	}
+
+	handshakeFailed(reason)
+	s.log.Warn("websocket handshake rejected", zap.String("ip", ip), zap.Int("status", code), zap.String("reason", reason))
+	http.Error(w, http.StatusText(code), code)
+	return "", false
+}
+
+// reserve takes a connection slot for ip, returning the exceeded limit when none is left
+// 为ip占用一个连接名额，没有名额时返回超过的限制
+func (s *Server) reserve(ip string) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.cfg.MaxConnections > 0 && s.slots >= s.cfg.MaxConnections {
+		return "max_connections"
+	}
+	if s.cfg.MaxConnectionsPerIP > 0 && s.perIP[ip] >= s.cfg.MaxConnectionsPerIP {
+		return "max_connections_per_ip"
+	}
+	s.slots++
+	s.perIP[ip]++
+	return ""
+}
+
+// release gives back the connection slot taken by admit
+// 归还admit占用的连接名额
+func (s *Server) release(ip string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.slots--
+	if s.perIP[ip]--; s.perIP[ip] <= 0 {
+		delete(s.perIP, ip)
+	}
+}
@@ -0,0 +1,119 @@
+package server
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/andrewbytecoder/nmq/pkg/network/ip"
+	"github.com/gorilla/websocket"
+	"go.uber.org/zap"
+)
+
+func TestConnectionLimits(t *testing.T) {
+	filter := ip.New(ip.Options{BlockedIPs: []string{"10.0.0.0/8"}})
+	cfg := NewConfig(SetIPFilter(filter, true), SetMaxConnections(2, 1))
+	connected := make(chan *Conn, 3)
+	disconnected := make(chan *Conn, 3)
+	cfg.SetOnConnect(func(conn *Conn) { connected <- conn })
+	cfg.SetOnDisconnect(func(conn *Conn) { disconnected <- conn })
+	s := NewServer(zap.NewNop(), cfg)
+	url := newTestServer(t, s)
+
+	before := map[string]float64{}
+	for _, reason := range []string{"blocked", "max_connections", "max_connections_per_ip"} {
+		before[reason] = counterValue(t, "nmq_websocket_handshake_failures_total", reason)
+	}
+	connect := func(addr string) (*websocket.Conn, int) {
+		ws, resp, err := websocket.DefaultDialer.Dial(url, http.Header{"X-Forwarded-For": {addr}})
+		if err == nil {
+			t.Cleanup(func() { _ = ws.Close() })
+			<-connected
+			return ws, http.StatusSwitchingProtocols
+		}
+		if !errors.Is(err, websocket.ErrBadHandshake) {
+			t.Fatal(err)
+		}
+		return nil, resp.StatusCode
+	}
+
+	tests := []struct {
+		addr string
+		code int
+	}{
+		{"10.1.1.1", http.StatusForbidden},
+		{"192.168.1.1", http.StatusSwitchingProtocols},
+		{"192.168.1.1", http.StatusServiceUnavailable},
+		{"192.168.1.2", http.StatusSwitchingProtocols},
+		{"192.168.1.3", http.StatusServiceUnavailable},
+	}
+	var first *websocket.Conn
+	for i, tt := range tests {
+		ws, code := connect(tt.addr)
+		if code != tt.code {
+			t.Errorf("%d %s: expected %d, got %d", i, tt.addr, tt.code, code)
+		}
+		if first == nil {
+			first = ws
+		}
+	}
+	for reason, want := range map[string]float64{"blocked": 1, "max_connections": 1, "max_connections_per_ip": 1} {
+		if got := counterValue(t, "nmq_websocket_handshake_failures_total", reason) - before[reason]; got != want {
+			t.Errorf("Expected %v %s rejections, got %v", want, reason, got)
+		}
+	}
+
+	// 连接关闭后归还名额
+	_ = first.Close()
+	select {
+	case <-disconnected:
+	case <-time.After(time.Second):
+		t.Fatal("Expected onDisconnect")
+	}
+	if _, code := connect("192.168.1.1"); code != http.StatusSwitchingProtocols {
+		t.Errorf("Expected slot to be released, got %d", code)
+	}
+}
+
+func TestAuthReleasesSlot(t *testing.T) {
+	cfg := NewConfig(SetMaxConnections(1, 0), SetAuth(TokenAuth(func(token string) (string, error) {
+		if token != "ok" {
+			return "", ErrUnauthorized
+		}
+		return "user", nil
+	})))
+	connected := make(chan *Conn, 1)
+	cfg.SetOnConnect(func(conn *Conn) { connected <- conn })
+	url := newTestServer(t, NewServer(zap.NewNop(), cfg))
+
+	// 认证失败的握手不占用名额
+	for i := 0; i < 3; i++ {
+		if _, resp, _ := websocket.DefaultDialer.Dial(url, nil); resp == nil || resp.StatusCode != http.StatusUnauthorized {
+			t.Fatalf("Expected 401, got %v", resp)
+		}
+	}
+	dial(t, url+"?token=ok", connected)
+}
+
+type skewGuard bool
+
+func (g skewGuard) Skewed() bool { return bool(g) }
+
+func TestClockSkewRejectsHandshake(t *testing.T) {
+	cfg := NewConfig(SetMaxConnections(1, 0))
+	connected := make(chan *Conn, 1)
+	cfg.SetOnConnect(func(conn *Conn) { connected <- conn })
+	s := NewServer(zap.NewNop(), cfg)
+	url := newTestServer(t, s)
+
+	// 时钟偏差期间拒绝握手且不占用名额
+	s.node.SetSkewGuard(skewGuard(true))
+	for i := 0; i < 2; i++ {
+		if _, resp, _ := websocket.DefaultDialer.Dial(url, nil); resp == nil || resp.StatusCode != http.StatusServiceUnavailable {
+			t.Fatalf("Expected 503, got %v", resp)
+		}
+	}
+	s.node.SetSkewGuard(skewGuard(false))
+	dial(t, url, connected)
+}
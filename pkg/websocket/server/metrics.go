@@ -0,0 +1,48 @@
+package server
+
+import (
+	"github.com/andrewbytecoder/nmq/internal/prometheus"
+	stdprometheus "github.com/prometheus/client_golang/prometheus"
+)
+
+// handshakeFailures counts rejected or failed handshakes partitioned by reason
+// 按原因统计被拒绝或失败的握手次数
+var handshakeFailures = prometheus.NewCounterFrom(stdprometheus.CounterOpts{
+	Namespace: "nmq",
+	Subsystem: "websocket",
+	Name:      "handshake_failures_total",
+	Help:      "Number of websocket handshakes rejected or failed, partitioned by reason.",
+}, []string{"reason"})
+
+// connectionsReaped counts connections closed by the server partitioned by reason
+// 按原因统计被服务器主动关闭的连接数
+var connectionsReaped = prometheus.NewCounterFrom(stdprometheus.CounterOpts{
+	Namespace: "nmq",
+	Subsystem: "websocket",
+	Name:      "connections_reaped_total",
+	Help:      "Number of websocket connections closed by the server, partitioned by reason.",
+}, []string{"reason"})
+
+// bytesTransferred counts the message bytes read from and written to clients
+// 统计从客户端读取和向客户端写入的消息字节数
+var bytesTransferred = prometheus.NewCounterFrom(stdprometheus.CounterOpts{
+	Namespace: "nmq",
+	Subsystem: "websocket",
+	Name:      "bytes_total",
+	Help:      "Number of websocket message bytes transferred, partitioned by direction.",
+}, []string{"direction"})
+
+// throughput is the bytes per second read from and written to clients over the last second
+// 最近一秒每秒从客户端读取和向客户端写入的字节数
+var throughput = prometheus.NewGaugeFrom(stdprometheus.GaugeOpts{
+	Namespace: "nmq",
+	Subsystem: "websocket",
+	Name:      "throughput_bytes_per_second",
+	Help:      "Websocket message bytes per second over the last second, partitioned by direction.",
+}, []string{"direction"})
+
+// handshakeFailed records a failed handshake
+// 记录一次失败的握手
+func handshakeFailed(reason string) {
+	handshakeFailures.With("reason", reason).Add(1)
+}
@@ -0,0 +1,179 @@
+package server
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	nethttp "github.com/andrewbytecoder/nmq/pkg/network/http"
+	"go.uber.org/zap"
+)
+
+// Middleware wraps the handshake handler, it runs before the IP filter,
+// authentication and the upgrade and rejects a handshake by not calling next
+// 包装握手处理函数，在IP过滤、认证和升级之前执行，不调用next即拒绝握手
+type Middleware func(next http.Handler) http.Handler
+
+// Chain wraps h with mws, the first middleware is the outermost and sees the request first
+// 用中间件包装处理函数，第一个中间件在最外层，最先处理请求
+func Chain(h http.Handler, mws ...Middleware) http.Handler {
+	for i := len(mws) - 1; i >= 0; i-- {
+		h = mws[i](h)
+	}
+	return h
+}
+
+// handler returns the handshake handler wrapped by the configured middlewares
+// 返回使用配置的中间件包装后的握手处理函数
+func (s *Server) handler() http.Handler {
+	return Chain(http.HandlerFunc(s.ws), s.cfg.middlewares...)
+}
+
+// originCheckedKey marks requests whose Origin was accepted by CheckOrigin
+// 标记Origin已被CheckOrigin放行的请求
+type originCheckedKey struct{}
+
+// checkOrigin replaces the same origin check of the upgrader for requests accepted by CheckOrigin
+// 对已被CheckOrigin放行的请求跳过升级器的同源检查
+func checkOrigin(r *http.Request) bool {
+	if checked, _ := r.Context().Value(originCheckedKey{}).(bool); checked {
+		return true
+	}
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return true
+	}
+	u, err := url.Parse(origin)
+	return err == nil && strings.EqualFold(u.Host, r.Host)
+}
+
+// reject records a handshake rejected by a middleware and answers with code
+// 记录被中间件拒绝的握手并返回code
+func reject(w http.ResponseWriter, reason string, code int) {
+	handshakeFailed(reason)
+	http.Error(w, http.StatusText(code), code)
+}
+
+// CheckOrigin accepts browser handshakes whose Origin is in origins and rejects others with 403.
+// An entry is a full origin such as https://app.example.com, a host such as app.example.com,
+// or a wildcard host such as *.example.com, hosts match with or without the port.
+// Handshakes without an Origin header are accepted
+// 放行Origin在白名单中的浏览器握手，其余返回403；白名单项可以是完整的origin如https://app.example.com、
+// 主机名如app.example.com或通配主机名如*.example.com，主机名匹配时忽略端口；没有Origin头的握手直接放行
+func CheckOrigin(origins ...string) Middleware {
+	allowed := func(origin string) bool {
+		u, err := url.Parse(origin)
+		if err != nil || u.Host == "" {
+			return false
+		}
+		host := u.Hostname()
+		for _, o := range origins {
+			switch {
+			case strings.EqualFold(o, origin), strings.EqualFold(o, u.Host), strings.EqualFold(o, host):
+				return true
+			case strings.HasPrefix(o, "*.") && len(host) > len(o)-1 &&
+				strings.EqualFold(host[len(host)-len(o)+1:], o[1:]):
+				return true
+			}
+		}
+		return false
+	}
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			origin := r.Header.Get("Origin")
+			if origin == "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+			if !allowed(origin) {
+				reject(w, "origin", http.StatusForbidden)
+				return
+			}
+			next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), originCheckedKey{}, true)))
+		})
+	}
+}
+
+// RequireHeader validates the header name with validate and rejects the handshake with 400
+// when it fails, a missing header is validated as the empty string
+// 使用validate校验请求头name，校验失败时返回400，缺少该头部时按空字符串校验
+func RequireHeader(name string, validate func(value string) error) Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if err := validate(r.Header.Get(name)); err != nil {
+				reject(w, "header", http.StatusBadRequest)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// HeaderEquals returns a validator for RequireHeader accepting only the given values
+// 返回只接受指定值的RequireHeader校验函数
+func HeaderEquals(values ...string) func(value string) error {
+	return func(value string) error {
+		for _, v := range values {
+			if value == v {
+				return nil
+			}
+		}
+		return fmt.Errorf("unexpected header value %q", value)
+	}
+}
+
+// LogRequests logs every handshake with the client ip taken from the proxy headers,
+// the response status and the duration; accepted handshakes are logged at Debug level
+// and rejected ones at Warn level
+// 记录每次握手的客户端IP（优先从代理头部获取）、响应状态码和耗时，成功的握手为Debug级别，被拒绝的为Warn级别
+func LogRequests(logger *zap.Logger) Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			start := time.Now()
+			rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+			next.ServeHTTP(rec, r)
+			log := logger.With(
+				zap.String("client_ip", nethttp.ClientIP(r)),
+				zap.String("path", r.URL.Path),
+				zap.String("user_agent", r.UserAgent()),
+				zap.Int("status", rec.status),
+				zap.Duration("duration", time.Since(start)))
+			if rec.status == http.StatusSwitchingProtocols {
+				log.Debug("websocket handshake accepted")
+			} else {
+				log.Warn("websocket handshake failed")
+			}
+		})
+	}
+}
+
+// statusRecorder records the response status, a hijacked connection counts as 101
+// 记录响应状态码，被接管的连接记为101
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (r *statusRecorder) WriteHeader(code int) {
+	r.status = code
+	r.ResponseWriter.WriteHeader(code)
+}
+
+// Hijack lets the upgrader take over the connection through the recorder
+// 使升级器可以通过记录器接管连接
+func (r *statusRecorder) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	h, ok := r.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, http.ErrNotSupported
+	}
+	conn, rw, err := h.Hijack()
+	if err == nil {
+		r.status = http.StatusSwitchingProtocols
+	}
+	return conn, rw, err
+}
@@ -0,0 +1,150 @@
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+func TestMiddlewareChain(t *testing.T) {
+	var order []string
+	mark := func(name string) Middleware {
+		return func(next http.Handler) http.Handler {
+			return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				order = append(order, name)
+				next.ServeHTTP(w, r)
+			})
+		}
+	}
+	h := Chain(http.HandlerFunc(func(http.ResponseWriter, *http.Request) { order = append(order, "handler") }), mark("a"), mark("b"))
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/ws", nil))
+	if got := strings.Join(order, ","); got != "a,b,handler" {
+		t.Errorf("Expected a,b,handler, got %s", got)
+	}
+}
+
+func TestCheckOrigin(t *testing.T) {
+	cfg := NewConfig(SetMiddleware(CheckOrigin("https://app.example.com", "*.example.org", "localhost")))
+	connected := make(chan *Conn, 8)
+	cfg.SetOnConnect(func(conn *Conn) { connected <- conn })
+	url := newTestServer(t, NewServer(zap.NewNop(), cfg))
+	rejected := counterValue(t, "nmq_websocket_handshake_failures_total", "origin")
+
+	tests := []struct {
+		origin string
+		code   int
+	}{
+		{"", http.StatusSwitchingProtocols},
+		{"https://app.example.com", http.StatusSwitchingProtocols},
+		{"https://cdn.example.org", http.StatusSwitchingProtocols},
+		{"http://localhost:3000", http.StatusSwitchingProtocols},
+		{"https://example.org", http.StatusForbidden},
+		{"https://evil.com", http.StatusForbidden},
+		{"http://app.example.com", http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		header := http.Header{}
+		if tt.origin != "" {
+			header.Set("Origin", tt.origin)
+		}
+		ws, resp, err := websocket.DefaultDialer.Dial(url, header)
+		if err == nil {
+			_ = ws.Close()
+		} else if !errors.Is(err, websocket.ErrBadHandshake) {
+			t.Fatal(err)
+		}
+		if resp.StatusCode != tt.code {
+			t.Errorf("%q: expected %d, got %d", tt.origin, tt.code, resp.StatusCode)
+		}
+	}
+	if got := counterValue(t, "nmq_websocket_handshake_failures_total", "origin") - rejected; got != 3 {
+		t.Errorf("Expected 3 origin rejections, got %v", got)
+	}
+}
+
+// syncBuffer is a bytes.Buffer safe for the concurrent handler goroutines
+// 可以被多个处理协程并发写入的缓冲区
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+// entries decodes the JSON log lines written so far
+// 解析已写入的JSON日志
+func (b *syncBuffer) entries(t *testing.T) []map[string]any {
+	t.Helper()
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	var entries []map[string]any
+	for _, line := range bytes.Split(bytes.TrimSpace(b.buf.Bytes()), []byte("\n")) {
+		if len(line) == 0 {
+			continue
+		}
+		var e map[string]any
+		if err := json.Unmarshal(line, &e); err != nil {
+			t.Fatal(err)
+		}
+		entries = append(entries, e)
+	}
+	return entries
+}
+
+func TestRequireHeaderAndLogging(t *testing.T) {
+	var buf syncBuffer
+	logger := zap.New(zapcore.NewCore(zapcore.NewJSONEncoder(zap.NewProductionEncoderConfig()), zapcore.AddSync(&buf), zap.DebugLevel))
+	cfg := NewConfig(SetMiddleware(
+		LogRequests(logger),
+		RequireHeader("X-Client-Version", HeaderEquals("1", "2")),
+	))
+	connected := make(chan *Conn, 1)
+	cfg.SetOnConnect(func(conn *Conn) { connected <- conn })
+	url := newTestServer(t, NewServer(zap.NewNop(), cfg))
+	rejected := counterValue(t, "nmq_websocket_handshake_failures_total", "header")
+
+	_, resp, err := websocket.DefaultDialer.Dial(url, http.Header{"X-Client-Version": {"3"}, "X-Forwarded-For": {"203.0.113.7"}})
+	if !errors.Is(err, websocket.ErrBadHandshake) || resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("Expected 400, got %v %v", resp, err)
+	}
+	if got := counterValue(t, "nmq_websocket_handshake_failures_total", "header") - rejected; got != 1 {
+		t.Errorf("Expected 1 header rejection, got %v", got)
+	}
+	ws, _, err := websocket.DefaultDialer.Dial(url, http.Header{"X-Client-Version": {"2"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ws.Close()
+	<-connected
+
+	// 握手处理函数返回后才写日志
+	var entries []map[string]any
+	for i := 0; i < 100 && len(entries) < 2; i++ {
+		time.Sleep(10 * time.Millisecond)
+		entries = buf.entries(t)
+	}
+	if len(entries) != 2 {
+		t.Fatalf("Expected 2 log entries, got %d", len(entries))
+	}
+	failed, accepted := entries[0], entries[1]
+	if failed["level"] != "warn" || failed["status"] != float64(http.StatusBadRequest) || failed["client_ip"] != "203.0.113.7" {
+		t.Errorf("Unexpected rejected handshake log: %v", failed)
+	}
+	if accepted["level"] != "debug" || accepted["status"] != float64(http.StatusSwitchingProtocols) || accepted["client_ip"] != "127.0.0.1" {
+		t.Errorf("Unexpected accepted handshake log: %v", accepted)
+	}
+}
@@ -3,10 +3,19 @@
 package server
 
 import (
+	"context"
+	"crypto/tls"
+	"errors"
 	"fmt"
-	"log"
+	"net"
 	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
 
+	"github.com/andrewbytecoder/nmq/pkg/network/listener"
+	"github.com/andrewbytecoder/nmq/pkg/recovery"
+	"github.com/andrewbytecoder/nmq/pkg/utils"
 	"github.com/gorilla/websocket"
 	"go.uber.org/zap"
 )
@@ -20,87 +29,371 @@ type Server struct {
 	// cfg holds the server configuration including address and port
 	// 包含地址和端口的服务器配置
 	cfg *Config
-	// cliSet is a set of active websocket connections
-	// 存储活跃websocket连接的集合
-	cliSet map[*websocket.Conn]struct{}
+	// mu guards cliSet, topics, slots, perIP, httpSrv, stopping and the bandwidth limits
+	// 保护cliSet、topics、slots、perIP、httpSrv、stopping和限速配置
+	mu sync.RWMutex
+	// httpSrv is the HTTP server created by Start
+	// Start创建的HTTP服务器
+	httpSrv *http.Server
+	// stopping is set by Shutdown, new connections are refused afterwards
+	// Shutdown时设置，之后拒绝新连接
+	stopping bool
+	// cliSet holds the active connections keyed by connection id
+	// 按连接ID存储的活跃连接
+	cliSet map[utils.SnowID]*Conn
+	// topics holds the subscribers of every topic
+	// 每个主题的订阅连接
+	topics map[string]map[utils.SnowID]*Conn
+	// slots counts the connections and handshakes in progress, perIP counts them by client ip
+	// 已建立的连接和正在握手的请求数，perIP按客户端IP统计
+	slots int
+	perIP map[string]int
+	// bandwidth is the default limit of every connection, principalBandwidth overrides it by identity
+	// 所有连接默认的限速，principalBandwidth按身份覆盖
+	bandwidth          Bandwidth
+	principalBandwidth map[string]Bandwidth
+	// bytesRead and bytesWritten count the message bytes of all connections for the throughput gauge
+	// 所有连接读写的消息字节数，用于吞吐量指标
+	bytesRead    atomic.Int64
+	bytesWritten atomic.Int64
+	// node generates connection ids
+	// 生成连接ID的雪花节点
+	node *utils.SnowNode
+	// rec recovers panics raised by the handler and the connection callbacks
+	// 恢复处理函数和连接回调中的panic
+	rec *recovery.Recoverer
+	// upgrader upgrades HTTP connections to websocket connections
+	// 用于将HTTP连接升级为websocket连接
+	upgrader websocket.Upgrader
+	// certs holds the reloadable TLS config, nil when TLS is disabled
+	// 可重新加载的TLS配置，未开启TLS时为nil
+	certs *certStore
+	// done is closed by Stop to end background goroutines
+	// Stop时关闭，结束后台协程
+	done     chan struct{}
+	stopOnce sync.Once
 }
 
 // NewServer creates a new Server instance with the provided logger and configuration
 // 使用提供的日志记录器和配置创建新的Server实例
 // 参数log是zap日志记录器，cfg是服务器配置
 func NewServer(log *zap.Logger, cfg *Config) *Server {
+	node := cfg.SnowNode
+	if node == nil {
+		// node 0 is always in range
+		// 节点0总是合法的
+		node, _ = utils.NewSnowNode(0)
+	}
 	return &Server{
-		log: log,
-		cfg: cfg,
+		log:    log,
+		cfg:    cfg,
+		rec:    recovery.New(recovery.SetLogger(log)),
+		cliSet: make(map[utils.SnowID]*Conn),
+		topics: make(map[string]map[utils.SnowID]*Conn),
+		perIP:  make(map[string]int),
+		node:   node,
+		done:   make(chan struct{}),
+
+		bandwidth:          cfg.Bandwidth,
+		principalBandwidth: make(map[string]Bandwidth),
+		// Offer permessage-deflate when compression is enabled and skip the same origin check for origins accepted by CheckOrigin
+		// 开启压缩时协商permessage-deflate，并对CheckOrigin放行的origin跳过同源检查
+		upgrader: websocket.Upgrader{EnableCompression: cfg.Compression, CheckOrigin: checkOrigin},
 	}
 }
 
-// upgrader is used to upgrade HTTP connections to websocket connections
-// 用于将HTTP连接升级为websocket连接
-var upgrader = websocket.Upgrader{} // use default options 使用默认选项
-
-// Start begins the websocket server and starts listening for connections
-// 启动websocket服务器并开始监听连接
-// 绑定/ws路径处理函数并启动HTTP服务器
+// Start begins the websocket server and serves connections until Stop or Shutdown is called
+// 启动websocket服务器并处理连接，直到调用Stop或Shutdown
+// 绑定/ws路径处理函数，阻塞运行，正常停止时返回nil，否则返回监听或服务错误
 func (s *Server) Start() error {
+	s.mu.Lock()
+	if s.stopping {
+		s.mu.Unlock()
+		return http.ErrServerClosed
+	}
+	// Register the websocket handler function on a private mux
+	// 在独立的mux上注册websocket处理函数，避免重复启动时重复注册
+	mux := http.NewServeMux()
+	mux.Handle("/ws", s.rec.Middleware("websocket.server", s.handler()))
+	srv := &http.Server{Handler: mux}
+	s.httpSrv = srv
+	s.mu.Unlock()
+	go s.sampleThroughput(time.Second)
+
+	ln, err := s.listen()
+	if err != nil {
+		return err
+	}
+	// Serve returns ErrServerClosed after Shutdown, which is a normal stop
+	// Shutdown后Serve返回ErrServerClosed，属于正常停止
+	if err = srv.Serve(ln); !errors.Is(err, http.ErrServerClosed) {
+		s.log.Error("serve failed", zap.Error(err))
+		return err
+	}
+	return nil
+}
+
+// listen creates the listener, wrapped in TLS when a certificate is configured
+// 创建监听器，配置了证书时使用TLS
+func (s *Server) listen() (net.Listener, error) {
 	// Format the address with host and port
 	// 格式化包含主机和端口的地址
 	addr := fmt.Sprintf("%s:%d", s.cfg.Addr, s.cfg.Port)
 
-	// Register the websocket handler function
-	// 注册websocket处理函数
-	http.HandleFunc("/ws", s.ws)
-	// Start the HTTP server (this call blocks and logs fatal errors)
-	// 启动HTTP服务器（此调用会阻塞并记录致命错误）
-	log.Fatal(http.ListenAndServe(addr, nil))
+	// Create the listener, honoring SO_REUSEPORT and socket activation
+	// 创建监听器，支持SO_REUSEPORT和systemd socket activation
+	ln, err := listener.Listen("tcp", addr, listener.NewConfig(
+		listener.SetReusePort(s.cfg.ReusePort),
+		listener.SetSocketActivation(s.cfg.SocketActivation)))
+	if err != nil {
+		s.log.Error("listen failed", zap.String("addr", addr), zap.Error(err))
+		return nil, err
+	}
+	if !s.cfg.TLSEnabled() {
+		return ln, nil
+	}
 
-	return nil
+	// Load the certificate, new handshakes pick up reloaded certificates
+	// 加载证书，重新加载后新的握手使用新证书
+	s.certs, err = newCertStore(s.cfg)
+	if err != nil {
+		_ = ln.Close()
+		s.log.Error("load certificate failed", zap.String("cert", s.cfg.CertFile), zap.Error(err))
+		return nil, err
+	}
+	if s.cfg.CertReloadInterval > 0 {
+		go s.watchCertificate(s.cfg.CertReloadInterval)
+	}
+	return tls.NewListener(ln, s.certs.tlsConfig()), nil
 }
 
 // ws is the HTTP handler function that upgrades connections to websocket and handles messages
 // ws是将连接升级为websocket并处理消息的HTTP处理函数
 // 参数w是HTTP响应写入器，r是HTTP请求
 func (s *Server) ws(w http.ResponseWriter, r *http.Request) {
+	ip, ok := s.admit(w, r)
+	if !ok {
+		return
+	}
+	principal, ok := s.authenticate(w, r)
+	if !ok {
+		s.release(ip)
+		return
+	}
+	// Connection IDs are snowflakes; refuse new clients while the clock is skewed
+	// 连接ID使用雪花算法生成，时钟偏差期间拒绝新的客户端
+	id, err := s.node.TryGenerate()
+	if err != nil {
+		s.release(ip)
+		handshakeFailed("clock_skew")
+		s.log.Warn("websocket handshake rejected", zap.String("ip", ip), zap.Error(err))
+		http.Error(w, http.StatusText(http.StatusServiceUnavailable), http.StatusServiceUnavailable)
+		return
+	}
 	// Upgrade the HTTP connection to a websocket connection
 	// 将HTTP连接升级为websocket连接
-	c, err := upgrader.Upgrade(w, r, nil)
+	ws, err := s.upgrader.Upgrade(w, r, nil)
 	if err != nil {
-		log.Print("upgrade:", err)
+		s.release(ip)
+		handshakeFailed("upgrade")
+		s.log.Warn("upgrade failed", zap.String("remote", r.RemoteAddr), zap.Error(err))
+		return
+	}
+	conn := s.register(ws, id, principal, ip)
+	if conn == nil {
+		// The server is shutting down
+		// 服务器正在停止
+		s.release(ip)
+		_ = ws.WriteControl(websocket.CloseMessage, closeGoingAway, time.Now().Add(time.Second))
+		_ = ws.Close()
 		return
 	}
-	s.cliSet[c] = struct{}{}
-	s.cfg.onConnect(c)
+	s.callback("websocket.onConnect", s.cfg.onConnect, conn)
+	go conn.writeLoop()
+	go conn.readLoop()
 }
 
-// Stop shuts down the websocket server (currently unimplemented)
-// 停止websocket服务器（目前未实现）
+// register adds an upgraded connection to cliSet
+// 将升级后的连接加入cliSet
+func (s *Server) register(ws *websocket.Conn, id utils.SnowID, principal, ip string) *Conn {
+	size := s.cfg.SendQueueSize
+	if size <= 0 {
+		size = 1
+	}
+	conn := &Conn{
+		id:        id,
+		principal: principal,
+		ip:        ip,
+		ws:        ws,
+		send:      make(chan message, size),
+		done:      make(chan struct{}),
+		srv:       s,
+	}
+	conn.ctx, conn.cancel = context.WithCancel(context.Background())
+	conn.lastActive.Store(time.Now().UnixNano())
+	if s.cfg.Compression {
+		// A no-op when the client did not negotiate compression
+		// 客户端未协商压缩时不生效
+		if err := ws.SetCompressionLevel(s.cfg.CompressionLevel); err != nil {
+			s.log.Warn("invalid compression level", zap.Int("level", s.cfg.CompressionLevel), zap.Error(err))
+		}
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.stopping {
+		conn.cancel()
+		return nil
+	}
+	s.applyBandwidth(conn)
+	s.cliSet[conn.id] = conn
+	return conn
+}
+
+// Stop gracefully shuts the server down, waiting at most ShutdownTimeout for clients to drain
+// 优雅停止服务器，最多等待ShutdownTimeout让客户端断开
 func (s *Server) Stop() error {
-	for conn := range s.cliSet {
-		s.cfg.onDisconnect(conn)
-		s.Close(conn)
+	ctx := context.Background()
+	if s.cfg.ShutdownTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, s.cfg.ShutdownTimeout)
+		defer cancel()
+	}
+	return s.Shutdown(ctx)
+}
+
+// Shutdown stops accepting connections, sends a close frame to every client after its
+// queued messages and waits until they disconnect or ctx is done. Connections still open
+// when ctx is done are closed forcibly and the ctx error is returned
+// 停止接受新连接，在每个客户端已排队的消息之后发送关闭帧，并等待客户端断开或ctx结束；
+// ctx结束时仍未断开的连接会被强制关闭，并返回ctx的错误
+func (s *Server) Shutdown(ctx context.Context) error {
+	s.stopOnce.Do(func() { close(s.done) })
+	s.mu.Lock()
+	s.stopping = true
+	srv := s.httpSrv
+	s.mu.Unlock()
+
+	// Stop accepting, hijacked websocket connections are not tracked by the HTTP server
+	// 停止接受新连接，升级后的websocket连接不由HTTP服务器管理
+	var err error
+	if srv != nil {
+		err = srv.Shutdown(ctx)
+	}
+
+	conns := s.Conns()
+	for _, conn := range conns {
+		conn.shutdown()
+	}
+	for _, conn := range conns {
+		select {
+		case <-conn.done:
+		case <-ctx.Done():
+			_ = s.Close(conn)
+			if err == nil {
+				err = ctx.Err()
+			}
+		}
+	}
+	if err != nil {
+		s.log.Warn("server stopped before clients drained", zap.Error(err))
+		return err
 	}
 	s.log.Info("server stopped")
 	return nil
 }
 
-func (s *Server) Close(conn *websocket.Conn) error {
-	delete(s.cliSet, conn)
-	return conn.Close()
+// Conns returns a snapshot of the active connections
+// 返回活跃连接的快照
+func (s *Server) Conns() []*Conn {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	conns := make([]*Conn, 0, len(s.cliSet))
+	for _, conn := range s.cliSet {
+		conns = append(conns, conn)
+	}
+	return conns
+}
+
+// Conn returns the active connection with the given id
+// 返回指定ID的活跃连接
+func (s *Server) Conn(id utils.SnowID) (*Conn, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	conn, ok := s.cliSet[id]
+	return conn, ok
+}
+
+// Broadcast queues a text message to every active connection, connections whose
+// send queue is full are handled by FullPolicy and reported in the returned error
+// 向所有活跃连接发送文本消息，发送队列已满的连接按FullPolicy处理并在返回的错误中报告
+func (s *Server) Broadcast(data []byte) error {
+	var errs []error
+	for _, conn := range s.Conns() {
+		if err := conn.Send(websocket.TextMessage, data); err != nil {
+			errs = append(errs, fmt.Errorf("conn %d: %w", conn.id, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// SendTo queues a text message to the connection with the given id
+// 向指定ID的连接发送文本消息
+func (s *Server) SendTo(id utils.SnowID, data []byte) error {
+	conn, ok := s.Conn(id)
+	if !ok {
+		return fmt.Errorf("%w: %d", ErrConnNotFound, id)
+	}
+	return conn.Send(websocket.TextMessage, data)
+}
+
+// callback runs a connection callback, a panic in the callback is logged instead of crashing the server
+// 执行连接回调，回调中的panic会被记录而不会导致服务器退出
+func (s *Server) callback(source string, fn func(conn *Conn), conn *Conn) {
+	if fn == nil {
+		return
+	}
+	_ = s.rec.Do(source, nil, func() error {
+		fn(conn)
+		return nil
+	})
+}
+
+// message runs the onMessage callback, a panic in the callback is logged instead of crashing the reader
+// 执行onMessage回调，回调中的panic会被记录而不会导致读协程退出
+func (s *Server) message(conn *Conn, messageType int, data []byte) {
+	if s.cfg.onMessage == nil {
+		return
+	}
+	_ = s.rec.Do("websocket.onMessage", nil, func() error {
+		s.cfg.onMessage(conn, messageType, data)
+		return nil
+	})
 }
 
-// ReadMessage reads a message from the websocket connection
-// 从websocket连接中读取消息
-// 实现了websocket.Server接口的ReadMessage方法
-// 注意：当前实现存在缺陷，因为s.ws未被正确初始化
-func (s *Server) ReadMessage(conn *websocket.Conn) (messageType int, p []byte, err error) {
-	return conn.ReadMessage()
+// Close removes the connection from cliSet and its topics, closes it and runs onDisconnect once
+// 将连接从cliSet和订阅的主题中移除并关闭，onDisconnect只调用一次
+func (s *Server) Close(conn *Conn) error {
+	var err error
+	conn.once.Do(func() {
+		s.mu.Lock()
+		delete(s.cliSet, conn.id)
+		s.unsubscribeAll(conn)
+		s.mu.Unlock()
+		s.release(conn.ip)
+		close(conn.done)
+		conn.cancel()
+		err = conn.ws.Close()
+		if t := conn.trace.Load(); t != nil {
+			conn.stopTrace(t)
+		}
+		s.callback("websocket.onDisconnect", s.cfg.onDisconnect, conn)
+	})
+	return err
 }
 
-// WriteMessage writes a message to the websocket connection
-// 向websocket连接写入消息
-// 实现了websocket.Server接口的WriteMessage方法
+// WriteMessage queues a message to the connection
+// 将消息放入连接的发送队列
 // 参数messageType是消息类型，data是消息数据
-// 注意：当前实现存在缺陷，因为s.ws未被正确初始化
-func (s *Server) WriteMessage(conn *websocket.Conn, messageType int, data []byte) error {
-	return conn.WriteMessage(messageType, data)
+func (s *Server) WriteMessage(conn *Conn, messageType int, data []byte) error {
+	return conn.Send(messageType, data)
 }
@@ -0,0 +1,394 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/andrewbytecoder/nmq/pkg/utils"
+	"github.com/gorilla/websocket"
+	"go.uber.org/zap"
+)
+
+// newTestServer starts s behind an httptest server and returns its ws:// url
+// 在httptest服务器上运行s并返回ws://地址
+func newTestServer(t *testing.T, s *Server) string {
+	t.Helper()
+	ts := httptest.NewServer(s.handler())
+	t.Cleanup(func() {
+		_ = s.Stop()
+		ts.Close()
+	})
+	return "ws" + strings.TrimPrefix(ts.URL, "http")
+}
+
+// dial connects a client and waits until the server registered it
+// 连接客户端并等待服务器注册该连接
+func dial(t *testing.T, url string, connected <-chan *Conn) (*websocket.Conn, *Conn) {
+	t.Helper()
+	ws, _, err := websocket.DefaultDialer.Dial(url, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = ws.Close() })
+	select {
+	case conn := <-connected:
+		return ws, conn
+	case <-time.After(time.Second):
+		t.Fatal("Expected connection to be registered")
+		return nil, nil
+	}
+}
+
+func readText(t *testing.T, ws *websocket.Conn) string {
+	t.Helper()
+	_ = ws.SetReadDeadline(time.Now().Add(time.Second))
+	_, data, err := ws.ReadMessage()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+func TestHub(t *testing.T) {
+	cfg := NewConfig()
+	connected := make(chan *Conn, 2)
+	disconnected := make(chan utils.SnowID, 2)
+	cfg.SetOnConnect(func(conn *Conn) { connected <- conn })
+	cfg.SetOnDisconnect(func(conn *Conn) { disconnected <- conn.ID() })
+	cfg.SetOnMessage(func(conn *Conn, messageType int, data []byte) {
+		_ = conn.Send(messageType, append([]byte("echo:"), data...))
+	})
+	s := NewServer(zap.NewNop(), cfg)
+	url := newTestServer(t, s)
+
+	ws1, c1 := dial(t, url, connected)
+	ws2, c2 := dial(t, url, connected)
+	if c1.ID() == c2.ID() || len(s.Conns()) != 2 {
+		t.Fatalf("Expected two distinct connections, got %d and %d", c1.ID(), c2.ID())
+	}
+
+	if err := s.Broadcast([]byte("all")); err != nil {
+		t.Fatal(err)
+	}
+	if got := readText(t, ws1); got != "all" {
+		t.Errorf("Expected broadcast on ws1, got %q", got)
+	}
+	if got := readText(t, ws2); got != "all" {
+		t.Errorf("Expected broadcast on ws2, got %q", got)
+	}
+
+	if err := s.SendTo(c2.ID(), []byte("only2")); err != nil {
+		t.Fatal(err)
+	}
+	if got := readText(t, ws2); got != "only2" {
+		t.Errorf("Expected direct message, got %q", got)
+	}
+	if err := s.SendTo(0, []byte("x")); !errors.Is(err, ErrConnNotFound) {
+		t.Errorf("Expected ErrConnNotFound, got %v", err)
+	}
+
+	if err := ws1.WriteMessage(websocket.TextMessage, []byte("hi")); err != nil {
+		t.Fatal(err)
+	}
+	if got := readText(t, ws1); got != "echo:hi" {
+		t.Errorf("Expected echo, got %q", got)
+	}
+
+	// 客户端断开后连接从cliSet移除并调用onDisconnect
+	_ = ws1.Close()
+	select {
+	case id := <-disconnected:
+		if id != c1.ID() {
+			t.Errorf("Expected %d to disconnect, got %d", c1.ID(), id)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Expected onDisconnect")
+	}
+	if _, ok := s.Conn(c1.ID()); ok {
+		t.Error("Expected closed connection to be removed")
+	}
+	if err := c1.Send(websocket.TextMessage, []byte("late")); !errors.Is(err, ErrConnClosed) {
+		t.Errorf("Expected ErrConnClosed, got %v", err)
+	}
+}
+
+// stalledConn registers a connection without starting its writer so the send queue fills up
+// 注册一个不启动写协程的连接，使发送队列可以被填满
+func stalledConn(t *testing.T, s *Server) (*websocket.Conn, *Conn) {
+	t.Helper()
+	registered := make(chan *Conn, 1)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ws, err := s.upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		registered <- s.register(ws, s.node.Generate(), "", "")
+	}))
+	t.Cleanup(ts.Close)
+	ws, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(ts.URL, "http"), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = ws.Close() })
+	return ws, <-registered
+}
+
+func TestSendQueueFull(t *testing.T) {
+	// FullDrop 丢弃消息，连接保持打开
+	s := NewServer(zap.NewNop(), NewConfig(SetSendQueueSize(1)))
+	_, conn := stalledConn(t, s)
+	if err := conn.Send(websocket.TextMessage, []byte("1")); err != nil {
+		t.Fatal(err)
+	}
+	if err := conn.Send(websocket.TextMessage, []byte("2")); !errors.Is(err, ErrSendQueueFull) {
+		t.Errorf("Expected ErrSendQueueFull, got %v", err)
+	}
+	select {
+	case <-conn.Done():
+		t.Error("Expected FullDrop to keep the connection open")
+	default:
+	}
+}
+
+func TestFullClose(t *testing.T) {
+	s := NewServer(zap.NewNop(), NewConfig(SetSendQueueSize(1), SetFullPolicy(FullClose, 0)))
+	ws, conn := stalledConn(t, s)
+	reaped := counterValue(t, "nmq_websocket_connections_reaped_total", "slow_client")
+	_ = conn.Send(websocket.TextMessage, []byte("1"))
+	if err := conn.Send(websocket.TextMessage, []byte("2")); !errors.Is(err, ErrSendQueueFull) {
+		t.Errorf("Expected ErrSendQueueFull, got %v", err)
+	}
+	select {
+	case <-conn.Done():
+	default:
+		t.Error("Expected slow client to be closed")
+	}
+	_ = ws.SetReadDeadline(time.Now().Add(time.Second))
+	if _, _, err := ws.ReadMessage(); !websocket.IsCloseError(err, websocket.ClosePolicyViolation) {
+		t.Errorf("Expected policy violation close frame, got %v", err)
+	}
+	if got := counterValue(t, "nmq_websocket_connections_reaped_total", "slow_client") - reaped; got != 1 {
+		t.Errorf("Expected 1 slow client, got %v", got)
+	}
+}
+
+func TestFullBlock(t *testing.T) {
+	s := NewServer(zap.NewNop(), NewConfig(SetSendQueueSize(1), SetFullPolicy(FullBlock, 50*time.Millisecond)))
+	_, conn := stalledConn(t, s)
+	_ = conn.Send(websocket.TextMessage, []byte("1"))
+
+	// 超时后返回ErrSendQueueFull
+	start := time.Now()
+	if err := conn.Send(websocket.TextMessage, []byte("2")); !errors.Is(err, ErrSendQueueFull) {
+		t.Errorf("Expected ErrSendQueueFull, got %v", err)
+	}
+	if d := time.Since(start); d < 50*time.Millisecond {
+		t.Errorf("Expected Send to wait for SendTimeout, returned after %v", d)
+	}
+
+	// 队列腾出空位后阻塞的Send成功返回
+	sent := make(chan error, 1)
+	go func() { sent <- conn.Send(websocket.TextMessage, []byte("3")) }()
+	<-conn.send
+	if err := <-sent; err != nil {
+		t.Errorf("Expected blocked Send to succeed, got %v", err)
+	}
+	if m := <-conn.send; string(m.data) != "3" {
+		t.Errorf("Expected queued message 3, got %q", m.data)
+	}
+}
+
+func TestMaxMessageSize(t *testing.T) {
+	cfg := NewConfig(SetMaxMessageSize(16))
+	connected := make(chan *Conn, 1)
+	received := make(chan string, 1)
+	cfg.SetOnConnect(func(conn *Conn) { connected <- conn })
+	cfg.SetOnMessage(func(conn *Conn, messageType int, data []byte) { received <- string(data) })
+	ws, conn := dial(t, newTestServer(t, NewServer(zap.NewNop(), cfg)), connected)
+	reaped := counterValue(t, "nmq_websocket_connections_reaped_total", "read_limit")
+
+	if err := ws.WriteMessage(websocket.TextMessage, []byte("small")); err != nil {
+		t.Fatal(err)
+	}
+	if got := <-received; got != "small" {
+		t.Errorf("Expected small message, got %q", got)
+	}
+	if err := ws.WriteMessage(websocket.TextMessage, bytes.Repeat([]byte("x"), 32)); err != nil {
+		t.Fatal(err)
+	}
+	_ = ws.SetReadDeadline(time.Now().Add(time.Second))
+	if _, _, err := ws.ReadMessage(); !websocket.IsCloseError(err, websocket.CloseMessageTooBig) {
+		t.Errorf("Expected message too big close frame, got %v", err)
+	}
+	select {
+	case <-conn.Done():
+	case <-time.After(time.Second):
+		t.Fatal("Expected oversized message to close the connection")
+	}
+	if got := counterValue(t, "nmq_websocket_connections_reaped_total", "read_limit") - reaped; got != 1 {
+		t.Errorf("Expected 1 read limit close, got %v", got)
+	}
+}
+
+func TestStop(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	port := ln.Addr().(*net.TCPAddr).Port
+	_ = ln.Close()
+
+	cfg := NewConfig(SetAddr("127.0.0.1"), SetPort(port))
+	connected := make(chan *Conn, 1)
+	cfg.SetOnConnect(func(conn *Conn) { connected <- conn })
+	s := NewServer(zap.NewNop(), cfg)
+	errc := make(chan error, 1)
+	go func() { errc <- s.Start() }()
+
+	url := fmt.Sprintf("ws://127.0.0.1:%d/ws", port)
+	var ws *websocket.Conn
+	for i := 0; ws == nil; i++ {
+		if ws, _, err = websocket.DefaultDialer.Dial(url, nil); err != nil && i == 50 {
+			t.Fatal(err)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	defer ws.Close()
+	<-connected
+
+	// 停止前排队的消息先于关闭帧发送
+	if err = s.Broadcast([]byte("bye")); err != nil {
+		t.Fatal(err)
+	}
+	stopped := make(chan error, 1)
+	go func() { stopped <- s.Stop() }()
+	if got := readText(t, ws); got != "bye" {
+		t.Errorf("Expected queued message before close, got %q", got)
+	}
+	_ = ws.SetReadDeadline(time.Now().Add(time.Second))
+	if _, _, err = ws.ReadMessage(); !websocket.IsCloseError(err, websocket.CloseGoingAway) {
+		t.Errorf("Expected going away close frame, got %v", err)
+	}
+
+	for _, c := range []chan error{stopped, errc} {
+		select {
+		case err = <-c:
+			if err != nil {
+				t.Errorf("Expected graceful stop, got %v", err)
+			}
+		case <-time.After(time.Second):
+			t.Fatal("Expected server to stop")
+		}
+	}
+	if _, _, err = websocket.DefaultDialer.Dial(url, nil); err == nil {
+		t.Error("Expected stopped server to refuse connections")
+	}
+}
+
+func TestShutdownTimeout(t *testing.T) {
+	connected := make(chan *Conn, 1)
+	cfg := NewConfig()
+	cfg.SetOnConnect(func(conn *Conn) { connected <- conn })
+	s := NewServer(zap.NewNop(), cfg)
+	_, conn := dial(t, newTestServer(t, s), connected)
+
+	// 客户端不读取消息，不会回复关闭帧
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	if err := s.Shutdown(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected DeadlineExceeded, got %v", err)
+	}
+	select {
+	case <-conn.Done():
+	default:
+		t.Error("Expected undrained connection to be closed")
+	}
+	if len(s.Conns()) != 0 {
+		t.Errorf("Expected no connections, got %d", len(s.Conns()))
+	}
+	if err := s.Start(); !errors.Is(err, http.ErrServerClosed) {
+		t.Errorf("Expected ErrServerClosed after shutdown, got %v", err)
+	}
+}
+
+func TestHeartbeat(t *testing.T) {
+	cfg := NewConfig(SetHeartbeat(20*time.Millisecond, 100*time.Millisecond))
+	connected := make(chan *Conn, 2)
+	disconnected := make(chan utils.SnowID, 2)
+	cfg.SetOnConnect(func(conn *Conn) { connected <- conn })
+	cfg.SetOnDisconnect(func(conn *Conn) { disconnected <- conn.ID() })
+	s := NewServer(zap.NewNop(), cfg)
+	url := newTestServer(t, s)
+	reaped := counterValue(t, "nmq_websocket_connections_reaped_total", "pong_timeout")
+
+	// alive回复pong，dead忽略ping
+	alive, c1 := dial(t, url, connected)
+	dead, c2 := dial(t, url, connected)
+	dead.SetPingHandler(func(string) error { return nil })
+	for _, ws := range []*websocket.Conn{alive, dead} {
+		go func(ws *websocket.Conn) {
+			for {
+				if _, _, err := ws.ReadMessage(); err != nil {
+					return
+				}
+			}
+		}(ws)
+	}
+
+	select {
+	case id := <-disconnected:
+		if id != c2.ID() {
+			t.Errorf("Expected %d to be reaped, got %d", c2.ID(), id)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Expected dead client to be reaped")
+	}
+	if _, ok := s.Conn(c1.ID()); !ok {
+		t.Error("Expected client answering pings to stay connected")
+	}
+	if _, ok := s.Conn(c2.ID()); ok {
+		t.Error("Expected reaped client to be removed")
+	}
+	if got := counterValue(t, "nmq_websocket_connections_reaped_total", "pong_timeout") - reaped; got != 1 {
+		t.Errorf("Expected 1 reaped connection, got %v", got)
+	}
+}
+
+func TestIdleTimeout(t *testing.T) {
+	cfg := NewConfig(SetHeartbeat(0, 0), SetIdleTimeout(100*time.Millisecond))
+	connected := make(chan *Conn, 1)
+	cfg.SetOnConnect(func(conn *Conn) { connected <- conn })
+	s := NewServer(zap.NewNop(), cfg)
+	ws, conn := dial(t, newTestServer(t, s), connected)
+
+	// 持续发送消息的连接不会被关闭
+	for i := 0; i < 5; i++ {
+		if err := ws.WriteMessage(websocket.TextMessage, []byte("x")); err != nil {
+			t.Fatal(err)
+		}
+		time.Sleep(40 * time.Millisecond)
+	}
+	select {
+	case <-conn.Done():
+		t.Fatal("Expected active connection to stay open")
+	default:
+	}
+
+	_ = ws.SetReadDeadline(time.Now().Add(time.Second))
+	if _, _, err := ws.ReadMessage(); !websocket.IsCloseError(err, websocket.CloseNormalClosure) {
+		t.Errorf("Expected idle close frame, got %v", err)
+	}
+	select {
+	case <-conn.Done():
+	case <-time.After(time.Second):
+		t.Error("Expected idle connection to be closed")
+	}
+}
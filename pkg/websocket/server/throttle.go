@@ -0,0 +1,128 @@
+package server
+
+import (
+	"time"
+
+	"github.com/andrewbytecoder/nmq/pkg/ratelimit"
+)
+
+// Bandwidth holds the byte rate limits of a connection, 0 means unlimited
+// 连接的字节速率限制，0表示不限制
+type Bandwidth struct {
+	// Read limits the bytes per second read from the client
+	// 每秒从客户端读取的字节数
+	Read int
+	// Write limits the bytes per second written to the client
+	// 每秒向客户端写入的字节数
+	Write int
+}
+
+// SetBandwidth changes the limits of every connection without a per-identity or per-connection limit
+// 调整没有按身份或按连接单独限速的所有连接的限速
+func (s *Server) SetBandwidth(bw Bandwidth) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.bandwidth = bw
+	for _, conn := range s.cliSet {
+		s.applyBandwidth(conn)
+	}
+}
+
+// SetPrincipalBandwidth limits the connections authenticated as principal, overriding the server limits
+// 为认证身份为principal的连接单独限速，优先于服务器的限速
+func (s *Server) SetPrincipalBandwidth(principal string, bw Bandwidth) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.principalBandwidth[principal] = bw
+	s.applyPrincipalBandwidth(principal)
+}
+
+// ClearPrincipalBandwidth removes the limits set by SetPrincipalBandwidth
+// 移除SetPrincipalBandwidth设置的限速
+func (s *Server) ClearPrincipalBandwidth(principal string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.principalBandwidth, principal)
+	s.applyPrincipalBandwidth(principal)
+}
+
+// applyPrincipalBandwidth reapplies the limits of the connections of principal, the caller must hold s.mu
+// 重新应用principal的连接的限速，调用方需持有s.mu
+func (s *Server) applyPrincipalBandwidth(principal string) {
+	for _, conn := range s.cliSet {
+		if conn.principal == principal {
+			s.applyBandwidth(conn)
+		}
+	}
+}
+
+// applyBandwidth sets the buckets of conn to its effective limits: the connection limits,
+// then the limits of its principal, then the server limits. The caller must hold s.mu
+// 按连接、身份、服务器的优先级设置连接的令牌桶，调用方需持有s.mu
+func (s *Server) applyBandwidth(conn *Conn) {
+	bw := s.bandwidth
+	if conn.bandwidth != nil {
+		bw = *conn.bandwidth
+	} else if p, ok := s.principalBandwidth[conn.principal]; ok {
+		bw = p
+	}
+	if conn.readLimit == nil {
+		conn.readLimit = ratelimit.NewBucket(bw.Read, 0)
+		conn.writeLimit = ratelimit.NewBucket(bw.Write, 0)
+		return
+	}
+	conn.readLimit.SetRate(bw.Read, 0)
+	conn.writeLimit.SetRate(bw.Write, 0)
+}
+
+// SetBandwidth limits this connection, overriding the identity and server limits
+// 为连接单独限速，优先于身份和服务器的限速
+func (c *Conn) SetBandwidth(bw Bandwidth) {
+	c.srv.mu.Lock()
+	defer c.srv.mu.Unlock()
+	c.bandwidth = &bw
+	c.srv.applyBandwidth(c)
+}
+
+// ClearBandwidth removes the limits set by Conn.SetBandwidth
+// 移除Conn.SetBandwidth设置的限速
+func (c *Conn) ClearBandwidth() {
+	c.srv.mu.Lock()
+	defer c.srv.mu.Unlock()
+	c.bandwidth = nil
+	c.srv.applyBandwidth(c)
+}
+
+// Bandwidth returns the limits in effect for the connection
+// 返回连接当前生效的限速
+func (c *Conn) Bandwidth() Bandwidth {
+	read, _ := c.readLimit.Rate()
+	write, _ := c.writeLimit.Rate()
+	return Bandwidth{Read: read, Write: write}
+}
+
+// sampleThroughput publishes the bytes per second read and written by the server until it stops
+// 定期发布服务器每秒读写的字节数，直到服务器停止
+func (s *Server) sampleThroughput(interval time.Duration) {
+	t := time.NewTicker(interval)
+	defer t.Stop()
+	var lastRead, lastWrite, readRate, writeRate float64
+	last := time.Now()
+	for {
+		select {
+		case now := <-t.C:
+			elapsed := now.Sub(last).Seconds()
+			read, write := float64(s.bytesRead.Load()), float64(s.bytesWritten.Load())
+			r, w := (read-lastRead)/elapsed, (write-lastWrite)/elapsed
+			// Add the change so several servers sum up in the shared gauge
+			// 按变化量累加，多个服务器共享同一个指标
+			throughput.With("direction", "read").Add(r - readRate)
+			throughput.With("direction", "write").Add(w - writeRate)
+			lastRead, lastWrite, readRate, writeRate, last = read, write, r, w, now
+		case <-s.done:
+			throughput.With("direction", "read").Add(-readRate)
+			throughput.With("direction", "write").Add(-writeRate)
+			return
+		}
+	}
+}
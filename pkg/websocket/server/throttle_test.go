@@ -0,0 +1,65 @@
+package server
+
+import (
+	"bytes"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"go.uber.org/zap"
+)
+
+func TestBandwidthPrecedence(t *testing.T) {
+	cfg := NewConfig(SetBandwidth(100, 200), SetAuth(func(*http.Request) (string, error) { return "alice", nil }))
+	connected := make(chan *Conn, 1)
+	cfg.SetOnConnect(func(conn *Conn) { connected <- conn })
+	s := NewServer(zap.NewNop(), cfg)
+	_, conn := dial(t, newTestServer(t, s), connected)
+
+	check := func(step string, want Bandwidth) {
+		t.Helper()
+		if got := conn.Bandwidth(); got != want {
+			t.Errorf("%s: expected %+v, got %+v", step, want, got)
+		}
+	}
+	check("server", Bandwidth{Read: 100, Write: 200})
+	s.SetPrincipalBandwidth("alice", Bandwidth{Read: 10, Write: 20})
+	check("principal", Bandwidth{Read: 10, Write: 20})
+	conn.SetBandwidth(Bandwidth{Read: 1})
+	check("connection", Bandwidth{Read: 1})
+	// 服务器限速不覆盖按身份和按连接的限速
+	s.SetBandwidth(Bandwidth{Read: 300, Write: 400})
+	check("connection after server change", Bandwidth{Read: 1})
+	conn.ClearBandwidth()
+	check("principal after clear", Bandwidth{Read: 10, Write: 20})
+	s.ClearPrincipalBandwidth("alice")
+	check("server after clear", Bandwidth{Read: 300, Write: 400})
+}
+
+func TestBandwidthThrottlesWrites(t *testing.T) {
+	cfg := NewConfig()
+	connected := make(chan *Conn, 1)
+	cfg.SetOnConnect(func(conn *Conn) { connected <- conn })
+	s := NewServer(zap.NewNop(), cfg)
+	ws, conn := dial(t, newTestServer(t, s), connected)
+
+	// 100KB/s 写入 3 条 25KB 消息需要约0.75秒
+	conn.SetBandwidth(Bandwidth{Write: 100 << 10})
+	payload := bytes.Repeat([]byte("x"), 25<<10)
+	start := time.Now()
+	for i := 0; i < 3; i++ {
+		if err := conn.Send(websocket.BinaryMessage, payload); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for i := 0; i < 3; i++ {
+		_ = ws.SetReadDeadline(time.Now().Add(5 * time.Second))
+		if _, data, err := ws.ReadMessage(); err != nil || len(data) != len(payload) {
+			t.Fatalf("Read %d failed: %v", i, err)
+		}
+	}
+	if elapsed := time.Since(start); elapsed < 400*time.Millisecond {
+		t.Errorf("Expected throttled writes to take at least 400ms, took %v", elapsed)
+	}
+}
@@ -0,0 +1,148 @@
+package server
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+var (
+	// ErrNoClientCA is returned when the client CA file contains no certificate
+	// 客户端CA文件中没有证书
+	ErrNoClientCA = errors.New("websocket: no certificate found in client CA file")
+	// ErrTLSDisabled is returned by ReloadCertificate when TLS is not configured
+	// 未配置TLS时ReloadCertificate返回的错误
+	ErrTLSDisabled = errors.New("websocket: tls is not enabled")
+)
+
+// certStore holds the current TLS config, reloading it replaces the config used by
+// new handshakes while established connections keep their session
+// 保存当前的TLS配置，重新加载只影响之后的握手，已建立的连接不受影响
+type certStore struct {
+	certFile, keyFile, caFile string
+	requireClientCert         bool
+
+	current atomic.Pointer[tls.Config]
+	modTime atomic.Int64 // latest modification time of the loaded files 已加载文件的最新修改时间
+}
+
+// newCertStore resolves the file names against certPath and loads them
+// 将文件名定位到certPath下并加载
+func newCertStore(cfg *Config) (*certStore, error) {
+	resolve := func(name string) string {
+		if name == "" || filepath.IsAbs(name) {
+			return name
+		}
+		return filepath.Join(cfg.CertPath, name)
+	}
+	cs := &certStore{
+		certFile:          resolve(cfg.CertFile),
+		keyFile:           resolve(cfg.KeyFile),
+		caFile:            resolve(cfg.ClientCAFile),
+		requireClientCert: cfg.RequireClientCert,
+	}
+	if err := cs.load(); err != nil {
+		return nil, err
+	}
+	return cs, nil
+}
+
+// load reads the certificate, key and client CA and swaps in a new TLS config
+// 读取证书、私钥和客户端CA并替换TLS配置
+func (cs *certStore) load() error {
+	modTime := cs.latestModTime()
+	cert, err := tls.LoadX509KeyPair(cs.certFile, cs.keyFile)
+	if err != nil {
+		return err
+	}
+	tc := &tls.Config{Certificates: []tls.Certificate{cert}, MinVersion: tls.VersionTLS12}
+	if cs.caFile != "" {
+		pem, err := os.ReadFile(cs.caFile)
+		if err != nil {
+			return err
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return fmt.Errorf("%w: %s", ErrNoClientCA, cs.caFile)
+		}
+		tc.ClientCAs = pool
+		tc.ClientAuth = tls.VerifyClientCertIfGiven
+		if cs.requireClientCert {
+			tc.ClientAuth = tls.RequireAndVerifyClientCert
+		}
+	}
+	cs.current.Store(tc)
+	cs.modTime.Store(modTime)
+	return nil
+}
+
+// latestModTime returns the latest modification time of the configured files
+// 返回配置文件中最新的修改时间
+func (cs *certStore) latestModTime() int64 {
+	var latest int64
+	for _, name := range []string{cs.certFile, cs.keyFile, cs.caFile} {
+		if name == "" {
+			continue
+		}
+		if fi, err := os.Stat(name); err == nil && fi.ModTime().UnixNano() > latest {
+			latest = fi.ModTime().UnixNano()
+		}
+	}
+	return latest
+}
+
+// changed reports whether any file was modified since the last load
+// 判断上次加载后文件是否有修改
+func (cs *certStore) changed() bool {
+	return cs.latestModTime() != cs.modTime.Load()
+}
+
+// tlsConfig returns the listener config, every handshake picks up the current config
+// 返回监听器使用的TLS配置，每次握手都使用当前的配置
+func (cs *certStore) tlsConfig() *tls.Config {
+	return &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		GetConfigForClient: func(*tls.ClientHelloInfo) (*tls.Config, error) {
+			return cs.current.Load(), nil
+		},
+	}
+}
+
+// ReloadCertificate reloads the certificate, key and client CA from disk, existing
+// connections are kept and only new handshakes use the new files
+// 从磁盘重新加载证书、私钥和客户端CA，已有连接保持不变，之后的握手使用新证书
+func (s *Server) ReloadCertificate() error {
+	if s.certs == nil {
+		return ErrTLSDisabled
+	}
+	if err := s.certs.load(); err != nil {
+		s.log.Error("reload certificate failed", zap.String("cert", s.certs.certFile), zap.Error(err))
+		return err
+	}
+	s.log.Info("certificate reloaded", zap.String("cert", s.certs.certFile))
+	return nil
+}
+
+// watchCertificate reloads the certificate when the files change until the server stops
+// 文件变化时重新加载证书，直到服务器停止
+func (s *Server) watchCertificate(interval time.Duration) {
+	t := time.NewTicker(interval)
+	defer t.Stop()
+	for {
+		select {
+		case <-t.C:
+			if s.certs.changed() {
+				_ = s.ReloadCertificate()
+			}
+		case <-s.done:
+			return
+		}
+	}
+}
@@ -0,0 +1,147 @@
+package server
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"math/big"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"go.uber.org/zap"
+)
+
+// writeCert writes a self-signed certificate for 127.0.0.1 with the given serial into dir
+// 在dir中写入序列号为serial的127.0.0.1自签名证书
+func writeCert(t *testing.T, dir string, serial int64) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(serial),
+		Subject:               pkix.Name{CommonName: "127.0.0.1"},
+		IPAddresses:           []net.IP{net.IPv4(127, 0, 0, 1)},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	certPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+	keyPEM := pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})
+	if err = os.WriteFile(filepath.Join(dir, "server.crt"), certPEM, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err = os.WriteFile(filepath.Join(dir, "server.key"), keyPEM, 0o600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// serveTLS runs s on a TLS listener and returns the wss:// url
+// 在TLS监听器上运行s并返回wss://地址
+func serveTLS(t *testing.T, s *Server) string {
+	t.Helper()
+	ln, err := s.listen()
+	if err != nil {
+		t.Fatal(err)
+	}
+	go func() { _ = http.Serve(ln, s.handler()) }()
+	t.Cleanup(func() {
+		_ = s.Stop()
+		_ = ln.Close()
+	})
+	return "wss://" + ln.Addr().String()
+}
+
+// dialTLS connects with InsecureSkipVerify and returns the serial of the server certificate
+// 跳过校验连接服务器并返回服务器证书的序列号
+func dialTLS(t *testing.T, url string, cert *tls.Certificate) (*websocket.Conn, int64, error) {
+	t.Helper()
+	d := *websocket.DefaultDialer
+	d.TLSClientConfig = &tls.Config{InsecureSkipVerify: true}
+	if cert != nil {
+		d.TLSClientConfig.Certificates = []tls.Certificate{*cert}
+	}
+	ws, _, err := d.Dial(url, nil)
+	if err != nil {
+		return nil, 0, err
+	}
+	t.Cleanup(func() { _ = ws.Close() })
+	state, _ := ws.UnderlyingConn().(*tls.Conn)
+	return ws, state.ConnectionState().PeerCertificates[0].SerialNumber.Int64(), nil
+}
+
+func TestTLSReload(t *testing.T) {
+	dir := t.TempDir()
+	writeCert(t, dir, 1)
+	cfg := NewConfig(SetAddr("127.0.0.1"), SetPort(0), SetCertPath(dir), SetTLS("server.crt", "server.key"), SetCertReloadInterval(0))
+	connected := make(chan *Conn, 2)
+	cfg.SetOnConnect(func(conn *Conn) { connected <- conn })
+	s := NewServer(zap.NewNop(), cfg)
+	url := serveTLS(t, s)
+
+	ws1, serial, err := dialTLS(t, url, nil)
+	if err != nil || serial != 1 {
+		t.Fatalf("Unexpected handshake: serial=%d err=%v", serial, err)
+	}
+	c1 := <-connected
+
+	// 重新加载证书后新连接使用新证书，已有连接不受影响
+	writeCert(t, dir, 2)
+	if err = s.ReloadCertificate(); err != nil {
+		t.Fatal(err)
+	}
+	if _, serial, err = dialTLS(t, url, nil); err != nil || serial != 2 {
+		t.Fatalf("Expected reloaded certificate, serial=%d err=%v", serial, err)
+	}
+	if err = s.SendTo(c1.ID(), []byte("still here")); err != nil {
+		t.Fatal(err)
+	}
+	if got := readText(t, ws1); got != "still here" {
+		t.Errorf("Expected existing connection to survive reload, got %q", got)
+	}
+}
+
+func TestTLSClientCert(t *testing.T) {
+	dir := t.TempDir()
+	writeCert(t, dir, 1)
+	cfg := NewConfig(SetAddr("127.0.0.1"), SetPort(0), SetCertPath(dir), SetTLS("server.crt", "server.key"),
+		SetClientCA("server.crt", true), SetCertReloadInterval(0))
+	s := NewServer(zap.NewNop(), cfg)
+	url := serveTLS(t, s)
+
+	if _, _, err := dialTLS(t, url, nil); err == nil {
+		t.Error("Expected handshake without client certificate to fail")
+	}
+	cert, err := tls.LoadX509KeyPair(filepath.Join(dir, "server.crt"), filepath.Join(dir, "server.key"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err = dialTLS(t, url, &cert); err != nil {
+		t.Errorf("Expected handshake with client certificate, got %v", err)
+	}
+	if err = NewServer(zap.NewNop(), NewConfig()).ReloadCertificate(); !errors.Is(err, ErrTLSDisabled) {
+		t.Errorf("Expected ErrTLSDisabled, got %v", err)
+	}
+}
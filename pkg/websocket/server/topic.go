@@ -0,0 +1,99 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/andrewbytecoder/nmq/pkg/utils"
+	"github.com/gorilla/websocket"
+)
+
+// Join subscribes conn to topic, joining a topic twice has no effect.
+// Closed connections cannot join and leave their topics automatically
+// 将连接加入主题，重复加入没有影响；已关闭的连接不能加入，关闭时自动离开所有主题
+func (s *Server) Join(conn *Conn, topic string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.cliSet[conn.id]; !ok {
+		return ErrConnClosed
+	}
+	subs, ok := s.topics[topic]
+	if !ok {
+		subs = make(map[utils.SnowID]*Conn)
+		s.topics[topic] = subs
+	}
+	subs[conn.id] = conn
+	if conn.topics == nil {
+		conn.topics = make(map[string]struct{})
+	}
+	conn.topics[topic] = struct{}{}
+	return nil
+}
+
+// Leave unsubscribes conn from topic, empty topics are removed
+// 将连接从主题中移除，没有订阅者的主题会被删除
+func (s *Server) Leave(conn *Conn, topic string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.unsubscribe(conn, topic)
+}
+
+// Publish queues a text message to every subscriber of topic, subscribers whose
+// send queue is full are handled by FullPolicy and reported in the returned error
+// 向主题的所有订阅者发送文本消息，发送队列已满的连接按FullPolicy处理并在返回的错误中报告
+func (s *Server) Publish(topic string, data []byte) error {
+	var errs []error
+	for _, conn := range s.Subscribers(topic) {
+		if err := conn.Send(websocket.TextMessage, data); err != nil {
+			errs = append(errs, fmt.Errorf("conn %d: %w", conn.id, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Subscribers returns a snapshot of the connections subscribed to topic
+// 返回主题订阅连接的快照
+func (s *Server) Subscribers(topic string) []*Conn {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	subs := s.topics[topic]
+	conns := make([]*Conn, 0, len(subs))
+	for _, conn := range subs {
+		conns = append(conns, conn)
+	}
+	return conns
+}
+
+// Topics returns the topics that have at least one subscriber
+// 返回至少有一个订阅者的主题
+func (s *Server) Topics() []string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	topics := make([]string, 0, len(s.topics))
+	for topic := range s.topics {
+		topics = append(topics, topic)
+	}
+	return topics
+}
+
+// unsubscribe removes conn from topic, the caller must hold s.mu
+// 将连接从主题中移除，调用方需持有s.mu
+func (s *Server) unsubscribe(conn *Conn, topic string) {
+	delete(conn.topics, topic)
+	subs, ok := s.topics[topic]
+	if !ok {
+		return
+	}
+	delete(subs, conn.id)
+	if len(subs) == 0 {
+		delete(s.topics, topic)
+	}
+}
+
+// unsubscribeAll removes conn from all of its topics, the caller must hold s.mu
+// 将连接从所有主题中移除，调用方需持有s.mu
+func (s *Server) unsubscribeAll(conn *Conn) {
+	for topic := range conn.topics {
+		s.unsubscribe(conn, topic)
+	}
+}
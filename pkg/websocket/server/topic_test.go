@@ -0,0 +1,79 @@
+package server
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"go.uber.org/zap"
+)
+
+func TestTopics(t *testing.T) {
+	cfg := NewConfig()
+	connected := make(chan *Conn, 3)
+	disconnected := make(chan *Conn, 3)
+	cfg.SetOnConnect(func(conn *Conn) { connected <- conn })
+	cfg.SetOnDisconnect(func(conn *Conn) { disconnected <- conn })
+	s := NewServer(zap.NewNop(), cfg)
+	url := newTestServer(t, s)
+
+	ws1, c1 := dial(t, url, connected)
+	ws2, c2 := dial(t, url, connected)
+	ws3, c3 := dial(t, url, connected)
+	for _, err := range []error{s.Join(c1, "news"), c2.Join("news"), c2.Join("news"), c3.Join("sports")} {
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	if n := len(s.Subscribers("news")); n != 2 {
+		t.Fatalf("Expected 2 news subscribers, got %d", n)
+	}
+
+	if err := s.Publish("news", []byte("headline")); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Publish("sports", []byte("score")); err != nil {
+		t.Fatal(err)
+	}
+	for _, ws := range []*websocket.Conn{ws1, ws2} {
+		if got := readText(t, ws); got != "headline" {
+			t.Errorf("Expected headline, got %q", got)
+		}
+	}
+	if got := readText(t, ws3); got != "score" {
+		t.Errorf("Expected only sports on ws3, got %q", got)
+	}
+
+	// 离开主题后不再收到消息，空主题被删除
+	c3.Leave("sports")
+	if topics := s.Topics(); len(topics) != 1 || topics[0] != "news" {
+		t.Errorf("Expected only news to remain, got %v", topics)
+	}
+	c1.Leave("news")
+	if err := s.Publish("news", []byte("second")); err != nil {
+		t.Fatal(err)
+	}
+	if got := readText(t, ws2); got != "second" {
+		t.Errorf("Expected second on ws2, got %q", got)
+	}
+	_ = ws1.SetReadDeadline(time.Now().Add(50 * time.Millisecond))
+	if _, _, err := ws1.ReadMessage(); err == nil {
+		t.Error("Expected no message after leaving")
+	}
+
+	// 关闭的连接自动离开所有主题，且不能再加入
+	_ = ws2.Close()
+	if conn := <-disconnected; conn != c2 {
+		t.Fatalf("Expected c2 to disconnect, got %d", conn.ID())
+	}
+	if n := len(s.Subscribers("news")); n != 0 {
+		t.Errorf("Expected no news subscribers, got %d", n)
+	}
+	if len(s.Topics()) != 0 {
+		t.Errorf("Expected no topics, got %v", s.Topics())
+	}
+	if err := c2.Join("news"); !errors.Is(err, ErrConnClosed) {
+		t.Errorf("Expected ErrConnClosed, got %v", err)
+	}
+}
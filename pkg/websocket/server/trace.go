@@ -0,0 +1,206 @@
+package server
+
+import (
+	"bufio"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/andrewbytecoder/nmq/pkg/utils"
+	"github.com/gorilla/websocket"
+	"go.uber.org/zap"
+)
+
+// ErrInvalidTraceDuration is returned by TraceConn when the duration is not positive
+// TraceConn 的跟踪时长不是正数
+var ErrInvalidTraceDuration = errors.New("websocket: trace duration must be positive")
+
+// Frame directions written to the trace file
+// 跟踪文件中的帧方向
+const (
+	traceSend = "send"
+	traceRecv = "recv"
+)
+
+// tracer writes annotated hex dumps of the frames of one connection to a dedicated file
+// until it expires or the connection closes
+// 将一个连接收发的帧以带注释的十六进制形式写入独立文件，到期或连接关闭时结束
+type tracer struct {
+	mu      sync.Mutex
+	path    string
+	f       *os.File
+	w       *bufio.Writer
+	expires time.Time
+	timer   *time.Timer
+}
+
+// frame records one frame with its direction, opcode and length
+// 记录一帧的方向、操作码和长度
+func (t *tracer) frame(dir string, opcode int, data []byte) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.f == nil {
+		return
+	}
+	fmt.Fprintf(t.w, "%s %s opcode=%d(%s) len=%d\n", time.Now().Format(time.RFC3339Nano), dir, opcode, opcodeName(opcode), len(data))
+	if len(data) > 0 {
+		d := hex.Dumper(t.w)
+		_, _ = d.Write(data)
+		_ = d.Close()
+	}
+	_ = t.w.Flush()
+}
+
+// close flushes and closes the trace file, it is safe to call more than once
+// 刷新并关闭跟踪文件，可重复调用
+func (t *tracer) close() error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.f == nil {
+		return nil
+	}
+	t.timer.Stop()
+	err := t.w.Flush()
+	if e := t.f.Close(); err == nil {
+		err = e
+	}
+	t.f, t.w = nil, nil
+	return err
+}
+
+// opcodeName returns the name of a websocket opcode
+// 返回websocket操作码的名称
+func opcodeName(opcode int) string {
+	switch opcode {
+	case websocket.TextMessage:
+		return "text"
+	case websocket.BinaryMessage:
+		return "binary"
+	case websocket.CloseMessage:
+		return "close"
+	case websocket.PingMessage:
+		return "ping"
+	case websocket.PongMessage:
+		return "pong"
+	}
+	return "unknown"
+}
+
+// traceFrame records a frame when tracing is on for the connection
+// 连接开启跟踪时记录一帧
+func (c *Conn) traceFrame(dir string, opcode int, data []byte) {
+	if t := c.trace.Load(); t != nil {
+		t.frame(dir, opcode, data)
+	}
+}
+
+// stopTrace ends tracing t if it is still the active tracer of the connection
+// t 仍是连接当前的跟踪时结束跟踪
+func (c *Conn) stopTrace(t *tracer) {
+	if c.trace.CompareAndSwap(t, nil) {
+		if err := t.close(); err != nil {
+			c.srv.log.Warn("close trace file failed", zap.String("path", t.path), zap.Error(err))
+		}
+	}
+}
+
+// traceConn finds the connection by the decimal id returned by SnowID.String
+// 按SnowID.String返回的十进制ID查找连接
+func (s *Server) traceConn(id string) (*Conn, error) {
+	sid, err := utils.ParseString(id)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrConnNotFound, id)
+	}
+	conn, ok := s.Conn(sid)
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrConnNotFound, id)
+	}
+	return conn, nil
+}
+
+// TraceConn writes hex dumps of the frames sent and received by the connection with the given id
+// to a file in TraceDir for d, tracing a connection again extends it to d from now.
+// Returns the path of the trace file
+// 在d时间内将指定ID连接收发的帧以十六进制写入TraceDir下的文件，再次开启时从现在起延长到d，返回跟踪文件路径
+func (s *Server) TraceConn(id string, d time.Duration) (string, error) {
+	if d <= 0 {
+		return "", ErrInvalidTraceDuration
+	}
+	conn, err := s.traceConn(id)
+	if err != nil {
+		return "", err
+	}
+	if t := conn.trace.Load(); t != nil {
+		t.mu.Lock()
+		active := t.f != nil && t.timer.Stop()
+		if active {
+			t.expires = time.Now().Add(d)
+			t.timer.Reset(d)
+		}
+		t.mu.Unlock()
+		if active {
+			return t.path, nil
+		}
+	}
+
+	if err = os.MkdirAll(s.cfg.TraceDir, 0o755); err != nil {
+		return "", err
+	}
+	name := fmt.Sprintf("ws-trace-%s-%s.log", id, time.Now().Format("20060102T150405"))
+	path := filepath.Join(s.cfg.TraceDir, name)
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o600)
+	if err != nil {
+		return "", err
+	}
+	t := &tracer{path: path, f: f, w: bufio.NewWriter(f), expires: time.Now().Add(d)}
+	t.mu.Lock()
+	t.timer = time.AfterFunc(d, func() { conn.stopTrace(t) })
+	t.mu.Unlock()
+	if old := conn.trace.Swap(t); old != nil {
+		_ = old.close()
+	}
+	// The connection may have closed while the file was opened
+	// 打开文件期间连接可能已经关闭
+	select {
+	case <-conn.done:
+		conn.stopTrace(t)
+		return "", fmt.Errorf("%w: %s", ErrConnClosed, id)
+	default:
+	}
+	s.log.Info("connection trace started", zap.String("id", id), zap.String("path", path), zap.Duration("duration", d))
+	return path, nil
+}
+
+// StopConnTrace stops tracing the connection with the given id, it is a no-op when the connection is not traced
+// 结束指定ID连接的跟踪，连接未开启跟踪时不做任何操作
+func (s *Server) StopConnTrace(id string) error {
+	conn, err := s.traceConn(id)
+	if err != nil {
+		return err
+	}
+	if t := conn.trace.Load(); t != nil {
+		conn.stopTrace(t)
+		s.log.Info("connection trace stopped", zap.String("id", id), zap.String("path", t.path))
+	}
+	return nil
+}
+
+// ConnTraces returns the ids of the traced connections and when their traces expire
+// 返回正在跟踪的连接ID及跟踪的结束时间
+func (s *Server) ConnTraces() map[string]time.Time {
+	traces := make(map[string]time.Time)
+	for _, conn := range s.Conns() {
+		if t := conn.trace.Load(); t != nil {
+			t.mu.Lock()
+			if t.f != nil {
+				traces[conn.id.String()] = t.expires
+			}
+			t.mu.Unlock()
+		}
+	}
+	return traces
+}
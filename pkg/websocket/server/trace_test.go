@@ -0,0 +1,89 @@
+package server
+
+import (
+	"errors"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"go.uber.org/zap"
+)
+
+func TestTraceConn(t *testing.T) {
+	cfg := NewConfig(SetTraceDir(t.TempDir()))
+	connected := make(chan *Conn, 1)
+	cfg.SetOnConnect(func(conn *Conn) { connected <- conn })
+	cfg.SetOnMessage(func(conn *Conn, messageType int, data []byte) {
+		_ = conn.Send(messageType, append([]byte("echo:"), data...))
+	})
+	s := NewServer(zap.NewNop(), cfg)
+	ws, conn := dial(t, newTestServer(t, s), connected)
+	id := conn.ID().String()
+
+	if _, err := s.TraceConn("42", time.Minute); !errors.Is(err, ErrConnNotFound) {
+		t.Errorf("Expected ErrConnNotFound, got %v", err)
+	}
+	if _, err := s.TraceConn(id, 0); !errors.Is(err, ErrInvalidTraceDuration) {
+		t.Errorf("Expected ErrInvalidTraceDuration, got %v", err)
+	}
+	path, err := s.TraceConn(id, time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 再次开启延长同一个跟踪
+	if again, err := s.TraceConn(id, time.Hour); err != nil || again != path {
+		t.Errorf("Expected trace to be extended, got %q, %v", again, err)
+	}
+	if exp, ok := s.ConnTraces()[id]; !ok || time.Until(exp) < 59*time.Minute {
+		t.Errorf("Expected trace expiring in an hour, got %v, %v", exp, ok)
+	}
+
+	if err = ws.WriteMessage(websocket.TextMessage, []byte("hi")); err != nil {
+		t.Fatal(err)
+	}
+	if got := readText(t, ws); got != "echo:hi" {
+		t.Fatalf("Expected echo, got %q", got)
+	}
+	if err = s.StopConnTrace(id); err != nil {
+		t.Fatal(err)
+	}
+	if len(s.ConnTraces()) != 0 {
+		t.Errorf("Expected no traces after stop, got %v", s.ConnTraces())
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"recv opcode=1(text) len=2",
+		"send opcode=1(text) len=7",
+		"68 69", // hi
+		"|echo:hi|",
+	} {
+		if !strings.Contains(string(data), want) {
+			t.Errorf("Expected trace to contain %q, got:\n%s", want, data)
+		}
+	}
+}
+
+func TestTraceConnExpires(t *testing.T) {
+	cfg := NewConfig(SetTraceDir(t.TempDir()))
+	connected := make(chan *Conn, 1)
+	cfg.SetOnConnect(func(conn *Conn) { connected <- conn })
+	s := NewServer(zap.NewNop(), cfg)
+	_, conn := dial(t, newTestServer(t, s), connected)
+
+	if _, err := s.TraceConn(conn.ID().String(), 10*time.Millisecond); err != nil {
+		t.Fatal(err)
+	}
+	deadline := time.Now().Add(time.Second)
+	for conn.trace.Load() != nil {
+		if time.Now().After(deadline) {
+			t.Fatal("Expected trace to expire")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
@@ -0,0 +1,10 @@
+//go:build !windows
+
+package workdir
+
+import "os"
+
+// restrict 将目录权限设置为仅属主可读写执行
+func restrict(dir string) error {
+	return os.Chmod(dir, 0o700)
+}
@@ -0,0 +1,49 @@
+//go:build windows
+
+package workdir
+
+import "golang.org/x/sys/windows"
+
+// restrict 为目录设置受保护的 DACL，只允许当前用户、SYSTEM 和管理员组访问，并由子目录和文件继承
+func restrict(dir string) error {
+	user, err := windows.GetCurrentProcessToken().GetTokenUser()
+	if err != nil {
+		return err
+	}
+	system, err := windows.CreateWellKnownSid(windows.WinLocalSystemSid)
+	if err != nil {
+		return err
+	}
+	admins, err := windows.CreateWellKnownSid(windows.WinBuiltinAdministratorsSid)
+	if err != nil {
+		return err
+	}
+
+	entries := []windows.EXPLICIT_ACCESS{
+		grant(user.User.Sid, windows.TRUSTEE_IS_USER),
+		grant(system, windows.TRUSTEE_IS_WELL_KNOWN_GROUP),
+		grant(admins, windows.TRUSTEE_IS_WELL_KNOWN_GROUP),
+	}
+	acl, err := windows.ACLFromEntries(entries, nil)
+	if err != nil {
+		return err
+	}
+	// PROTECTED 阻止从父目录继承更宽松的权限
+	return windows.SetNamedSecurityInfo(dir, windows.SE_FILE_OBJECT,
+		windows.DACL_SECURITY_INFORMATION|windows.PROTECTED_DACL_SECURITY_INFORMATION,
+		nil, nil, acl, nil)
+}
+
+// grant 构造授予完全控制权限的访问项
+func grant(sid *windows.SID, typ windows.TRUSTEE_TYPE) windows.EXPLICIT_ACCESS {
+	return windows.EXPLICIT_ACCESS{
+		AccessPermissions: windows.GENERIC_ALL,
+		AccessMode:        windows.GRANT_ACCESS,
+		Inheritance:       windows.SUB_CONTAINERS_AND_OBJECTS_INHERIT,
+		Trustee: windows.TRUSTEE{
+			TrusteeForm:  windows.TRUSTEE_IS_SID,
+			TrusteeType:  typ,
+			TrusteeValue: windows.TrusteeValueFromSID(sid),
+		},
+	}
+}
@@ -0,0 +1,180 @@
+// Package workdir 管理 nmq 的工作目录：创建 log/、data/、tmp/ 目录结构，
+// 在 Unix 上设置仅属主可访问的权限、在 Windows 上设置仅当前用户和管理员可访问的 ACL，
+// 启动时检查目录可写，并将 "./log/ncp.log" 这类相对路径定位到工作目录下而不是进程的当前目录
+package workdir
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/andrewbytecoder/nmq/pkg/options"
+)
+
+// 工作目录下的标准子目录
+const (
+	LogDir  = "log"
+	DataDir = "data"
+	TmpDir  = "tmp"
+)
+
+// ErrNotWritable 工作目录或其子目录不可写
+var ErrNotWritable = errors.New("workdir: directory is not writable")
+
+// Config 工作目录配置
+type Config struct {
+	// Dirs 需要创建的子目录，默认为 log、data、tmp
+	Dirs []string
+	// Restrict 是否限制目录只有当前用户可以访问，默认开启
+	Restrict bool
+}
+
+// SetDirs 设置需要创建的子目录
+func SetDirs(dirs ...string) options.Option {
+	return func(c any) {
+		if c, ok := c.(*Config); ok {
+			c.Dirs = dirs
+		}
+	}
+}
+
+// SetRestrict 设置是否限制目录只有当前用户可以访问
+func SetRestrict(restrict bool) options.Option {
+	return func(c any) {
+		if c, ok := c.(*Config); ok {
+			c.Restrict = restrict
+		}
+	}
+}
+
+// NewConfig 创建工作目录配置
+func NewConfig(opts ...options.Option) *Config {
+	c := &Config{
+		Dirs:     []string{LogDir, DataDir, TmpDir},
+		Restrict: true,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// Manager 工作目录管理器
+type Manager struct {
+	root string
+	cfg  *Config
+}
+
+// New 创建工作目录管理器，root 为空时使用进程的当前目录
+//
+// 会创建根目录和子目录、按配置限制访问权限并检查可写，任意一步失败都返回错误
+func New(root string, opts ...options.Option) (*Manager, error) {
+	abs, err := Root(root)
+	if err != nil {
+		return nil, err
+	}
+	m := &Manager{root: abs, cfg: NewConfig(opts...)}
+	if err = m.init(); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// Root 返回 root 的绝对路径，root 为空时返回进程的当前目录
+func Root(root string) (string, error) {
+	if root == "" {
+		return os.Getwd()
+	}
+	return filepath.Abs(root)
+}
+
+// Resolve 将相对路径定位到 root 下，绝对路径原样返回，root 为空时相对于进程的当前目录
+//
+// 用于在创建 Manager 之前（例如创建日志记录器时）确定文件位置
+func Resolve(root, path string) string {
+	if path == "" || filepath.IsAbs(path) || root == "" {
+		return filepath.Clean(path)
+	}
+	return filepath.Join(root, path)
+}
+
+// init 创建目录、限制权限并检查可写
+func (m *Manager) init() error {
+	dirs := append([]string{m.root}, m.dirs()...)
+	for _, dir := range dirs {
+		if err := os.MkdirAll(dir, 0o700); err != nil {
+			return err
+		}
+	}
+	if m.cfg.Restrict {
+		// 子目录继承根目录的 ACL，Unix 上需要逐个设置
+		for _, dir := range dirs {
+			if err := restrict(dir); err != nil {
+				return fmt.Errorf("workdir: restrict %s: %w", dir, err)
+			}
+		}
+	}
+	return m.Validate()
+}
+
+// dirs 返回所有子目录的绝对路径
+func (m *Manager) dirs() []string {
+	dirs := make([]string, len(m.cfg.Dirs))
+	for i, d := range m.cfg.Dirs {
+		dirs[i] = m.Path(d)
+	}
+	return dirs
+}
+
+// Validate 通过创建并删除临时文件检查根目录和所有子目录可写
+func (m *Manager) Validate() error {
+	for _, dir := range append([]string{m.root}, m.dirs()...) {
+		f, err := os.CreateTemp(dir, ".nmq-write-check-*")
+		if err != nil {
+			return fmt.Errorf("%w: %s: %v", ErrNotWritable, dir, err)
+		}
+		name := f.Name()
+		_, err = f.Write([]byte("ok"))
+		if e := f.Close(); err == nil {
+			err = e
+		}
+		if e := os.Remove(name); err == nil {
+			err = e
+		}
+		if err != nil {
+			return fmt.Errorf("%w: %s: %v", ErrNotWritable, dir, err)
+		}
+	}
+	return nil
+}
+
+// Root 返回工作目录的绝对路径
+func (m *Manager) Root() string {
+	return m.root
+}
+
+// Path 返回工作目录下的路径
+func (m *Manager) Path(elem ...string) string {
+	return filepath.Join(append([]string{m.root}, elem...)...)
+}
+
+// Resolve 将相对路径定位到工作目录下，绝对路径原样返回
+func (m *Manager) Resolve(path string) string {
+	return Resolve(m.root, path)
+}
+
+// LogDir 返回日志目录
+func (m *Manager) LogDir() string {
+	return m.Path(LogDir)
+}
+
+// DataDir 返回数据目录
+func (m *Manager) DataDir() string {
+	return m.Path(DataDir)
+}
+
+// TmpDir 返回临时文件目录
+func (m *Manager) TmpDir() string {
+	return m.Path(TmpDir)
+}
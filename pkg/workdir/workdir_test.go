@@ -0,0 +1,82 @@
+// workdir_test.go
+package workdir
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+func TestNew(t *testing.T) {
+	root := filepath.Join(t.TempDir(), "nmq")
+	m, err := New(root)
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	if m.Root() != root {
+		t.Errorf("Expected root %s, got %s", root, m.Root())
+	}
+	for _, dir := range []string{m.LogDir(), m.DataDir(), m.TmpDir()} {
+		info, err := os.Stat(dir)
+		if err != nil {
+			t.Fatalf("Expected %s to exist: %v", dir, err)
+		}
+		if runtime.GOOS != "windows" && info.Mode().Perm() != 0o700 {
+			t.Errorf("Expected %s to be 0700, got %v", dir, info.Mode().Perm())
+		}
+	}
+	entries, _ := os.ReadDir(m.LogDir())
+	if len(entries) != 0 {
+		t.Errorf("Expected write check files to be removed, got %v", entries)
+	}
+
+	if got := m.Resolve("./log/ncp.log"); got != filepath.Join(root, "log", "ncp.log") {
+		t.Errorf("Unexpected resolved path %s", got)
+	}
+	abs := filepath.Join(t.TempDir(), "abs.log")
+	if got := m.Resolve(abs); got != abs {
+		t.Errorf("Expected absolute path to be kept, got %s", got)
+	}
+}
+
+func TestResolve(t *testing.T) {
+	if got := Resolve("", "./log/ncp.log"); got != filepath.Join("log", "ncp.log") {
+		t.Errorf("Expected path relative to cwd, got %s", got)
+	}
+	if got := Resolve("/srv/nmq", "data/cache.db"); got != filepath.Join("/srv/nmq", "data", "cache.db") {
+		t.Errorf("Unexpected resolved path %s", got)
+	}
+}
+
+func TestCustomDirs(t *testing.T) {
+	root := t.TempDir()
+	m, err := New(root, SetDirs("wal", "backup"), SetRestrict(false))
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	if _, err = os.Stat(m.Path("wal")); err != nil {
+		t.Errorf("Expected wal dir: %v", err)
+	}
+	if _, err = os.Stat(m.LogDir()); !os.IsNotExist(err) {
+		t.Errorf("Expected log dir not to be created, got %v", err)
+	}
+}
+
+func TestValidateNotWritable(t *testing.T) {
+	if runtime.GOOS == "windows" || os.Geteuid() == 0 {
+		t.Skip("permission bits are not enforced")
+	}
+	m, err := New(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = os.Chmod(m.DataDir(), 0o500); err != nil {
+		t.Fatal(err)
+	}
+	defer os.Chmod(m.DataDir(), 0o700)
+	if err = m.Validate(); !errors.Is(err, ErrNotWritable) {
+		t.Errorf("Expected ErrNotWritable, got %v", err)
+	}
+}
@@ -0,0 +1,426 @@
+package admin
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/andrewbytecoder/nmq/interfaces"
+	"github.com/andrewbytecoder/nmq/interfaces/nmq"
+	"github.com/andrewbytecoder/nmq/pkg/correlation"
+	"github.com/andrewbytecoder/nmq/pkg/network/listener"
+	"github.com/andrewbytecoder/nmq/pkg/options"
+	"github.com/andrewbytecoder/nmq/pkg/recovery"
+	"github.com/andrewbytecoder/nmq/pkg/websocket/server"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+var (
+	// ErrNoAdmin 组件管理器没有实现 ComponentAdmin
+	ErrNoAdmin = errors.New("admin: component manager does not support administration")
+	// ErrNoQuarantine 没有组件提供消息隔离区
+	ErrNoQuarantine = errors.New("admin: no message quarantine registered")
+	// ErrNoLoggers 没有可以调整日志级别的上下文
+	ErrNoLoggers = errors.New("admin: component log levels not available")
+	// ErrNoConnTracer 没有组件提供连接帧跟踪
+	ErrNoConnTracer = errors.New("admin: no connection tracer registered")
+)
+
+// websocket 服务器可以直接作为连接帧跟踪返回
+var _ nmq.ConnTracer = (*server.Server)(nil)
+
+// defaultTraceMinutes 开启连接跟踪时没有指定时长使用的分钟数
+const defaultTraceMinutes = 5
+
+// Config 管理端点配置
+type Config struct {
+	Addr            string        `mapstructure:"addr" validate:"required"`            // 监听地址
+	Token           string        `mapstructure:"token"`                               // 访问令牌，非空时请求需要携带 Authorization: Bearer <token>
+	Timeout         time.Duration `mapstructure:"timeout" validate:"min=1ms"`          // 读取请求头的超时时间
+	ShutdownTimeout time.Duration `mapstructure:"shutdown_timeout" validate:"min=1ms"` // 停止时等待请求处理完成的时间
+}
+
+// SetAddr 设置监听地址
+func SetAddr(addr string) options.Option {
+	return func(c any) {
+		if c, ok := c.(*Config); ok {
+			c.Addr = addr
+		}
+	}
+}
+
+// SetToken 设置访问令牌
+func SetToken(token string) options.Option {
+	return func(c any) {
+		if c, ok := c.(*Config); ok {
+			c.Token = token
+		}
+	}
+}
+
+// NewConfig 创建管理端点配置，默认只监听本机 127.0.0.1:8082
+func NewConfig(opts ...options.Option) *Config {
+	c := &Config{
+		Addr:            "127.0.0.1:8082",
+		Timeout:         5 * time.Second,
+		ShutdownTimeout: 5 * time.Second,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// Component 组件管理器的 REST 管理端点
+//
+//	GET  /admin/components               列出所有组件的名称、版本和状态
+//	GET  /admin/components/{name}        查看单个组件
+//	POST /admin/components/{name}/start  启动组件
+//	POST /admin/components/{name}/stop   停止组件
+//	POST /admin/components/{name}/reset  重置组件
+//	GET  /admin/config                   查看当前配置，敏感字段已脱敏
+//	POST /admin/config/reload            重新加载配置
+//	GET  /admin/loglevels                查看各组件的日志级别
+//	PUT  /admin/loglevels/{name}         调整组件的日志级别，请求体为 {"level": "debug"}
+//	GET  /admin/traces                   查看正在跟踪的连接及结束时间
+//	PUT  /admin/traces/{id}              开启连接的帧跟踪，请求体为 {"minutes": 5}，到期自动结束
+//	DELETE /admin/traces/{id}            提前结束连接的帧跟踪
+type Component struct {
+	nmq.ComponentBase
+	cfg   *Config
+	admin nmq.ComponentAdmin
+	srv   *http.Server
+}
+
+// NewAdminComponent 创建管理端点组件
+func NewAdminComponent(ctx nmq.NmqContext, opts ...options.Option) *Component {
+	return &Component{
+		ComponentBase: nmq.NewNamedComponentBase(ctx, interfaces.AdminComponentName),
+		cfg:           NewConfig(opts...),
+	}
+}
+
+// GetInterface 获取组件内部某个接口的实现
+func (ac *Component) GetInterface(uuid string) any {
+	return nil
+}
+
+// ConfigSection 返回配置，组件管理器将配置文件中的 admin 配置段解码到其中
+func (ac *Component) ConfigSection() any {
+	return ac.cfg
+}
+
+// Init 初始化组件
+func (ac *Component) Init() error {
+	admin, ok := ac.ComponentManager.(nmq.ComponentAdmin)
+	if !ok {
+		return ErrNoAdmin
+	}
+	ac.admin = admin
+
+	rec := recovery.New(recovery.SetLogger(ac.Log))
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /admin/components", ac.listComponents)
+	mux.HandleFunc("GET /admin/components/{name}", ac.getComponent)
+	mux.HandleFunc("POST /admin/components/{name}/{action}", ac.operateComponent)
+	mux.HandleFunc("GET /admin/config", ac.getConfig)
+	mux.HandleFunc("POST /admin/config/reload", ac.reloadConfig)
+	mux.HandleFunc("GET /admin/loglevels", ac.listLogLevels)
+	mux.HandleFunc("PUT /admin/loglevels/{name}", ac.setLogLevel)
+	mux.HandleFunc("GET /admin/quarantine", ac.listQuarantined)
+	mux.HandleFunc("POST /admin/quarantine/{id}/retry", ac.retryQuarantined)
+	mux.HandleFunc("DELETE /admin/quarantine/{id}", ac.releaseQuarantined)
+	mux.HandleFunc("GET /admin/traces", ac.listTraces)
+	mux.HandleFunc("PUT /admin/traces/{id}", ac.startTrace)
+	mux.HandleFunc("DELETE /admin/traces/{id}", ac.stopTrace)
+	ac.srv = &http.Server{Handler: correlation.Middleware(rec.Middleware("admin", ac.auth(mux))), ReadHeaderTimeout: ac.cfg.Timeout}
+	return nil
+}
+
+// Start 启动HTTP端点
+func (ac *Component) Start() error {
+	ln, err := listener.Listen("tcp", ac.cfg.Addr, listener.NewConfig())
+	if err != nil {
+		ac.Log.Error("admin listen failed", zap.String("addr", ac.cfg.Addr), zap.Error(err))
+		return err
+	}
+	ac.serve(ln)
+	return nil
+}
+
+// serve 在后台协程中处理请求
+func (ac *Component) serve(ln net.Listener) {
+	go func() {
+		if err := ac.srv.Serve(ln); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			ac.Log.Error("admin server stopped", zap.Error(err))
+		}
+	}()
+}
+
+// Stop 停止HTTP端点
+func (ac *Component) Stop() error {
+	if ac.srv == nil {
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), ac.cfg.ShutdownTimeout)
+	defer cancel()
+	return ac.srv.Shutdown(ctx)
+}
+
+// Reset 重置组件
+func (ac *Component) Reset() error {
+	return nil
+}
+
+// GetName 获取组件名称
+func (ac *Component) GetName() string {
+	return interfaces.AdminComponentName
+}
+
+// GetVersion 获取组件版本号
+func (ac *Component) GetVersion() string {
+	return "1.0.0"
+}
+
+// Notify 接收系统广播事件
+func (ac *Component) Notify(event string, data any) {}
+
+// auth 配置了访问令牌时校验 Authorization 请求头
+func (ac *Component) auth(next http.Handler) http.Handler {
+	if ac.cfg.Token == "" {
+		return next
+	}
+	want := []byte("Bearer " + ac.cfg.Token)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if subtle.ConstantTimeCompare([]byte(r.Header.Get("Authorization")), want) != 1 {
+			writeError(w, http.StatusUnauthorized, errors.New("unauthorized"))
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func (ac *Component) listComponents(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, ac.admin.ListComponents())
+}
+
+func (ac *Component) getComponent(w http.ResponseWriter, r *http.Request) {
+	name := r.PathValue("name")
+	for _, info := range ac.admin.ListComponents() {
+		if info.Name == name {
+			writeJSON(w, http.StatusOK, info)
+			return
+		}
+	}
+	writeError(w, http.StatusNotFound, nmq.ErrComponentNotFound)
+}
+
+// operateComponent 启动、停止或重置组件，成功后返回组件的最新信息
+func (ac *Component) operateComponent(w http.ResponseWriter, r *http.Request) {
+	name := r.PathValue("name")
+	var err error
+	switch action := r.PathValue("action"); action {
+	case "start":
+		err = ac.admin.StartComponent(name)
+	case "stop":
+		err = ac.admin.StopComponent(name)
+	case "reset":
+		err = ac.admin.ResetComponent(name)
+	default:
+		writeError(w, http.StatusNotFound, errors.New("unknown action "+action))
+		return
+	}
+	ac.Log.Info("admin component operation", zap.String("component", name), zap.String("action", r.PathValue("action")),
+		zap.String("remote", r.RemoteAddr), correlation.Field(r.Context()), zap.Error(err))
+	if err != nil {
+		writeError(w, errorStatus(err), err)
+		return
+	}
+	ac.getComponent(w, r)
+}
+
+func (ac *Component) getConfig(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, ac.admin.ConfigSettings())
+}
+
+func (ac *Component) reloadConfig(w http.ResponseWriter, r *http.Request) {
+	err := ac.admin.ReloadConfig()
+	ac.Log.Info("admin config reload", zap.String("remote", r.RemoteAddr), correlation.Field(r.Context()), zap.Error(err))
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// loggers 返回提供组件日志记录器的上下文
+func (ac *Component) loggers(w http.ResponseWriter) (nmq.ComponentLoggers, bool) {
+	if ac.NcpCtx == nil {
+		writeError(w, http.StatusNotFound, ErrNoLoggers)
+		return nil, false
+	}
+	return ac.NcpCtx, true
+}
+
+func (ac *Component) listLogLevels(w http.ResponseWriter, r *http.Request) {
+	if l, ok := ac.loggers(w); ok {
+		writeJSON(w, http.StatusOK, l.ComponentLogLevels())
+	}
+}
+
+// setLogLevel 运行时调整组件的日志级别
+func (ac *Component) setLogLevel(w http.ResponseWriter, r *http.Request) {
+	l, ok := ac.loggers(w)
+	if !ok {
+		return
+	}
+	var body struct {
+		Level zapcore.Level `json:"level"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	name := r.PathValue("name")
+	l.SetComponentLogLevel(name, body.Level)
+	ac.Log.Info("admin log level change", zap.String("component", name), zap.Stringer("level", body.Level),
+		zap.String("remote", r.RemoteAddr), correlation.Field(r.Context()))
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// quarantine 查找提供消息隔离区的组件
+func (ac *Component) quarantine(w http.ResponseWriter) (nmq.Quarantine, bool) {
+	if q, err := nmq.Resolve[nmq.Quarantine](ac.NcpCtx, nmq.QuarantineInterface); err == nil {
+		return q, true
+	}
+	writeError(w, http.StatusNotFound, ErrNoQuarantine)
+	return nil, false
+}
+
+func (ac *Component) listQuarantined(w http.ResponseWriter, r *http.Request) {
+	if q, ok := ac.quarantine(w); ok {
+		writeJSON(w, http.StatusOK, q.Quarantined())
+	}
+}
+
+// retryQuarantined 使用 canary 处理函数重试一次隔离的消息
+func (ac *Component) retryQuarantined(w http.ResponseWriter, r *http.Request) {
+	q, ok := ac.quarantine(w)
+	if !ok {
+		return
+	}
+	id := r.PathValue("id")
+	err := q.RetryQuarantined(r.Context(), id)
+	ac.Log.Info("admin quarantine retry", zap.String("id", id), zap.String("remote", r.RemoteAddr),
+		correlation.Field(r.Context()), zap.Error(err))
+	if err != nil {
+		writeError(w, errorStatus(err), err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// releaseQuarantined 将消息移出隔离区
+func (ac *Component) releaseQuarantined(w http.ResponseWriter, r *http.Request) {
+	q, ok := ac.quarantine(w)
+	if !ok {
+		return
+	}
+	id := r.PathValue("id")
+	err := q.ReleaseQuarantined(id)
+	ac.Log.Info("admin quarantine release", zap.String("id", id), zap.String("remote", r.RemoteAddr),
+		correlation.Field(r.Context()), zap.Error(err))
+	if err != nil {
+		writeError(w, errorStatus(err), err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// tracer 查找提供连接帧跟踪的组件
+func (ac *Component) tracer(w http.ResponseWriter) (nmq.ConnTracer, bool) {
+	if t, err := nmq.Resolve[nmq.ConnTracer](ac.NcpCtx, nmq.ConnTraceInterface); err == nil {
+		return t, true
+	}
+	writeError(w, http.StatusNotFound, ErrNoConnTracer)
+	return nil, false
+}
+
+func (ac *Component) listTraces(w http.ResponseWriter, r *http.Request) {
+	if t, ok := ac.tracer(w); ok {
+		writeJSON(w, http.StatusOK, t.ConnTraces())
+	}
+}
+
+// startTrace 开启连接的帧跟踪，返回跟踪文件路径
+func (ac *Component) startTrace(w http.ResponseWriter, r *http.Request) {
+	t, ok := ac.tracer(w)
+	if !ok {
+		return
+	}
+	body := struct {
+		Minutes int `json:"minutes"`
+	}{Minutes: defaultTraceMinutes}
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			writeError(w, http.StatusBadRequest, err)
+			return
+		}
+	}
+	id := r.PathValue("id")
+	file, err := t.TraceConn(id, time.Duration(body.Minutes)*time.Minute)
+	ac.Log.Info("admin connection trace start", zap.String("id", id), zap.Int("minutes", body.Minutes),
+		zap.String("remote", r.RemoteAddr), correlation.Field(r.Context()), zap.Error(err))
+	if err != nil {
+		writeError(w, errorStatus(err), err)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"id": id, "file": file, "minutes": body.Minutes})
+}
+
+// stopTrace 提前结束连接的帧跟踪
+func (ac *Component) stopTrace(w http.ResponseWriter, r *http.Request) {
+	t, ok := ac.tracer(w)
+	if !ok {
+		return
+	}
+	id := r.PathValue("id")
+	err := t.StopConnTrace(id)
+	ac.Log.Info("admin connection trace stop", zap.String("id", id), zap.String("remote", r.RemoteAddr),
+		correlation.Field(r.Context()), zap.Error(err))
+	if err != nil {
+		writeError(w, errorStatus(err), err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// errorStatus 将组件操作错误映射为HTTP状态码
+func errorStatus(err error) int {
+	switch {
+	case errors.Is(err, nmq.ErrComponentNotFound), errors.Is(err, nmq.ErrMessageNotFound),
+		errors.Is(err, server.ErrConnNotFound), errors.Is(err, server.ErrConnClosed):
+		return http.StatusNotFound
+	case errors.Is(err, nmq.ErrComponentSelf), errors.Is(err, server.ErrInvalidTraceDuration):
+		return http.StatusBadRequest
+	case errors.Is(err, nmq.ErrNoCanary):
+		return http.StatusNotImplemented
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+func writeJSON(w http.ResponseWriter, code int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, code int, err error) {
+	writeJSON(w, code, map[string]string{"error": err.Error()})
+}
@@ -0,0 +1,321 @@
+package admin
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/andrewbytecoder/nmq/interfaces/nmq"
+	"github.com/andrewbytecoder/nmq/pkg/websocket/server"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// fakeManager 记录管理操作的组件管理器
+type fakeManager struct {
+	nmq.ComponentManager
+	infos    []nmq.ComponentInfo
+	ops      []string
+	reloaded bool
+}
+
+func (m *fakeManager) ListComponents() []nmq.ComponentInfo { return m.infos }
+func (m *fakeManager) ConfigSettings() map[string]any {
+	return map[string]any{"db": map[string]any{"password": "******"}}
+}
+func (m *fakeManager) ReloadConfig() error { m.reloaded = true; return nil }
+func (m *fakeManager) StartComponent(name string) error {
+	return m.op("start", name)
+}
+func (m *fakeManager) StopComponent(name string) error {
+	return m.op("stop", name)
+}
+func (m *fakeManager) ResetComponent(name string) error {
+	return m.op("reset", name)
+}
+
+func (m *fakeManager) op(action, name string) error {
+	if name == "nmq" {
+		return nmq.ErrComponentSelf
+	}
+	for i, info := range m.infos {
+		if info.Name == name {
+			m.ops = append(m.ops, action+" "+name)
+			m.infos[i].Status = nmq.ComponentRunning
+			return nil
+		}
+	}
+	return nmq.ErrComponentNotFound
+}
+
+func newTestComponent(t *testing.T, m *fakeManager, token string) *Component {
+	t.Helper()
+	ac := &Component{cfg: NewConfig(SetToken(token))}
+	ac.Log = zap.NewNop()
+	ac.ComponentManager = m
+	if err := ac.Init(); err != nil {
+		t.Fatal(err)
+	}
+	return ac
+}
+
+func serve(ac *Component, method, path, token string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, nil)
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	rec := httptest.NewRecorder()
+	ac.srv.Handler.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestAdminComponents(t *testing.T) {
+	m := &fakeManager{infos: []nmq.ComponentInfo{{Name: "api", Version: "1.0.0", Status: nmq.ComponentStopped}}}
+	ac := newTestComponent(t, m, "")
+
+	rec := serve(ac, http.MethodGet, "/admin/components", "")
+	var infos []nmq.ComponentInfo
+	if err := json.NewDecoder(rec.Body).Decode(&infos); err != nil || rec.Code != http.StatusOK || len(infos) != 1 {
+		t.Fatalf("Unexpected list %d %+v %v", rec.Code, infos, err)
+	}
+
+	rec = serve(ac, http.MethodPost, "/admin/components/api/start", "")
+	var info nmq.ComponentInfo
+	if err := json.NewDecoder(rec.Body).Decode(&info); err != nil || rec.Code != http.StatusOK || info.Status != nmq.ComponentRunning {
+		t.Errorf("Unexpected start response %d %+v %v", rec.Code, info, err)
+	}
+
+	tests := []struct {
+		method, path string
+		code         int
+	}{
+		{http.MethodGet, "/admin/components/missing", http.StatusNotFound},
+		{http.MethodPost, "/admin/components/missing/stop", http.StatusNotFound},
+		{http.MethodPost, "/admin/components/nmq/stop", http.StatusBadRequest},
+		{http.MethodPost, "/admin/components/api/kill", http.StatusNotFound},
+		{http.MethodPost, "/admin/components/api/reset", http.StatusOK},
+		{http.MethodDelete, "/admin/components/api", http.StatusMethodNotAllowed},
+		{http.MethodPost, "/admin/config/reload", http.StatusNoContent},
+		{http.MethodGet, "/admin/config", http.StatusOK},
+	}
+	for _, tt := range tests {
+		if rec := serve(ac, tt.method, tt.path, ""); rec.Code != tt.code {
+			t.Errorf("%s %s: expected %d, got %d", tt.method, tt.path, tt.code, rec.Code)
+		}
+	}
+	if len(m.ops) != 2 || m.ops[1] != "reset api" || !m.reloaded {
+		t.Errorf("Unexpected operations %v reloaded=%v", m.ops, m.reloaded)
+	}
+}
+
+func TestAdminAuth(t *testing.T) {
+	ac := newTestComponent(t, &fakeManager{}, "secret")
+	if rec := serve(ac, http.MethodGet, "/admin/config", ""); rec.Code != http.StatusUnauthorized {
+		t.Errorf("Expected 401 without token, got %d", rec.Code)
+	}
+	if rec := serve(ac, http.MethodGet, "/admin/config", "wrong"); rec.Code != http.StatusUnauthorized {
+		t.Errorf("Expected 401 with wrong token, got %d", rec.Code)
+	}
+	if rec := serve(ac, http.MethodGet, "/admin/config", "secret"); rec.Code != http.StatusOK {
+		t.Errorf("Expected 200 with token, got %d", rec.Code)
+	}
+}
+
+// plainManager 没有实现 ComponentAdmin 的组件管理器
+type plainManager struct {
+	nmq.ComponentManager
+}
+
+func TestAdminInitWithoutAdmin(t *testing.T) {
+	ac := &Component{cfg: NewConfig()}
+	ac.Log = zap.NewNop()
+	ac.ComponentManager = &plainManager{}
+	if err := ac.Init(); !errors.Is(err, ErrNoAdmin) {
+		t.Errorf("Expected ErrNoAdmin, got %v", err)
+	}
+}
+
+// fakeQuarantine 记录操作的消息隔离区
+type fakeQuarantine struct {
+	msgs []nmq.QuarantinedMessage
+	ops  []string
+}
+
+func (q *fakeQuarantine) Quarantined() []nmq.QuarantinedMessage { return q.msgs }
+func (q *fakeQuarantine) RetryQuarantined(ctx context.Context, id string) error {
+	return q.op("retry", id)
+}
+func (q *fakeQuarantine) ReleaseQuarantined(id string) error {
+	return q.op("release", id)
+}
+
+func (q *fakeQuarantine) op(action, id string) error {
+	for _, m := range q.msgs {
+		if m.ID == id {
+			q.ops = append(q.ops, action+" "+id)
+			return nil
+		}
+	}
+	return nmq.ErrMessageNotFound
+}
+
+// quarantineContext 通过 GetInterface 提供消息隔离区的上下文
+type quarantineContext struct {
+	nmq.NmqContext
+	q nmq.Quarantine
+}
+
+func (c *quarantineContext) GetInterface(uuid string) any {
+	if uuid == nmq.QuarantineInterface && c.q != nil {
+		return c.q
+	}
+	return nil
+}
+
+func TestAdminQuarantine(t *testing.T) {
+	ac := newTestComponent(t, &fakeManager{}, "")
+	if rec := serve(ac, http.MethodGet, "/admin/quarantine", ""); rec.Code != http.StatusNotFound {
+		t.Errorf("Expected 404 without quarantine, got %d", rec.Code)
+	}
+
+	q := &fakeQuarantine{msgs: []nmq.QuarantinedMessage{{ID: "m1", Topic: "orders"}}}
+	ac.NcpCtx = &quarantineContext{q: q}
+	rec := serve(ac, http.MethodGet, "/admin/quarantine", "")
+	var msgs []nmq.QuarantinedMessage
+	if err := json.NewDecoder(rec.Body).Decode(&msgs); err != nil || rec.Code != http.StatusOK || len(msgs) != 1 {
+		t.Fatalf("Unexpected list %d %+v %v", rec.Code, msgs, err)
+	}
+
+	tests := []struct {
+		method string
+		path   string
+		code   int
+	}{
+		{http.MethodPost, "/admin/quarantine/m1/retry", http.StatusNoContent},
+		{http.MethodPost, "/admin/quarantine/m2/retry", http.StatusNotFound},
+		{http.MethodDelete, "/admin/quarantine/m1", http.StatusNoContent},
+	}
+	for _, tt := range tests {
+		if rec = serve(ac, tt.method, tt.path, ""); rec.Code != tt.code {
+			t.Errorf("%s %s: expected %d, got %d", tt.method, tt.path, tt.code, rec.Code)
+		}
+	}
+	if len(q.ops) != 2 || q.ops[0] != "retry m1" || q.ops[1] != "release m1" {
+		t.Errorf("Unexpected operations %v", q.ops)
+	}
+}
+
+// loggersContext 记录日志级别的上下文
+type loggersContext struct {
+	nmq.NmqContext
+	levels map[string]zapcore.Level
+}
+
+func (c *loggersContext) SetComponentLogLevel(name string, level zapcore.Level) {
+	c.levels[name] = level
+}
+func (c *loggersContext) ComponentLogLevels() map[string]zapcore.Level { return c.levels }
+
+func TestAdminLogLevels(t *testing.T) {
+	ac := newTestComponent(t, &fakeManager{}, "")
+	if rec := serve(ac, http.MethodGet, "/admin/loglevels", ""); rec.Code != http.StatusNotFound {
+		t.Errorf("Expected 404 without context, got %d", rec.Code)
+	}
+
+	c := &loggersContext{levels: map[string]zapcore.Level{"api": zapcore.InfoLevel}}
+	ac.NcpCtx = c
+	put := func(body string) int {
+		req := httptest.NewRequest(http.MethodPut, "/admin/loglevels/api", strings.NewReader(body))
+		rec := httptest.NewRecorder()
+		ac.srv.Handler.ServeHTTP(rec, req)
+		return rec.Code
+	}
+	if code := put(`{"level":"debug"}`); code != http.StatusNoContent || c.levels["api"] != zapcore.DebugLevel {
+		t.Errorf("Unexpected set response %d, levels %v", code, c.levels)
+	}
+	if code := put(`{"level":"verbose"}`); code != http.StatusBadRequest {
+		t.Errorf("Expected 400 for invalid level, got %d", code)
+	}
+
+	rec := serve(ac, http.MethodGet, "/admin/loglevels", "")
+	var levels map[string]string
+	if err := json.NewDecoder(rec.Body).Decode(&levels); err != nil || levels["api"] != "debug" {
+		t.Errorf("Unexpected levels %v: %v", levels, err)
+	}
+}
+
+// fakeTracer 记录跟踪操作的连接帧跟踪
+type fakeTracer struct {
+	traces map[string]time.Time
+}
+
+func (f *fakeTracer) TraceConn(id string, d time.Duration) (string, error) {
+	if id != "1" {
+		return "", server.ErrConnNotFound
+	}
+	f.traces[id] = time.Now().Add(d)
+	return "/tmp/ws-trace-1.log", nil
+}
+
+func (f *fakeTracer) StopConnTrace(id string) error {
+	if id != "1" {
+		return server.ErrConnNotFound
+	}
+	delete(f.traces, id)
+	return nil
+}
+
+func (f *fakeTracer) ConnTraces() map[string]time.Time { return f.traces }
+
+// tracerContext 通过 GetInterface 提供连接帧跟踪的上下文
+type tracerContext struct {
+	nmq.NmqContext
+	t nmq.ConnTracer
+}
+
+func (c *tracerContext) GetInterface(uuid string) any {
+	if uuid == nmq.ConnTraceInterface {
+		return c.t
+	}
+	return nil
+}
+
+func TestAdminTraces(t *testing.T) {
+	ac := newTestComponent(t, &fakeManager{}, "")
+	if rec := serve(ac, http.MethodGet, "/admin/traces", ""); rec.Code != http.StatusNotFound {
+		t.Errorf("Expected 404 without tracer, got %d", rec.Code)
+	}
+
+	tracer := &fakeTracer{traces: map[string]time.Time{}}
+	ac.NcpCtx = &tracerContext{t: tracer}
+	req := httptest.NewRequest(http.MethodPut, "/admin/traces/1", strings.NewReader(`{"minutes":10}`))
+	rec := httptest.NewRecorder()
+	ac.srv.Handler.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK || time.Until(tracer.traces["1"]) < 9*time.Minute {
+		t.Fatalf("Unexpected start response %d, traces %v", rec.Code, tracer.traces)
+	}
+
+	tests := []struct {
+		method string
+		path   string
+		code   int
+	}{
+		{http.MethodPut, "/admin/traces/2", http.StatusNotFound},
+		{http.MethodGet, "/admin/traces", http.StatusOK},
+		{http.MethodDelete, "/admin/traces/1", http.StatusNoContent},
+		{http.MethodDelete, "/admin/traces/2", http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		if rec = serve(ac, tt.method, tt.path, ""); rec.Code != tt.code {
+			t.Errorf("%s %s: expected %d, got %d", tt.method, tt.path, tt.code, rec.Code)
+		}
+	}
+	if len(tracer.traces) != 0 {
+		t.Errorf("Expected trace to be stopped, got %v", tracer.traces)
+	}
+}
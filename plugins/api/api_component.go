@@ -4,6 +4,7 @@ import (
 	"hash/fnv"
 
 	"github.com/andrewbytecoder/nmq/interfaces"
+	"github.com/andrewbytecoder/nmq/interfaces/network"
 	"github.com/andrewbytecoder/nmq/interfaces/nmq"
 	"github.com/andrewbytecoder/nmq/pkg/httpclient"
 	"github.com/andrewbytecoder/nmq/pkg/utils"
@@ -19,7 +20,7 @@ type Component struct {
 // NewNetComponent 创建网络组件实例
 func NewNetComponent(ctx nmq.NmqContext) *Component {
 	c := &Component{
-		ComponentBase: nmq.NewComponentBase(ctx),
+		ComponentBase: nmq.NewNamedComponentBase(ctx, interfaces.NetworkComponentName),
 	}
 	return c
 }
@@ -29,7 +30,7 @@ func NewNetComponent(ctx nmq.NmqContext) *Component {
 // @param uuid string 接口唯一标识
 // @return any 接口实现对象或 nil
 func (nc *Component) GetInterface(uuid string) any {
-	if uuid == "network_snow_flake" {
+	if uuid == network.SnowFlakeInterface {
 		return nc.snowNode
 	}
 
@@ -54,7 +55,7 @@ func (nc *Component) Init() error {
 		return err
 	}
 
-	return nil
+	return nmq.RegisterService[network.NetSnowFlake](network.SnowFlakeInterface, nc.snowNode)
 }
 
 // Start 启动组件
@@ -75,6 +76,7 @@ func (nc *Component) Stop() error {
 //
 // @return error 错误信息
 func (nc *Component) Reset() error {
+	nmq.UnregisterService(network.SnowFlakeInterface)
 	return nil
 }
 
@@ -85,6 +87,13 @@ func (nc *Component) GetName() string {
 	return interfaces.NetworkComponentName
 }
 
+// ShutdownPriority 作为流量入口最先停止
+//
+// @return nmq.ShutdownPriority 停止优先级
+func (nc *Component) ShutdownPriority() nmq.ShutdownPriority {
+	return nmq.ShutdownIngress
+}
+
 // GetVersion 获取组件版本号
 //
 // @return string 版本号
@@ -104,5 +113,5 @@ func (nc *Component) Notify(event string, data any) {
 //
 // @return ComponentStatus 当前状态
 func (nc *Component) GetStatus() nmq.ComponentStatus {
-	return nmq.ComponentOk
+	return nc.ComponentBase.GetStatus()
 }
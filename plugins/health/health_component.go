@@ -0,0 +1,165 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/andrewbytecoder/nmq/interfaces"
+	"github.com/andrewbytecoder/nmq/interfaces/nmq"
+	"github.com/andrewbytecoder/nmq/pkg/network/listener"
+	"github.com/andrewbytecoder/nmq/pkg/options"
+	"github.com/andrewbytecoder/nmq/pkg/recovery"
+	"go.uber.org/zap"
+)
+
+// 健康检查路径
+const (
+	LivenessPath  = "/healthz"
+	ReadinessPath = "/readyz"
+)
+
+// Config 健康检查端点配置
+type Config struct {
+	Addr            string        `mapstructure:"addr" validate:"required"`            // 监听地址
+	Timeout         time.Duration `mapstructure:"timeout" validate:"min=1ms"`          // 单次检查的超时时间
+	ShutdownTimeout time.Duration `mapstructure:"shutdown_timeout" validate:"min=1ms"` // 停止时等待请求处理完成的时间
+}
+
+// SetAddr 设置监听地址
+func SetAddr(addr string) options.Option {
+	return func(c any) {
+		if c, ok := c.(*Config); ok {
+			c.Addr = addr
+		}
+	}
+}
+
+// SetTimeout 设置单次检查的超时时间
+func SetTimeout(timeout time.Duration) options.Option {
+	return func(c any) {
+		if c, ok := c.(*Config); ok {
+			c.Timeout = timeout
+		}
+	}
+}
+
+// NewConfig 创建健康检查端点配置，默认监听 :8081，检查超时2秒
+func NewConfig(opts ...options.Option) *Config {
+	c := &Config{
+		Addr:            ":8081",
+		Timeout:         2 * time.Second,
+		ShutdownTimeout: 5 * time.Second,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// Component 提供 /healthz 存活检查和 /readyz 就绪检查的HTTP端点
+//
+// 状态正常时返回200，否则返回503，响应体为 JSON 格式的 HealthReport
+type Component struct {
+	nmq.ComponentBase
+	cfg *Config
+	srv *http.Server
+}
+
+// NewHealthComponent 创建健康检查端点组件
+func NewHealthComponent(ctx nmq.NmqContext, opts ...options.Option) *Component {
+	return &Component{
+		ComponentBase: nmq.NewNamedComponentBase(ctx, interfaces.HealthComponentName),
+		cfg:           NewConfig(opts...),
+	}
+}
+
+// GetInterface 获取组件内部某个接口的实现
+func (hc *Component) GetInterface(uuid string) any {
+	return nil
+}
+
+// ConfigSection 返回配置，组件管理器将配置文件中的 health 配置段解码到其中
+func (hc *Component) ConfigSection() any {
+	return hc.cfg
+}
+
+// Init 初始化组件
+func (hc *Component) Init() error {
+	rec := recovery.New(recovery.SetLogger(hc.Log))
+	mux := http.NewServeMux()
+	mux.Handle(LivenessPath, rec.Middleware("health.liveness", hc.handler(false)))
+	mux.Handle(ReadinessPath, rec.Middleware("health.readiness", hc.handler(true)))
+	hc.srv = &http.Server{Handler: mux, ReadHeaderTimeout: hc.cfg.Timeout}
+	return nil
+}
+
+// Start 启动HTTP端点
+func (hc *Component) Start() error {
+	ln, err := listener.Listen("tcp", hc.cfg.Addr, listener.NewConfig())
+	if err != nil {
+		hc.Log.Error("health listen failed", zap.String("addr", hc.cfg.Addr), zap.Error(err))
+		return err
+	}
+	hc.serve(ln)
+	return nil
+}
+
+// serve 在后台协程中处理请求
+func (hc *Component) serve(ln net.Listener) {
+	go func() {
+		if err := hc.srv.Serve(ln); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			hc.Log.Error("health server stopped", zap.Error(err))
+		}
+	}()
+}
+
+// Stop 停止HTTP端点
+func (hc *Component) Stop() error {
+	if hc.srv == nil {
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), hc.cfg.ShutdownTimeout)
+	defer cancel()
+	return hc.srv.Shutdown(ctx)
+}
+
+// Reset 重置组件
+func (hc *Component) Reset() error {
+	return nil
+}
+
+// GetName 获取组件名称
+func (hc *Component) GetName() string {
+	return interfaces.HealthComponentName
+}
+
+// GetVersion 获取组件版本号
+func (hc *Component) GetVersion() string {
+	return "1.0.0"
+}
+
+// Notify 接收系统广播事件
+func (hc *Component) Notify(event string, data any) {}
+
+// handler ready 为 true 时按就绪状态返回，否则按存活状态返回
+func (hc *Component) handler(ready bool) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx, cancel := context.WithTimeout(r.Context(), hc.cfg.Timeout)
+		defer cancel()
+		report := hc.ComponentManager.Health(ctx)
+
+		ok := report.Live
+		if ready {
+			ok = report.Ready
+		}
+		w.Header().Set("Content-Type", "application/json")
+		if !ok {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+		_ = json.NewEncoder(w).Encode(report)
+	})
+}
@@ -0,0 +1,54 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/andrewbytecoder/nmq/interfaces/nmq"
+	"go.uber.org/zap"
+)
+
+// fakeManager 返回固定健康状态的组件管理器
+type fakeManager struct {
+	nmq.ComponentManager
+	report nmq.HealthReport
+}
+
+func (m *fakeManager) Health(ctx context.Context) nmq.HealthReport { return m.report }
+
+func TestHandler(t *testing.T) {
+	m := &fakeManager{}
+	hc := &Component{cfg: NewConfig()}
+	hc.Log = zap.NewNop()
+	hc.ComponentManager = m
+	if err := hc.Init(); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		live, ready bool
+		path        string
+		code        int
+	}{
+		{true, true, LivenessPath, http.StatusOK},
+		{true, true, ReadinessPath, http.StatusOK},
+		{true, false, LivenessPath, http.StatusOK},
+		{true, false, ReadinessPath, http.StatusServiceUnavailable},
+		{false, false, LivenessPath, http.StatusServiceUnavailable},
+	}
+	for _, tt := range tests {
+		m.report = nmq.HealthReport{Live: tt.live, Ready: tt.ready}
+		rec := httptest.NewRecorder()
+		hc.srv.Handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, tt.path, nil))
+		if rec.Code != tt.code {
+			t.Errorf("%s live=%v ready=%v: expected %d, got %d", tt.path, tt.live, tt.ready, tt.code, rec.Code)
+		}
+		var report nmq.HealthReport
+		if err := json.NewDecoder(rec.Body).Decode(&report); err != nil || report.Ready != tt.ready {
+			t.Errorf("Unexpected body %+v, %v", report, err)
+		}
+	}
+}
@@ -1 +1,61 @@
+// Package consumer 定义消息消费者的处理函数和中间件
+//
+// 中间件的用法与HTTP中间件相同，Chain 按参数顺序由外到内包装处理函数：
+//
+//	h := consumer.Chain(handle,
+//		consumer.Recover(nil),
+//		consumer.Correlation(),
+//		consumer.Logging(logger),
+//		consumer.Metrics(),
+//		consumer.Retry(3, 100*time.Millisecond),
+//		consumer.Decode[Order](json.Unmarshal),
+//	)
 package consumer
+
+import (
+	"context"
+	"errors"
+)
+
+// Message 消费者收到的消息
+type Message struct {
+	Topic   string            // 消息主题
+	Headers map[string]string // 消息头，例如关联ID
+	Payload []byte            // 原始消息内容
+	Value   any               // Decode 中间件解码后的消息内容
+	Attempt int               // 当前是第几次处理，从1开始，由 Retry 中间件设置
+}
+
+// Handler 消息处理函数
+type Handler func(ctx context.Context, msg *Message) error
+
+// Middleware 包装消息处理函数，在处理前后执行日志、监控、重试等横切逻辑
+type Middleware func(next Handler) Handler
+
+// Chain 用中间件包装处理函数，第一个中间件在最外层，最先处理消息
+func Chain(h Handler, mws ...Middleware) Handler {
+	for i := len(mws) - 1; i >= 0; i-- {
+		h = mws[i](h)
+	}
+	return h
+}
+
+// ErrPermanent 不应重试的错误，Retry 中间件遇到时直接返回
+var ErrPermanent = errors.New("consumer: permanent error")
+
+// permanentError 标记为不可重试的错误
+type permanentError struct {
+	err error
+}
+
+func (e *permanentError) Error() string { return e.err.Error() }
+
+func (e *permanentError) Unwrap() []error { return []error{ErrPermanent, e.err} }
+
+// Permanent 将错误标记为不可重试，err 为nil时返回nil
+func Permanent(err error) error {
+	if err == nil || errors.Is(err, ErrPermanent) {
+		return err
+	}
+	return &permanentError{err: err}
+}
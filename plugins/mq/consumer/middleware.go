@@ -0,0 +1,169 @@
+package consumer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/andrewbytecoder/nmq/internal/metrics"
+	"github.com/andrewbytecoder/nmq/internal/prometheus"
+	"github.com/andrewbytecoder/nmq/pkg/correlation"
+	"github.com/andrewbytecoder/nmq/pkg/recovery"
+	stdprometheus "github.com/prometheus/client_golang/prometheus"
+	"go.uber.org/zap"
+)
+
+// ErrDecode 消息内容解码失败，该错误不会被重试
+var ErrDecode = errors.New("consumer: decode message")
+
+// Recover 将处理函数中的 panic 转换为 recovery.PanicError，r 为nil时使用 recovery.Default
+func Recover(r *recovery.Recoverer) Middleware {
+	if r == nil {
+		r = recovery.Default
+	}
+	return func(next Handler) Handler {
+		return func(ctx context.Context, msg *Message) error {
+			return r.Do("consumer."+msg.Topic, msg.Payload, func() error { return next(ctx, msg) })
+		}
+	}
+}
+
+// Correlation 从消息头中取出关联ID放入 ctx，消息头中没有时生成新的关联ID并写回消息头
+func Correlation() Middleware {
+	return func(next Handler) Handler {
+		return func(ctx context.Context, msg *Message) error {
+			ctx, _ = correlation.ExtractMap(ctx, msg.Headers)
+			msg.Headers = correlation.InjectMap(ctx, msg.Headers)
+			return next(ctx, msg)
+		}
+	}
+}
+
+// Logging 记录每条消息的处理结果和耗时，成功时为 Debug 级别，失败时为 Error 级别
+func Logging(logger *zap.Logger) Middleware {
+	return func(next Handler) Handler {
+		return func(ctx context.Context, msg *Message) error {
+			start := time.Now()
+			err := next(ctx, msg)
+			log := correlation.Logger(ctx, logger).With(zap.String("topic", msg.Topic), zap.Duration("duration", time.Since(start)))
+			if err != nil {
+				log.Error("Failed to handle message", zap.Int("attempt", msg.Attempt), zap.Error(err))
+			} else {
+				log.Debug("Message handled")
+			}
+			return err
+		}
+	}
+}
+
+// consumerMetrics 消费者监控指标，注册失败时为nil
+type consumerMetrics struct {
+	handled  metrics.Counter
+	duration metrics.Histogram
+}
+
+// newConsumerMetrics 注册消费者监控指标，已注册时复用已有的指标
+func newConsumerMetrics() *consumerMetrics {
+	m := &consumerMetrics{}
+	cv := stdprometheus.NewCounterVec(stdprometheus.CounterOpts{
+		Namespace: "nmq",
+		Subsystem: "consumer",
+		Name:      "messages_total",
+		Help:      "Number of messages handled by consumers, by topic and result.",
+	}, []string{"topic", "result"})
+	if err := stdprometheus.Register(cv); err != nil {
+		var are stdprometheus.AlreadyRegisteredError
+		if !errors.As(err, &are) {
+			return m
+		}
+		cv, _ = are.ExistingCollector.(*stdprometheus.CounterVec)
+	}
+	if cv != nil {
+		m.handled = prometheus.NewCounter(cv)
+	}
+
+	hv := stdprometheus.NewHistogramVec(stdprometheus.HistogramOpts{
+		Namespace: "nmq",
+		Subsystem: "consumer",
+		Name:      "handle_seconds",
+		Help:      "Time spent handling a message, including retries.",
+		Buckets:   stdprometheus.DefBuckets,
+	}, []string{"topic"})
+	if err := stdprometheus.Register(hv); err != nil {
+		var are stdprometheus.AlreadyRegisteredError
+		if !errors.As(err, &are) {
+			return m
+		}
+		hv, _ = are.ExistingCollector.(*stdprometheus.HistogramVec)
+	}
+	if hv != nil {
+		m.duration = prometheus.NewHistogram(hv)
+	}
+	return m
+}
+
+// Metrics 按主题统计处理成功和失败的消息数以及处理耗时
+func Metrics() Middleware {
+	m := newConsumerMetrics()
+	return func(next Handler) Handler {
+		return func(ctx context.Context, msg *Message) error {
+			start := time.Now()
+			err := next(ctx, msg)
+			if m.duration != nil {
+				m.duration.With("topic", msg.Topic).Observe(time.Since(start).Seconds())
+			}
+			if m.handled != nil {
+				result := "success"
+				if err != nil {
+					result = "failure"
+				}
+				m.handled.With("topic", msg.Topic, "result", result).Add(1)
+			}
+			return err
+		}
+	}
+}
+
+// Retry 处理失败时最多重试 attempts-1 次，等待时间从 backoff 开始翻倍
+//
+// 标记为 Permanent 的错误和 ctx 取消时不再重试，返回最后一次的错误
+func Retry(attempts int, backoff time.Duration) Middleware {
+	if attempts <= 0 {
+		attempts = 1
+	}
+	return func(next Handler) Handler {
+		return func(ctx context.Context, msg *Message) error {
+			wait := backoff
+			for attempt := 1; ; attempt++ {
+				msg.Attempt = attempt
+				err := next(ctx, msg)
+				if err == nil || attempt >= attempts || errors.Is(err, ErrPermanent) {
+					return err
+				}
+				t := time.NewTimer(wait)
+				select {
+				case <-ctx.Done():
+					t.Stop()
+					return err
+				case <-t.C:
+				}
+				wait *= 2
+			}
+		}
+	}
+}
+
+// Decode 用 unmarshal 将消息内容解码为 T 存入 Message.Value，解码失败时返回不可重试的 ErrDecode
+func Decode[T any](unmarshal func(data []byte, v any) error) Middleware {
+	return func(next Handler) Handler {
+		return func(ctx context.Context, msg *Message) error {
+			var v T
+			if err := unmarshal(msg.Payload, &v); err != nil {
+				return Permanent(fmt.Errorf("%w: %s: %w", ErrDecode, msg.Topic, err))
+			}
+			msg.Value = v
+			return next(ctx, msg)
+		}
+	}
+}
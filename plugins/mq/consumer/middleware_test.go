@@ -0,0 +1,118 @@
+package consumer
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/andrewbytecoder/nmq/pkg/correlation"
+	"github.com/andrewbytecoder/nmq/pkg/recovery"
+	"go.uber.org/zap"
+)
+
+func TestChainOrder(t *testing.T) {
+	var got []string
+	mw := func(name string) Middleware {
+		return func(next Handler) Handler {
+			return func(ctx context.Context, msg *Message) error {
+				got = append(got, name+">")
+				err := next(ctx, msg)
+				got = append(got, "<"+name)
+				return err
+			}
+		}
+	}
+	h := Chain(func(context.Context, *Message) error {
+		got = append(got, "handler")
+		return nil
+	}, mw("a"), mw("b"))
+	if err := h(context.Background(), &Message{}); err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"a>", "b>", "handler", "<b", "<a"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected %v, got %v", want, got)
+	}
+}
+
+func TestRetry(t *testing.T) {
+	calls := 0
+	h := Chain(func(context.Context, *Message) error {
+		calls++
+		if calls < 3 {
+			return errors.New("temporary")
+		}
+		return nil
+	}, Retry(5, time.Millisecond))
+	msg := &Message{}
+	if err := h(context.Background(), msg); err != nil || calls != 3 || msg.Attempt != 3 {
+		t.Errorf("Unexpected result: %v, calls %d, attempt %d", err, calls, msg.Attempt)
+	}
+
+	// 不可重试的错误只处理一次
+	calls = 0
+	h = Chain(func(context.Context, *Message) error {
+		calls++
+		return Permanent(errors.New("bad message"))
+	}, Retry(5, time.Millisecond))
+	if err := h(context.Background(), &Message{}); !errors.Is(err, ErrPermanent) || calls != 1 {
+		t.Errorf("Unexpected result: %v, calls %d", err, calls)
+	}
+
+	// 重试次数用尽后返回最后一次的错误
+	calls = 0
+	h = Chain(func(context.Context, *Message) error {
+		calls++
+		return errors.New("temporary")
+	}, Retry(2, time.Millisecond))
+	if err := h(context.Background(), &Message{}); err == nil || calls != 2 {
+		t.Errorf("Unexpected result: %v, calls %d", err, calls)
+	}
+}
+
+func TestRecoverAndDecode(t *testing.T) {
+	type order struct {
+		ID int `json:"id"`
+	}
+	var got order
+	h := Chain(func(ctx context.Context, msg *Message) error {
+		got = msg.Value.(order)
+		if got.ID < 0 {
+			panic("negative id")
+		}
+		return nil
+	}, Recover(nil), Decode[order](json.Unmarshal))
+
+	if err := h(context.Background(), &Message{Payload: []byte(`{"id":7}`)}); err != nil || got.ID != 7 {
+		t.Errorf("Unexpected result: %v %+v", err, got)
+	}
+	err := h(context.Background(), &Message{Topic: "orders", Payload: []byte(`{"id":-1}`)})
+	var pe *recovery.PanicError
+	if !errors.As(err, &pe) {
+		t.Errorf("Expected PanicError, got %v", err)
+	}
+	err = h(context.Background(), &Message{Topic: "orders", Payload: []byte(`not json`)})
+	if !errors.Is(err, ErrDecode) || !errors.Is(err, ErrPermanent) {
+		t.Errorf("Expected permanent ErrDecode, got %v", err)
+	}
+}
+
+func TestCorrelationLoggingMetrics(t *testing.T) {
+	var id string
+	h := Chain(func(ctx context.Context, msg *Message) error {
+		id, _ = correlation.FromContext(ctx)
+		return nil
+	}, Correlation(), Logging(zap.NewNop()), Metrics(), Metrics())
+
+	msg := &Message{Topic: "orders", Headers: map[string]string{correlation.MessageKey: "abc"}}
+	if err := h(context.Background(), msg); err != nil || id != "abc" {
+		t.Errorf("Expected correlation id from headers, got %q %v", id, err)
+	}
+
+	msg = &Message{Topic: "orders"}
+	if err := h(context.Background(), msg); err != nil || id == "" || msg.Headers[correlation.MessageKey] != id {
+		t.Errorf("Expected generated correlation id, got %q %v", id, msg.Headers)
+	}
+}
@@ -0,0 +1,333 @@
+package consumer
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/andrewbytecoder/nmq/interfaces/nmq"
+	"github.com/andrewbytecoder/nmq/pkg/options"
+	"github.com/andrewbytecoder/nmq/pkg/recovery"
+)
+
+// MessageIDKey 消息头中的消息ID键，没有时按主题和内容计算消息ID
+const MessageIDKey = "message_id"
+
+// ErrQuarantined 消息已被隔离，不再交给处理函数
+var ErrQuarantined = errors.New("consumer: message quarantined")
+
+// QuarantineConfig 消息隔离配置
+type QuarantineConfig struct {
+	Threshold   int          // 同一条消息连续失败多少次后隔离
+	MaxTracked  int          // 最多跟踪多少条失败但尚未隔离的消息，超过时丢弃最早的跟踪记录
+	MaxFailures int          // 每条消息保留的最近失败记录数
+	Bus         nmq.EventBus // 消息被隔离时发布事件，为nil时不发布
+	Topic       string       // 隔离事件的主题
+	Canary      Handler      // 管理接口重试隔离消息时使用的处理函数
+}
+
+// SetQuarantineThreshold 设置连续失败多少次后隔离
+func SetQuarantineThreshold(n int) options.Option {
+	return func(c any) {
+		if c, ok := c.(*QuarantineConfig); ok {
+			c.Threshold = n
+		}
+	}
+}
+
+// SetQuarantineLimits 设置最多跟踪的消息数和每条消息保留的失败记录数
+func SetQuarantineLimits(maxTracked, maxFailures int) options.Option {
+	return func(c any) {
+		if c, ok := c.(*QuarantineConfig); ok {
+			c.MaxTracked = maxTracked
+			c.MaxFailures = maxFailures
+		}
+	}
+}
+
+// SetQuarantineBus 设置隔离事件发布的事件总线和主题，事件数据为 nmq.QuarantinedMessage
+func SetQuarantineBus(bus nmq.EventBus, topic string) options.Option {
+	return func(c any) {
+		if c, ok := c.(*QuarantineConfig); ok {
+			c.Bus = bus
+			c.Topic = topic
+		}
+	}
+}
+
+// SetCanary 设置重试隔离消息时使用的处理函数，通常是带有额外日志或修复逻辑的处理函数
+func SetCanary(h Handler) options.Option {
+	return func(c any) {
+		if c, ok := c.(*QuarantineConfig); ok {
+			c.Canary = h
+		}
+	}
+}
+
+// NewQuarantineConfig 创建消息隔离配置，默认连续失败5次后隔离
+func NewQuarantineConfig(opts ...options.Option) *QuarantineConfig {
+	c := &QuarantineConfig{Threshold: 5, MaxTracked: 10000, MaxFailures: 10, Topic: "consumer.quarantined"}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// tracked 失败但尚未隔离的消息
+type tracked struct {
+	failures []nmq.MessageFailure
+	total    int
+}
+
+// Quarantine 消息隔离区，同一条消息连续失败达到阈值后隔离，隔离的消息不再交给处理函数
+//
+// 实现了 nmq.Quarantine，持有隔离区的组件在 GetInterface(nmq.QuarantineInterface) 中返回它即可通过管理接口操作
+type Quarantine struct {
+	mu          sync.Mutex
+	cfg         *QuarantineConfig
+	tracked     map[string]*tracked
+	order       []string // 跟踪记录的创建顺序，用于限制跟踪的消息数
+	quarantined map[string]*nmq.QuarantinedMessage
+}
+
+// NewQuarantine 创建消息隔离区
+func NewQuarantine(cfg *QuarantineConfig) *Quarantine {
+	if cfg == nil {
+		cfg = NewQuarantineConfig()
+	}
+	return &Quarantine{
+		cfg:         cfg,
+		tracked:     make(map[string]*tracked),
+		quarantined: make(map[string]*nmq.QuarantinedMessage),
+	}
+}
+
+// MessageID 返回消息ID，消息头中没有 MessageIDKey 时按主题和内容计算
+func MessageID(msg *Message) string {
+	if id := msg.Headers[MessageIDKey]; id != "" {
+		return id
+	}
+	h := sha256.New()
+	h.Write([]byte(msg.Topic))
+	h.Write([]byte{0})
+	h.Write(msg.Payload)
+	return hex.EncodeToString(h.Sum(nil)[:16])
+}
+
+// Middleware 返回隔离中间件，需要放在 Recover 外层才能记录 panic 的调用栈，放在 Retry 外层时每次交付只计一次失败
+func (q *Quarantine) Middleware() Middleware {
+	return func(next Handler) Handler {
+		return func(ctx context.Context, msg *Message) error {
+			id := MessageID(msg)
+			q.mu.Lock()
+			_, ok := q.quarantined[id]
+			q.mu.Unlock()
+			if ok {
+				return Permanent(fmt.Errorf("%w: %s", ErrQuarantined, id))
+			}
+
+			err := next(ctx, msg)
+			if err == nil {
+				q.mu.Lock()
+				delete(q.tracked, id)
+				q.mu.Unlock()
+				return nil
+			}
+			if qm := q.fail(id, msg, err); qm != nil {
+				q.publish(*qm)
+				return Permanent(fmt.Errorf("%w: %s: %w", ErrQuarantined, id, err))
+			}
+			return err
+		}
+	}
+}
+
+// fail 记录一次失败，达到阈值时隔离消息并返回隔离记录的副本
+func (q *Quarantine) fail(id string, msg *Message, err error) *nmq.QuarantinedMessage {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	t, ok := q.tracked[id]
+	if !ok {
+		t = &tracked{}
+		q.tracked[id] = t
+		q.order = append(q.order, id)
+		q.evict()
+	}
+	t.total++
+	t.failures = q.appendFailure(t.failures, err)
+	if t.total < q.cfg.Threshold {
+		return nil
+	}
+
+	delete(q.tracked, id)
+	qm := &nmq.QuarantinedMessage{
+		ID:            id,
+		Topic:         msg.Topic,
+		Headers:       copyHeaders(msg.Headers),
+		Payload:       append([]byte(nil), msg.Payload...),
+		Failures:      t.failures,
+		QuarantinedAt: time.Now(),
+	}
+	qm.Hints = analyze(qm)
+	q.quarantined[id] = qm
+	ret := copyQuarantined(qm)
+	return &ret
+}
+
+// evict 跟踪的消息超过上限时丢弃最早的跟踪记录 调用方需持有 q.mu
+func (q *Quarantine) evict() {
+	for len(q.tracked) > q.cfg.MaxTracked && len(q.order) > 0 {
+		delete(q.tracked, q.order[0])
+		q.order = q.order[1:]
+	}
+	// 已经成功或隔离的消息留在 order 中，数量明显多于跟踪记录时压缩
+	if len(q.order) > 2*len(q.tracked)+16 {
+		order := q.order[:0]
+		for _, id := range q.order {
+			if _, ok := q.tracked[id]; ok {
+				order = append(order, id)
+			}
+		}
+		q.order = order
+	}
+}
+
+// appendFailure 追加失败记录，只保留最近的 MaxFailures 条 调用方需持有 q.mu
+func (q *Quarantine) appendFailure(failures []nmq.MessageFailure, err error) []nmq.MessageFailure {
+	f := nmq.MessageFailure{Time: time.Now(), Error: err.Error()}
+	var pe *recovery.PanicError
+	if errors.As(err, &pe) {
+		f.Stack = string(pe.Stack)
+	}
+	failures = append(failures, f)
+	if n := q.cfg.MaxFailures; n > 0 && len(failures) > n {
+		failures = append(failures[:0:0], failures[len(failures)-n:]...)
+	}
+	return failures
+}
+
+// publish 发布隔离事件
+func (q *Quarantine) publish(qm nmq.QuarantinedMessage) {
+	if q.cfg.Bus != nil {
+		_ = q.cfg.Bus.Publish(q.cfg.Topic, qm)
+	}
+}
+
+// Quarantined 返回所有被隔离的消息，按进入隔离区的时间排序
+func (q *Quarantine) Quarantined() []nmq.QuarantinedMessage {
+	q.mu.Lock()
+	list := make([]nmq.QuarantinedMessage, 0, len(q.quarantined))
+	for _, qm := range q.quarantined {
+		list = append(list, copyQuarantined(qm))
+	}
+	q.mu.Unlock()
+	sort.Slice(list, func(i, j int) bool { return list[i].QuarantinedAt.Before(list[j].QuarantinedAt) })
+	return list
+}
+
+// RetryQuarantined 使用 canary 处理函数重试一次，成功后移出隔离区，失败时追加失败记录
+func (q *Quarantine) RetryQuarantined(ctx context.Context, id string) error {
+	if q.cfg.Canary == nil {
+		return nmq.ErrNoCanary
+	}
+	q.mu.Lock()
+	qm, ok := q.quarantined[id]
+	var msg *Message
+	if ok {
+		msg = &Message{Topic: qm.Topic, Headers: copyHeaders(qm.Headers), Payload: append([]byte(nil), qm.Payload...), Attempt: 1}
+	}
+	q.mu.Unlock()
+	if !ok {
+		return fmt.Errorf("%w: %s", nmq.ErrMessageNotFound, id)
+	}
+
+	err := recovery.Default.Do("consumer.canary."+msg.Topic, msg.Payload, func() error { return q.cfg.Canary(ctx, msg) })
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	qm, ok = q.quarantined[id]
+	if !ok {
+		return err
+	}
+	if err != nil {
+		qm.Failures = q.appendFailure(qm.Failures, err)
+		qm.Hints = analyze(qm)
+		return err
+	}
+	delete(q.quarantined, id)
+	return nil
+}
+
+// ReleaseQuarantined 将消息移出隔离区
+func (q *Quarantine) ReleaseQuarantined(id string) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if _, ok := q.quarantined[id]; !ok {
+		return fmt.Errorf("%w: %s", nmq.ErrMessageNotFound, id)
+	}
+	delete(q.quarantined, id)
+	return nil
+}
+
+// analyze 根据失败记录给出排查建议
+func analyze(qm *nmq.QuarantinedMessage) []string {
+	var hints []string
+	if len(qm.Payload) == 0 {
+		hints = append(hints, "payload is empty; check the producer")
+	}
+	panics, decode, timeout := 0, 0, 0
+	same := true
+	for _, f := range qm.Failures {
+		switch {
+		case f.Stack != "":
+			panics++
+		case strings.Contains(f.Error, ErrDecode.Error()):
+			decode++
+		case strings.Contains(f.Error, context.DeadlineExceeded.Error()):
+			timeout++
+		}
+		same = same && f.Error == qm.Failures[0].Error
+	}
+	if panics > 0 {
+		hints = append(hints, fmt.Sprintf("handler panicked %d time(s); inspect the captured stack traces", panics))
+	}
+	if decode > 0 {
+		hints = append(hints, "payload could not be decoded; the producer and consumer schemas may differ")
+	}
+	if timeout > 0 {
+		hints = append(hints, "handler exceeded its deadline; the message may be too large or a dependency is slow")
+	}
+	if same && len(qm.Failures) > 1 {
+		hints = append(hints, "every attempt failed with the same error; the failure is likely deterministic for this message")
+	}
+	return hints
+}
+
+// copyQuarantined 复制隔离记录，避免调用方修改隔离区中的数据
+func copyQuarantined(qm *nmq.QuarantinedMessage) nmq.QuarantinedMessage {
+	ret := *qm
+	ret.Headers = copyHeaders(qm.Headers)
+	ret.Payload = append([]byte(nil), qm.Payload...)
+	ret.Failures = append([]nmq.MessageFailure(nil), qm.Failures...)
+	ret.Hints = append([]string(nil), qm.Hints...)
+	return ret
+}
+
+// copyHeaders 复制消息头
+func copyHeaders(h map[string]string) map[string]string {
+	if h == nil {
+		return nil
+	}
+	ret := make(map[string]string, len(h))
+	for k, v := range h {
+		ret[k] = v
+	}
+	return ret
+}
+
+var _ nmq.Quarantine = (*Quarantine)(nil)
@@ -0,0 +1,121 @@
+package consumer
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/andrewbytecoder/nmq/interfaces/nmq"
+	"github.com/andrewbytecoder/nmq/pkg/options"
+)
+
+// fakeBus 记录发布的事件
+type fakeBus struct {
+	nmq.EventBus
+	mu     sync.Mutex
+	topics []string
+	data   []any
+}
+
+func (b *fakeBus) Publish(topic string, data any, opts ...options.Option) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.topics = append(b.topics, topic)
+	b.data = append(b.data, data)
+	return nil
+}
+
+func TestQuarantine(t *testing.T) {
+	bus := &fakeBus{}
+	var canaryCalls int
+	q := NewQuarantine(NewQuarantineConfig(
+		SetQuarantineThreshold(3),
+		SetQuarantineBus(bus, "alerts.quarantine"),
+		SetCanary(func(ctx context.Context, msg *Message) error {
+			canaryCalls++
+			return nil
+		}),
+	))
+	calls := 0
+	h := Chain(func(ctx context.Context, msg *Message) error {
+		calls++
+		if string(msg.Payload) == "poison" {
+			panic("bad message")
+		}
+		return nil
+	}, q.Middleware(), Recover(nil))
+
+	poison := &Message{Topic: "orders", Payload: []byte("poison")}
+	for i := 0; i < 2; i++ {
+		if err := h(context.Background(), poison); err == nil || errors.Is(err, ErrQuarantined) {
+			t.Fatalf("Unexpected error before threshold: %v", err)
+		}
+	}
+	err := h(context.Background(), poison)
+	if !errors.Is(err, ErrQuarantined) || !errors.Is(err, ErrPermanent) {
+		t.Fatalf("Expected ErrQuarantined, got %v", err)
+	}
+	// 隔离后不再交给处理函数
+	if err = h(context.Background(), poison); !errors.Is(err, ErrQuarantined) || calls != 3 {
+		t.Fatalf("Expected quarantined message skipped, got %v after %d calls", err, calls)
+	}
+	// 其他消息不受影响
+	if err = h(context.Background(), &Message{Topic: "orders", Payload: []byte("ok")}); err != nil {
+		t.Fatal(err)
+	}
+
+	list := q.Quarantined()
+	if len(list) != 1 || len(list[0].Failures) != 3 || list[0].Failures[0].Stack == "" {
+		t.Fatalf("Unexpected quarantine: %+v", list)
+	}
+	if hints := strings.Join(list[0].Hints, "\n"); !strings.Contains(hints, "panicked 3 time(s)") || !strings.Contains(hints, "same error") {
+		t.Errorf("Unexpected hints: %v", list[0].Hints)
+	}
+	if len(bus.topics) != 1 || bus.topics[0] != "alerts.quarantine" || bus.data[0].(nmq.QuarantinedMessage).ID != list[0].ID {
+		t.Errorf("Unexpected alerts: %v %v", bus.topics, bus.data)
+	}
+
+	if err = q.RetryQuarantined(context.Background(), list[0].ID); err != nil || canaryCalls != 1 {
+		t.Fatalf("Unexpected canary result: %v %d", err, canaryCalls)
+	}
+	if len(q.Quarantined()) != 0 {
+		t.Error("Expected message released after successful canary")
+	}
+	if err = q.RetryQuarantined(context.Background(), list[0].ID); !errors.Is(err, nmq.ErrMessageNotFound) {
+		t.Errorf("Expected ErrMessageNotFound, got %v", err)
+	}
+}
+
+func TestQuarantineRelease(t *testing.T) {
+	q := NewQuarantine(NewQuarantineConfig(SetQuarantineThreshold(1), SetQuarantineLimits(10, 2)))
+	h := Chain(func(context.Context, *Message) error { return errors.New("boom") }, q.Middleware())
+	msg := &Message{Topic: "orders", Headers: map[string]string{MessageIDKey: "m1"}}
+	_ = h(context.Background(), msg)
+
+	if err := q.RetryQuarantined(context.Background(), "m1"); !errors.Is(err, nmq.ErrNoCanary) {
+		t.Errorf("Expected ErrNoCanary, got %v", err)
+	}
+	if err := q.ReleaseQuarantined("m1"); err != nil {
+		t.Fatal(err)
+	}
+	if err := q.ReleaseQuarantined("m1"); !errors.Is(err, nmq.ErrMessageNotFound) {
+		t.Errorf("Expected ErrMessageNotFound, got %v", err)
+	}
+	if err := h(context.Background(), msg); !errors.Is(err, ErrQuarantined) {
+		t.Errorf("Expected released message handled and quarantined again, got %v", err)
+	}
+}
+
+func TestQuarantineTrackedLimit(t *testing.T) {
+	q := NewQuarantine(NewQuarantineConfig(SetQuarantineThreshold(2), SetQuarantineLimits(2, 1)))
+	h := Chain(func(context.Context, *Message) error { return errors.New("boom") }, q.Middleware())
+	for _, id := range []string{"a", "b", "c", "a"} {
+		_ = h(context.Background(), &Message{Headers: map[string]string{MessageIDKey: id}})
+	}
+	// a 的跟踪记录在 c 加入时被丢弃，第二次失败重新计数
+	if list := q.Quarantined(); len(list) != 0 {
+		t.Errorf("Expected nothing quarantined, got %+v", list)
+	}
+}
@@ -1,20 +1,28 @@
 package mq
 
 import (
+	"sync"
+
 	"github.com/andrewbytecoder/nmq/interfaces/nmq"
 	"go.uber.org/zap"
 )
 
+// componentName 消息队列组件的名称
+const componentName = "subscribe_component"
+
 type MessageQueueComponent struct {
 	ctx nmq.NmqContext
 	log *zap.Logger
+
+	mu     sync.RWMutex
+	status nmq.ComponentStatus // 由组件管理器在生命周期变化时设置
 }
 
 // NewNetComponent 创建网络组件实例
 func NewNetComponent(ctx nmq.NmqContext) *MessageQueueComponent {
 	return &MessageQueueComponent{
 		ctx: ctx,
-		log: ctx.GetLogger(),
+		log: ctx.ComponentLogger(componentName),
 	}
 }
 
@@ -60,7 +68,14 @@ func (nc *MessageQueueComponent) Reset() error {
 //
 // @return string 组件名称
 func (nc *MessageQueueComponent) GetName() string {
-	return "subscribe_component"
+	return componentName
+}
+
+// ShutdownPriority 在流量入口之后、持久化层之前停止
+//
+// @return nmq.ShutdownPriority 停止优先级
+func (nc *MessageQueueComponent) ShutdownPriority() nmq.ShutdownPriority {
+	return nmq.ShutdownBroker
 }
 
 // GetVersion 获取组件版本号
@@ -82,5 +97,16 @@ func (nc *MessageQueueComponent) Notify(event string, data any) {
 //
 // @return ComponentStatus 当前状态
 func (nc *MessageQueueComponent) GetStatus() nmq.ComponentStatus {
-	return nmq.ComponentOk
+	nc.mu.RLock()
+	defer nc.mu.RUnlock()
+	return nc.status
+}
+
+// SetStatus 设置组件状态
+//
+// @param status ComponentStatus 新状态
+func (nc *MessageQueueComponent) SetStatus(status nmq.ComponentStatus) {
+	nc.mu.Lock()
+	nc.status = status
+	nc.mu.Unlock()
 }
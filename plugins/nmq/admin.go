@@ -0,0 +1,107 @@
+package nmq
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+
+	"github.com/andrewbytecoder/nmq/interfaces/nmq"
+	"github.com/spf13/viper"
+	"go.uber.org/zap"
+)
+
+// secretKey 配置项名称匹配时脱敏
+var secretKey = regexp.MustCompile(`(?i)(password|passwd|secret|token|credential|private_?key|api_?key)`)
+
+// ListComponents 返回所有组件的信息，按名称排序，不包括组件管理器自身
+func (n *Nmq) ListComponents() []nmq.ComponentInfo {
+	names := n.componentNames()
+	sort.Strings(names)
+	infos := make([]nmq.ComponentInfo, 0, len(names))
+	for _, name := range names {
+		c, err := n.adminComponent(name)
+		if err != nil {
+			continue
+		}
+		status, err := n.ComponentStatus(name)
+		if err != nil {
+			continue
+		}
+		info := nmq.ComponentInfo{Name: name, Version: c.GetVersion(), Status: status, Policy: n.policy(name).String()}
+		n.supMu.Lock()
+		if s, ok := n.supervised[name]; ok {
+			info.Restarts = s.restarts
+			if s.lastErr != nil {
+				info.LastError = s.lastErr.Error()
+			}
+		}
+		n.supMu.Unlock()
+		infos = append(infos, info)
+	}
+	return infos
+}
+
+// adminComponent 查找可以通过管理接口操作的组件
+func (n *Nmq) adminComponent(name string) (nmq.Component, error) {
+	n.mux.RLock()
+	c, ok := n.components[name]
+	n.mux.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", nmq.ErrComponentNotFound, name)
+	}
+	if c.GetName() == n.GetName() {
+		return nil, nmq.ErrComponentSelf
+	}
+	return c, nil
+}
+
+// StartComponent 启动组件，启动失败时按组件的重启策略重试
+func (n *Nmq) StartComponent(name string) error {
+	c, err := n.adminComponent(name)
+	if err != nil {
+		return err
+	}
+	n.logger.Info("Starting component", zap.String("component", name))
+	return n.startComponent(name, c)
+}
+
+// StopComponent 停止组件
+func (n *Nmq) StopComponent(name string) error {
+	c, err := n.adminComponent(name)
+	if err != nil {
+		return err
+	}
+	n.logger.Info("Stopping component", zap.String("component", name))
+	return n.stopComponent(name, c)
+}
+
+// ResetComponent 重置组件
+func (n *Nmq) ResetComponent(name string) error {
+	c, err := n.adminComponent(name)
+	if err != nil {
+		return err
+	}
+	n.logger.Info("Resetting component", zap.String("component", name))
+	return n.resetComponent(name, c)
+}
+
+// ConfigSettings 返回当前生效的配置，名称包含 password、secret、token 等的配置项已脱敏
+func (n *Nmq) ConfigSettings() map[string]any {
+	return redactSettings(viper.AllSettings())
+}
+
+// redactSettings 递归脱敏配置项
+func redactSettings(m map[string]any) map[string]any {
+	ret := make(map[string]any, len(m))
+	for k, v := range m {
+		if secretKey.MatchString(k) {
+			ret[k] = "******"
+			continue
+		}
+		if sub, ok := v.(map[string]any); ok {
+			v = redactSettings(sub)
+		}
+		ret[k] = v
+	}
+	return ret
+}
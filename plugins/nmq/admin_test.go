@@ -0,0 +1,51 @@
+package nmq
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/andrewbytecoder/nmq/interfaces/nmq"
+)
+
+func TestComponentAdmin(t *testing.T) {
+	n := newSupervisedNmq()
+	var admin nmq.ComponentAdmin = n
+	c := &flakyComponent{}
+	n.RegisterComponent("flaky", c)
+	n.RegisterComponent("nmq", n)
+
+	infos := admin.ListComponents()
+	if len(infos) != 1 || infos[0].Name != "flaky" || infos[0].Status != nmq.ComponentRunning {
+		t.Fatalf("Unexpected components: %+v", infos)
+	}
+	if err := admin.StartComponent("flaky"); err != nil {
+		t.Fatal(err)
+	}
+	if err := admin.StopComponent("flaky"); err != nil {
+		t.Fatal(err)
+	}
+	if starts, stops := c.counts(); starts != 1 || stops != 1 {
+		t.Errorf("Expected 1 start and 1 stop, got %d %d", starts, stops)
+	}
+	if err := admin.StopComponent("missing"); !errors.Is(err, nmq.ErrComponentNotFound) {
+		t.Errorf("Expected ErrComponentNotFound, got %v", err)
+	}
+	if err := admin.ResetComponent("nmq"); !errors.Is(err, nmq.ErrComponentSelf) {
+		t.Errorf("Expected ErrComponentSelf, got %v", err)
+	}
+}
+
+func TestRedactSettings(t *testing.T) {
+	got := redactSettings(map[string]any{
+		"addr":    ":8080",
+		"db":      map[string]any{"user": "nmq", "Password": "hunter2", "tls": map[string]any{"private_key": "..."}},
+		"api_key": "abc",
+	})
+	db := got["db"].(map[string]any)
+	if got["addr"] != ":8080" || got["api_key"] != "******" || db["user"] != "nmq" || db["Password"] != "******" {
+		t.Errorf("Unexpected redaction: %v", got)
+	}
+	if db["tls"].(map[string]any)["private_key"] != "******" {
+		t.Errorf("Expected nested secret redacted, got %v", db["tls"])
+	}
+}
@@ -1,13 +1,9 @@
 package nmq
 
-import (
-	"github.com/google/gops/agent"
-)
-
 func loadAgentByConfig(cfg *Config) error {
 	// 启动gops agent
 	if cfg.enableGoPs && envEnableGoPs() {
-		if err := agent.Listen(agent.Options{}); err != nil {
+		if err := startGoPs(); err != nil {
 			return err
 		}
 	}
@@ -0,0 +1,27 @@
+package nmq
+
+import (
+	"github.com/andrewbytecoder/nmq/pkg/alert"
+	"github.com/andrewbytecoder/nmq/pkg/options"
+)
+
+// startAlerting 创建并启动告警规则评估器，告警发布到事件总线，规则无效时返回错误
+func (n *Nmq) startAlerting() error {
+	publish := alert.Publisher(func(topic string, data any) error { return n.Publish(topic, data) })
+	opts := append([]options.Option{alert.SetNotifiers(publish)}, n.cfg.alerting...)
+	e, err := alert.NewEvaluator(n.logger, alert.NewConfig(opts...))
+	if err != nil {
+		return err
+	}
+	n.alerts = e
+	e.Start(n.ctx)
+	return nil
+}
+
+// Alerts 返回当前 pending 和 firing 的告警，没有开启告警时返回 nil
+func (n *Nmq) Alerts() []alert.Alert {
+	if n.alerts == nil {
+		return nil
+	}
+	return n.alerts.Alerts()
+}
@@ -0,0 +1,61 @@
+package nmq
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/andrewbytecoder/nmq/interfaces/nmq"
+	"github.com/andrewbytecoder/nmq/pkg/alert"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+func TestAlertingPublishesEvents(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	depth := prometheus.NewGauge(prometheus.GaugeOpts{Name: "queue_depth"})
+	reg.MustRegister(depth)
+	depth.Set(20000)
+
+	n := newSupervisedNmq(SetAlerting(
+		alert.SetGatherer(reg),
+		alert.SetInterval(time.Hour),
+		alert.SetRules(alert.Rule{Name: "QueueBacklog", Expr: "queue_depth > 10000"}),
+	))
+	fired := make(chan alert.Alert, 1)
+	if _, err := nmq.Subscribe(n, alert.Topic(alert.StateFiring), func(_ context.Context, _ string, a alert.Alert) error {
+		fired <- a
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if err := n.startAlerting(); err != nil {
+		t.Fatal(err)
+	}
+	defer n.alerts.Stop()
+
+	if err := n.alerts.Evaluate(context.Background(), time.Now()); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case a := <-fired:
+		if a.Rule != "QueueBacklog" || a.Value != 20000 {
+			t.Errorf("Unexpected alert %+v", a)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Timed out waiting for alert event")
+	}
+	if alerts := n.Alerts(); len(alerts) != 1 || alerts[0].State != alert.StateFiring {
+		t.Errorf("Expected one firing alert, got %+v", alerts)
+	}
+}
+
+func TestAlertingInvalidRule(t *testing.T) {
+	n := newSupervisedNmq(SetAlerting(alert.SetRules(alert.Rule{Name: "bad", Expr: "queue_depth >"})))
+	if err := n.startAlerting(); !errors.Is(err, alert.ErrInvalidRule) {
+		t.Errorf("Expected ErrInvalidRule, got %v", err)
+	}
+	if n.Alerts() != nil {
+		t.Error("Expected no alerts without evaluator")
+	}
+}
@@ -0,0 +1,226 @@
+package nmq
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/andrewbytecoder/nmq/interfaces/nmq"
+	"github.com/andrewbytecoder/nmq/pkg/cache/localcache"
+	"github.com/andrewbytecoder/nmq/pkg/options"
+	"github.com/andrewbytecoder/nmq/pkg/utils/hash"
+	"go.uber.org/zap"
+)
+
+// 快照损坏时的改名后缀和写入过程中的临时文件后缀
+const (
+	cacheCorruptSuffix = ".corrupt"
+	cacheTmpSuffix     = ".tmp"
+)
+
+// componentCache 组件注册的缓存
+type componentCache struct {
+	name    string
+	cache   nmq.PersistentCache
+	cfg     nmq.CacheConfig
+	unwatch func() // 取消变更事件订阅，未开启事件时为nil
+}
+
+// cacheNotifier 可以订阅变更的缓存，localcache.Cache 满足该接口
+type cacheNotifier interface {
+	OnSet(fn func(localcache.Event)) func()
+	OnDelete(fn func(localcache.Event)) func()
+	OnExpire(fn func(localcache.Event)) func()
+	OnEvict(fn func(localcache.Event)) func()
+}
+
+// RegisterCache 注册 component 的缓存 name，同名缓存重复注册时替换
+func (nmq *Nmq) RegisterCache(component, name string, c nmq.PersistentCache, opts ...options.Option) {
+	cc := &componentCache{name: name, cache: c}
+	for _, opt := range opts {
+		opt(&cc.cfg)
+	}
+
+	cc.unwatch = nmq.watchCache(component, cc)
+
+	nmq.cacheMu.Lock()
+	defer nmq.cacheMu.Unlock()
+	if nmq.caches == nil {
+		nmq.caches = make(map[string][]*componentCache)
+	}
+	list := nmq.caches[component]
+	for i, old := range list {
+		if old.name == name {
+			old.stopWatch()
+			list[i] = cc
+			return
+		}
+	}
+	nmq.caches[component] = append(list, cc)
+}
+
+// UnregisterCache 取消注册 component 的缓存 name
+func (nmq *Nmq) UnregisterCache(component, name string) {
+	nmq.cacheMu.Lock()
+	defer nmq.cacheMu.Unlock()
+	list := nmq.caches[component]
+	for i, cc := range list {
+		if cc.name == name {
+			cc.stopWatch()
+			nmq.caches[component] = append(list[:i:i], list[i+1:]...)
+			return
+		}
+	}
+}
+
+// watchCache 开启事件时订阅缓存的写入、删除、过期和淘汰，以 nmq.CacheEvent 发布到 nmq.CacheTopic，返回取消订阅函数
+func (n *Nmq) watchCache(component string, cc *componentCache) func() {
+	if !cc.cfg.Events {
+		return nil
+	}
+	src, ok := cc.cache.(cacheNotifier)
+	if !ok {
+		n.logger.Warn("Cache does not support change events", zap.String("component", component), zap.String("cache", cc.name))
+		return nil
+	}
+	publish := func(ev localcache.Event) {
+		e := nmq.CacheEvent{Component: component, Cache: cc.name, Op: ev.Type.String(), Key: ev.Key, Time: ev.Time}
+		if cc.cfg.ValueHash {
+			e.ValueHash = valueHash(ev.Value)
+		}
+		if err := n.Publish(nmq.CacheTopic(component, cc.name, e.Op), e); err != nil {
+			n.logger.Warn("Failed to publish cache event", zap.String("component", component),
+				zap.String("cache", cc.name), zap.Error(err))
+		}
+	}
+	stops := []func(){src.OnSet(publish), src.OnDelete(publish), src.OnExpire(publish), src.OnEvict(publish)}
+	return func() {
+		for _, stop := range stops {
+			stop()
+		}
+	}
+}
+
+// stopWatch 取消变更事件订阅
+func (cc *componentCache) stopWatch() {
+	if cc.unwatch != nil {
+		cc.unwatch()
+	}
+}
+
+// valueHash 返回值的 fnv64a 哈希，[]byte 和 string 按内容计算，其他类型按 %v 格式化后计算
+func valueHash(v interface{}) string {
+	var s string
+	switch v := v.(type) {
+	case string:
+		s = v
+	case []byte:
+		s = string(v)
+	default:
+		s = fmt.Sprintf("%v", v)
+	}
+	return fmt.Sprintf("%016x", hash.Fnv64a(s))
+}
+
+// persistedCaches 返回 component 开启持久化的缓存
+func (nmq *Nmq) persistedCaches(component string) []*componentCache {
+	nmq.cacheMu.Lock()
+	defer nmq.cacheMu.Unlock()
+	var list []*componentCache
+	for _, cc := range nmq.caches[component] {
+		if cc.cfg.Persist {
+			list = append(list, cc)
+		}
+	}
+	return list
+}
+
+// cachePath 返回缓存快照文件的路径
+func (nmq *Nmq) cachePath(component string, cc *componentCache) string {
+	file := cc.cfg.File
+	if file == "" {
+		file = cc.name + ".cache"
+	}
+	if filepath.IsAbs(file) {
+		return file
+	}
+	return filepath.Join(nmq.GetWorkDir(), "data", "cache", component, file)
+}
+
+// restoreCaches 组件启动前从快照恢复缓存，快照文件的修改时间到现在的时间视为停机时间，不计入TTL；
+// 恢复成功后删除快照，避免异常退出后再次加载过时的数据；快照损坏时改名为 .corrupt 保留现场并以空缓存启动，
+// 恢复失败不影响组件启动
+func (nmq *Nmq) restoreCaches(component string) {
+	for _, cc := range nmq.persistedCaches(component) {
+		path := nmq.cachePath(component, cc)
+		log := nmq.logger.With(zap.String("component", component), zap.String("cache", cc.name), zap.String("path", path))
+		f, err := os.Open(path)
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			log.Warn("Failed to open cache snapshot", zap.Error(err))
+			continue
+		}
+		var downtime time.Duration
+		if info, err := f.Stat(); err == nil {
+			downtime = max(time.Since(info.ModTime()), 0)
+		}
+		loaded, err := cc.cache.Restore(bufio.NewReader(f), downtime)
+		_ = f.Close()
+		if err != nil {
+			log.Warn("Cache snapshot is corrupt, starting with an empty cache", zap.Error(err))
+			if err = os.Rename(path, path+cacheCorruptSuffix); err != nil {
+				log.Warn("Failed to move corrupt cache snapshot", zap.Error(err))
+			}
+			continue
+		}
+		if err = os.Remove(path); err != nil {
+			log.Warn("Failed to remove loaded cache snapshot", zap.Error(err))
+		}
+		log.Info("Cache restored", zap.Int("loaded", loaded), zap.Duration("downtime", downtime))
+	}
+}
+
+// saveCaches 组件停止后保存缓存快照，先写临时文件再改名，保证快照文件总是完整的；保存失败只记录日志
+func (nmq *Nmq) saveCaches(component string) {
+	for _, cc := range nmq.persistedCaches(component) {
+		path := nmq.cachePath(component, cc)
+		if err := saveCache(cc.cache, path); err != nil {
+			nmq.logger.Warn("Failed to save cache snapshot", zap.String("component", component),
+				zap.String("cache", cc.name), zap.String("path", path), zap.Error(err))
+		}
+	}
+}
+
+// saveCache 将缓存写入 path
+func saveCache(c nmq.PersistentCache, path string) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return err
+	}
+	tmp := path + cacheTmpSuffix
+	f, err := os.OpenFile(tmp, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o600)
+	if err != nil {
+		return err
+	}
+	w := bufio.NewWriter(f)
+	if err = c.Save(w); err == nil {
+		err = w.Flush()
+	}
+	if err == nil {
+		err = f.Sync()
+	}
+	if e := f.Close(); err == nil {
+		err = e
+	}
+	if err == nil {
+		err = os.Rename(tmp, path)
+	}
+	if err != nil {
+		_ = os.Remove(tmp)
+	}
+	return err
+}
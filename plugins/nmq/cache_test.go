@@ -0,0 +1,143 @@
+package nmq
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/andrewbytecoder/nmq/interfaces/nmq"
+	"github.com/andrewbytecoder/nmq/pkg/cache/localcache"
+	"go.uber.org/zap"
+)
+
+var _ nmq.PersistentCache = localcache.Cache{}
+
+// newCacheNmq 创建只用于缓存保存和恢复的组件管理器
+func newCacheNmq(t *testing.T) *Nmq {
+	n := &Nmq{cfg: DefaultConfig(), logger: zap.NewNop()}
+	n.cfg.workDir = t.TempDir()
+	return n
+}
+
+func TestPersistCache(t *testing.T) {
+	n := newCacheNmq(t)
+
+	cache := localcache.NewCache()
+	cache.Set("long", "1", time.Hour)
+	cache.Set("short", "2", 200*time.Millisecond)
+	cache.SetNoExpire("forever", "3")
+	scratch := localcache.NewCache()
+	scratch.SetNoExpire("k", "v")
+	n.RegisterCache("sessions", "users", cache, nmq.SetCachePersist(true))
+	n.RegisterCache("sessions", "scratch", scratch)
+
+	n.saveCaches("sessions")
+	dir := filepath.Join(n.cfg.workDir, "data", "cache", "sessions")
+	path := filepath.Join(dir, "users.cache")
+	if _, err := os.Stat(path); err != nil {
+		t.Fatalf("Expected snapshot to be saved: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "scratch.cache")); !os.IsNotExist(err) {
+		t.Errorf("Expected cache without persist option not to be saved, got %v", err)
+	}
+
+	// 停机时间不计入TTL，停机超过 short 的TTL后 short 仍然被加载
+	time.Sleep(300 * time.Millisecond)
+	restored := localcache.NewCache()
+	n.RegisterCache("sessions", "users", restored, nmq.SetCachePersist(true))
+	n.restoreCaches("sessions")
+	for k, want := range map[string]string{"long": "1", "short": "2", "forever": "3"} {
+		if v, ok := restored.Get(k); !ok || v != want {
+			t.Errorf("Expected %s=%s after restart, got %v %v", k, want, v, ok)
+		}
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("Expected loaded snapshot to be removed, got %v", err)
+	}
+}
+
+func TestPersistCacheCorrupt(t *testing.T) {
+	n := newCacheNmq(t)
+	cache := localcache.NewCache()
+	n.RegisterCache("sessions", "users", cache, nmq.SetCachePersist(true), nmq.SetCacheFile("users.snap"))
+
+	path := filepath.Join(n.cfg.workDir, "data", "cache", "sessions", "users.snap")
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte("NMQC"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	// 快照损坏时改名保留并以空缓存启动
+	n.restoreCaches("sessions")
+	if cache.Count() != 0 {
+		t.Errorf("Expected empty cache, got %d items", cache.Count())
+	}
+	if _, err := os.Stat(path + cacheCorruptSuffix); err != nil {
+		t.Errorf("Expected corrupt snapshot to be kept aside: %v", err)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("Expected corrupt snapshot to be moved, got %v", err)
+	}
+
+	// 取消注册后不再保存
+	n.UnregisterCache("sessions", "users")
+	n.saveCaches("sessions")
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("Expected unregistered cache not to be saved, got %v", err)
+	}
+}
+
+func TestCacheEvents(t *testing.T) {
+	n := newCacheNmq(t)
+	n.ctx = context.Background()
+
+	var mu sync.Mutex
+	var got []nmq.CacheEvent
+	sub, err := nmq.Subscribe(n, nmq.CacheTopicPrefix+"#", func(ctx context.Context, topic string, ev nmq.CacheEvent) error {
+		if topic != nmq.CacheTopic(ev.Component, ev.Cache, ev.Op) {
+			return fmt.Errorf("unexpected topic %s", topic)
+		}
+		mu.Lock()
+		got = append(got, ev)
+		mu.Unlock()
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sub.Unsubscribe()
+
+	cache := localcache.NewCache(localcache.SetCapture(nil))
+	quiet := localcache.NewCache(localcache.SetCapture(nil))
+	n.RegisterCache("sessions", "users", cache, nmq.SetCacheEvents(true), nmq.SetCacheEventValueHash(true))
+	n.RegisterCache("sessions", "quiet", quiet)
+	cache.Set("u1", "alice", 0)
+	quiet.Set("q1", "x", 0)
+	cache.Delete("u1")
+	if st := waitStats(t, sub, 2); st.Delivered != 2 {
+		t.Fatalf("Expected 2 cache events, got %+v", st)
+	}
+
+	mu.Lock()
+	if got[0].Op != "set" || got[0].Key != "u1" || got[0].ValueHash == "" || got[1].Op != "delete" {
+		t.Errorf("Unexpected cache events %+v", got)
+	}
+	if got[0].ValueHash != got[1].ValueHash {
+		t.Errorf("Expected delete to hash the removed value, got %s %s", got[0].ValueHash, got[1].ValueHash)
+	}
+	mu.Unlock()
+
+	// 取消注册后不再发布
+	n.UnregisterCache("sessions", "users")
+	cache.Set("u2", "bob", 0)
+	time.Sleep(20 * time.Millisecond)
+	if st := sub.Stats(); st.Delivered != 2 {
+		t.Errorf("Expected no events after unregister, got %+v", st)
+	}
+}
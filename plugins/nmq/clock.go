@@ -0,0 +1,63 @@
+package nmq
+
+import (
+	"time"
+
+	"github.com/andrewbytecoder/nmq/pkg/clock"
+	"github.com/andrewbytecoder/nmq/pkg/options"
+	"github.com/andrewbytecoder/nmq/pkg/utils"
+	"github.com/spf13/viper"
+	"go.uber.org/zap"
+)
+
+// clockKey 配置文件中时钟偏差检测的配置项，例如 clock.ntp: [pool.ntp.org]、clock.threshold: 1s、clock.interval: 1m
+const clockKey = "clock"
+
+// startClockSkew 按 SetClockSkew 和配置文件 clock 创建时钟偏差监控，配置文件优先，没有时间源时不监控；
+// 监控作为所有雪花算法节点的默认 SkewGuard，偏差超过阈值期间 TryGenerate 暂停生成ID
+func (n *Nmq) startClockSkew() {
+	opts := append([]options.Option(nil), n.cfg.clockSkew...)
+	if servers := viper.GetStringSlice(clockKey + ".ntp"); len(servers) > 0 {
+		sources := make([]clock.Source, 0, len(servers))
+		for _, server := range servers {
+			sources = append(sources, clock.NTPSource(server))
+		}
+		opts = append(opts, clock.SetSources(sources...))
+	}
+	if d := viper.GetDuration(clockKey + ".threshold"); d > 0 {
+		opts = append(opts, clock.SetSkewThreshold(d))
+	}
+	if d := viper.GetDuration(clockKey + ".interval"); d > 0 {
+		opts = append(opts, clock.SetSkewInterval(d))
+	}
+
+	cfg := clock.NewSkewConfig(opts...)
+	if len(cfg.Sources) == 0 {
+		return
+	}
+	onSkew := cfg.OnSkew
+	opts = append(opts, clock.SetOnSkew(func(offset time.Duration, skewed bool) {
+		if skewed {
+			n.logger.Warn("Clock skew exceeds threshold, snowflake generation paused",
+				zap.Duration("offset", offset), zap.Duration("threshold", cfg.Threshold))
+		} else {
+			n.logger.Info("Clock skew back within threshold, snowflake generation resumed", zap.Duration("offset", offset))
+		}
+		if onSkew != nil {
+			onSkew(offset, skewed)
+		}
+	}))
+
+	n.skew = clock.NewSkewMonitor(opts...)
+	utils.SetDefaultSkewGuard(n.skew)
+	n.skew.Start()
+}
+
+// stopClockSkew 停止时钟偏差监控并移除默认 SkewGuard
+func (n *Nmq) stopClockSkew() {
+	if n.skew == nil {
+		return
+	}
+	utils.SetDefaultSkewGuard(nil)
+	n.skew.Stop()
+}
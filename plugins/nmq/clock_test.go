@@ -0,0 +1,51 @@
+package nmq
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/andrewbytecoder/nmq/pkg/clock"
+	"github.com/andrewbytecoder/nmq/pkg/utils"
+)
+
+func TestClockSkewGuardsSnowflake(t *testing.T) {
+	skewed := make(chan bool, 1)
+	n := newSupervisedNmq(SetClockSkew(
+		clock.SetSources(func(context.Context) (time.Duration, error) { return 5 * time.Second, nil }),
+		clock.SetSkewInterval(time.Hour),
+		clock.SetOnSkew(func(_ time.Duration, s bool) { skewed <- s }),
+	))
+	node, err := utils.NewSnowNode(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	n.startClockSkew()
+	select {
+	case s := <-skewed:
+		if !s {
+			t.Fatal("Expected skewed clock")
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Timed out waiting for skew check")
+	}
+	if _, err = node.TryGenerate(); !errors.Is(err, utils.ErrClockSkew) {
+		t.Errorf("Expected ErrClockSkew, got %v", err)
+	}
+
+	n.stopClockSkew()
+	if _, err = node.TryGenerate(); err != nil {
+		t.Errorf("Expected generation to resume after stop, got %v", err)
+	}
+}
+
+func TestClockSkewWithoutSources(t *testing.T) {
+	n := newSupervisedNmq()
+	n.startClockSkew()
+	defer n.stopClockSkew()
+	if n.skew != nil {
+		t.Error("Expected no monitor without time sources")
+	}
+}
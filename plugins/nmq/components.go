@@ -0,0 +1,83 @@
+package nmq
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/andrewbytecoder/nmq/interfaces/nmq"
+	"github.com/spf13/cobra"
+)
+
+// componentInfo components 子命令输出的组件信息
+type componentInfo struct {
+	Name         string              `json:"name"`
+	Version      string              `json:"version"`
+	Status       nmq.ComponentStatus `json:"status"`
+	Dependencies []string            `json:"dependencies"`
+}
+
+// componentsCmd 创建 components 子命令，以表格或 JSON 输出已注册的组件
+func (n *Nmq) componentsCmd() *cobra.Command {
+	var output string
+	cmd := &cobra.Command{
+		Use:   "components",
+		Short: "List registered components, versions, statuses and dependencies",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return n.writeComponents(cmd.OutOrStdout(), output)
+		},
+	}
+	cmd.Flags().StringVarP(&output, "output", "o", "table", "output format, table or json")
+	return cmd
+}
+
+// writeComponents 按 format 将组件信息写入 w
+func (n *Nmq) writeComponents(w io.Writer, format string) error {
+	infos := n.componentInfos()
+	switch format {
+	case "json":
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(infos)
+	case "table", "":
+		tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+		_, _ = fmt.Fprintln(tw, "NAME\tVERSION\tSTATUS\tDEPENDENCIES")
+		for _, info := range infos {
+			deps := strings.Join(info.Dependencies, ",")
+			if deps == "" {
+				deps = "-"
+			}
+			_, _ = fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", info.Name, info.Version, info.Status, deps)
+		}
+		return tw.Flush()
+	default:
+		return fmt.Errorf("unknown output format %q", format)
+	}
+}
+
+// componentInfos 返回除组件管理器自身外的组件信息，按名称排序，状态优先使用组件管理器记录的状态
+func (n *Nmq) componentInfos() []componentInfo {
+	n.mux.RLock()
+	components := make(map[string]nmq.Component, len(n.components))
+	for name, c := range n.components {
+		if c.GetName() != n.GetName() {
+			components[name] = c
+		}
+	}
+	n.mux.RUnlock()
+
+	infos := make([]componentInfo, 0, len(components))
+	for name, c := range components {
+		status, _ := n.ComponentStatus(name)
+		deps := n.declaredDependencies(name, c)
+		if deps == nil {
+			deps = []string{}
+		}
+		infos = append(infos, componentInfo{Name: name, Version: c.GetVersion(), Status: status, Dependencies: deps})
+	}
+	sort.Slice(infos, func(i, j int) bool { return infos[i].Name < infos[j].Name })
+	return infos
+}
@@ -0,0 +1,64 @@
+package nmq
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/andrewbytecoder/nmq/interfaces/nmq"
+)
+
+// listedComponent 带版本和状态的依赖组件
+type listedComponent struct {
+	depComponent
+	version string
+}
+
+func (l *listedComponent) GetVersion() string             { return l.version }
+func (l *listedComponent) GetStatus() nmq.ComponentStatus { return nmq.ComponentOk }
+
+func TestComponentsCmd(t *testing.T) {
+	n := newSupervisedNmq(SetDependencies("api", "cache"))
+	n.RegisterComponent("db", &listedComponent{depComponent: depComponent{name: "db"}, version: "1.0.0"})
+	n.RegisterComponent("api", &listedComponent{depComponent: depComponent{name: "api", deps: []string{"db"}}, version: "2.1.0"})
+	n.RegisterComponent("cache", &listedComponent{depComponent: depComponent{name: "cache"}, version: "0.3.0"})
+	n.setStatus("db", n.components["db"], nmq.ComponentRunning, nil)
+
+	var out bytes.Buffer
+	cmd := n.componentsCmd()
+	cmd.SetOut(&out)
+	cmd.SetArgs(nil)
+	if err := cmd.Execute(); err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if len(lines) != 4 || !strings.HasPrefix(lines[0], "NAME") {
+		t.Fatalf("Unexpected table:\n%s", out.String())
+	}
+	for i, want := range [][]string{{"api", "2.1.0", "ok", "db,cache"}, {"cache", "0.3.0", "ok", "-"}, {"db", "1.0.0", "running", "-"}} {
+		if got := strings.Fields(lines[i+1]); strings.Join(got, " ") != strings.Join(want, " ") {
+			t.Errorf("Unexpected row %d: %v, want %v", i, got, want)
+		}
+	}
+
+	out.Reset()
+	cmd.SetArgs([]string{"-o", "json"})
+	if err := cmd.Execute(); err != nil {
+		t.Fatal(err)
+	}
+	var infos []componentInfo
+	if err := json.Unmarshal(out.Bytes(), &infos); err != nil {
+		t.Fatal(err)
+	}
+	if len(infos) != 3 || infos[2].Name != "db" || infos[2].Status != nmq.ComponentRunning || len(infos[1].Dependencies) != 0 {
+		t.Errorf("Unexpected json output: %+v", infos)
+	}
+	if !strings.Contains(out.String(), `"status": "running"`) {
+		t.Errorf("Expected status name in json, got %s", out.String())
+	}
+
+	if err := n.writeComponents(&out, "yaml"); err == nil {
+		t.Error("Expected error for unknown format")
+	}
+}
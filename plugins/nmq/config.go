@@ -1,5 +1,14 @@
 package nmq
 
+import (
+	"time"
+
+	"github.com/andrewbytecoder/nmq/interfaces/nmq"
+	"github.com/andrewbytecoder/nmq/pkg/options"
+	"github.com/andrewbytecoder/nmq/pkg/preflight"
+	"go.uber.org/zap/zapcore"
+)
+
 type Config struct {
 	enableGoPs      bool
 	enablePyroscope bool
@@ -7,13 +16,52 @@ type Config struct {
 	configFile      string // 配置文件
 	certPath        string // 证书路径
 	workDir         string // 当前工作目录
+
+	reload bool // 是否开启配置热加载
+
+	drainTimeout   time.Duration // 停止时等待进行中任务完成的时间
+	upgradeTimeout time.Duration // 热升级时等待新进程就绪的时间，为0时不开启热升级
+	hookTimeout    time.Duration // 单个生命周期钩子的超时时间
+
+	dependencies  map[string][]string // 组件名称到依赖的组件，与组件实现的 Dependent 合并
+	parallelStart bool                // 是否并行启动没有依赖关系的组件
+	startFailFast bool                // 并行启动时第一个错误之后不再启动新的组件
+
+	shutdownPriority map[string]nmq.ShutdownPriority // 组件名称到停止优先级，覆盖组件实现的 ShutdownPrioritizer
+
+	restart           map[string]nmq.RestartPolicy // 组件名称到重启策略
+	restartBackoff    time.Duration                // 第一次重启前的等待时间
+	restartMaxBackoff time.Duration                // 重启前的最大等待时间
+	maxRestarts       int                          // 最大连续重启次数，小于等于0时不限制
+
+	preflight        []preflight.Check // 启动前检查
+	preflightTimeout time.Duration     // 单项启动前检查的超时时间
+
+	queueSize   int        // 提交队列容量
+	queuePolicy FullPolicy // 提交队列已满时的处理方式
+
+	componentPools map[string]int // 组件名称到专用协程池大小，配置文件的 pool.components 会覆盖
+
+	telemetry []options.Option // 匿名使用情况上报配置，默认关闭
+	alerting  []options.Option // 进程内告警规则评估配置，没有规则时不评估
+	clockSkew []options.Option // 时钟偏差检测配置，没有时间源时不检测
+
+	logLevels map[string]zapcore.Level // 组件名称到初始日志级别，配置文件的 log.levels 会覆盖
 }
 
 func DefaultConfig() *Config {
 	return &Config{
-		enableGoPs:      false,
-		enablePyroscope: false,
-		poolNumber:      10,
+		enableGoPs:        false,
+		enablePyroscope:   false,
+		poolNumber:        10,
+		queueSize:         1024,
+		queuePolicy:       FullBlock,
+		drainTimeout:      30 * time.Second,
+		preflightTimeout:  10 * time.Second,
+		hookTimeout:       30 * time.Second,
+		restartBackoff:    time.Second,
+		restartMaxBackoff: time.Minute,
+		maxRestarts:       10,
 	}
 }
 
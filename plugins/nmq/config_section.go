@@ -0,0 +1,57 @@
+package nmq
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+
+	"github.com/andrewbytecoder/nmq/interfaces/nmq"
+	"github.com/andrewbytecoder/nmq/pkg/convert"
+	"github.com/spf13/viper"
+)
+
+// ErrInvalidConfig 组件配置段解码或校验失败
+var ErrInvalidConfig = errors.New("nmq: invalid component config")
+
+// configureComponents 将各组件的配置段解码到实现了 Configurable 的组件，汇总所有组件的错误后返回
+func (n *Nmq) configureComponents() error {
+	if _, err := os.Stat(n.cfg.configFile); err == nil {
+		if err = n.readConfig(); err != nil {
+			return err
+		}
+	}
+
+	n.mux.RLock()
+	configurables := make(map[string]nmq.Configurable)
+	for name, c := range n.components {
+		if cfg, ok := c.(nmq.Configurable); ok && c.GetName() != n.GetName() {
+			configurables[name] = cfg
+		}
+	}
+	n.mux.RUnlock()
+	names := make([]string, 0, len(configurables))
+	for name := range configurables {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var errs []error
+	for _, name := range names {
+		err := convert.DecodeSection(viper.GetStringMap(name), configurables[name].ConfigSection())
+		var verrs convert.ValidationErrors
+		switch {
+		case err == nil:
+		case errors.As(err, &verrs):
+			for _, fe := range verrs {
+				errs = append(errs, fmt.Errorf("%s.%s: %s", name, fe.Field, fe.Message))
+			}
+		default:
+			errs = append(errs, fmt.Errorf("%s: %w", name, err))
+		}
+	}
+	if len(errs) == 0 {
+		return nil
+	}
+	return fmt.Errorf("%w:\n%w", ErrInvalidConfig, errors.Join(errs...))
+}
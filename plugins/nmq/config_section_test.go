@@ -0,0 +1,55 @@
+package nmq
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/andrewbytecoder/nmq/interfaces/nmq"
+	"github.com/spf13/viper"
+)
+
+type sectionConfig struct {
+	Addr    string        `mapstructure:"addr" validate:"required"`
+	Timeout time.Duration `mapstructure:"timeout" validate:"min=1ms"`
+}
+
+// sectionComponent 带配置段的测试组件
+type sectionComponent struct {
+	flakyComponent
+	cfg sectionConfig
+}
+
+func (s *sectionComponent) ConfigSection() any { return &s.cfg }
+
+func TestConfigureComponents(t *testing.T) {
+	t.Cleanup(viper.Reset)
+	n := newSupervisedNmq()
+	n.cfg.configFile = ""
+	c := &sectionComponent{cfg: sectionConfig{Timeout: time.Second}}
+	n.RegisterComponent("section", c)
+
+	viper.Set("section.addr", "127.0.0.1:9000")
+	viper.Set("section.timeout", "2m")
+	if err := n.configureComponents(); err != nil {
+		t.Fatal(err)
+	}
+	if c.cfg.Addr != "127.0.0.1:9000" || c.cfg.Timeout != 2*time.Minute {
+		t.Errorf("Unexpected config: %+v", c.cfg)
+	}
+
+	viper.Set("section.addr", "")
+	viper.Set("section.timeout", "10us")
+	err := n.configureComponents()
+	if !errors.Is(err, ErrInvalidConfig) {
+		t.Fatalf("Expected ErrInvalidConfig, got %v", err)
+	}
+	for _, want := range []string{"section.addr: is required", "section.timeout: must be at least 1ms"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("Expected %q in %v", want, err)
+		}
+	}
+}
+
+var _ nmq.Configurable = (*sectionComponent)(nil)
@@ -0,0 +1,56 @@
+package nmq
+
+import "context"
+
+// componentContext 组件的子上下文和取消函数
+type componentContext struct {
+	ctx    context.Context
+	cancel context.CancelFunc
+}
+
+// componentContext 返回组件当前的子上下文，不存在时从组件管理器上下文派生 调用方需持有 n.ctxMu
+func (n *Nmq) componentContext(name string) *componentContext {
+	if cx, ok := n.componentCx[name]; ok {
+		return cx
+	}
+	if n.componentCx == nil {
+		n.componentCx = make(map[string]*componentContext)
+	}
+	ctx, cancel := context.WithCancel(n.ctx)
+	cx := &componentContext{ctx: ctx, cancel: cancel}
+	n.componentCx[name] = cx
+	return cx
+}
+
+// GetComponentContext 返回组件的子上下文，组件停止或组件管理器上下文取消时取消，组件重新启动后返回新的上下文
+func (n *Nmq) GetComponentContext(name string) context.Context {
+	n.ctxMu.Lock()
+	defer n.ctxMu.Unlock()
+	return n.componentContext(name).ctx
+}
+
+// GetComponentCancel 返回组件当前子上下文的取消函数
+func (n *Nmq) GetComponentCancel(name string) context.CancelFunc {
+	n.ctxMu.Lock()
+	defer n.ctxMu.Unlock()
+	return n.componentContext(name).cancel
+}
+
+// cancelComponentContext 在组件停止前取消组件的子上下文，通知组件的协程退出
+func (n *Nmq) cancelComponentContext(name string) {
+	n.ctxMu.Lock()
+	cx, ok := n.componentCx[name]
+	n.ctxMu.Unlock()
+	if ok {
+		cx.cancel()
+	}
+}
+
+// renewComponentContext 在组件启动前替换已经取消的子上下文，组件管理器上下文已经取消时保持不变
+func (n *Nmq) renewComponentContext(name string) {
+	n.ctxMu.Lock()
+	defer n.ctxMu.Unlock()
+	if cx, ok := n.componentCx[name]; ok && cx.ctx.Err() != nil && n.ctx.Err() == nil {
+		delete(n.componentCx, name)
+	}
+}
@@ -0,0 +1,70 @@
+package nmq
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/andrewbytecoder/nmq/interfaces/nmq"
+)
+
+// stoppableComponent 可以启动和停止的组件
+type stoppableComponent struct {
+	depComponent
+}
+
+func (s *stoppableComponent) Stop() error { return nil }
+
+func TestComponentContext(t *testing.T) {
+	n := newSupervisedNmq()
+	log := &startLog{}
+	n.RegisterComponent("a", &stoppableComponent{depComponent{name: "a", log: log}})
+	n.RegisterComponent("b", &stoppableComponent{depComponent{name: "b", log: log}})
+
+	a, b := n.GetComponentContext("a"), n.GetComponentContext("b")
+	if a != n.GetComponentContext("a") {
+		t.Error("Expected the same context until the component stops")
+	}
+
+	// Go 的协程收到组件的子上下文，停止组件时退出且不视为失败
+	exited := make(chan struct{})
+	n.Go("a", func(ctx context.Context) error {
+		<-ctx.Done()
+		close(exited)
+		return ctx.Err()
+	})
+
+	if err := n.stopComponent("a", n.components["a"]); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case <-exited:
+	case <-time.After(time.Second):
+		t.Fatal("Expected goroutine to exit after component stopped")
+	}
+	if a.Err() == nil || b.Err() != nil {
+		t.Errorf("Expected only a's context to be canceled, got a=%v b=%v", a.Err(), b.Err())
+	}
+	n.wg.Wait()
+	if status, _ := n.ComponentStatus("a"); status == nmq.ComponentFailed {
+		t.Error("Expected stopped component not to be reported as failed")
+	}
+
+	// 重新启动后使用新的上下文
+	if err := n.startComponent("a", n.components["a"]); err != nil {
+		t.Fatal(err)
+	}
+	if ctx := n.GetComponentContext("a"); ctx == a || ctx.Err() != nil {
+		t.Error("Expected a fresh context after restart")
+	}
+
+	// 组件管理器上下文取消时所有子上下文都取消
+	n.cancel()
+	if n.GetComponentContext("a").Err() == nil || b.Err() == nil {
+		t.Error("Expected child contexts to be canceled with the manager context")
+	}
+	n.renewComponentContext("b")
+	if n.GetComponentContext("b").Err() == nil {
+		t.Error("Expected no fresh context after the manager stopped")
+	}
+}
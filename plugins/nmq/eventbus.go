@@ -0,0 +1,340 @@
+package nmq
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/andrewbytecoder/nmq/interfaces/nmq"
+	"github.com/andrewbytecoder/nmq/internal/metrics"
+	"github.com/andrewbytecoder/nmq/internal/prometheus"
+	"github.com/andrewbytecoder/nmq/pkg/options"
+	"github.com/andrewbytecoder/nmq/pkg/recovery"
+	stdprometheus "github.com/prometheus/client_golang/prometheus"
+	"go.uber.org/zap"
+)
+
+// 订阅者默认的缓冲区大小
+const defaultEventBuffer = 256
+
+var (
+	ErrInvalidTopic   = errors.New("nmq: invalid event topic")
+	ErrInvalidPattern = errors.New("nmq: invalid event pattern")
+	ErrNilHandler     = errors.New("nmq: nil event handler")
+)
+
+// eventBus 基于主题的事件总线，事件经协程池投递给订阅者
+type eventBus struct {
+	n    *Nmq
+	mu   sync.RWMutex
+	subs map[*subscriber]struct{}
+
+	published metrics.Counter
+	delivered metrics.Counter
+	failed    metrics.Counter
+	dropped   metrics.Counter
+	expired   metrics.Counter
+}
+
+// subscriber 一个订阅者，缓冲区中的事件由同一时刻至多一个投递任务按顺序处理
+type subscriber struct {
+	bus     *eventBus
+	cfg     *nmq.SubscribeConfig
+	pattern string
+	segs    []string
+	handler nmq.EventHandler
+	ch      chan nmq.Event
+
+	running atomic.Bool // 是否已有投递任务
+	closed  atomic.Bool // 是否已取消订阅
+
+	delivered atomic.Uint64
+	failed    atomic.Uint64
+	dropped   atomic.Uint64
+	expired   atomic.Uint64
+}
+
+// events 返回事件总线，第一次使用时创建
+func (n *Nmq) events() *eventBus {
+	n.busOnce.Do(func() {
+		n.bus = newEventBus(n)
+	})
+	return n.bus
+}
+
+// newEventBus 创建事件总线并注册监控指标，指标注册失败时不统计
+func newEventBus(n *Nmq) *eventBus {
+	b := &eventBus{n: n, subs: make(map[*subscriber]struct{})}
+	if cv, err := registerEventCounter("published_total", "Number of events published to the event bus."); err == nil {
+		b.published = prometheus.NewCounter(cv)
+	}
+	if cv, err := registerEventCounter("delivered_total", "Number of events handled successfully by subscribers.", "subscriber"); err == nil {
+		b.delivered = prometheus.NewCounter(cv)
+	}
+	if cv, err := registerEventCounter("failed_total", "Number of events whose handler returned an error or panicked.", "subscriber"); err == nil {
+		b.failed = prometheus.NewCounter(cv)
+	}
+	if cv, err := registerEventCounter("dropped_total", "Number of events dropped because the subscriber buffer was full.", "subscriber"); err == nil {
+		b.dropped = prometheus.NewCounter(cv)
+	}
+	if cv, err := registerEventCounter("expired_total", "Number of events skipped because they expired before delivery.", "topic"); err == nil {
+		b.expired = prometheus.NewCounter(cv)
+	}
+	return b
+}
+
+// registerEventCounter 注册事件总线计数器，已注册时返回已有的计数器
+func registerEventCounter(name, help string, labels ...string) (*stdprometheus.CounterVec, error) {
+	cv := stdprometheus.NewCounterVec(stdprometheus.CounterOpts{
+		Namespace: "nmq",
+		Subsystem: "eventbus",
+		Name:      name,
+		Help:      help,
+	}, labels)
+	if err := stdprometheus.Register(cv); err != nil {
+		var are stdprometheus.AlreadyRegisteredError
+		if errors.As(err, &are) {
+			if existing, ok := are.ExistingCollector.(*stdprometheus.CounterVec); ok {
+				return existing, nil
+			}
+		}
+		return nil, err
+	}
+	return cv, nil
+}
+
+// Publish 发布事件到事件总线，不等待订阅者处理，主题不能为空或包含通配符
+func (n *Nmq) Publish(topic string, data any, opts ...options.Option) error {
+	segs, ok := publishTopic(topic)
+	if !ok {
+		return ErrInvalidTopic
+	}
+
+	b := n.events()
+	if b.published != nil {
+		b.published.Add(1)
+	}
+	cfg := &nmq.PublishConfig{}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+	ev := nmq.Event{Topic: topic, Data: data, Time: time.Now()}
+	if cfg.TTL > 0 {
+		ev.Expires = ev.Time.Add(cfg.TTL)
+	}
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	for s := range b.subs {
+		if matchTopic(s.segs, segs) {
+			s.enqueue(ev)
+		}
+	}
+	return nil
+}
+
+// Subscribe 订阅匹配 pattern 的事件，"*" 匹配一段，"#" 匹配零段或多段
+func (n *Nmq) Subscribe(pattern string, handler nmq.EventHandler, opts ...options.Option) (nmq.Subscription, error) {
+	segs, ok := splitTopic(pattern)
+	if !ok {
+		return nil, ErrInvalidPattern
+	}
+	if handler == nil {
+		return nil, ErrNilHandler
+	}
+	cfg := &nmq.SubscribeConfig{Name: pattern, Buffer: defaultEventBuffer}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+	if cfg.Buffer <= 0 {
+		cfg.Buffer = defaultEventBuffer
+	}
+	if _, ok = publishTopic(cfg.ExpiredTopic); cfg.ExpiredTopic != "" && !ok {
+		return nil, ErrInvalidTopic
+	}
+
+	b := n.events()
+	s := &subscriber{
+		bus:     b,
+		cfg:     cfg,
+		pattern: pattern,
+		segs:    segs,
+		handler: handler,
+		ch:      make(chan nmq.Event, cfg.Buffer),
+	}
+	b.mu.Lock()
+	b.subs[s] = struct{}{}
+	b.mu.Unlock()
+	return s, nil
+}
+
+// splitTopic 按 "." 分割主题，存在空段时返回 false
+func splitTopic(topic string) ([]string, bool) {
+	if topic == "" {
+		return nil, false
+	}
+	segs := strings.Split(topic, ".")
+	for _, s := range segs {
+		if s == "" {
+			return nil, false
+		}
+	}
+	return segs, true
+}
+
+// publishTopic 分割可以发布的主题，主题不能为空或包含通配符
+func publishTopic(topic string) ([]string, bool) {
+	segs, ok := splitTopic(topic)
+	if !ok {
+		return nil, false
+	}
+	for _, s := range segs {
+		if s == "*" || s == "#" {
+			return nil, false
+		}
+	}
+	return segs, true
+}
+
+// matchTopic 判断主题是否匹配模式
+func matchTopic(pattern, topic []string) bool {
+	for i, p := range pattern {
+		if p == "#" {
+			// "#" 匹配零段或多段，尝试所有可能的剩余主题
+			for j := i; j <= len(topic); j++ {
+				if matchTopic(pattern[i+1:], topic[j:]) {
+					return true
+				}
+			}
+			return false
+		}
+		if i >= len(topic) || (p != "*" && p != topic[i]) {
+			return false
+		}
+	}
+	return len(pattern) == len(topic)
+}
+
+// enqueue 将事件放入缓冲区并确保有投递任务，缓冲区已满时丢弃
+func (s *subscriber) enqueue(ev nmq.Event) {
+	if s.closed.Load() {
+		return
+	}
+	select {
+	case s.ch <- ev:
+	default:
+		s.dropped.Add(1)
+		if s.bus.dropped != nil {
+			s.bus.dropped.With("subscriber", s.cfg.Name).Add(1)
+		}
+		return
+	}
+	s.schedule()
+}
+
+// schedule 没有投递任务时向协程池提交一个，协程池不可用时使用独立协程
+func (s *subscriber) schedule() {
+	if !s.running.CompareAndSwap(false, true) {
+		return
+	}
+	pool := s.bus.n.pool
+	if pool == nil || pool.Submit(s.drain) != nil {
+		go s.drain()
+	}
+}
+
+// drain 按顺序处理缓冲区中的事件直到缓冲区为空
+func (s *subscriber) drain() {
+	for {
+		select {
+		case ev := <-s.ch:
+			s.deliver(ev)
+		default:
+			s.running.Store(false)
+			// 退出前可能有新事件入队但因 running 为 true 没有提交任务，需要重新检查
+			if len(s.ch) == 0 || !s.running.CompareAndSwap(false, true) {
+				return
+			}
+		}
+	}
+}
+
+// deliver 调用处理函数，处理函数中的 panic 视为处理失败，已过期的事件不调用处理函数
+func (s *subscriber) deliver(ev nmq.Event) {
+	if s.closed.Load() {
+		return
+	}
+	if ev.Expired(time.Now()) {
+		s.expire(ev)
+		return
+	}
+	ctx := s.bus.n.ctx
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	err := recovery.Default.Do("event."+s.cfg.Name, nil, func() error { return s.handler(ctx, ev) })
+	if err == nil {
+		s.delivered.Add(1)
+		if s.bus.delivered != nil {
+			s.bus.delivered.With("subscriber", s.cfg.Name).Add(1)
+		}
+		return
+	}
+
+	s.failed.Add(1)
+	if s.bus.failed != nil {
+		s.bus.failed.With("subscriber", s.cfg.Name).Add(1)
+	}
+	if s.cfg.OnError != nil {
+		s.cfg.OnError(ev, err)
+	} else if logger := s.bus.n.logger; logger != nil {
+		logger.Error("Failed to handle event", zap.String("subscriber", s.cfg.Name), zap.String("topic", ev.Topic), zap.Error(err))
+	}
+}
+
+// expire 统计过期事件，配置了过期主题时转发
+func (s *subscriber) expire(ev nmq.Event) {
+	s.expired.Add(1)
+	if s.bus.expired != nil {
+		s.bus.expired.With("topic", ev.Topic).Add(1)
+	}
+	if s.cfg.ExpiredTopic == "" {
+		return
+	}
+	err := s.bus.n.Publish(s.cfg.ExpiredTopic, nmq.ExpiredEvent{Event: ev, Subscriber: s.cfg.Name})
+	if err != nil && s.bus.n.logger != nil {
+		s.bus.n.logger.Warn("Failed to forward expired event", zap.String("subscriber", s.cfg.Name),
+			zap.String("topic", ev.Topic), zap.String("expired_topic", s.cfg.ExpiredTopic), zap.Error(err))
+	}
+}
+
+// Name 订阅者名称
+func (s *subscriber) Name() string {
+	return s.cfg.Name
+}
+
+// Pattern 订阅的主题模式
+func (s *subscriber) Pattern() string {
+	return s.pattern
+}
+
+// Stats 投递统计
+func (s *subscriber) Stats() nmq.SubscriberStats {
+	return nmq.SubscriberStats{
+		Delivered: s.delivered.Load(),
+		Failed:    s.failed.Load(),
+		Dropped:   s.dropped.Load(),
+		Expired:   s.expired.Load(),
+		Pending:   len(s.ch),
+	}
+}
+
+// Unsubscribe 取消订阅，可重复调用
+func (s *subscriber) Unsubscribe() {
+	s.closed.Store(true)
+	s.bus.mu.Lock()
+	delete(s.bus.subs, s)
+	s.bus.mu.Unlock()
+}
@@ -0,0 +1,209 @@
+package nmq
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/andrewbytecoder/nmq/interfaces/nmq"
+	"go.uber.org/zap"
+)
+
+func TestMatchTopic(t *testing.T) {
+	tests := []struct {
+		pattern string
+		topic   string
+		match   bool
+	}{
+		{"mq.client.connected", "mq.client.connected", true},
+		{"mq.*.connected", "mq.client.connected", true},
+		{"mq.*", "mq.client.connected", false},
+		{"mq.#", "mq.client.connected", true},
+		{"mq.#", "mq", true},
+		{"#.connected", "mq.client.connected", true},
+		{"mq.#.connected", "mq.connected", true},
+		{"#", "anything.at.all", true},
+		{"mq.client", "mq.client.connected", false},
+		{"mq.client.connected.extra", "mq.client.connected", false},
+	}
+	for _, tt := range tests {
+		p, _ := splitTopic(tt.pattern)
+		topic, _ := splitTopic(tt.topic)
+		if got := matchTopic(p, topic); got != tt.match {
+			t.Errorf("matchTopic(%q, %q) = %v, want %v", tt.pattern, tt.topic, got, tt.match)
+		}
+	}
+}
+
+// waitStats 等待订阅者处理完所有事件
+func waitStats(t *testing.T, s nmq.Subscription, handled uint64) nmq.SubscriberStats {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		st := s.Stats()
+		if st.Delivered+st.Failed >= handled || time.Now().After(deadline) {
+			return st
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestEventBus(t *testing.T) {
+	n := &Nmq{ctx: context.Background(), logger: zap.NewNop()}
+
+	var mu sync.Mutex
+	var got []string
+	sub, err := nmq.Subscribe(n, "mq.client.*", func(ctx context.Context, topic string, id int) error {
+		mu.Lock()
+		got = append(got, topic)
+		mu.Unlock()
+		if id < 0 {
+			return errors.New("bad id")
+		}
+		return nil
+	}, nmq.SetSubscriberName("clients"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sub.Name() != "clients" || sub.Pattern() != "mq.client.*" {
+		t.Errorf("Unexpected subscription %q %q", sub.Name(), sub.Pattern())
+	}
+
+	var errs []error
+	failing, _ := n.Subscribe("mq.#", func(ctx context.Context, ev nmq.Event) error {
+		panic("boom")
+	}, nmq.SetSubscriberOnError(func(ev nmq.Event, err error) {
+		mu.Lock()
+		errs = append(errs, err)
+		mu.Unlock()
+	}))
+
+	for _, topic := range []string{"mq.client.connected", "mq.client.closed", "mq.server.started"} {
+		if err := n.Publish(topic, 1); err != nil {
+			t.Fatal(err)
+		}
+	}
+	_ = n.Publish("mq.client.error", -1)
+	// 数据类型不匹配的事件被忽略，不调用处理函数
+	_ = n.Publish("mq.client.other", "text")
+
+	st := waitStats(t, sub, 4)
+	if st.Delivered != 3 || st.Failed != 1 {
+		t.Errorf("Unexpected stats: %+v", st)
+	}
+	mu.Lock()
+	if len(got) != 3 || got[0] != "mq.client.connected" || got[2] != "mq.client.error" {
+		t.Errorf("Expected events in publish order, got %v", got)
+	}
+	mu.Unlock()
+
+	if st := waitStats(t, failing, 5); st.Failed != 5 {
+		t.Errorf("Expected panics counted as failures, got %+v", st)
+	}
+	mu.Lock()
+	if len(errs) != 5 {
+		t.Errorf("Expected 5 errors, got %d", len(errs))
+	}
+	mu.Unlock()
+
+	sub.Unsubscribe()
+	_ = n.Publish("mq.client.connected", 1)
+	time.Sleep(10 * time.Millisecond)
+	if st := sub.Stats(); st.Delivered+st.Failed != 4 {
+		t.Errorf("Expected no delivery after Unsubscribe, got %+v", st)
+	}
+}
+
+func TestEventBusDrop(t *testing.T) {
+	n := &Nmq{ctx: context.Background(), logger: zap.NewNop()}
+	block := make(chan struct{})
+	sub, _ := n.Subscribe("slow", func(ctx context.Context, ev nmq.Event) error {
+		<-block
+		return nil
+	}, nmq.SetSubscriberBuffer(2))
+
+	// 第一个事件被取出处理并阻塞，之后两个填满缓冲区，其余被丢弃
+	_ = n.Publish("slow", 0)
+	for sub.Stats().Pending != 0 {
+		time.Sleep(time.Millisecond)
+	}
+	for i := 0; i < 5; i++ {
+		_ = n.Publish("slow", i)
+	}
+	if st := sub.Stats(); st.Dropped != 3 || st.Pending != 2 {
+		t.Errorf("Unexpected stats: %+v", st)
+	}
+	close(block)
+	if st := waitStats(t, sub, 3); st.Delivered != 3 {
+		t.Errorf("Unexpected stats: %+v", st)
+	}
+}
+
+func TestEventBusInvalid(t *testing.T) {
+	n := &Nmq{}
+	if err := n.Publish("mq.*", nil); !errors.Is(err, ErrInvalidTopic) {
+		t.Errorf("Expected ErrInvalidTopic, got %v", err)
+	}
+	if err := n.Publish("mq..client", nil); !errors.Is(err, ErrInvalidTopic) {
+		t.Errorf("Expected ErrInvalidTopic, got %v", err)
+	}
+	if _, err := n.Subscribe("mq.#", func(context.Context, nmq.Event) error { return nil },
+		nmq.SetSubscriberExpiredTopic("mq.#")); !errors.Is(err, ErrInvalidTopic) {
+		t.Errorf("Expected ErrInvalidTopic, got %v", err)
+	}
+	if _, err := n.Subscribe("", func(context.Context, nmq.Event) error { return nil }); !errors.Is(err, ErrInvalidPattern) {
+		t.Errorf("Expected ErrInvalidPattern, got %v", err)
+	}
+	if _, err := n.Subscribe("mq", nil); !errors.Is(err, ErrNilHandler) {
+		t.Errorf("Expected ErrNilHandler, got %v", err)
+	}
+}
+
+func TestEventBusExpired(t *testing.T) {
+	n := &Nmq{ctx: context.Background(), logger: zap.NewNop()}
+	block := make(chan struct{})
+	sub, _ := n.Subscribe("cmd.#", func(ctx context.Context, ev nmq.Event) error {
+		if ev.Topic == "cmd.block" {
+			<-block
+		}
+		return nil
+	}, nmq.SetSubscriberName("executor"), nmq.SetSubscriberExpiredTopic("cmd.expired"))
+
+	var mu sync.Mutex
+	var expired []nmq.ExpiredEvent
+	dlq, _ := nmq.Subscribe(n, "cmd.expired", func(ctx context.Context, topic string, ev nmq.ExpiredEvent) error {
+		mu.Lock()
+		expired = append(expired, ev)
+		mu.Unlock()
+		return nil
+	})
+
+	// 第一个事件阻塞投递，之后的短 TTL 事件在缓冲区中过期
+	_ = n.Publish("cmd.block", nil)
+	for sub.Stats().Pending != 0 {
+		time.Sleep(time.Millisecond)
+	}
+	_ = n.Publish("cmd.reboot", 1, nmq.SetEventTTL(time.Millisecond))
+	_ = n.Publish("cmd.status", 2, nmq.SetEventTTL(time.Hour))
+	time.Sleep(5 * time.Millisecond)
+	close(block)
+
+	// cmd.block、cmd.status 和转发的 cmd.expired 投递成功，cmd.reboot 过期
+	deadline := time.Now().Add(2 * time.Second)
+	st := sub.Stats()
+	for st.Delivered+st.Expired < 4 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+		st = sub.Stats()
+	}
+	if st.Delivered != 3 || st.Expired != 1 {
+		t.Errorf("Unexpected stats: %+v", st)
+	}
+	waitStats(t, dlq, 1)
+	mu.Lock()
+	defer mu.Unlock()
+	if len(expired) != 1 || expired[0].Event.Topic != "cmd.reboot" || expired[0].Subscriber != "executor" {
+		t.Errorf("Unexpected expired events: %+v", expired)
+	}
+}
@@ -0,0 +1,103 @@
+package nmq
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/andrewbytecoder/nmq/interfaces/nmq"
+	"github.com/spf13/cobra"
+)
+
+// ErrFeatureNotCompiled 配置或组件依赖的可选功能没有编译进来
+var ErrFeatureNotCompiled = errors.New("nmq: feature not compiled in")
+
+var (
+	featureMu sync.RWMutex
+	features  = make(map[string]struct{})
+)
+
+// RegisterFeature 登记一个已编译进来的可选功能，供可选集成在构建标签控制的文件或子模块的 init 中调用
+func RegisterFeature(name string) {
+	featureMu.Lock()
+	defer featureMu.Unlock()
+	features[name] = struct{}{}
+}
+
+// HasFeature 返回可选功能是否已编译进来
+func HasFeature(name string) bool {
+	featureMu.RLock()
+	defer featureMu.RUnlock()
+	_, ok := features[name]
+	return ok
+}
+
+// Features 返回已编译进来的可选功能，按名称排序
+func Features() []string {
+	featureMu.RLock()
+	list := make([]string, 0, len(features))
+	for name := range features {
+		list = append(list, name)
+	}
+	featureMu.RUnlock()
+	sort.Strings(list)
+	return list
+}
+
+// checkFeatures 检查配置开启的功能和组件声明依赖的功能都已编译进来
+func (n *Nmq) checkFeatures() error {
+	var missing []string
+	require := func(owner, feature string) {
+		if !HasFeature(feature) {
+			missing = append(missing, fmt.Sprintf("%s (required by %s)", feature, owner))
+		}
+	}
+	if n.cfg.enableGoPs {
+		require("enable_gops", nmq.FeatureGoPs)
+	}
+	if n.cfg.enablePyroscope {
+		require("enable_pyroscope", nmq.FeaturePyroscope)
+	}
+
+	n.mux.RLock()
+	for name, c := range n.components {
+		if r, ok := c.(nmq.FeatureRequirer); ok && c.GetName() != n.GetName() {
+			for _, feature := range r.RequiredFeatures() {
+				require("component "+name, feature)
+			}
+		}
+	}
+	n.mux.RUnlock()
+
+	if len(missing) == 0 {
+		return nil
+	}
+	sort.Strings(missing)
+	return fmt.Errorf("%w: %s", ErrFeatureNotCompiled, strings.Join(missing, ", "))
+}
+
+// versionCmd 创建 version 子命令，输出版本、Go 版本和已编译进来的可选功能
+func (n *Nmq) versionCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "version",
+		Short: "Print version and compiled-in features",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return n.writeVersion(cmd.OutOrStdout())
+		},
+	}
+}
+
+// writeVersion 将版本信息写入 w
+func (n *Nmq) writeVersion(w io.Writer) error {
+	list := Features()
+	if len(list) == 0 {
+		list = []string{"-"}
+	}
+	_, err := fmt.Fprintf(w, "version:  %s\ngo:       %s %s/%s\nfeatures: %s\n",
+		n.GetVersion(), runtime.Version(), runtime.GOOS, runtime.GOARCH, strings.Join(list, ","))
+	return err
+}
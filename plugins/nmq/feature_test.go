@@ -0,0 +1,66 @@
+package nmq
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/andrewbytecoder/nmq/interfaces/nmq"
+)
+
+// featureComponent 声明依赖可选功能的组件
+type featureComponent struct {
+	depComponent
+	features []string
+}
+
+func (f *featureComponent) RequiredFeatures() []string { return f.features }
+
+func TestFeatures(t *testing.T) {
+	RegisterFeature("test-feature")
+	defer func() {
+		featureMu.Lock()
+		delete(features, "test-feature")
+		featureMu.Unlock()
+	}()
+	if !HasFeature("test-feature") {
+		t.Error("Expected registered feature")
+	}
+
+	n := newSupervisedNmq()
+	var out bytes.Buffer
+	if err := n.writeVersion(&out); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out.String(), n.GetVersion()) || !strings.Contains(out.String(), "test-feature") {
+		t.Errorf("Unexpected version output:\n%s", out.String())
+	}
+}
+
+func TestCheckFeatures(t *testing.T) {
+	n := newSupervisedNmq()
+	n.cfg.enablePyroscope = true
+	// 使用 nopyroscope 构建时开启 pyroscope 的配置无效
+	if err := n.checkFeatures(); HasFeature(nmq.FeaturePyroscope) != (err == nil) {
+		t.Fatalf("Unexpected result for enable_pyroscope: %v", err)
+	}
+	n.cfg.enablePyroscope = false
+
+	RegisterFeature("bridge")
+	defer func() {
+		featureMu.Lock()
+		delete(features, "bridge")
+		featureMu.Unlock()
+	}()
+	n.RegisterComponent("bridge", &featureComponent{depComponent: depComponent{name: "bridge"}, features: []string{"bridge"}})
+	if err := n.checkFeatures(); err != nil {
+		t.Fatalf("Expected compiled-in features to pass, got %v", err)
+	}
+
+	n.RegisterComponent("kafka", &featureComponent{depComponent: depComponent{name: "kafka"}, features: []string{"kafka"}})
+	err := n.checkFeatures()
+	if !errors.Is(err, ErrFeatureNotCompiled) || !strings.Contains(err.Error(), "kafka (required by component kafka)") {
+		t.Errorf("Expected ErrFeatureNotCompiled, got %v", err)
+	}
+}
@@ -0,0 +1,16 @@
+//go:build !nogops
+
+package nmq
+
+import (
+	"github.com/andrewbytecoder/nmq/interfaces/nmq"
+	"github.com/google/gops/agent"
+)
+
+func init() {
+	RegisterFeature(nmq.FeatureGoPs)
+}
+
+func startGoPs() error {
+	return agent.Listen(agent.Options{})
+}
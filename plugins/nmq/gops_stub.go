@@ -0,0 +1,14 @@
+//go:build nogops
+
+package nmq
+
+import (
+	"fmt"
+
+	"github.com/andrewbytecoder/nmq/interfaces/nmq"
+)
+
+// startGoPs 使用 nogops 构建时不包含 gops agent
+func startGoPs() error {
+	return fmt.Errorf("%w: %s", ErrFeatureNotCompiled, nmq.FeatureGoPs)
+}
@@ -0,0 +1,68 @@
+package nmq
+
+import (
+	"context"
+	"sort"
+	"sync"
+
+	"github.com/andrewbytecoder/nmq/interfaces/nmq"
+	"github.com/andrewbytecoder/nmq/pkg/recovery"
+)
+
+// Health 并发检查所有组件的健康状态，检查耗时受 ctx 限制，检查中的 panic 视为不存活
+func (n *Nmq) Health(ctx context.Context) nmq.HealthReport {
+	n.mux.RLock()
+	components := make(map[string]nmq.Component, len(n.components))
+	for name, c := range n.components {
+		if c.GetName() != n.GetName() {
+			components[name] = c
+		}
+	}
+	n.mux.RUnlock()
+
+	report := nmq.HealthReport{Live: true, Ready: true, Components: make([]nmq.ComponentHealth, 0, len(components))}
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for name, c := range components {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			h := checkHealth(ctx, name, c)
+			mu.Lock()
+			report.Components = append(report.Components, h)
+			mu.Unlock()
+		}()
+	}
+	wg.Wait()
+
+	sort.Slice(report.Components, func(i, j int) bool { return report.Components[i].Name < report.Components[j].Name })
+	for _, h := range report.Components {
+		report.Live = report.Live && h.Live
+		report.Ready = report.Ready && h.Ready
+	}
+	return report
+}
+
+// checkHealth 检查单个组件，没有实现 HealthChecker 的组件停止或重置后视为未就绪
+func checkHealth(ctx context.Context, name string, c nmq.Component) nmq.ComponentHealth {
+	h := nmq.ComponentHealth{Name: name, Live: true, Ready: true}
+	checker, ok := c.(nmq.HealthChecker)
+	if !ok {
+		if status := c.GetStatus(); status == nmq.ComponentStopped || status == nmq.ComponentReset {
+			h.Ready = false
+			h.Error = "component not running"
+		}
+		return h
+	}
+
+	if err := recovery.Default.Do("health."+name, nil, func() error { return checker.Liveness(ctx) }); err != nil {
+		h.Live, h.Ready = false, false
+		h.Error = err.Error()
+		return h
+	}
+	if err := recovery.Default.Do("health."+name, nil, func() error { return checker.Readiness(ctx) }); err != nil {
+		h.Ready = false
+		h.Error = err.Error()
+	}
+	return h
+}
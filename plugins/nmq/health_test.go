@@ -0,0 +1,77 @@
+package nmq
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/andrewbytecoder/nmq/interfaces/nmq"
+)
+
+// fakeComponent 只用于测试健康检查的组件
+type fakeComponent struct {
+	nmq.Component
+	name   string
+	status nmq.ComponentStatus
+	live   error
+	ready  error
+}
+
+func (f *fakeComponent) GetName() string                    { return f.name }
+func (f *fakeComponent) GetStatus() nmq.ComponentStatus     { return f.status }
+func (f *fakeComponent) Liveness(ctx context.Context) error { return f.live }
+func (f *fakeComponent) Readiness(ctx context.Context) error {
+	if f.name == "panic" {
+		panic("readiness failed")
+	}
+	return f.ready
+}
+
+// plainComponent 没有实现 HealthChecker 的组件
+type plainComponent struct {
+	nmq.Component
+	status nmq.ComponentStatus
+}
+
+func (p *plainComponent) GetName() string                { return "plain" }
+func (p *plainComponent) GetStatus() nmq.ComponentStatus { return p.status }
+
+func TestHealth(t *testing.T) {
+	n := &Nmq{components: make(map[string]nmq.Component)}
+	n.RegisterComponent("db", &fakeComponent{name: "db"})
+	n.RegisterComponent("plain", &plainComponent{status: nmq.ComponentRunning})
+
+	report := n.Health(context.Background())
+	if !report.Live || !report.Ready || len(report.Components) != 2 {
+		t.Fatalf("Expected healthy report, got %+v", report)
+	}
+
+	n.RegisterComponent("cache", &fakeComponent{name: "cache", ready: errors.New("warming up")})
+	report = n.Health(context.Background())
+	if !report.Live || report.Ready {
+		t.Errorf("Expected live but not ready, got %+v", report)
+	}
+	if h := report.Components[0]; h.Name != "cache" || h.Error != "warming up" {
+		t.Errorf("Expected cache first with error, got %+v", h)
+	}
+
+	n.RegisterComponent("panic", &fakeComponent{name: "panic"})
+	n.RegisterComponent("plain", &plainComponent{status: nmq.ComponentStopped})
+	n.RegisterComponent("broken", &fakeComponent{name: "broken", live: errors.New("deadlock")})
+	report = n.Health(context.Background())
+	if report.Live || report.Ready {
+		t.Errorf("Expected not live, got %+v", report)
+	}
+	for _, h := range report.Components {
+		switch h.Name {
+		case "panic", "plain":
+			if !h.Live || h.Ready || h.Error == "" {
+				t.Errorf("Expected %s live but not ready, got %+v", h.Name, h)
+			}
+		case "broken":
+			if h.Live || h.Ready {
+				t.Errorf("Expected broken not live, got %+v", h)
+			}
+		}
+	}
+}
@@ -0,0 +1,108 @@
+package nmq
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/andrewbytecoder/nmq/interfaces/nmq"
+	"github.com/andrewbytecoder/nmq/pkg/recovery"
+	"go.uber.org/zap"
+)
+
+// ErrHookFailed 启动阶段的生命周期钩子返回错误
+var ErrHookFailed = errors.New("nmq: lifecycle hook failed")
+
+// hook 一个已注册的生命周期钩子
+type hook struct {
+	name  string
+	order int
+	seq   int // 注册序号，order 相同时保持注册顺序
+	fn    nmq.Hook
+}
+
+// AddHook 注册 phase 阶段的钩子，同一阶段按 order 从小到大依次执行，order 相同时按注册顺序执行
+func (n *Nmq) AddHook(phase nmq.HookPhase, name string, order int, fn nmq.Hook) {
+	if fn == nil {
+		return
+	}
+	n.hookMu.Lock()
+	defer n.hookMu.Unlock()
+	if n.hooks == nil {
+		n.hooks = make(map[nmq.HookPhase][]hook)
+	}
+	n.hookSeq++
+	n.hooks[phase] = append(n.hooks[phase], hook{name: name, order: order, seq: n.hookSeq, fn: fn})
+}
+
+// phaseHooks 返回 phase 阶段按执行顺序排列的钩子
+func (n *Nmq) phaseHooks(phase nmq.HookPhase) []hook {
+	n.hookMu.Lock()
+	hooks := append([]hook(nil), n.hooks[phase]...)
+	n.hookMu.Unlock()
+	sort.Slice(hooks, func(i, j int) bool {
+		if hooks[i].order != hooks[j].order {
+			return hooks[i].order < hooks[j].order
+		}
+		return hooks[i].seq < hooks[j].seq
+	})
+	return hooks
+}
+
+// runHooks 依次执行 phase 阶段的钩子，每个钩子最多执行 hookTimeout
+//
+// failFast 为 true 时第一个失败的钩子终止执行并返回错误，否则记录日志后继续执行剩余钩子
+func (n *Nmq) runHooks(phase nmq.HookPhase, failFast bool) error {
+	for _, h := range n.phaseHooks(phase) {
+		start := time.Now()
+		err := n.runHook(phase, h)
+		if err == nil {
+			n.logger.Debug("Lifecycle hook finished", zap.Stringer("phase", phase), zap.String("hook", h.name),
+				zap.Duration("duration", time.Since(start)))
+			continue
+		}
+		if failFast {
+			n.logger.Error("Lifecycle hook failed", zap.Stringer("phase", phase), zap.String("hook", h.name), zap.Error(err))
+			return fmt.Errorf("%w: %s %s: %w", ErrHookFailed, phase, h.name, err)
+		}
+		n.logger.Warn("Lifecycle hook failed", zap.Stringer("phase", phase), zap.String("hook", h.name), zap.Error(err))
+	}
+	return nil
+}
+
+// runHook 执行单个钩子，停止阶段 n.ctx 已经取消，因此钩子的 ctx 不继承 n.ctx 的取消
+func (n *Nmq) runHook(phase nmq.HookPhase, h hook) error {
+	parent := n.ctx
+	if parent == nil {
+		parent = context.Background()
+	}
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(parent), n.cfg.hookTimeout)
+	defer cancel()
+	return recovery.Default.Do("hook."+phase.String()+"."+h.name, nil, func() error { return h.fn(ctx) })
+}
+
+// beforeStart 进入 PhaseStarting 并执行 HookPreStart 钩子
+func (n *Nmq) beforeStart() error {
+	n.setPhase(nmq.PhaseStarting, nil)
+	return n.runHooks(nmq.HookPreStart, true)
+}
+
+// afterStart 执行 HookPostStart 钩子，全部成功后进入 PhaseRunning
+func (n *Nmq) afterStart() error {
+	if err := n.runHooks(nmq.HookPostStart, true); err != nil {
+		return err
+	}
+	n.setPhase(nmq.PhaseRunning, nil)
+	return nil
+}
+
+// beforeStop 进入 PhaseDraining 并执行 HookPreStop 钩子
+func (n *Nmq) beforeStop() {
+	n.setPhase(nmq.PhaseDraining, nil)
+	_ = n.runHooks(nmq.HookPreStop, false)
+}
+
+// afterStop 执行 HookPostStop 钩子
+func (n *Nmq) afterStop() { _ = n.runHooks(nmq.HookPostStop, false) }
@@ -0,0 +1,61 @@
+package nmq
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/andrewbytecoder/nmq/interfaces/nmq"
+)
+
+func TestRunHooksOrder(t *testing.T) {
+	n := newSupervisedNmq()
+	var got []string
+	record := func(name string) nmq.Hook {
+		return func(context.Context) error {
+			got = append(got, name)
+			return nil
+		}
+	}
+	n.AddHook(nmq.HookPreStart, "metrics", 10, record("metrics"))
+	n.AddHook(nmq.HookPreStart, "cache", 0, record("cache"))
+	n.AddHook(nmq.HookPreStart, "discovery", 10, record("discovery"))
+	n.AddHook(nmq.HookPostStart, "other", 0, record("other"))
+	n.AddHook(nmq.HookPreStart, "nil", 0, nil)
+
+	if err := n.beforeStart(); err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"cache", "metrics", "discovery"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected %v, got %v", want, got)
+	}
+}
+
+func TestRunHooksFailure(t *testing.T) {
+	n := newSupervisedNmq()
+	boom := errors.New("boom")
+	var ran []string
+	n.AddHook(nmq.HookPreStart, "fail", 0, func(context.Context) error { return boom })
+	n.AddHook(nmq.HookPreStart, "after", 1, func(context.Context) error {
+		ran = append(ran, "start")
+		return nil
+	})
+	n.AddHook(nmq.HookPreStop, "panic", 0, func(context.Context) error { panic("oops") })
+	n.AddHook(nmq.HookPreStop, "after", 1, func(ctx context.Context) error {
+		// 停止阶段 n.ctx 已取消，钩子的 ctx 仍然可用
+		if ctx.Err() == nil {
+			ran = append(ran, "stop")
+		}
+		return nil
+	})
+
+	if err := n.beforeStart(); !errors.Is(err, ErrHookFailed) || !errors.Is(err, boom) {
+		t.Errorf("Expected ErrHookFailed wrapping boom, got %v", err)
+	}
+	n.cancel()
+	n.beforeStop()
+	if want := []string{"stop"}; !reflect.DeepEqual(ran, want) {
+		t.Errorf("Expected %v, got %v", want, ran)
+	}
+}
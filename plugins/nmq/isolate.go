@@ -0,0 +1,77 @@
+package nmq
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/andrewbytecoder/nmq/interfaces/nmq"
+	"github.com/andrewbytecoder/nmq/pkg/recovery"
+	"go.uber.org/zap"
+)
+
+// ComponentError 组件在生命周期调用中 panic，记录出错的组件和调用，Err 为 *recovery.PanicError
+type ComponentError struct {
+	Component string
+	Op        string
+	Err       error
+}
+
+func (e *ComponentError) Error() string {
+	return fmt.Sprintf("nmq: component %s panicked in %s: %v", e.Component, e.Op, e.Err)
+}
+
+func (e *ComponentError) Unwrap() error {
+	return e.Err
+}
+
+// guard 执行组件的生命周期调用，panic 转换为归属到该组件的 ComponentError，普通错误原样返回
+func guard(name, op string, fn func() error) error {
+	err := recovery.Default.Do(op+"."+name, nil, fn)
+	var pe *recovery.PanicError
+	if errors.As(err, &pe) {
+		return &ComponentError{Component: name, Op: op, Err: err}
+	}
+	return err
+}
+
+// notifyComponent 通知组件，panic 不影响其他组件，按重启策略报告组件失败
+func (n *Nmq) notifyComponent(name string, c nmq.Component, event string, data any) {
+	err := guard(name, "notify", func() error {
+		c.Notify(event, data)
+		return nil
+	})
+	if err == nil {
+		return
+	}
+	n.logger.Error("Component panicked while handling notification", zap.String("component", name),
+		zap.String("event", event), zap.Error(err))
+	n.ReportFailure(name, err)
+}
+
+// lookupInterface 先查找通过 RegisterService 注册的服务，再依次从组件的 GetInterface 获取
+func (n *Nmq) lookupInterface(uuid string) any {
+	if impl, ok := nmq.LookupService(uuid); ok {
+		return impl
+	}
+	for name, component := range n.components {
+		if f := n.componentInterface(name, component, uuid); f != nil {
+			return f
+		}
+	}
+	return nil
+}
+
+// componentInterface 从组件获取接口，panic 的组件视为不提供该接口
+func (n *Nmq) componentInterface(name string, c nmq.Component, uuid string) any {
+	var f any
+	err := guard(name, "interface", func() error {
+		f = c.GetInterface(uuid)
+		return nil
+	})
+	if err != nil {
+		n.logger.Error("Component panicked while providing interface", zap.String("component", name),
+			zap.String("interface", uuid), zap.Error(err))
+		return nil
+	}
+	return f
+}
@@ -0,0 +1,113 @@
+package nmq
+
+import (
+	"errors"
+	"sync/atomic"
+	"testing"
+
+	"github.com/andrewbytecoder/nmq/interfaces/nmq"
+	"github.com/andrewbytecoder/nmq/pkg/recovery"
+)
+
+// panicComponent 生命周期调用 panic 的组件，panics 为 false 时正常执行并计数
+type panicComponent struct {
+	nmq.Component
+	name     string
+	panics   bool
+	stops    atomic.Int32
+	notifies atomic.Int32
+}
+
+func (p *panicComponent) GetName() string                { return p.name }
+func (p *panicComponent) GetStatus() nmq.ComponentStatus { return nmq.ComponentRunning }
+func (p *panicComponent) Init() error                    { return p.call() }
+func (p *panicComponent) Start() error                   { return p.call() }
+func (p *panicComponent) Reset() error                   { return p.call() }
+func (p *panicComponent) Stop() error {
+	p.stops.Add(1)
+	return p.call()
+}
+func (p *panicComponent) Notify(string, any) {
+	p.notifies.Add(1)
+	_ = p.call()
+}
+func (p *panicComponent) GetInterface(uuid string) any {
+	_ = p.call()
+	return p.name
+}
+
+func (p *panicComponent) call() error {
+	if p.panics {
+		panic("bad plugin " + p.name)
+	}
+	return nil
+}
+
+func TestGuardPanic(t *testing.T) {
+	n := newSupervisedNmq()
+	c := &panicComponent{name: "bad", panics: true}
+	n.RegisterComponent("bad", c)
+
+	err := n.startComponent("bad", c)
+	var ce *ComponentError
+	if !errors.As(err, &ce) || ce.Component != "bad" || ce.Op != "start" {
+		t.Fatalf("Expected ComponentError for bad start, got %v", err)
+	}
+	var pe *recovery.PanicError
+	if !errors.As(err, &pe) {
+		t.Errorf("Expected wrapped PanicError, got %v", err)
+	}
+	if status, _ := n.ComponentStatus("bad"); status != nmq.ComponentFailed {
+		t.Errorf("Expected failed status, got %v", status)
+	}
+
+	// 普通错误不包装
+	plain := errors.New("plain")
+	if err = guard("bad", "init", func() error { return plain }); err != plain {
+		t.Errorf("Expected plain error, got %v", err)
+	}
+}
+
+func TestStopContinuesAfterPanic(t *testing.T) {
+	n := newSupervisedNmq()
+	bad := &panicComponent{name: "bad", panics: true}
+	good := &panicComponent{name: "good"}
+	n.RegisterComponent("bad", bad)
+	n.RegisterComponent("good", good)
+
+	err := n.Stop()
+	var ce *ComponentError
+	if !errors.As(err, &ce) || ce.Component != "bad" || ce.Op != "stop" {
+		t.Fatalf("Expected ComponentError for bad stop, got %v", err)
+	}
+	if good.stops.Load() != 1 {
+		t.Errorf("Expected good component to be stopped, got %d stops", good.stops.Load())
+	}
+	if status, _ := n.ComponentStatus("good"); status != nmq.ComponentStopped {
+		t.Errorf("Expected good stopped, got %v", status)
+	}
+}
+
+func TestNotifyIsolatesPanic(t *testing.T) {
+	n := newSupervisedNmq()
+	bad := &panicComponent{name: "bad", panics: true}
+	good := &panicComponent{name: "good"}
+	n.RegisterComponent("bad", bad)
+	n.RegisterComponent("good", good)
+
+	n.Notify("config.changed", nil)
+	if good.notifies.Load() != 1 || bad.notifies.Load() != 1 {
+		t.Errorf("Expected both components notified, got good=%d bad=%d", good.notifies.Load(), bad.notifies.Load())
+	}
+	// 默认 RestartNever，panic 的组件标记为失败
+	if status, _ := n.ComponentStatus("bad"); status != nmq.ComponentFailed {
+		t.Errorf("Expected bad failed, got %v", status)
+	}
+	if status, _ := n.ComponentStatus("good"); status == nmq.ComponentFailed {
+		t.Errorf("Expected good unaffected, got %v", status)
+	}
+
+	if f := n.GetInterface("any"); f != "good" {
+		t.Errorf("Expected interface from good component, got %v", f)
+	}
+}
@@ -0,0 +1,74 @@
+package nmq
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/spf13/viper"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// newBufferLogger 创建 info 级别、输出到 buf 的日志记录器
+func newBufferLogger(buf *bytes.Buffer) *zap.Logger {
+	enc := zapcore.NewConsoleEncoder(zap.NewDevelopmentEncoderConfig())
+	return zap.New(zapcore.NewCore(enc, zapcore.AddSync(buf), zapcore.InfoLevel))
+}
+
+func TestComponentLogger(t *testing.T) {
+	var buf bytes.Buffer
+	n := newSupervisedNmq(SetComponentLogLevel("db", zapcore.WarnLevel))
+	n.logger = newBufferLogger(&buf)
+
+	api := n.ComponentLogger("api")
+	db := n.ComponentLogger("db")
+	api.Debug("api debug")
+	api.Info("api info")
+	db.Info("db info")
+	if out := buf.String(); !strings.Contains(out, "api\tapi info") || strings.Contains(out, "api debug") || strings.Contains(out, "db info") {
+		t.Fatalf("Unexpected output before level change:\n%s", out)
+	}
+
+	// 组件级别可以低于全局级别，并对已经获取的日志记录器立即生效
+	buf.Reset()
+	n.SetComponentLogLevel("api", zapcore.DebugLevel)
+	api.With(zap.String("k", "v")).Debug("api debug")
+	n.ComponentLogger("api").Debug("again")
+	db.Debug("db debug")
+	if out := buf.String(); !strings.Contains(out, "api debug") || !strings.Contains(out, "again") || strings.Contains(out, "db debug") {
+		t.Errorf("Unexpected output after level change:\n%s", out)
+	}
+
+	levels := n.ComponentLogLevels()
+	if levels["api"] != zapcore.DebugLevel || levels["db"] != zapcore.WarnLevel {
+		t.Errorf("Unexpected levels %v", levels)
+	}
+}
+
+func TestConfigureLogLevels(t *testing.T) {
+	t.Cleanup(viper.Reset)
+	n := newSupervisedNmq()
+	n.logger = newBufferLogger(&bytes.Buffer{})
+	api := n.ComponentLogger("api")
+
+	viper.Set("log.levels", map[string]any{"api": "error", "admin": "debug"})
+	if err := n.configureLogLevels(); err != nil {
+		t.Fatal(err)
+	}
+	if api.Core().Enabled(zapcore.WarnLevel) || !api.Core().Enabled(zapcore.ErrorLevel) {
+		t.Error("Expected api logger at error level")
+	}
+	if levels := n.ComponentLogLevels(); levels["admin"] != zapcore.DebugLevel {
+		t.Errorf("Expected admin level from config, got %v", levels)
+	}
+
+	viper.Set("log.levels", map[string]any{"api": "loud"})
+	if err := n.configureLogLevels(); !errors.Is(err, ErrInvalidConfig) {
+		t.Errorf("Expected ErrInvalidConfig, got %v", err)
+	}
+	if levels := n.ComponentLogLevels(); levels["api"] != zapcore.ErrorLevel {
+		t.Errorf("Expected api level unchanged, got %v", levels["api"])
+	}
+}
@@ -0,0 +1,136 @@
+package nmq
+
+import (
+	"errors"
+	"time"
+
+	"github.com/andrewbytecoder/nmq/internal/metrics"
+	"github.com/andrewbytecoder/nmq/internal/prometheus"
+	stdprometheus "github.com/prometheus/client_golang/prometheus"
+)
+
+// lifecycleMetrics 组件生命周期监控指标，注册失败的指标为nil
+type lifecycleMetrics struct {
+	startSeconds metrics.Histogram
+	stopSeconds  metrics.Histogram
+	restarts     metrics.Counter
+	status       metrics.Gauge
+}
+
+// lifecycle 返回组件生命周期监控指标，第一次使用时注册
+func (n *Nmq) lifecycle() *lifecycleMetrics {
+	n.metricsOnce.Do(func() {
+		n.metrics = newLifecycleMetrics()
+	})
+	return n.metrics
+}
+
+// newLifecycleMetrics 注册组件生命周期监控指标，已注册时复用已有的指标
+func newLifecycleMetrics() *lifecycleMetrics {
+	m := &lifecycleMetrics{}
+	if hv, err := registerCollector(stdprometheus.NewHistogramVec(stdprometheus.HistogramOpts{
+		Namespace: "nmq",
+		Subsystem: "component",
+		Name:      "start_seconds",
+		Help:      "Time spent in a single component Start call.",
+		Buckets:   stdprometheus.DefBuckets,
+	}, []string{"component"})); err == nil {
+		m.startSeconds = prometheus.NewHistogram(hv)
+	}
+	if hv, err := registerCollector(stdprometheus.NewHistogramVec(stdprometheus.HistogramOpts{
+		Namespace: "nmq",
+		Subsystem: "component",
+		Name:      "stop_seconds",
+		Help:      "Time spent in a single component Stop call.",
+		Buckets:   stdprometheus.DefBuckets,
+	}, []string{"component"})); err == nil {
+		m.stopSeconds = prometheus.NewHistogram(hv)
+	}
+	if cv, err := registerCollector(stdprometheus.NewCounterVec(stdprometheus.CounterOpts{
+		Namespace: "nmq",
+		Subsystem: "component",
+		Name:      "restarts_total",
+		Help:      "Number of times a component was restarted by the supervisor.",
+	}, []string{"component"})); err == nil {
+		m.restarts = prometheus.NewCounter(cv)
+	}
+	if gv, err := registerCollector(stdprometheus.NewGaugeVec(stdprometheus.GaugeOpts{
+		Namespace: "nmq",
+		Subsystem: "component",
+		Name:      "status",
+		Help:      "Current lifecycle status of a component (0 ok, 1 init, 2 running, 3 stopped, 4 reset, 5 failed, 6 restarting).",
+	}, []string{"component"})); err == nil {
+		m.status = prometheus.NewGauge(gv)
+	}
+	return m
+}
+
+// registerCollector 注册指标，已注册同名指标时返回已有的指标
+func registerCollector[T stdprometheus.Collector](c T) (T, error) {
+	err := stdprometheus.Register(c)
+	if err == nil {
+		return c, nil
+	}
+	var are stdprometheus.AlreadyRegisteredError
+	if errors.As(err, &are) {
+		if existing, ok := are.ExistingCollector.(T); ok {
+			return existing, nil
+		}
+	}
+	var zero T
+	return zero, err
+}
+
+// observeStart 记录一次 Start 调用的耗时
+func (m *lifecycleMetrics) observeStart(name string, d time.Duration) {
+	if m.startSeconds != nil {
+		m.startSeconds.With("component", name).Observe(d.Seconds())
+	}
+}
+
+// observeStop 记录一次 Stop 调用的耗时
+func (m *lifecycleMetrics) observeStop(name string, d time.Duration) {
+	if m.stopSeconds != nil {
+		m.stopSeconds.With("component", name).Observe(d.Seconds())
+	}
+}
+
+// restarted 记录一次重启
+func (m *lifecycleMetrics) restarted(name string) {
+	if m.restarts != nil {
+		m.restarts.With("component", name).Add(1)
+	}
+}
+
+// setStatus 记录组件当前状态
+func (m *lifecycleMetrics) setStatus(name string, status uint) {
+	if m.status != nil {
+		m.status.With("component", name).Set(float64(status))
+	}
+}
+
+// registerPoolMetrics 注册协程池使用情况指标，协程池创建之后调用
+func (n *Nmq) registerPoolMetrics() error {
+	gauges := []struct {
+		name string
+		help string
+		load func() float64
+	}{
+		{"capacity", "Capacity of the worker pool.", func() float64 { return float64(n.pool.Cap()) }},
+		{"running_workers", "Number of busy workers in the worker pool.", func() float64 { return float64(n.pool.Running()) }},
+		{"free_workers", "Number of idle worker slots in the worker pool.", func() float64 { return float64(n.pool.Free()) }},
+		{"waiting_tasks", "Number of submits blocked waiting for a free worker.", func() float64 { return float64(n.pool.Waiting()) }},
+	}
+	for _, g := range gauges {
+		err := prometheus.RegisterGaugeFunc(stdprometheus.GaugeOpts{
+			Namespace: "nmq",
+			Subsystem: "pool",
+			Name:      g.name,
+			Help:      g.help,
+		}, g.load)
+		if err != nil && !alreadyRegistered(err) {
+			return err
+		}
+	}
+	return nil
+}
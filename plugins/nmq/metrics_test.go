@@ -0,0 +1,52 @@
+package nmq
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/andrewbytecoder/nmq/interfaces/nmq"
+	"github.com/panjf2000/ants/v2"
+)
+
+func TestLifecycleMetrics(t *testing.T) {
+	// 指标注册在全局，每次运行使用不同的组件名称
+	name := fmt.Sprintf("metered%d", time.Now().UnixNano())
+	n := newSupervisedNmq(SetRestartPolicy(name, nmq.RestartOnFailure))
+	c := &flakyComponent{failStarts: 1}
+	n.RegisterComponent(name, c)
+	if err := n.startComponent(name, c); err != nil {
+		t.Fatal(err)
+	}
+	if err := n.StopComponent(name); err != nil {
+		t.Fatal(err)
+	}
+
+	pool, err := ants.NewPool(4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer pool.Release()
+	n.pool = pool
+	if err = n.registerPoolMetrics(); err != nil {
+		t.Fatal(err)
+	}
+
+	text, err := gatherMetrics()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		`nmq_component_start_seconds_count{component="` + name + `"} 2`,
+		`nmq_component_stop_seconds_count{component="` + name + `"} 1`,
+		`nmq_component_restarts_total{component="` + name + `"} 1`,
+		`nmq_component_status{component="` + name + `"} 3`,
+		`nmq_pool_capacity 4`,
+		`nmq_pool_free_workers 4`,
+	} {
+		if !strings.Contains(string(text), want) {
+			t.Errorf("Expected %q in metrics", want)
+		}
+	}
+}
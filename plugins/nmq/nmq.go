@@ -2,13 +2,20 @@ package nmq
 
 import (
 	"context"
+	"errors"
 	"fmt"
 
 	"sync"
 
 	"github.com/andrewbytecoder/nmq/interfaces"
 	"github.com/andrewbytecoder/nmq/interfaces/nmq"
+	"github.com/andrewbytecoder/nmq/pkg/alert"
+	"github.com/andrewbytecoder/nmq/pkg/clock"
+	"github.com/andrewbytecoder/nmq/pkg/network/listener"
+	"github.com/andrewbytecoder/nmq/pkg/options"
+	"github.com/andrewbytecoder/nmq/pkg/telemetry"
 	"github.com/andrewbytecoder/nmq/pkg/utils"
+	"github.com/andrewbytecoder/nmq/pkg/workdir"
 	"github.com/panjf2000/ants/v2"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
@@ -25,11 +32,52 @@ type Nmq struct {
 	logger  *zap.Logger
 	ctx     context.Context
 	cancel  context.CancelFunc
+	exit    func(code int) // 强制退出函数，为nil时使用 os.Exit
 	rootCmd *cobra.Command
 	wg      sync.WaitGroup // 协程同步
 	cfg     *Config
 
-	pool *ants.Pool
+	pool  *ants.Pool
+	queue *taskQueue                // 协程池前的有界提交队列
+	pools map[string]*componentPool // 组件名称到专用协程池，Start 之后只读
+
+	resMux    sync.RWMutex                // for resources
+	resources map[string]*resourceAccount // component name to resource usage
+
+	telemetry *telemetry.Reporter // 匿名使用情况上报
+	alerts    *alert.Evaluator    // 进程内告警规则评估
+	skew      *clock.SkewMonitor  // 时钟偏差监控，没有配置时间源时为 nil
+
+	reloadMu sync.Mutex                // 保证同一时刻只有一次热加载
+	sections map[string]map[string]any // 最近一次应用的各组件配置段
+	workdir  *workdir.Manager          // 工作目录，Init 之后可用
+
+	busOnce sync.Once
+	bus     *eventBus // 事件总线，第一次使用时创建
+
+	supMu      sync.Mutex
+	supervised map[string]*supervised // 组件名称到监控记录
+
+	hookMu  sync.Mutex
+	hookSeq int
+	hooks   map[nmq.HookPhase][]hook // 各阶段注册的生命周期钩子
+
+	metricsOnce sync.Once
+	metrics     *lifecycleMetrics // 组件生命周期监控指标，第一次使用时注册
+
+	logMu     sync.Mutex
+	logLevels map[string]zap.AtomicLevel // 组件名称到日志级别，组件的日志记录器共享
+
+	phaseMu   sync.Mutex
+	phase     nmq.Phase     // 当前生命周期阶段
+	phaseSeen uint          // 到达过的阶段，按位记录
+	phaseCh   chan struct{} // 阶段变化时关闭，唤醒 WaitForPhase
+
+	ctxMu       sync.Mutex
+	componentCx map[string]*componentContext // 组件名称到从 ctx 派生的子上下文
+
+	cacheMu sync.Mutex
+	caches  map[string][]*componentCache // 组件名称到注册的缓存
 }
 
 // NewNmq 创建一个组件管理器
@@ -42,12 +90,13 @@ func NewNmq(op ...Option) *Nmq {
 	}
 
 	n.components = make(map[string]nmq.Component)
+	n.resources = make(map[string]*resourceAccount)
 	// 没有指定日志记录器的情况下，创建默认日志记录器
 	if n.logger == nil {
 		log, err := utils.CreateProductZapLogger(utils.SetLogLevel(zapcore.DebugLevel),
 			utils.SetLogMaxSize(50), utils.SetLogMaxBackups(2),
 			utils.SetLogMaxAge(30), utils.SetLogCompress(true),
-			utils.SetLogFilename("./log/ncp.log"), utils.SetLogLevelKey("info"))
+			utils.SetLogFilename(workdir.Resolve(n.cfg.workDir, "./log/ncp.log")), utils.SetLogLevelKey("info"))
 		if err != nil {
 			fmt.Println("Failed to create logger")
 			return nil
@@ -80,6 +129,7 @@ func NewNmq(op ...Option) *Nmq {
 		// parse flags
 		err := n.Init()
 		if err != nil {
+			n.setPhase(nmq.PhaseFailed, err)
 			return err
 		}
 		n.logger.Info("Starting NCP")
@@ -87,6 +137,7 @@ func NewNmq(op ...Option) *Nmq {
 		err = n.Start()
 		if err != nil {
 			n.logger.Error("Failed to start NCP", zap.Error(err))
+			n.setPhase(nmq.PhaseFailed, err)
 			return err
 		}
 		return nil
@@ -99,8 +150,11 @@ func NewNmq(op ...Option) *Nmq {
 			n.logger.Error("Failed to stop NCP", zap.Error(err))
 			return err
 		}
-		// 在清理资源之前进行善后工作
-		n.pool.Release()
+		// 在清理资源之前进行善后工作，等待进行中的任务完成
+		if err = n.drain(n.cfg.drainTimeout); err != nil {
+			n.logger.Warn("In-flight work not drained", zap.Duration("timeout", n.cfg.drainTimeout), zap.Error(err))
+		}
+		n.setPhase(nmq.PhaseStopped, nil)
 
 		// todo: 清理资源，根据实际看是否需要将该部分动作放到Execute() 执行结束之后执行
 		// 如果用户将部分自定义资源绑定到cobra中这里释放资源可能会有问题
@@ -113,13 +167,15 @@ func NewNmq(op ...Option) *Nmq {
 		return nil
 	}
 
+	n.rootCmd.AddCommand(n.supportBundleCmd(), n.componentsCmd(), n.versionCmd())
+
 	n.rootCmd.SetUsageFunc(usageFunc)
 	// Make help just show the usage
 	n.rootCmd.SetHelpTemplate(`{{.UsageString}}`)
 	// config --config.file 无论在子命令还是主命令里面都只能使用一次
 	n.rootCmd.PersistentFlags().StringVarP(&n.cfg.configFile, "config.file", "f", "ncp.yaml", "input the config file name")
 	n.rootCmd.PersistentFlags().StringVarP(&n.cfg.certPath, "cert.path", "c", "./", "cert path for https")
-	n.rootCmd.PersistentFlags().StringVarP(&n.cfg.workDir, "work", "w", "", "config the work path")
+	n.rootCmd.PersistentFlags().StringVarP(&n.cfg.workDir, "work", "w", n.cfg.workDir, "config the work path")
 
 	return n
 }
@@ -173,15 +229,9 @@ func (nmq *Nmq) GetComponentManager() nmq.ComponentManager {
 	return nmq
 }
 
-// GetInterface 获取接口
+// GetInterface 获取接口，优先返回通过 RegisterService 注册的服务
 func (nmq *Nmq) GetInterface(uuid string) any {
-	for _, component := range nmq.components {
-		f := component.GetInterface(uuid)
-		if f != nil {
-			return f
-		}
-	}
-	return nil
+	return nmq.lookupInterface(uuid)
 }
 
 // Init 初始化组件
@@ -194,25 +244,42 @@ func (nmq *Nmq) Init() error {
 	}
 	viper.SetConfigType("yaml")
 
-	for _, component := range nmq.components {
-		// 自己不能初始化自己
-		if component.GetName() == "nmq" {
-			continue
-		}
-		err := component.Init()
-		if err != nil {
-			nmq.logger.Error("Failed to init component", zap.Error(err))
-			return err
-		}
+	// 创建工作目录结构并检查可写，目录不可用时直接退出
+	nmq.workdir, err = workdir.New(nmq.cfg.workDir)
+	if err != nil {
+		nmq.logger.Error("Failed to prepare work dir", zap.String("dir", nmq.cfg.workDir), zap.Error(err))
+		return err
 	}
 
-	return nil
+	// 加载配置文件中声明的插件组件，之后与内置组件一起初始化
+	if err = nmq.loadPlugins(); err != nil {
+		nmq.logger.Error("Failed to load plugins", zap.Error(err))
+		return err
+	}
+
+	// 解码并校验各组件的配置段，所有错误一起报告
+	if err = nmq.configureComponents(); err != nil {
+		nmq.logger.Error("Invalid component config", zap.Error(err))
+		return err
+	}
+	if err = nmq.configureLogLevels(); err != nil {
+		nmq.logger.Error("Invalid component log level", zap.Error(err))
+		return err
+	}
+
+	return nmq.initComponents()
 }
 
-// Start 启动组件
+// Start 启动组件，组件启动前恢复其开启持久化的缓存
 func (nmq *Nmq) Start() error {
+	// 启动前检查，存在失败的 fatal 检查时不启动任何组件
+	err := nmq.runPreflight()
+	if err != nil {
+		return err
+	}
+
 	// 加载ncp各种辅助代理
-	err := loadAgentByConfig(nmq.cfg)
+	err = loadAgentByConfig(nmq.cfg)
 	if err != nil {
 		return err
 	}
@@ -225,48 +292,105 @@ func (nmq *Nmq) Start() error {
 		nmq.logger.Error("Failed to create pool", zap.Error(err))
 		return err
 	}
+	nmq.queue = newTaskQueue(nmq.pool, nmq.cfg.queueSize, nmq.cfg.queuePolicy)
+	if err = nmq.startComponentPools(); err != nil {
+		nmq.logger.Error("Failed to create component pools", zap.Error(err))
+		return err
+	}
+	if err = nmq.registerQueueMetrics(); err != nil {
+		nmq.logger.Warn("Failed to register submit queue metrics", zap.Error(err))
+	}
+	if err = nmq.registerPoolMetrics(); err != nil {
+		nmq.logger.Warn("Failed to register pool metrics", zap.Error(err))
+	}
+	if err = nmq.startAlerting(); err != nil {
+		nmq.logger.Error("Failed to start alerting", zap.Error(err))
+		return err
+	}
+	nmq.startClockSkew()
 
-	for _, component := range nmq.components {
-		if component.GetName() == nmq.GetName() {
-			continue
-		}
-		err := component.Start()
-		if err != nil {
-			nmq.logger.Error("Failed to start component", zap.Error(err))
-			return err
-		}
+	if err = nmq.beforeStart(); err != nil {
+		return err
 	}
-	return nil
+
+	if err = nmq.startComponents(); err != nil {
+		return err
+	}
+
+	if nmq.cfg.reload {
+		nmq.startReload()
+	}
+
+	// 热升级启动时通知父进程开始停止
+	if err = listener.Ready(); err != nil {
+		nmq.logger.Warn("Failed to notify parent process", zap.Error(err))
+	}
+	if nmq.cfg.upgradeTimeout > 0 {
+		nmq.watchUpgrade()
+	}
+
+	// 启动匿名使用情况上报，未开启时不做任何事
+	opts := append([]options.Option{
+		telemetry.SetVersion(nmq.GetVersion()),
+		telemetry.SetComponents(nmq.componentNames),
+	}, nmq.cfg.telemetry...)
+	nmq.telemetry = telemetry.NewReporter(nmq.logger, telemetry.NewConfig(opts...))
+	nmq.telemetry.Start(nmq.ctx)
+	return nmq.afterStart()
+}
+
+// componentNames 获取已注册组件的名称
+func (nmq *Nmq) componentNames() []string {
+	nmq.mux.RLock()
+	defer nmq.mux.RUnlock()
+	names := make([]string, 0, len(nmq.components))
+	for name := range nmq.components {
+		names = append(names, name)
+	}
+	return names
 }
 
-// Stop 停止组件
+// Stop 停止组件，组件停止后保存其开启持久化的缓存
 func (nmq *Nmq) Stop() error {
+	// 取消 context 之前执行，钩子还可以使用组件，例如从服务发现注销
+	nmq.beforeStop()
+	defer nmq.afterStop()
 
 	nmq.cancel()
+	if nmq.telemetry != nil {
+		nmq.telemetry.Stop()
+	}
+	if nmq.alerts != nil {
+		nmq.alerts.Stop()
+	}
+	nmq.stopClockSkew()
 
-	for _, component := range nmq.components {
-		if component.GetName() == nmq.GetName() {
-			continue
-		}
-		err := component.Stop()
+	for name, usage := range nmq.ResourceReport() {
+		nmq.logger.Info("Component resource usage", zap.String("component", name), zap.Any("usage", usage))
+	}
+
+	// 按停止优先级依次停止，单个组件停止失败或 panic 不影响其他组件停止，所有错误一起返回
+	var errs []error
+	for _, name := range nmq.stopOrder() {
+		err := nmq.stopComponent(name, nmq.GetComponent(name))
 		if err != nil {
-			nmq.logger.Error("Failed to stop component", zap.Error(err))
-			return err
+			nmq.logger.Error("Failed to stop component", zap.String("component", name), zap.Error(err))
+			errs = append(errs, fmt.Errorf("stop %s: %w", name, err))
 		}
 	}
 
-	return nil
+	return errors.Join(errs...)
 }
 
 // Reset 重置组件
 func (nmq *Nmq) Reset() error {
-	for _, component := range nmq.components {
+	for name, component := range nmq.components {
 		if component.GetName() == nmq.GetName() {
 			continue
 		}
-		err := component.Reset()
+		err := nmq.resetComponent(name, component)
 		if err != nil {
-			nmq.logger.Error("Failed to reset component", zap.Error(err))
+			nmq.logger.Error("Failed to reset component", zap.String("component", name), zap.Error(err))
 			return err
 		}
 	}
@@ -283,24 +407,23 @@ func (nmq *Nmq) GetVersion() string {
 	return "v1.0.0.0"
 }
 
-// Notify 通知组件
+// Notify 通知组件，同时以 event 为主题发布到事件总线
 func (nmq *Nmq) Notify(event string, data any) {
-	for _, component := range nmq.components {
+	for name, component := range nmq.components {
 		if component.GetName() == nmq.GetName() {
 			continue
 		}
-		component.Notify(event, data)
+		nmq.notifyComponent(name, component, event, data)
 	}
+	_ = nmq.Publish(event, data)
 }
 
+// Submit 直接提交任务到共享协程池，协程池已满时阻塞，需要优先级或背压时使用 SubmitPriority
 func (nmq *Nmq) Submit(task func()) error {
-	nmq.mux.Lock()
-	defer nmq.mux.Unlock()
-	err := nmq.pool.Submit(task)
-	if err != nil {
-		return err
+	if nmq.pool == nil {
+		return ErrQueueClosed
 	}
-	return nil
+	return nmq.pool.Submit(task)
 }
 
 func (nmq *Nmq) GetConfigFile() string {
@@ -312,9 +435,17 @@ func (nmq *Nmq) GetCertPath() string {
 }
 
 func (nmq *Nmq) GetWorkDir() string {
+	if nmq.workdir != nil {
+		return nmq.workdir.Root()
+	}
 	return nmq.cfg.workDir
 }
 
+// ResolvePath 将相对路径定位到工作目录下，绝对路径原样返回
+func (nmq *Nmq) ResolvePath(path string) string {
+	return workdir.Resolve(nmq.GetWorkDir(), path)
+}
+
 // GetStatus 获取组件状态
 func (nmq *Nmq) GetStatus() nmq.ComponentStatus {
 	return nmq.status
@@ -338,6 +469,8 @@ func (nmq *Nmq) GetLogger() *zap.Logger {
 func (nmq *Nmq) Execute() error {
 
 	nmq.logger.Info("Waiting for NCP to exit")
+	stop := nmq.handleSignals()
+	defer stop()
 	if err := nmq.rootCmd.Execute(); err != nil {
 		nmq.logger.Error("Failed to execute NCP", zap.Error(err))
 		return err
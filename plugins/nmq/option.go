@@ -2,8 +2,13 @@ package nmq
 
 import (
 	"context"
+	"time"
 
+	"github.com/andrewbytecoder/nmq/interfaces/nmq"
+	"github.com/andrewbytecoder/nmq/pkg/options"
+	"github.com/andrewbytecoder/nmq/pkg/preflight"
 	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
 )
 
 // An Option configures a Logger.
@@ -51,3 +56,155 @@ func SetEnablePyroscope(enablePyroscope bool) Option {
 		n.cfg.setPyroscope(enablePyroscope)
 	})
 }
+
+// SetComponentPool 为组件创建大小为 size 的专用协程池，组件通过 SubmitComponent 提交的任务不与其他组件竞争共享协程池
+func SetComponentPool(component string, size int) Option {
+	return optionFunc(func(n *Nmq) {
+		if n.cfg.componentPools == nil {
+			n.cfg.componentPools = make(map[string]int)
+		}
+		n.cfg.componentPools[component] = size
+	})
+}
+
+// SetTelemetry 设置匿名使用情况上报，需要同时设置 telemetry.SetEnabled(true) 和上报地址才会开启
+func SetTelemetry(opts ...options.Option) Option {
+	return optionFunc(func(n *Nmq) {
+		n.cfg.telemetry = opts
+	})
+}
+
+// SetAlerting 设置进程内告警规则，按 alert.SetInterval 的间隔评估默认注册器中的指标，
+// 告警以 alert.Alert 发布到事件总线的 "alert.firing" 和 "alert.resolved" 主题，其他通知方式通过 alert.SetNotifiers 添加
+func SetAlerting(opts ...options.Option) Option {
+	return optionFunc(func(n *Nmq) {
+		n.cfg.alerting = opts
+	})
+}
+
+// SetClockSkew 设置时钟偏差检测，通过 clock.SetSources 添加时间源后开启，
+// 偏差超过 clock.SetSkewThreshold 期间记录告警日志，雪花算法节点的 TryGenerate 返回 utils.ErrClockSkew
+func SetClockSkew(opts ...options.Option) Option {
+	return optionFunc(func(n *Nmq) {
+		n.cfg.clockSkew = opts
+	})
+}
+
+// SetComponentLogLevel 设置组件日志记录器的初始级别，没有设置的组件使用全局日志记录器的级别
+func SetComponentLogLevel(component string, level zapcore.Level) Option {
+	return optionFunc(func(n *Nmq) {
+		if n.cfg.logLevels == nil {
+			n.cfg.logLevels = make(map[string]zapcore.Level)
+		}
+		n.cfg.logLevels[component] = level
+	})
+}
+
+// SetWorkDir 设置工作目录，相对路径的日志和数据文件都定位到该目录下，命令行参数 --work 会覆盖该设置
+func SetWorkDir(dir string) Option {
+	return optionFunc(func(n *Nmq) {
+		n.cfg.workDir = dir
+	})
+}
+
+// SetSubmitQueue 设置 SubmitWait、TrySubmit 使用的提交队列容量和队列已满时的处理方式
+func SetSubmitQueue(size int, policy FullPolicy) Option {
+	return optionFunc(func(n *Nmq) {
+		n.cfg.queueSize = size
+		n.cfg.queuePolicy = policy
+	})
+}
+
+// SetConfigReload 开启配置热加载：配置文件变化或收到 SIGHUP 时重新读取配置，
+// 并调用配置段有变化且实现了 Reloader 的组件的 Reload 方法
+func SetConfigReload(enable bool) Option {
+	return optionFunc(func(n *Nmq) {
+		n.cfg.reload = enable
+	})
+}
+
+// SetDrainTimeout 设置停止时等待进行中任务完成的时间，收到 SIGINT/SIGTERM 后超过该时间仍未退出则强制退出
+func SetDrainTimeout(timeout time.Duration) Option {
+	return optionFunc(func(n *Nmq) {
+		n.cfg.drainTimeout = timeout
+	})
+}
+
+// SetPreflight 注册启动前检查，检查在所有组件 Start 之前并发执行，每项检查耗时不超过 timeout
+func SetPreflight(timeout time.Duration, checks ...preflight.Check) Option {
+	return optionFunc(func(n *Nmq) {
+		n.cfg.preflightTimeout = timeout
+		n.cfg.preflight = append(n.cfg.preflight, checks...)
+	})
+}
+
+// SetRestartPolicy 设置组件失败后的重启策略，没有设置的组件不重启
+func SetRestartPolicy(component string, policy nmq.RestartPolicy) Option {
+	return optionFunc(func(n *Nmq) {
+		if n.cfg.restart == nil {
+			n.cfg.restart = make(map[string]nmq.RestartPolicy)
+		}
+		n.cfg.restart[component] = policy
+	})
+}
+
+// SetRestartBackoff 设置重启的退避时间和最大连续重启次数，退避时间从 initial 开始翻倍直到 max，
+// 组件稳定运行超过 max 后重新计算连续重启次数，maxRestarts 小于等于0时不限制
+func SetRestartBackoff(initial, max time.Duration, maxRestarts int) Option {
+	return optionFunc(func(n *Nmq) {
+		n.cfg.restartBackoff = initial
+		n.cfg.restartMaxBackoff = max
+		n.cfg.maxRestarts = maxRestarts
+	})
+}
+
+// SetGracefulUpgrade 开启热升级：收到 SIGUSR2 后以相同参数启动新版本的可执行文件并移交监听套接字，
+// 新进程在 timeout 内就绪后当前进程停止接受新连接，按 SetDrainTimeout 等待进行中的任务完成后退出
+func SetGracefulUpgrade(timeout time.Duration) Option {
+	return optionFunc(func(n *Nmq) {
+		n.cfg.upgradeTimeout = timeout
+	})
+}
+
+// SetHook 注册生命周期钩子，同一阶段按 order 从小到大依次执行，组件可以在 Init 中通过 ComponentManager.AddHook 注册
+func SetHook(phase nmq.HookPhase, name string, order int, hook nmq.Hook) Option {
+	return optionFunc(func(n *Nmq) {
+		n.AddHook(phase, name, order, hook)
+	})
+}
+
+// SetHookTimeout 设置单个生命周期钩子的超时时间
+func SetHookTimeout(timeout time.Duration) Option {
+	return optionFunc(func(n *Nmq) {
+		n.cfg.hookTimeout = timeout
+	})
+}
+
+// SetDependencies 声明组件依赖的组件，component 在 deps 全部启动成功后才会启动
+func SetDependencies(component string, deps ...string) Option {
+	return optionFunc(func(n *Nmq) {
+		if n.cfg.dependencies == nil {
+			n.cfg.dependencies = make(map[string][]string)
+		}
+		n.cfg.dependencies[component] = append(n.cfg.dependencies[component], deps...)
+	})
+}
+
+// SetShutdownPriority 设置组件的停止优先级，覆盖组件实现的 ShutdownPrioritizer
+func SetShutdownPriority(component string, priority nmq.ShutdownPriority) Option {
+	return optionFunc(func(n *Nmq) {
+		if n.cfg.shutdownPriority == nil {
+			n.cfg.shutdownPriority = make(map[string]nmq.ShutdownPriority)
+		}
+		n.cfg.shutdownPriority[component] = priority
+	})
+}
+
+// SetParallelStart 开启并行启动，依赖全部启动成功的组件在协程池中并发启动，
+// failFast 为 true 时第一个组件启动失败后不再启动新的组件，否则启动所有不受影响的组件后汇总错误返回
+func SetParallelStart(failFast bool) Option {
+	return optionFunc(func(n *Nmq) {
+		n.cfg.parallelStart = true
+		n.cfg.startFailFast = failFast
+	})
+}
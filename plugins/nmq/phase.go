@@ -0,0 +1,77 @@
+package nmq
+
+import (
+	"context"
+	"time"
+
+	"github.com/andrewbytecoder/nmq/interfaces/nmq"
+	"go.uber.org/zap"
+)
+
+// Phase 返回组件管理器当前的生命周期阶段
+func (n *Nmq) Phase() nmq.Phase {
+	n.phaseMu.Lock()
+	defer n.phaseMu.Unlock()
+	return n.phase
+}
+
+// reached 判断是否到达过阶段 p 调用方需持有 n.phaseMu
+func (n *Nmq) reached(p nmq.Phase) bool {
+	return p == nmq.PhaseLoading || n.phaseSeen&(1<<p) != 0
+}
+
+// WaitForPhase 等待进入 phase 阶段，已经到达过该阶段时立即返回；
+// 没有到达过且已经越过该阶段或进入结束阶段时返回 nmq.ErrPhaseUnreachable
+func (n *Nmq) WaitForPhase(ctx context.Context, phase nmq.Phase) error {
+	for {
+		n.phaseMu.Lock()
+		if n.reached(phase) {
+			n.phaseMu.Unlock()
+			return nil
+		}
+		if n.phase.Terminal() || n.phase > phase {
+			n.phaseMu.Unlock()
+			return nmq.ErrPhaseUnreachable
+		}
+		if n.phaseCh == nil {
+			n.phaseCh = make(chan struct{})
+		}
+		ch := n.phaseCh
+		n.phaseMu.Unlock()
+
+		select {
+		case <-ch:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// setPhase 进入阶段 to 并以 nmq.PhaseTransition 发布到 nmq.PhaseTopic(to)，
+// 阶段只能前进，结束阶段之后不再变化，无效的变化被忽略并返回 false
+func (n *Nmq) setPhase(to nmq.Phase, err error) bool {
+	n.phaseMu.Lock()
+	from := n.phase
+	if to <= from || from.Terminal() {
+		n.phaseMu.Unlock()
+		n.logger.Debug("Invalid phase transition ignored", zap.Stringer("from", from), zap.Stringer("to", to))
+		return false
+	}
+	n.phase = to
+	n.phaseSeen |= 1 << to
+	if n.phaseCh != nil {
+		close(n.phaseCh)
+		n.phaseCh = nil
+	}
+	n.phaseMu.Unlock()
+
+	t := nmq.PhaseTransition{From: from, To: to, Time: time.Now()}
+	if err != nil {
+		t.Error = err.Error()
+	}
+	n.logger.Info("Phase changed", zap.Stringer("from", from), zap.Stringer("to", to))
+	if err = n.Publish(nmq.PhaseTopic(to), t); err != nil {
+		n.logger.Warn("Failed to publish phase transition", zap.Stringer("phase", to), zap.Error(err))
+	}
+	return true
+}
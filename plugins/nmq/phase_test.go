@@ -0,0 +1,108 @@
+package nmq
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/andrewbytecoder/nmq/interfaces/nmq"
+)
+
+func TestPhaseTransitions(t *testing.T) {
+	n := newSupervisedNmq()
+	if p := n.Phase(); p != nmq.PhaseLoading {
+		t.Fatalf("Expected loading phase, got %v", p)
+	}
+
+	events := make(chan nmq.PhaseTransition, 8)
+	_, err := nmq.Subscribe(n, nmq.PhaseTopicPrefix+"*", func(_ context.Context, _ string, tr nmq.PhaseTransition) error {
+		events <- tr
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, p := range []nmq.Phase{nmq.PhaseInit, nmq.PhasePreflight, nmq.PhaseStarting, nmq.PhaseRunning} {
+		if !n.setPhase(p, nil) {
+			t.Fatalf("Expected transition to %v", p)
+		}
+	}
+	// 阶段不能后退
+	if n.setPhase(nmq.PhaseInit, nil) || n.Phase() != nmq.PhaseRunning {
+		t.Errorf("Expected backward transition to be ignored, phase %v", n.Phase())
+	}
+
+	from := nmq.PhaseLoading
+	for _, want := range []nmq.Phase{nmq.PhaseInit, nmq.PhasePreflight, nmq.PhaseStarting, nmq.PhaseRunning} {
+		select {
+		case tr := <-events:
+			if tr.From != from || tr.To != want || tr.Time.IsZero() {
+				t.Errorf("Unexpected transition %+v, want %v -> %v", tr, from, want)
+			}
+			from = tr.To
+		case <-time.After(time.Second):
+			t.Fatalf("Timed out waiting for %v event", want)
+		}
+	}
+}
+
+func TestWaitForPhase(t *testing.T) {
+	n := newSupervisedNmq()
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	done := make(chan error, 1)
+	go func() { done <- n.WaitForPhase(ctx, nmq.PhaseRunning) }()
+	n.setPhase(nmq.PhaseStarting, nil)
+	select {
+	case err := <-done:
+		t.Fatalf("Expected wait to block before running, got %v", err)
+	case <-time.After(10 * time.Millisecond):
+	}
+	n.setPhase(nmq.PhaseRunning, nil)
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+
+	// 已经到达过的阶段立即返回，跳过的阶段不可达
+	if err := n.WaitForPhase(ctx, nmq.PhaseStarting); err != nil {
+		t.Errorf("Expected reached phase, got %v", err)
+	}
+	if err := n.WaitForPhase(ctx, nmq.PhasePreflight); !errors.Is(err, nmq.ErrPhaseUnreachable) {
+		t.Errorf("Expected ErrPhaseUnreachable for skipped phase, got %v", err)
+	}
+
+	short, stop := context.WithTimeout(ctx, 10*time.Millisecond)
+	defer stop()
+	if err := n.WaitForPhase(short, nmq.PhaseStopped); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected deadline exceeded, got %v", err)
+	}
+}
+
+func TestWaitForPhaseFailed(t *testing.T) {
+	n := newSupervisedNmq()
+	done := make(chan error, 1)
+	go func() { done <- n.WaitForPhase(context.Background(), nmq.PhaseRunning) }()
+	n.setPhase(nmq.PhaseInit, nil)
+	n.setPhase(nmq.PhaseFailed, errors.New("boom"))
+	if err := <-done; !errors.Is(err, nmq.ErrPhaseUnreachable) {
+		t.Errorf("Expected ErrPhaseUnreachable, got %v", err)
+	}
+	// 结束阶段之后不再变化
+	if n.setPhase(nmq.PhaseDraining, nil) || n.Phase() != nmq.PhaseFailed {
+		t.Errorf("Expected failed phase to be terminal, got %v", n.Phase())
+	}
+}
+
+func TestStopEntersDraining(t *testing.T) {
+	n := newSupervisedNmq()
+	n.setPhase(nmq.PhaseRunning, nil)
+	if err := n.Stop(); err != nil {
+		t.Fatal(err)
+	}
+	if p := n.Phase(); p != nmq.PhaseDraining {
+		t.Errorf("Expected draining phase after Stop, got %v", p)
+	}
+}
@@ -0,0 +1,103 @@
+package nmq
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/andrewbytecoder/nmq/interfaces/nmq"
+	"github.com/spf13/viper"
+	"go.uber.org/zap"
+)
+
+// DefaultPluginSymbol 插件中组件构造函数的默认符号名
+const DefaultPluginSymbol = "NewComponent"
+
+// 插件类型，目前只支持进程内的 Go 插件
+const (
+	PluginTypeGo      = "go"      // 进程内加载的 Go 插件（.so）
+	PluginTypeProcess = "process" // 通过 gRPC 通信的外部进程插件，尚未支持
+)
+
+var (
+	// ErrPluginUnsupported 当前平台或构建方式（未开启 cgo）不支持 Go 插件
+	ErrPluginUnsupported = errors.New("nmq: go plugins are not supported on this platform")
+	// ErrPluginSymbol 插件导出的符号不是组件构造函数
+	ErrPluginSymbol = errors.New("nmq: plugin symbol is not a component constructor")
+	// ErrPluginType 插件类型未知或尚未支持
+	ErrPluginType = errors.New("nmq: unsupported plugin type")
+)
+
+// ComponentFactory 插件导出的组件构造函数类型
+type ComponentFactory = func(ctx nmq.NmqContext) nmq.Component
+
+// PluginSpec 配置文件 plugins 段中的一个插件
+//
+//	plugins:
+//	  - name: audit
+//	    type: go
+//	    path: ./plugins/audit.so
+//	    symbol: NewComponent
+//
+// 外部进程插件（type: process）需要 gRPC 依赖，目前加载时返回 ErrPluginType
+type PluginSpec struct {
+	Name   string `mapstructure:"name"`   // 组件名称，不能与已注册的组件重复
+	Type   string `mapstructure:"type"`   // 插件类型，默认为 go
+	Path   string `mapstructure:"path"`   // 插件文件路径，相对路径基于工作目录
+	Symbol string `mapstructure:"symbol"` // 组件构造函数的符号名，默认为 NewComponent
+}
+
+// loadPlugins 读取配置文件的 plugins 段，打开每个 Go 插件并注册其中的组件，配置文件不存在时不做任何事
+func (n *Nmq) loadPlugins() error {
+	if err := n.readConfig(); err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+	var specs []PluginSpec
+	if err := viper.UnmarshalKey("plugins", &specs); err != nil {
+		return fmt.Errorf("parse plugins config: %w", err)
+	}
+
+	for _, spec := range specs {
+		if spec.Name == "" || spec.Path == "" {
+			return fmt.Errorf("plugin %q: name and path are required", spec.Name)
+		}
+		if spec.Type != "" && spec.Type != PluginTypeGo {
+			return fmt.Errorf("plugin %q: %w: %s", spec.Name, ErrPluginType, spec.Type)
+		}
+		if n.GetComponent(spec.Name) != nil {
+			return fmt.Errorf("plugin %q: component already registered", spec.Name)
+		}
+		symbol := spec.Symbol
+		if symbol == "" {
+			symbol = DefaultPluginSymbol
+		}
+		path := n.ResolvePath(spec.Path)
+		sym, err := openPlugin(path, symbol)
+		if err != nil {
+			return fmt.Errorf("plugin %q: %w", spec.Name, err)
+		}
+		factory, err := componentFactory(sym)
+		if err != nil {
+			return fmt.Errorf("plugin %q: %s: %w", spec.Name, symbol, err)
+		}
+		n.RegisterComponent(spec.Name, factory(n))
+		n.logger.Info("Plugin component loaded", zap.String("name", spec.Name), zap.String("path", path))
+	}
+	return nil
+}
+
+// componentFactory 将插件符号转换为组件构造函数，导出的可以是函数或函数变量
+func componentFactory(sym any) (ComponentFactory, error) {
+	switch f := sym.(type) {
+	case ComponentFactory:
+		return f, nil
+	case *ComponentFactory:
+		if f != nil && *f != nil {
+			return *f, nil
+		}
+	}
+	return nil, ErrPluginSymbol
+}
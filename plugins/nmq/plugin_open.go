@@ -0,0 +1,14 @@
+//go:build (linux || darwin || freebsd) && cgo
+
+package nmq
+
+import "plugin"
+
+// openPlugin 打开 Go 插件并查找符号，同一个插件文件只会被加载一次
+func openPlugin(path, symbol string) (any, error) {
+	p, err := plugin.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	return p.Lookup(symbol)
+}
@@ -0,0 +1,8 @@
+//go:build !((linux || darwin || freebsd) && cgo)
+
+package nmq
+
+// openPlugin 当前平台不支持 Go 插件
+func openPlugin(path, symbol string) (any, error) {
+	return nil, ErrPluginUnsupported
+}
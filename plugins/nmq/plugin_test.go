@@ -0,0 +1,66 @@
+package nmq
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/andrewbytecoder/nmq/interfaces/nmq"
+	"github.com/spf13/viper"
+	"go.uber.org/zap"
+)
+
+func TestComponentFactory(t *testing.T) {
+	var f ComponentFactory = func(ctx nmq.NmqContext) nmq.Component { return &plainComponent{} }
+	if got, err := componentFactory(f); err != nil || got == nil {
+		t.Errorf("Expected function symbol to be accepted, got %v", err)
+	}
+	if got, err := componentFactory(&f); err != nil || got(nil).GetName() != "plain" {
+		t.Errorf("Expected variable symbol to be accepted, got %v", err)
+	}
+	if _, err := componentFactory(func() {}); !errors.Is(err, ErrPluginSymbol) {
+		t.Errorf("Expected ErrPluginSymbol, got %v", err)
+	}
+}
+
+func TestLoadPlugins(t *testing.T) {
+	t.Cleanup(viper.Reset)
+	dir := t.TempDir()
+	n := &Nmq{components: make(map[string]nmq.Component), logger: zap.NewNop(), cfg: DefaultConfig()}
+	n.cfg.workDir = dir
+
+	// 配置文件不存在时没有插件
+	n.cfg.configFile = filepath.Join(dir, "missing.yaml")
+	if err := n.loadPlugins(); err != nil {
+		t.Fatalf("Expected no error without config file, got %v", err)
+	}
+
+	n.cfg.configFile = filepath.Join(dir, "nmq.yaml")
+	write := func(content string) {
+		if err := os.WriteFile(n.cfg.configFile, []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("plugins:\n  - name: audit\n    path: missing.so\n")
+	if err := n.loadPlugins(); err == nil || !strings.Contains(err.Error(), `plugin "audit"`) {
+		t.Errorf("Expected open error for missing plugin, got %v", err)
+	}
+
+	n.RegisterComponent("audit", &plainComponent{})
+	if err := n.loadPlugins(); err == nil || !strings.Contains(err.Error(), "already registered") {
+		t.Errorf("Expected duplicate name error, got %v", err)
+	}
+
+	write("plugins:\n  - name: audit\n")
+	if err := n.loadPlugins(); err == nil || !strings.Contains(err.Error(), "required") {
+		t.Errorf("Expected missing path error, got %v", err)
+	}
+
+	// 外部进程插件尚未支持
+	write("plugins:\n  - name: remote\n    type: process\n    path: ./remote\n")
+	if err := n.loadPlugins(); !errors.Is(err, ErrPluginType) {
+		t.Errorf("Expected ErrPluginType, got %v", err)
+	}
+}
@@ -0,0 +1,60 @@
+package nmq
+
+import (
+	"context"
+	"os"
+
+	"github.com/andrewbytecoder/nmq/interfaces/nmq"
+	"github.com/andrewbytecoder/nmq/pkg/preflight"
+	"go.uber.org/zap"
+)
+
+// Preflight 并发执行启动前检查：工作目录可写、配置文件有效、依赖的可选功能已编译进来、SetPreflight 注册的检查和实现了 Preflighter 的组件
+func (n *Nmq) Preflight(ctx context.Context) preflight.Report {
+	checks := []preflight.Check{
+		preflight.Func("workdir", preflight.SeverityFatal, func(context.Context) error {
+			if n.workdir == nil {
+				return nil
+			}
+			return n.workdir.Validate()
+		}),
+		preflight.Func("config", preflight.SeverityFatal, func(context.Context) error {
+			// 没有配置文件时各组件使用默认配置
+			if _, err := os.Stat(n.cfg.configFile); os.IsNotExist(err) {
+				return nil
+			}
+			return n.readConfig()
+		}),
+		preflight.Func("features", preflight.SeverityFatal, func(context.Context) error {
+			return n.checkFeatures()
+		}),
+	}
+	checks = append(checks, n.cfg.preflight...)
+
+	n.mux.RLock()
+	for name, c := range n.components {
+		if p, ok := c.(nmq.Preflighter); ok && c.GetName() != n.GetName() {
+			checks = append(checks, preflight.Func("component "+name, preflight.SeverityFatal, p.Preflight))
+		}
+	}
+	n.mux.RUnlock()
+
+	return preflight.Run(ctx, n.cfg.preflightTimeout, checks...)
+}
+
+// runPreflight 进入 PhasePreflight，执行启动前检查并记录结果，存在失败的 SeverityFatal 检查时返回错误
+func (n *Nmq) runPreflight() error {
+	n.setPhase(nmq.PhasePreflight, nil)
+	report := n.Preflight(n.ctx)
+	for _, res := range report.Results {
+		switch {
+		case res.Passed():
+			n.logger.Debug("Preflight check passed", zap.String("check", res.Name), zap.Duration("duration", res.Duration))
+		case res.Severity == preflight.SeverityFatal:
+			n.logger.Error("Preflight check failed", zap.String("check", res.Name), zap.Error(res.Err))
+		default:
+			n.logger.Warn("Preflight check failed", zap.String("check", res.Name), zap.Error(res.Err))
+		}
+	}
+	return report.Err()
+}
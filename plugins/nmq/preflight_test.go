@@ -0,0 +1,48 @@
+package nmq
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"github.com/andrewbytecoder/nmq/interfaces/nmq"
+	"github.com/andrewbytecoder/nmq/pkg/preflight"
+	"go.uber.org/zap"
+)
+
+// preflightComponent 实现了 Preflighter 的组件
+type preflightComponent struct {
+	nmq.Component
+	err error
+}
+
+func (p *preflightComponent) GetName() string                     { return "dep" }
+func (p *preflightComponent) Preflight(ctx context.Context) error { return p.err }
+
+func TestPreflight(t *testing.T) {
+	n := &Nmq{
+		ctx:        context.Background(),
+		logger:     zap.NewNop(),
+		cfg:        DefaultConfig(),
+		components: make(map[string]nmq.Component),
+	}
+	n.cfg.configFile = filepath.Join(t.TempDir(), "missing.yaml")
+	SetPreflight(0, preflight.Func("disk", preflight.SeverityWarn, func(context.Context) error {
+		return errors.New("low disk")
+	})).apply(n)
+	n.RegisterComponent("dep", &preflightComponent{})
+
+	if err := n.runPreflight(); err != nil {
+		t.Fatalf("Expected warnings not to abort, got %v", err)
+	}
+	report := n.Preflight(context.Background())
+	if len(report.Results) != 5 || len(report.Failures(preflight.SeverityWarn)) != 1 {
+		t.Errorf("Unexpected report: %+v", report)
+	}
+
+	n.RegisterComponent("dep", &preflightComponent{err: errors.New("unreachable")})
+	if err := n.runPreflight(); !errors.Is(err, preflight.ErrFailed) {
+		t.Errorf("Expected ErrFailed, got %v", err)
+	}
+}
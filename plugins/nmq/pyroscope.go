@@ -1,13 +1,20 @@
+//go:build !nopyroscope
+
 package nmq
 
 import (
 	"fmt"
 	"os"
 
+	"github.com/andrewbytecoder/nmq/interfaces/nmq"
 	"github.com/andrewbytecoder/nmq/pkg/check"
 	"github.com/grafana/pyroscope-go"
 )
 
+func init() {
+	RegisterFeature(nmq.FeaturePyroscope)
+}
+
 func startPyroscope() error {
 	// 只有配置了DP_PYROSCOPE_ENABLE为true时，才启动pyroscope
 	address := os.Getenv("DP_PYROSCOPE_SERVER_ADDRESS")
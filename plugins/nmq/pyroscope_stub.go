@@ -0,0 +1,14 @@
+//go:build nopyroscope
+
+package nmq
+
+import (
+	"fmt"
+
+	"github.com/andrewbytecoder/nmq/interfaces/nmq"
+)
+
+// startPyroscope 使用 nopyroscope 构建时不包含 pyroscope 客户端
+func startPyroscope() error {
+	return fmt.Errorf("%w: %s", ErrFeatureNotCompiled, nmq.FeaturePyroscope)
+}
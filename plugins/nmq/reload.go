@@ -0,0 +1,116 @@
+package nmq
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"reflect"
+	"syscall"
+
+	"github.com/andrewbytecoder/nmq/interfaces/nmq"
+	"github.com/andrewbytecoder/nmq/pkg/recovery"
+	"github.com/fsnotify/fsnotify"
+	"github.com/spf13/viper"
+	"go.uber.org/zap"
+)
+
+// ReloadConfig 重新读取配置文件，对比各组件的配置段，调用配置段有变化的组件的 Reload 方法
+//
+// 没有实现 Reloader 的组件忽略配置变化；Reload 失败的组件保留旧配置段，下次热加载时会再次尝试
+func (n *Nmq) ReloadConfig() error {
+	n.reloadMu.Lock()
+	defer n.reloadMu.Unlock()
+
+	if err := n.readConfig(); err != nil {
+		n.logger.Error("Failed to read config", zap.String("file", n.cfg.configFile), zap.Error(err))
+		return err
+	}
+	if err := n.configureLogLevels(); err != nil {
+		n.logger.Error("Failed to reload component log levels", zap.Error(err))
+	}
+	sections := n.configSections()
+
+	n.mux.RLock()
+	reloaders := make(map[string]nmq.Reloader)
+	for name, c := range n.components {
+		if r, ok := c.(nmq.Reloader); ok && c.GetName() != n.GetName() {
+			reloaders[name] = r
+		}
+	}
+	n.mux.RUnlock()
+
+	var errs []error
+	for name, r := range reloaders {
+		if reflect.DeepEqual(n.sections[name], sections[name]) {
+			continue
+		}
+		err := recovery.Default.Do("reload."+name, nil, func() error { return r.Reload(sections[name]) })
+		if err != nil {
+			n.logger.Error("Failed to reload component config", zap.String("component", name), zap.Error(err))
+			errs = append(errs, fmt.Errorf("reload %s: %w", name, err))
+			if old, ok := n.sections[name]; ok {
+				sections[name] = old
+			} else {
+				delete(sections, name)
+			}
+			continue
+		}
+		n.logger.Info("Component config reloaded", zap.String("component", name))
+	}
+	n.sections = sections
+	return errors.Join(errs...)
+}
+
+// readConfig 读取配置文件，没有指定配置文件时不做任何事
+func (n *Nmq) readConfig() error {
+	if n.cfg.configFile == "" {
+		return nil
+	}
+	viper.SetConfigFile(n.cfg.configFile)
+	return viper.ReadInConfig()
+}
+
+// configSections 按组件名称取出配置段
+func (n *Nmq) configSections() map[string]map[string]any {
+	n.mux.RLock()
+	defer n.mux.RUnlock()
+	sections := make(map[string]map[string]any)
+	for name := range n.components {
+		if m := viper.GetStringMap(name); len(m) > 0 {
+			sections[name] = m
+		}
+	}
+	return sections
+}
+
+// startReload 记录当前配置段作为基准，之后监听配置文件变化和 SIGHUP 触发热加载，ctx 结束后停止监听 SIGHUP
+func (n *Nmq) startReload() {
+	n.reloadMu.Lock()
+	if err := n.readConfig(); err != nil {
+		n.logger.Warn("Failed to read config, reload baseline is empty", zap.Error(err))
+	}
+	n.sections = n.configSections()
+	n.reloadMu.Unlock()
+
+	viper.OnConfigChange(func(e fsnotify.Event) {
+		n.logger.Info("Config file changed", zap.String("file", e.Name))
+		_ = n.ReloadConfig()
+	})
+	viper.WatchConfig()
+
+	ch := make(chan os.Signal, 1)
+	signal.Notify(ch, syscall.SIGHUP)
+	go func() {
+		defer signal.Stop(ch)
+		for {
+			select {
+			case <-ch:
+				n.logger.Info("SIGHUP received, reloading config")
+				_ = n.ReloadConfig()
+			case <-n.ctx.Done():
+				return
+			}
+		}
+	}()
+}
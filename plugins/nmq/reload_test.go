@@ -0,0 +1,78 @@
+package nmq
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/andrewbytecoder/nmq/interfaces/nmq"
+	"github.com/spf13/viper"
+	"go.uber.org/zap"
+)
+
+// reloadComponent 记录收到的配置段
+type reloadComponent struct {
+	nmq.Component
+	name    string
+	configs []map[string]any
+	err     error
+}
+
+func (r *reloadComponent) GetName() string { return r.name }
+func (r *reloadComponent) Reload(cfg map[string]any) error {
+	r.configs = append(r.configs, cfg)
+	return r.err
+}
+
+func TestReloadConfig(t *testing.T) {
+	t.Cleanup(viper.Reset)
+	file := filepath.Join(t.TempDir(), "nmq.yaml")
+	write := func(content string) {
+		if err := os.WriteFile(file, []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("api:\n  port: 80\ncache:\n  size: 10\n")
+
+	n := &Nmq{components: make(map[string]nmq.Component), logger: zap.NewNop(), cfg: DefaultConfig()}
+	n.cfg.configFile = file
+	api := &reloadComponent{name: "api"}
+	cache := &reloadComponent{name: "cache"}
+	n.RegisterComponent("api", api)
+	n.RegisterComponent("cache", cache)
+
+	// 首次加载所有配置段都是新的
+	if err := n.ReloadConfig(); err != nil {
+		t.Fatal(err)
+	}
+	if len(api.configs) != 1 || len(cache.configs) != 1 {
+		t.Fatalf("Expected initial reload, got %v %v", api.configs, cache.configs)
+	}
+
+	// 只有 api 的配置段变化
+	write("api:\n  port: 8080\ncache:\n  size: 10\n")
+	if err := n.ReloadConfig(); err != nil {
+		t.Fatal(err)
+	}
+	if len(api.configs) != 2 || api.configs[1]["port"] != 8080 || len(cache.configs) != 1 {
+		t.Errorf("Expected only api to reload, got %v %v", api.configs, cache.configs)
+	}
+
+	// 失败的组件下次重试
+	cache.err = errors.New("invalid size")
+	write("api:\n  port: 8080\ncache:\n  size: -1\n")
+	if err := n.ReloadConfig(); err == nil {
+		t.Error("Expected reload error")
+	}
+	cache.err = nil
+	if err := n.ReloadConfig(); err != nil || len(cache.configs) != 3 || len(api.configs) != 2 {
+		t.Errorf("Expected cache to retry, got %v, %v", cache.configs, err)
+	}
+
+	// 配置段被删除时收到空配置
+	write("api:\n  port: 8080\n")
+	if err := n.ReloadConfig(); err != nil || len(cache.configs) != 4 || cache.configs[3] != nil {
+		t.Errorf("Expected empty config for removed section, got %v, %v", cache.configs, err)
+	}
+}
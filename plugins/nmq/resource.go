@@ -0,0 +1,112 @@
+package nmq
+
+import (
+	"net"
+	"os"
+	"sync"
+	"sync/atomic"
+
+	"github.com/andrewbytecoder/nmq/interfaces/nmq"
+)
+
+// resourceAccount 单个组件的资源计数
+type resourceAccount struct {
+	goroutines atomic.Int64
+	tasks      atomic.Uint64
+	conns      atomic.Int64
+	files      atomic.Int64
+	cacheBytes []func() int64 // 缓存字节数采样函数，受 Nmq.resMux 保护
+}
+
+// account 获取组件的资源计数，不存在时创建
+func (n *Nmq) account(component string) *resourceAccount {
+	n.resMux.RLock()
+	a, ok := n.resources[component]
+	n.resMux.RUnlock()
+	if ok {
+		return a
+	}
+
+	n.resMux.Lock()
+	defer n.resMux.Unlock()
+	if a, ok = n.resources[component]; !ok {
+		a = &resourceAccount{}
+		n.resources[component] = a
+	}
+	return a
+}
+
+// SubmitFor 提交一个异步任务并计入 component 的任务统计
+func (n *Nmq) SubmitFor(component string, task func()) error {
+	a := n.account(component)
+	a.goroutines.Add(1)
+	err := n.Submit(func() {
+		defer a.goroutines.Add(-1)
+		task()
+	})
+	if err != nil {
+		a.goroutines.Add(-1)
+		return err
+	}
+	a.tasks.Add(1)
+	return nil
+}
+
+// TrackCacheBytes 注册 component 的缓存字节数采样函数
+func (n *Nmq) TrackCacheBytes(component string, probe func() int64) {
+	a := n.account(component)
+	n.resMux.Lock()
+	defer n.resMux.Unlock()
+	a.cacheBytes = append(a.cacheBytes, probe)
+}
+
+// WrapConn 包装连接，连接关闭前计入 component 的连接统计
+func (n *Nmq) WrapConn(component string, conn net.Conn) net.Conn {
+	a := n.account(component)
+	a.conns.Add(1)
+	return &trackedConn{Conn: conn, release: func() { a.conns.Add(-1) }}
+}
+
+// OpenFile 打开文件，文件关闭前计入 component 的文件统计
+func (n *Nmq) OpenFile(component string, name string, flag int, perm os.FileMode) (*nmq.TrackedFile, error) {
+	f, err := os.OpenFile(name, flag, perm)
+	if err != nil {
+		return nil, err
+	}
+	a := n.account(component)
+	a.files.Add(1)
+	return nmq.NewTrackedFile(f, func() { a.files.Add(-1) }), nil
+}
+
+// ResourceReport 返回所有组件的资源使用情况
+func (n *Nmq) ResourceReport() map[string]nmq.ResourceUsage {
+	n.resMux.RLock()
+	defer n.resMux.RUnlock()
+	report := make(map[string]nmq.ResourceUsage, len(n.resources))
+	for name, a := range n.resources {
+		usage := nmq.ResourceUsage{
+			Goroutines: a.goroutines.Load(),
+			Tasks:      a.tasks.Load(),
+			Conns:      a.conns.Load(),
+			Files:      a.files.Load(),
+		}
+		for _, probe := range a.cacheBytes {
+			usage.CacheBytes += probe()
+		}
+		report[name] = usage
+	}
+	return report
+}
+
+// trackedConn 计入资源统计的连接，关闭时释放统计
+type trackedConn struct {
+	net.Conn
+	once    sync.Once
+	release func()
+}
+
+// Close 关闭连接并释放统计
+func (c *trackedConn) Close() error {
+	c.once.Do(c.release)
+	return c.Conn.Close()
+}
@@ -0,0 +1,83 @@
+package nmq
+
+import (
+	"net"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/panjf2000/ants/v2"
+)
+
+func newTestNmq(t *testing.T) *Nmq {
+	pool, err := ants.NewPool(10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(pool.Release)
+	return &Nmq{pool: pool, resources: make(map[string]*resourceAccount)}
+}
+
+func TestResourceReport(t *testing.T) {
+	n := newTestNmq(t)
+
+	var started, done sync.WaitGroup
+	block := make(chan struct{})
+	started.Add(2)
+	done.Add(2)
+	for i := 0; i < 2; i++ {
+		err := n.SubmitFor("api", func() {
+			defer done.Done()
+			started.Done()
+			<-block
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	started.Wait()
+
+	n.TrackCacheBytes("api", func() int64 { return 100 })
+	n.TrackCacheBytes("api", func() int64 { return 28 })
+
+	c1, c2 := net.Pipe()
+	defer c2.Close()
+	conn := n.WrapConn("mq", c1)
+
+	f, err := n.OpenFile("mq", filepath.Join(t.TempDir(), "data"), os.O_CREATE|os.O_RDWR, 0o600)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	report := n.ResourceReport()
+	if u := report["api"]; u.Goroutines != 2 || u.Tasks != 2 || u.CacheBytes != 128 {
+		t.Errorf("Unexpected api usage: %+v", u)
+	}
+	if u := report["mq"]; u.Conns != 1 || u.Files != 1 {
+		t.Errorf("Unexpected mq usage: %+v", u)
+	}
+
+	close(block)
+	done.Wait()
+	_ = conn.Close()
+	_ = conn.Close()
+	// 并发关闭只释放一次统计
+	var closing sync.WaitGroup
+	for i := 0; i < 2; i++ {
+		closing.Add(1)
+		go func() {
+			defer closing.Done()
+			_ = f.Close()
+		}()
+	}
+	closing.Wait()
+
+	report = n.ResourceReport()
+	if u := report["api"]; u.Tasks != 2 {
+		t.Errorf("Expected 2 tasks, got %+v", u)
+	}
+	if u := report["mq"]; u.Conns != 0 || u.Files != 0 {
+		t.Errorf("Expected released mq resources, got %+v", u)
+	}
+}
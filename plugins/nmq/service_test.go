@@ -0,0 +1,68 @@
+package nmq
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/andrewbytecoder/nmq/interfaces/nmq"
+)
+
+// greeter 测试用的服务接口
+type greeter interface {
+	Greet() string
+}
+
+type english struct{}
+
+func (english) Greet() string { return "hello" }
+
+func TestService(t *testing.T) {
+	n := newSupervisedNmq()
+	defer nmq.UnregisterService("greeter")
+
+	if err := nmq.RegisterService[greeter]("greeter", english{}); err != nil {
+		t.Fatal(err)
+	}
+	g, err := nmq.Resolve[greeter](n, "greeter")
+	if err != nil || g.Greet() != "hello" {
+		t.Fatalf("Unexpected service %v, %v", g, err)
+	}
+
+	// 旧的 GetInterface 调用方同样可以取到注册的服务
+	if g, ok := n.GetInterface("greeter").(greeter); !ok || g.Greet() != "hello" {
+		t.Errorf("Expected GetInterface to return the registered service, got %v", n.GetInterface("greeter"))
+	}
+
+	if _, err = nmq.Resolve[fmt.Stringer](n, "greeter"); !errors.Is(err, nmq.ErrServiceType) {
+		t.Errorf("Expected ErrServiceType, got %v", err)
+	}
+	if _, err = nmq.Resolve[greeter](n, "missing"); !errors.Is(err, nmq.ErrServiceNotFound) {
+		t.Errorf("Expected ErrServiceNotFound, got %v", err)
+	}
+
+	// 同名服务只能以相同的类型重新注册
+	if err = nmq.RegisterService[greeter]("greeter", english{}); err != nil {
+		t.Errorf("Expected re-registration with the same type, got %v", err)
+	}
+	if err = nmq.RegisterService[english]("greeter", english{}); !errors.Is(err, nmq.ErrServiceContract) {
+		t.Errorf("Expected ErrServiceContract, got %v", err)
+	}
+	if err = nmq.RegisterService[greeter]("nil", nil); !errors.Is(err, nmq.ErrNilService) {
+		t.Errorf("Expected ErrNilService, got %v", err)
+	}
+}
+
+func TestResolveGetInterface(t *testing.T) {
+	n := newSupervisedNmq()
+	n.RegisterComponent("legacy", &panicComponent{name: "legacy"})
+
+	// 只实现了 GetInterface 的组件通过 Resolve 回退查找
+	s, err := nmq.Resolve[string](n, "anything")
+	if err != nil || s != "legacy" {
+		t.Errorf("Expected fallback to GetInterface, got %q, %v", s, err)
+	}
+	if _, err = nmq.Resolve[int](n, "anything"); !errors.Is(err, nmq.ErrServiceType) {
+		t.Errorf("Expected ErrServiceType, got %v", err)
+	}
+}
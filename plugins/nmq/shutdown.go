@@ -0,0 +1,115 @@
+package nmq
+
+import (
+	"errors"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/panjf2000/ants/v2"
+	"go.uber.org/zap"
+)
+
+// ErrDrainTimeout 停止时进行中的任务没有在限定时间内完成
+var ErrDrainTimeout = errors.New("nmq: drain timeout")
+
+// handleSignals 收到 SIGINT/SIGTERM 后取消全局 context，让阻塞在 context 上的命令返回并进入停止流程，
+// 超过 drainTimeout 仍未退出或再次收到信号时强制退出；返回的函数在 Execute 结束时调用
+func (n *Nmq) handleSignals() func() {
+	ch := make(chan os.Signal, 2)
+	signal.Notify(ch, os.Interrupt, syscall.SIGTERM)
+	done := make(chan struct{})
+	go n.watchSignals(ch, done)
+	return func() {
+		signal.Stop(ch)
+		close(done)
+	}
+}
+
+// watchSignals 等待第一个信号触发停止，之后等待 done 关闭，超时或再次收到信号时强制退出
+func (n *Nmq) watchSignals(ch <-chan os.Signal, done <-chan struct{}) {
+	select {
+	case sig := <-ch:
+		n.logger.Info("Signal received, shutting down", zap.String("signal", sig.String()),
+			zap.Duration("drain_timeout", n.cfg.drainTimeout))
+		if n.cancel != nil {
+			n.cancel()
+		}
+	case <-done:
+		return
+	}
+
+	timer := time.NewTimer(n.cfg.drainTimeout)
+	defer timer.Stop()
+	select {
+	case <-done:
+	case sig := <-ch:
+		n.logger.Warn("Second signal received, forcing exit", zap.String("signal", sig.String()))
+		n.forceExit()
+	case <-timer.C:
+		n.logger.Error("Shutdown timed out, forcing exit", zap.Duration("drain_timeout", n.cfg.drainTimeout))
+		n.forceExit()
+	}
+}
+
+// forceExit 刷新日志后以状态码1退出
+func (n *Nmq) forceExit() {
+	_ = n.logger.Sync()
+	exit := n.exit
+	if exit == nil {
+		exit = os.Exit
+	}
+	exit(1)
+}
+
+// drain 在 timeout 内依次等待组件协程（WgAdd 登记的）、提交队列中的任务和协程池（包括组件专用协程池）中正在执行的任务完成
+//
+// 超时返回 ErrDrainTimeout，协程池仍会被释放，未完成的任务不再等待
+func (n *Nmq) drain(timeout time.Duration) error {
+	deadline := time.Now().Add(timeout)
+	err := ErrDrainTimeout
+	if waitTimeout(n.wg.Wait, timeout) && (n.queue == nil || waitTimeout(n.queue.close, time.Until(deadline))) {
+		err = nil
+	}
+	pools := make([]*ants.Pool, 0, len(n.pools)+1)
+	if n.pool != nil {
+		pools = append(pools, n.pool)
+	}
+	for _, pool := range n.pools {
+		pools = append(pools, pool.Pool)
+	}
+	if err != nil {
+		for _, pool := range pools {
+			pool.Release()
+		}
+		return err
+	}
+	var errs []error
+	for _, pool := range pools {
+		if e := pool.ReleaseTimeout(max(time.Until(deadline), time.Millisecond)); e != nil {
+			errs = append(errs, e)
+		}
+	}
+	if len(errs) > 0 {
+		return errors.Join(append([]error{ErrDrainTimeout}, errs...)...)
+	}
+	return nil
+}
+
+// waitTimeout 在 timeout 内等待 fn 返回，超时返回 false，fn 会继续在后台执行
+func waitTimeout(fn func(), timeout time.Duration) bool {
+	done := make(chan struct{})
+	go func() {
+		fn()
+		close(done)
+	}()
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	select {
+	case <-done:
+		return true
+	case <-timer.C:
+		return false
+	}
+}
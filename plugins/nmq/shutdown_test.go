@@ -0,0 +1,86 @@
+package nmq
+
+import (
+	"context"
+	"errors"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/panjf2000/ants/v2"
+	"go.uber.org/zap"
+)
+
+func TestDrain(t *testing.T) {
+	pool, err := ants.NewPool(2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	n := &Nmq{pool: pool, queue: newTaskQueue(pool, 4, FullBlock)}
+
+	// 队列和协程池中的任务在超时前完成
+	finished := make(chan struct{}, 3)
+	for i := 0; i < 3; i++ {
+		if err := n.TrySubmit(func() { time.Sleep(10 * time.Millisecond); finished <- struct{}{} }); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := n.drain(time.Second); err != nil {
+		t.Fatalf("Expected drain to finish, got %v", err)
+	}
+	if len(finished) != 3 {
+		t.Errorf("Expected 3 finished tasks, got %d", len(finished))
+	}
+	if !pool.IsClosed() {
+		t.Error("Expected pool to be released")
+	}
+}
+
+func TestDrainTimeout(t *testing.T) {
+	pool, err := ants.NewPool(2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	n := &Nmq{pool: pool}
+	n.WgAdd(1)
+	defer n.wg.Done()
+
+	start := time.Now()
+	if err := n.drain(20 * time.Millisecond); !errors.Is(err, ErrDrainTimeout) {
+		t.Errorf("Expected ErrDrainTimeout, got %v", err)
+	}
+	if time.Since(start) > time.Second || !pool.IsClosed() {
+		t.Error("Expected drain to give up and release the pool")
+	}
+}
+
+func TestWatchSignals(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	exited := make(chan int, 1)
+	n := &Nmq{ctx: ctx, cancel: cancel, logger: zap.NewNop(), cfg: DefaultConfig(), exit: func(code int) { exited <- code }}
+	n.cfg.drainTimeout = 20 * time.Millisecond
+
+	// 停止流程卡住时超时强制退出
+	ch := make(chan os.Signal, 1)
+	ch <- os.Interrupt
+	go n.watchSignals(ch, make(chan struct{}))
+	select {
+	case code := <-exited:
+		if code != 1 || ctx.Err() == nil {
+			t.Errorf("Expected cancelled context and exit code 1, got %d, %v", code, ctx.Err())
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Expected forced exit")
+	}
+
+	// 及时退出时不会强制退出
+	done := make(chan struct{})
+	ch <- os.Interrupt
+	go n.watchSignals(ch, done)
+	close(done)
+	select {
+	case <-exited:
+		t.Error("Unexpected forced exit")
+	case <-time.After(50 * time.Millisecond):
+	}
+}
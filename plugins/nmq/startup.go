@@ -0,0 +1,241 @@
+package nmq
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/andrewbytecoder/nmq/interfaces/nmq"
+	"go.uber.org/zap"
+)
+
+var (
+	ErrUnknownDependency = errors.New("nmq: unknown component dependency")
+	ErrDependencyCycle   = errors.New("nmq: component dependency cycle")
+	ErrDependencyFailed  = errors.New("nmq: component dependency failed to start")
+)
+
+// dependencies 返回组件名称到依赖组件的映射，合并组件实现的 Dependent 和 SetDependencies 配置的依赖
+func (n *Nmq) dependencies() (map[string]nmq.Component, map[string][]string, error) {
+	n.mux.RLock()
+	components := make(map[string]nmq.Component, len(n.components))
+	for name, c := range n.components {
+		if c.GetName() != n.GetName() {
+			components[name] = c
+		}
+	}
+	n.mux.RUnlock()
+
+	deps := make(map[string][]string, len(components))
+	for name, c := range components {
+		list := n.declaredDependencies(name, c)
+		for _, dep := range list {
+			if _, ok := components[dep]; !ok {
+				return nil, nil, fmt.Errorf("%w: %s depends on %s", ErrUnknownDependency, name, dep)
+			}
+		}
+		deps[name] = list
+	}
+	return components, deps, nil
+}
+
+// declaredDependencies 返回组件通过 Dependent 和 SetDependencies 声明的依赖，不检查依赖是否存在
+func (n *Nmq) declaredDependencies(name string, c nmq.Component) []string {
+	var list []string
+	if d, ok := c.(nmq.Dependent); ok {
+		list = append(list, d.Dependencies()...)
+	}
+	return append(list, n.cfg.dependencies[name]...)
+}
+
+// shutdownPriority 返回组件的停止优先级，SetShutdownPriority 的配置优先于组件的声明
+func (n *Nmq) shutdownPriority(name string, c nmq.Component) nmq.ShutdownPriority {
+	if p, ok := n.cfg.shutdownPriority[name]; ok {
+		return p
+	}
+	if p, ok := c.(nmq.ShutdownPrioritizer); ok {
+		return p.ShutdownPriority()
+	}
+	return nmq.ShutdownDefault
+}
+
+// stopOrder 返回组件的停止顺序：按停止优先级从小到大，同一优先级内按启动顺序的逆序，
+// 依赖关系无效时同一优先级内按名称排序
+func (n *Nmq) stopOrder() []string {
+	n.mux.RLock()
+	components := make(map[string]nmq.Component, len(n.components))
+	for name, c := range n.components {
+		if c.GetName() != n.GetName() {
+			components[name] = c
+		}
+	}
+	n.mux.RUnlock()
+
+	rank := make(map[string]int, len(components))
+	if _, deps, err := n.dependencies(); err == nil {
+		if order, err := startOrder(deps); err == nil {
+			for i, name := range order {
+				rank[name] = len(order) - i
+			}
+		}
+	}
+	priority := make(map[string]nmq.ShutdownPriority, len(components))
+	names := make([]string, 0, len(components))
+	for name, c := range components {
+		priority[name] = n.shutdownPriority(name, c)
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		a, b := names[i], names[j]
+		if priority[a] != priority[b] {
+			return priority[a] < priority[b]
+		}
+		if rank[a] != rank[b] {
+			return rank[a] < rank[b]
+		}
+		return a < b
+	})
+	return names
+}
+
+// startOrder 按依赖关系对组件拓扑排序，没有依赖关系的组件按名称排序，存在环时返回 ErrDependencyCycle
+func startOrder(deps map[string][]string) ([]string, error) {
+	pending := make(map[string]int, len(deps))
+	dependents := make(map[string][]string, len(deps))
+	var ready []string
+	for name, list := range deps {
+		pending[name] = len(list)
+		for _, dep := range list {
+			dependents[dep] = append(dependents[dep], name)
+		}
+		if len(list) == 0 {
+			ready = append(ready, name)
+		}
+	}
+
+	order := make([]string, 0, len(deps))
+	for len(ready) > 0 {
+		sort.Strings(ready)
+		name := ready[0]
+		ready = ready[1:]
+		order = append(order, name)
+		for _, d := range dependents[name] {
+			pending[d]--
+			if pending[d] == 0 {
+				ready = append(ready, d)
+			}
+		}
+	}
+	if len(order) < len(deps) {
+		var cycle []string
+		for name, p := range pending {
+			if p > 0 {
+				cycle = append(cycle, name)
+			}
+		}
+		sort.Strings(cycle)
+		return nil, fmt.Errorf("%w: %s", ErrDependencyCycle, strings.Join(cycle, ", "))
+	}
+	return order, nil
+}
+
+// startComponents 按依赖顺序启动所有组件，开启并行启动时没有依赖关系的组件在协程池中并发启动
+func (n *Nmq) startComponents() error {
+	components, deps, err := n.dependencies()
+	if err != nil {
+		return err
+	}
+	order, err := startOrder(deps)
+	if err != nil {
+		return err
+	}
+	if !n.cfg.parallelStart {
+		for _, name := range order {
+			// 启动失败时按组件的重启策略重试
+			if err = n.startComponent(name, components[name]); err != nil {
+				n.logger.Error("Failed to start component", zap.String("component", name), zap.Error(err))
+				return err
+			}
+		}
+		return nil
+	}
+	return n.startParallel(components, deps, order)
+}
+
+// startResult 单个组件的启动结果
+type startResult struct {
+	name string
+	err  error
+}
+
+// startParallel 依赖全部启动成功的组件提交到协程池启动，汇总所有组件的启动错误
+//
+// 依赖启动失败的组件不再启动；开启 fail-fast 时第一个错误之后不再启动新的组件，只等待已经开始启动的组件
+func (n *Nmq) startParallel(components map[string]nmq.Component, deps map[string][]string, order []string) error {
+	pending := make(map[string]int, len(deps))
+	dependents := make(map[string][]string, len(deps))
+	for _, name := range order {
+		pending[name] = len(deps[name])
+		for _, dep := range deps[name] {
+			dependents[dep] = append(dependents[dep], name)
+		}
+	}
+
+	results := make(chan startResult, len(order))
+	running := 0
+	submit := func(name string) {
+		running++
+		task := func() {
+			results <- startResult{name: name, err: n.startComponent(name, components[name])}
+		}
+		if n.pool == nil || n.pool.Submit(task) != nil {
+			go task()
+		}
+	}
+
+	var errs []error
+	failed := make(map[string]bool)
+	var finish func(name string, err error)
+	finish = func(name string, err error) {
+		if err != nil {
+			failed[name] = true
+			errs = append(errs, fmt.Errorf("start %s: %w", name, err))
+		}
+		for _, d := range dependents[name] {
+			pending[d]--
+			if pending[d] > 0 {
+				continue
+			}
+			var failedDep string
+			for _, dep := range deps[d] {
+				if failed[dep] {
+					failedDep = dep
+					break
+				}
+			}
+			switch {
+			case n.cfg.startFailFast && len(errs) > 0:
+			case failedDep != "":
+				finish(d, fmt.Errorf("%w: %s", ErrDependencyFailed, failedDep))
+			default:
+				submit(d)
+			}
+		}
+	}
+
+	for _, name := range order {
+		if pending[name] == 0 {
+			submit(name)
+		}
+	}
+	for running > 0 {
+		r := <-results
+		running--
+		if r.err != nil {
+			n.logger.Error("Failed to start component", zap.String("component", r.name), zap.Error(r.err))
+		}
+		finish(r.name, r.err)
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,169 @@
+package nmq
+
+import (
+	"errors"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/andrewbytecoder/nmq/interfaces/nmq"
+)
+
+// depComponent 声明依赖并记录启动顺序的组件
+type depComponent struct {
+	nmq.Component
+	name string
+	deps []string
+	err  error
+	log  *startLog
+}
+
+func (d *depComponent) GetName() string        { return d.name }
+func (d *depComponent) Dependencies() []string { return d.deps }
+func (d *depComponent) Start() error {
+	d.log.record(d.name)
+	return d.err
+}
+
+// startLog 记录组件启动顺序
+type startLog struct {
+	mu    sync.Mutex
+	names []string
+}
+
+func (l *startLog) record(name string) {
+	time.Sleep(5 * time.Millisecond)
+	l.mu.Lock()
+	l.names = append(l.names, name)
+	l.mu.Unlock()
+}
+
+func (l *startLog) index(name string) int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for i, n := range l.names {
+		if n == name {
+			return i
+		}
+	}
+	return -1
+}
+
+// newDepNmq 注册 db <- cache <- api、db <- worker 四个组件
+func newDepNmq(log *startLog, failing string, opts ...Option) *Nmq {
+	n := newSupervisedNmq(opts...)
+	for _, c := range []*depComponent{
+		{name: "db"},
+		{name: "cache", deps: []string{"db"}},
+		{name: "api", deps: []string{"cache"}},
+		{name: "worker", deps: []string{"db"}},
+	} {
+		c.log = log
+		if c.name == failing {
+			c.err = errors.New("boom")
+		}
+		n.RegisterComponent(c.name, c)
+	}
+	return n
+}
+
+func TestStartOrder(t *testing.T) {
+	order, err := startOrder(map[string][]string{
+		"api": {"cache"}, "cache": {"db"}, "db": nil, "worker": {"db"}, "metrics": nil,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"db", "cache", "api", "metrics", "worker"}; !reflect.DeepEqual(order, want) {
+		t.Errorf("Expected %v, got %v", want, order)
+	}
+
+	_, err = startOrder(map[string][]string{"a": {"b"}, "b": {"a"}, "c": nil})
+	if !errors.Is(err, ErrDependencyCycle) {
+		t.Errorf("Expected ErrDependencyCycle, got %v", err)
+	}
+}
+
+func TestStartComponentsUnknownDependency(t *testing.T) {
+	n := newSupervisedNmq(SetDependencies("flaky", "missing"))
+	n.RegisterComponent("flaky", &flakyComponent{})
+	if err := n.startComponents(); !errors.Is(err, ErrUnknownDependency) {
+		t.Errorf("Expected ErrUnknownDependency, got %v", err)
+	}
+}
+
+func TestStartComponents(t *testing.T) {
+	for _, parallel := range []bool{false, true} {
+		log := &startLog{}
+		var opts []Option
+		if parallel {
+			opts = append(opts, SetParallelStart(false))
+		}
+		n := newDepNmq(log, "", opts...)
+		if err := n.startComponents(); err != nil {
+			t.Fatal(err)
+		}
+		if log.index("db") > log.index("cache") || log.index("cache") > log.index("api") || log.index("db") > log.index("worker") {
+			t.Errorf("Dependencies started out of order (parallel=%v): %v", parallel, log.names)
+		}
+	}
+}
+
+func TestStartParallelErrors(t *testing.T) {
+	// cache 失败，api 因依赖失败跳过，worker 不受影响
+	log := &startLog{}
+	n := newDepNmq(log, "cache", SetParallelStart(false))
+	err := n.startComponents()
+	if !errors.Is(err, ErrDependencyFailed) || log.index("api") != -1 || log.index("worker") == -1 {
+		t.Errorf("Unexpected result: %v, started %v", err, log.names)
+	}
+
+	// fail-fast 时 db 失败后不再启动任何组件
+	log = &startLog{}
+	n = newDepNmq(log, "db", SetParallelStart(true))
+	err = n.startComponents()
+	if err == nil || errors.Is(err, ErrDependencyFailed) || len(log.names) != 1 {
+		t.Errorf("Unexpected result: %v, started %v", err, log.names)
+	}
+}
+
+// prioritizedComponent 声明停止优先级并记录停止顺序的组件
+type prioritizedComponent struct {
+	depComponent
+	priority nmq.ShutdownPriority
+}
+
+func (p *prioritizedComponent) ShutdownPriority() nmq.ShutdownPriority { return p.priority }
+func (p *prioritizedComponent) Stop() error {
+	p.log.record(p.name)
+	return nil
+}
+
+func TestStopOrder(t *testing.T) {
+	log := &startLog{}
+	n := newSupervisedNmq(SetShutdownPriority("metrics", nmq.ShutdownStorage))
+	for _, c := range []*prioritizedComponent{
+		{depComponent{name: "listener", log: log}, nmq.ShutdownIngress},
+		{depComponent{name: "api", deps: []string{"broker"}, log: log}, nmq.ShutdownIngress},
+		{depComponent{name: "broker", deps: []string{"store"}, log: log}, nmq.ShutdownBroker},
+		{depComponent{name: "store", log: log}, nmq.ShutdownStorage},
+		{depComponent{name: "wal", deps: []string{"store"}, log: log}, nmq.ShutdownStorage},
+		{depComponent{name: "metrics", log: log}, nmq.ShutdownIngress},
+		{depComponent{name: "worker", log: log}, nmq.ShutdownDefault},
+	} {
+		n.RegisterComponent(c.name, c)
+	}
+
+	// 同一优先级内按启动顺序的逆序：依赖 store 的 wal 先停止
+	want := []string{"api", "listener", "broker", "worker", "wal", "store", "metrics"}
+	if got := n.stopOrder(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected stop order %v, got %v", want, got)
+	}
+	if err := n.Stop(); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(log.names, want) {
+		t.Errorf("Expected components stopped in %v, got %v", want, log.names)
+	}
+}
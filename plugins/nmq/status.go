@@ -0,0 +1,100 @@
+package nmq
+
+import (
+	"time"
+
+	"github.com/andrewbytecoder/nmq/interfaces/nmq"
+	"go.uber.org/zap"
+)
+
+// 每个组件保留的状态变化记录数
+const statusHistorySize = 32
+
+// transition 记录组件状态变化并同步给实现了 StatusSetter 的组件，状态没有变化时不记录 调用方需持有 n.supMu
+func (n *Nmq) transition(name string, s *supervised, c nmq.Component, to nmq.ComponentStatus, err error) {
+	if s.status == to && s.status != 0 {
+		return
+	}
+	t := nmq.StatusTransition{From: s.status, To: to, Time: time.Now()}
+	if err != nil {
+		t.Error = err.Error()
+	}
+	if len(s.history) >= statusHistorySize {
+		s.history = append(s.history[:0], s.history[1:]...)
+	}
+	s.history = append(s.history, t)
+	s.status = to
+	n.lifecycle().setStatus(name, uint(to))
+	if setter, ok := c.(nmq.StatusSetter); ok {
+		setter.SetStatus(to)
+	}
+}
+
+// setStatus 加锁记录组件状态变化
+func (n *Nmq) setStatus(name string, c nmq.Component, to nmq.ComponentStatus, err error) {
+	n.supMu.Lock()
+	defer n.supMu.Unlock()
+	n.transition(name, n.supervision(name), c, to, err)
+}
+
+// StatusHistory 返回组件最近的状态变化，按时间顺序排列，组件不存在时返回 nil
+func (n *Nmq) StatusHistory(name string) []nmq.StatusTransition {
+	n.supMu.Lock()
+	defer n.supMu.Unlock()
+	s, ok := n.supervised[name]
+	if !ok {
+		return nil
+	}
+	return append([]nmq.StatusTransition(nil), s.history...)
+}
+
+// initComponents 进入 PhaseInit 并初始化所有组件，遇到第一个错误时返回
+func (n *Nmq) initComponents() error {
+	n.setPhase(nmq.PhaseInit, nil)
+	for name, component := range n.components {
+		// 自己不能初始化自己
+		if component.GetName() == n.GetName() {
+			continue
+		}
+		if err := n.initComponent(name, component); err != nil {
+			n.logger.Error("Failed to init component", zap.String("component", name), zap.Error(err))
+			return err
+		}
+	}
+	return nil
+}
+
+// initComponent 初始化组件并记录状态
+func (n *Nmq) initComponent(name string, c nmq.Component) error {
+	if err := guard(name, "init", c.Init); err != nil {
+		n.setStatus(name, c, nmq.ComponentFailed, err)
+		return err
+	}
+	n.setStatus(name, c, nmq.ComponentInit, nil)
+	return nil
+}
+
+// stopComponent 取消组件的子上下文后停止组件并记录状态，停止后保存开启持久化的缓存
+func (n *Nmq) stopComponent(name string, c nmq.Component) error {
+	n.cancelComponentContext(name)
+	start := time.Now()
+	err := guard(name, "stop", c.Stop)
+	n.lifecycle().observeStop(name, time.Since(start))
+	n.saveCaches(name)
+	if err != nil {
+		n.setStatus(name, c, nmq.ComponentFailed, err)
+		return err
+	}
+	n.setStatus(name, c, nmq.ComponentStopped, nil)
+	return nil
+}
+
+// resetComponent 重置组件并记录状态
+func (n *Nmq) resetComponent(name string, c nmq.Component) error {
+	if err := guard(name, "reset", c.Reset); err != nil {
+		n.setStatus(name, c, nmq.ComponentFailed, err)
+		return err
+	}
+	n.setStatus(name, c, nmq.ComponentReset, nil)
+	return nil
+}
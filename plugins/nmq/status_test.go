@@ -0,0 +1,84 @@
+package nmq
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/andrewbytecoder/nmq/interfaces/nmq"
+)
+
+// baseComponent 嵌入 ComponentBase 的组件，状态由组件管理器同步
+type baseComponent struct {
+	nmq.Component
+	nmq.ComponentBase
+	startErr error
+}
+
+func (b *baseComponent) GetName() string                { return "base" }
+func (b *baseComponent) GetStatus() nmq.ComponentStatus { return b.ComponentBase.GetStatus() }
+func (b *baseComponent) Init() error                    { return nil }
+func (b *baseComponent) Start() error                   { return b.startErr }
+func (b *baseComponent) Stop() error                    { return nil }
+func (b *baseComponent) Reset() error                   { return nil }
+
+func TestStatusHistory(t *testing.T) {
+	n := newSupervisedNmq()
+	c := &baseComponent{}
+	n.RegisterComponent("base", c)
+	if h := n.StatusHistory("base"); h != nil {
+		t.Errorf("Expected no history, got %v", h)
+	}
+
+	steps := []func() error{
+		func() error { return n.initComponent("base", c) },
+		func() error { return n.startComponent("base", c) },
+		func() error { return n.startComponent("base", c) }, // 状态没有变化时不记录
+		func() error { return n.StopComponent("base") },
+		func() error { return n.ResetComponent("base") },
+	}
+	for _, step := range steps {
+		if err := step(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	c.startErr = errors.New("boom")
+	if err := n.startComponent("base", c); err == nil {
+		t.Fatal("Expected start error")
+	}
+
+	want := []nmq.ComponentStatus{nmq.ComponentInit, nmq.ComponentRunning, nmq.ComponentStopped, nmq.ComponentReset, nmq.ComponentFailed}
+	history := n.StatusHistory("base")
+	if len(history) != len(want) {
+		t.Fatalf("Expected %d transitions, got %+v", len(want), history)
+	}
+	from := nmq.ComponentOk
+	for i, tr := range history {
+		if tr.From != from || tr.To != want[i] || tr.Time.IsZero() {
+			t.Errorf("Unexpected transition %d: %+v", i, tr)
+		}
+		from = tr.To
+	}
+	if history[len(history)-1].Error != "boom" {
+		t.Errorf("Expected failure reason, got %+v", history[len(history)-1])
+	}
+	if c.GetStatus() != nmq.ComponentFailed {
+		t.Errorf("Expected ComponentBase status synced, got %v", c.GetStatus())
+	}
+	if status, _ := n.ComponentStatus("base"); status != nmq.ComponentFailed {
+		t.Errorf("Expected ComponentFailed, got %v", status)
+	}
+}
+
+func TestStatusHistoryBounded(t *testing.T) {
+	n := newSupervisedNmq()
+	c := &baseComponent{}
+	n.RegisterComponent("base", c)
+	for i := 0; i < statusHistorySize; i++ {
+		_ = n.StopComponent("base")
+		_ = n.ResetComponent("base")
+	}
+	history := n.StatusHistory("base")
+	if len(history) != statusHistorySize || history[len(history)-1].To != nmq.ComponentReset {
+		t.Errorf("Unexpected history: %d entries", len(history))
+	}
+}
@@ -0,0 +1,410 @@
+package nmq
+
+import (
+	"container/heap"
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/andrewbytecoder/nmq/internal/prometheus"
+	"github.com/panjf2000/ants/v2"
+	stdprometheus "github.com/prometheus/client_golang/prometheus"
+	"github.com/spf13/viper"
+	"go.uber.org/zap"
+)
+
+// componentPoolsKey 配置文件中组件专用协程池大小的配置项，例如 pool.components.mq: 64
+const componentPoolsKey = "pool.components"
+
+// FullPolicy 提交队列已满时的处理方式
+type FullPolicy int
+
+const (
+	FullBlock  FullPolicy = iota // SubmitWait 阻塞等待直到有空位或 ctx 结束，TrySubmit 直接拒绝
+	FullReject                   // 直接拒绝新任务
+	FullShed                     // 丢弃队列中优先级最低的任务，新任务优先级不高于它时拒绝新任务
+)
+
+var (
+	ErrQueueFull   = errors.New("nmq: submit queue full")
+	ErrQueueClosed = errors.New("nmq: submit queue closed")
+)
+
+// queuedTask 等待派发到协程池的任务
+type queuedTask struct {
+	task     func()
+	priority int
+	seq      uint64
+}
+
+// taskHeap 优先级高的先出队，优先级相同时先进先出
+type taskHeap []*queuedTask
+
+func (h taskHeap) Len() int { return len(h) }
+func (h taskHeap) Less(i, j int) bool {
+	if h[i].priority != h[j].priority {
+		return h[i].priority > h[j].priority
+	}
+	return h[i].seq < h[j].seq
+}
+func (h taskHeap) Swap(i, j int)       { h[i], h[j] = h[j], h[i] }
+func (h *taskHeap) Push(x interface{}) { *h = append(*h, x.(*queuedTask)) }
+func (h *taskHeap) Pop() interface{} {
+	old := *h
+	t := old[len(old)-1]
+	old[len(old)-1] = nil
+	*h = old[:len(old)-1]
+	return t
+}
+
+// lowest 返回优先级最低、入队最晚的任务下标
+func (h taskHeap) lowest() int {
+	low := 0
+	for i := 1; i < len(h); i++ {
+		if h[i].priority < h[low].priority || (h[i].priority == h[low].priority && h[i].seq > h[low].seq) {
+			low = i
+		}
+	}
+	return low
+}
+
+// taskQueue 协程池前的有界队列，单个派发协程按优先级把任务交给协程池
+//
+// 协程池满时 ants 的 Submit 会阻塞派发协程，队列随之积压，从而把压力反馈给提交方
+type taskQueue struct {
+	mu     sync.Mutex
+	tasks  taskHeap
+	size   int
+	policy FullPolicy
+	seq    uint64
+	closed bool
+	space  chan struct{} // 出队时关闭并替换，唤醒等待空位的提交方
+	ready  chan struct{} // 入队时通知派发协程
+	done   chan struct{} // 派发协程退出
+	pool   *ants.Pool
+
+	submitted atomic.Uint64
+	rejected  atomic.Uint64
+	shed      atomic.Uint64
+	blocked   atomic.Uint64
+}
+
+// newTaskQueue 创建提交队列并启动派发协程，size 至少为1
+func newTaskQueue(pool *ants.Pool, size int, policy FullPolicy) *taskQueue {
+	q := &taskQueue{
+		size:   max(size, 1),
+		policy: policy,
+		space:  make(chan struct{}),
+		ready:  make(chan struct{}, 1),
+		done:   make(chan struct{}),
+		pool:   pool,
+	}
+	go q.run()
+	return q
+}
+
+// push 将任务入队，wait 为 false 时不会阻塞
+func (q *taskQueue) push(ctx context.Context, priority int, task func(), wait bool) error {
+	q.mu.Lock()
+	waited := false
+	for {
+		if q.closed {
+			q.mu.Unlock()
+			return ErrQueueClosed
+		}
+		if len(q.tasks) < q.size {
+			break
+		}
+		if q.policy == FullShed {
+			low := q.tasks.lowest()
+			if q.tasks[low].priority >= priority {
+				q.mu.Unlock()
+				q.rejected.Add(1)
+				return ErrQueueFull
+			}
+			heap.Remove(&q.tasks, low)
+			q.shed.Add(1)
+			break
+		}
+		if q.policy != FullBlock || !wait {
+			q.mu.Unlock()
+			q.rejected.Add(1)
+			return ErrQueueFull
+		}
+
+		if !waited {
+			waited = true
+			q.blocked.Add(1)
+		}
+		space := q.space
+		q.mu.Unlock()
+		select {
+		case <-space:
+		case <-ctx.Done():
+			q.rejected.Add(1)
+			return ctx.Err()
+		}
+		q.mu.Lock()
+	}
+
+	q.seq++
+	heap.Push(&q.tasks, &queuedTask{task: task, priority: priority, seq: q.seq})
+	q.mu.Unlock()
+	q.submitted.Add(1)
+	select {
+	case q.ready <- struct{}{}:
+	default:
+	}
+	return nil
+}
+
+// run 派发协程，关闭后把剩余任务全部交给协程池再退出
+func (q *taskQueue) run() {
+	defer close(q.done)
+	for {
+		q.mu.Lock()
+		for len(q.tasks) == 0 {
+			if q.closed {
+				q.mu.Unlock()
+				return
+			}
+			q.mu.Unlock()
+			<-q.ready
+			q.mu.Lock()
+		}
+		t := heap.Pop(&q.tasks).(*queuedTask)
+		close(q.space)
+		q.space = make(chan struct{})
+		q.mu.Unlock()
+
+		if err := q.pool.Submit(t.task); err != nil {
+			q.rejected.Add(1)
+		}
+	}
+}
+
+// close 停止接收任务，唤醒所有等待的提交方，等待队列中的任务全部交给协程池
+func (q *taskQueue) close() {
+	q.mu.Lock()
+	if !q.closed {
+		q.closed = true
+		close(q.space)
+		q.space = make(chan struct{})
+	}
+	q.mu.Unlock()
+	select {
+	case q.ready <- struct{}{}:
+	default:
+	}
+	<-q.done
+}
+
+// len 返回队列中等待派发的任务数
+func (q *taskQueue) len() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return len(q.tasks)
+}
+
+// QueueStats 提交队列和协程池的饱和度
+type QueueStats struct {
+	Depth     int    // 等待派发的任务数
+	Capacity  int    // 队列容量
+	Running   int    // 协程池中正在执行的任务数
+	PoolCap   int    // 协程池容量
+	Submitted uint64 // 累计入队的任务数
+	Rejected  uint64 // 累计被拒绝的任务数（包括等待超时）
+	Shed      uint64 // 累计被丢弃的低优先级任务数
+	Blocked   uint64 // 累计因队列已满而阻塞的提交次数
+}
+
+// SubmitWait 提交任务，队列已满时按 FullPolicy 处理，FullBlock 模式下阻塞直到有空位或 ctx 结束
+func (n *Nmq) SubmitWait(ctx context.Context, task func()) error {
+	return n.SubmitPriority(ctx, 0, task)
+}
+
+// SubmitPriority 以指定优先级提交任务，数值越大越先执行，FullShed 模式下优先丢弃优先级低的任务
+func (n *Nmq) SubmitPriority(ctx context.Context, priority int, task func()) error {
+	if n.queue == nil {
+		return ErrQueueClosed
+	}
+	return n.queue.push(ctx, priority, task, true)
+}
+
+// TrySubmit 提交任务，队列已满时不阻塞，FullShed 模式下仍会丢弃优先级更低的任务
+func (n *Nmq) TrySubmit(task func()) error {
+	if n.queue == nil {
+		return ErrQueueClosed
+	}
+	return n.queue.push(context.Background(), 0, task, false)
+}
+
+// SubmitComponent 以组件的名义提交任务，配置了专用协程池的组件提交到自己的协程池，协程池已满时等待空位直到 ctx 结束；
+// 其他组件以 priority 经过共享队列提交
+//
+// 专用协程池没有排队，任务直接交给空闲的协程执行，priority 对专用协程池不生效
+func (n *Nmq) SubmitComponent(ctx context.Context, component string, priority int, task func()) error {
+	if pool, ok := n.pools[component]; ok {
+		return pool.submit(ctx, task)
+	}
+	return n.SubmitPriority(ctx, priority, task)
+}
+
+// componentPoolRetry 专用协程池已满时重试提交的最长间隔，任务结束到协程归还协程池之间没有通知
+const componentPoolRetry = 10 * time.Millisecond
+
+// componentPool 组件专用协程池，非阻塞模式，协程池已满时由提交方按 ctx 等待
+type componentPool struct {
+	*ants.Pool
+	mu    sync.Mutex
+	space chan struct{} // 任务结束时关闭并替换，唤醒等待空位的提交方
+}
+
+func newComponentPool(size int, options ...ants.Option) (*componentPool, error) {
+	pool, err := ants.NewPool(size, append(options, ants.WithNonblocking(true))...)
+	if err != nil {
+		return nil, err
+	}
+	return &componentPool{Pool: pool, space: make(chan struct{})}, nil
+}
+
+// submit 提交任务，协程池已满时等待有任务结束或重试间隔到期后再次提交，ctx 结束时返回 ctx.Err()
+func (p *componentPool) submit(ctx context.Context, task func()) error {
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		// 提交前取出当前的通知通道，避免错过提交失败和开始等待之间结束的任务
+		p.mu.Lock()
+		space := p.space
+		p.mu.Unlock()
+		err := p.Pool.Submit(func() {
+			defer p.release()
+			task()
+		})
+		if !errors.Is(err, ants.ErrPoolOverload) {
+			return err
+		}
+		timer := time.NewTimer(componentPoolRetry)
+		select {
+		case <-space:
+		case <-timer.C:
+		case <-ctx.Done():
+		}
+		timer.Stop()
+	}
+}
+
+// release 任务结束，唤醒等待空位的提交方
+func (p *componentPool) release() {
+	p.mu.Lock()
+	close(p.space)
+	p.space = make(chan struct{})
+	p.mu.Unlock()
+}
+
+// startComponentPools 按 SetComponentPool 和配置文件 pool.components 创建组件专用协程池，配置文件优先
+func (n *Nmq) startComponentPools() error {
+	sizes := make(map[string]int, len(n.cfg.componentPools))
+	for name, size := range n.cfg.componentPools {
+		sizes[name] = size
+	}
+	for name := range viper.GetStringMap(componentPoolsKey) {
+		sizes[name] = viper.GetInt(componentPoolsKey + "." + name)
+	}
+
+	pools := make(map[string]*componentPool, len(sizes))
+	for name, size := range sizes {
+		if size <= 0 {
+			continue
+		}
+		component := name
+		pool, err := newComponentPool(size, ants.WithPanicHandler(func(p interface{}) {
+			n.logger.Error("panic", zap.String("component", component), zap.Any("panic", p))
+		}))
+		if err != nil {
+			for _, p := range pools {
+				p.Release()
+			}
+			return fmt.Errorf("component %s pool: %w", name, err)
+		}
+		pools[name] = pool
+	}
+	n.pools = pools
+	return nil
+}
+
+// SubmitQueueStats 返回提交队列和协程池的饱和度，队列未启动时返回零值
+func (n *Nmq) SubmitQueueStats() QueueStats {
+	q := n.queue
+	if q == nil {
+		return QueueStats{}
+	}
+	return QueueStats{
+		Depth:     q.len(),
+		Capacity:  q.size,
+		Running:   q.pool.Running(),
+		PoolCap:   q.pool.Cap(),
+		Submitted: q.submitted.Load(),
+		Rejected:  q.rejected.Load(),
+		Shed:      q.shed.Load(),
+		Blocked:   q.blocked.Load(),
+	}
+}
+
+// registerQueueMetrics 将提交队列饱和度注册到 prometheus 默认注册器，重复注册时忽略
+func (n *Nmq) registerQueueMetrics() error {
+	gauges := []struct {
+		name string
+		help string
+		load func(QueueStats) float64
+	}{
+		{"depth", "Number of tasks waiting in the submit queue.", func(s QueueStats) float64 { return float64(s.Depth) }},
+		{"capacity", "Capacity of the submit queue.", func(s QueueStats) float64 { return float64(s.Capacity) }},
+		{"pool_running", "Number of tasks running in the worker pool.", func(s QueueStats) float64 { return float64(s.Running) }},
+	}
+	counters := []struct {
+		name string
+		help string
+		load func(QueueStats) float64
+	}{
+		{"rejected_total", "Number of tasks rejected because the submit queue was full.", func(s QueueStats) float64 { return float64(s.Rejected) }},
+		{"shed_total", "Number of low priority tasks dropped from the submit queue.", func(s QueueStats) float64 { return float64(s.Shed) }},
+		{"blocked_total", "Number of submits that blocked on a full queue.", func(s QueueStats) float64 { return float64(s.Blocked) }},
+	}
+
+	for _, g := range gauges {
+		load := g.load
+		err := prometheus.RegisterGaugeFunc(stdprometheus.GaugeOpts{
+			Namespace: "nmq",
+			Subsystem: "submit_queue",
+			Name:      g.name,
+			Help:      g.help,
+		}, func() float64 { return load(n.SubmitQueueStats()) })
+		if err != nil && !alreadyRegistered(err) {
+			return err
+		}
+	}
+	for _, c := range counters {
+		load := c.load
+		err := prometheus.RegisterCounterFunc(stdprometheus.CounterOpts{
+			Namespace: "nmq",
+			Subsystem: "submit_queue",
+			Name:      c.name,
+			Help:      c.help,
+		}, func() float64 { return load(n.SubmitQueueStats()) })
+		if err != nil && !alreadyRegistered(err) {
+			return err
+		}
+	}
+	return nil
+}
+
+// alreadyRegistered 判断注册失败是否因为指标已经注册，多次 Start 时复用已注册的指标
+func alreadyRegistered(err error) bool {
+	var are stdprometheus.AlreadyRegisteredError
+	return errors.As(err, &are)
+}
@@ -0,0 +1,211 @@
+package nmq
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/andrewbytecoder/nmq/interfaces/nmq"
+	"github.com/panjf2000/ants/v2"
+	"github.com/spf13/viper"
+	"go.uber.org/zap"
+)
+
+// newTestQueue 创建单协程的协程池和提交队列，返回阻塞协程池的函数
+func newTestQueue(t *testing.T, size int, policy FullPolicy) (*Nmq, func() (release func())) {
+	pool, err := ants.NewPool(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	n := &Nmq{pool: pool, queue: newTaskQueue(pool, size, policy)}
+	t.Cleanup(func() {
+		n.queue.close()
+		pool.Release()
+	})
+	// 占满协程池和派发协程，之后提交的任务都留在队列中
+	saturate := func() func() {
+		block := make(chan struct{})
+		started := make(chan struct{}, 2)
+		for i := 0; i < 2; i++ {
+			if err := n.TrySubmit(func() { started <- struct{}{}; <-block }); err != nil {
+				t.Fatal(err)
+			}
+			for n.queue.len() > 0 {
+				time.Sleep(time.Millisecond)
+			}
+		}
+		<-started
+		return func() { close(block) }
+	}
+	return n, saturate
+}
+
+func TestSubmitQueueReject(t *testing.T) {
+	n, saturate := newTestQueue(t, 2, FullReject)
+	release := saturate()
+	defer release()
+
+	for i := 0; i < 2; i++ {
+		if err := n.SubmitWait(context.Background(), func() {}); err != nil {
+			t.Fatalf("Expected submit %d to be queued, got %v", i, err)
+		}
+	}
+	if err := n.SubmitWait(context.Background(), func() {}); !errors.Is(err, ErrQueueFull) {
+		t.Errorf("Expected ErrQueueFull, got %v", err)
+	}
+	if s := n.SubmitQueueStats(); s.Depth != 2 || s.Capacity != 2 || s.Rejected != 1 {
+		t.Errorf("Unexpected stats: %+v", s)
+	}
+}
+
+func TestSubmitQueueBlock(t *testing.T) {
+	n, saturate := newTestQueue(t, 1, FullBlock)
+	release := saturate()
+
+	if err := n.TrySubmit(func() {}); err != nil {
+		t.Fatal(err)
+	}
+	if err := n.TrySubmit(func() {}); !errors.Is(err, ErrQueueFull) {
+		t.Errorf("Expected TrySubmit to reject, got %v", err)
+	}
+
+	// 截止时间内没有空位
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if err := n.SubmitWait(ctx, func() {}); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected DeadlineExceeded, got %v", err)
+	}
+
+	// 协程池空闲后阻塞的提交可以继续
+	var wg sync.WaitGroup
+	wg.Add(1)
+	errc := make(chan error, 1)
+	go func() { errc <- n.SubmitWait(context.Background(), wg.Done) }()
+	time.Sleep(10 * time.Millisecond)
+	release()
+	if err := <-errc; err != nil {
+		t.Fatalf("Expected blocked submit to succeed, got %v", err)
+	}
+	wg.Wait()
+	if s := n.SubmitQueueStats(); s.Blocked != 2 {
+		t.Errorf("Expected 2 blocked submits, got %+v", s)
+	}
+}
+
+func TestSubmitQueueShed(t *testing.T) {
+	n, saturate := newTestQueue(t, 2, FullShed)
+	release := saturate()
+
+	var mu sync.Mutex
+	var ran []int
+	var wg sync.WaitGroup
+	wg.Add(2) // 只有优先级为2和1的任务会执行
+	task := func(i int) func() {
+		return func() { mu.Lock(); ran = append(ran, i); mu.Unlock(); wg.Done() }
+	}
+	ctx := context.Background()
+	n.SubmitPriority(ctx, 1, task(1))
+	n.SubmitPriority(ctx, 0, task(0))
+	// 丢弃优先级为0的任务
+	if err := n.SubmitPriority(ctx, 2, task(2)); err != nil {
+		t.Fatalf("Expected shed to make room, got %v", err)
+	}
+	// 不高于队列中最低优先级时拒绝
+	if err := n.SubmitPriority(ctx, 1, task(3)); !errors.Is(err, ErrQueueFull) {
+		t.Errorf("Expected ErrQueueFull, got %v", err)
+	}
+
+	release()
+	n.queue.close()
+	wg.Wait()
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(ran) != 2 || ran[0] != 2 || ran[1] != 1 {
+		t.Errorf("Expected tasks [2 1] by priority, got %v", ran)
+	}
+	if s := n.SubmitQueueStats(); s.Shed != 1 || s.Rejected != 1 {
+		t.Errorf("Unexpected stats: %+v", s)
+	}
+	if err := n.TrySubmit(func() {}); !errors.Is(err, ErrQueueClosed) {
+		t.Errorf("Expected ErrQueueClosed, got %v", err)
+	}
+}
+
+func TestSubmitComponentPool(t *testing.T) {
+	t.Cleanup(viper.Reset)
+	viper.Set("pool.components", map[string]any{"chatty": 1, "disabled": 0})
+
+	n, saturate := newTestQueue(t, 4, FullReject)
+	n.logger = zap.NewNop()
+	n.cfg = DefaultConfig()
+	SetComponentPool("audit", 2).apply(n)
+	if err := n.startComponentPools(); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		for _, pool := range n.pools {
+			pool.Release()
+		}
+	})
+	if len(n.pools) != 2 || n.pools["chatty"].Cap() != 1 || n.pools["audit"].Cap() != 2 {
+		t.Fatalf("Unexpected component pools %v", n.pools)
+	}
+
+	// 共享协程池占满时，有专用协程池的组件仍然可以执行任务
+	release := saturate()
+	defer release()
+	done := make(chan struct{})
+	if err := n.SubmitComponent(context.Background(), "chatty", nmq.PriorityLow, func() { close(done) }); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("Expected task to run on the dedicated pool")
+	}
+
+	// 没有专用协程池的组件经过共享队列
+	if err := n.SubmitComponent(context.Background(), "other", nmq.PriorityHigh, func() {}); err != nil {
+		t.Fatal(err)
+	}
+	if n.queue.len() != 1 {
+		t.Errorf("Expected task queued in the shared queue, got depth %d", n.queue.len())
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := n.SubmitComponent(ctx, "chatty", nmq.PriorityNormal, func() {}); !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected context.Canceled, got %v", err)
+	}
+
+	// 专用协程池已满时等待空位，ctx 结束时返回
+	block := make(chan struct{})
+	if err := n.SubmitComponent(context.Background(), "chatty", nmq.PriorityNormal, func() { <-block }); err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel = context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if err := n.SubmitComponent(ctx, "chatty", nmq.PriorityNormal, func() {}); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected context.DeadlineExceeded, got %v", err)
+	}
+	time.AfterFunc(20*time.Millisecond, func() { close(block) })
+	done = make(chan struct{})
+	if err := n.SubmitComponent(context.Background(), "chatty", nmq.PriorityNormal, func() { close(done) }); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("Expected waiting task to run once the pool had space")
+	}
+}
+
+func TestSubmitWithoutPool(t *testing.T) {
+	n := &Nmq{}
+	if err := n.Submit(func() {}); !errors.Is(err, ErrQueueClosed) {
+		t.Errorf("Expected ErrQueueClosed, got %v", err)
+	}
+}
@@ -0,0 +1,210 @@
+package nmq
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/andrewbytecoder/nmq/interfaces/nmq"
+	"github.com/andrewbytecoder/nmq/pkg/recovery"
+	"go.uber.org/zap"
+)
+
+// ErrComponentExited 通过 Go 运行的协程正常退出，只在 RestartAlways 策略下触发重启
+var ErrComponentExited = errors.New("nmq: component goroutine exited")
+
+// supervised 单个组件的监控记录
+type supervised struct {
+	status     nmq.ComponentStatus // 组件管理器记录的状态，为0时使用组件自身的状态
+	history    []nmq.StatusTransition
+	restarts   int // 累计重启次数
+	attempts   int // 连续重启次数，用于计算退避时间
+	lastErr    error
+	started    time.Time // 最近一次启动成功的时间
+	restarting bool
+}
+
+// policy 返回组件的重启策略，没有配置的组件不重启
+func (n *Nmq) policy(name string) nmq.RestartPolicy {
+	return n.cfg.restart[name]
+}
+
+// supervision 返回组件的监控记录，调用方需持有 n.supMu
+func (n *Nmq) supervision(name string) *supervised {
+	if n.supervised == nil {
+		n.supervised = make(map[string]*supervised)
+	}
+	s, ok := n.supervised[name]
+	if !ok {
+		s = &supervised{}
+		n.supervised[name] = s
+	}
+	return s
+}
+
+// backoff 返回第 attempts 次连续重启前的等待时间，按指数增长，不超过最大退避时间
+func (n *Nmq) backoff(attempts int) time.Duration {
+	d := n.cfg.restartBackoff
+	for i := 1; i < attempts && d < n.cfg.restartMaxBackoff; i++ {
+		d *= 2
+	}
+	return min(d, n.cfg.restartMaxBackoff)
+}
+
+// startComponent 恢复组件开启持久化的缓存后启动组件，失败时按重启策略退避重试，重试次数用尽或 nmq 停止后返回最后一次错误
+func (n *Nmq) startComponent(name string, c nmq.Component) error {
+	n.restoreCaches(name)
+	for {
+		n.renewComponentContext(name)
+		start := time.Now()
+		err := guard(name, "start", c.Start)
+		n.lifecycle().observeStart(name, time.Since(start))
+		n.supMu.Lock()
+		s := n.supervision(name)
+		if err == nil {
+			s.started = time.Now()
+			n.transition(name, s, c, nmq.ComponentRunning, nil)
+			n.supMu.Unlock()
+			return nil
+		}
+		s.lastErr = err
+		if n.policy(name) == nmq.RestartNever || !n.canRestart(s) {
+			n.transition(name, s, c, nmq.ComponentFailed, err)
+			n.supMu.Unlock()
+			return err
+		}
+		s.attempts++
+		s.restarts++
+		n.lifecycle().restarted(name)
+		n.transition(name, s, c, nmq.ComponentRestarting, err)
+		wait := n.backoff(s.attempts)
+		n.supMu.Unlock()
+
+		n.logger.Warn("Failed to start component, retrying", zap.String("component", name),
+			zap.Duration("backoff", wait), zap.Error(err))
+		if !n.sleep(wait) {
+			return err
+		}
+	}
+}
+
+// canRestart 判断是否还可以重启，组件稳定运行超过最大退避时间后重新计算连续重启次数 调用方需持有 n.supMu
+func (n *Nmq) canRestart(s *supervised) bool {
+	if !s.started.IsZero() && time.Since(s.started) > n.cfg.restartMaxBackoff {
+		s.attempts = 0
+	}
+	return n.cfg.maxRestarts <= 0 || s.attempts < n.cfg.maxRestarts
+}
+
+// sleep 等待 d，nmq 停止时返回 false
+func (n *Nmq) sleep(d time.Duration) bool {
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-t.C:
+		return true
+	case <-n.ctx.Done():
+		return false
+	}
+}
+
+// Go 以组件的名义运行协程，fn 收到组件的子上下文，协程 panic 或返回错误时按组件的重启策略处理，
+// 组件停止后协程退出不视为失败
+func (n *Nmq) Go(component string, fn func(ctx context.Context) error) {
+	ctx := n.GetComponentContext(component)
+	n.wg.Add(1)
+	go func() {
+		defer n.wg.Done()
+		err := recovery.Default.Do("component."+component, nil, func() error { return fn(ctx) })
+		if ctx.Err() != nil {
+			return
+		}
+		if err != nil {
+			n.ReportFailure(component, err)
+		} else if n.policy(component) == nmq.RestartAlways {
+			n.ReportFailure(component, ErrComponentExited)
+		}
+	}()
+}
+
+// ReportFailure 报告组件运行时失败，RestartNever 的组件标记为 ComponentFailed，其余组件在后台重启
+func (n *Nmq) ReportFailure(component string, err error) {
+	n.mux.RLock()
+	c, ok := n.components[component]
+	n.mux.RUnlock()
+	if !ok {
+		n.logger.Warn("Failure reported for unknown component", zap.String("component", component), zap.Error(err))
+		return
+	}
+
+	n.supMu.Lock()
+	s := n.supervision(component)
+	s.lastErr = err
+	if n.policy(component) == nmq.RestartNever || !n.canRestart(s) {
+		n.transition(component, s, c, nmq.ComponentFailed, err)
+		n.supMu.Unlock()
+		n.logger.Error("Component failed", zap.String("component", component), zap.Error(err))
+		return
+	}
+	if s.restarting {
+		n.supMu.Unlock()
+		return
+	}
+	s.restarting = true
+	n.transition(component, s, c, nmq.ComponentRestarting, err)
+	n.supMu.Unlock()
+
+	n.logger.Warn("Component failed, restarting", zap.String("component", component), zap.Error(err))
+	n.wg.Add(1)
+	go func() {
+		defer n.wg.Done()
+		n.restart(component, c)
+	}()
+}
+
+// restart 退避后先 Stop 再 Start 组件，启动失败时继续退避重试
+func (n *Nmq) restart(name string, c nmq.Component) {
+	defer func() {
+		n.supMu.Lock()
+		n.supervision(name).restarting = false
+		n.supMu.Unlock()
+	}()
+
+	n.supMu.Lock()
+	s := n.supervision(name)
+	s.attempts++
+	s.restarts++
+	wait := n.backoff(s.attempts)
+	n.supMu.Unlock()
+	n.lifecycle().restarted(name)
+	if !n.sleep(wait) {
+		return
+	}
+
+	n.cancelComponentContext(name)
+	if err := guard(name, "stop", c.Stop); err != nil {
+		n.logger.Warn("Failed to stop component before restart", zap.String("component", name), zap.Error(err))
+	}
+	if err := n.startComponent(name, c); err != nil {
+		n.logger.Error("Failed to restart component", zap.String("component", name), zap.Error(err))
+		return
+	}
+	n.logger.Info("Component restarted", zap.String("component", name))
+}
+
+// ComponentStatus 返回组件管理器记录的状态，还没有经过生命周期变化的组件返回组件自身的状态
+func (n *Nmq) ComponentStatus(name string) (nmq.ComponentStatus, error) {
+	n.mux.RLock()
+	c, ok := n.components[name]
+	n.mux.RUnlock()
+	if !ok {
+		return 0, fmt.Errorf("%w: %s", nmq.ErrComponentNotFound, name)
+	}
+	n.supMu.Lock()
+	defer n.supMu.Unlock()
+	if s, ok := n.supervised[name]; ok && s.status != 0 {
+		return s.status, nil
+	}
+	return c.GetStatus(), nil
+}
@@ -0,0 +1,159 @@
+package nmq
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/andrewbytecoder/nmq/interfaces/nmq"
+	"go.uber.org/zap"
+)
+
+// flakyComponent 前 failStarts 次 Start 失败的组件
+type flakyComponent struct {
+	nmq.Component
+	mu         sync.Mutex
+	failStarts int
+	starts     int
+	stops      int
+}
+
+func (f *flakyComponent) GetName() string                { return "flaky" }
+func (f *flakyComponent) GetStatus() nmq.ComponentStatus { return nmq.ComponentRunning }
+func (f *flakyComponent) GetVersion() string             { return "1.0.0" }
+func (f *flakyComponent) Reset() error                   { return nil }
+func (f *flakyComponent) Stop() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.stops++
+	return nil
+}
+func (f *flakyComponent) Start() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.starts++
+	if f.starts <= f.failStarts {
+		return errors.New("not yet")
+	}
+	return nil
+}
+
+func (f *flakyComponent) counts() (int, int) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.starts, f.stops
+}
+
+func newSupervisedNmq(opts ...Option) *Nmq {
+	ctx, cancel := context.WithCancel(context.Background())
+	n := &Nmq{ctx: ctx, cancel: cancel, logger: zap.NewNop(), cfg: DefaultConfig(), components: make(map[string]nmq.Component)}
+	SetRestartBackoff(time.Millisecond, 10*time.Millisecond, 3).apply(n)
+	for _, opt := range opts {
+		opt.apply(n)
+	}
+	return n
+}
+
+func waitStatus(t *testing.T, n *Nmq, name string, want nmq.ComponentStatus) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		if status, _ := n.ComponentStatus(name); status == want {
+			return
+		}
+		time.Sleep(time.Millisecond)
+	}
+	status, _ := n.ComponentStatus(name)
+	t.Fatalf("Expected status %v, got %v", want, status)
+}
+
+func TestStartComponentRetry(t *testing.T) {
+	n := newSupervisedNmq(SetRestartPolicy("flaky", nmq.RestartOnFailure))
+	c := &flakyComponent{failStarts: 2}
+	n.RegisterComponent("flaky", c)
+	if err := n.startComponent("flaky", c); err != nil {
+		t.Fatal(err)
+	}
+	if starts, _ := c.counts(); starts != 3 {
+		t.Errorf("Expected 3 starts, got %d", starts)
+	}
+	if st := n.ListComponents(); len(st) != 1 || st[0].Restarts != 2 || st[0].Status != nmq.ComponentRunning {
+		t.Errorf("Unexpected supervision: %+v", st)
+	}
+
+	// 超过最大连续重启次数后放弃
+	c = &flakyComponent{failStarts: 10}
+	n.RegisterComponent("flaky", c)
+	if err := n.startComponent("flaky", c); err == nil {
+		t.Error("Expected start error after max restarts")
+	}
+	waitStatus(t, n, "flaky", nmq.ComponentFailed)
+
+	// 不重启的组件启动失败直接返回
+	n = newSupervisedNmq()
+	c = &flakyComponent{failStarts: 1}
+	n.RegisterComponent("flaky", c)
+	if err := n.startComponent("flaky", c); err == nil {
+		t.Error("Expected start error with RestartNever")
+	}
+	if starts, _ := c.counts(); starts != 1 {
+		t.Errorf("Expected 1 start, got %d", starts)
+	}
+}
+
+func TestSupervisorRuntimeFailure(t *testing.T) {
+	n := newSupervisedNmq(SetRestartPolicy("flaky", nmq.RestartOnFailure))
+	c := &flakyComponent{}
+	n.RegisterComponent("flaky", c)
+	if err := n.startComponent("flaky", c); err != nil {
+		t.Fatal(err)
+	}
+
+	n.Go("flaky", func(ctx context.Context) error { panic("boom") })
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		if starts, stops := c.counts(); starts == 2 && stops == 1 {
+			break
+		}
+		time.Sleep(time.Millisecond)
+	}
+	if starts, stops := c.counts(); starts != 2 || stops != 1 {
+		t.Fatalf("Expected restart, got %d starts %d stops", starts, stops)
+	}
+	waitStatus(t, n, "flaky", nmq.ComponentRunning)
+
+	// RestartOnFailure 下正常退出不重启
+	n.Go("flaky", func(ctx context.Context) error { return nil })
+	n.cancel()
+	n.wg.Wait()
+	if starts, _ := c.counts(); starts != 2 {
+		t.Errorf("Expected no restart on clean exit, got %d starts", starts)
+	}
+}
+
+func TestSupervisorNever(t *testing.T) {
+	n := newSupervisedNmq()
+	c := &flakyComponent{}
+	n.RegisterComponent("flaky", c)
+	n.ReportFailure("flaky", errors.New("lost connection"))
+	waitStatus(t, n, "flaky", nmq.ComponentFailed)
+	if st := n.ListComponents(); st[0].LastError != "lost connection" || st[0].Policy != "never" {
+		t.Errorf("Unexpected supervision: %+v", st)
+	}
+	n.ReportFailure("missing", errors.New("ignored"))
+	if _, err := n.ComponentStatus("missing"); err == nil {
+		t.Error("Expected error for unknown component")
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	n := newSupervisedNmq()
+	SetRestartBackoff(time.Second, 5*time.Second, 0).apply(n)
+	for attempts, want := range map[int]time.Duration{1: time.Second, 2: 2 * time.Second, 3: 4 * time.Second, 4: 5 * time.Second, 10: 5 * time.Second} {
+		if got := n.backoff(attempts); got != want {
+			t.Errorf("backoff(%d) = %v, want %v", attempts, got, want)
+		}
+	}
+}
@@ -0,0 +1,219 @@
+package nmq
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"runtime"
+	"runtime/pprof"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/expfmt"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"go.uber.org/zap"
+)
+
+// redacted 脱敏后的配置值
+const redacted = "******"
+
+// sensitiveKeys 配置项名称包含这些片段时值会被脱敏
+var sensitiveKeys = []string{"password", "passwd", "secret", "token", "key", "credential", "auth"}
+
+// componentStatus 支持包中的组件状态
+type componentStatus struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+	Status  uint   `json:"status"`
+}
+
+// runtimeInfo 支持包中的运行时信息
+type runtimeInfo struct {
+	Time         time.Time `json:"time"`
+	Version      string    `json:"version"`
+	GoVersion    string    `json:"go_version"`
+	Features     []string  `json:"features"`
+	OS           string    `json:"os"`
+	Arch         string    `json:"arch"`
+	NumCPU       int       `json:"num_cpu"`
+	NumGoroutine int       `json:"num_goroutine"`
+	HeapAlloc    uint64    `json:"heap_alloc"`
+	HeapSys      uint64    `json:"heap_sys"`
+	NumGC        uint32    `json:"num_gc"`
+}
+
+// supportBundleCmd 创建 support-bundle 子命令
+func (n *Nmq) supportBundleCmd() *cobra.Command {
+	var output string
+	cmd := &cobra.Command{
+		Use:   "support-bundle",
+		Short: "Write a support bundle for bug reports",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if output == "" {
+				output = fmt.Sprintf("nmq-support-%s.tar.gz", time.Now().Format("20060102-150405"))
+			}
+			f, err := os.OpenFile(output, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o600)
+			if err != nil {
+				return err
+			}
+			if err = n.WriteSupportBundle(f); err != nil {
+				f.Close()
+				return err
+			}
+			if err = f.Close(); err != nil {
+				return err
+			}
+			n.logger.Info("Support bundle written", zap.String("file", output))
+			_, _ = fmt.Fprintln(cmd.OutOrStdout(), output)
+			return nil
+		},
+	}
+	cmd.Flags().StringVarP(&output, "output", "o", "", "output file, defaults to nmq-support-<time>.tar.gz")
+	return cmd
+}
+
+// WriteSupportBundle 将当前运行状态打包为 tar.gz 写入 w，用于附加到问题报告中
+//
+// 包含脱敏后的配置、组件状态、资源统计、指标快照、运行时信息和协程栈
+func (n *Nmq) WriteSupportBundle(w io.Writer) error {
+	gz := gzip.NewWriter(w)
+	tw := tar.NewWriter(gz)
+	now := time.Now()
+
+	files := []struct {
+		name  string
+		build func() ([]byte, error)
+	}{
+		{"runtime.json", func() ([]byte, error) { return marshalIndent(n.runtimeInfo(now)) }},
+		{"config.json", func() ([]byte, error) { return marshalIndent(n.redactedConfig()) }},
+		{"components.json", func() ([]byte, error) { return marshalIndent(n.componentStatuses()) }},
+		{"resources.json", func() ([]byte, error) { return marshalIndent(n.ResourceReport()) }},
+		{"metrics.txt", gatherMetrics},
+		{"goroutines.txt", func() ([]byte, error) {
+			var buf bytes.Buffer
+			err := pprof.Lookup("goroutine").WriteTo(&buf, 2)
+			return buf.Bytes(), err
+		}},
+	}
+	for _, file := range files {
+		data, err := file.build()
+		if err != nil {
+			// 单个文件失败不影响其他内容，错误写入对应的 .error 文件
+			file.name += ".error"
+			data = []byte(err.Error())
+		}
+		hdr := &tar.Header{Name: file.name, Mode: 0o600, Size: int64(len(data)), ModTime: now}
+		if err = tw.WriteHeader(hdr); err != nil {
+			return err
+		}
+		if _, err = tw.Write(data); err != nil {
+			return err
+		}
+	}
+	if err := tw.Close(); err != nil {
+		return err
+	}
+	return gz.Close()
+}
+
+// runtimeInfo 收集运行时信息
+func (n *Nmq) runtimeInfo(now time.Time) runtimeInfo {
+	var ms runtime.MemStats
+	runtime.ReadMemStats(&ms)
+	return runtimeInfo{
+		Time:         now,
+		Version:      n.GetVersion(),
+		GoVersion:    runtime.Version(),
+		Features:     Features(),
+		OS:           runtime.GOOS,
+		Arch:         runtime.GOARCH,
+		NumCPU:       runtime.NumCPU(),
+		NumGoroutine: runtime.NumGoroutine(),
+		HeapAlloc:    ms.HeapAlloc,
+		HeapSys:      ms.HeapSys,
+		NumGC:        ms.NumGC,
+	}
+}
+
+// redactedConfig 返回脱敏后的配置，包括命令行参数和配置文件中的所有配置项
+func (n *Nmq) redactedConfig() map[string]any {
+	cfg := map[string]any{
+		"config_file":      n.cfg.configFile,
+		"cert_path":        n.cfg.certPath,
+		"work_dir":         n.cfg.workDir,
+		"enable_gops":      n.cfg.enableGoPs,
+		"enable_pyroscope": n.cfg.enablePyroscope,
+		"pool_number":      n.cfg.poolNumber,
+	}
+	if settings := viper.AllSettings(); len(settings) > 0 {
+		cfg["settings"] = redact(settings)
+	}
+	return cfg
+}
+
+// redact 递归脱敏配置项
+func redact(settings map[string]any) map[string]any {
+	out := make(map[string]any, len(settings))
+	for k, v := range settings {
+		if sub, ok := v.(map[string]any); ok {
+			out[k] = redact(sub)
+			continue
+		}
+		if isSensitive(k) {
+			out[k] = redacted
+			continue
+		}
+		out[k] = v
+	}
+	return out
+}
+
+// isSensitive 判断配置项名称是否敏感
+func isSensitive(key string) bool {
+	key = strings.ToLower(key)
+	for _, s := range sensitiveKeys {
+		if strings.Contains(key, s) {
+			return true
+		}
+	}
+	return false
+}
+
+// componentStatuses 按名称排序返回已注册组件的状态
+func (n *Nmq) componentStatuses() []componentStatus {
+	n.mux.RLock()
+	list := make([]componentStatus, 0, len(n.components))
+	for name, c := range n.components {
+		list = append(list, componentStatus{Name: name, Version: c.GetVersion(), Status: uint(c.GetStatus())})
+	}
+	n.mux.RUnlock()
+	sort.Slice(list, func(i, j int) bool { return list[i].Name < list[j].Name })
+	return list
+}
+
+// gatherMetrics 以 prometheus 文本格式导出默认注册器中的指标
+func gatherMetrics() ([]byte, error) {
+	mfs, err := prometheus.DefaultGatherer.Gather()
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	for _, mf := range mfs {
+		if _, err = expfmt.MetricFamilyToText(&buf, mf); err != nil {
+			return nil, err
+		}
+	}
+	return buf.Bytes(), nil
+}
+
+// marshalIndent 格式化为便于阅读的 JSON
+func marshalIndent(v any) ([]byte, error) {
+	return json.MarshalIndent(v, "", "  ")
+}
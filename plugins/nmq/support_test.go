@@ -0,0 +1,69 @@
+package nmq
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"io"
+	"testing"
+
+	"github.com/spf13/viper"
+)
+
+func TestWriteSupportBundle(t *testing.T) {
+	n := newTestNmq(t)
+	n.cfg = DefaultConfig()
+	n.cfg.configFile = "nmq.yaml"
+	viper.Set("redis.password", "hunter2")
+	viper.Set("redis.addr", "127.0.0.1:6379")
+	t.Cleanup(viper.Reset)
+
+	var buf bytes.Buffer
+	if err := n.WriteSupportBundle(&buf); err != nil {
+		t.Fatalf("WriteSupportBundle failed: %v", err)
+	}
+
+	gz, err := gzip.NewReader(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	files := map[string][]byte{}
+	tr := tar.NewReader(gz)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		data, err := io.ReadAll(tr)
+		if err != nil {
+			t.Fatal(err)
+		}
+		files[hdr.Name] = data
+	}
+
+	for _, name := range []string{"runtime.json", "config.json", "components.json", "resources.json", "metrics.txt", "goroutines.txt"} {
+		if _, ok := files[name]; !ok {
+			t.Errorf("Expected %s in bundle, got %d files", name, len(files))
+		}
+	}
+	if bytes.Contains(files["config.json"], []byte("hunter2")) {
+		t.Error("Expected password to be redacted")
+	}
+	var cfg struct {
+		ConfigFile string                    `json:"config_file"`
+		Settings   map[string]map[string]any `json:"settings"`
+	}
+	if err = json.Unmarshal(files["config.json"], &cfg); err != nil {
+		t.Fatal(err)
+	}
+	if cfg.ConfigFile != "nmq.yaml" || cfg.Settings["redis"]["addr"] != "127.0.0.1:6379" || cfg.Settings["redis"]["password"] != redacted {
+		t.Errorf("Unexpected config %+v", cfg)
+	}
+	if !bytes.Contains(files["goroutines.txt"], []byte("TestWriteSupportBundle")) {
+		t.Error("Expected goroutine dump to include the test goroutine")
+	}
+}
@@ -0,0 +1,8 @@
+//go:build windows
+
+package nmq
+
+// watchUpgrade 当前平台不支持热升级
+func (n *Nmq) watchUpgrade() {
+	n.logger.Warn("Graceful upgrade is not supported on this platform")
+}
@@ -0,0 +1,47 @@
+//go:build !windows
+
+package nmq
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/andrewbytecoder/nmq/pkg/network/listener"
+	"go.uber.org/zap"
+)
+
+// watchUpgrade 收到 SIGUSR2 后启动新版本进程并移交监听套接字，新进程就绪后停止当前进程
+func (n *Nmq) watchUpgrade() {
+	ch := make(chan os.Signal, 1)
+	signal.Notify(ch, syscall.SIGUSR2)
+	go func() {
+		defer signal.Stop(ch)
+		for {
+			select {
+			case <-ch:
+				if n.upgrade() {
+					return
+				}
+			case <-n.ctx.Done():
+				return
+			}
+		}
+	}()
+}
+
+// upgrade 执行一次热升级，成功时取消全局 context 进入停止流程
+func (n *Nmq) upgrade() bool {
+	n.logger.Info("Upgrade requested, starting new process", zap.Duration("timeout", n.cfg.upgradeTimeout))
+	ctx, cancel := context.WithTimeout(n.ctx, n.cfg.upgradeTimeout)
+	defer cancel()
+	p, err := listener.Upgrade(ctx)
+	if err != nil {
+		n.logger.Error("Upgrade failed, keep serving", zap.Error(err))
+		return false
+	}
+	n.logger.Info("New process ready, draining", zap.Int("pid", p.Pid), zap.Duration("drain_timeout", n.cfg.drainTimeout))
+	n.cancel()
+	return true
+}
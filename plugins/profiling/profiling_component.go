@@ -0,0 +1,310 @@
+package profiling
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/pprof"
+	"runtime"
+	"sync"
+	"time"
+
+	"github.com/andrewbytecoder/nmq/interfaces"
+	"github.com/andrewbytecoder/nmq/interfaces/nmq"
+	"github.com/andrewbytecoder/nmq/pkg/check"
+	"github.com/andrewbytecoder/nmq/pkg/network/listener"
+	"github.com/andrewbytecoder/nmq/pkg/options"
+	"github.com/andrewbytecoder/nmq/pkg/recovery"
+	"go.uber.org/zap"
+)
+
+// 通过 Notify 控制剖析的事件，EventPyroscopeStart 的数据可以是新的服务地址
+const (
+	EventPprofStart     = "profiling.pprof.start"
+	EventPprofStop      = "profiling.pprof.stop"
+	EventPyroscopeStart = "profiling.pyroscope.start"
+	EventPyroscopeStop  = "profiling.pyroscope.stop"
+)
+
+var (
+	// ErrInvalidPyroscopeAddress pyroscope 服务地址无效
+	ErrInvalidPyroscopeAddress = errors.New("profiling: invalid pyroscope server address")
+	// ErrPyroscopeUnavailable 使用 nopyroscope 构建，不包含 pyroscope 客户端
+	ErrPyroscopeUnavailable = errors.New("profiling: pyroscope support not compiled in")
+)
+
+// defaultPprofAddr 没有配置监听地址时通过 EventPprofStart 开启 pprof 使用的地址
+const defaultPprofAddr = "127.0.0.1:6060"
+
+// PyroscopeConfig 持续剖析推送配置
+type PyroscopeConfig struct {
+	Enabled         bool              `mapstructure:"enabled"`                              // 启动时是否推送
+	ServerAddress   string            `mapstructure:"server_address"`                       // 服务地址，例如 http://pyroscope:4040
+	ApplicationName string            `mapstructure:"application_name" validate:"required"` // 应用名称
+	Tags            map[string]string `mapstructure:"tags"`                                 // 附加在所有剖析数据上的标签
+	UploadRate      time.Duration     `mapstructure:"upload_rate" validate:"min=1s"`        // 上传间隔
+}
+
+// Config 剖析组件配置
+type Config struct {
+	Addr                 string          `mapstructure:"addr"`                                    // pprof 监听地址，为空时启动时不开启，EventPprofStart 使用 127.0.0.1:6060
+	MutexProfileFraction int             `mapstructure:"mutex_profile_fraction" validate:"min=0"` // 互斥锁剖析采样率，0表示不采集
+	BlockProfileRate     int             `mapstructure:"block_profile_rate" validate:"min=0"`     // 阻塞剖析采样率，0表示不采集
+	ShutdownTimeout      time.Duration   `mapstructure:"shutdown_timeout" validate:"min=1ms"`     // 停止时等待请求处理完成的时间
+	Pyroscope            PyroscopeConfig `mapstructure:"pyroscope"`
+}
+
+// SetAddr 设置 pprof 监听地址
+func SetAddr(addr string) options.Option {
+	return func(c any) {
+		if c, ok := c.(*Config); ok {
+			c.Addr = addr
+		}
+	}
+}
+
+// SetPyroscope 开启 pyroscope 推送并设置服务地址
+func SetPyroscope(address string) options.Option {
+	return func(c any) {
+		if c, ok := c.(*Config); ok {
+			c.Pyroscope.Enabled = true
+			c.Pyroscope.ServerAddress = address
+		}
+	}
+}
+
+// NewConfig 创建剖析组件配置，默认不开启 pprof 和 pyroscope
+func NewConfig(opts ...options.Option) *Config {
+	c := &Config{
+		ShutdownTimeout: 5 * time.Second,
+		Pyroscope: PyroscopeConfig{
+			ApplicationName: "nmq",
+			UploadRate:      15 * time.Second,
+		},
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// Validate 校验开启推送时 pyroscope 服务地址有效
+func (c *Config) Validate() error {
+	if c.Pyroscope.Enabled && !check.IsValidPyroscopeAddress(c.Pyroscope.ServerAddress) {
+		return fmt.Errorf("%w: %q", ErrInvalidPyroscopeAddress, c.Pyroscope.ServerAddress)
+	}
+	return nil
+}
+
+// profiler 正在运行的持续剖析推送
+type profiler interface {
+	Stop() error
+}
+
+// Component 剖析组件，在 Addr 上提供 /debug/pprof/ 端点，并向 pyroscope 推送持续剖析数据
+//
+// 两者都可以在运行时通过 Notify 的 EventPprofStart、EventPprofStop、EventPyroscopeStart、EventPyroscopeStop 开关
+type Component struct {
+	nmq.ComponentBase
+	cfg *Config
+
+	mu       sync.Mutex
+	srv      *http.Server
+	addr     net.Addr // pprof 实际监听的地址
+	profiler profiler
+}
+
+// NewProfilingComponent 创建剖析组件
+func NewProfilingComponent(ctx nmq.NmqContext, opts ...options.Option) *Component {
+	return &Component{
+		ComponentBase: nmq.NewNamedComponentBase(ctx, interfaces.ProfilingComponentName),
+		cfg:           NewConfig(opts...),
+	}
+}
+
+// GetInterface 获取组件内部某个接口的实现
+func (pc *Component) GetInterface(uuid string) any {
+	return nil
+}
+
+// ConfigSection 返回配置，组件管理器将配置文件中的 profiling 配置段解码到其中
+func (pc *Component) ConfigSection() any {
+	return pc.cfg
+}
+
+// RequiredFeatures 开启 pyroscope 推送时依赖 pyroscope 功能
+func (pc *Component) RequiredFeatures() []string {
+	if pc.cfg.Pyroscope.Enabled {
+		return []string{nmq.FeaturePyroscope}
+	}
+	return nil
+}
+
+// Init 校验配置
+func (pc *Component) Init() error {
+	return pc.cfg.Validate()
+}
+
+// Start 按配置开启 pprof 端点和 pyroscope 推送
+func (pc *Component) Start() error {
+	if pc.cfg.MutexProfileFraction > 0 {
+		runtime.SetMutexProfileFraction(pc.cfg.MutexProfileFraction)
+	}
+	if pc.cfg.BlockProfileRate > 0 {
+		runtime.SetBlockProfileRate(pc.cfg.BlockProfileRate)
+	}
+	if pc.cfg.Addr != "" {
+		if err := pc.startPprof(); err != nil {
+			return err
+		}
+	}
+	if pc.cfg.Pyroscope.Enabled {
+		if err := pc.startPyroscope(""); err != nil {
+			_ = pc.stopPprof()
+			return err
+		}
+	}
+	return nil
+}
+
+// Stop 关闭 pprof 端点和 pyroscope 推送
+func (pc *Component) Stop() error {
+	return errors.Join(pc.stopPyroscope(), pc.stopPprof())
+}
+
+// Reset 重置组件
+func (pc *Component) Reset() error {
+	return nil
+}
+
+// GetName 获取组件名称
+func (pc *Component) GetName() string {
+	return interfaces.ProfilingComponentName
+}
+
+// GetVersion 获取组件版本号
+func (pc *Component) GetVersion() string {
+	return "1.0.0"
+}
+
+// Notify 接收系统广播事件，开关 pprof 端点和 pyroscope 推送
+func (pc *Component) Notify(event string, data any) {
+	var err error
+	switch event {
+	case EventPprofStart:
+		err = pc.startPprof()
+	case EventPprofStop:
+		err = pc.stopPprof()
+	case EventPyroscopeStart:
+		address, _ := data.(string)
+		err = pc.startPyroscope(address)
+	case EventPyroscopeStop:
+		err = pc.stopPyroscope()
+	default:
+		return
+	}
+	if err != nil {
+		pc.Log.Error("profiling event failed", zap.String("event", event), zap.Error(err))
+	}
+}
+
+// GetStatus 获取组件当前状态
+func (pc *Component) GetStatus() nmq.ComponentStatus {
+	return pc.ComponentBase.GetStatus()
+}
+
+// Addr 返回 pprof 端点实际监听的地址，未开启时返回 nil
+func (pc *Component) Addr() net.Addr {
+	pc.mu.Lock()
+	defer pc.mu.Unlock()
+	return pc.addr
+}
+
+// handler 返回 /debug/pprof/ 下的剖析端点
+func (pc *Component) handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/debug/pprof/", pprof.Index)
+	mux.HandleFunc("/debug/pprof/cmdline", pprof.Cmdline)
+	mux.HandleFunc("/debug/pprof/profile", pprof.Profile)
+	mux.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
+	mux.HandleFunc("/debug/pprof/trace", pprof.Trace)
+	return recovery.New(recovery.SetLogger(pc.Log)).Middleware("profiling.pprof", mux)
+}
+
+// startPprof 开启 pprof 端点，已经开启时不做任何事
+func (pc *Component) startPprof() error {
+	pc.mu.Lock()
+	defer pc.mu.Unlock()
+	if pc.srv != nil {
+		return nil
+	}
+	addr := pc.cfg.Addr
+	if addr == "" {
+		addr = defaultPprofAddr
+	}
+	ln, err := listener.Listen("tcp", addr, listener.NewConfig())
+	if err != nil {
+		pc.Log.Error("pprof listen failed", zap.String("addr", addr), zap.Error(err))
+		return err
+	}
+	srv := &http.Server{Handler: pc.handler(), ReadHeaderTimeout: 5 * time.Second}
+	go func() {
+		if err := srv.Serve(ln); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			pc.Log.Error("pprof server stopped", zap.Error(err))
+		}
+	}()
+	pc.srv, pc.addr = srv, ln.Addr()
+	pc.Log.Info("pprof started", zap.Stringer("addr", ln.Addr()))
+	return nil
+}
+
+// stopPprof 关闭 pprof 端点
+func (pc *Component) stopPprof() error {
+	pc.mu.Lock()
+	srv := pc.srv
+	pc.srv, pc.addr = nil, nil
+	pc.mu.Unlock()
+	if srv == nil {
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), pc.cfg.ShutdownTimeout)
+	defer cancel()
+	return srv.Shutdown(ctx)
+}
+
+// startPyroscope 开启 pyroscope 推送，address 非空时替换配置的服务地址，已经开启时不做任何事
+func (pc *Component) startPyroscope(address string) error {
+	pc.mu.Lock()
+	defer pc.mu.Unlock()
+	if pc.profiler != nil {
+		return nil
+	}
+	cfg := pc.cfg.Pyroscope
+	if address != "" {
+		cfg.ServerAddress = address
+	}
+	if !check.IsValidPyroscopeAddress(cfg.ServerAddress) {
+		return fmt.Errorf("%w: %q", ErrInvalidPyroscopeAddress, cfg.ServerAddress)
+	}
+	p, err := startPyroscope(cfg, pc.Log)
+	if err != nil {
+		return err
+	}
+	pc.profiler = p
+	pc.Log.Info("pyroscope started", zap.String("server", cfg.ServerAddress))
+	return nil
+}
+
+// stopPyroscope 停止 pyroscope 推送
+func (pc *Component) stopPyroscope() error {
+	pc.mu.Lock()
+	p := pc.profiler
+	pc.profiler = nil
+	pc.mu.Unlock()
+	if p == nil {
+		return nil
+	}
+	return p.Stop()
+}
@@ -0,0 +1,108 @@
+package profiling
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/andrewbytecoder/nmq/interfaces/nmq"
+	"github.com/andrewbytecoder/nmq/pkg/convert"
+	"github.com/andrewbytecoder/nmq/pkg/options"
+	"go.uber.org/zap"
+)
+
+func newTestComponent(opts ...options.Option) *Component {
+	pc := &Component{cfg: NewConfig(opts...)}
+	pc.Log = zap.NewNop()
+	return pc
+}
+
+func TestConfig(t *testing.T) {
+	cfg := NewConfig()
+	err := convert.DecodeSection(map[string]any{
+		"addr": "127.0.0.1:0",
+		"pyroscope": map[string]any{
+			"enabled":        true,
+			"server_address": "http://pyroscope:4040",
+			"upload_rate":    "30s",
+			"tags":           map[string]any{"region": "eu"},
+		},
+	}, cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Addr != "127.0.0.1:0" || !cfg.Pyroscope.Enabled || cfg.Pyroscope.Tags["region"] != "eu" || cfg.Pyroscope.ApplicationName != "nmq" {
+		t.Errorf("Unexpected config: %+v", cfg)
+	}
+	if err = cfg.Validate(); err != nil {
+		t.Errorf("Expected valid config, got %v", err)
+	}
+
+	cfg.Pyroscope.ServerAddress = "https://pyroscope:4040/path"
+	if err = cfg.Validate(); !errors.Is(err, ErrInvalidPyroscopeAddress) {
+		t.Errorf("Expected ErrInvalidPyroscopeAddress, got %v", err)
+	}
+}
+
+func TestRequiredFeatures(t *testing.T) {
+	if got := newTestComponent().RequiredFeatures(); len(got) != 0 {
+		t.Errorf("Expected no features, got %v", got)
+	}
+	pc := newTestComponent(SetPyroscope("http://pyroscope:4040"))
+	if got := pc.RequiredFeatures(); len(got) != 1 || got[0] != nmq.FeaturePyroscope {
+		t.Errorf("Expected pyroscope feature, got %v", got)
+	}
+	if err := newTestComponent(SetPyroscope("pyroscope:4040")).Init(); !errors.Is(err, ErrInvalidPyroscopeAddress) {
+		t.Errorf("Expected Init to reject invalid address, got %v", err)
+	}
+}
+
+func TestHandler(t *testing.T) {
+	rec := httptest.NewRecorder()
+	newTestComponent().handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/debug/pprof/goroutine?debug=1", nil))
+	if rec.Code != http.StatusOK {
+		t.Errorf("Expected 200, got %d", rec.Code)
+	}
+}
+
+func TestNotify(t *testing.T) {
+	pc := newTestComponent(SetAddr("127.0.0.1:0"))
+	if err := pc.Start(); err != nil {
+		t.Fatal(err)
+	}
+	defer pc.Stop()
+
+	addr := pc.Addr()
+	if addr == nil {
+		t.Fatal("Expected pprof to be listening")
+	}
+	resp, err := http.Get("http://" + addr.String() + "/debug/pprof/cmdline")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, _ = io.Copy(io.Discard, resp.Body)
+	_ = resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("Expected 200, got %d", resp.StatusCode)
+	}
+
+	pc.Notify(EventPprofStop, nil)
+	if pc.Addr() != nil {
+		t.Error("Expected pprof to be stopped")
+	}
+	pc.Notify(EventPprofStart, nil)
+	if pc.Addr() == nil {
+		t.Error("Expected pprof to be restarted")
+	}
+
+	// 无效的服务地址不会开启推送
+	pc.Notify(EventPyroscopeStart, "ftp://pyroscope")
+	pc.mu.Lock()
+	started := pc.profiler != nil
+	pc.mu.Unlock()
+	if started {
+		t.Error("Expected pyroscope not to start with invalid address")
+	}
+}
@@ -0,0 +1,26 @@
+//go:build !nopyroscope
+
+package profiling
+
+import (
+	"github.com/grafana/pyroscope-go"
+	"go.uber.org/zap"
+)
+
+// startPyroscope 启动 pyroscope 客户端，除默认类型外还推送协程、互斥锁和阻塞剖析
+func startPyroscope(cfg PyroscopeConfig, log *zap.Logger) (profiler, error) {
+	return pyroscope.Start(pyroscope.Config{
+		ApplicationName: cfg.ApplicationName,
+		ServerAddress:   cfg.ServerAddress,
+		Tags:            cfg.Tags,
+		UploadRate:      cfg.UploadRate,
+		Logger:          log.Sugar(),
+		ProfileTypes: append(append([]pyroscope.ProfileType(nil), pyroscope.DefaultProfileTypes...),
+			pyroscope.ProfileGoroutines,
+			pyroscope.ProfileMutexCount,
+			pyroscope.ProfileMutexDuration,
+			pyroscope.ProfileBlockCount,
+			pyroscope.ProfileBlockDuration,
+		),
+	})
+}
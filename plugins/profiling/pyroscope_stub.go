@@ -0,0 +1,10 @@
+//go:build nopyroscope
+
+package profiling
+
+import "go.uber.org/zap"
+
+// startPyroscope 使用 nopyroscope 构建时不包含 pyroscope 客户端
+func startPyroscope(cfg PyroscopeConfig, log *zap.Logger) (profiler, error) {
+	return nil, ErrPyroscopeUnavailable
+}